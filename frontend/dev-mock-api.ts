@@ -262,8 +262,8 @@ export function mockApiPlugin(): Plugin {
 
 				res.setHeader('Content-Type', 'application/json');
 
-				// GET /api/rollouts
-				if (req.url === '/api/rollouts') {
+				// GET /api/v1/rollouts
+				if (req.url === '/api/v1/rollouts') {
 					return res.end(
 						JSON.stringify({
 							rollouts: { items: [mockRolloutResponse.rollout] }
@@ -271,18 +271,18 @@ export function mockApiPlugin(): Plugin {
 					);
 				}
 
-				// GET /api/rollouts/:namespace/:name
-				if (req.url === `/api/rollouts/${NAMESPACE}/${ROLLOUT_NAME}`) {
+				// GET /api/v1/rollouts/:namespace/:name
+				if (req.url === `/api/v1/rollouts/${NAMESPACE}/${ROLLOUT_NAME}`) {
 					return res.end(JSON.stringify(mockRolloutResponse));
 				}
 
-				// GET /api/rollouts/:namespace/:name/permissions/all
-				if (req.url === `/api/rollouts/${NAMESPACE}/${ROLLOUT_NAME}/permissions/all`) {
+				// GET /api/v1/rollouts/:namespace/:name/permissions/all
+				if (req.url === `/api/v1/rollouts/${NAMESPACE}/${ROLLOUT_NAME}/permissions/all`) {
 					return res.end(JSON.stringify(mockPermissions));
 				}
 
-				// GET /api/rollouts/:namespace/:name/rollout-tests
-				if (req.url === `/api/rollouts/${NAMESPACE}/${ROLLOUT_NAME}/rollout-tests`) {
+				// GET /api/v1/rollouts/:namespace/:name/rollout-tests
+				if (req.url === `/api/v1/rollouts/${NAMESPACE}/${ROLLOUT_NAME}/rollout-tests`) {
 					const rolloutTestObject = mockManagedResources.managedResources.find(
 						(r) => r.groupVersionKind === 'rollout.kuberik.com/v1alpha1/RolloutTest'
 					);
@@ -294,13 +294,13 @@ export function mockApiPlugin(): Plugin {
 					);
 				}
 
-				// GET /api/rollouts/:namespace/:name/health-checks
-				if (req.url === `/api/rollouts/${NAMESPACE}/${ROLLOUT_NAME}/health-checks`) {
+				// GET /api/v1/rollouts/:namespace/:name/health-checks
+				if (req.url === `/api/v1/rollouts/${NAMESPACE}/${ROLLOUT_NAME}/health-checks`) {
 					return res.end(JSON.stringify(mockHealthChecks));
 				}
 
-				// GET /api/rollouts/:namespace/:name/pods/logs (SSE stream)
-				if (req.url?.startsWith(`/api/rollouts/${NAMESPACE}/${ROLLOUT_NAME}/pods/logs`)) {
+				// GET /api/v1/rollouts/:namespace/:name/pods/logs (SSE stream)
+				if (req.url?.startsWith(`/api/v1/rollouts/${NAMESPACE}/${ROLLOUT_NAME}/pods/logs`)) {
 					res.setHeader('Content-Type', 'text/event-stream');
 					res.setHeader('Cache-Control', 'no-cache');
 					res.setHeader('Connection', 'keep-alive');
@@ -401,10 +401,10 @@ export function mockApiPlugin(): Plugin {
 					return;
 				}
 
-				// GET /api/kustomizations/:namespace/:name/managed-resources
+				// GET /api/v1/kustomizations/:namespace/:name/managed-resources
 				if (
 					req.url ===
-					`/api/kustomizations/${KUSTOMIZATION_NAMESPACE}/${KUSTOMIZATION_NAME}/managed-resources`
+					`/api/v1/kustomizations/${KUSTOMIZATION_NAMESPACE}/${KUSTOMIZATION_NAME}/managed-resources`
 				) {
 					return res.end(JSON.stringify(mockManagedResources));
 				}
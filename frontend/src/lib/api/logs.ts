@@ -12,6 +12,8 @@ export interface LogLine {
 	line: string;
 	timestamp?: number;
 	formattedTimestamp?: string; // Pre-formatted timestamp from backend
+	truncated?: boolean; // Line exceeded the backend's maximum log line length
+	originalLength?: number; // Size of the truncated line in bytes
 }
 
 export interface PodInfo {
@@ -39,7 +41,7 @@ export function createLogsStreamUrl(
 	if (since) {
 		params.set('since', since.toString());
 	}
-	return `/api/rollouts/${namespace}/${name}/pods/logs?${params.toString()}`;
+	return `/api/v1/rollouts/${namespace}/${name}/pods/logs?${params.toString()}`;
 }
 
 // Create an async iterable that yields log lines from EventSource
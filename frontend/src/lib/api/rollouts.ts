@@ -34,7 +34,7 @@ export const rolloutQueryKey = (namespace: string, name: string) =>
 export const rolloutsListQueryKey = ['rollouts', 'all'] as const;
 
 export async function fetchRollout(namespace: string, name: string): Promise<RolloutResponse> {
-    const res = await fetch(`/api/rollouts/${namespace}/${name}`);
+    const res = await fetch(`/api/v1/rollouts/${namespace}/${name}`);
     if (!res.ok) {
         if (res.status === 404) {
             return { rollout: null };
@@ -45,7 +45,7 @@ export async function fetchRollout(namespace: string, name: string): Promise<Rol
 }
 
 export async function fetchRolloutsList(): Promise<RolloutsListResponse> {
-    const res = await fetch('/api/rollouts');
+    const res = await fetch('/api/v1/rollouts');
     if (!res.ok) {
         throw new Error('Failed to fetch rollouts');
     }
@@ -53,7 +53,7 @@ export async function fetchRolloutsList(): Promise<RolloutsListResponse> {
 }
 
 export async function fetchRolloutsInNamespace(namespace: string): Promise<RolloutsListResponse> {
-    const res = await fetch(`/api/rollouts?namespace=${encodeURIComponent(namespace)}`);
+    const res = await fetch(`/api/v1/rollouts?namespace=${encodeURIComponent(namespace)}`);
     if (!res.ok) {
         throw new Error('Failed to fetch rollouts');
     }
@@ -122,7 +122,7 @@ export async function fetchRolloutPermissions(
     namespace: string,
     name: string
 ): Promise<PermissionsResponse> {
-    const res = await fetch(`/api/rollouts/${namespace}/${name}/permissions/all`);
+    const res = await fetch(`/api/v1/rollouts/${namespace}/${name}/permissions/all`);
     if (!res.ok) {
         throw new Error('Failed to load permissions');
     }
@@ -157,7 +157,7 @@ export async function fetchRolloutTests(
     namespace: string,
     name: string
 ): Promise<RolloutTestsResponse> {
-    const res = await fetch(`/api/rollouts/${namespace}/${name}/rollout-tests`);
+    const res = await fetch(`/api/v1/rollouts/${namespace}/${name}/rollout-tests`);
     if (!res.ok) {
         if (res.status === 404) {
             return { rolloutTests: { items: [] } };
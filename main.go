@@ -4,12 +4,18 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"bytes"
@@ -23,13 +29,40 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/gorilla/websocket"
+	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
+	openkruisev1alpha1 "github.com/kuberik/openkruise-controller/api/v1alpha1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/alertmanager"
+	"github.com/kuberik/rollout-dashboard/pkg/anomaly"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
 	"github.com/kuberik/rollout-dashboard/pkg/auth"
+	"github.com/kuberik/rollout-dashboard/pkg/chaos"
+	"github.com/kuberik/rollout-dashboard/pkg/columns"
+	"github.com/kuberik/rollout-dashboard/pkg/cors"
+	"github.com/kuberik/rollout-dashboard/pkg/freeze"
+	"github.com/kuberik/rollout-dashboard/pkg/gitops"
+	"github.com/kuberik/rollout-dashboard/pkg/gitprovider"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/links"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
 	"github.com/kuberik/rollout-dashboard/pkg/logs"
+	"github.com/kuberik/rollout-dashboard/pkg/notify"
 	"github.com/kuberik/rollout-dashboard/pkg/oci"
-	openkruisev1alpha1 "github.com/kuberik/openkruise-controller/api/v1alpha1"
-	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/oncall"
+	"github.com/kuberik/rollout-dashboard/pkg/prometheus"
+	"github.com/kuberik/rollout-dashboard/pkg/ratelimit"
+	"github.com/kuberik/rollout-dashboard/pkg/registryhook"
+	"github.com/kuberik/rollout-dashboard/pkg/releasenotes"
+	"github.com/kuberik/rollout-dashboard/pkg/requestid"
+	"github.com/kuberik/rollout-dashboard/pkg/server"
+	"github.com/kuberik/rollout-dashboard/pkg/share"
+	"github.com/kuberik/rollout-dashboard/pkg/streamhealth"
+	"github.com/kuberik/rollout-dashboard/pkg/terminal"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // dockerConfigKeychain implements authn.Keychain interface for Docker config JSON
@@ -52,96 +85,519 @@ func (k *dockerConfigKeychain) Resolve(resource authn.Resource) (authn.Authentic
 }
 
 func main() {
-	r := gin.Default()
+	logging.Setup()
+
+	serverConfig, err := server.ParseFlags(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		slog.Error("Invalid server configuration", "error", err)
+		os.Exit(2)
+	}
+
+	if serverConfig.SelfTest {
+		os.Exit(runSelfTest(os.Stdout))
+	}
+
+	// Verify tokens against the OIDC issuer when one is configured
+	verifierConfig, err := auth.VerifierConfigFromEnv()
+	if err != nil {
+		slog.Error("Invalid OIDC config", "error", err)
+		os.Exit(1)
+	}
+	var verifier *auth.Verifier
+	if verifierConfig != nil {
+		verifier = auth.NewVerifier(*verifierConfig)
+	}
+
+	// Act as the user with the service account instead of passing their token to the API server
+	switch mode := os.Getenv("KUBERNETES_AUTH_MODE"); mode {
+	case "", "token":
+	case "impersonate":
+		if verifier == nil {
+			slog.Error("Impersonation requires token verification, set OIDC_ISSUER_URL and OIDC_CLIENT_ID")
+			os.Exit(1)
+		}
+		kubernetes.UseImpersonation(true)
+	default:
+		slog.Error("Invalid KUBERNETES_AUTH_MODE, expected token or impersonate", "mode", mode)
+		os.Exit(1)
+	}
+
+	// Share links are signed with SHARE_LINK_SECRET; sharing is disabled without it
+	shareConfig, err := share.ConfigFromEnv()
+	if err != nil {
+		slog.Error("Invalid share link config", "error", err)
+		os.Exit(1)
+	}
+	var shares *share.Signer
+	if shareConfig != nil {
+		shares = share.NewSigner(*shareConfig)
+	}
+
+	// Inject latency and errors for frontend development when CHAOS_CONFIG is set
+	chaosConfig, err := chaos.ConfigFromEnv()
+	if err != nil {
+		slog.Error("Invalid chaos config", "error", err)
+		os.Exit(1)
+	}
+	var injector *chaos.Injector
+	if chaosConfig != nil {
+		slog.Warn("Injecting faults into API requests, do not use in production", "rules", len(chaosConfig.Rules))
+		injector = chaos.New(*chaosConfig)
+	}
+
+	// Serve rollout details from memory until a watch reports a change to one of their objects
+	detailCacheTTL := defaultDetailCacheTTL
+	if parsed, err := time.ParseDuration(os.Getenv("ROLLOUT_DETAIL_CACHE_TTL")); err == nil {
+		detailCacheTTL = parsed
+	}
+	var details *detailCache
+	if detailCacheTTL > 0 {
+		details = newDetailCache(detailCacheTTL)
+	}
+
+	// Notifications are routed by NOTIFY_CONFIG, which can be reloaded after webhooks are rotated,
+	// or by the NOTIFY_CONFIGMAP ConfigMap, which is re-read before every check
+	notifyConfig, err := notify.ConfigFromEnv()
+	if err != nil {
+		slog.Error("Invalid notification config", "error", err)
+		os.Exit(1)
+	}
+	notifier := notify.New(notifyConfig)
+	var loadNotifyConfig, watchNotifyConfig func() (notify.Config, error)
+	if os.Getenv("NOTIFY_CONFIG") != "" {
+		loadNotifyConfig = notify.ConfigFromEnv
+	} else if ref := os.Getenv("NOTIFY_CONFIGMAP"); ref != "" {
+		if loadNotifyConfig, err = notifyConfigMapLoader(ref); err != nil {
+			slog.Error("Invalid notification config", "error", err)
+			os.Exit(1)
+		}
+		watchNotifyConfig = loadNotifyConfig
+	}
+	metrics := prometheus.FromEnv()
+	alerts := alertmanager.FromEnv()
+	admin := newIntegrations(notifier, loadNotifyConfig, anomaly.ConfigFromEnv().WebhookURL, verifier, metrics, alerts)
+
+	// Teams named by rollout.kuberik.com/team are resolved to their on-call through ONCALL_CONFIG
+	var owners *oncall.Directory
+	if os.Getenv("ONCALL_CONFIG") != "" {
+		onCallConfig, err := oncall.ConfigFromEnv()
+		if err != nil {
+			slog.Error("Invalid on-call config", "error", err)
+			os.Exit(1)
+		}
+		owners = oncall.New(onCallConfig)
+	}
+
+	// Pushes announced by registries and CI are verified with REGISTRY_WEBHOOK_SECRET
+	registryHook, err := registryhook.FromEnv()
+	if err != nil {
+		slog.Error("Invalid registry webhook config", "error", err)
+		os.Exit(1)
+	}
+
+	// Mutating requests are rejected during the deployment freeze windows of FREEZE_CONFIG
+	var freezes *freeze.Policy
+	if os.Getenv("FREEZE_CONFIG") != "" {
+		freezeConfig, err := freeze.ConfigFromEnv()
+		if err != nil {
+			slog.Error("Invalid freeze config", "error", err)
+			os.Exit(1)
+		}
+		freezes = freeze.New(freezeConfig)
+	}
+
+	// Quick links of LINKS_CONFIG are added to the details of every rollout
+	var quickLinks *links.Templates
+	if os.Getenv("LINKS_CONFIG") != "" {
+		linksConfig, err := links.ConfigFromEnv()
+		if err != nil {
+			slog.Error("Invalid links config", "error", err)
+			os.Exit(1)
+		}
+		quickLinks = links.New(linksConfig)
+	}
+
+	// Release commits are resolved through the GitHub and GitLab instances of GITHUB_* and GITLAB_*
+	commits, err := gitprovider.FromEnv()
+	if err != nil {
+		slog.Error("Invalid git provider config", "error", err)
+		os.Exit(1)
+	}
+
+	// Version changes of rollouts listed in GITOPS_CONFIG are proposed as pull requests
+	gitopsConfig, err := gitops.ConfigFromEnv()
+	if err != nil {
+		slog.Error("Invalid gitops config", "error", err)
+		os.Exit(1)
+	}
+	if len(gitopsConfig.WriteBack) > 0 && commits == nil {
+		slog.Error("Invalid gitops config", "error", "write-back needs GITHUB_TOKEN_FILE or GITLAB_TOKEN_FILE")
+		os.Exit(1)
+	}
+	writeBack := gitops.New(gitopsConfig, commits)
+
+	// Extra columns of COLUMNS_CONFIG are projected into the rollout lists
+	var fleetColumns *columns.Projector
+	if os.Getenv("COLUMNS_CONFIG") != "" {
+		columnsConfig, err := columns.ConfigFromEnv()
+		if err != nil {
+			slog.Error("Invalid columns config", "error", err)
+			os.Exit(1)
+		}
+		fleetColumns = columns.New(columnsConfig)
+	}
+
+	// Cross-origin access is configured with CORS_* variables, see newRouter
+	if _, err := cors.ConfigFromEnv(); err != nil {
+		slog.Error("Invalid CORS config", "error", err)
+		os.Exit(1)
+	}
+
+	r := newRouter(verifier, shares, injector, details, admin, owners, metrics, alerts, registryHook, freezes, quickLinks, commits, writeBack, fleetColumns)
+	// Behind an L4 load balancer the PROXY protocol header carries the client address, so
+	// X-Forwarded-For headers can only come from clients and must not be trusted
+	if serverConfig.ProxyProtocol {
+		if err := r.SetTrustedProxies(nil); err != nil {
+			slog.Error("Failed to configure trusted proxies", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// Start server and shut down gracefully on SIGTERM/SIGINT
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	if details != nil {
+		go details.watch(ctx)
+	}
+
+	// Keep rollouts that follow a channel pinned to the channel's current release
+	channelTrackingInterval := defaultChannelTrackingInterval
+	if parsed, err := time.ParseDuration(os.Getenv("CHANNEL_TRACKING_INTERVAL")); err == nil {
+		channelTrackingInterval = parsed
+	}
+	if channelTrackingInterval > 0 {
+		go trackChannels(ctx, channelTrackingInterval)
+	}
+
+	// Remove override annotations once their version has been deployed and baked
+	annotationCleanupInterval := defaultAnnotationCleanupInterval
+	if parsed, err := time.ParseDuration(os.Getenv("ANNOTATION_CLEANUP_INTERVAL")); err == nil {
+		annotationCleanupInterval = parsed
+	}
+	annotationGracePeriod := defaultAnnotationGracePeriod
+	if parsed, err := time.ParseDuration(os.Getenv("ANNOTATION_GRACE_PERIOD")); err == nil && parsed >= 0 {
+		annotationGracePeriod = parsed
+	}
+	if annotationCleanupInterval > 0 {
+		go cleanupAnnotations(ctx, annotationCleanupInterval, annotationGracePeriod)
+	}
+
+	// Warm the manifest cache with the newest releases whenever an ImageRepository is scanned
+	prefetchInterval := defaultPrefetchInterval
+	if parsed, err := time.ParseDuration(os.Getenv("PREFETCH_INTERVAL")); err == nil {
+		prefetchInterval = parsed
+	}
+	if prefetchTags := prefetchTagsFromEnv(); prefetchTags > 0 && prefetchInterval > 0 {
+		go newVersionWarmer(prefetchTags).run(ctx, prefetchInterval)
+	}
+
+	// Apply version changes scheduled for later once they are due
+	schedulerInterval := defaultSchedulerInterval
+	if parsed, err := time.ParseDuration(os.Getenv("SCHEDULER_INTERVAL")); err == nil {
+		schedulerInterval = parsed
+	}
+	if schedulerInterval > 0 {
+		go runScheduler(ctx, schedulerInterval, freezes)
+	}
+
+	// Record the results of RolloutTests before their Jobs are deleted by the next canary
+	testHistoryInterval := defaultTestHistoryInterval
+	if parsed, err := time.ParseDuration(os.Getenv("TEST_HISTORY_INTERVAL")); err == nil {
+		testHistoryInterval = parsed
+	}
+	if testHistoryInterval > 0 {
+		go recordTestHistory(ctx, testHistoryInterval)
+	}
+
+	// Route notifications about rollout lifecycle events to the configured webhooks. Routes can be
+	// reloaded, so watching starts even without routes.
+	notifyInterval := defaultNotifyInterval
+	if parsed, err := time.ParseDuration(os.Getenv("NOTIFY_INTERVAL")); err == nil {
+		notifyInterval = parsed
+	}
+	if loadNotifyConfig != nil && notifyInterval > 0 {
+		go newRolloutWatcher(notifier, watchNotifyConfig).run(ctx, notifyInterval)
+	}
+
+	if err := server.Run(ctx, serverConfig, r); err != nil {
+		slog.Error("Server error", "error", err)
+		os.Exit(1)
+	}
+}
+
+// sharedRoutes are the read-only routes a share link grants access to, relative to the API version
+var sharedRoutes = []string{
+	"/rollouts/:namespace/:name",
+	"/rollouts/:namespace/:name/pods/logs",
+	"/rollouts/:namespace/:name/pods/logs/download",
+}
+
+// newRouter builds the HTTP handler: middleware, the versioned API routes and the frontend.
+// Tokens are verified with verifier and share links with shares, and faults are injected with
+// injector, and rollout details are cached in details, unless they are nil. The admin API manages
+// the integrations in admin. Changes to rollouts are rejected during the windows of freezes, unless
+// it is nil.
+func newRouter(verifier *auth.Verifier, shares *share.Signer, injector *chaos.Injector, details *detailCache, admin *integrations, owners *oncall.Directory, metrics *prometheus.Client, alerts *alertmanager.Client, registryHook *registryhook.Verifier, freezes *freeze.Policy, quickLinks *links.Templates, commits *gitprovider.Client, writeBack *gitops.WriteBack, fleetColumns *columns.Projector) *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Recovery())
+
+	// Allow cross-origin API access for separately hosted frontends (e.g. Backstage plugins)
+	// An invalid policy, which main refuses to start with, leaves the API same-origin only
+	corsConfig, err := cors.ConfigFromEnv()
+	if err != nil {
+		slog.Error("Invalid CORS config, disabling cross-origin access", "error", err)
+	}
+	r.Use(cors.Middleware(corsConfig))
+
+	// Assign an X-Request-ID to every request for log correlation and error responses
+	r.Use(requestid.Middleware())
 
 	// Apply token extraction middleware to all routes
 	r.Use(auth.ExtractTokenMiddleware())
 
-	// API routes under /api prefix
-	api := r.Group("/api")
-	{
-		api.GET("/health", func(c *gin.Context) {
-			c.JSON(http.StatusOK, gin.H{
-				"status": "ok",
-			})
+	requestUser := func(c *gin.Context) string {
+		return auth.UsernameFromToken(auth.GetTokenFromContext(c))
+	}
+
+	// Attach a request-scoped structured logger and log completed requests
+	r.Use(logging.Middleware(requestUser))
+
+	// Development only: delay and fail requests so the frontend's loading and error states can be
+	// tested. Registered after logging so injected failures show up in the request log.
+	if injector != nil {
+		r.Use(injector.Middleware())
+	}
+
+	// Audit and alert on unusual spikes of mutating requests, e.g. from a compromised token.
+	// Registered before the rate limiter so rejected bursts are counted too. Requests are recorded
+	// once handled, so users are identified by their verified identity rather than token claims.
+	r.Use(anomaly.New(anomaly.ConfigFromEnv(), auth.ClientKey).Middleware())
+
+	// Per-user (or per-IP) limits on mutating requests and concurrent streams. Clients are keyed by
+	// their verified identity, so the limiter runs on API routes after token verification.
+	limiter := ratelimit.New(ratelimit.ConfigFromEnv(), auth.ClientKey)
+	// Streams whose clients stopped reading or keep dropping messages are ended with a reconnect event
+	streams := streamhealth.New(streamhealth.ConfigFromEnv())
+	// Issues in release notes link to the JIRA instance at JIRA_URL
+	notes := releasenotes.FromEnv()
+
+	// Log lines longer than this are truncated before they are streamed
+	maxLogLineLength := logs.MaxLineLengthFromEnv()
+
+	// OpenAPI document generated from the typed request/response models
+	openAPIDocument := api.NewDocument("Rollout Dashboard API", "1.0.0", apiOperations)
+
+	// Health check stays unversioned so probes don't depend on the API version
+	r.GET("/api/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, api.HealthResponse{Status: "ok"})
+	})
+
+	// Registries and CI announce pushed tags here. They have no user token, so the webhook is
+	// outside the token-verified API and authenticated by an HMAC signature of the body instead.
+	r.POST("/api/webhooks/registry", limiter.Mutations(), func(c *gin.Context) {
+		receiveRegistryWebhook(c, registryHook, freezes)
+	})
+
+	// Reject invalid and expired tokens on API routes before a Kubernetes client is built with them.
+	// The frontend is still served, so it can send the user to sign in again.
+	// Requests with a valid share link are served with the service account instead, so a
+	// stakeholder's own (possibly expired) token is not verified on them.
+	apiMiddleware := []gin.HandlerFunc{shares.Middleware(sharedRoutes, func(c *gin.Context, err error) {
+		logging.FromContext(c).Warn("Rejected share link", "error", err)
+		if errors.Is(err, share.ErrWrongRollout) {
+			api.RespondError(c, http.StatusForbidden, api.CodeForbidden, "Share link not valid for this resource", err)
+			return
+		}
+		api.RespondError(c, http.StatusUnauthorized, api.CodeUnauthorized, "Invalid share link", err)
+	})}
+	if verifier != nil {
+		verify := auth.VerifyTokenMiddleware(verifier, func(c *gin.Context, err error) {
+			if errors.Is(err, auth.ErrInvalidToken) {
+				logging.FromContext(c).Warn("Rejected token", "error", err)
+				api.RespondError(c, http.StatusUnauthorized, api.CodeUnauthorized, "Invalid token", err)
+				return
+			}
+			logging.FromContext(c).Error("Failed to verify token", "error", err)
+			api.RespondError(c, http.StatusServiceUnavailable, api.CodeInternal, "Failed to verify token", err)
+		})
+		apiMiddleware = append(apiMiddleware, func(c *gin.Context) {
+			if share.FromContext(c) != nil {
+				c.Next()
+				return
+			}
+			verify(c)
 		})
+	}
+	apiMiddleware = append(apiMiddleware, limiter.Mutations())
+	// Requests without a user token are served with the service account, so in the public
+	// read-only mode they are limited to the namespaces that may be shown to anyone
+	if namespaces := anonymousNamespacesFromEnv(); namespaces != nil {
+		apiMiddleware = append(apiMiddleware, restrictAnonymous(namespaces))
+	}
+
+	// API routes are versioned under /api/<version>. A future v2 can inherit v1 and only
+	// redefine the endpoints whose response shape changes, while v1 keeps being served.
+	versions := api.NewVersionedRouter(r.Group("/api", apiMiddleware...))
+
+	// Changes to what a rollout deploys and how it progresses wait for freeze windows to end
+	unfrozen := requireUnfrozen(freezes, requestUser)
+	v1 := versions.Version("v1", nil)
+	{
+		v1.GET("/openapi.json", openAPIDocument.Handler())
 
-		api.GET("/rollouts", func(c *gin.Context) {
+		// listRollouts lists rollouts as trimmed summaries, or as raw resources with their
+		// associated Flux resources
+		listRollouts := func(c *gin.Context, summary bool) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
 			}
 
+			fields, ok := api.RequestFields(c)
+			if !ok {
+				return
+			}
 			namespace := c.DefaultQuery("namespace", "all")
 
-			// Get Rollouts
-			var rollouts interface{}
-			var err error
-			if namespace == "all" || namespace == "*" || namespace == "" {
-				rollouts, err = k8sClient.GetRolloutsAllNamespaces(context.Background())
-			} else {
-				rollouts, err = k8sClient.GetRollouts(context.Background(), namespace)
+			// The summary view returns trimmed DTOs and skips the associated Flux resources, unless
+			// the configured columns read them
+			kinds := relatedKinds{imagePolicies: true, imageRepositories: true, kustomizations: true, ociRepositories: true}
+			if summary {
+				kinds = relatedKinds{
+					imagePolicies:     fleetColumns.Reads(columns.SourceImagePolicy) || fleetColumns.Reads(columns.SourceImageRepository),
+					imageRepositories: fleetColumns.Reads(columns.SourceImageRepository),
+				}
 			}
-			if err != nil {
-				log.Printf("Error fetching rollouts: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to fetch rollouts",
-					"details": err.Error(),
-				})
+			rollouts, related, skippedNamespaces, ok := listRequestRolloutsWithRelated(c, k8sClient, namespace, kinds)
+			if !ok {
 				return
 			}
+			imagePolicies, imageRepositories := related.imagePolicies, related.imageRepositories
 
-			// Get associated Flux resources
-			var imagePolicies interface{}
-			if namespace == "all" || namespace == "*" || namespace == "" {
-				imagePolicies, err = k8sClient.GetImagePoliciesAllNamespaces(context.Background())
-			} else {
-				imagePolicies, err = k8sClient.GetImagePolicies(context.Background(), namespace)
-			}
-			if err != nil {
-				log.Printf("Error fetching image policies: %v", err)
+			if summary {
+				etag := api.NewETag(c)
+				etag.AddObjects(rollouts, imagePolicies, imageRepositories)
+				etag.AddJSON(skippedNamespaces)
+				if api.NotModified(c, etag.String()) {
+					return
+				}
+				response := api.RolloutSummaryListResponse{
+					Rollouts:          api.NewRolloutSummaries(rollouts.Items),
+					SkippedNamespaces: skippedNamespaces,
+					Columns:           fleetColumns.Columns(),
+					ColumnValues:      rolloutColumnValues(fleetColumns, rollouts.Items, imagePolicies, imageRepositories),
+				}
+				if api.WantsNDJSON(c) {
+					streamRolloutSummaries(c, response)
+					return
+				}
+				api.RespondJSON(c, http.StatusOK, response, "rollouts", fields)
+				return
 			}
 
-			var imageRepositories interface{}
-			if namespace == "all" || namespace == "*" || namespace == "" {
-				imageRepositories, err = k8sClient.GetImageRepositoriesAllNamespaces(context.Background())
-			} else {
-				imageRepositories, err = k8sClient.GetImageRepositories(context.Background(), namespace)
+			kustomizations, ociRepositories := related.kustomizations, related.ociRepositories
+
+			etag := api.NewETag(c)
+			etag.AddObjects(rollouts, imagePolicies, imageRepositories, kustomizations, ociRepositories)
+			etag.AddJSON(skippedNamespaces)
+			if api.NotModified(c, etag.String()) {
+				return
 			}
-			if err != nil {
-				log.Printf("Error fetching image repositories: %v", err)
+			response := api.RolloutListResponse{
+				Rollouts:          rollouts,
+				ImagePolicies:     imagePolicies,
+				ImageRepositories: imageRepositories,
+				Kustomizations:    kustomizations,
+				OCIRepositories:   ociRepositories,
+				SkippedNamespaces: skippedNamespaces,
+				Columns:           fleetColumns.Columns(),
+				ColumnValues:      rolloutColumnValues(fleetColumns, rollouts.Items, imagePolicies, imageRepositories),
+			}
+			// Large lists can be streamed item by item instead of marshaled as one document
+			if api.WantsNDJSON(c) {
+				streamRolloutList(c, response)
+				return
 			}
+			api.RespondJSON(c, http.StatusOK, response, "rollouts.items", fields)
+		}
+		v1.GET("/rollouts", func(c *gin.Context) {
+			listRollouts(c, c.Query("view") == "summary")
+		})
 
-			var kustomizations interface{}
-			if namespace == "all" || namespace == "*" || namespace == "" {
-				kustomizations, err = k8sClient.GetKustomizationsAllNamespaces(context.Background())
-			} else {
-				kustomizations, err = k8sClient.GetKustomizations(context.Background(), namespace)
+		v1.GET("/summary", getFleetSummary)
+
+		// getRollout looks up the details of a rollout, from the cache when they are cached, and
+		// responds with them and the requested fields through respond unless the client's copy is
+		// still current
+		getRollout := func(c *gin.Context, respond func(c *gin.Context, detail *api.RolloutDetailResponse, fields api.Fields)) {
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
 			}
-			if err != nil {
-				log.Printf("Error fetching kustomizations: %v", err)
+			fields, ok := api.RequestFields(c)
+			if !ok {
+				return
+			}
+			respondIfModified := func(c *gin.Context, detail *api.RolloutDetailResponse) {
+				if !api.NotModified(c, rolloutDetailETag(c, detail)) {
+					respond(c, detail, fields)
+				}
 			}
 
-			var ociRepositories interface{}
-			if namespace == "all" || namespace == "*" || namespace == "" {
-				ociRepositories, err = k8sClient.GetOCIRepositoriesAllNamespaces(context.Background())
-			} else {
-				ociRepositories, err = k8sClient.GetOCIRepositories(context.Background(), namespace)
+			namespace := c.Param("namespace")
+			name := c.Param("name")
+
+			key := detailCacheKey{credentials: k8sClient.CredentialKey(), namespace: namespace, name: name}
+			if details != nil {
+				if detail := details.get(key); detail != nil {
+					respondIfModified(c, withLinks(quickLinks, withOwnership(c.Request.Context(), owners, detail)))
+					return
+				}
 			}
+
+			var version uint64
+			if details != nil {
+				version = details.begin()
+			}
+			detail, complete, err := getRolloutDetail(c.Request.Context(), logging.FromContext(c), k8sClient, namespace, name)
 			if err != nil {
-				log.Printf("Error fetching OCI repositories: %v", err)
+				logging.FromContext(c).Error("Error fetching rollout", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout", err)
+				return
+			}
+			if details != nil && complete {
+				details.put(key, version, detail)
 			}
 
-			c.JSON(http.StatusOK, gin.H{
-				"rollouts":          rollouts,
-				"imagePolicies":     imagePolicies,
-				"imageRepositories": imageRepositories,
-				"kustomizations":    kustomizations,
-				"ociRepositories":   ociRepositories,
+			respondIfModified(c, withLinks(quickLinks, withOwnership(c.Request.Context(), owners, detail)))
+		}
+		v1.GET("/rollouts/:namespace/:name", func(c *gin.Context) {
+			getRollout(c, func(c *gin.Context, detail *api.RolloutDetailResponse, fields api.Fields) {
+				api.RespondJSON(c, http.StatusOK, detail, "rollout", fields)
 			})
 		})
 
-		api.GET("/rollouts/:namespace/:name", func(c *gin.Context) {
+		// Create a link granting read-only access to the rollout's details and logs for a limited
+		// time, e.g. for stakeholders without RBAC. Only users who can read the rollout may share it.
+		v1.POST("/rollouts/:namespace/:name/share", func(c *gin.Context) {
+			if shares == nil {
+				api.RespondErrorDetails(c, http.StatusNotFound, api.CodeNotFound, "Sharing is not enabled", "set SHARE_LINK_SECRET to enable share links")
+				return
+			}
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
@@ -150,86 +606,74 @@ func main() {
 			namespace := c.Param("namespace")
 			name := c.Param("name")
 
-			// Get Rollout
-			rollout, err := k8sClient.GetRollout(context.Background(), namespace, name)
-			if err != nil {
-				log.Printf("Error fetching rollout: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to fetch rollout",
-					"details": err.Error(),
-				})
+			var req api.ShareRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid request body", err)
 				return
 			}
-
-			// Get associated Kustomizations that reference this rollout
-			kustomizations, err := k8sClient.GetKustomizationsByRolloutAnnotation(context.Background(), namespace, name)
-			if err != nil {
-				log.Printf("Error fetching kustomizations: %v", err)
+			ttl := share.DefaultTTL
+			if req.TTL != "" {
+				parsed, err := time.ParseDuration(req.TTL)
+				if err != nil {
+					api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid ttl", err)
+					return
+				}
+				ttl = parsed
 			}
-
-			// Get associated OCIRepositories that reference this rollout
-			ociRepositories, err := k8sClient.GetOCIRepositoriesByRolloutAnnotation(context.Background(), namespace, name)
-			if err != nil {
-				log.Printf("Error fetching OCI repositories: %v", err)
+			if ttl <= 0 || ttl > shares.MaxTTL() {
+				api.RespondErrorDetails(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid ttl", fmt.Sprintf("ttl must be positive and at most %s", shares.MaxTTL()))
+				return
 			}
 
-			// Get associated RolloutGates that reference this rollout
-			rolloutGates, err := k8sClient.GetRolloutGatesByRolloutReference(context.Background(), namespace, name)
-			if err != nil {
-				log.Printf("Error fetching rollout gates: %v", err)
+			// Reading the rollout with the caller's credentials checks they may see what they share
+			if _, err := k8sClient.GetRollout(c.Request.Context(), namespace, name); err != nil {
+				switch {
+				case apierrors.IsNotFound(err):
+					api.RespondError(c, http.StatusNotFound, api.CodeNotFound, "Rollout not found", err)
+				case apierrors.IsForbidden(err):
+					api.RespondError(c, http.StatusForbidden, api.CodeForbidden, "Not allowed to read rollout", err)
+				default:
+					logging.FromContext(c).Error("Error fetching rollout", "error", err)
+					api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout", err)
+				}
+				return
 			}
 
-			// Get associated KuberikEnvironment that references this rollout
-			environment, err := k8sClient.GetEnvironmentByRolloutReference(context.Background(), namespace, name)
+			// The link also grants the rollout's pod logs, so the caller must be able to read those too
+			allowed, err := k8sClient.CheckPodLogPermission(c.Request.Context(), namespace)
 			if err != nil {
-				log.Printf("Error fetching environment: %v", err)
+				logging.FromContext(c).Error("Error checking permission", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to check permission", err)
+				return
 			}
-
-			// Try to get the KruiseRollout (may not exist)
-			// Note: We use interface{} since we don't import kruiserolloutv1beta1 in main.go
-			var kruiseRollout interface{}
-			kruiseRolloutObj, err := k8sClient.GetKruiseRollout(context.Background(), namespace, name)
-			if err != nil {
-				// KruiseRollout might not exist, that's okay
-				kruiseRollout = nil
-			} else {
-				kruiseRollout = kruiseRolloutObj
+			if !allowed {
+				api.RespondErrorDetails(c, http.StatusForbidden, api.CodeForbidden, "Not allowed to share pod logs",
+					fmt.Sprintf("getting pods/log in namespace %s is not permitted", namespace))
+				return
 			}
 
-			// Get all RolloutTests in the namespace (they will be filtered by rollout name in frontend)
-			// We fetch all tests and let the frontend filter by the actual KruiseRollout name
-			rolloutTests, err := k8sClient.GetAllRolloutTests(context.Background(), namespace)
+			user, err := verifiedUser(c, k8sClient)
 			if err != nil {
-				log.Printf("Error fetching rollout tests: %v", err)
-				// Continue without rollout tests if there's an error
-				rolloutTests = nil
+				logging.FromContext(c).Error("Error identifying user", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to identify user", err)
+				return
 			}
-
-			// Get the ImageRepository's scanTime for the rollout's ImagePolicy
-			var imageRepoScanTime string
-			if rollout.Spec.ReleasesImagePolicy.Name != "" {
-				imagePolicy, err := k8sClient.GetImagePolicy(context.Background(), namespace, rollout.Spec.ReleasesImagePolicy.Name)
-				if err == nil && imagePolicy.Spec.ImageRepositoryRef.Name != "" {
-					imageRepo, err := k8sClient.GetImageRepository(context.Background(), namespace, imagePolicy.Spec.ImageRepositoryRef.Name)
-					if err == nil && imageRepo.Status.LastScanResult != nil {
-						imageRepoScanTime = imageRepo.Status.LastScanResult.ScanTime.Format(time.RFC3339)
-					}
-				}
+			token, claims, err := shares.Sign(namespace, name, user, ttl)
+			if err != nil {
+				api.RespondError(c, http.StatusInternalServerError, api.CodeInternal, "Failed to create share link", err)
+				return
 			}
+			logging.FromContext(c).Info("Created share link", "audit", true, "user", user,
+				"namespace", namespace, "rollout", name, "expiresAt", claims.ExpiresAt.Format(time.RFC3339))
 
-			c.JSON(http.StatusOK, gin.H{
-				"rollout":           rollout,
-				"kustomizations":    kustomizations,
-				"ociRepositories":   ociRepositories,
-				"rolloutGates":      rolloutGates,
-				"environment":       environment,
-				"kruiseRollout":     kruiseRollout,
-				"rolloutTests":      rolloutTests,
-				"imageRepoScanTime": imageRepoScanTime,
+			c.JSON(http.StatusOK, api.ShareResponse{
+				Token:     token,
+				URL:       fmt.Sprintf("/api/%s/rollouts/%s/%s?%s=%s", api.VersionFromContext(c), namespace, name, share.QueryParameter, token),
+				ExpiresAt: claims.ExpiresAt,
 			})
 		})
 
-		api.GET("/rollouts/:namespace/:name/environments", func(c *gin.Context) {
+		v1.GET("/rollouts/:namespace/:name/environments", func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
@@ -238,23 +682,18 @@ func main() {
 			namespace := c.Param("namespace")
 
 			// Get all Environments in the namespace
-			environments, err := k8sClient.GetEnvironments(context.Background(), namespace)
+			environments, err := k8sClient.GetEnvironments(c.Request.Context(), namespace)
 			if err != nil {
-				log.Printf("Error fetching environments: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to fetch environments",
-					"details": err.Error(),
-				})
+				logging.FromContext(c).Error("Error fetching environments", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch environments", err)
 				return
 			}
 
-			c.JSON(http.StatusOK, gin.H{
-				"environments": environments,
-			})
+			c.JSON(http.StatusOK, api.EnvironmentsResponse{Environments: environments})
 		})
 
 		// Get RolloutTests for a KruiseRollout
-		api.GET("/rollouts/:namespace/:name/rollout-tests", func(c *gin.Context) {
+		v1.GET("/rollouts/:namespace/:name/rollout-tests", func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
@@ -264,34 +703,36 @@ func main() {
 			name := c.Param("name")
 
 			// Get RolloutTests that reference this KruiseRollout
-			rolloutTests, err := k8sClient.GetRolloutTestsByRolloutName(context.Background(), namespace, name)
+			rolloutTests, err := k8sClient.GetRolloutTestsByRolloutName(c.Request.Context(), namespace, name)
 			if err != nil {
-				log.Printf("Error fetching rollout tests: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to fetch rollout tests",
-					"details": err.Error(),
-				})
+				logging.FromContext(c).Error("Error fetching rollout tests", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout tests", err)
 				return
 			}
 
 			// Try to get the KruiseRollout to get current step info
-			// Note: We use interface{} since we don't import kruiserolloutv1beta1 in main.go
-			var kruiseRollout interface{}
-			kruiseRolloutObj, err := k8sClient.GetKruiseRollout(context.Background(), namespace, name)
+			kruiseRollout, err := k8sClient.GetKruiseRollout(c.Request.Context(), namespace, name)
 			if err != nil {
 				// KruiseRollout might not exist, that's okay
 				kruiseRollout = nil
-			} else {
-				kruiseRollout = kruiseRolloutObj
 			}
 
-			c.JSON(http.StatusOK, gin.H{
-				"rolloutTests":  rolloutTests,
-				"kruiseRollout": kruiseRollout,
+			c.JSON(http.StatusOK, api.RolloutTestsResponse{
+				RolloutTests:  rolloutTests,
+				KruiseRollout: kruiseRollout,
 			})
 		})
 
-		api.POST("/rollouts/:namespace/:name/pin", func(c *gin.Context) {
+		v1.GET("/rollouts/:namespace/:name/rollout-tests/gates", getRolloutTestGates)
+		v1.GET("/rollouts/:namespace/:name/rollout-tests/history", getRolloutTestHistory)
+		v1.POST("/rollout-tests/:namespace/:name/rerun", rerunRolloutTest(freezes, requestUser))
+		v1.GET("/rollout-tests/:namespace/:name/report", getRolloutTestReport)
+
+		v1.GET("/rollouts/:namespace/:name/canary-analysis", func(c *gin.Context) {
+			getCanaryAnalysis(c, metrics)
+		})
+
+		v1.POST("/rollouts/:namespace/:name/pin", unfrozen, func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
@@ -300,15 +741,9 @@ func main() {
 			namespace := c.Param("namespace")
 			name := c.Param("name")
 
-			var pinRequest struct {
-				Version     *string `json:"version"`
-				Explanation string  `json:"explanation"`
-			}
+			var pinRequest api.PinRequest
 			if err := c.ShouldBindJSON(&pinRequest); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{
-					"error":   "Invalid request body",
-					"details": err.Error(),
-				})
+				api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid request body", err)
 				return
 			}
 
@@ -321,25 +756,26 @@ func main() {
 					explanation = "Cleared version pin"
 				}
 			}
+			if target := writeBack.Target(namespace); target != nil {
+				proposeVersion(c, k8sClient, writeBack, target, pinRequest.Version, explanation)
+				return
+			}
 
 			// Update the rollout with the new version and explanation
 			updatedRollout, err := k8sClient.UpdateRolloutVersion(c.Request.Context(), namespace, name, pinRequest.Version, explanation)
 			if err != nil {
-				log.Printf("Error updating rollout: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to update rollout version",
-					"details": err.Error(),
-				})
+				logging.FromContext(c).Error("Error updating rollout", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to update rollout version", err)
 				return
 			}
 
-			c.JSON(http.StatusOK, gin.H{
-				"rollout": updatedRollout,
-			})
+			c.JSON(http.StatusOK, api.RolloutResponse{Rollout: updatedRollout})
 		})
 
 		// Add force-deploy annotation to rollout
-		api.POST("/rollouts/:namespace/:name/force-deploy", func(c *gin.Context) {
+		// With wait=true (and optional timeout=<duration>) the response is delayed until the
+		// controller reports the version as deployed, or 202 is returned when the wait times out
+		v1.POST("/rollouts/:namespace/:name/force-deploy", unfrozen, func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
@@ -348,15 +784,9 @@ func main() {
 			namespace := c.Param("namespace")
 			name := c.Param("name")
 
-			var forceDeployRequest struct {
-				Version string `json:"version" binding:"required"`
-				Message string `json:"message"`
-			}
+			var forceDeployRequest api.ForceDeployRequest
 			if err := c.ShouldBindJSON(&forceDeployRequest); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{
-					"error":   "Invalid request body",
-					"details": err.Error(),
-				})
+				api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid request body", err)
 				return
 			}
 
@@ -369,21 +799,16 @@ func main() {
 			// Add the force-deploy annotation with the specific version and optional message
 			updatedRollout, err := k8sClient.AddForceDeployAnnotation(c.Request.Context(), namespace, name, forceDeployRequest.Version, message)
 			if err != nil {
-				log.Printf("Error adding force-deploy annotation: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to add force-deploy annotation",
-					"details": err.Error(),
-				})
+				logging.FromContext(c).Error("Error adding force-deploy annotation", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to add force-deploy annotation", err)
 				return
 			}
 
-			c.JSON(http.StatusOK, gin.H{
-				"rollout": updatedRollout,
-			})
+			respondWithVersionChange(c, k8sClient, namespace, name, forceDeployRequest.Version, updatedRollout)
 		})
 
 		// Add bypass-gates annotation to rollout
-		api.POST("/rollouts/:namespace/:name/bypass-gates", func(c *gin.Context) {
+		v1.POST("/rollouts/:namespace/:name/bypass-gates", unfrozen, func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
@@ -392,35 +817,26 @@ func main() {
 			namespace := c.Param("namespace")
 			name := c.Param("name")
 
-			var bypassRequest struct {
-				Version string `json:"version" binding:"required"`
-			}
+			var bypassRequest api.BypassGatesRequest
 			if err := c.ShouldBindJSON(&bypassRequest); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{
-					"error":   "Invalid request body",
-					"details": err.Error(),
-				})
+				api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid request body", err)
 				return
 			}
 
 			// Add the bypass-gates annotation with the specific version
-			updatedRollout, err := k8sClient.AddBypassGatesAnnotation(context.Background(), namespace, name, bypassRequest.Version)
+			updatedRollout, err := k8sClient.AddBypassGatesAnnotation(c.Request.Context(), namespace, name, bypassRequest.Version)
 			if err != nil {
-				log.Printf("Error adding bypass-gates annotation: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to add bypass-gates annotation",
-					"details": err.Error(),
-				})
+				logging.FromContext(c).Error("Error adding bypass-gates annotation", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to add bypass-gates annotation", err)
 				return
 			}
 
-			c.JSON(http.StatusOK, gin.H{
-				"rollout": updatedRollout,
-			})
+			c.JSON(http.StatusOK, api.RolloutResponse{Rollout: updatedRollout})
 		})
 
 		// Change version (pin or unpin + force-deploy) atomically
-		api.POST("/rollouts/:namespace/:name/change-version", func(c *gin.Context) {
+		// Supports the same wait=true/timeout parameters as force-deploy
+		v1.POST("/rollouts/:namespace/:name/change-version", func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
@@ -429,16 +845,9 @@ func main() {
 			namespace := c.Param("namespace")
 			name := c.Param("name")
 
-			var req struct {
-				Version string `json:"version" binding:"required"`
-				Pin     bool   `json:"pin"`
-				Message string `json:"message"`
-			}
+			var req api.ChangeVersionRequest
 			if err := c.ShouldBindJSON(&req); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{
-					"error":   "Invalid request body",
-					"details": err.Error(),
-				})
+				api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid request body", err)
 				return
 			}
 
@@ -452,23 +861,63 @@ func main() {
 				}
 			}
 
+			// Rollouts managed in git can only be pinned, through a pull request
+			target := writeBack.Target(namespace)
+			if req.ScheduleAt != nil && rejectGitOpsChange(c, target, "a scheduled version change") {
+				return
+			}
+			if !req.Pin && rejectGitOpsChange(c, target, "a force deploy") {
+				return
+			}
+
+			// A change for later is only recorded, the scheduler applies it once it is due
+			if req.ScheduleAt != nil {
+				if !req.ScheduleAt.After(time.Now()) {
+					api.RespondErrorDetails(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid request body", "scheduleAt must be in the future")
+					return
+				}
+				user, err := verifiedUser(c, k8sClient)
+				if err != nil {
+					logging.FromContext(c).Error("Error identifying user", "error", err)
+					api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to identify user", err)
+					return
+				}
+				updatedRollout, err := k8sClient.ScheduleVersionChange(c.Request.Context(), namespace, name, req.Version, req.Pin, message, *req.ScheduleAt)
+				if err != nil {
+					logging.FromContext(c).Error("Error scheduling version change", "error", err)
+					api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to schedule version change", err)
+					return
+				}
+				logging.FromContext(c).Info("Scheduled version change", "audit", true, "user", user,
+					"namespace", namespace, "rollout", name, "version", req.Version, "pin", req.Pin, "at", *req.ScheduleAt)
+				c.JSON(http.StatusAccepted, api.VersionChangeResponse{Rollout: updatedRollout})
+				return
+			}
+			// Changes scheduled for later are only checked by the scheduler once they are due
+			if !checkUnfrozen(c, freezes, requestUser(c)) {
+				return
+			}
+			if target != nil {
+				proposeVersion(c, k8sClient, writeBack, target, &req.Version, message)
+				return
+			}
+
 			updatedRollout, err := k8sClient.ChangeVersion(c.Request.Context(), namespace, name, req.Version, req.Pin, message)
 			if err != nil {
-				log.Printf("Error changing version: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to change version",
-					"details": err.Error(),
-				})
+				logging.FromContext(c).Error("Error changing version", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to change version", err)
 				return
 			}
 
-			c.JSON(http.StatusOK, gin.H{
-				"rollout": updatedRollout,
-			})
+			respondWithVersionChange(c, k8sClient, namespace, name, req.Version, updatedRollout)
 		})
 
+		// Version changes scheduled for later with change-version
+		v1.GET("/scheduled-changes", listScheduledChanges)
+		v1.DELETE("/rollouts/:namespace/:name/scheduled-change", cancelScheduledChange)
+
 		// Add unblock-failed annotation to rollout
-		api.POST("/rollouts/:namespace/:name/unblock-failed", func(c *gin.Context) {
+		v1.POST("/rollouts/:namespace/:name/unblock-failed", unfrozen, func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
@@ -478,23 +927,18 @@ func main() {
 			name := c.Param("name")
 
 			// Add the unblock-failed annotation
-			updatedRollout, err := k8sClient.AddUnblockFailedAnnotation(context.Background(), namespace, name)
+			updatedRollout, err := k8sClient.AddUnblockFailedAnnotation(c.Request.Context(), namespace, name)
 			if err != nil {
-				log.Printf("Error adding unblock-failed annotation: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to add unblock-failed annotation",
-					"details": err.Error(),
-				})
+				logging.FromContext(c).Error("Error adding unblock-failed annotation", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to add unblock-failed annotation", err)
 				return
 			}
 
-			c.JSON(http.StatusOK, gin.H{
-				"rollout": updatedRollout,
-			})
+			c.JSON(http.StatusOK, api.RolloutResponse{Rollout: updatedRollout})
 		})
 
 		// Mark deployment as successful
-		api.POST("/rollouts/:namespace/:name/mark-successful", func(c *gin.Context) {
+		v1.POST("/rollouts/:namespace/:name/mark-successful", unfrozen, func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
@@ -503,62 +947,143 @@ func main() {
 			namespace := c.Param("namespace")
 			name := c.Param("name")
 
-			var markSuccessfulRequest struct {
-				Message string `json:"message"`
-			}
+			var markSuccessfulRequest api.MarkSuccessfulRequest
 			if err := c.ShouldBindJSON(&markSuccessfulRequest); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{
-					"error":   "Invalid request body",
-					"details": err.Error(),
-				})
+				api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid request body", err)
 				return
 			}
 
 			// Mark the deployment as successful
-			updatedRollout, err := k8sClient.MarkDeploymentSuccessful(context.Background(), namespace, name, markSuccessfulRequest.Message)
+			updatedRollout, err := k8sClient.MarkDeploymentSuccessful(c.Request.Context(), namespace, name, markSuccessfulRequest.Message)
 			if err != nil {
-				log.Printf("Error marking deployment as successful: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to mark deployment as successful",
-					"details": err.Error(),
-				})
+				logging.FromContext(c).Error("Error marking deployment as successful", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to mark deployment as successful", err)
 				return
 			}
 
-			c.JSON(http.StatusOK, gin.H{
-				"rollout": updatedRollout,
-			})
+			c.JSON(http.StatusOK, api.RolloutResponse{Rollout: updatedRollout})
 		})
 
-		// Reconcile all associated Flux resources for a rollout
-		api.POST("/rollouts/:namespace/:name/reconcile", func(c *gin.Context) {
+		// Routine tuning of dashboard-managed rollouts without a GitOps change
+		v1.PATCH("/rollouts/:namespace/:name/spec", unfrozen, patchRolloutSpec(requestUser))
+		v1.DELETE("/rollouts/:namespace/:name", unfrozen, deleteRollout())
+
+		// Bake progress of the current deployment, with its ETA and the health checks it depends on
+		v1.GET("/rollouts/:namespace/:name/bake", func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
 			}
-
-			namespace := c.Param("namespace")
-			name := c.Param("name")
-
-			// Reconcile all associated Flux resources
-			previousScanTime, err := k8sClient.ReconcileAllFluxResources(context.Background(), namespace, name)
+			progress, err := k8sClient.GetBakeProgress(c.Request.Context(), c.Param("namespace"), c.Param("name"), time.Now())
+			if apierrors.IsNotFound(err) {
+				api.RespondError(c, http.StatusNotFound, api.CodeNotFound, "Rollout not found", err)
+				return
+			}
 			if err != nil {
-				log.Printf("Error reconciling Flux resources: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to reconcile Flux resources",
-					"details": err.Error(),
-				})
+				logging.FromContext(c).Error("Error computing bake progress", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to compute bake progress", err)
+				return
+			}
+			c.JSON(http.StatusOK, api.BakeProgressResponse{BakeProgress: *progress})
+		})
+
+		// Extend the bake of the current deployment, keeping it from being promoted while it is
+		// observed longer. Extending only delays a promotion, so it is allowed during freezes.
+		v1.POST("/rollouts/:namespace/:name/extend-bake", func(c *gin.Context) {
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
+			}
+
+			var req api.ExtendBakeRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid request body", err)
+				return
+			}
+			duration, err := time.ParseDuration(req.Duration)
+			if err != nil || duration <= 0 || duration > maxBakeExtension {
+				api.RespondErrorDetails(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid duration",
+					fmt.Sprintf("duration must be a positive Go duration of at most %s", maxBakeExtension))
+				return
+			}
+
+			updatedRollout, err := k8sClient.ExtendBake(c.Request.Context(), c.Param("namespace"), c.Param("name"), req.Version, duration, req.Reason)
+			switch {
+			case errors.Is(err, kubernetes.ErrNotBaking), errors.Is(err, kubernetes.ErrVersionNotBaking):
+				api.RespondError(c, http.StatusConflict, api.CodeConflict, "Bake cannot be extended", err)
+				return
+			case err != nil:
+				logging.FromContext(c).Error("Error extending bake", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to extend bake", err)
+				return
+			}
+
+			response := api.BakeExtensionResponse{Rollout: updatedRollout, BakeExtension: kubernetes.GetBakeExtension(updatedRollout)}
+			if start := updatedRollout.Status.History[0].BakeStartTime; start != nil {
+				end := start.Add(updatedRollout.Spec.BakeTime.Duration)
+				response.BakeEndsAt = &end
+			}
+			c.JSON(http.StatusOK, response)
+		})
+
+		// Reconcile all associated Flux resources for a rollout
+		v1.POST("/rollouts/:namespace/:name/reconcile", unfrozen, func(c *gin.Context) {
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
+			}
+
+			namespace := c.Param("namespace")
+			name := c.Param("name")
+
+			// Reconcile all associated Flux resources concurrently; the request is cancelled with the client.
+			// withSource also requests their upstream sources and the image scan, like flux reconcile --with-source.
+			withSource, _ := strconv.ParseBool(c.Query("withSource"))
+			previousScanTime, results, err := k8sClient.ReconcileAllFluxResources(c.Request.Context(), namespace, name, withSource)
+			if err != nil {
+				logging.FromContext(c).Error("Error reconciling Flux resources", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to reconcile Flux resources", err)
 				return
 			}
 
-			c.JSON(http.StatusOK, gin.H{
-				"message":          "Successfully triggered reconciliation of all associated Flux resources",
-				"previousScanTime": previousScanTime,
+			// Partial failures are reported per resource with 207 Multi-Status
+			status := http.StatusOK
+			message := "Successfully triggered reconciliation of all associated Flux resources"
+			failed := 0
+			for _, result := range results {
+				if !result.Succeeded {
+					failed++
+				}
+			}
+			if failed > 0 {
+				status = http.StatusMultiStatus
+				message = fmt.Sprintf("Failed to trigger reconciliation of %d of %d associated Flux resources", failed, len(results))
+			}
+
+			c.JSON(status, api.ReconcileResponse{
+				Message:          message,
+				PreviousScanTime: previousScanTime,
+				Results:          results,
 			})
 		})
 
+		// Reconcile, suspend or resume one of the HelmReleases deploying a rollout. Suspending stops
+		// deploys, so it is allowed during a deployment freeze.
+		v1.POST("/rollouts/:namespace/:name/helmreleases/:helmrelease/reconcile", unfrozen, reconcileHelmRelease)
+		v1.POST("/rollouts/:namespace/:name/helmreleases/:helmrelease/suspend", suspendHelmRelease(true))
+		v1.POST("/rollouts/:namespace/:name/helmreleases/:helmrelease/resume", unfrozen, suspendHelmRelease(false))
+
+		// Suspend or resume a Kustomization, OCIRepository or ImageRepository, halting GitOps
+		// delivery of the rollouts behind it, e.g. during an incident. Resuming delivers again, so
+		// like for HelmReleases it is rejected during a deployment freeze.
+		namespaceUnfrozen := requireNamespaceUnfrozen(freezes, requestUser)
+		for _, resource := range []string{"kustomizations", "ocirepositories", "imagerepositories"} {
+			v1.POST("/"+resource+"/:namespace/:name/suspend", suspendFluxObject(resource, true))
+			v1.POST("/"+resource+"/:namespace/:name/resume", namespaceUnfrozen, suspendFluxObject(resource, false))
+		}
+
 		// Continue OpenKruise rollout
-		api.POST("/rollouts/:namespace/:name/continue", func(c *gin.Context) {
+		v1.POST("/rollouts/:namespace/:name/continue", unfrozen, func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
@@ -568,55 +1093,133 @@ func main() {
 			kruiseRolloutName := c.Param("name")
 
 			// Parse request body to get Kuberik rollout name
-			var req struct {
-				KuberikRolloutName string `json:"kuberikRolloutName"`
-			}
+			var req api.ContinueRequest
 			if err := c.ShouldBindJSON(&req); err != nil {
-				log.Printf("Error parsing continue request body: %v", err)
-				c.JSON(http.StatusBadRequest, gin.H{
-					"error":   "Invalid request body",
-					"details": err.Error(),
-				})
+				logging.FromContext(c).Warn("Error parsing continue request body", "error", err)
+				api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid request body", err)
 				return
 			}
 
 			// Reset bake status to Deploying on the Kuberik rollout
 			if req.KuberikRolloutName != "" {
-				_, err := k8sClient.ResetBakeStatusToDeploying(context.Background(), namespace, req.KuberikRolloutName)
+				_, err := k8sClient.ResetBakeStatusToDeploying(c.Request.Context(), namespace, req.KuberikRolloutName)
 				if err != nil {
-					log.Printf("Error resetting bake status: %v", err)
-					c.JSON(http.StatusInternalServerError, gin.H{
-						"error":   "Failed to reset bake status",
-						"details": err.Error(),
-					})
+					logging.FromContext(c).Error("Error resetting bake status", "error", err)
+					api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to reset bake status", err)
 					return
 				}
 
 				// Reset health checks to Pending
-				if err := k8sClient.ResetHealthChecksToPending(context.Background(), namespace, req.KuberikRolloutName); err != nil {
-					log.Printf("Error resetting health checks: %v", err)
-					c.JSON(http.StatusInternalServerError, gin.H{
-						"error":   "Failed to reset health checks",
-						"details": err.Error(),
-					})
+				if err := k8sClient.ResetHealthChecksToPending(c.Request.Context(), namespace, req.KuberikRolloutName); err != nil {
+					logging.FromContext(c).Error("Error resetting health checks", "error", err)
+					api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to reset health checks", err)
 					return
 				}
 			}
 
-			// Continue the OpenKruise rollout
-			updatedRollout, err := k8sClient.ContinueKruiseRollout(context.Background(), namespace, kruiseRolloutName)
+			// Continue the OpenKruise or Argo rollout, whichever drives the workload
+			provider, _, err := k8sClient.GetProgressiveRollout(c.Request.Context(), namespace, kruiseRolloutName)
 			if err != nil {
-				log.Printf("Error continuing kruise rollout: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to continue kruise rollout",
-					"details": err.Error(),
-				})
+				respondProgressiveError(c, "Failed to continue kruise rollout", err)
+				return
+			}
+			if err := provider.Continue(c.Request.Context(), namespace, kruiseRolloutName); err != nil {
+				respondProgressiveError(c, "Failed to continue kruise rollout", err)
 				return
 			}
 
-			c.JSON(http.StatusOK, gin.H{
-				"rollout": updatedRollout,
-			})
+			progressive, err := provider.Get(c.Request.Context(), namespace, kruiseRolloutName)
+			if err != nil {
+				respondProgressiveError(c, "Failed to continue kruise rollout", err)
+				return
+			}
+			response := api.KruiseRolloutResponse{Progressive: progressive}
+			if provider.Name() == kubernetes.ProviderKruise {
+				if response.Rollout, err = k8sClient.GetKruiseRollout(c.Request.Context(), namespace, kruiseRolloutName); err != nil {
+					logging.FromContext(c).Error("Error fetching kruise rollout", "error", err)
+					api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch kruise rollout", err)
+					return
+				}
+			}
+			c.JSON(http.StatusOK, response)
+		})
+
+		// Manual RolloutGates, e.g. to hold a rollout during a holiday freeze. Creating a gate only
+		// holds a rollout, so it is allowed during freezes; changing or deleting one may release it.
+		v1.GET("/rollouts/:namespace/:name/gates/explain", explainRolloutGates)
+		v1.GET("/rollouts/:namespace/:name/pending-versions", getPendingVersions)
+		v1.POST("/rollouts/:namespace/:name/gates", createRolloutGate())
+		v1.PUT("/rollouts/:namespace/:name/gates/:gate", unfrozen, updateRolloutGate())
+		v1.DELETE("/rollouts/:namespace/:name/gates/:gate", unfrozen, deleteRolloutGate())
+
+		// Progressive rollout of the workload by OpenKruise or Argo Rollouts, whichever CRDs are
+		// installed. Pause and abort stop a bad version, so they are allowed during freezes.
+		v1.GET("/rollouts/:namespace/:name/progressive", getProgressiveRollout)
+		v1.POST("/rollouts/:namespace/:name/progressive/continue", unfrozen, progressiveAction("continue", requestUser))
+		v1.POST("/rollouts/:namespace/:name/progressive/pause", progressiveAction("pause", requestUser))
+		v1.POST("/rollouts/:namespace/:name/progressive/abort", progressiveAction("abort", requestUser))
+		v1.POST("/rollouts/:namespace/:name/progressive/step", unfrozen, progressiveAction("step", requestUser))
+
+		// Summarize probe failures and container states of the rollout's pods
+		v1.GET("/rollouts/:namespace/:name/readiness", func(c *gin.Context) {
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
+			}
+
+			readiness, err := k8sClient.GetRolloutReadiness(c.Request.Context(), c.Param("namespace"), c.Param("name"))
+			if err != nil {
+				logging.FromContext(c).Error("Error summarizing pod readiness", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to summarize pod readiness", err)
+				return
+			}
+
+			c.JSON(http.StatusOK, api.ReadinessResponse{Readiness: readiness})
+		})
+
+		// Blue/green view of an OpenKruise rollout: preview/stable services and traffic switch state
+		v1.GET("/rollouts/:namespace/:name/bluegreen", func(c *gin.Context) {
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
+			}
+
+			kruiseRollout, err := k8sClient.GetKruiseRollout(c.Request.Context(), c.Param("namespace"), c.Param("name"))
+			if err != nil {
+				logging.FromContext(c).Error("Error fetching kruise rollout", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch kruise rollout", err)
+				return
+			}
+			if !kubernetes.IsBlueGreen(kruiseRollout) {
+				api.RespondError(c, http.StatusNotFound, api.CodeNotFound, "Kruise rollout does not use a blue/green strategy", kubernetes.ErrNotBlueGreen)
+				return
+			}
+
+			c.JSON(http.StatusOK, api.BlueGreenResponse{BlueGreen: api.NewBlueGreenStatus(kruiseRollout)})
+		})
+
+		// Switch production traffic of a blue/green OpenKruise rollout to the new version
+		v1.POST("/rollouts/:namespace/:name/bluegreen/switch-traffic", unfrozen, func(c *gin.Context) {
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
+			}
+
+			updatedRollout, err := k8sClient.SwitchBlueGreenTraffic(c.Request.Context(), c.Param("namespace"), c.Param("name"))
+			switch {
+			case errors.Is(err, kubernetes.ErrNotBlueGreen):
+				api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Kruise rollout does not use a blue/green strategy", err)
+				return
+			case errors.Is(err, kubernetes.ErrTrafficAlreadySwitched), errors.Is(err, kubernetes.ErrNotWaitingForSwitch):
+				api.RespondError(c, http.StatusConflict, api.CodeConflict, "Traffic cannot be switched now", err)
+				return
+			case err != nil:
+				logging.FromContext(c).Error("Error switching blue/green traffic", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to switch traffic", err)
+				return
+			}
+
+			c.JSON(http.StatusOK, api.BlueGreenResponse{BlueGreen: api.NewBlueGreenStatus(updatedRollout)})
 		})
 
 		// Retry or skip a failed deployment by setting the rollout.kuberik.com/retry
@@ -625,7 +1228,7 @@ func main() {
 		//   "skip":             mark failed RolloutTests as Skipped (treated as passing)
 		// The controllers handle the cascade — no direct Kruise patching needed.
 		// kruiseRolloutName in the body is legacy and ignored.
-		api.POST("/rollouts/:namespace/:name/retry", func(c *gin.Context) {
+		v1.POST("/rollouts/:namespace/:name/retry", unfrozen, func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
@@ -634,12 +1237,9 @@ func main() {
 			namespace := c.Param("namespace")
 			kuberikRolloutName := c.Param("name")
 
-			var req struct {
-				KruiseRolloutName string `json:"kruiseRolloutName"`
-				TestAction        string `json:"testAction"`
-			}
+			var req api.RetryRequest
 			if err := c.ShouldBindJSON(&req); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+				api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid request body", err)
 				return
 			}
 
@@ -648,14 +1248,60 @@ func main() {
 				mode = openkruisev1alpha1.RetryModeSkip
 			}
 
-			if err := k8sClient.SetRetryAnnotation(context.Background(), namespace, kuberikRolloutName, mode); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to trigger retry", "details": err.Error()})
+			if err := k8sClient.SetRetryAnnotation(c.Request.Context(), namespace, kuberikRolloutName, mode); err != nil {
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to trigger retry", err)
+				return
+			}
+			c.JSON(http.StatusOK, api.RetryResponse{Status: "ok", Action: mode})
+		})
+
+		// Run an action on many rollouts at once, e.g. to reconcile or unblock every rollout
+		// affected by a registry outage. Items fail independently and are reported per item.
+		batchConcurrency := batchConcurrencyFromEnv()
+		v1.POST("/rollouts/batch", func(c *gin.Context) {
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
+			}
+
+			var req api.BatchRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid request body", err)
+				return
+			}
+			if len(req.Items) == 0 || len(req.Items) > maxBatchItems {
+				api.RespondErrorDetails(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid request body",
+					fmt.Sprintf("items must contain between 1 and %d entries", maxBatchItems))
+				return
+			}
+			// Every item is a change of its own to rate limits and anomaly detection
+			targets := make([]anomaly.Target, 0, len(req.Items))
+			for _, item := range req.Items {
+				targets = append(targets, anomaly.Target{Namespace: item.Namespace, Name: item.Name, Action: item.Action})
+			}
+			anomaly.SetTargets(c, targets)
+			if !limiter.ChargeMutations(c, len(req.Items)-1) {
+				return
+			}
+			if restricted := anonymousNamespaces(c); restricted != nil {
+				for _, item := range req.Items {
+					if !slices.Contains(restricted, item.Namespace) {
+						api.RespondErrorDetails(c, http.StatusForbidden, api.CodeForbidden, "Namespace not readable anonymously",
+							"namespace "+item.Namespace+" is not in ANONYMOUS_NAMESPACES, sign in to access it")
+						return
+					}
+				}
+			}
+
+			frozen, ok := newFreezeCheck(c, k8sClient, freezes)
+			if !ok {
 				return
 			}
-			c.JSON(http.StatusOK, gin.H{"status": "ok", "action": mode})
+			logger := logging.FromContext(c).With("audit", true, "user", requestUser(c))
+			c.JSON(http.StatusOK, runBatch(c.Request.Context(), k8sClient, req.Items, batchConcurrency, logger, frozen))
 		})
 
-		api.GET("/rollouts/:namespace/:name/manifest/:version", func(c *gin.Context) {
+		v1.GET("/rollouts/:namespace/:name/manifest/:version", func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
@@ -666,48 +1312,43 @@ func main() {
 			version := c.Param("version")
 
 			// Get Rollout to get the image policy reference
-			rollout, err := k8sClient.GetRollout(context.Background(), namespace, name)
+			rollout, err := k8sClient.GetRollout(c.Request.Context(), namespace, name)
 			if err != nil {
-				log.Printf("Error fetching rollout: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to fetch rollout",
-					"details": err.Error(),
-				})
+				logging.FromContext(c).Error("Error fetching rollout", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout", err)
+				return
+			}
+			// Limit the files to one component of a monorepo artifact
+			component := c.Query("component")
+			components, ok := rolloutComponents(c, rollout, component)
+			if !ok {
 				return
 			}
 
 			// Get the ImagePolicy referenced by the rollout
 			imagePolicyName := rollout.Spec.ReleasesImagePolicy.Name
-			imagePolicy, err := k8sClient.GetImagePolicy(context.Background(), namespace, imagePolicyName)
+			imagePolicy, err := k8sClient.GetImagePolicy(c.Request.Context(), namespace, imagePolicyName)
 			if err != nil {
-				log.Printf("Error fetching image policy: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to fetch image policy",
-					"details": err.Error(),
-				})
+				logging.FromContext(c).Error("Error fetching image policy", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch image policy", err)
 				return
 			}
 
 			// Get the ImageRepository referenced by the ImagePolicy
 			imageRepoName := imagePolicy.Spec.ImageRepositoryRef.Name
-			imageRepo, err := k8sClient.GetImageRepository(context.Background(), namespace, imageRepoName)
+			imageRepo, err := k8sClient.GetImageRepository(c.Request.Context(), namespace, imageRepoName)
 			if err != nil {
-				log.Printf("Error fetching image repository: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to fetch image repository",
-					"details": err.Error(),
-				})
+				logging.FromContext(c).Error("Error fetching image repository", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch image repository", err)
 				return
 			}
 
 			var opts []crane.Option
 			if imageRepo.Spec.SecretRef != nil {
-				secret, err := k8sClient.GetSecret(context.Background(), namespace, imageRepo.Spec.SecretRef.Name)
+				secret, err := k8sClient.GetSecret(c.Request.Context(), namespace, imageRepo.Spec.SecretRef.Name)
 				if err != nil {
-					log.Printf("Error fetching secret: %v", err)
-					c.JSON(http.StatusInternalServerError, gin.H{
-						"error": "Failed to fetch secret",
-					})
+					logging.FromContext(c).Error("Error fetching secret", "error", err)
+					api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch secret", nil)
 					return
 				}
 
@@ -715,8 +1356,8 @@ func main() {
 				reader := bytes.NewReader(secret.Data[".dockerconfigjson"])
 				configFile, err := config.LoadFromReader(reader)
 				if err != nil {
-					log.Printf("Error loading Docker config: %v", err)
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse Docker config"})
+					logging.FromContext(c).Error("Error loading Docker config", "error", err)
+					api.RespondError(c, http.StatusInternalServerError, api.CodeInternal, "Failed to parse Docker config", err)
 					return
 				}
 
@@ -727,33 +1368,31 @@ func main() {
 
 			// Get the image contents
 			files, err := oci.GetImageContents(
-				context.Background(),
+				c.Request.Context(),
 				imageRepo.Spec.Image,
 				version,
 				opts...,
 			)
 			if err != nil {
-				log.Printf("Error fetching image contents: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to fetch image contents",
-					"details": err.Error(),
-				})
+				logging.FromContext(c).Error("Error fetching image contents", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeRegistry, "Failed to fetch image contents", err)
 				return
 			}
 
 			// Convert files to a map for JSON response
 			contents := make(map[string]string)
 			for _, file := range files {
+				if component != "" && oci.ComponentOf(components, file.Name) != component {
+					continue
+				}
 				contents[file.Name] = string(file.Content)
 			}
 
-			c.JSON(http.StatusOK, gin.H{
-				"files": contents,
-			})
+			c.JSON(http.StatusOK, api.ManifestResponse{Files: contents})
 		})
 
 		// New endpoint to fetch the media type for a given version
-		api.GET("/rollouts/:namespace/:name/mediatype/:version", func(c *gin.Context) {
+		v1.GET("/rollouts/:namespace/:name/mediatype/:version", func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
@@ -763,35 +1402,35 @@ func main() {
 			name := c.Param("name")
 			version := c.Param("version")
 
-			rollout, err := k8sClient.GetRollout(context.Background(), namespace, name)
+			rollout, err := k8sClient.GetRollout(c.Request.Context(), namespace, name)
 			if err != nil {
-				log.Printf("Error fetching rollout: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rollout"})
+				logging.FromContext(c).Error("Error fetching rollout", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout", err)
 				return
 			}
 
 			imagePolicyName := rollout.Spec.ReleasesImagePolicy.Name
-			imagePolicy, err := k8sClient.GetImagePolicy(context.Background(), namespace, imagePolicyName)
+			imagePolicy, err := k8sClient.GetImagePolicy(c.Request.Context(), namespace, imagePolicyName)
 			if err != nil {
-				log.Printf("Error fetching image policy: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch image policy"})
+				logging.FromContext(c).Error("Error fetching image policy", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch image policy", err)
 				return
 			}
 
 			imageRepoName := imagePolicy.Spec.ImageRepositoryRef.Name
-			imageRepo, err := k8sClient.GetImageRepository(context.Background(), namespace, imageRepoName)
+			imageRepo, err := k8sClient.GetImageRepository(c.Request.Context(), namespace, imageRepoName)
 			if err != nil {
-				log.Printf("Error fetching image repository: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch image repository"})
+				logging.FromContext(c).Error("Error fetching image repository", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch image repository", err)
 				return
 			}
 
 			var opts []crane.Option
 			if imageRepo.Spec.SecretRef != nil {
-				secret, err := k8sClient.GetSecret(context.Background(), namespace, imageRepo.Spec.SecretRef.Name)
+				secret, err := k8sClient.GetSecret(c.Request.Context(), namespace, imageRepo.Spec.SecretRef.Name)
 				if err != nil {
-					log.Printf("Error fetching secret: %v", err)
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch secret"})
+					logging.FromContext(c).Error("Error fetching secret", "error", err)
+					api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch secret", nil)
 					return
 				}
 
@@ -799,8 +1438,8 @@ func main() {
 				reader := bytes.NewReader(secret.Data[".dockerconfigjson"])
 				configFile, err := config.LoadFromReader(reader)
 				if err != nil {
-					log.Printf("Error loading Docker config: %v", err)
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse Docker config"})
+					logging.FromContext(c).Error("Error loading Docker config", "error", err)
+					api.RespondError(c, http.StatusInternalServerError, api.CodeInternal, "Failed to parse Docker config", err)
 					return
 				}
 
@@ -809,17 +1448,17 @@ func main() {
 				opts = append(opts, crane.WithAuthFromKeychain(keychain))
 			}
 
-			mediaType, err := oci.GetArtifactType(context.Background(), imageRepo.Spec.Image, version, opts...)
+			mediaType, err := oci.GetArtifactType(c.Request.Context(), imageRepo.Spec.Image, version, opts...)
 			if err != nil {
-				log.Printf("Error fetching media type: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch media type"})
+				logging.FromContext(c).Error("Error fetching media type", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeRegistry, "Failed to fetch media type", err)
 				return
 			}
 
-			c.JSON(http.StatusOK, gin.H{"mediaType": mediaType})
+			c.JSON(http.StatusOK, api.MediaTypeResponse{MediaType: mediaType})
 		})
 
-		api.GET("/rollouts/:namespace/:name/annotations/:version", func(c *gin.Context) {
+		v1.GET("/rollouts/:namespace/:name/annotations/:version", func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
@@ -830,36 +1469,36 @@ func main() {
 			version := c.Param("version")
 
 			// Get Rollout to get the image policy reference
-			rollout, err := k8sClient.GetRollout(context.Background(), namespace, name)
+			rollout, err := k8sClient.GetRollout(c.Request.Context(), namespace, name)
 			if err != nil {
-				log.Printf("Error fetching rollout: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rollout"})
+				logging.FromContext(c).Error("Error fetching rollout", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout", err)
 				return
 			}
 
 			// Get the ImagePolicy referenced by the rollout
 			imagePolicyName := rollout.Spec.ReleasesImagePolicy.Name
-			imagePolicy, err := k8sClient.GetImagePolicy(context.Background(), namespace, imagePolicyName)
+			imagePolicy, err := k8sClient.GetImagePolicy(c.Request.Context(), namespace, imagePolicyName)
 			if err != nil {
-				log.Printf("Error fetching image policy: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch image policy"})
+				logging.FromContext(c).Error("Error fetching image policy", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch image policy", err)
 				return
 			}
 
 			imageRepoName := imagePolicy.Spec.ImageRepositoryRef.Name
-			imageRepo, err := k8sClient.GetImageRepository(context.Background(), namespace, imageRepoName)
+			imageRepo, err := k8sClient.GetImageRepository(c.Request.Context(), namespace, imageRepoName)
 			if err != nil {
-				log.Printf("Error fetching image repository: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch image repository"})
+				logging.FromContext(c).Error("Error fetching image repository", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch image repository", err)
 				return
 			}
 
 			var opts []crane.Option
 			if imageRepo.Spec.SecretRef != nil {
-				secret, err := k8sClient.GetSecret(context.Background(), namespace, imageRepo.Spec.SecretRef.Name)
+				secret, err := k8sClient.GetSecret(c.Request.Context(), namespace, imageRepo.Spec.SecretRef.Name)
 				if err != nil {
-					log.Printf("Error fetching secret: %v", err)
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch secret"})
+					logging.FromContext(c).Error("Error fetching secret", "error", err)
+					api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch secret", nil)
 					return
 				}
 
@@ -867,8 +1506,8 @@ func main() {
 				reader := bytes.NewReader(secret.Data[".dockerconfigjson"])
 				configFile, err := config.LoadFromReader(reader)
 				if err != nil {
-					log.Printf("Error loading Docker config: %v", err)
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse Docker config"})
+					logging.FromContext(c).Error("Error loading Docker config", "error", err)
+					api.RespondError(c, http.StatusInternalServerError, api.CodeInternal, "Failed to parse Docker config", err)
 					return
 				}
 
@@ -877,18 +1516,138 @@ func main() {
 				opts = append(opts, crane.WithAuthFromKeychain(keychain))
 			}
 
-			annotations, err := oci.GetImageAnnotations(context.Background(), imageRepo.Spec.Image, version, opts...)
+			annotations, err := oci.GetImageAnnotations(c.Request.Context(), imageRepo.Spec.Image, version, opts...)
 			if err != nil {
-				log.Printf("Error fetching annotations: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch annotations"})
+				logging.FromContext(c).Error("Error fetching annotations", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeRegistry, "Failed to fetch annotations", err)
 				return
 			}
 
-			c.JSON(http.StatusOK, gin.H{"annotations": annotations})
+			c.JSON(http.StatusOK, api.AnnotationsResponse{Annotations: annotations})
+		})
+
+		// Channel tags (stable, canary, nightly, ...) and where they currently point
+		v1.GET("/rollouts/:namespace/:name/channels", func(c *gin.Context) {
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
+			}
+
+			rollout, err := k8sClient.GetRollout(c.Request.Context(), c.Param("namespace"), c.Param("name"))
+			if err != nil {
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout", err)
+				return
+			}
+			image, opts, err := rolloutRegistry(c.Request.Context(), k8sClient, rollout)
+			if err != nil {
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to get image repository", err)
+				return
+			}
+
+			channels := configuredChannels()
+			tracking := kubernetes.RolloutChannel(rollout)
+			if tracking != "" && !slices.Contains(channels, tracking) {
+				channels = append(channels, tracking)
+			}
+			response := api.ChannelsResponse{Tracking: tracking, Channels: []api.ChannelStatus{}}
+			for _, channel := range channels {
+				status := resolveChannel(c.Request.Context(), rollout, image, opts, channel, channels)
+				// Channels the repository does not publish are left out unless the rollout tracks them
+				if status.Digest == "" && !status.Tracking {
+					continue
+				}
+				response.Channels = append(response.Channels, status)
+			}
+			c.JSON(http.StatusOK, response)
+		})
+
+		v1.GET("/rollouts/:namespace/:name/channels/:channel", func(c *gin.Context) {
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
+			}
+
+			rollout, err := k8sClient.GetRollout(c.Request.Context(), c.Param("namespace"), c.Param("name"))
+			if err != nil {
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout", err)
+				return
+			}
+			image, opts, err := rolloutRegistry(c.Request.Context(), k8sClient, rollout)
+			if err != nil {
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to get image repository", err)
+				return
+			}
+
+			status := resolveChannel(c.Request.Context(), rollout, image, opts, c.Param("channel"), configuredChannels())
+			if status.Digest == "" {
+				api.RespondErrorDetails(c, http.StatusNotFound, api.CodeNotFound, "Channel tag not found", status.Error)
+				return
+			}
+			c.JSON(http.StatusOK, api.ChannelResponse{Channel: status})
+		})
+
+		// Follow a channel: pin to its current release and re-pin whenever it moves
+		v1.POST("/rollouts/:namespace/:name/channel", unfrozen, func(c *gin.Context) {
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
+			}
+
+			namespace := c.Param("namespace")
+			name := c.Param("name")
+
+			var req api.TrackChannelRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid request body", err)
+				return
+			}
+
+			if req.Channel == nil || *req.Channel == "" {
+				updatedRollout, err := k8sClient.SetRolloutChannel(c.Request.Context(), namespace, name, "", "")
+				if err != nil {
+					logging.FromContext(c).Error("Error clearing rollout channel", "error", err)
+					api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to stop tracking channel", err)
+					return
+				}
+				c.JSON(http.StatusOK, api.TrackChannelResponse{Rollout: updatedRollout})
+				return
+			}
+
+			rollout, err := k8sClient.GetRollout(c.Request.Context(), namespace, name)
+			if err != nil {
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout", err)
+				return
+			}
+			image, opts, err := rolloutRegistry(c.Request.Context(), k8sClient, rollout)
+			if err != nil {
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to get image repository", err)
+				return
+			}
+
+			channel := *req.Channel
+			status := resolveChannel(c.Request.Context(), rollout, image, opts, channel, configuredChannels())
+			if status.Digest == "" {
+				api.RespondErrorDetails(c, http.StatusNotFound, api.CodeNotFound, "Channel tag not found", status.Error)
+				return
+			}
+			if status.Release == nil {
+				api.RespondErrorDetails(c, http.StatusConflict, api.CodeConflict, "Channel points at an unknown release",
+					fmt.Sprintf("no release of the rollout has digest %s", status.Digest))
+				return
+			}
+
+			updatedRollout, err := k8sClient.SetRolloutChannel(c.Request.Context(), namespace, name, channel, status.Release.Tag)
+			if err != nil {
+				logging.FromContext(c).Error("Error setting rollout channel", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to track channel", err)
+				return
+			}
+			status.Tracking, status.InSync = true, true
+			c.JSON(http.StatusOK, api.TrackChannelResponse{Rollout: updatedRollout, Channel: &status})
 		})
 
 		// New endpoint to fetch all available tags from a repository
-		api.GET("/rollouts/:namespace/:name/tags", func(c *gin.Context) {
+		v1.GET("/rollouts/:namespace/:name/tags", func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
@@ -898,36 +1657,36 @@ func main() {
 			name := c.Param("name")
 
 			// Get Rollout to get the image policy reference
-			rollout, err := k8sClient.GetRollout(context.Background(), namespace, name)
+			rollout, err := k8sClient.GetRollout(c.Request.Context(), namespace, name)
 			if err != nil {
-				log.Printf("Error fetching rollout: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rollout"})
+				logging.FromContext(c).Error("Error fetching rollout", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout", err)
 				return
 			}
 
 			// Get the ImagePolicy referenced by the rollout
 			imagePolicyName := rollout.Spec.ReleasesImagePolicy.Name
-			imagePolicy, err := k8sClient.GetImagePolicy(context.Background(), namespace, imagePolicyName)
+			imagePolicy, err := k8sClient.GetImagePolicy(c.Request.Context(), namespace, imagePolicyName)
 			if err != nil {
-				log.Printf("Error fetching image policy: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch image policy"})
+				logging.FromContext(c).Error("Error fetching image policy", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch image policy", err)
 				return
 			}
 
 			imageRepoName := imagePolicy.Spec.ImageRepositoryRef.Name
-			imageRepo, err := k8sClient.GetImageRepository(context.Background(), namespace, imageRepoName)
+			imageRepo, err := k8sClient.GetImageRepository(c.Request.Context(), namespace, imageRepoName)
 			if err != nil {
-				log.Printf("Error fetching image repository: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch image repository"})
+				logging.FromContext(c).Error("Error fetching image repository", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch image repository", err)
 				return
 			}
 
 			var opts []crane.Option
 			if imageRepo.Spec.SecretRef != nil {
-				secret, err := k8sClient.GetSecret(context.Background(), namespace, imageRepo.Spec.SecretRef.Name)
+				secret, err := k8sClient.GetSecret(c.Request.Context(), namespace, imageRepo.Spec.SecretRef.Name)
 				if err != nil {
-					log.Printf("Error fetching secret: %v", err)
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch secret"})
+					logging.FromContext(c).Error("Error fetching secret", "error", err)
+					api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch secret", nil)
 					return
 				}
 
@@ -935,8 +1694,8 @@ func main() {
 				reader := bytes.NewReader(secret.Data[".dockerconfigjson"])
 				configFile, err := config.LoadFromReader(reader)
 				if err != nil {
-					log.Printf("Error loading Docker config: %v", err)
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse Docker config"})
+					logging.FromContext(c).Error("Error loading Docker config", "error", err)
+					api.RespondError(c, http.StatusInternalServerError, api.CodeInternal, "Failed to parse Docker config", err)
 					return
 				}
 
@@ -946,17 +1705,17 @@ func main() {
 			}
 
 			// Get all tags from the repository
-			tags, err := oci.ListRepositoryTags(context.Background(), imageRepo.Spec.Image, opts...)
+			tags, err := oci.ListRepositoryTags(c.Request.Context(), imageRepo.Spec.Image, opts...)
 			if err != nil {
-				log.Printf("Error fetching repository tags: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch repository tags"})
+				logging.FromContext(c).Error("Error fetching repository tags", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeRegistry, "Failed to fetch repository tags", err)
 				return
 			}
 
-			c.JSON(http.StatusOK, gin.H{"tags": tags})
+			c.JSON(http.StatusOK, api.TagsResponse{Tags: tags})
 		})
 
-		api.GET("/kustomizations/:namespace/:name/managed-resources", func(c *gin.Context) {
+		v1.GET("/kustomizations/:namespace/:name/managed-resources", func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
@@ -966,49 +1725,55 @@ func main() {
 			name := c.Param("name")
 
 			// Get the Kustomization first to check its inventory
-			kustomization, err := k8sClient.GetKustomization(context.Background(), namespace, name)
+			kustomization, err := k8sClient.GetKustomization(c.Request.Context(), namespace, name)
 			if err != nil {
-				log.Printf("Error fetching kustomization: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to fetch kustomization",
-					"details": err.Error(),
-				})
+				logging.FromContext(c).Error("Error fetching kustomization", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch kustomization", err)
 				return
 			}
 
-			// Get managed resources for the Kustomization
-			managedResources, err := k8sClient.GetKustomizationManagedResources(context.Background(), namespace, name)
+			// Get managed resources for the Kustomization with the objects they own, diffed against the
+			// source artifact on request
+			opts := []kubernetes.ManagedResourcesOption{kubernetes.WithResourceTree()}
+			if drift, _ := strconv.ParseBool(c.Query("drift")); drift {
+				opts = append(opts, kubernetes.WithDriftDetection())
+			}
+			managedResources, err := k8sClient.GetKustomizationManagedResources(c.Request.Context(), namespace, name, opts...)
 			if err != nil {
-				log.Printf("Error fetching managed resources: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to fetch managed resources",
-					"details": err.Error(),
+				logging.FromContext(c).Error("Error fetching managed resources", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch managed resources", err)
+				return
+			}
+
+			if c.Query("view") == "summary" {
+				c.JSON(http.StatusOK, api.ManagedResourceSummaryListResponse{
+					ManagedResources: api.NewManagedResources(managedResources),
 				})
 				return
 			}
 
 			// Add debug information
-			response := gin.H{
-				"managedResources": managedResources,
-				"debug": gin.H{
-					"hasInventory": kustomization.Status.Inventory != nil,
-					"inventoryEntries": func() []string {
-						if kustomization.Status.Inventory == nil {
-							return []string{}
-						}
-						entries := make([]string, len(kustomization.Status.Inventory.Entries))
-						for i, entry := range kustomization.Status.Inventory.Entries {
-							entries[i] = entry.ID
-						}
-						return entries
-					}(),
-				},
+			debug := api.ManagedResourcesDebug{
+				HasInventory:     kustomization.Status.Inventory != nil,
+				InventoryEntries: []string{},
+			}
+			if kustomization.Status.Inventory != nil {
+				for _, entry := range kustomization.Status.Inventory.Entries {
+					debug.InventoryEntries = append(debug.InventoryEntries, entry.ID)
+				}
 			}
 
-			c.JSON(http.StatusOK, response)
+			c.JSON(http.StatusOK, api.ManagedResourcesResponse{
+				ManagedResources: managedResources,
+				Debug:            debug,
+			})
 		})
 
-		api.GET("/kustomizations/:namespace/:name/test", func(c *gin.Context) {
+		// Get a resource applied by a Kustomization as YAML, or its status, conditions and events
+		v1.GET("/resources/:namespace/:kind/:name/yaml", getResourceYAML)
+		v1.GET("/resources/:namespace/:kind/:name/describe", describeResource)
+
+		v1.GET("/kustomizations/:namespace/:name/test", func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
@@ -1018,24 +1783,21 @@ func main() {
 			name := c.Param("name")
 
 			// Get the Kustomization
-			kustomization, err := k8sClient.GetKustomization(context.Background(), namespace, name)
+			kustomization, err := k8sClient.GetKustomization(c.Request.Context(), namespace, name)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to fetch kustomization",
-					"details": err.Error(),
-				})
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch kustomization", err)
 				return
 			}
 
-			c.JSON(http.StatusOK, gin.H{
-				"name":         kustomization.Name,
-				"namespace":    kustomization.Namespace,
-				"hasInventory": kustomization.Status.Inventory != nil,
+			c.JSON(http.StatusOK, api.KustomizationTestResponse{
+				Name:         kustomization.Name,
+				Namespace:    kustomization.Namespace,
+				HasInventory: kustomization.Status.Inventory != nil,
 			})
 		})
 
 		// Get child resources (ReplicaSets + Pods) for a Deployment
-		api.GET("/namespaces/:namespace/deployments/:name/children", func(c *gin.Context) {
+		v1.GET("/namespaces/:namespace/deployments/:name/children", func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
@@ -1043,13 +1805,13 @@ func main() {
 
 			namespace := c.Param("namespace")
 			name := c.Param("name")
-			ctx := context.Background()
+			ctx := c.Request.Context()
 			clientset := k8sClient.GetClientset()
 
 			// Get the Deployment to get its UID and selector
 			deployment, err := clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
 			if err != nil {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Deployment not found"})
+				api.RespondError(c, http.StatusNotFound, api.CodeNotFound, "Deployment not found", err)
 				return
 			}
 
@@ -1058,33 +1820,11 @@ func main() {
 			// Get all ReplicaSets in namespace and filter by owner
 			allRS, err := clientset.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{})
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list ReplicaSets"})
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to list ReplicaSets", err)
 				return
 			}
 
-			type PodInfo struct {
-				Name        string   `json:"name"`
-				Namespace   string   `json:"namespace"`
-				Phase       string   `json:"phase"`
-				Ready       bool     `json:"ready"`
-				Terminating bool     `json:"terminating"`
-				Restarts    int32    `json:"restarts"`
-				Node        string   `json:"node"`
-				Age         string   `json:"age"`
-				Images      []string `json:"images"`
-				Message     string   `json:"message,omitempty"`
-			}
-			type RSInfo struct {
-				Name            string    `json:"name"`
-				Namespace       string    `json:"namespace"`
-				Replicas        int32     `json:"replicas"`
-				ReadyReplicas   int32     `json:"readyReplicas"`
-				DesiredReplicas int32     `json:"desiredReplicas"`
-				IsCurrentRS     bool      `json:"isCurrentRS"`
-				Pods            []PodInfo `json:"pods"`
-			}
-
-			var replicaSets []RSInfo
+			var replicaSets []api.ReplicaSetInfo
 			currentRSRevision := deployment.Annotations["deployment.kubernetes.io/revision"]
 
 			for _, rs := range allRS.Items {
@@ -1108,7 +1848,7 @@ func main() {
 					continue
 				}
 
-				pods := []PodInfo{}
+				pods := []api.PodInfo{}
 				for _, pod := range allPods.Items {
 					isPodOwned := false
 					for _, ownerRef := range pod.OwnerReferences {
@@ -1180,7 +1920,7 @@ func main() {
 						}
 					}
 
-					pods = append(pods, PodInfo{
+					pods = append(pods, api.PodInfo{
 						Name:        pod.Name,
 						Namespace:   pod.Namespace,
 						Phase:       string(pod.Status.Phase),
@@ -1199,7 +1939,7 @@ func main() {
 					desiredReplicas = *rs.Spec.Replicas
 				}
 
-				replicaSets = append(replicaSets, RSInfo{
+				replicaSets = append(replicaSets, api.ReplicaSetInfo{
 					Name:            rs.Name,
 					Namespace:       rs.Namespace,
 					Replicas:        rs.Status.Replicas,
@@ -1211,25 +1951,25 @@ func main() {
 			}
 
 			if replicaSets == nil {
-				replicaSets = []RSInfo{}
-			}
-
-			c.JSON(http.StatusOK, gin.H{
-				"replicaSets": replicaSets,
-				"deployment": map[string]interface{}{
-					"name":              deployment.Name,
-					"namespace":         deployment.Namespace,
-					"replicas":          deployment.Status.Replicas,
-					"readyReplicas":     deployment.Status.ReadyReplicas,
-					"updatedReplicas":   deployment.Status.UpdatedReplicas,
-					"availableReplicas": deployment.Status.AvailableReplicas,
+				replicaSets = []api.ReplicaSetInfo{}
+			}
+
+			c.JSON(http.StatusOK, api.DeploymentChildrenResponse{
+				ReplicaSets: replicaSets,
+				Deployment: api.DeploymentInfo{
+					Name:              deployment.Name,
+					Namespace:         deployment.Namespace,
+					Replicas:          deployment.Status.Replicas,
+					ReadyReplicas:     deployment.Status.ReadyReplicas,
+					UpdatedReplicas:   deployment.Status.UpdatedReplicas,
+					AvailableReplicas: deployment.Status.AvailableReplicas,
 				},
 			})
 		})
 
 		// New endpoint to fetch health checks for a rollout
 		// Check permissions for a rollout action
-		api.GET("/rollouts/:namespace/:name/permissions", func(c *gin.Context) {
+		v1.GET("/rollouts/:namespace/:name/permissions", func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
@@ -1239,30 +1979,101 @@ func main() {
 			name := c.Param("name")
 			verb := c.DefaultQuery("verb", "update") // Default to "update" for most actions
 
-			allowed, err := k8sClient.CheckRolloutPermission(context.Background(), verb, namespace, name)
+			allowed, err := k8sClient.CheckRolloutPermission(c.Request.Context(), verb, namespace, name)
 			if err != nil {
-				log.Printf("Error checking permission: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to check permission",
-					"details": err.Error(),
-				})
+				logging.FromContext(c).Error("Error checking permission", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to check permission", err)
 				return
 			}
 
-			c.JSON(http.StatusOK, gin.H{
-				"allowed": allowed,
-				"verb":    verb,
-				"resource": gin.H{
-					"apiGroup":  "kuberik.com",
-					"kind":      "Rollout",
-					"name":      name,
-					"namespace": namespace,
+			c.JSON(http.StatusOK, api.PermissionResponse{
+				Allowed: allowed,
+				Verb:    verb,
+				Resource: api.ResourceRef{
+					APIGroup:  "kuberik.com",
+					Kind:      "Rollout",
+					Name:      name,
+					Namespace: namespace,
 				},
 			})
 		})
 
+		// Identity of the caller and the dashboard actions they may perform in a namespace, so the UI
+		// can hide buttons up front instead of checking each rollout
+		v1.GET("/me", func(c *gin.Context) {
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
+			}
+
+			namespace := c.DefaultQuery("namespace", "default")
+
+			userInfo, err := k8sClient.GetCurrentUserInfo(c.Request.Context())
+			if err != nil {
+				logging.FromContext(c).Error("Error getting user identity", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to get user identity", err)
+				return
+			}
+			capabilities, incomplete, err := k8sClient.GetCapabilities(c.Request.Context(), namespace)
+			if err != nil {
+				logging.FromContext(c).Error("Error reviewing user rules", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to review user permissions", err)
+				return
+			}
+
+			groups := userInfo.Groups
+			if groups == nil {
+				groups = []string{}
+			}
+			c.JSON(http.StatusOK, api.MeResponse{
+				Username:       userInfo.Username,
+				Groups:         groups,
+				ServiceAccount: kubernetes.IsServiceAccount(userInfo.Username),
+				Namespace:      namespace,
+				Capabilities:   capabilities,
+				Incomplete:     incomplete,
+			})
+		})
+
+		// Admin API: the outside services the dashboard calls, a live test of their credentials and
+		// a reload after the credentials were rotated
+		v1.GET("/admin/integrations", requireAdmin, func(c *gin.Context) {
+			c.JSON(http.StatusOK, api.IntegrationsResponse{Integrations: admin.statuses()})
+		})
+
+		// Open SSE streams with when they last wrote and how many messages they dropped
+		v1.GET("/admin/streams", requireAdmin, func(c *gin.Context) {
+			c.JSON(http.StatusOK, api.StreamsResponse{Streams: streams.Streams()})
+		})
+
+		v1.POST("/admin/integrations/:integration/test", requireAdmin, func(c *gin.Context) {
+			name := c.Param("integration")
+			status, ok := admin.status(name)
+			if !ok || !status.Configured {
+				api.RespondErrorDetails(c, http.StatusNotFound, api.CodeNotFound, "Integration not configured",
+					fmt.Sprintf("integration %q is not configured", name))
+				return
+			}
+
+			result := admin.test(c.Request.Context(), name)
+			logging.FromContext(c).With("audit", true, "user", requestUser(c)).Info("Tested integration",
+				"integration", name, "ok", result.OK)
+			status, _ = admin.status(name)
+			c.JSON(http.StatusOK, status)
+		})
+
+		v1.POST("/admin/integrations/reload", requireAdmin, func(c *gin.Context) {
+			ok := admin.reload(c.Request.Context())
+			logging.FromContext(c).With("audit", true, "user", requestUser(c)).Info("Reloaded integrations", "ok", ok)
+			status := http.StatusOK
+			if !ok {
+				status = http.StatusMultiStatus
+			}
+			c.JSON(status, api.IntegrationsResponse{Integrations: admin.statuses()})
+		})
+
 		// Check permissions for all common rollout actions
-		api.GET("/rollouts/:namespace/:name/permissions/all", func(c *gin.Context) {
+		v1.GET("/rollouts/:namespace/:name/permissions/all", func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
@@ -1279,80 +2090,299 @@ func main() {
 
 			permissions := make(map[string]bool)
 			for action, verb := range actions {
-				allowed, err := k8sClient.CheckRolloutPermission(context.Background(), verb, namespace, name)
+				allowed, err := k8sClient.CheckRolloutPermission(c.Request.Context(), verb, namespace, name)
 				if err != nil {
-					log.Printf("Error checking permission for %s: %v", action, err)
+					logging.FromContext(c).Warn("Error checking permission", "action", action, "error", err)
 					permissions[action] = false
 				} else {
 					permissions[action] = allowed
 				}
 			}
 
-			c.JSON(http.StatusOK, gin.H{
-				"permissions": permissions,
-				"resource": gin.H{
-					"apiGroup":  "kuberik.com",
-					"kind":      "Rollout",
-					"name":      name,
-					"namespace": namespace,
+			c.JSON(http.StatusOK, api.PermissionsResponse{
+				Permissions: permissions,
+				Resource: api.ResourceRef{
+					APIGroup:  "kuberik.com",
+					Kind:      "Rollout",
+					Name:      name,
+					Namespace: namespace,
 				},
 			})
 		})
 
-		api.GET("/rollouts/:namespace/:name/health-checks", func(c *gin.Context) {
+		// Check in one call whether the user may act on every rollout of an environment, so a
+		// promotion is only offered when it cannot fail halfway through
+		v1.GET("/environments/:environment/permissions", func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
 			}
 
-			namespace := c.Param("namespace")
-			name := c.Param("name")
+			environment := c.Param("environment")
+			verb := c.DefaultQuery("verb", "update")
 
-			// Get Rollout to get the health check selector
-			rollout, err := k8sClient.GetRollout(context.Background(), namespace, name)
+			environments, err := k8sClient.GetEnvironmentsAllNamespaces(c.Request.Context())
 			if err != nil {
-				log.Printf("Error fetching rollout: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to fetch rollout",
-					"details": err.Error(),
-				})
+				logging.FromContext(c).Error("Error fetching environments", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch environments", err)
+				return
+			}
+			environments.Items = filterAnonymous(c, environments.Items, func(env envv1alpha1.Environment) string { return env.Namespace })
+			rollouts := kubernetes.EnvironmentRollouts(environments.Items, environment, c.Query("name"))
+			if len(rollouts) == 0 {
+				api.RespondErrorDetails(c, http.StatusNotFound, api.CodeNotFound, "No rollouts in environment",
+					fmt.Sprintf("no Environment with environment %q references a rollout", environment))
 				return
 			}
 
-			// Get health checks that match the rollout's health selector
-			healthChecks, err := k8sClient.GetHealthChecksBySelector(context.Background(), namespace, rollout.Spec.HealthCheckSelector)
+			denied, err := k8sClient.CheckRolloutPermissions(c.Request.Context(), verb, rollouts)
 			if err != nil {
-				log.Printf("Error fetching health checks: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to fetch health checks",
-					"details": err.Error(),
-				})
+				logging.FromContext(c).Error("Error checking permissions", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to check permissions", err)
 				return
 			}
 
-			// Add debug information about namespace search
-			debugInfo := gin.H{
-				"rolloutNamespace":       namespace,
-				"hasHealthCheckSelector": rollout.Spec.HealthCheckSelector != nil,
-			}
+			c.JSON(http.StatusOK, api.EnvironmentPermissionsResponse{
+				Environment: environment,
+				Verb:        verb,
+				Allowed:     len(denied) == 0,
+				Rollouts:    rolloutResourceRefs(rollouts),
+				Denied:      rolloutResourceRefs(denied),
+			})
+		})
+
+		// Promote the version that is healthy in the source environment (e.g. staging) to every
+		// rollout of the environment, pinning or force deploying it in one step
+		v1.POST("/environments/:environment/promote", func(c *gin.Context) {
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
+			}
+
+			environment := c.Param("environment")
+
+			var req api.PromoteRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid request body", err)
+				return
+			}
+			if req.Mode != "" && req.Mode != "pin" && req.Mode != "force-deploy" {
+				api.RespondErrorDetails(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid mode", "mode must be pin or force-deploy")
+				return
+			}
+
+			environments, err := k8sClient.GetEnvironmentsAllNamespaces(c.Request.Context())
+			if err != nil {
+				logging.FromContext(c).Error("Error fetching environments", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch environments", err)
+				return
+			}
+			environments.Items = filterAnonymous(c, environments.Items, func(env envv1alpha1.Environment) string { return env.Namespace })
+			promotions := kubernetes.Promotions(environments.Items, environment, req.From, req.Name)
+			if len(promotions) == 0 {
+				api.RespondErrorDetails(c, http.StatusNotFound, api.CodeNotFound, "No rollouts in environment",
+					fmt.Sprintf("no Environment with environment %q references a rollout", environment))
+				return
+			}
+
+			frozen, ok := newFreezeCheck(c, k8sClient, freezes)
+			if !ok {
+				return
+			}
+			response := api.PromoteResponse{Environment: environment}
+			logger := logging.FromContext(c).With("audit", true, "user", requestUser(c))
+			for _, promotion := range promotions {
+				result := promote(c.Request.Context(), k8sClient, promotion, req.Mode != "force-deploy", req.Message, frozen)
+				logger.Info("Promoted version", "environment", environment, "from", promotion.SourceEnvironment,
+					"namespace", promotion.Target.Namespace, "rollout", promotion.Target.Name, "version", result.Version, "error", result.Error)
+				if result.Code == "" {
+					response.Promoted++
+				} else {
+					response.Failed++
+				}
+				response.Results = append(response.Results, result)
+			}
+			if req.Reconcile && response.Promoted > 0 {
+				response.Reconcile = syncPromoted(c.Request.Context(), k8sClient, response.Results)
+			}
+
+			// Partial failures are reported per rollout with 207 Multi-Status
+			status := http.StatusOK
+			if response.Failed > 0 || (response.Reconcile != nil && (response.Reconcile.Failed > 0 || response.Reconcile.Skipped > 0 || response.Reconcile.Error != "")) {
+				status = http.StatusMultiStatus
+			}
+			c.JSON(status, response)
+		})
+
+		// Pins and overrides of an environment's rollouts as a declarative document, and applying
+		// such a document again, e.g. in disaster recovery runbooks or to clone an environment
+		v1.GET("/environments/:environment/overrides", exportEnvironmentOverrides)
+		v1.PUT("/environments/:environment/overrides", func(c *gin.Context) {
+			applyEnvironmentOverrides(c, requestUser(c), freezes)
+		})
+
+		// Reconcile the Flux objects of every rollout of an environment in dependency order,
+		// streaming progress, instead of requesting them all at once
+		v1.POST("/environments/:environment/reconcile", limiter.Streams(), func(c *gin.Context) {
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
+			}
+			streamEnvironmentReconcile(c, k8sClient, streams, freezes, requestUser(c))
+		})
+
+		// Versions an application (the deployment name its Environments share) runs in each
+		// environment, for a promotion board
+		v1.GET("/applications/:name/environments", func(c *gin.Context) {
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
+			}
+
+			application := c.Param("name")
+			response, err := getApplicationEnvironments(c.Request.Context(), k8sClient, application, anonymousNamespaces(c))
+			if err != nil {
+				logging.FromContext(c).Error("Error fetching environments", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch environments", err)
+				return
+			}
+			if response == nil {
+				api.RespondErrorDetails(c, http.StatusNotFound, api.CodeNotFound, "Application not found",
+					fmt.Sprintf("no Environment with name %q", application))
+				return
+			}
+			c.JSON(http.StatusOK, response)
+		})
+
+		v1.GET("/rollouts/:namespace/:name/health-checks", func(c *gin.Context) {
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
+			}
+
+			namespace := c.Param("namespace")
+			name := c.Param("name")
+
+			// Get Rollout to get the health check selector
+			rollout, err := k8sClient.GetRollout(c.Request.Context(), namespace, name)
+			if err != nil {
+				logging.FromContext(c).Error("Error fetching rollout", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout", err)
+				return
+			}
+
+			// Get health checks that match the rollout's health selector
+			healthChecks, err := k8sClient.GetHealthChecksBySelector(c.Request.Context(), namespace, rollout.Spec.HealthCheckSelector)
+			if err != nil {
+				logging.FromContext(c).Error("Error fetching health checks", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch health checks", err)
+				return
+			}
+
+			// Add debug information about namespace search
+			debugInfo := api.HealthChecksDebug{
+				RolloutNamespace:       namespace,
+				HasHealthCheckSelector: rollout.Spec.HealthCheckSelector != nil,
+			}
 
 			if rollout.Spec.HealthCheckSelector != nil {
-				debugInfo["hasNamespaceSelector"] = rollout.Spec.HealthCheckSelector.NamespaceSelector != nil
-				if rollout.Spec.HealthCheckSelector.NamespaceSelector != nil {
-					debugInfo["namespaceSelectorType"] = "configured"
+				hasNamespaceSelector := rollout.Spec.HealthCheckSelector.NamespaceSelector != nil
+				debugInfo.HasNamespaceSelector = &hasNamespaceSelector
+				if hasNamespaceSelector {
+					debugInfo.NamespaceSelectorType = "configured"
 				} else {
-					debugInfo["namespaceSelectorType"] = "current namespace only"
+					debugInfo.NamespaceSelectorType = "current namespace only"
 				}
 			}
 
-			c.JSON(http.StatusOK, gin.H{
-				"healthChecks": healthChecks,
-				"debug":        debugInfo,
+			c.JSON(http.StatusOK, api.HealthChecksResponse{
+				HealthChecks: healthChecks,
+				Debug:        debugInfo,
+			})
+		})
+
+		// Chart metrics of the rollout's pods, e.g. the error rate next to the bake status
+		v1.GET("/rollouts/:namespace/:name/metrics", func(c *gin.Context) {
+			getRolloutMetrics(c, metrics)
+		})
+
+		// Firing alerts about the rollout's workloads, to check before marking a deployment successful
+		v1.GET("/rollouts/:namespace/:name/alerts", func(c *gin.Context) {
+			getRolloutAlerts(c, alerts)
+		})
+
+		// Files of monorepo artifacts by the components the rollout maps sub-paths to
+		v1.GET("/rollouts/:namespace/:name/components", getRolloutComponents)
+		v1.GET("/rollouts/:namespace/:name/components/diff", diffRolloutComponents)
+		v1.GET("/rollouts/:namespace/:name/changelog", getRolloutChangelog)
+		v1.GET("/rollouts/:namespace/:name/commit/:version", func(c *gin.Context) {
+			getRolloutCommit(c, commits)
+		})
+		v1.GET("/rollouts/:namespace/:name/release-notes", func(c *gin.Context) {
+			getRolloutReleaseNotes(c, notes, commits)
+		})
+
+		// Dry-run the manifests of a candidate release against the cluster before promoting it
+		v1.GET("/rollouts/:namespace/:name/validate/:version", validateRolloutVersion)
+
+		// List HealthChecks, filtered by namespace, class and labels
+		v1.GET("/health-checks", func(c *gin.Context) {
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
+			}
+
+			namespace := c.DefaultQuery("namespace", "all")
+			if namespace == "all" || namespace == "*" {
+				namespace = ""
+			}
+			selector, err := labels.Parse(c.Query("labelSelector"))
+			if err != nil {
+				api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid label selector", err)
+				return
+			}
+
+			healthChecks, err := k8sClient.ListHealthChecks(c.Request.Context(), namespace, selector, c.Query("class"))
+			if err != nil {
+				logging.FromContext(c).Error("Error listing health checks", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to list health checks", err)
+				return
+			}
+			healthChecks = filterAnonymous(c, healthChecks, func(healthCheck rolloutv1alpha1.HealthCheck) string { return healthCheck.Namespace })
+
+			c.JSON(http.StatusOK, api.HealthCheckListResponse{HealthChecks: healthChecks})
+		})
+
+		// Get a HealthCheck with its recent status transitions and last error
+		v1.GET("/health-checks/:namespace/:name", func(c *gin.Context) {
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
+			}
+
+			healthCheck, err := k8sClient.GetHealthCheck(c.Request.Context(), c.Param("namespace"), c.Param("name"))
+			if err != nil {
+				logging.FromContext(c).Error("Error fetching health check", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch health check", err)
+				return
+			}
+
+			transitions, err := k8sClient.GetHealthCheckTransitions(c.Request.Context(), healthCheck)
+			if err != nil {
+				logging.FromContext(c).Error("Error fetching health check transitions", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch health check transitions", err)
+				return
+			}
+
+			c.JSON(http.StatusOK, api.HealthCheckDetailResponse{
+				HealthCheck: *healthCheck,
+				Transitions: api.NewHealthCheckTransitions(transitions),
+				LastError:   api.NewHealthCheckError(kubernetes.LastHealthCheckError(healthCheck, transitions)),
 			})
 		})
 
 		// Get events for a specific rollout
-		api.GET("/rollouts/:namespace/:name/events", func(c *gin.Context) {
+		v1.GET("/rollouts/:namespace/:name/events", func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
@@ -1360,17 +2390,102 @@ func main() {
 			namespace := c.Param("namespace")
 			name := c.Param("name")
 
-			events, err := k8sClient.GetEventsForRollout(context.Background(), namespace, name)
+			events, err := k8sClient.GetEventsForRollout(c.Request.Context(), namespace, name)
 			if err != nil {
-				log.Printf("Error fetching events: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch events", "details": err.Error()})
+				logging.FromContext(c).Error("Error fetching events", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch events", err)
 				return
 			}
-			c.JSON(http.StatusOK, gin.H{"events": events})
+			c.JSON(http.StatusOK, api.EventsResponse{Events: events})
+		})
+
+		// Deployment history extended with deployments reconstructed from Flux and registry data
+		v1.GET("/rollouts/:namespace/:name/history/reconstructed", func(c *gin.Context) {
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
+			}
+
+			history, err := getRolloutHistory(c.Request.Context(), logging.FromContext(c), k8sClient, c.Param("namespace"), c.Param("name"))
+			if err != nil {
+				logging.FromContext(c).Error("Error fetching rollout", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout", err)
+				return
+			}
+			c.JSON(http.StatusOK, history)
+		})
+
+		// Stream Warning events of the rollout's Flux objects (sources, Kustomizations, image
+		// automation) from flux-system and the rollout namespaces using Server-Sent Events
+		v1.GET("/rollouts/:namespace/:name/flux-events/stream", limiter.Streams(), func(c *gin.Context) {
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
+			}
+
+			namespace := c.Param("namespace")
+			name := c.Param("name")
+
+			// Check the rollout exists before switching to SSE so a missing rollout is a plain error response
+			if _, err := k8sClient.GetRollout(c.Request.Context(), namespace, name); err != nil {
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout", err)
+				return
+			}
+
+			c.Header("Content-Type", sse.ContentType)
+			c.Header("Cache-Control", "no-cache")
+			c.Header("Connection", "keep-alive")
+			c.Header("X-Accel-Buffering", "no")
+
+			ctx, cancel := server.StreamContext(c.Request.Context())
+			defer cancel()
+			ctx, stream := streams.Start(ctx, c, requestUser(c))
+			defer stream.Close()
+
+			logger := logging.FromContext(c)
+			events := make(chan api.Event, 64)
+			go func() {
+				defer close(events)
+				err := k8sClient.StreamRolloutWarningEvents(ctx, namespace, name, func(event corev1.Event) {
+					select {
+					case events <- api.NewEvent(event):
+					case <-ctx.Done():
+					}
+				})
+				if err != nil {
+					logger.Error("Error streaming flux events", "error", err)
+				}
+			}()
+
+			if flusher, ok := c.Writer.(http.Flusher); ok {
+				flusher.Flush()
+			}
+
+			ticker := time.NewTicker(10 * time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					stream.Send(sse.Event{Event: "ping", Data: "{}"})
+				case event, ok := <-events:
+					if !ok {
+						return
+					}
+					data, err := json.Marshal(event)
+					if err != nil {
+						continue
+					}
+					if err := stream.Send(sse.Event{Event: "event", Data: string(data)}); err != nil {
+						return
+					}
+				}
+			}
 		})
 
 		// Get schedules for a specific rollout
-		api.GET("/rollouts/:namespace/:name/schedules", func(c *gin.Context) {
+		v1.GET("/rollouts/:namespace/:name/schedules", func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
@@ -1380,47 +2495,41 @@ func main() {
 			name := c.Param("name")
 
 			// Get the rollout to get its labels
-			rollout, err := k8sClient.GetRollout(context.Background(), namespace, name)
+			rollout, err := k8sClient.GetRollout(c.Request.Context(), namespace, name)
 			if err != nil {
-				log.Printf("Error fetching rollout: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to fetch rollout",
-					"details": err.Error(),
-				})
+				logging.FromContext(c).Error("Error fetching rollout", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout", err)
 				return
 			}
 
 			// Get the namespace to get its labels
-			namespaceObj, err := k8sClient.GetClientset().CoreV1().Namespaces().Get(context.Background(), namespace, metav1.GetOptions{})
+			namespaceObj, err := k8sClient.GetClientset().CoreV1().Namespaces().Get(c.Request.Context(), namespace, metav1.GetOptions{})
 			if err != nil {
-				log.Printf("Error fetching namespace: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to fetch namespace",
-					"details": err.Error(),
-				})
+				logging.FromContext(c).Error("Error fetching namespace", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch namespace", err)
 				return
 			}
 
 			// Get RolloutSchedules in this namespace that match the rollout
-			rolloutSchedules, err := k8sClient.GetRolloutSchedulesByRollout(context.Background(), namespace, name, rollout.Labels)
+			rolloutSchedules, err := k8sClient.GetRolloutSchedulesByRollout(c.Request.Context(), namespace, name, rollout.Labels)
 			if err != nil {
-				log.Printf("Error fetching rollout schedules: %v", err)
+				logging.FromContext(c).Warn("Error fetching rollout schedules", "error", err)
 			}
 
 			// Get ClusterRolloutSchedules that match the rollout
-			clusterSchedules, err := k8sClient.GetClusterRolloutSchedulesByRollout(context.Background(), namespace, name, rollout.Labels, namespaceObj.Labels)
+			clusterSchedules, err := k8sClient.GetClusterRolloutSchedulesByRollout(c.Request.Context(), namespace, name, rollout.Labels, namespaceObj.Labels)
 			if err != nil {
-				log.Printf("Error fetching cluster rollout schedules: %v", err)
+				logging.FromContext(c).Warn("Error fetching cluster rollout schedules", "error", err)
 			}
 
-			c.JSON(http.StatusOK, gin.H{
-				"rolloutSchedules":        rolloutSchedules,
-				"clusterRolloutSchedules": clusterSchedules,
+			c.JSON(http.StatusOK, api.SchedulesResponse{
+				RolloutSchedules:        rolloutSchedules,
+				ClusterRolloutSchedules: clusterSchedules,
 			})
 		})
 
 		// Get all schedules in a namespace
-		api.GET("/schedules", func(c *gin.Context) {
+		v1.GET("/schedules", func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
@@ -1432,34 +2541,320 @@ func main() {
 			var err error
 
 			if namespace == "all" || namespace == "*" || namespace == "" {
-				rolloutSchedules, err = k8sClient.GetRolloutSchedulesAllNamespaces(context.Background())
+				rolloutSchedules, err = k8sClient.GetRolloutSchedulesAllNamespaces(c.Request.Context())
 			} else {
-				rolloutSchedules, err = k8sClient.GetRolloutSchedules(context.Background(), namespace)
+				rolloutSchedules, err = k8sClient.GetRolloutSchedules(c.Request.Context(), namespace)
 			}
 
 			if err != nil {
-				log.Printf("Error fetching rollout schedules: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to fetch rollout schedules",
-					"details": err.Error(),
-				})
+				logging.FromContext(c).Error("Error fetching rollout schedules", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout schedules", err)
 				return
 			}
+			rolloutSchedules.Items = filterAnonymous(c, rolloutSchedules.Items, func(schedule rolloutv1alpha1.RolloutSchedule) string {
+				return schedule.Namespace
+			})
 
 			// Always get cluster schedules (they're cluster-scoped)
-			clusterSchedules, err := k8sClient.GetClusterRolloutSchedules(context.Background())
+			clusterSchedules, err := k8sClient.GetClusterRolloutSchedules(c.Request.Context())
 			if err != nil {
-				log.Printf("Error fetching cluster schedules: %v", err)
+				logging.FromContext(c).Warn("Error fetching cluster schedules", "error", err)
 			}
 
-			c.JSON(http.StatusOK, gin.H{
-				"rolloutSchedules":        rolloutSchedules,
-				"clusterRolloutSchedules": clusterSchedules,
+			c.JSON(http.StatusOK, api.SchedulesResponse{
+				RolloutSchedules:        rolloutSchedules,
+				ClusterRolloutSchedules: clusterSchedules,
 			})
 		})
 
+		// Find which rollouts currently run a given image (tag/digest) or source revision, or rank
+		// rollouts against a free-text query
+		v1.GET("/search", func(c *gin.Context) {
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
+			}
+
+			if c.Query("q") != "" {
+				searchRollouts(c, k8sClient)
+				return
+			}
+			searchDeployedVersions(c, k8sClient)
+		})
+
+		// List the pods whose logs the log stream would show, without opening the stream
+		v1.GET("/rollouts/:namespace/:name/pods", func(c *gin.Context) {
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
+			}
+
+			filterType := c.DefaultQuery("type", "")
+			if filterType != "" && filterType != "pod" && filterType != "test" {
+				api.RespondErrorDetails(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid pod type", "type must be pod or test")
+				return
+			}
+			track := c.Query("track")
+			if track != "" && track != kubernetes.TrackCanary && track != kubernetes.TrackStable {
+				api.RespondErrorDetails(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid track", "track must be canary or stable")
+				return
+			}
+
+			// All versions are listed, so old and new pods are both visible during a rollout
+			discovery := logs.NewPodDiscovery(k8sClient, c.Param("namespace"), c.Param("name"), "", filterType).WithTrack(track)
+			discovered, err := discovery.DiscoverPods(c.Request.Context())
+			if err != nil {
+				logging.FromContext(c).Error("Error discovering pods", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to discover pods", err)
+				return
+			}
+
+			pods := make([]api.Pod, 0, len(discovered))
+			for _, pod := range discovered {
+				apiPod := api.NewPod(pod.Pod, pod.Type)
+				apiPod.Track = pod.Track
+				pods = append(pods, apiPod)
+			}
+			c.JSON(http.StatusOK, api.PodsResponse{Pods: pods})
+		})
+
+		// Restart a pod by deleting it, so its controller replaces it
+		v1.POST("/pods/:namespace/:name/restart", func(c *gin.Context) {
+			// Requests without a user token would delete pods as the service account
+			if share.FromContext(c) != nil {
+				api.RespondErrorDetails(c, http.StatusForbidden, api.CodeForbidden, "Not allowed to restart pod", "share links do not grant pod restarts")
+				return
+			}
+			if isAnonymous(c) {
+				api.RespondErrorDetails(c, http.StatusUnauthorized, api.CodeUnauthorized, "Authentication required", "restarting pods requires a user token")
+				return
+			}
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
+			}
+
+			namespace := c.Param("namespace")
+			name := c.Param("name")
+
+			// Check up front, so the caller gets a clear answer instead of a failed deletion
+			allowed, err := k8sClient.CheckPermission(c.Request.Context(), "", "pods", "delete", namespace, name)
+			if err != nil {
+				logging.FromContext(c).Error("Error checking permission", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to check permission", err)
+				return
+			}
+			if !allowed {
+				api.RespondErrorDetails(c, http.StatusForbidden, api.CodeForbidden, "Not allowed to restart pod",
+					fmt.Sprintf("deleting pods in namespace %s is not permitted", namespace))
+				return
+			}
+
+			user, err := verifiedUser(c, k8sClient)
+			if err != nil {
+				logging.FromContext(c).Error("Error identifying user", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to identify user", err)
+				return
+			}
+
+			pod, err := k8sClient.RestartPod(c.Request.Context(), namespace, name)
+			if errors.Is(err, kubernetes.ErrPodNotControlled) {
+				api.RespondError(c, http.StatusConflict, api.CodeConflict, "Pod cannot be restarted", err)
+				return
+			}
+			if err != nil {
+				logging.FromContext(c).Error("Error restarting pod", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to restart pod", err)
+				return
+			}
+
+			owner := metav1.GetControllerOf(pod)
+			logging.FromContext(c).Info("Restarted pod", "audit", true, "user", user, "namespace", namespace, "pod", name, "owner", owner.Name)
+			c.JSON(http.StatusOK, api.PodRestartResponse{
+				Pod:   api.ResourceRef{Kind: "Pod", Name: name, Namespace: namespace},
+				Owner: owner.Kind + "/" + owner.Name,
+			})
+		})
+
+		// Download the logs of all pods of a rollout (all versions) as a zip archive, e.g. to attach
+		// to an incident ticket
+		v1.GET("/rollouts/:namespace/:name/pods/logs/download", limiter.Streams(), func(c *gin.Context) {
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
+			}
+
+			namespace := c.Param("namespace")
+			name := c.Param("name")
+			filterType := c.DefaultQuery("type", "")
+			if filterType != "" && filterType != "pod" && filterType != "test" {
+				api.RespondErrorDetails(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid pod type", "type must be pod or test")
+				return
+			}
+			track := c.Query("track")
+			if track != "" && track != kubernetes.TrackCanary && track != kubernetes.TrackStable {
+				api.RespondErrorDetails(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid track", "track must be canary or stable")
+				return
+			}
+
+			opts := logs.ArchiveOptions{}
+			opts.Previous, _ = strconv.ParseBool(c.Query("previous"))
+			// tailLines is the original name of tail on this endpoint
+			tail := c.DefaultQuery("tail", c.Query("tailLines"))
+			window, err := logs.ParseWindow(tail, c.Query("since"), c.Query("sinceTime"), 0)
+			if err != nil {
+				api.RespondErrorDetails(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid log window", err.Error())
+				return
+			}
+			opts.Window = window
+
+			discovered, err := logs.NewPodDiscovery(k8sClient, namespace, name, "", filterType).WithTrack(track).DiscoverPods(c.Request.Context())
+			if err != nil {
+				logging.FromContext(c).Error("Error discovering pods", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to discover pods", err)
+				return
+			}
+
+			ctx, cancel := server.StreamContext(c.Request.Context())
+			defer cancel()
+
+			filename := fmt.Sprintf("%s-%s-logs-%s.zip", namespace, name, time.Now().UTC().Format("20060102T150405Z"))
+			c.Header("Content-Type", "application/zip")
+			c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+			c.Status(http.StatusOK)
+
+			// Headers are already sent, so a failure can only be logged; the client sees a broken archive
+			if err := logs.WriteArchive(ctx, c.Writer, discovered, logs.ClientLogOpener(k8sClient), opts); err != nil {
+				logging.FromContext(c).Warn("Error writing log archive", "error", err)
+			}
+		})
+
+		// Open an interactive shell (pods/exec) in a container of one of the rollout's pods over a
+		// WebSocket. See pkg/terminal for the message protocol.
+		v1.GET("/rollouts/:namespace/:name/exec", limiter.Streams(), func(c *gin.Context) {
+			// Requests without a user token would run commands as the service account
+			if share.FromContext(c) != nil {
+				api.RespondErrorDetails(c, http.StatusForbidden, api.CodeForbidden, "Not allowed to exec into pod", "share links do not grant exec")
+				return
+			}
+			if isAnonymous(c) {
+				api.RespondErrorDetails(c, http.StatusUnauthorized, api.CodeUnauthorized, "Authentication required", "exec requires a user token")
+				return
+			}
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
+			}
+
+			namespace := c.Param("namespace")
+			name := c.Param("name")
+			podName := c.Query("pod")
+			if podName == "" {
+				api.RespondErrorDetails(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid exec request", "pod query parameter is required")
+				return
+			}
+			command := c.QueryArray("command")
+			if len(command) == 0 {
+				command = []string{"sh"}
+			}
+			tty := true
+			if parsed, err := strconv.ParseBool(c.DefaultQuery("tty", "true")); err == nil {
+				tty = parsed
+			}
+
+			// Only pods of the rollout can be entered, so the endpoint cannot be used to reach arbitrary pods
+			discovered, err := logs.NewPodDiscovery(k8sClient, namespace, name, "", "").DiscoverPods(c.Request.Context())
+			if err != nil {
+				logging.FromContext(c).Error("Error discovering pods", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to discover pods", err)
+				return
+			}
+			var pod *corev1.Pod
+			for i := range discovered {
+				if discovered[i].Pod.Name == podName {
+					pod = &discovered[i].Pod
+					break
+				}
+			}
+			if pod == nil {
+				api.RespondErrorDetails(c, http.StatusNotFound, api.CodeNotFound, "Pod not found",
+					fmt.Sprintf("pod %s does not belong to rollout %s/%s", podName, namespace, name))
+				return
+			}
+			container, err := kubernetes.ExecContainer(pod, c.Query("container"))
+			if err != nil {
+				api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid container", err)
+				return
+			}
+
+			// Check before upgrading, so the browser gets a proper error response instead of a closed socket
+			allowed, err := k8sClient.CheckPodExecPermission(c.Request.Context(), pod.Namespace, pod.Name)
+			if err != nil {
+				logging.FromContext(c).Error("Error checking permission", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to check permission", err)
+				return
+			}
+			if !allowed {
+				api.RespondErrorDetails(c, http.StatusForbidden, api.CodeForbidden, "Not allowed to exec into pod",
+					fmt.Sprintf("creating pods/exec in namespace %s is not permitted", pod.Namespace))
+				return
+			}
+			user, err := verifiedUser(c, k8sClient)
+			if err != nil {
+				logging.FromContext(c).Error("Error identifying user", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to identify user", err)
+				return
+			}
+
+			// Credentials may come from cookies, which browsers send with WebSockets from any site,
+			// so only same-origin pages may connect regardless of the CORS policy
+			upgrader := websocket.Upgrader{
+				CheckOrigin: func(r *http.Request) bool {
+					origin := r.Header.Get("Origin")
+					return origin == "" || origin == "http://"+r.Host || origin == "https://"+r.Host
+				},
+			}
+			conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+			if err != nil {
+				// The upgrader has already written an error response
+				logging.FromContext(c).Warn("Error upgrading exec connection", "error", err)
+				return
+			}
+			defer conn.Close()
+
+			ctx, cancel := server.StreamContext(c.Request.Context())
+			defer cancel()
+
+			session := terminal.NewSession(conn)
+			go session.ReadLoop()
+			go func() {
+				// End the command when the browser goes away
+				select {
+				case <-session.Done():
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+
+			logger := logging.FromContext(c).With("audit", true, "user", user, "namespace", pod.Namespace,
+				"pod", pod.Name, "container", container, "command", strings.Join(command, " "))
+			logger.Info("Started exec session")
+			started := time.Now()
+
+			err = k8sClient.ExecInPod(ctx, pod.Namespace, pod.Name, container, command, session.StreamOptions(tty))
+			logger.Info("Ended exec session", "duration", time.Since(started).Round(time.Second).String(), "result", execResult(err))
+			session.Exit(err)
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		})
+
+		// Running log streams, so clients can mute and solo their sources mid-stream
+		logStreams := logs.NewStreamRegistry()
+		logStreamOwner := func(c *gin.Context, k8sClient *kubernetes.Client) logs.StreamOwner {
+			return logs.StreamOwner{Credentials: k8sClient.CredentialKey(), Namespace: c.Param("namespace"), Rollout: c.Param("name")}
+		}
+
 		// Stream pod logs using Server-Sent Events
-		api.GET("/rollouts/:namespace/:name/pods/logs", func(c *gin.Context) {
+		v1.GET("/rollouts/:namespace/:name/pods/logs", limiter.Streams(), func(c *gin.Context) {
 			k8sClient, ok := getK8sClient(c)
 			if !ok {
 				return
@@ -1470,6 +2865,37 @@ func main() {
 			filterType := c.DefaultQuery("type", "")
 			podName := c.Query("pod")
 			containerName := c.DefaultQuery("container", "")
+			track := c.Query("track")
+			if track != "" && track != kubernetes.TrackCanary && track != kubernetes.TrackStable {
+				api.RespondErrorDetails(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid track", "track must be canary or stable")
+				return
+			}
+
+			filter, err := logs.NewFilter(c.QueryArray("grep"), c.QueryArray("exclude"), c.Query("level"))
+			if err != nil {
+				api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid log filter", err)
+				return
+			}
+			window, err := logs.ParseWindow(c.Query("tail"), c.Query("since"), c.Query("sinceTime"), logs.DefaultTailLines)
+			if err != nil {
+				api.RespondErrorDetails(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid log window", err.Error())
+				return
+			}
+
+			// Share links act as the service account, so they may only read pods of the shared rollout
+			if podName != "" && share.FromContext(c) != nil {
+				discovered, err := logs.NewPodDiscovery(k8sClient, namespace, name, "", "").DiscoverPods(c.Request.Context())
+				if err != nil {
+					logging.FromContext(c).Error("Error discovering pods", "error", err)
+					api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to discover pods", err)
+					return
+				}
+				if !slices.ContainsFunc(discovered, func(p logs.DiscoveredPod) bool { return p.Pod.Name == podName }) {
+					api.RespondErrorDetails(c, http.StatusNotFound, api.CodeNotFound, "Pod not found",
+						fmt.Sprintf("pod %s does not belong to rollout %s/%s", podName, namespace, name))
+					return
+				}
+			}
 
 			// Set headers for SSE
 			c.Header("Content-Type", sse.ContentType)
@@ -1504,18 +2930,10 @@ func main() {
 					Container: containerName,
 					Follow:    true,
 				}
-
-				// If since timestamp is provided, fetch logs from that time
-				if sinceStr := c.Query("since"); sinceStr != "" {
-					if sinceMs, err := strconv.ParseInt(sinceStr, 10, 64); err == nil {
-						// Convert milliseconds to seconds for Kubernetes API
-						sinceTime := metav1.NewTime(time.Unix(sinceMs/1000, (sinceMs%1000)*1000000))
-						opts.SinceTime = &sinceTime
-					}
-				}
+				window.Apply(opts)
 
 				req := clientset.CoreV1().Pods(namespace).GetLogs(podName, opts)
-				stream, err := req.Stream(context.Background())
+				stream, err := req.Stream(c.Request.Context())
 				if err != nil {
 					sse.Encode(c.Writer, sse.Event{
 						Event: "error",
@@ -1534,12 +2952,12 @@ func main() {
 						return
 					}
 					line := scanner.Text()
-					if line != "" {
-						logLine := map[string]string{
-							"pod":       podName,
-							"container": containerName,
-							"type":      filterType,
-							"line":      line,
+					if line != "" && filter.Match(line) {
+						logLine := api.LogLine{
+							Pod:       podName,
+							Container: containerName,
+							Type:      filterType,
+							Line:      line,
 						}
 						if jsonBytes, err := json.Marshal(logLine); err == nil {
 							sse.Encode(c.Writer, sse.Event{
@@ -1555,31 +2973,20 @@ func main() {
 				return
 			}
 
-			// Use the refactored log streaming service
-			// For SSE, we need a context that doesn't timeout, but we still check
-			// the request context to detect client disconnection
-			requestCtx := c.Request.Context()
-			// Create a context that won't be cancelled by request timeout
-			// but will be cancelled if the request context is cancelled (client disconnect)
-			ctx, cancel := context.WithCancel(context.Background())
+			// Use the refactored log streaming service. The stream ends when the client
+			// disconnects, the server starts shutting down or the client stops keeping up.
+			ctx, cancel := server.StreamContext(c.Request.Context())
 			defer cancel()
-
-			// Monitor request context for client disconnection
-			go func() {
-				<-requestCtx.Done()
-				cancel()
-			}()
+			ctx, stream := streams.Start(ctx, c, requestUser(c))
+			defer stream.Close()
 
 			// Get the rollout to find current version tag
-			rollout, err := k8sClient.GetRollout(context.Background(), namespace, name)
+			rollout, err := k8sClient.GetRollout(c.Request.Context(), namespace, name)
 			if err != nil {
-				sse.Encode(c.Writer, sse.Event{
+				stream.Send(sse.Event{
 					Event: "error",
 					Data:  fmt.Sprintf("Failed to fetch rollout: %v", err),
 				})
-				if flusher, ok := c.Writer.(http.Flusher); ok {
-					flusher.Flush()
-				}
 				return
 			}
 
@@ -1588,31 +2995,29 @@ func main() {
 				currentVersionTag = rollout.Status.History[0].Version.Tag
 			}
 
-			// Parse since timestamp if provided
-			var sinceTime *time.Time
-			if sinceStr := c.Query("since"); sinceStr != "" {
-				if sinceMs, err := strconv.ParseInt(sinceStr, 10, 64); err == nil {
-					t := time.Unix(sinceMs/1000, (sinceMs%1000)*1000000)
-					sinceTime = &t
-				}
-			}
-
 			// Create pod discovery and log streamer
-			discovery := logs.NewPodDiscovery(k8sClient, namespace, name, currentVersionTag, filterType)
-			streamer := logs.NewLogStreamer(k8sClient, discovery, ctx, sinceTime)
+			discovery := logs.NewPodDiscovery(k8sClient, namespace, name, currentVersionTag, filterType).WithTrack(track)
+			streamer := logs.NewLogStreamer(k8sClient, discovery, ctx, window, maxLogLineLength, filter)
+
+			streamer.Select(c.QueryArray("mute"), c.QueryArray("solo"))
 
 			// Start streaming
 			if err := streamer.Start(); err != nil {
-				sse.Encode(c.Writer, sse.Event{
+				stream.Send(sse.Event{
 					Event: "error",
 					Data:  fmt.Sprintf("Failed to start streaming: %v", err),
 				})
-				if flusher, ok := c.Writer.(http.Flusher); ok {
-					flusher.Flush()
-				}
 				return
 			}
 			defer streamer.Stop()
+			stream.TrackDropped(streamer.Dropped)
+
+			// Announce the stream's ID so the client can mute and solo sources with control requests
+			streamID, unregister := logStreams.Register(logStreamOwner(c, k8sClient), streamer)
+			defer unregister()
+			if started, err := json.Marshal(api.LogStreamStarted{ID: streamID}); err == nil {
+				stream.Send(sse.Event{Event: "stream", Data: string(started)})
+			}
 
 			// SSE writer goroutine
 			sseChan := streamer.GetSSEChannel()
@@ -1638,18 +3043,11 @@ func main() {
 							}()
 							messagesSent++
 
-							// Use gin-contrib/sse for proper SSE encoding
-							if err := sse.Encode(c.Writer, sse.Event{
+							// Every message is flushed to ensure real-time delivery
+							stream.Send(sse.Event{
 								Event: msg.Event,
 								Data:  msg.Data,
-							}); err != nil {
-								return
-							}
-
-							// Flush every message to ensure real-time delivery
-							if flusher, ok := c.Writer.(http.Flusher); ok {
-								flusher.Flush()
-							}
+							})
 						}()
 					}
 				}
@@ -1674,17 +3072,67 @@ func main() {
 				}
 			}
 		})
+
+		// Mute or solo pods and containers of a running log stream, so sources hidden in the UI are
+		// dropped on the server instead of being sent
+		v1.POST("/rollouts/:namespace/:name/pods/logs/control", func(c *gin.Context) {
+			k8sClient, ok := getK8sClient(c)
+			if !ok {
+				return
+			}
+
+			var req api.LogControlRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid request body", err)
+				return
+			}
+
+			// Streams can only be controlled with the credentials they were opened with
+			streamer, ok := logStreams.Get(req.Stream, logStreamOwner(c, k8sClient))
+			if !ok {
+				api.RespondErrorDetails(c, http.StatusNotFound, api.CodeNotFound, "Log stream not found",
+					fmt.Sprintf("no running log stream %q of rollout %s/%s", req.Stream, c.Param("namespace"), c.Param("name")))
+				return
+			}
+			streamer.Select(req.Mute, req.Solo)
+
+			response := api.LogControlResponse{Stream: req.Stream, Mute: []string{}, Solo: []string{}}
+			response.Mute = append(response.Mute, req.Mute...)
+			response.Solo = append(response.Solo, req.Solo...)
+			c.JSON(http.StatusOK, response)
+		})
+
+		// v2 answers with trimmed models instead of raw resources by default; ?view=raw returns
+		// the resources as v1 does. Every other route is inherited from v1.
+		v2 := versions.Version("v2", v1)
+		v2.GET("/rollouts", func(c *gin.Context) {
+			listRollouts(c, c.Query("view") != "raw")
+		})
+		v2.GET("/rollouts/:namespace/:name", func(c *gin.Context) {
+			getRollout(c, func(c *gin.Context, detail *api.RolloutDetailResponse, fields api.Fields) {
+				if c.Query("view") == "raw" {
+					api.RespondJSON(c, http.StatusOK, detail, "rollout", fields)
+					return
+				}
+				api.RespondJSON(c, http.StatusOK, api.NewRolloutDetailView(detail), "rollout", fields)
+			})
+		})
 	}
+	// Clients of the paths from before the API was versioned keep working, with deprecation
+	// headers pointing them to /api/v1
+	versions.Legacy(v1)
+	versions.Register()
 
 	// Serve frontend
 	r.Use(static.Serve("/", static.LocalFile(os.Getenv("KO_DATA_PATH"), false)))
 	r.NoRoute(func(c *gin.Context) {
+		// Unknown API paths (e.g. unsupported versions) get a JSON error instead of the SPA
+		if strings.HasPrefix(c.Request.URL.Path, "/api/") {
+			api.RespondErrorDetails(c, http.StatusNotFound, api.CodeNotFound, "Unknown API endpoint", c.Request.URL.Path)
+			return
+		}
 		c.File(filepath.Join(os.Getenv("KO_DATA_PATH"), "index.html"))
 	})
 
-	// Start server
-	if err := r.Run(":8080"); err != nil {
-		log.Printf("Failed to start server: %v", err)
-		os.Exit(1)
-	}
+	return r
 }
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/alertmanager"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+)
+
+// getRolloutAlerts returns the firing alerts about the rollout's workloads and their namespaces
+func getRolloutAlerts(c *gin.Context, alerts *alertmanager.Client) {
+	if alerts == nil {
+		api.RespondErrorDetails(c, http.StatusNotFound, api.CodeNotFound, "Alerts are not enabled", "set ALERTMANAGER_URL to enable alerts")
+		return
+	}
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return
+	}
+
+	workloads, err := k8sClient.GetRolloutWorkloads(c.Request.Context(), c.Param("namespace"), c.Param("name"))
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching rollout workloads", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout workloads", err)
+		return
+	}
+	response := api.AlertsResponse{Workloads: workloads, Alerts: []alertmanager.Alert{}}
+	if len(workloads) == 0 {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	var namespaces []string
+	for _, workload := range workloads {
+		namespaces = append(namespaces, workload.Namespace)
+	}
+	firing, err := alerts.GetAlerts(c.Request.Context(), namespaces)
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching alerts", "error", err)
+		api.RespondError(c, http.StatusBadGateway, api.CodeAlerts, "Failed to fetch alerts from Alertmanager", err)
+		return
+	}
+	response.Alerts = alertmanager.Match(firing, workloads)
+	response.Firing = len(response.Alerts)
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+)
+
+const (
+	// defaultAnnotationCleanupInterval is how often stale override annotations are removed
+	defaultAnnotationCleanupInterval = 10 * time.Minute
+	// defaultAnnotationGracePeriod is how long override annotations are kept after their version baked
+	defaultAnnotationGracePeriod = time.Hour
+	// maxBakeExtension bounds a single extension of a deployment's bake
+	maxBakeExtension = 24 * time.Hour
+)
+
+// cleanupAnnotations periodically removes the force-deploy, bypass-gates and unblock-failed
+// annotations that have served their purpose, see kubernetes.StaleAnnotations, and restores the
+// bake time of rollouts whose extended bake is over
+func cleanupAnnotations(ctx context.Context, interval, gracePeriod time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := removeStaleAnnotations(ctx, gracePeriod, time.Now()); err != nil {
+				slog.Warn("Failed to clean up rollout annotations", "error", err)
+			}
+		}
+	}
+}
+
+func removeStaleAnnotations(ctx context.Context, gracePeriod time.Duration, now time.Time) error {
+	k8sClient, err := kubernetes.GetDefaultClient()
+	if err != nil {
+		return err
+	}
+	rollouts, err := k8sClient.GetRolloutsAllNamespaces(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range rollouts.Items {
+		rollout := &rollouts.Items[i]
+		// Extended bakes are restored right away, the next deployment must not inherit them
+		if kubernetes.BakeExtensionOver(rollout) {
+			logger := slog.With("namespace", rollout.Namespace, "rollout", rollout.Name)
+			if err := k8sClient.RestoreBakeTime(ctx, rollout); err != nil {
+				logger.Warn("Failed to restore bake time", "error", err)
+			} else {
+				logger.Info("Restored bake time after extended bake")
+			}
+		}
+
+		stale := kubernetes.StaleAnnotations(rollout, gracePeriod, now)
+		if len(stale) == 0 {
+			continue
+		}
+		logger := slog.With("namespace", rollout.Namespace, "rollout", rollout.Name, "annotations", stale)
+		if err := k8sClient.RemoveRolloutAnnotations(ctx, rollout.Namespace, rollout.Name, stale); err != nil {
+			logger.Warn("Failed to remove stale annotations", "error", err)
+			continue
+		}
+		logger.Info("Removed stale annotations")
+	}
+	return nil
+}
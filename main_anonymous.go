@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/auth"
+	"github.com/kuberik/rollout-dashboard/pkg/share"
+)
+
+// anonymousNamespacesKey is the gin context key of the namespaces an anonymous request may read
+const anonymousNamespacesKey = "anonymousNamespaces"
+
+// anonymousNamespacesFromEnv returns the sorted namespaces from ANONYMOUS_NAMESPACES, the only
+// namespaces requests without a user token may read. Nil when unset, leaving them unrestricted.
+func anonymousNamespacesFromEnv() []string {
+	var namespaces []string
+	for _, namespace := range strings.Split(os.Getenv("ANONYMOUS_NAMESPACES"), ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" && !slices.Contains(namespaces, namespace) {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	slices.Sort(namespaces)
+	return namespaces
+}
+
+// isAnonymous reports whether the request is served with the service account on nobody's behalf.
+// Share links are not anonymous, they are limited to their rollout by share.Signer.Middleware.
+func isAnonymous(c *gin.Context) bool {
+	return share.FromContext(c) == nil && auth.GetTokenFromContext(c) == ""
+}
+
+// restrictAnonymous limits anonymous requests to reading namespaces: changes are refused with 401,
+// a namespace outside of them in the path or the namespace query is forbidden, and handlers
+// narrow listings of all namespaces, environments and namespaces of the body to them with
+// anonymousNamespaces. Requests with a user token are left to the cluster's RBAC.
+func restrictAnonymous(namespaces []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isAnonymous(c) {
+			c.Next()
+			return
+		}
+		// Changes would be made with the service account on nobody's behalf
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			api.RespondErrorDetails(c, http.StatusUnauthorized, api.CodeUnauthorized, "Authentication required",
+				"anonymous access is read-only, sign in to make changes")
+			return
+		}
+		for _, namespace := range []string{c.Param("namespace"), c.Query("namespace")} {
+			if namespace == "" || namespace == "all" || namespace == "*" || slices.Contains(namespaces, namespace) {
+				continue
+			}
+			api.RespondErrorDetails(c, http.StatusForbidden, api.CodeForbidden, "Namespace not readable anonymously",
+				"namespace "+namespace+" is not in ANONYMOUS_NAMESPACES, sign in to access it")
+			return
+		}
+		c.Set(anonymousNamespacesKey, namespaces)
+		c.Next()
+	}
+}
+
+// anonymousNamespaces returns the namespaces an anonymous request is limited to, nil when the
+// request is not limited
+func anonymousNamespaces(c *gin.Context) []string {
+	namespaces, _ := c.Get(anonymousNamespacesKey)
+	restricted, _ := namespaces.([]string)
+	return restricted
+}
+
+// filterAnonymous drops the items outside of the namespaces an anonymous request is limited to
+func filterAnonymous[T any](c *gin.Context, items []T, namespace func(T) string) []T {
+	return filterNamespaces(anonymousNamespaces(c), items, namespace)
+}
+
+// filterNamespaces drops the items outside of restricted, unless it is nil
+func filterNamespaces[T any](restricted []string, items []T, namespace func(T) string) []T {
+	if restricted == nil {
+		return items
+	}
+	return slices.DeleteFunc(items, func(item T) bool {
+		return !slices.Contains(restricted, namespace(item))
+	})
+}
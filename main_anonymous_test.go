@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/share"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRestrictAnonymous(t *testing.T) {
+	scheme, err := kubernetes.NewScheme()
+	require.NoError(t, err)
+	kubernetes.UseStaticClient(kubernetes.NewClientFrom(fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "public", Name: "web"}},
+		&rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "payments", Name: "ledger"}},
+	).Build(), nil))
+	defer kubernetes.UseStaticClient(nil)
+	t.Setenv("ANONYMOUS_NAMESPACES", "public, staging")
+
+	r := newRouter(nil, nil, nil, nil, newIntegrations(nil, nil, "", nil, nil, nil), nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	get := func(path, token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+	names := func(w *httptest.ResponseRecorder) []string {
+		var response api.RolloutSummaryListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		var names []string
+		for _, rollout := range response.Rollouts {
+			names = append(names, rollout.Namespace+"/"+rollout.Name)
+		}
+		return names
+	}
+
+	w := get("/api/v1/rollouts?view=summary", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []string{"public/web"}, names(w))
+
+	w = get("/api/v1/rollouts?view=summary&namespace=payments", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = get("/api/v1/rollouts/payments/ledger", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = get("/api/v1/rollouts/public/web", "")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// Users with a token are left to RBAC
+	w = get("/api/v1/rollouts?view=summary", "user-token")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []string{"payments/ledger", "public/web"}, names(w))
+
+	// Anonymous access is read-only, also in the allowed namespaces
+	for _, request := range []struct{ method, path, body string }{
+		{http.MethodPost, "/api/v1/rollouts/public/web/pin", `{"version":"v2"}`},
+		{http.MethodPost, "/api/v1/rollouts/batch", `{"items":[{"namespace":"payments","name":"ledger","action":"reconcile"}]}`},
+		{http.MethodPost, "/api/v1/environments/production/reconcile", ""},
+		{http.MethodPost, "/api/v1/environments/production/promote", `{"from":"staging"}`},
+		{http.MethodPut, "/api/v1/environments/production/overrides", "rollouts: []"},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(request.method, request.path, strings.NewReader(request.body)))
+		assert.Equal(t, http.StatusUnauthorized, w.Code, request.path)
+	}
+}
+
+func TestExec_RequiresUserToken(t *testing.T) {
+	scheme, err := kubernetes.NewScheme()
+	require.NoError(t, err)
+	kubernetes.UseStaticClient(kubernetes.NewClientFrom(fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "public", Name: "web"}},
+	).Build(), nil))
+	defer kubernetes.UseStaticClient(nil)
+
+	shares := share.NewSigner(share.Config{Secret: []byte("test-share-link-secret-0000000000"), MaxTTL: share.DefaultTTL})
+	shareToken, _, err := shares.Sign("public", "web", "jane", time.Hour)
+	require.NoError(t, err)
+
+	r := newRouter(nil, shares, nil, nil, newIntegrations(nil, nil, "", nil, nil, nil), nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	// Both would otherwise run commands as the service account
+	w := get("/api/v1/rollouts/public/web/exec?pod=web-abc12")
+	assert.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+	w = get("/api/v1/rollouts/public/web/exec?pod=web-abc12&share=" + shareToken)
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+}
+
+func TestRestartPod_RequiresUserToken(t *testing.T) {
+	scheme, err := kubernetes.NewScheme()
+	require.NoError(t, err)
+	kubernetes.UseStaticClient(kubernetes.NewClientFrom(fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "public", Name: "web-abc12"}},
+	).Build(), nil))
+	defer kubernetes.UseStaticClient(nil)
+
+	// The pod would otherwise be deleted with the service account's permissions
+	r := newRouter(nil, nil, nil, nil, newIntegrations(nil, nil, "", nil, nil, nil), nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/pods/public/web-abc12/restart", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+}
+
+// newAnonymousEnvironmentsRouter serves a "shop" application deployed to production in the public
+// and payments namespaces, with ANONYMOUS_NAMESPACES set to namespaces
+func newAnonymousEnvironmentsRouter(t *testing.T, namespaces string) func(path, token string) *httptest.ResponseRecorder {
+	environment := func(namespace string) *envv1alpha1.Environment {
+		return &envv1alpha1.Environment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "shop-production"},
+			Spec: envv1alpha1.EnvironmentSpec{
+				Name:        "shop",
+				Environment: "production",
+				RolloutRef:  corev1.LocalObjectReference{Name: "shop"},
+			},
+		}
+	}
+	healthCheck := func(namespace string) *rolloutv1alpha1.HealthCheck {
+		return &rolloutv1alpha1.HealthCheck{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "shop"}}
+	}
+	scheme, err := kubernetes.NewScheme()
+	require.NoError(t, err)
+	kubernetes.UseStaticClient(kubernetes.NewClientFrom(fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		environment("public"), environment("payments"),
+		&rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "public", Name: "shop"}},
+		&rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "payments", Name: "shop"}},
+		healthCheck("public"), healthCheck("payments"),
+	).Build(), nil))
+	t.Cleanup(func() { kubernetes.UseStaticClient(nil) })
+	t.Setenv("ANONYMOUS_NAMESPACES", namespaces)
+
+	r := newRouter(nil, nil, nil, nil, newIntegrations(nil, nil, "", nil, nil, nil), nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	return func(path, token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+}
+
+func TestHealthChecks_Anonymous(t *testing.T) {
+	get := newAnonymousEnvironmentsRouter(t, "public")
+	namespaces := func(w *httptest.ResponseRecorder) []string {
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response api.HealthCheckListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		var namespaces []string
+		for _, healthCheck := range response.HealthChecks {
+			namespaces = append(namespaces, healthCheck.Namespace)
+		}
+		return namespaces
+	}
+
+	assert.Equal(t, []string{"public"}, namespaces(get("/api/v1/health-checks", "")))
+	assert.ElementsMatch(t, []string{"payments", "public"}, namespaces(get("/api/v1/health-checks", "user-token")))
+}
+
+func TestApplicationEnvironments_Anonymous(t *testing.T) {
+	get := newAnonymousEnvironmentsRouter(t, "public")
+	rollouts := func(w *httptest.ResponseRecorder) []string {
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response api.ApplicationEnvironmentsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		var rollouts []string
+		for _, env := range response.Environments {
+			for _, rollout := range env.Rollouts {
+				rollouts = append(rollouts, rollout.Namespace+"/"+rollout.Name)
+			}
+		}
+		return rollouts
+	}
+
+	assert.Equal(t, []string{"public/shop"}, rollouts(get("/api/v1/applications/shop/environments", "")))
+	assert.Equal(t, []string{"payments/shop", "public/shop"}, rollouts(get("/api/v1/applications/shop/environments", "user-token")))
+}
+
+func TestEnvironmentOverrides_Anonymous(t *testing.T) {
+	get := newAnonymousEnvironmentsRouter(t, "public")
+	rollouts := func(w *httptest.ResponseRecorder) []string {
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var document api.OverridesDocument
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &document))
+		var rollouts []string
+		for _, rollout := range document.Rollouts {
+			rollouts = append(rollouts, rollout.Namespace+"/"+rollout.Name)
+		}
+		return rollouts
+	}
+
+	assert.Equal(t, []string{"public/shop"}, rollouts(get("/api/v1/environments/production/overrides", "")))
+	assert.Equal(t, []string{"payments/shop", "public/shop"}, rollouts(get("/api/v1/environments/production/overrides", "user-token")))
+}
+
+func TestEnvironmentPermissions_Anonymous(t *testing.T) {
+	get := newAnonymousEnvironmentsRouter(t, "staging")
+
+	// None of the environment's rollouts can be read anonymously, so none are checked or named
+	w := get("/api/v1/environments/production/permissions", "")
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	// Users with a token get to the access reviews, which the fake client cannot answer
+	w = get("/api/v1/environments/production/permissions", "user-token")
+	assert.Equal(t, http.StatusInternalServerError, w.Code, w.Body.String())
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"slices"
+
+	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+)
+
+// getApplicationEnvironments compares the versions the application runs in each of its
+// environments. Rollouts that cannot be read are reported with their error instead of failing the
+// whole comparison. It returns nil when no Environment belongs to the application. Environments
+// outside of namespaces are left out, unless it is nil.
+func getApplicationEnvironments(ctx context.Context, k8sClient *kubernetes.Client, application string, namespaces []string) (*api.ApplicationEnvironmentsResponse, error) {
+	environments, err := k8sClient.GetEnvironmentsAllNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	environments.Items = filterNamespaces(namespaces, environments.Items, func(env envv1alpha1.Environment) string { return env.Namespace })
+	appEnvs := kubernetes.ApplicationEnvironments(environments.Items, application)
+	if len(appEnvs) == 0 {
+		return nil, nil
+	}
+
+	response := &api.ApplicationEnvironmentsResponse{
+		Application:  application,
+		Environments: make([]api.ApplicationEnvironment, 0, len(appEnvs)),
+		Versions:     []string{},
+	}
+	for _, appEnv := range appEnvs {
+		env := api.ApplicationEnvironment{
+			Environment:  appEnv.Environment,
+			Upstream:     appEnv.Upstream,
+			Relationship: appEnv.Relationship,
+			Remote:       len(appEnv.Rollouts) == 0,
+			Rollouts:     []api.ApplicationRollout{},
+		}
+		if appEnv.Deployment != nil {
+			setLatestDeployment(&env, *appEnv.Deployment)
+		}
+
+		var versions []string
+		for _, ref := range appEnv.Rollouts {
+			rollout := api.ApplicationRollout{Namespace: ref.Namespace, Name: ref.Name}
+			obj, err := k8sClient.GetRollout(ctx, ref.Namespace, ref.Name)
+			if err != nil {
+				rollout.Error = err.Error()
+			} else if len(obj.Status.History) > 0 {
+				current := obj.Status.History[0]
+				rollout.Version = current.Version.Tag
+				deployedAt := current.Timestamp.Time
+				rollout.DeployedAt = &deployedAt
+				if current.BakeStatus != nil {
+					rollout.BakeStatus = *current.BakeStatus
+				}
+				setLatestDeployment(&env, current)
+				if !slices.Contains(versions, rollout.Version) {
+					versions = append(versions, rollout.Version)
+				}
+			}
+			env.Rollouts = append(env.Rollouts, rollout)
+		}
+		env.Drift = len(versions) > 1
+		response.Environments = append(response.Environments, env)
+	}
+
+	// Environments are in promotion order, so upstreams are compared with their final version
+	versionOf := map[string]string{}
+	for i := range response.Environments {
+		env := &response.Environments[i]
+		versionOf[env.Environment] = env.Version
+		if upstream, ok := versionOf[env.Upstream]; ok && upstream != "" {
+			env.UpstreamVersion = upstream
+			env.Drift = env.Drift || env.Version != upstream
+		}
+		if env.Version != "" && !slices.Contains(response.Versions, env.Version) {
+			response.Versions = append(response.Versions, env.Version)
+		}
+		for _, rollout := range env.Rollouts {
+			if rollout.Version != "" && !slices.Contains(response.Versions, rollout.Version) {
+				response.Versions = append(response.Versions, rollout.Version)
+			}
+		}
+	}
+	response.InSync = len(response.Versions) <= 1
+	return response, nil
+}
+
+// setLatestDeployment records deployment as the environment's version if it is the latest seen
+func setLatestDeployment(env *api.ApplicationEnvironment, deployment rolloutv1alpha1.DeploymentHistoryEntry) {
+	if env.LastDeployedAt != nil && !deployment.Timestamp.After(*env.LastDeployedAt) {
+		return
+	}
+	deployedAt := deployment.Timestamp.Time
+	env.LastDeployedAt = &deployedAt
+	env.Version = deployment.Version.Tag
+	env.BakeStatus = ""
+	if deployment.BakeStatus != nil {
+		env.BakeStatus = *deployment.BakeStatus
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetApplicationEnvironments(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	environment := func(namespace, env, upstream string, infos ...envv1alpha1.EnvironmentInfo) *envv1alpha1.Environment {
+		e := &envv1alpha1.Environment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "shop-" + env},
+			Spec: envv1alpha1.EnvironmentSpec{
+				Name:        "shop",
+				Environment: env,
+				RolloutRef:  corev1.LocalObjectReference{Name: "shop"},
+			},
+			Status: envv1alpha1.EnvironmentStatus{EnvironmentInfos: infos},
+		}
+		if upstream != "" {
+			e.Spec.Relationship = &envv1alpha1.EnvironmentRelationship{Environment: upstream, Type: envv1alpha1.RelationshipTypeAfter}
+		}
+		return e
+	}
+	rollout := func(namespace, version string, ago time.Duration) *rolloutv1alpha1.Rollout {
+		return &rolloutv1alpha1.Rollout{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "shop"},
+			Status: rolloutv1alpha1.RolloutStatus{History: []rolloutv1alpha1.DeploymentHistoryEntry{
+				{Version: rolloutv1alpha1.VersionInfo{Tag: version}, Timestamp: metav1.NewTime(now.Add(-ago))},
+			}},
+		}
+	}
+
+	scheme, err := kubernetes.NewScheme()
+	require.NoError(t, err)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		environment("shop-prod-eu", "production", "staging"),
+		environment("shop-prod-us", "production", "staging"),
+		environment("shop-staging", "staging", "", envv1alpha1.EnvironmentInfo{
+			Environment:  "edge",
+			Relationship: &envv1alpha1.EnvironmentRelationship{Environment: "production", Type: envv1alpha1.RelationshipTypeAfter},
+			History: []rolloutv1alpha1.DeploymentHistoryEntry{
+				{Version: rolloutv1alpha1.VersionInfo{Tag: "v1"}, Timestamp: metav1.NewTime(now.Add(-48 * time.Hour))},
+			},
+		}),
+		rollout("shop-staging", "v3", time.Hour),
+		rollout("shop-prod-eu", "v3", 30*time.Minute),
+		rollout("shop-prod-us", "v2", 24*time.Hour),
+	).Build()
+	k8sClient := kubernetes.NewClientFrom(fakeClient, k8sfake.NewClientset())
+
+	response, err := getApplicationEnvironments(context.Background(), k8sClient, "shop", nil)
+	require.NoError(t, err)
+	require.NotNil(t, response)
+	require.Len(t, response.Environments, 3)
+
+	staging, production, edge := response.Environments[0], response.Environments[1], response.Environments[2]
+	assert.Equal(t, "staging", staging.Environment)
+	assert.Equal(t, "v3", staging.Version)
+	assert.False(t, staging.Drift)
+
+	assert.Equal(t, "production", production.Environment)
+	assert.Equal(t, "v3", production.Version)
+	assert.Equal(t, now.Add(-30*time.Minute), *production.LastDeployedAt)
+	assert.Len(t, production.Rollouts, 2)
+	assert.True(t, production.Drift, "production rollouts run v3 and v2")
+
+	assert.Equal(t, "edge", edge.Environment)
+	assert.True(t, edge.Remote)
+	assert.Equal(t, "v1", edge.Version)
+	assert.Equal(t, "v3", edge.UpstreamVersion)
+	assert.True(t, edge.Drift)
+
+	assert.Equal(t, []string{"v3", "v2", "v1"}, response.Versions)
+	assert.False(t, response.InSync)
+
+	missing, err := getApplicationEnvironments(context.Background(), k8sClient, "billing", nil)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
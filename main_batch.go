@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+
+	openkruisev1alpha1 "github.com/kuberik/openkruise-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+)
+
+const (
+	// maxBatchItems bounds the items of one batch request
+	maxBatchItems = 100
+	// defaultBatchConcurrency is how many batch items run at once unless BATCH_CONCURRENCY says otherwise
+	defaultBatchConcurrency = 8
+)
+
+// batchConcurrencyFromEnv returns BATCH_CONCURRENCY, or the default
+func batchConcurrencyFromEnv() int {
+	if value, err := strconv.Atoi(os.Getenv("BATCH_CONCURRENCY")); err == nil && value > 0 {
+		return value
+	}
+	return defaultBatchConcurrency
+}
+
+// batchAction runs an action on one rollout. Errors are reported with the status and code of
+// api.ClassifyError, so Kubernetes API errors such as forbidden keep their status.
+type batchAction func(ctx context.Context, k8sClient *kubernetes.Client, item api.BatchItem) error
+
+// batchActions are the actions a batch may run, named like their endpoints
+var batchActions = map[string]batchAction{
+	"reconcile": func(ctx context.Context, k8sClient *kubernetes.Client, item api.BatchItem) error {
+		withSource, _ := strconv.ParseBool(item.Params["withSource"])
+		_, results, err := k8sClient.ReconcileAllFluxResources(ctx, item.Namespace, item.Name, withSource)
+		if err != nil {
+			return err
+		}
+		failed := 0
+		for _, result := range results {
+			if !result.Succeeded {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("failed to trigger reconciliation of %d of %d associated Flux resources", failed, len(results))
+		}
+		return nil
+	},
+	"unblock-failed": func(ctx context.Context, k8sClient *kubernetes.Client, item api.BatchItem) error {
+		_, err := k8sClient.AddUnblockFailedAnnotation(ctx, item.Namespace, item.Name)
+		return err
+	},
+	"retry": func(ctx context.Context, k8sClient *kubernetes.Client, item api.BatchItem) error {
+		mode := openkruisev1alpha1.RetryModeRetry
+		if item.Params["testAction"] == openkruisev1alpha1.RetryModeSkip {
+			mode = openkruisev1alpha1.RetryModeSkip
+		}
+		return k8sClient.SetRetryAnnotation(ctx, item.Namespace, item.Name, mode)
+	},
+	"mark-successful": func(ctx context.Context, k8sClient *kubernetes.Client, item api.BatchItem) error {
+		_, err := k8sClient.MarkDeploymentSuccessful(ctx, item.Namespace, item.Name, item.Params["message"])
+		return err
+	},
+}
+
+// errUnknownBatchAction is reported for items whose action is not in batchActions
+var errUnknownBatchAction = errors.New("unknown action")
+
+// runBatch runs the items with at most concurrency actions at once. A failing item does not stop
+// the others; results are in the order of items. Items whose rollout is frozen are not run.
+func runBatch(ctx context.Context, k8sClient *kubernetes.Client, items []api.BatchItem, concurrency int, logger *slog.Logger, frozen freezeCheck) api.BatchResponse {
+	results := make([]api.BatchResult, len(items))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, item := range items {
+		result := &results[i]
+		*result = api.BatchResult{Namespace: item.Namespace, Name: item.Name, Action: item.Action, Status: http.StatusOK}
+		action, ok := batchActions[item.Action]
+		if !ok {
+			result.Status, result.Code, result.Error = http.StatusBadRequest, api.CodeBadRequest, fmt.Sprintf("%s: %q", errUnknownBatchAction, item.Action)
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			err := frozen(ctx, item.Namespace, item.Name)
+			if err == nil {
+				err = action(ctx, k8sClient, item)
+			}
+			logger.Info("Ran batch action", "namespace", item.Namespace, "rollout", item.Name, "action", item.Action, "error", err)
+			if err != nil {
+				result.Status, result.Code = api.ClassifyError(err, http.StatusInternalServerError, api.CodeKubernetesAPI)
+				result.Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	response := api.BatchResponse{Results: results}
+	for _, result := range results {
+		if result.Code == "" {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+	return response
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/freeze"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRunBatch(t *testing.T) {
+	scheme, err := kubernetes.NewScheme()
+	require.NoError(t, err)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api"}},
+		&rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web"}},
+	).Build()
+	k8sClient := kubernetes.NewClientFrom(fakeClient, k8sfake.NewClientset())
+
+	response := runBatch(context.Background(), k8sClient, []api.BatchItem{
+		{Namespace: "shop", Name: "api", Action: "unblock-failed"},
+		{Namespace: "shop", Name: "missing", Action: "unblock-failed"},
+		{Namespace: "shop", Name: "web", Action: "delete"},
+		{Namespace: "shop", Name: "web", Action: "retry", Params: map[string]string{"testAction": "skip"}},
+		{Namespace: "payments", Name: "ledger", Action: "unblock-failed"},
+	}, 2, slog.New(slog.NewTextHandler(io.Discard, nil)), func(ctx context.Context, namespace, name string) error {
+		if namespace == "payments" {
+			return &freeze.FrozenError{Window: freeze.ActiveWindow{Name: "month-end"}}
+		}
+		return nil
+	})
+
+	assert.Equal(t, 2, response.Succeeded)
+	assert.Equal(t, 3, response.Failed)
+	require.Len(t, response.Results, 5)
+	assert.Equal(t, api.BatchResult{Namespace: "shop", Name: "api", Action: "unblock-failed", Status: http.StatusOK}, response.Results[0])
+	assert.Equal(t, http.StatusNotFound, response.Results[1].Status)
+	assert.Equal(t, api.CodeNotFound, response.Results[1].Code)
+	assert.Equal(t, http.StatusBadRequest, response.Results[2].Status)
+	assert.Equal(t, http.StatusOK, response.Results[3].Status)
+	assert.Equal(t, http.StatusLocked, response.Results[4].Status)
+	assert.Equal(t, api.CodeFrozen, response.Results[4].Code)
+
+	rollout := &rolloutv1alpha1.Rollout{}
+	require.NoError(t, fakeClient.Get(context.Background(), client.ObjectKey{Namespace: "shop", Name: "api"}, rollout))
+	assert.Contains(t, rollout.Annotations, "rollout.kuberik.com/unblock-failed")
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+	"github.com/kuberik/rollout-dashboard/pkg/prometheus"
+	kruiserolloutv1beta1 "github.com/openkruise/kruise-rollout-api/rollouts/v1beta1"
+)
+
+const (
+	// canaryMetricsMinRange and canaryMetricsMaxRange bound the range canary and stable metrics are
+	// compared over, which otherwise starts with the canary
+	canaryMetricsMinRange = 15 * time.Minute
+	canaryMetricsMaxRange = 6 * time.Hour
+)
+
+// canaryComparisonPresets are the metrics compared between the canary and the stable pods
+var canaryComparisonPresets = []string{"error-rate", "latency-p99"}
+
+// getCanaryAnalysis combines the step progress of a Kruise canary rollout with the readiness of its
+// canary and stable pods and, with Prometheus configured, their error rate and latency side by
+// side, to inform whether to continue or abort the canary
+func getCanaryAnalysis(c *gin.Context, metrics *prometheus.Client) {
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return
+	}
+	kruiseRollout, err := k8sClient.GetKruiseRollout(c.Request.Context(), c.Param("namespace"), c.Param("name"))
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching kruise rollout", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch kruise rollout", err)
+		return
+	}
+	if kruiseRollout.Spec.Strategy.Canary == nil {
+		api.RespondError(c, http.StatusNotFound, api.CodeNotFound, "Kruise rollout does not use a canary strategy", kubernetes.ErrNotCanary)
+		return
+	}
+	pods, err := k8sClient.GetKruiseWorkloadPods(c.Request.Context(), kruiseRollout)
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching workload pods", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch workload pods", err)
+		return
+	}
+	now := time.Now()
+	analysis, err := kubernetes.AnalyzeCanary(kruiseRollout, pods, now)
+	if err != nil {
+		api.RespondError(c, http.StatusNotFound, api.CodeNotFound, "Kruise rollout does not use a canary strategy", err)
+		return
+	}
+
+	response := api.CanaryAnalysisResponse{CanaryAnalysis: *analysis}
+	if metrics != nil {
+		// A canary is usually judged despite a metrics outage, so failed queries are reported
+		// alongside the analysis instead of failing it
+		response.Metrics, err = compareCanaryMetrics(c.Request.Context(), metrics, kruiseRollout, analysis, now)
+		if err != nil {
+			logging.FromContext(c).Warn("Error comparing canary metrics", "error", err)
+			response.MetricsError = err.Error()
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// compareCanaryMetrics runs the comparison presets over the canary and the stable pods of a
+// Deployment since the canary started. It returns nil without a canary in progress.
+func compareCanaryMetrics(ctx context.Context, metrics *prometheus.Client, rollout *kruiserolloutv1beta1.Rollout, analysis *kubernetes.CanaryAnalysis, now time.Time) (*api.CanaryMetrics, error) {
+	if analysis.CanaryRevision == "" {
+		return nil, nil
+	}
+	if rollout.Spec.WorkloadRef.Kind != "Deployment" {
+		return nil, errors.New("metrics can only be compared for Deployments, whose pod names carry the revision")
+	}
+
+	start := now.Add(-canaryMetricsMinRange)
+	if analysis.StartedAt != nil && analysis.StartedAt.Before(start) {
+		start = *analysis.StartedAt
+	}
+	if oldest := now.Add(-canaryMetricsMaxRange); start.Before(oldest) {
+		start = oldest
+	}
+	step := prometheus.Step(start, now)
+	canary, stable := prometheus.RevisionSelectors(rollout.Namespace, rollout.Spec.WorkloadRef.Name, analysis.CanaryRevision)
+
+	result := &api.CanaryMetrics{Start: start, End: now, Step: step.String(), Comparisons: []api.CanaryComparison{}}
+	for _, preset := range canaryComparisonPresets {
+		comparison := api.CanaryComparison{Preset: preset}
+		for selector, series := range map[string]*[]prometheus.Series{canary: &comparison.Canary, stable: &comparison.Stable} {
+			query, err := prometheus.Render(prometheus.Presets[preset], selector, prometheus.RateInterval(step))
+			if err != nil {
+				return nil, err
+			}
+			if *series, err = metrics.QueryRange(ctx, query, start, now, step); err != nil {
+				return nil, err
+			}
+		}
+		result.Comparisons = append(result.Comparisons, comparison)
+	}
+	return result, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/prometheus"
+	kruiserolloutv1beta1 "github.com/openkruise/kruise-rollout-api/rollouts/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCompareCanaryMetrics(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries = append(queries, r.URL.Query().Get("query"))
+		mu.Unlock()
+		pod := "web-5d9f8b7c4-x2x7q"
+		if strings.Contains(r.URL.Query().Get("query"), "pod!~") {
+			pod = "web-7c6b5a4d3-a1b2c"
+		}
+		w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"pod":"` + pod + `"},"values":[[1767366000,"0.5"]]}]}}`))
+	}))
+	defer srv.Close()
+
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	rollout := &kruiserolloutv1beta1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+		Spec:       kruiserolloutv1beta1.RolloutSpec{WorkloadRef: kruiserolloutv1beta1.ObjectRef{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"}},
+	}
+	startedAt := now.Add(-time.Hour)
+	analysis := &kubernetes.CanaryAnalysis{CanaryRevision: "5d9f8b7c4", StartedAt: &startedAt}
+
+	result, err := compareCanaryMetrics(context.Background(), prometheus.New(srv.URL, ""), rollout, analysis, now)
+	require.NoError(t, err)
+	assert.Equal(t, startedAt, result.Start)
+	require.Len(t, result.Comparisons, 2)
+	assert.Equal(t, "error-rate", result.Comparisons[0].Preset)
+	assert.Equal(t, "web-5d9f8b7c4-x2x7q", result.Comparisons[0].Canary[0].Metric["pod"])
+	assert.Equal(t, "web-7c6b5a4d3-a1b2c", result.Comparisons[0].Stable[0].Metric["pod"])
+	assert.Len(t, queries, 4)
+
+	// A canary that just started is compared over the minimum range
+	startedAt = now.Add(-time.Minute)
+	result, err = compareCanaryMetrics(context.Background(), prometheus.New(srv.URL, ""), rollout, analysis, now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-canaryMetricsMinRange), result.Start)
+
+	// Without a canary in progress there is nothing to compare
+	result, err = compareCanaryMetrics(context.Background(), prometheus.New(srv.URL, ""), rollout, &kubernetes.CanaryAnalysis{}, now)
+	require.NoError(t, err)
+	assert.Nil(t, result)
+
+	rollout.Spec.WorkloadRef.Kind = "CloneSet"
+	_, err = compareCanaryMetrics(context.Background(), prometheus.New(srv.URL, ""), rollout, analysis, now)
+	assert.Error(t, err)
+}
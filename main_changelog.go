@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+	"github.com/kuberik/rollout-dashboard/pkg/oci"
+)
+
+const (
+	// maxChangelogVersions bounds the releases whose annotations are read for one changelog
+	maxChangelogVersions = 100
+	// changelogConcurrency is how many release manifests are read from the registry at once
+	changelogConcurrency = 8
+)
+
+// changelogRange is the releases a changelog or release notes cover, read from a rollout's
+// release registry
+type changelogRange struct {
+	from, to string
+	// versions are newest first and at most maxChangelogVersions; omitted counts the older ones
+	versions []string
+	omitted  int
+	image    string
+	opts     []crane.Option
+}
+
+// annotations reads the OCI annotations of a release in the range's registry
+func (r *changelogRange) annotations(ctx context.Context, version string) (map[string]string, error) {
+	return oci.GetImageAnnotations(ctx, r.image, version, r.opts...)
+}
+
+// getRolloutChangelog lists the releases between two versions with the revision and description
+// from their OCI annotations, by default from the deployed version to the pinned version or the
+// newest release candidate, i.e. what the pending upgrade brings
+func getRolloutChangelog(c *gin.Context) {
+	releases, ok := resolveChangelogRange(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, api.ChangelogResponse{
+		From:    releases.from,
+		To:      releases.to,
+		Entries: buildChangelog(c.Request.Context(), releases.versions, releases.annotations),
+		Omitted: releases.omitted,
+	})
+}
+
+// resolveChangelogRange lists the releases after ?from= up to and including ?to=, by default the
+// pending upgrade. It responds with an error and returns false when they cannot be listed.
+func resolveChangelogRange(c *gin.Context) (*changelogRange, bool) {
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return nil, false
+	}
+	rollout, err := k8sClient.GetRollout(c.Request.Context(), c.Param("namespace"), c.Param("name"))
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching rollout", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout", err)
+		return nil, false
+	}
+	from, to := c.Query("from"), c.Query("to")
+	if from == "" && len(rollout.Status.History) > 0 {
+		from = rollout.Status.History[0].Version.Tag
+	}
+	if to == "" && rollout.Spec.WantedVersion != nil {
+		to = *rollout.Spec.WantedVersion
+	}
+	if to == "" && len(rollout.Status.ReleaseCandidates) > 0 {
+		to = rollout.Status.ReleaseCandidates[0].Tag
+	}
+	if from == "" || to == "" {
+		api.RespondErrorDetails(c, http.StatusBadRequest, api.CodeBadRequest, "Missing versions",
+			"the rollout has no deployment or no pending release, pass from and to")
+		return nil, false
+	}
+
+	image, opts, err := rolloutRegistry(c.Request.Context(), k8sClient, rollout)
+	if err != nil {
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to get image repository", err)
+		return nil, false
+	}
+	tags, err := oci.ListRepositoryTags(c.Request.Context(), image, opts...)
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching repository tags", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeRegistry, "Failed to fetch repository tags", err)
+		return nil, false
+	}
+	versions, err := oci.VersionsBetween(tags, from, to)
+	if err != nil {
+		api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid versions", err)
+		return nil, false
+	}
+
+	releases := &changelogRange{from: from, to: to, versions: versions, image: image, opts: opts}
+	if len(versions) > maxChangelogVersions {
+		releases.omitted = len(versions) - maxChangelogVersions
+		releases.versions = versions[:maxChangelogVersions]
+	}
+	return releases, true
+}
+
+// buildChangelog reads the annotations of versions with at most changelogConcurrency lookups at
+// once. A release whose annotations cannot be read is listed with the error; entries are in the
+// order of versions.
+func buildChangelog(ctx context.Context, versions []string, annotations func(ctx context.Context, version string) (map[string]string, error)) []oci.ChangelogEntry {
+	entries := make([]oci.ChangelogEntry, len(versions))
+	sem := make(chan struct{}, changelogConcurrency)
+	var wg sync.WaitGroup
+	for i, version := range versions {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			values, err := annotations(ctx, version)
+			entries[i] = oci.NewChangelogEntry(version, values)
+			if err != nil {
+				entries[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+	return entries
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kuberik/rollout-dashboard/pkg/oci"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildChangelog(t *testing.T) {
+	entries := buildChangelog(context.Background(), []string{"v1.3.0", "v1.2.0", "v1.1.0"}, func(_ context.Context, version string) (map[string]string, error) {
+		if version == "v1.2.0" {
+			return nil, errors.New("manifest unknown")
+		}
+		return map[string]string{oci.RevisionAnnotation: "main@sha1:" + version}, nil
+	})
+	assert.Equal(t, []oci.ChangelogEntry{
+		{Version: "v1.3.0", Revision: "main@sha1:v1.3.0"},
+		{Version: "v1.2.0", Error: "manifest unknown"},
+		{Version: "v1.1.0", Revision: "main@sha1:v1.1.0"},
+	}, entries)
+}
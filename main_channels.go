@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/docker/cli/cli/config"
+	"github.com/google/go-containerregistry/pkg/crane"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/oci"
+)
+
+// defaultChannelTrackingInterval is how often rollouts tracking a channel are re-pinned
+const defaultChannelTrackingInterval = 5 * time.Minute
+
+// configuredChannels returns the channel tags from CHANNEL_TAGS, or the defaults
+func configuredChannels() []string {
+	var channels []string
+	for _, channel := range strings.Split(os.Getenv("CHANNEL_TAGS"), ",") {
+		if channel = strings.TrimSpace(channel); channel != "" {
+			channels = append(channels, channel)
+		}
+	}
+	if len(channels) == 0 {
+		return kubernetes.DefaultChannels
+	}
+	return channels
+}
+
+// rolloutRegistry returns the image of the rollout's ImageRepository and the registry options
+// (credentials from the repository's secret) needed to access it
+func rolloutRegistry(ctx context.Context, k8sClient *kubernetes.Client, rollout *rolloutv1alpha1.Rollout) (string, []crane.Option, error) {
+	imagePolicy, err := k8sClient.GetImagePolicy(ctx, rollout.Namespace, rollout.Spec.ReleasesImagePolicy.Name)
+	if err != nil {
+		return "", nil, err
+	}
+	imageRepo, err := k8sClient.GetImageRepository(ctx, rollout.Namespace, imagePolicy.Spec.ImageRepositoryRef.Name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var opts []crane.Option
+	if imageRepo.Spec.SecretRef != nil {
+		secret, err := k8sClient.GetSecret(ctx, rollout.Namespace, imageRepo.Spec.SecretRef.Name)
+		if err != nil {
+			return "", nil, err
+		}
+		configFile, err := config.LoadFromReader(bytes.NewReader(secret.Data[".dockerconfigjson"]))
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to parse Docker config: %w", err)
+		}
+		opts = append(opts, crane.WithAuthFromKeychain(&dockerConfigKeychain{config: configFile}))
+	}
+	return imageRepo.Spec.Image, opts, nil
+}
+
+// resolveChannel looks up where a channel tag points and which of the rollout's releases that is
+func resolveChannel(ctx context.Context, rollout *rolloutv1alpha1.Rollout, image string, opts []crane.Option, channel string, channels []string) api.ChannelStatus {
+	status := api.ChannelStatus{
+		Name:     channel,
+		Tracking: kubernetes.RolloutChannel(rollout) == channel,
+	}
+	digest, err := oci.ResolveTag(ctx, image, channel, opts...)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.Digest = digest
+
+	if release := kubernetes.FindReleaseByDigest(rollout, digest, channels); release != nil {
+		version := api.NewVersionInfo(*release)
+		status.Release = &version
+		status.Deployed = len(rollout.Status.History) > 0 && rollout.Status.History[0].Version.Tag == release.Tag
+		if status.Tracking && rollout.Spec.WantedVersion != nil {
+			status.InSync = *rollout.Spec.WantedVersion == release.Tag
+		}
+	}
+	return status
+}
+
+// trackChannels periodically re-pins rollouts that track a channel to the release the channel
+// currently points at. It uses the dashboard's service account and runs until ctx is cancelled.
+func trackChannels(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := syncChannels(ctx); err != nil {
+				slog.Warn("Failed to sync rollout channels", "error", err)
+			}
+		}
+	}
+}
+
+func syncChannels(ctx context.Context) error {
+	k8sClient, err := kubernetes.GetDefaultClient()
+	if err != nil {
+		return err
+	}
+	rollouts, err := k8sClient.GetRolloutsAllNamespaces(ctx)
+	if err != nil {
+		return err
+	}
+
+	channels := configuredChannels()
+	for i := range rollouts.Items {
+		rollout := &rollouts.Items[i]
+		channel := kubernetes.RolloutChannel(rollout)
+		if channel == "" {
+			continue
+		}
+		logger := slog.With("namespace", rollout.Namespace, "rollout", rollout.Name, "channel", channel)
+
+		image, opts, err := rolloutRegistry(ctx, k8sClient, rollout)
+		if err != nil {
+			logger.Warn("Failed to get registry of tracked rollout", "error", err)
+			continue
+		}
+		status := resolveChannel(ctx, rollout, image, opts, channel, channels)
+		if status.Release == nil || status.InSync {
+			continue
+		}
+		if _, err := k8sClient.SetRolloutChannel(ctx, rollout.Namespace, rollout.Name, channel, status.Release.Tag); err != nil {
+			logger.Warn("Failed to follow channel", "error", err)
+			continue
+		}
+		logger.Info("Rollout follows channel to new release", "version", status.Release.Tag)
+	}
+	return nil
+}
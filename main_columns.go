@@ -0,0 +1,32 @@
+package main
+
+import (
+	imagereflectorv1beta2 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/columns"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// rolloutColumnValues projects the extra columns of each rollout, keyed by namespace/name. Rollouts
+// read their imagePolicy and imageRepository columns from the ImagePolicy providing their releases
+// and the ImageRepository it watches; either list may be nil when the columns do not read it.
+func rolloutColumnValues(projector *columns.Projector, rollouts []rolloutv1alpha1.Rollout, imagePolicies *imagereflectorv1beta2.ImagePolicyList, imageRepositories *imagereflectorv1beta2.ImageRepositoryList) map[string]map[string]string {
+	if len(projector.Columns()) == 0 {
+		return nil
+	}
+	images := newRolloutImages(imagePolicies, imageRepositories)
+	values := make(map[string]map[string]string, len(rollouts))
+	for i := range rollouts {
+		rollout := &rollouts[i]
+		sources := map[string]client.Object{columns.SourceRollout: rollout}
+		policy, repository := images.lookup(rollout)
+		if policy != nil {
+			sources[columns.SourceImagePolicy] = policy
+		}
+		if repository != nil {
+			sources[columns.SourceImageRepository] = repository
+		}
+		values[rollout.Namespace+"/"+rollout.Name] = projector.Project(sources)
+	}
+	return values
+}
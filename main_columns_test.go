@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	imagereflectorv1beta2 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	"github.com/fluxcd/pkg/apis/meta"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/columns"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRolloutListColumns(t *testing.T) {
+	scheme, err := kubernetes.NewScheme()
+	require.NoError(t, err)
+	kubernetes.UseStaticClient(kubernetes.NewClientFrom(fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&rolloutv1alpha1.Rollout{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api", Labels: map[string]string{"example.com/tier": "gold"}},
+			Spec:       rolloutv1alpha1.RolloutSpec{ReleasesImagePolicy: corev1.LocalObjectReference{Name: "api"}},
+		},
+		&rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web"}},
+		&imagereflectorv1beta2.ImagePolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api"},
+			Spec:       imagereflectorv1beta2.ImagePolicySpec{ImageRepositoryRef: meta.NamespacedObjectReference{Name: "api-images", Namespace: "images"}},
+		},
+		&imagereflectorv1beta2.ImageRepository{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "images", Name: "api-images"},
+			Spec:       imagereflectorv1beta2.ImageRepositorySpec{Image: "ghcr.io/example/api"},
+		},
+	).Build(), nil))
+	defer kubernetes.UseStaticClient(nil)
+
+	cfg, err := columns.ParseConfig([]byte(`
+columns:
+  - name: tier
+    title: Tier
+    label: example.com/tier
+  - name: image
+    source: imageRepository
+    field: .spec.image
+`))
+	require.NoError(t, err)
+	r := newRouter(nil, nil, nil, nil, newIntegrations(nil, nil, "", nil, nil, nil), nil, nil, nil, nil, nil, nil, nil, nil, columns.New(cfg))
+
+	wantColumns := []columns.Definition{{Name: "tier", Title: "Tier"}, {Name: "image", Title: "image"}}
+	wantValues := map[string]map[string]string{
+		"shop/api": {"tier": "gold", "image": "ghcr.io/example/api"},
+		"shop/web": {},
+	}
+
+	for _, path := range []string{"/api/v1/rollouts", "/api/v1/rollouts?view=summary"} {
+		t.Run(path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			var response struct {
+				Columns      []columns.Definition         `json:"columns"`
+				ColumnValues map[string]map[string]string `json:"columnValues"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, wantColumns, response.Columns)
+			assert.Equal(t, wantValues, response.ColumnValues)
+		})
+	}
+
+	t.Run("ndjson", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/rollouts?view=summary", nil)
+		req.Header.Set("Accept", api.NDJSONContentType)
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var kinds []string
+		var values []api.RolloutColumnValues
+		scanner := bufio.NewScanner(w.Body)
+		for scanner.Scan() {
+			var entry struct {
+				Kind   string          `json:"kind"`
+				Object json.RawMessage `json:"object"`
+			}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+			kinds = append(kinds, entry.Kind)
+			if entry.Kind == api.StreamKindColumnValues {
+				var value api.RolloutColumnValues
+				require.NoError(t, json.Unmarshal(entry.Object, &value))
+				values = append(values, value)
+			}
+		}
+		assert.Equal(t, []string{
+			api.StreamKindColumn, api.StreamKindColumn,
+			api.StreamKindRolloutSummary, api.StreamKindRolloutSummary,
+			api.StreamKindColumnValues, api.StreamKindColumnValues,
+		}, kinds)
+		assert.Equal(t, []api.RolloutColumnValues{
+			{Namespace: "shop", Name: "api", Values: wantValues["shop/api"]},
+			{Namespace: "shop", Name: "web", Values: map[string]string{}},
+		}, values)
+	})
+}
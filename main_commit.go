@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/gitprovider"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+	"github.com/kuberik/rollout-dashboard/pkg/oci"
+)
+
+// getRolloutCommit resolves the commit a release was built from, named by its source and
+// revision annotations, to its message, author and pull requests on GitHub or GitLab
+func getRolloutCommit(c *gin.Context, commits *gitprovider.Client) {
+	if commits == nil {
+		api.RespondErrorDetails(c, http.StatusNotFound, api.CodeNotFound, "Commit lookup is not enabled", "set GITHUB_TOKEN_FILE or GITLAB_TOKEN_FILE to enable commit lookup")
+		return
+	}
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return
+	}
+	rollout, err := k8sClient.GetRollout(c.Request.Context(), c.Param("namespace"), c.Param("name"))
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching rollout", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout", err)
+		return
+	}
+	image, opts, err := rolloutRegistry(c.Request.Context(), k8sClient, rollout)
+	if err != nil {
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to get image repository", err)
+		return
+	}
+	annotations, err := oci.GetImageAnnotations(c.Request.Context(), image, c.Param("version"), opts...)
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching image annotations", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeRegistry, "Failed to fetch image annotations", err)
+		return
+	}
+	source, revision := annotations[oci.SourceAnnotation], annotations[oci.RevisionAnnotation]
+	if source == "" || revision == "" {
+		api.RespondErrorDetails(c, http.StatusNotFound, api.CodeNotFound, "Release has no commit",
+			"the release needs the "+oci.SourceAnnotation+" and "+oci.RevisionAnnotation+" annotations")
+		return
+	}
+
+	commit, err := commits.GetCommit(c.Request.Context(), source, revision)
+	switch {
+	case errors.Is(err, gitprovider.ErrUnsupportedSource), errors.Is(err, gitprovider.ErrInvalidRevision), errors.Is(err, gitprovider.ErrCommitNotFound):
+		api.RespondError(c, http.StatusNotFound, api.CodeNotFound, "Commit not found", err)
+		return
+	case err != nil:
+		logging.FromContext(c).Error("Error fetching commit", "error", err)
+		api.RespondError(c, http.StatusBadGateway, api.CodeGitProvider, "Failed to fetch commit from the git provider", err)
+		return
+	}
+	c.JSON(http.StatusOK, api.CommitResponse{Version: c.Param("version"), Source: source, Revision: revision, Commit: commit})
+}
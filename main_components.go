@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+	"github.com/kuberik/rollout-dashboard/pkg/oci"
+)
+
+// rolloutComponents returns the components configured with the rollout's components annotation,
+// responding with an error when it cannot be parsed or does not define component
+func rolloutComponents(c *gin.Context, rollout *rolloutv1alpha1.Rollout, component string) ([]oci.Component, bool) {
+	components, err := oci.ParseComponents(rollout.Annotations[kubernetes.ComponentsAnnotation])
+	if err != nil {
+		api.RespondError(c, http.StatusConflict, api.CodeConflict, "Invalid "+kubernetes.ComponentsAnnotation+" annotation", err)
+		return nil, false
+	}
+	if component != "" && !slices.ContainsFunc(components, func(defined oci.Component) bool { return defined.Name == component }) {
+		api.RespondErrorDetails(c, http.StatusNotFound, api.CodeNotFound, "Component not found",
+			fmt.Sprintf("the rollout's %s annotation does not define component %q", kubernetes.ComponentsAnnotation, component))
+		return nil, false
+	}
+	return components, true
+}
+
+// newComponentsResponse lists the files of version by component
+func newComponentsResponse(version string, files []oci.File, components []oci.Component) api.ComponentsResponse {
+	split := oci.SplitComponents(files, components)
+	names := func(files []oci.File) []string {
+		result := []string{}
+		for _, file := range files {
+			result = append(result, file.Name)
+		}
+		slices.Sort(result)
+		return result
+	}
+	response := api.ComponentsResponse{Version: version, Components: []api.ComponentFiles{}, Unassigned: names(split[""])}
+	for _, component := range components {
+		response.Components = append(response.Components, api.ComponentFiles{Component: component, Files: names(split[component.Name])})
+	}
+	return response
+}
+
+// newComponentDiffResponse compares two versions component by component. When only is set, other
+// components and unassigned files are left out.
+func newComponentDiffResponse(from, to string, fromFiles, toFiles []oci.File, components []oci.Component, only string) api.ComponentDiffResponse {
+	before, after := oci.SplitComponents(fromFiles, components), oci.SplitComponents(toFiles, components)
+	response := api.ComponentDiffResponse{From: from, To: to, Components: []api.ComponentDiff{}, Unassigned: []oci.FileDiff{}}
+	for _, component := range components {
+		if only != "" && component.Name != only {
+			continue
+		}
+		response.Components = append(response.Components, api.ComponentDiff{
+			Component: component,
+			Files:     oci.DiffFiles(before[component.Name], after[component.Name]),
+		})
+	}
+	if only == "" {
+		response.Unassigned = oci.DiffFiles(before[""], after[""])
+	}
+	return response
+}
+
+// getRolloutComponents lists the files of a release (by default the deployed one) by component
+func getRolloutComponents(c *gin.Context) {
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return
+	}
+	rollout, err := k8sClient.GetRollout(c.Request.Context(), c.Param("namespace"), c.Param("name"))
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching rollout", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout", err)
+		return
+	}
+	components, ok := rolloutComponents(c, rollout, "")
+	if !ok {
+		return
+	}
+	version := c.Query("version")
+	if version == "" && len(rollout.Status.History) > 0 {
+		version = rollout.Status.History[0].Version.Tag
+	}
+	if version == "" {
+		api.RespondErrorDetails(c, http.StatusBadRequest, api.CodeBadRequest, "Missing version", "the rollout has no deployment, pass version")
+		return
+	}
+
+	image, opts, err := rolloutRegistry(c.Request.Context(), k8sClient, rollout)
+	if err != nil {
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to get image repository", err)
+		return
+	}
+	files, err := oci.GetImageContents(c.Request.Context(), image, version, opts...)
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching image contents", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeRegistry, "Failed to fetch image contents", err)
+		return
+	}
+	c.JSON(http.StatusOK, newComponentsResponse(version, files, components))
+}
+
+// diffRolloutComponents compares two releases component by component, by default the previous
+// and the current deployment
+func diffRolloutComponents(c *gin.Context) {
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return
+	}
+	rollout, err := k8sClient.GetRollout(c.Request.Context(), c.Param("namespace"), c.Param("name"))
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching rollout", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout", err)
+		return
+	}
+	only := c.Query("component")
+	components, ok := rolloutComponents(c, rollout, only)
+	if !ok {
+		return
+	}
+	from, to := c.Query("from"), c.Query("to")
+	if to == "" && len(rollout.Status.History) > 0 {
+		to = rollout.Status.History[0].Version.Tag
+	}
+	if from == "" && len(rollout.Status.History) > 1 {
+		from = rollout.Status.History[1].Version.Tag
+	}
+	if from == "" || to == "" {
+		api.RespondErrorDetails(c, http.StatusBadRequest, api.CodeBadRequest, "Missing versions",
+			"the rollout has fewer than two deployments, pass from and to")
+		return
+	}
+
+	image, opts, err := rolloutRegistry(c.Request.Context(), k8sClient, rollout)
+	if err != nil {
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to get image repository", err)
+		return
+	}
+	var contents [2][]oci.File
+	for i, version := range []string{from, to} {
+		contents[i], err = oci.GetImageContents(c.Request.Context(), image, version, opts...)
+		if err != nil {
+			logging.FromContext(c).Error("Error fetching image contents", "version", version, "error", err)
+			api.RespondError(c, http.StatusInternalServerError, api.CodeRegistry, "Failed to fetch image contents of "+version, err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, newComponentDiffResponse(from, to, contents[0], contents[1], components, only))
+}
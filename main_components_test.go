@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/kuberik/rollout-dashboard/pkg/oci"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComponentResponses(t *testing.T) {
+	components := []oci.Component{{Name: "api", Path: "apps/api"}, {Name: "worker", Path: "apps/worker"}}
+	from := []oci.File{
+		{Name: "apps/api/deployment.yaml", Content: []byte("image: api:v1\n")},
+		{Name: "apps/worker/deployment.yaml", Content: []byte("image: worker:v1\n")},
+		{Name: "kustomization.yaml", Content: []byte("resources: []\n")},
+	}
+	to := []oci.File{
+		{Name: "apps/api/deployment.yaml", Content: []byte("image: api:v2\n")},
+		{Name: "apps/worker/deployment.yaml", Content: []byte("image: worker:v1\n")},
+		{Name: "kustomization.yaml", Content: []byte("resources: [apps]\n")},
+	}
+
+	listing := newComponentsResponse("v2", to, components)
+	require.Len(t, listing.Components, 2)
+	assert.Equal(t, []string{"apps/api/deployment.yaml"}, listing.Components[0].Files)
+	assert.Equal(t, []string{"kustomization.yaml"}, listing.Unassigned)
+
+	diff := newComponentDiffResponse("v1", "v2", from, to, components, "")
+	require.Len(t, diff.Components, 2)
+	assert.Len(t, diff.Components[0].Files, 1)
+	assert.Empty(t, diff.Components[1].Files)
+	assert.Len(t, diff.Unassigned, 1)
+
+	// A single component leaves out the others and unassigned files
+	diff = newComponentDiffResponse("v1", "v2", from, to, components, "worker")
+	require.Len(t, diff.Components, 1)
+	assert.Equal(t, "worker", diff.Components[0].Name)
+	assert.Empty(t, diff.Unassigned)
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	imagereflectorv1beta2 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+)
+
+const (
+	// defaultDetailCacheTTL bounds how long a cached rollout detail is served, as a safety net for
+	// changes the watches did not report
+	defaultDetailCacheTTL = 5 * time.Minute
+	// maxDetailCacheEntries bounds the cached details; the oldest are evicted first
+	maxDetailCacheEntries = 1000
+)
+
+// getRolloutDetail assembles the rollout detail from the rollout and its associated objects.
+// Objects other than the rollout that cannot be read are left out and logged; complete reports
+// whether all of them could be read, so incomplete details are not cached.
+func getRolloutDetail(ctx context.Context, logger *slog.Logger, k8sClient *kubernetes.Client, namespace, name string) (detail *api.RolloutDetailResponse, complete bool, err error) {
+	complete = true
+
+	// Get Rollout
+	rollout, err := k8sClient.GetRollout(ctx, namespace, name)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Get associated Kustomizations that reference this rollout
+	kustomizations, err := k8sClient.GetKustomizationsByRolloutAnnotation(ctx, namespace, name)
+	if err != nil {
+		logger.Warn("Error fetching kustomizations", "error", err)
+		complete = false
+	}
+
+	// Get associated HelmReleases, for rollouts deployed with Helm charts
+	helmReleases, err := k8sClient.GetHelmReleasesByRolloutAnnotation(ctx, namespace, name)
+	if err != nil {
+		logger.Warn("Error fetching helm releases", "error", err)
+		complete = false
+	}
+
+	// Get associated OCIRepositories that reference this rollout
+	ociRepositories, err := k8sClient.GetOCIRepositoriesByRolloutAnnotation(ctx, namespace, name)
+	if err != nil {
+		logger.Warn("Error fetching OCI repositories", "error", err)
+		complete = false
+	}
+
+	// Get associated RolloutGates that reference this rollout
+	rolloutGates, err := k8sClient.GetRolloutGatesByRolloutReference(ctx, namespace, name)
+	if err != nil {
+		logger.Warn("Error fetching rollout gates", "error", err)
+		complete = false
+	}
+
+	// Get associated KuberikEnvironment that references this rollout
+	environment, err := k8sClient.GetEnvironmentByRolloutReference(ctx, namespace, name)
+	if err != nil {
+		logger.Warn("Error fetching environment", "error", err)
+		complete = false
+	}
+
+	// Resolve which upstream rollouts the gates wait for, e.g. staging for production
+	var gateDependencies []api.GateDependency
+	if rolloutGates != nil && len(rolloutGates.Items) > 0 {
+		gateDependencies, err = getGateDependencies(ctx, k8sClient, rolloutGates.Items)
+		if err != nil {
+			logger.Warn("Error resolving gate dependencies", "error", err)
+			complete = false
+		}
+	}
+
+	// Migration Jobs are a common cause of stuck bakes, so their runs are shown per version
+	var migrations []api.Migration
+	if kustomizations != nil {
+		migrationJobs, err := k8sClient.GetMigrationJobs(ctx, kustomizations.Items)
+		if err != nil {
+			logger.Warn("Error fetching migration jobs", "error", err)
+			complete = false
+		} else {
+			migrations = api.NewMigrations(migrationJobs, rollout.Status.History)
+		}
+	}
+
+	// Try to get the KruiseRollout (may not exist)
+	kruiseRollout, err := k8sClient.GetKruiseRollout(ctx, namespace, name)
+	if err != nil {
+		// KruiseRollout might not exist, that's okay
+		kruiseRollout = nil
+	}
+
+	// Get all RolloutTests in the namespace (they will be filtered by rollout name in frontend)
+	// We fetch all tests and let the frontend filter by the actual KruiseRollout name
+	rolloutTests, err := k8sClient.GetAllRolloutTests(ctx, namespace)
+	if err != nil {
+		logger.Warn("Error fetching rollout tests", "error", err)
+		// Continue without rollout tests if there's an error
+		rolloutTests = nil
+		complete = false
+	}
+
+	// Get the ImageRepository's scanTime for the rollout's ImagePolicy
+	var imageRepoScanTime string
+	var imagePolicy *imagereflectorv1beta2.ImagePolicy
+	if rollout.Spec.ReleasesImagePolicy.Name != "" {
+		imagePolicy, err = k8sClient.GetImagePolicy(ctx, namespace, rollout.Spec.ReleasesImagePolicy.Name)
+		if err != nil {
+			imagePolicy = nil
+		} else if imagePolicy.Spec.ImageRepositoryRef.Name != "" {
+			imageRepo, err := k8sClient.GetImageRepository(ctx, namespace, imagePolicy.Spec.ImageRepositoryRef.Name)
+			if err == nil && imageRepo.Status.LastScanResult != nil {
+				imageRepoScanTime = imageRepo.Status.LastScanResult.ScanTime.Format(time.RFC3339)
+			}
+		}
+	}
+
+	return &api.RolloutDetailResponse{
+		Rollout:           rollout,
+		Kustomizations:    kustomizations,
+		HelmReleases:      helmReleases,
+		OCIRepositories:   ociRepositories,
+		RolloutGates:      rolloutGates,
+		Environment:       environment,
+		KruiseRollout:     kruiseRollout,
+		RolloutTests:      rolloutTests,
+		ImageRepoScanTime: imageRepoScanTime,
+		BlueGreen:         api.NewBlueGreenStatus(kruiseRollout),
+		GateDependencies:  gateDependencies,
+		Migrations:        migrations,
+		FluxStatus:        api.NewFluxStatus(kustomizations, helmReleases, ociRepositories, imagePolicy),
+	}, complete, nil
+}
+
+// detailScopes returns what a detail depends on: the namespaces its objects live in and, for
+// gate dependencies resolved from the Environments of all namespaces, the Environment kind
+func detailScopes(detail *api.RolloutDetailResponse) []string {
+	scopes := []string{detail.Rollout.Namespace}
+	for _, migration := range detail.Migrations {
+		scopes = append(scopes, migration.Namespace)
+	}
+	if detail.RolloutGates != nil && len(detail.RolloutGates.Items) > 0 {
+		scopes = append(scopes, kindScope(kubernetes.KindEnvironment))
+		for _, dependency := range detail.GateDependencies {
+			for _, rollout := range dependency.Rollouts {
+				scopes = append(scopes, rollout.Namespace)
+			}
+		}
+	}
+	return scopes
+}
+
+// kindScope is the scope of all objects of a kind; namespaces cannot contain a slash
+func kindScope(kind string) string {
+	return "kind/" + kind
+}
+
+// detailCache caches assembled rollout details per rollout and caller credentials, so users only
+// see details read with their own permissions. Entries are invalidated by watch events: every
+// change records a version per namespace and kind, and an entry is only served when nothing it
+// depends on changed after it began to be assembled. Until the watches are running nothing is
+// cached.
+type detailCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	synced  bool
+	version uint64
+	// resetAt is the version the watches were last (re)started at
+	resetAt uint64
+	// changedAt holds the version of the latest change per scope, see detailScopes
+	changedAt map[string]uint64
+	entries   map[detailCacheKey]*detailCacheEntry
+}
+
+type detailCacheKey struct {
+	credentials string
+	namespace   string
+	name        string
+}
+
+type detailCacheEntry struct {
+	detail *api.RolloutDetailResponse
+	scopes []string
+	// version is the cache version when assembling the detail began
+	version   uint64
+	fetchedAt time.Time
+}
+
+func newDetailCache(ttl time.Duration) *detailCache {
+	return &detailCache{
+		ttl:       ttl,
+		now:       time.Now,
+		changedAt: map[string]uint64{},
+		entries:   map[detailCacheKey]*detailCacheEntry{},
+	}
+}
+
+// begin returns the version to pass to put once the detail is assembled
+func (dc *detailCache) begin() uint64 {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	return dc.version
+}
+
+// get returns the cached detail, or nil when there is none or it may be stale
+func (dc *detailCache) get(key detailCacheKey) *api.RolloutDetailResponse {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	entry, ok := dc.entries[key]
+	if !ok {
+		return nil
+	}
+	if !dc.fresh(entry) {
+		delete(dc.entries, key)
+		return nil
+	}
+	return entry.detail
+}
+
+// put caches detail unless something it depends on changed since version
+func (dc *detailCache) put(key detailCacheKey, version uint64, detail *api.RolloutDetailResponse) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	entry := &detailCacheEntry{detail: detail, scopes: detailScopes(detail), version: version, fetchedAt: dc.now()}
+	if !dc.synced || !dc.fresh(entry) {
+		return
+	}
+	dc.entries[key] = entry
+	if len(dc.entries) > maxDetailCacheEntries {
+		dc.evict()
+	}
+}
+
+func (dc *detailCache) fresh(entry *detailCacheEntry) bool {
+	if entry.version < dc.resetAt || dc.now().Sub(entry.fetchedAt) >= dc.ttl {
+		return false
+	}
+	for _, scope := range entry.scopes {
+		if dc.changedAt[scope] > entry.version {
+			return false
+		}
+	}
+	return true
+}
+
+// evict removes stale entries and, if that is not enough, the oldest
+func (dc *detailCache) evict() {
+	var oldestKey detailCacheKey
+	var oldest *detailCacheEntry
+	for key, entry := range dc.entries {
+		if !dc.fresh(entry) {
+			delete(dc.entries, key)
+			continue
+		}
+		if oldest == nil || entry.fetchedAt.Before(oldest.fetchedAt) {
+			oldestKey, oldest = key, entry
+		}
+	}
+	if len(dc.entries) > maxDetailCacheEntries {
+		delete(dc.entries, oldestKey)
+	}
+}
+
+// changed invalidates the details depending on objects of kind in namespace
+func (dc *detailCache) changed(kind, namespace string) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.version++
+	dc.changedAt[namespace] = dc.version
+	dc.changedAt[kindScope(kind)] = dc.version
+}
+
+// setSynced enables the cache while all watches are running. Changes may have been missed while
+// they were not, so everything cached or being assembled before is dropped.
+func (dc *detailCache) setSynced(synced bool) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.synced = synced
+	dc.version++
+	dc.resetAt = dc.version
+	dc.changedAt = map[string]uint64{}
+	dc.entries = map[detailCacheKey]*detailCacheEntry{}
+}
+
+// watch keeps the cache in sync with the objects details are assembled from, with the service
+// account's credentials so changes are seen regardless of who cached the details
+func (dc *detailCache) watch(ctx context.Context) {
+	k8sClient, err := kubernetes.GetDefaultClient()
+	if err != nil {
+		slog.Warn("Rollout details are not cached", "error", err)
+		return
+	}
+	if err := k8sClient.WatchDetailObjects(ctx, dc.changed, dc.setSynced); err != nil && ctx.Err() == nil {
+		slog.Warn("Rollout details are not cached", "error", err)
+	}
+}
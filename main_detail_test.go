@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDetailCache(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	dc := newDetailCache(time.Minute)
+	dc.now = func() time.Time { return now }
+	key := detailCacheKey{credentials: "jane", namespace: "shop", name: "api"}
+	detail := &api.RolloutDetailResponse{Rollout: &rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api"}}}
+
+	// Nothing is cached until the watches are running
+	dc.put(key, dc.begin(), detail)
+	assert.Nil(t, dc.get(key))
+
+	dc.setSynced(true)
+	dc.put(key, dc.begin(), detail)
+	assert.Same(t, detail, dc.get(key))
+	assert.Nil(t, dc.get(detailCacheKey{credentials: "john", namespace: "shop", name: "api"}), "other credentials")
+
+	// Changes in other namespaces keep the entry
+	dc.changed(kubernetes.KindRollout, "billing")
+	assert.Same(t, detail, dc.get(key))
+	dc.changed(kubernetes.KindKustomization, "shop")
+	assert.Nil(t, dc.get(key))
+
+	// A change while the detail was assembled prevents caching it
+	version := dc.begin()
+	dc.changed(kubernetes.KindRolloutTest, "shop")
+	dc.put(key, version, detail)
+	assert.Nil(t, dc.get(key))
+
+	// Details with gates depend on the Environments of all namespaces
+	gated := &api.RolloutDetailResponse{
+		Rollout:      detail.Rollout,
+		RolloutGates: &rolloutv1alpha1.RolloutGateList{Items: []rolloutv1alpha1.RolloutGate{{}}},
+	}
+	dc.put(key, dc.begin(), gated)
+	assert.Same(t, gated, dc.get(key))
+	dc.changed(kubernetes.KindEnvironment, "staging")
+	assert.Nil(t, dc.get(key))
+
+	// Entries expire and are dropped when a watch stops
+	dc.put(key, dc.begin(), detail)
+	now = now.Add(time.Minute)
+	assert.Nil(t, dc.get(key))
+	dc.put(key, dc.begin(), detail)
+	dc.setSynced(false)
+	assert.Nil(t, dc.get(key))
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestConditionalGets(t *testing.T) {
+	scheme, err := kubernetes.NewScheme()
+	require.NoError(t, err)
+	rollout := &rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api"}}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(rollout).Build()
+	kubernetes.UseStaticClient(kubernetes.NewClientFrom(k8sClient, nil))
+	defer kubernetes.UseStaticClient(nil)
+	r := newRouter(nil, nil, nil, nil, newIntegrations(nil, nil, "", nil, nil, nil), nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	get := func(path, ifNoneMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	paths := []string{"/api/v1/rollouts", "/api/v1/rollouts?view=summary", "/api/v1/rollouts/shop/api", "/api/v2/rollouts/shop/api"}
+	etags := map[string]string{}
+	for _, path := range paths {
+		w := get(path, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		etags[path] = w.Header().Get("ETag")
+		require.NotEmpty(t, etags[path], path)
+
+		w = get(path, etags[path])
+		assert.Equal(t, http.StatusNotModified, w.Code, path)
+		assert.Empty(t, w.Body.String(), path)
+	}
+	assert.NotEqual(t, etags["/api/v1/rollouts/shop/api"], etags["/api/v2/rollouts/shop/api"])
+
+	// Changing the rollout changes its resourceVersion and with it every tag
+	rollout.Labels = map[string]string{"team": "shop"}
+	require.NoError(t, k8sClient.Update(context.Background(), rollout))
+	for _, path := range paths {
+		w := get(path, etags[path])
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.NotEqual(t, etags[path], w.Header().Get("ETag"), path)
+	}
+}
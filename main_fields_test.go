@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestFieldSelection(t *testing.T) {
+	scheme, err := kubernetes.NewScheme()
+	require.NoError(t, err)
+	kubernetes.UseStaticClient(kubernetes.NewClientFrom(fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&rolloutv1alpha1.Rollout{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api", Labels: map[string]string{"team": "shop"}},
+			Status: rolloutv1alpha1.RolloutStatus{History: []rolloutv1alpha1.DeploymentHistoryEntry{
+				{Version: rolloutv1alpha1.VersionInfo{Tag: "2.0.0"}}, {Version: rolloutv1alpha1.VersionInfo{Tag: "1.0.0"}},
+			}},
+		},
+	).Build(), nil))
+	defer kubernetes.UseStaticClient(nil)
+	r := newRouter(nil, nil, nil, nil, newIntegrations(nil, nil, "", nil, nil, nil), nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := get("/api/v1/rollouts?fields=metadata.name,status.history[0].version.tag")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"rollouts":{"items":[{"metadata":{"name":"api"},"status":{"history":[{"version":{"tag":"2.0.0"}}]}}]}}`, w.Body.String())
+
+	w = get("/api/v2/rollouts?fields=name,namespace")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"rollouts":[{"name":"api","namespace":"shop"}]}`, w.Body.String())
+
+	w = get("/api/v1/rollouts/shop/api?fields=metadata.labels")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"rollout":{"metadata":{"labels":{"team":"shop"}}}}`, w.Body.String())
+
+	w = get("/api/v2/rollouts/shop/api?fields=labels")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"rollout":{"labels":{"team":"shop"}}}`, w.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, get("/api/v1/rollouts?fields=status.history[").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/api/v1/rollouts?fields=metadata.name&format=ndjson").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/api/v1/rollouts/shop/api?fields=..").Code)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+)
+
+// suspendFluxObject returns the handler suspending, or resuming, a Flux object of a resource in
+// kubernetes.SuspendableFluxKinds, e.g. to halt GitOps delivery during an incident. The caller
+// needs patch permission on the object; both actions are audited with the verified user.
+func suspendFluxObject(resource string, suspend bool) gin.HandlerFunc {
+	gvk := kubernetes.SuspendableFluxKinds[resource]
+	action := "Resumed"
+	if suspend {
+		action = "Suspended"
+	}
+	return func(c *gin.Context) {
+		k8sClient, ok := getK8sClient(c)
+		if !ok {
+			return
+		}
+		namespace, name := c.Param("namespace"), c.Param("name")
+
+		// Check up front, so the caller gets a clear answer instead of a failed patch
+		allowed, err := k8sClient.CheckPermission(c.Request.Context(), gvk.Group, resource, "patch", namespace, name)
+		if err != nil {
+			logging.FromContext(c).Error("Error checking permission", "error", err)
+			api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to check permission", err)
+			return
+		}
+		if !allowed {
+			api.RespondErrorDetails(c, http.StatusForbidden, api.CodeForbidden, fmt.Sprintf("Not allowed to update %s", gvk.Kind),
+				fmt.Sprintf("patching %s in namespace %s is not permitted", resource, namespace))
+			return
+		}
+
+		user, err := verifiedUser(c, k8sClient)
+		if err != nil {
+			logging.FromContext(c).Error("Error identifying user", "error", err)
+			api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to identify user", err)
+			return
+		}
+
+		if err := k8sClient.SuspendFluxObject(c.Request.Context(), gvk, namespace, name, suspend); err != nil {
+			logging.FromContext(c).Error("Error suspending flux object", "kind", gvk.Kind, "suspend", suspend, "error", err)
+			api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, fmt.Sprintf("Failed to update %s", gvk.Kind), err)
+			return
+		}
+		logging.FromContext(c).Info(action+" flux object", "audit", true, "user", user,
+			"kind", gvk.Kind, "namespace", namespace, "name", name)
+		c.JSON(http.StatusOK, api.FluxSuspendResponse{
+			Object:    api.ResourceRef{APIGroup: gvk.Group, Kind: gvk.Kind, Name: name, Namespace: namespace},
+			Suspended: suspend,
+		})
+	}
+}
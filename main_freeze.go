@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/freeze"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+)
+
+// overrideFreezeQuery is the query parameter admins change rollouts during a freeze window with
+const overrideFreezeQuery = "overrideFreeze"
+
+// freezeCheck returns an error wrapping freeze.ErrFrozen when a change to the rollout is frozen
+type freezeCheck func(ctx context.Context, namespace, name string) error
+
+// newFreezeCheck returns the check of the request's changes against the windows of freezes, which
+// may be nil. With ?overrideFreeze=true callers in an admin group of the config go ahead during a
+// window, which is written to the audit log as done by the user the API server reports; other
+// callers asking to override are answered with 403 and ok is false.
+func newFreezeCheck(c *gin.Context, k8sClient *kubernetes.Client, freezes *freeze.Policy) (check freezeCheck, ok bool) {
+	if freezes == nil {
+		return func(context.Context, string, string) error { return nil }, true
+	}
+	override := c.Query(overrideFreezeQuery) == "true"
+	var user string
+	if override {
+		userInfo, err := k8sClient.GetCurrentUserInfo(c.Request.Context())
+		if err != nil {
+			logging.FromContext(c).Error("Error getting user identity", "error", err)
+			api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to get user identity", err)
+			return nil, false
+		}
+		if !freezes.CanOverride(userInfo.Groups) {
+			api.RespondErrorDetails(c, http.StatusForbidden, api.CodeForbidden, "Not allowed to override deployment freeze",
+				"overriding a freeze window requires membership in one of the adminGroups of FREEZE_CONFIG")
+			return nil, false
+		}
+		user = userInfo.Username
+	}
+
+	frozen := policyFreezeCheck(k8sClient, freezes, time.Now())
+	logger := logging.FromContext(c)
+	return func(ctx context.Context, namespace, name string) error {
+		err := frozen(ctx, namespace, name)
+		if err != nil && override {
+			logger.Info("Overrode deployment freeze", "audit", true, "user", user, "namespace", namespace, "rollout", name, "freeze", err)
+			return nil
+		}
+		return err
+	}, true
+}
+
+// policyFreezeCheck returns the check of changes against the windows of freezes, which may be nil,
+// at now. Nobody can override it, so it checks changes made on nobody's behalf, e.g. by webhooks.
+func policyFreezeCheck(k8sClient *kubernetes.Client, freezes *freeze.Policy, now time.Time) freezeCheck {
+	return func(ctx context.Context, namespace, name string) error {
+		if freezes == nil {
+			return nil
+		}
+		var rolloutLabels map[string]string
+		if freezes.UsesLabels() {
+			rollout, err := k8sClient.GetRollout(ctx, namespace, name)
+			if err != nil {
+				return err
+			}
+			rolloutLabels = rollout.Labels
+		}
+		return freezes.Check(namespace, rolloutLabels, now)
+	}
+}
+
+// checkUnfrozen reports whether the request may change the rollout of its path, responding with
+// 423 when a freeze window is active
+func checkUnfrozen(c *gin.Context, freezes *freeze.Policy, user string) bool {
+	return checkRolloutUnfrozen(c, freezes, user, c.Param("namespace"), c.Param("name"))
+}
+
+// checkRolloutUnfrozen is checkUnfrozen for a rollout not named by the path, e.g. the rollout of a
+// RolloutTest
+func checkRolloutUnfrozen(c *gin.Context, freezes *freeze.Policy, user, namespace, name string) bool {
+	if freezes == nil {
+		return true
+	}
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return false
+	}
+	check, ok := newFreezeCheck(c, k8sClient, freezes)
+	if !ok {
+		return false
+	}
+	return respondFreezeCheck(c, user, check(c.Request.Context(), namespace, name))
+}
+
+// checkNamespaceUnfrozen reports whether the request may change a Flux object in namespace,
+// responding with 423 when a freeze window is active for a rollout of the namespace. The rollouts
+// a Flux object delivers are not known, so any frozen rollout of its namespace freezes it.
+func checkNamespaceUnfrozen(c *gin.Context, freezes *freeze.Policy, user, namespace string) bool {
+	if freezes == nil {
+		return true
+	}
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return false
+	}
+	check, ok := newFreezeCheck(c, k8sClient, freezes)
+	if !ok {
+		return false
+	}
+	// Without label selectors the namespace decides, and the check does not look up the rollout
+	names := []string{""}
+	if freezes.UsesLabels() {
+		rollouts, err := k8sClient.GetRollouts(c.Request.Context(), namespace)
+		if err != nil {
+			logging.FromContext(c).Error("Error fetching rollouts", "error", err)
+			api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to check deployment freeze", err)
+			return false
+		}
+		names = names[:0]
+		for _, rollout := range rollouts.Items {
+			names = append(names, rollout.Name)
+		}
+	}
+	for _, name := range names {
+		if !respondFreezeCheck(c, user, check(c.Request.Context(), namespace, name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// respondFreezeCheck responds with the error of a freeze check, 423 when the change is frozen,
+// and reports whether the change may go ahead
+func respondFreezeCheck(c *gin.Context, user string, err error) bool {
+	if err == nil {
+		return true
+	}
+	if errors.Is(err, freeze.ErrFrozen) {
+		logging.FromContext(c).Info("Rejected change during deployment freeze", "user", user, "error", err)
+		api.RespondError(c, http.StatusLocked, api.CodeFrozen, "Deployment freeze in effect", err)
+		return false
+	}
+	logging.FromContext(c).Error("Error checking deployment freeze", "error", err)
+	api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to check deployment freeze", err)
+	return false
+}
+
+// requireUnfrozen rejects changes to the rollout of the path while a freeze window is active
+func requireUnfrozen(freezes *freeze.Policy, requestUser func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if checkUnfrozen(c, freezes, requestUser(c)) {
+			c.Next()
+		}
+	}
+}
+
+// requireNamespaceUnfrozen rejects changes to the Flux object of the path while a freeze window is
+// active for a rollout of its namespace
+func requireNamespaceUnfrozen(freezes *freeze.Policy, requestUser func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if checkNamespaceUnfrozen(c, freezes, requestUser(c), c.Param("namespace")) {
+			c.Next()
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
+	openkruisev1alpha1 "github.com/kuberik/openkruise-controller/api/v1alpha1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/freeze"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRequireUnfrozen(t *testing.T) {
+	environment := func(rollout, environment string) *envv1alpha1.Environment {
+		return &envv1alpha1.Environment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: rollout + "-" + environment},
+			Spec: envv1alpha1.EnvironmentSpec{
+				Name:        rollout,
+				Environment: environment,
+				RolloutRef:  corev1.LocalObjectReference{Name: rollout},
+			},
+		}
+	}
+	scheme, err := kubernetes.NewScheme()
+	require.NoError(t, err)
+	kubernetes.UseStaticClient(kubernetes.NewClientFrom(fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api", Labels: map[string]string{"tier": "critical"}}},
+		&rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web"}},
+		environment("api", "production"), environment("web", "production"), environment("api", "staging"),
+		&openkruisev1alpha1.RolloutTest{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api-smoke"},
+			Spec: openkruisev1alpha1.RolloutTestSpec{RolloutName: "api"}},
+		&openkruisev1alpha1.RolloutTest{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web-smoke"},
+			Spec: openkruisev1alpha1.RolloutTestSpec{RolloutName: "web"}},
+	).Build(), nil))
+	defer kubernetes.UseStaticClient(nil)
+	t.Setenv("RATE_LIMIT_MUTATION_BURST", "100")
+
+	// Frozen around the clock
+	cfg, err := freeze.ParseConfig([]byte(`
+windows:
+  - name: incident
+    schedule: "* * * * *"
+    duration: 1h
+    selector:
+      matchLabels: {tier: critical}
+    message: Checkout incident in progress
+`))
+	require.NoError(t, err)
+	r := newRouter(nil, nil, nil, nil, newIntegrations(nil, nil, "", nil, nil, nil), nil, nil, nil, nil, freeze.New(cfg), nil, nil, nil, nil)
+	post := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return w
+	}
+
+	w := post("/api/v1/rollouts/shop/api/force-deploy", `{"version":"v2"}`)
+	require.Equal(t, http.StatusLocked, w.Code, w.Body.String())
+	var errorResponse api.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errorResponse))
+	assert.Equal(t, api.CodeFrozen, errorResponse.Code)
+	assert.Contains(t, errorResponse.Details, "Checkout incident in progress")
+
+	w = post("/api/v1/rollouts/shop/api/change-version", `{"version":"v2"}`)
+	assert.Equal(t, http.StatusLocked, w.Code, w.Body.String())
+	// Scheduling is not frozen; the fake cluster cannot tell who is scheduling, which is audited
+	w = post("/api/v1/rollouts/shop/api/change-version", `{"version":"v2","scheduleAt":"2099-01-01T00:00:00Z"}`)
+	assert.NotEqual(t, http.StatusLocked, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "Failed to identify user")
+	w = post("/api/v1/rollouts/shop/web/force-deploy", `{"version":"v2"}`)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = post("/api/v1/rollouts/shop/missing/force-deploy", `{"version":"v2"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	// Rerunning a test is checked against the rollout of the test
+	w = post("/api/v1/rollout-tests/shop/api-smoke/rerun", "")
+	assert.Equal(t, http.StatusLocked, w.Code, w.Body.String())
+	w = post("/api/v1/rollout-tests/shop/web-smoke/rerun", "")
+	assert.NotEqual(t, http.StatusLocked, w.Code, w.Body.String())
+	w = post("/api/v1/rollout-tests/shop/missing/rerun", "")
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	// Flux objects deliver to the rollouts of their namespace, one of which is frozen
+	w = post("/api/v1/kustomizations/shop/apps/resume", "")
+	assert.Equal(t, http.StatusLocked, w.Code, w.Body.String())
+	w = post("/api/v1/kustomizations/shop/apps/suspend", "")
+	assert.NotEqual(t, http.StatusLocked, w.Code, w.Body.String())
+	w = post("/api/v1/kustomizations/payments/apps/resume", "")
+	assert.NotEqual(t, http.StatusLocked, w.Code, w.Body.String())
+
+	// Environments are reconciled without their frozen rollouts
+	w = post("/api/v1/environments/staging/reconcile", "")
+	assert.Equal(t, http.StatusLocked, w.Code, w.Body.String())
+	w = post("/api/v1/environments/production/reconcile", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"rollouts":[{"apiGroup":"kuberik.com","kind":"Rollout","name":"web","namespace":"shop"}]`)
+	assert.Contains(t, w.Body.String(), `"frozen":[{"apiGroup":"kuberik.com","kind":"Rollout","name":"api","namespace":"shop"}]`)
+}
+
+func TestApplyScheduledChangesDuringFreeze(t *testing.T) {
+	now := time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC)
+	scheme, err := kubernetes.NewScheme()
+	require.NoError(t, err)
+	kubernetes.UseStaticClient(kubernetes.NewClientFrom(fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		scheduledRollout(t, "api", "v2", now.Add(-time.Minute)),
+	).Build(), nil))
+	defer kubernetes.UseStaticClient(nil)
+	cfg, err := freeze.ParseConfig([]byte(`windows: [{name: weekend, schedule: "0 18 * * FRI", duration: 62h}]`))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, applyScheduledChanges(ctx, now, freeze.New(cfg)))
+	k8sClient, err := kubernetes.GetDefaultClient()
+	require.NoError(t, err)
+	rollout, err := k8sClient.GetRollout(ctx, "shop", "api")
+	require.NoError(t, err)
+	assert.NotContains(t, rollout.Annotations, kubernetes.ForceDeployAnnotation)
+	assert.NotNil(t, kubernetes.GetScheduledChange(rollout), "deferred until the window ends")
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/gitops"
+	"github.com/kuberik/rollout-dashboard/pkg/gitprovider"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+)
+
+// proposeVersion opens a pull or merge request pinning a rollout managed in git to version, or
+// unpinning it when version is nil, and responds with 202 and the proposal. The request is opened
+// with the dashboard's git credentials, so the caller must be allowed to patch the Rollout, as for
+// a change in the cluster. Proposals are attributed to the verified user and audited.
+func proposeVersion(c *gin.Context, k8sClient *kubernetes.Client, writeBack *gitops.WriteBack, target *gitops.Target, version *string, message string) {
+	namespace, name := c.Param("namespace"), c.Param("name")
+	allowed, err := k8sClient.CheckPermission(c.Request.Context(), rolloutv1alpha1.GroupVersion.Group, "rollouts", "patch", namespace, name)
+	if err != nil {
+		logging.FromContext(c).Error("Error checking permission", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to check permission", err)
+		return
+	}
+	if !allowed {
+		api.RespondErrorDetails(c, http.StatusForbidden, api.CodeForbidden, "Not allowed to change rollout",
+			fmt.Sprintf("patch rollouts in namespace %s is not permitted", namespace))
+		return
+	}
+	user, err := verifiedUser(c, k8sClient)
+	if err != nil {
+		logging.FromContext(c).Error("Error identifying user", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to identify user", err)
+		return
+	}
+
+	proposal, err := writeBack.ProposeVersion(c.Request.Context(), target, namespace, name, version, message, user)
+	switch {
+	case errors.Is(err, gitprovider.ErrNoChange):
+		api.RespondError(c, http.StatusConflict, api.CodeConflict, "Rollout is already at the requested version in git", err)
+		return
+	case errors.Is(err, gitprovider.ErrFileNotFound), errors.Is(err, gitops.ErrRolloutNotInFile):
+		api.RespondError(c, http.StatusNotFound, api.CodeNotFound, "Rollout not found in git", err)
+		return
+	case err != nil:
+		logging.FromContext(c).Error("Error proposing version change", "error", err)
+		api.RespondError(c, http.StatusBadGateway, api.CodeGitProvider, "Failed to propose the version change to the git provider", err)
+		return
+	}
+	logging.FromContext(c).Info("Proposed version change", "audit", true, "user", user,
+		"namespace", namespace, "rollout", name, "version", version, "pullRequest", proposal.PullRequest.URL)
+	c.JSON(http.StatusAccepted, api.GitOpsChangeResponse{Proposal: proposal})
+}
+
+// rejectGitOpsChange responds with 409 when a rollout is managed in git and the change cannot be
+// expressed there, returning whether it did
+func rejectGitOpsChange(c *gin.Context, target *gitops.Target, change string) bool {
+	if target == nil {
+		return false
+	}
+	api.RespondErrorDetails(c, http.StatusConflict, api.CodeConflict, "Rollout is managed in git",
+		change+" cannot be proposed to "+target.Repository+", pin the version instead")
+	return true
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+)
+
+// helmReleaseOfRollout looks up the HelmRelease named in the request among those deploying the
+// request's rollout, so the actions cannot reach HelmReleases of other rollouts. It responds with
+// the error and returns false when the rollout is not deployed by it.
+func helmReleaseOfRollout(c *gin.Context, k8sClient *kubernetes.Client) (*helmv2.HelmRelease, bool) {
+	namespace, name := c.Param("namespace"), c.Param("name")
+	helmReleases, err := k8sClient.GetHelmReleasesByRolloutAnnotation(c.Request.Context(), namespace, name)
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching helm releases", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch helm releases", err)
+		return nil, false
+	}
+	for i := range helmReleases.Items {
+		if helmReleases.Items[i].Name == c.Param("helmrelease") {
+			return &helmReleases.Items[i], true
+		}
+	}
+	api.RespondErrorDetails(c, http.StatusNotFound, api.CodeNotFound, "Helm release not found",
+		fmt.Sprintf("rollout %s/%s is not deployed by helm release %s", namespace, name, c.Param("helmrelease")))
+	return nil, false
+}
+
+// reconcileHelmRelease requests the reconciliation of a HelmRelease deploying a rollout
+func reconcileHelmRelease(c *gin.Context) {
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return
+	}
+	helmRelease, ok := helmReleaseOfRollout(c, k8sClient)
+	if !ok {
+		return
+	}
+
+	if err := k8sClient.ReconcileHelmRelease(c.Request.Context(), helmRelease.Namespace, helmRelease.Name); err != nil {
+		logging.FromContext(c).Error("Error reconciling helm release", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to reconcile helm release", err)
+		return
+	}
+	result := kubernetes.ReconcileResult{Kind: helmv2.HelmReleaseKind, Namespace: helmRelease.Namespace, Name: helmRelease.Name, Succeeded: true}
+	c.JSON(http.StatusOK, api.ReconcileResponse{
+		Message: fmt.Sprintf("Successfully triggered reconciliation of helm release %s", helmRelease.Name),
+		Results: map[string]kubernetes.ReconcileResult{result.Kind + "/" + result.Namespace + "/" + result.Name: result},
+	})
+}
+
+// suspendHelmRelease returns the handler suspending, or resuming, a HelmRelease deploying a
+// rollout. Both stop or restart deploys of the rollout and are audited with the verified user.
+func suspendHelmRelease(suspend bool) gin.HandlerFunc {
+	action := "Resumed"
+	if suspend {
+		action = "Suspended"
+	}
+	return func(c *gin.Context) {
+		k8sClient, ok := getK8sClient(c)
+		if !ok {
+			return
+		}
+		helmRelease, ok := helmReleaseOfRollout(c, k8sClient)
+		if !ok {
+			return
+		}
+
+		user, err := verifiedUser(c, k8sClient)
+		if err != nil {
+			logging.FromContext(c).Error("Error identifying user", "error", err)
+			api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to identify user", err)
+			return
+		}
+
+		updated, err := k8sClient.SuspendHelmRelease(c.Request.Context(), helmRelease.Namespace, helmRelease.Name, suspend)
+		if err != nil {
+			logging.FromContext(c).Error("Error suspending helm release", "suspend", suspend, "error", err)
+			api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to update helm release", err)
+			return
+		}
+		logging.FromContext(c).Info(action+" helm release", "audit", true, "user", user,
+			"namespace", updated.Namespace, "rollout", c.Param("name"), "helmRelease", updated.Name)
+		c.JSON(http.StatusOK, api.HelmReleaseResponse{HelmRelease: updated})
+	}
+}
@@ -1,24 +1,396 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	imagereflectorv1beta2 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
 	"github.com/gin-gonic/gin"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/auth"
 	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+	"golang.org/x/sync/errgroup"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// defaultActionWaitTimeout is used when wait=true is requested without an explicit timeout
+	defaultActionWaitTimeout = 30 * time.Second
+	// maxActionWaitTimeout bounds how long a mutating request may block waiting for the controller
+	maxActionWaitTimeout = 2 * time.Minute
 )
 
 // getK8sClient is a helper function to get Kubernetes client from context
 // It returns an error response if the client cannot be obtained
 func getK8sClient(c *gin.Context) (*kubernetes.Client, bool) {
 	k8sClient, err := kubernetes.GetClientFromContext(c)
+	if errors.Is(err, auth.ErrInvalidToken) {
+		logging.FromContext(c).Warn("Rejected token", "error", err)
+		api.RespondError(c, http.StatusUnauthorized, api.CodeUnauthorized, "Invalid token", err)
+		return nil, false
+	}
 	if err != nil {
-		log.Printf("Failed to get Kubernetes client: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to initialize Kubernetes client",
-			"details": err.Error(),
-		})
+		logging.FromContext(c).Error("Failed to get Kubernetes client", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeClientInit, "Failed to initialize Kubernetes client", err)
 		return nil, false
 	}
 	return k8sClient, true
 }
+
+// verifiedUser returns the name of the user the request's token was issued to. It is taken from
+// the verified token or, when tokens are not verified by the dashboard, from the API server, so
+// it can be recorded in audit logs and signed claims without trusting unverified token claims.
+func verifiedUser(c *gin.Context, k8sClient *kubernetes.Client) (string, error) {
+	if identity := auth.GetIdentityFromContext(c); identity != nil {
+		return identity.Username, nil
+	}
+	username, _, err := k8sClient.GetCurrentUserIdentity(c.Request.Context())
+	return username, err
+}
+
+// respondWithVersionChange writes the updated rollout for a version-changing action.
+// When the request has wait=true, it first waits (bounded by the timeout query parameter) for the
+// controller to acknowledge the version in status. A 202 is returned if the wait timed out.
+func respondWithVersionChange(c *gin.Context, k8sClient *kubernetes.Client, namespace, name, version string, updatedRollout *rolloutv1alpha1.Rollout) {
+	if wantWait, _ := strconv.ParseBool(c.Query("wait")); !wantWait {
+		c.JSON(http.StatusOK, api.VersionChangeResponse{Rollout: updatedRollout})
+		return
+	}
+
+	// The action has already been applied at this point, so an unparsable timeout falls back
+	// to the default instead of failing the request
+	timeout := defaultActionWaitTimeout
+	if parsed, err := time.ParseDuration(c.Query("timeout")); err == nil && parsed > 0 {
+		timeout = min(parsed, maxActionWaitTimeout)
+	}
+
+	// The status has not changed with the action, so its newest entry is the deployment before it
+	var previous *rolloutv1alpha1.DeploymentHistoryEntry
+	if len(updatedRollout.Status.History) > 0 {
+		previous = &updatedRollout.Status.History[0]
+	}
+	rollout, acknowledged, err := k8sClient.WaitForVersionAcknowledged(c.Request.Context(), namespace, name, version, previous, timeout)
+	if err != nil {
+		logging.FromContext(c).Error("Error waiting for version acknowledgement", "rollout", name, "version", version, "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeInternal, "Failed to wait for version acknowledgement", err)
+		return
+	}
+	if rollout == nil {
+		rollout = updatedRollout
+	}
+
+	status := http.StatusOK
+	if !acknowledged {
+		status = http.StatusAccepted
+	}
+	c.JSON(status, api.VersionChangeResponse{
+		Rollout:      rollout,
+		Acknowledged: &acknowledged,
+	})
+}
+
+// rolloutResourceRefs converts rollout names to API resource references
+func rolloutResourceRefs(rollouts []types.NamespacedName) []api.ResourceRef {
+	refs := make([]api.ResourceRef, 0, len(rollouts))
+	for _, rollout := range rollouts {
+		refs = append(refs, api.ResourceRef{
+			APIGroup:  "kuberik.com",
+			Kind:      "Rollout",
+			Name:      rollout.Name,
+			Namespace: rollout.Namespace,
+		})
+	}
+	return refs
+}
+
+// execResult describes how an exec session ended for the audit log
+func execResult(err error) string {
+	var exitErr interface{ ExitStatus() int }
+	switch {
+	case err == nil:
+		return "exit code 0"
+	case errors.As(err, &exitErr):
+		return fmt.Sprintf("exit code %d", exitErr.ExitStatus())
+	default:
+		return err.Error()
+	}
+}
+
+// getGateDependencies resolves the upstream environments and rollouts that gates wait for
+func getGateDependencies(ctx context.Context, k8sClient *kubernetes.Client, gates []rolloutv1alpha1.RolloutGate) ([]api.GateDependency, error) {
+	environments, err := k8sClient.GetEnvironmentsAllNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dependencies := kubernetes.GateDependencies(gates, environments.Items)
+	if len(dependencies) == 0 {
+		return nil, nil
+	}
+	rollouts, err := k8sClient.GetUpstreamRollouts(ctx, dependencies)
+	if err != nil {
+		return nil, err
+	}
+
+	converted := make([]api.GateDependency, 0, len(dependencies))
+	for _, dependency := range dependencies {
+		converted = append(converted, api.NewGateDependency(dependency, rollouts))
+	}
+	return converted, nil
+}
+
+// namespaceAllowlist returns the namespaces from NAMESPACE_ALLOWLIST, the candidates checked for
+// users who may not list rollouts cluster-wide. Empty means namespaces are listed instead.
+func namespaceAllowlist() []string {
+	var namespaces []string
+	for _, namespace := range strings.Split(os.Getenv("NAMESPACE_ALLOWLIST"), ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
+
+// listRequestRollouts lists the rollouts of namespace, or of every namespace for "all", that the
+// request may see. Users without cluster-wide list permission and anonymous requests get the
+// rollouts of the namespaces they can access, returned as accessibleNamespaces with the namespaces
+// skipped; accessibleNamespaces is nil otherwise. It responds with the error and returns false when
+// the rollouts cannot be listed.
+func listRequestRollouts(c *gin.Context, k8sClient *kubernetes.Client, namespace string) (*rolloutv1alpha1.RolloutList, []string, []kubernetes.SkippedNamespace, bool) {
+	var rollouts *rolloutv1alpha1.RolloutList
+	var err error
+	var accessibleNamespaces []string
+	var skippedNamespaces []kubernetes.SkippedNamespace
+	if restricted := anonymousNamespaces(c); restricted != nil && (namespace == "all" || namespace == "*" || namespace == "") {
+		// Anonymous requests list the namespaces they are limited to one by one
+		rollouts, accessibleNamespaces = &rolloutv1alpha1.RolloutList{}, restricted
+		skippedNamespaces, err = k8sClient.ListAcrossNamespaces(c.Request.Context(), rollouts, restricted)
+	} else if namespace == "all" || namespace == "*" || namespace == "" {
+		rollouts, err = k8sClient.GetRolloutsAllNamespaces(c.Request.Context())
+		if apierrors.IsForbidden(err) {
+			rollouts, accessibleNamespaces, skippedNamespaces, err = listRolloutsInAccessibleNamespaces(c.Request.Context(), k8sClient)
+			if errors.Is(err, kubernetes.ErrNoAccessibleNamespaces) {
+				api.RespondError(c, http.StatusForbidden, api.CodeForbidden, "Not allowed to list rollouts in all namespaces", err)
+				return nil, nil, nil, false
+			}
+		}
+	} else {
+		rollouts, err = k8sClient.GetRollouts(c.Request.Context(), namespace)
+	}
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching rollouts", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollouts", err)
+		return nil, nil, nil, false
+	}
+	return rollouts, accessibleNamespaces, skippedNamespaces, true
+}
+
+// relatedListTimeout bounds each list of the Flux resources fetched next to rollouts, so a slow
+// resource type cannot hold up the others
+const relatedListTimeout = 10 * time.Second
+
+// relatedKinds selects the Flux resources listRelatedResources lists
+type relatedKinds struct {
+	imagePolicies     bool
+	imageRepositories bool
+	kustomizations    bool
+	ociRepositories   bool
+}
+
+// relatedResources are the Flux resources listed next to rollouts. A list is nil when it was not
+// selected or could not be fetched.
+type relatedResources struct {
+	imagePolicies     *imagereflectorv1beta2.ImagePolicyList
+	imageRepositories *imagereflectorv1beta2.ImageRepositoryList
+	kustomizations    *kustomizev1.KustomizationList
+	ociRepositories   *sourcev1.OCIRepositoryList
+}
+
+// listRequestRolloutsWithRelated lists the rollouts like listRequestRollouts, together with the
+// selected Flux resources in the same namespaces. The resources are listed while the rollouts are,
+// in the namespaces the rollouts are expected in, and listed again only when the rollouts fall back
+// to the namespaces the user can access.
+func listRequestRolloutsWithRelated(c *gin.Context, k8sClient *kubernetes.Client, namespace string, kinds relatedKinds) (*rolloutv1alpha1.RolloutList, relatedResources, []kubernetes.SkippedNamespace, bool) {
+	var expectedNamespaces []string
+	if namespace == "all" || namespace == "*" || namespace == "" {
+		expectedNamespaces = anonymousNamespaces(c)
+	}
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	listed := make(chan relatedResources, 1)
+	go func() {
+		listed <- listRelatedResources(ctx, c, k8sClient, namespace, expectedNamespaces, kinds)
+	}()
+
+	rollouts, accessibleNamespaces, skippedNamespaces, ok := listRequestRollouts(c, k8sClient, namespace)
+	if !ok {
+		cancel()
+		<-listed
+		return nil, relatedResources{}, nil, false
+	}
+	related := <-listed
+	if (accessibleNamespaces == nil) != (expectedNamespaces == nil) || !slices.Equal(accessibleNamespaces, expectedNamespaces) {
+		related = listRelatedResources(c.Request.Context(), c, k8sClient, namespace, accessibleNamespaces, kinds)
+	}
+	return rollouts, related, skippedNamespaces, true
+}
+
+// listRelatedResources lists the selected Flux resources of namespace, or of accessibleNamespaces
+// when they are known. The kinds are listed concurrently, each within relatedListTimeout. Errors
+// are logged and leave what was listed, as the resources only add to the rollouts.
+func listRelatedResources(ctx context.Context, c *gin.Context, k8sClient *kubernetes.Client, namespace string, accessibleNamespaces []string, kinds relatedKinds) relatedResources {
+	var related relatedResources
+	var group errgroup.Group
+	fetch := func(selected bool, kind string, list func(ctx context.Context) error) {
+		if !selected {
+			return
+		}
+		group.Go(func() error {
+			listCtx, cancel := context.WithTimeout(ctx, relatedListTimeout)
+			defer cancel()
+			// Lists abandoned with the request are not worth a warning
+			if err := list(listCtx); err != nil && ctx.Err() == nil {
+				logging.FromContext(c).Warn("Error fetching "+kind, "error", err)
+			}
+			return nil
+		})
+	}
+	fetch(kinds.imagePolicies, "image policies", func(ctx context.Context) (err error) {
+		related.imagePolicies, err = listRelated(ctx, k8sClient, namespace, accessibleNamespaces,
+			k8sClient.GetImagePoliciesAllNamespaces, k8sClient.GetImagePolicies)
+		return err
+	})
+	fetch(kinds.imageRepositories, "image repositories", func(ctx context.Context) (err error) {
+		related.imageRepositories, err = listRelated(ctx, k8sClient, namespace, accessibleNamespaces,
+			k8sClient.GetImageRepositoriesAllNamespaces, k8sClient.GetImageRepositories)
+		return err
+	})
+	fetch(kinds.kustomizations, "kustomizations", func(ctx context.Context) (err error) {
+		related.kustomizations, err = listRelated(ctx, k8sClient, namespace, accessibleNamespaces,
+			k8sClient.GetKustomizationsAllNamespaces, k8sClient.GetKustomizations)
+		return err
+	})
+	fetch(kinds.ociRepositories, "OCI repositories", func(ctx context.Context) (err error) {
+		related.ociRepositories, err = listRelated(ctx, k8sClient, namespace, accessibleNamespaces,
+			k8sClient.GetOCIRepositoriesAllNamespaces, k8sClient.GetOCIRepositories)
+		return err
+	})
+	_ = group.Wait()
+	return related
+}
+
+// listRelated lists one kind of resources across the accessible namespaces when they are known,
+// otherwise in all namespaces or the requested one
+func listRelated[L any, PL interface {
+	*L
+	client.ObjectList
+}](ctx context.Context, k8sClient *kubernetes.Client, namespace string, accessibleNamespaces []string, all func(context.Context) (PL, error), in func(context.Context, string) (PL, error)) (PL, error) {
+	switch {
+	case accessibleNamespaces != nil:
+		list := PL(new(L))
+		_, err := k8sClient.ListAcrossNamespaces(ctx, list, accessibleNamespaces)
+		return list, err
+	case namespace == "all" || namespace == "*" || namespace == "":
+		return all(ctx)
+	default:
+		return in(ctx, namespace)
+	}
+}
+
+// rolloutImages finds the ImagePolicy providing the releases of each rollout and the
+// ImageRepository it watches
+type rolloutImages struct {
+	policies     map[string]*imagereflectorv1beta2.ImagePolicy
+	repositories map[string]*imagereflectorv1beta2.ImageRepository
+}
+
+// newRolloutImages indexes the policies and repositories, either of which may be nil
+func newRolloutImages(imagePolicies *imagereflectorv1beta2.ImagePolicyList, imageRepositories *imagereflectorv1beta2.ImageRepositoryList) *rolloutImages {
+	images := &rolloutImages{
+		policies:     map[string]*imagereflectorv1beta2.ImagePolicy{},
+		repositories: map[string]*imagereflectorv1beta2.ImageRepository{},
+	}
+	if imagePolicies != nil {
+		for i := range imagePolicies.Items {
+			policy := &imagePolicies.Items[i]
+			images.policies[policy.Namespace+"/"+policy.Name] = policy
+		}
+	}
+	if imageRepositories != nil {
+		for i := range imageRepositories.Items {
+			repository := &imageRepositories.Items[i]
+			images.repositories[repository.Namespace+"/"+repository.Name] = repository
+		}
+	}
+	return images
+}
+
+// lookup returns the ImagePolicy and ImageRepository of a rollout, nil when they are not indexed
+func (i *rolloutImages) lookup(rollout *rolloutv1alpha1.Rollout) (*imagereflectorv1beta2.ImagePolicy, *imagereflectorv1beta2.ImageRepository) {
+	policy, ok := i.policies[rollout.Namespace+"/"+rollout.Spec.ReleasesImagePolicy.Name]
+	if !ok {
+		return nil, nil
+	}
+	ref := policy.Spec.ImageRepositoryRef
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = policy.Namespace
+	}
+	return policy, i.repositories[namespace+"/"+ref.Name]
+}
+
+// listRolloutsInAccessibleNamespaces lists rollouts for users without cluster-wide list
+// permission by aggregating the namespaces they may access. It returns the namespaces listed,
+// which is never nil, and the namespaces skipped.
+func listRolloutsInAccessibleNamespaces(ctx context.Context, k8sClient *kubernetes.Client) (*rolloutv1alpha1.RolloutList, []string, []kubernetes.SkippedNamespace, error) {
+	namespaces, skipped, err := k8sClient.AccessibleNamespaces(ctx, "kuberik.com", "rollouts", namespaceAllowlist())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	rollouts := &rolloutv1alpha1.RolloutList{}
+	listSkipped, err := k8sClient.ListAcrossNamespaces(ctx, rollouts, namespaces)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	listed := []string{}
+	failed := map[string]bool{}
+	for _, s := range listSkipped {
+		failed[s.Namespace] = true
+	}
+	for _, namespace := range namespaces {
+		if !failed[namespace] {
+			listed = append(listed, namespace)
+		}
+	}
+	skipped = append(skipped, listSkipped...)
+	sort.Slice(skipped, func(i, j int) bool {
+		return skipped[i].Namespace < skipped[j].Namespace
+	})
+	return rollouts, listed, skipped, nil
+}
+
+// rolloutDetailETag tags a rollout's details by the resourceVersions of the resources they are
+// read from. The data derived from other resources, like gate dependencies and migrations, is
+// small and added as it is.
+func rolloutDetailETag(c *gin.Context, detail *api.RolloutDetailResponse) string {
+	etag := api.NewETag(c)
+	etag.AddObjects(detail.Rollout, detail.Kustomizations, detail.HelmReleases, detail.OCIRepositories, detail.RolloutGates,
+		detail.Environment, detail.KruiseRollout, detail.RolloutTests)
+	etag.AddJSON(detail.ImageRepoScanTime, detail.BlueGreen, detail.GateDependencies, detail.Migrations,
+		detail.FluxStatus, detail.Ownership, detail.Links)
+	return etag.String()
+}
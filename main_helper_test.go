@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	imagereflectorv1beta2 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	"github.com/gin-gonic/gin"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestListRequestRolloutsWithRelated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	scheme, err := kubernetes.NewScheme()
+	require.NoError(t, err)
+	k8sClient := kubernetes.NewClientFrom(fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api"}},
+		&imagereflectorv1beta2.ImagePolicy{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api"}},
+		&kustomizev1.Kustomization{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api"}},
+		&sourcev1.OCIRepository{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api"}},
+	).WithInterceptorFuncs(interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			if _, ok := list.(*kustomizev1.KustomizationList); ok {
+				return errors.New("kustomizations unavailable")
+			}
+			return c.List(ctx, list, opts...)
+		},
+	}).Build(), nil)
+
+	for _, namespace := range []string{"all", "shop"} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/rollouts?namespace="+namespace, nil)
+		rollouts, related, _, ok := listRequestRolloutsWithRelated(c, k8sClient, namespace,
+			relatedKinds{imagePolicies: true, kustomizations: true, ociRepositories: true})
+		require.True(t, ok, namespace)
+		require.Len(t, rollouts.Items, 1, namespace)
+
+		// A kind failing to list leaves the others
+		require.NotNil(t, related.imagePolicies, namespace)
+		assert.Len(t, related.imagePolicies.Items, 1, namespace)
+		require.NotNil(t, related.ociRepositories, namespace)
+		assert.Len(t, related.ociRepositories.Items, 1, namespace)
+		assert.Nil(t, related.kustomizations, namespace)
+		assert.Nil(t, related.imageRepositories, "kinds not selected are not listed")
+	}
+}
+
+func TestRespondWithVersionChange_Wait(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	scheme, err := kubernetes.NewScheme()
+	require.NoError(t, err)
+	deployedAt := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	entry := func(id int64, version string, at metav1.Time) rolloutv1alpha1.DeploymentHistoryEntry {
+		return rolloutv1alpha1.DeploymentHistoryEntry{ID: ptr.To(id), Version: rolloutv1alpha1.VersionInfo{Tag: version}, Timestamp: at}
+	}
+	// The rollout already runs v2, which is deployed again
+	before := &rolloutv1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api"},
+		Status:     rolloutv1alpha1.RolloutStatus{History: []rolloutv1alpha1.DeploymentHistoryEntry{entry(1, "v2", deployedAt)}},
+	}
+
+	// newClient serves the rollout as before for the first gets, and with status from then on
+	newClient := func(gets int, status rolloutv1alpha1.RolloutStatus) *kubernetes.Client {
+		calls := 0
+		return kubernetes.NewClientFrom(fake.NewClientBuilder().WithScheme(scheme).WithObjects(before.DeepCopy()).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+					if err := c.Get(ctx, key, obj, opts...); err != nil {
+						return err
+					}
+					if calls++; calls > gets {
+						obj.(*rolloutv1alpha1.Rollout).Status = status
+					}
+					return nil
+				},
+			}).Build(), nil)
+	}
+	respond := func(ctx context.Context, k8sClient *kubernetes.Client, query string) (*httptest.ResponseRecorder, api.VersionChangeResponse) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/?wait=true&"+query, nil).WithContext(ctx)
+		respondWithVersionChange(c, k8sClient, "shop", "api", "v2", before.DeepCopy())
+		var response api.VersionChangeResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w, response
+	}
+
+	t.Run("acknowledged", func(t *testing.T) {
+		redeployed := rolloutv1alpha1.RolloutStatus{History: []rolloutv1alpha1.DeploymentHistoryEntry{
+			entry(2, "v2", metav1.Now()), entry(1, "v2", deployedAt),
+		}}
+		w, response := respond(context.Background(), newClient(1, redeployed), "timeout=10s")
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NotNil(t, response.Acknowledged)
+		assert.True(t, *response.Acknowledged)
+		assert.Equal(t, int64(2), *response.Rollout.Status.History[0].ID)
+	})
+
+	t.Run("timed out", func(t *testing.T) {
+		// The deployment that was current before the change has the version, but is not the change
+		w, response := respond(context.Background(), newClient(0, before.Status), "timeout=10ms")
+		assert.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+		require.NotNil(t, response.Acknowledged)
+		assert.False(t, *response.Acknowledged)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		started := time.Now()
+		w, response := respond(ctx, newClient(0, before.Status), "timeout=1m")
+		assert.Less(t, time.Since(started), 10*time.Second, "the wait ends with the request")
+		assert.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+		require.NotNil(t, response.Acknowledged)
+		assert.False(t, *response.Acknowledged)
+	})
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/oci"
+)
+
+const (
+	// maxHistoryRegistryLookups bounds the registry reads for release times of reconstructed
+	// deployments per request
+	maxHistoryRegistryLookups = 10
+	// ociCreatedAnnotation is the standard OCI annotation holding an image's creation time
+	ociCreatedAnnotation = "org.opencontainers.image.created"
+)
+
+// getRolloutHistory reconstructs the rollout's deployment history, see
+// kubernetes.ReconstructHistory. Kustomizations and events that cannot be read are left out and
+// logged, the history is best effort either way.
+func getRolloutHistory(ctx context.Context, logger *slog.Logger, k8sClient *kubernetes.Client, namespace, name string) (*api.HistoryResponse, error) {
+	rollout, err := k8sClient.GetRollout(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	kustomizations, err := k8sClient.GetKustomizationsByRolloutAnnotation(ctx, namespace, name)
+	if err != nil {
+		logger.Warn("Error fetching kustomizations", "error", err)
+	}
+	events, err := k8sClient.GetRolloutHistoryEvents(ctx, namespace, name)
+	if err != nil {
+		logger.Warn("Error fetching events", "error", err)
+	}
+
+	var items []kustomizev1.Kustomization
+	if kustomizations != nil {
+		items = kustomizations.Items
+	}
+	records := kubernetes.ReconstructHistory(rollout, items, events)
+
+	// Versions no longer listed by the rollout have their release time looked up in the registry
+	var missing []string
+	for _, record := range records {
+		if record.Reconstructed && record.ReleasedAt == nil && !slices.Contains(missing, record.Version) && len(missing) < maxHistoryRegistryLookups {
+			missing = append(missing, record.Version)
+		}
+	}
+	if len(missing) > 0 && rollout.Spec.ReleasesImagePolicy.Name != "" {
+		image, opts, err := rolloutRegistry(ctx, k8sClient, rollout)
+		if err != nil {
+			logger.Warn("Error resolving registry", "error", err)
+		} else {
+			released := map[string]time.Time{}
+			for _, version := range missing {
+				annotations, err := oci.GetImageAnnotations(ctx, image, version, opts...)
+				if err != nil {
+					logger.Debug("Error fetching annotations", "version", version, "error", err)
+					continue
+				}
+				if created, err := time.Parse(time.RFC3339, annotations[ociCreatedAnnotation]); err == nil {
+					released[version] = created
+				}
+			}
+			for i := range records {
+				if created, ok := released[records[i].Version]; ok && records[i].ReleasedAt == nil {
+					records[i].ReleasedAt = &created
+					records[i].Sources = append(records[i].Sources, kubernetes.HistorySourceRegistry)
+				}
+			}
+		}
+	}
+
+	response := &api.HistoryResponse{Entries: api.NewHistoryEntries(records)}
+	for _, record := range records {
+		if record.Reconstructed {
+			response.Reconstructed++
+		}
+	}
+	return response, nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/alertmanager"
+	"github.com/kuberik/rollout-dashboard/pkg/anomaly"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/auth"
+	"github.com/kuberik/rollout-dashboard/pkg/notify"
+	"github.com/kuberik/rollout-dashboard/pkg/prometheus"
+)
+
+// Integrations managed by the admin API
+const (
+	integrationNotifications  = "notifications"
+	integrationAnomalyWebhook = "anomaly-webhook"
+	integrationOIDC           = "oidc"
+	integrationPrometheus     = "prometheus"
+	integrationAlertmanager   = "alertmanager"
+)
+
+// adminPath is the non-resource URL callers need the get (reads) or post (tests and reloads) verb
+// on to use the admin API, e.g. granted by a ClusterRole with nonResourceURLs: [/rollout-dashboard/admin]
+const adminPath = "/rollout-dashboard/admin"
+
+// integrations tests the credentials of the outside services the dashboard calls with a live
+// request, and re-reads them after a rotation, so broken credentials are found before a
+// notification or login fails
+type integrations struct {
+	notifier *notify.Notifier
+	// loadNotifyConfig re-reads the notification routes, nil when they do not come from a file or ConfigMap
+	loadNotifyConfig func() (notify.Config, error)
+	anomalyWebhook   string
+	verifier         *auth.Verifier
+	prometheus       *prometheus.Client
+	alertmanager     *alertmanager.Client
+	now              func() time.Time
+
+	mu          sync.Mutex
+	reloadedAt  map[string]time.Time
+	reloadError map[string]string
+	lastTest    map[string]*api.IntegrationTest
+}
+
+func newIntegrations(notifier *notify.Notifier, loadNotifyConfig func() (notify.Config, error), anomalyWebhook string, verifier *auth.Verifier, prometheus *prometheus.Client, alertmanager *alertmanager.Client) *integrations {
+	return &integrations{
+		notifier:         notifier,
+		loadNotifyConfig: loadNotifyConfig,
+		anomalyWebhook:   anomalyWebhook,
+		verifier:         verifier,
+		prometheus:       prometheus,
+		alertmanager:     alertmanager,
+		now:              time.Now,
+		reloadedAt:       map[string]time.Time{},
+		reloadError:      map[string]string{},
+		lastTest:         map[string]*api.IntegrationTest{},
+	}
+}
+
+// statuses describes every integration, configured or not
+func (i *integrations) statuses() []api.IntegrationStatus {
+	notifications := api.IntegrationStatus{Name: integrationNotifications, Reloadable: i.loadNotifyConfig != nil}
+	if i.notifier != nil {
+		routes, webhooks := 0, 0
+		for _, route := range i.notifier.Config().Routes {
+			routes++
+			webhooks += len(route.Webhooks)
+		}
+		notifications.Configured = routes > 0
+		notifications.Detail = fmt.Sprintf("%d routes, %d webhooks", routes, webhooks)
+	}
+	anomalyWebhook := api.IntegrationStatus{Name: integrationAnomalyWebhook, Configured: i.anomalyWebhook != ""}
+	if anomalyWebhook.Configured {
+		anomalyWebhook.Detail = notify.RedactURL(i.anomalyWebhook)
+	}
+	oidc := api.IntegrationStatus{Name: integrationOIDC, Configured: i.verifier != nil, Reloadable: i.verifier != nil}
+	metrics := api.IntegrationStatus{Name: integrationPrometheus, Configured: i.prometheus != nil}
+	if metrics.Configured {
+		metrics.Detail = notify.RedactURL(i.prometheus.URL())
+	}
+	alerts := api.IntegrationStatus{Name: integrationAlertmanager, Configured: i.alertmanager != nil}
+	if alerts.Configured {
+		alerts.Detail = notify.RedactURL(i.alertmanager.URL())
+	}
+
+	statuses := []api.IntegrationStatus{notifications, anomalyWebhook, oidc, metrics, alerts}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for j := range statuses {
+		status := &statuses[j]
+		if reloadedAt, ok := i.reloadedAt[status.Name]; ok {
+			status.ReloadedAt = &reloadedAt
+		}
+		status.ReloadError = i.reloadError[status.Name]
+		status.LastTest = i.lastTest[status.Name]
+	}
+	return statuses
+}
+
+func (i *integrations) status(name string) (api.IntegrationStatus, bool) {
+	for _, status := range i.statuses() {
+		if status.Name == name {
+			return status, true
+		}
+	}
+	return api.IntegrationStatus{}, false
+}
+
+// test calls the integration's service with its current credentials: every notification webhook
+// gets a test notification, the anomaly webhook a test alert, the OIDC issuer's signing keys are
+// fetched and Prometheus and Alertmanager are asked for their version
+func (i *integrations) test(ctx context.Context, name string) *api.IntegrationTest {
+	result := &api.IntegrationTest{TestedAt: i.now(), OK: true, Targets: []api.IntegrationTarget{}}
+	add := func(target string, err error) {
+		t := api.IntegrationTarget{Target: target, OK: err == nil}
+		if err != nil {
+			t.Error = err.Error()
+			result.OK = false
+		}
+		result.Targets = append(result.Targets, t)
+	}
+
+	switch name {
+	case integrationNotifications:
+		for _, webhook := range i.notifier.Test() {
+			add(webhook.Route+" "+webhook.Webhook, webhook.Err)
+		}
+	case integrationAnomalyWebhook:
+		add(notify.RedactURL(i.anomalyWebhook), anomaly.TestWebhook(i.anomalyWebhook, i.now()))
+	case integrationOIDC:
+		_, err := i.verifier.Refresh(ctx)
+		add("signing keys", err)
+	case integrationPrometheus:
+		_, err := i.prometheus.BuildInfo(ctx)
+		add(notify.RedactURL(i.prometheus.URL()), err)
+	case integrationAlertmanager:
+		_, err := i.alertmanager.Version(ctx)
+		add(notify.RedactURL(i.alertmanager.URL()), err)
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.lastTest[name] = result
+	return result
+}
+
+// reload re-reads the credentials of the reloadable integrations. An invalid notification config
+// keeps the previous one. It reports whether every reload succeeded.
+func (i *integrations) reload(ctx context.Context) bool {
+	reloaded := map[string]error{}
+	if i.loadNotifyConfig != nil {
+		cfg, err := i.loadNotifyConfig()
+		if err == nil {
+			i.notifier.SetConfig(cfg)
+		}
+		reloaded[integrationNotifications] = err
+	}
+	if i.verifier != nil {
+		_, err := i.verifier.Refresh(ctx)
+		reloaded[integrationOIDC] = err
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	ok := true
+	for name, err := range reloaded {
+		if err != nil {
+			i.reloadError[name] = err.Error()
+			ok = false
+			continue
+		}
+		i.reloadedAt[name] = i.now()
+		delete(i.reloadError, name)
+	}
+	return ok
+}
+
+// requireAdmin only lets callers with access to adminPath through, see adminPath. Requests
+// without a user token are refused, as they would be checked against the service account.
+func requireAdmin(c *gin.Context) {
+	if isAnonymous(c) {
+		api.RespondErrorDetails(c, http.StatusUnauthorized, api.CodeUnauthorized, "Authentication required", "the admin API requires a user token")
+		return
+	}
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return
+	}
+	verb := "post"
+	if c.Request.Method == http.MethodGet {
+		verb = "get"
+	}
+	allowed, err := k8sClient.CheckNonResourcePermission(c.Request.Context(), adminPath, verb)
+	if err != nil {
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to check permissions", err)
+		return
+	}
+	if !allowed {
+		api.RespondErrorDetails(c, http.StatusForbidden, api.CodeForbidden, "Admin access required",
+			fmt.Sprintf("%s on non-resource URL %s is required", verb, adminPath))
+		return
+	}
+	c.Next()
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kuberik/rollout-dashboard/pkg/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrations_TestAndReload(t *testing.T) {
+	accepted := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer accepted.Close()
+	revoked := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer revoked.Close()
+
+	cfg, err := notify.ParseConfig([]byte("routes: [{name: chat, webhooks: [" + revoked.URL + "/secret]}]"))
+	require.NoError(t, err)
+	notifier := notify.New(cfg)
+	rotated, loadErr := cfg, error(nil)
+	admin := newIntegrations(notifier, func() (notify.Config, error) { return rotated, loadErr }, accepted.URL+"/alerts", nil, nil, nil)
+
+	statuses := admin.statuses()
+	require.Len(t, statuses, 5)
+	assert.True(t, statuses[0].Configured)
+	assert.Equal(t, "1 routes, 1 webhooks", statuses[0].Detail)
+	assert.True(t, statuses[1].Configured)
+	assert.Equal(t, accepted.URL, statuses[1].Detail)
+	assert.False(t, statuses[2].Configured)
+	assert.False(t, statuses[3].Configured)
+	assert.False(t, statuses[4].Configured)
+
+	result := admin.test(context.Background(), integrationNotifications)
+	assert.False(t, result.OK)
+	require.Len(t, result.Targets, 1)
+	assert.Equal(t, "chat "+revoked.URL, result.Targets[0].Target)
+	assert.Contains(t, result.Targets[0].Error, "403")
+	assert.True(t, admin.test(context.Background(), integrationAnomalyWebhook).OK)
+
+	// An invalid config keeps the previous routes
+	loadErr = errors.New("route chat: at least one webhook is required")
+	assert.False(t, admin.reload(context.Background()))
+	status, _ := admin.status(integrationNotifications)
+	assert.Equal(t, loadErr.Error(), status.ReloadError)
+	assert.Nil(t, status.ReloadedAt)
+
+	rotated, err = notify.ParseConfig([]byte("routes: [{name: chat, webhooks: [" + accepted.URL + "/rotated]}]"))
+	require.NoError(t, err)
+	loadErr = nil
+	assert.True(t, admin.reload(context.Background()))
+	status, _ = admin.status(integrationNotifications)
+	assert.Empty(t, status.ReloadError)
+	assert.NotNil(t, status.ReloadedAt)
+	assert.True(t, admin.test(context.Background(), integrationNotifications).OK)
+}
+
+func TestRequireAdmin_Anonymous(t *testing.T) {
+	r := newRouter(nil, nil, nil, nil, newIntegrations(nil, nil, "", nil, nil, nil), nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	// Without a token the access review would be answered for the service account
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/integrations", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+}
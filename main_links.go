@@ -0,0 +1,36 @@
+package main
+
+import (
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/links"
+)
+
+// withLinks adds the rollout's quick links to the rollout detail. Like withOwnership it returns a
+// copy, leaving a cached detail unchanged, and is applied after the cache so changes to
+// LINKS_CONFIG need no invalidation.
+func withLinks(quickLinks *links.Templates, detail *api.RolloutDetailResponse) *api.RolloutDetailResponse {
+	if detail.Rollout == nil {
+		return detail
+	}
+	rollout := detail.Rollout
+	vars := links.Variables{
+		Namespace: rollout.Namespace,
+		Name:      rollout.Name,
+		Team:      rollout.Annotations[kubernetes.TeamAnnotation],
+	}
+	if len(rollout.Status.History) > 0 {
+		vars.Version = rollout.Status.History[0].Version.Tag
+	}
+	if detail.Environment != nil {
+		vars.Environment = detail.Environment.Spec.Environment
+	}
+	resolved := quickLinks.Resolve(rollout.Annotations, vars)
+	if len(resolved) == 0 {
+		return detail
+	}
+
+	out := *detail
+	out.Links = resolved
+	return &out
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/links"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWithLinks(t *testing.T) {
+	cfg, err := links.ParseConfig([]byte(`links: [{name: grafana, url: "https://grafana.example.com/d/$environment?var-rollout=$name&var-version=$version"}]`))
+	require.NoError(t, err)
+	quickLinks := links.New(cfg)
+
+	cached := &api.RolloutDetailResponse{
+		Rollout: &rolloutv1alpha1.Rollout{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api", Annotations: map[string]string{
+				links.AnnotationPrefix + links.Runbook: "https://wiki.example.com/runbooks/$namespace/$name",
+			}},
+			Status: rolloutv1alpha1.RolloutStatus{History: []rolloutv1alpha1.DeploymentHistoryEntry{{Version: rolloutv1alpha1.VersionInfo{Tag: "v1.2.0"}}}},
+		},
+		Environment: &envv1alpha1.Environment{Spec: envv1alpha1.EnvironmentSpec{Environment: "production"}},
+	}
+	detail := withLinks(quickLinks, cached)
+	assert.Equal(t, []links.Link{
+		{Name: links.Grafana, Title: "Grafana dashboard", URL: "https://grafana.example.com/d/production?var-rollout=api&var-version=v1.2.0", Source: links.SourceConfig},
+		{Name: links.Runbook, Title: "Runbook", URL: "https://wiki.example.com/runbooks/shop/api", Source: links.SourceAnnotation},
+	}, detail.Links)
+	assert.Nil(t, cached.Links, "the cached detail is not modified")
+
+	// Without a configuration only the annotated links are shown
+	detail = withLinks(nil, cached)
+	require.Len(t, detail.Links, 1)
+	assert.Equal(t, links.Runbook, detail.Links[0].Name)
+
+	unlinked := &api.RolloutDetailResponse{Rollout: &rolloutv1alpha1.Rollout{}}
+	assert.Same(t, unlinked, withLinks(quickLinks, unlinked))
+}
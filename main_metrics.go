@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+	"github.com/kuberik/rollout-dashboard/pkg/prometheus"
+)
+
+// defaultMetricsRange is the time range of metrics queries without a start
+const defaultMetricsRange = time.Hour
+
+// metricsQuery is a validated metrics request
+type metricsQuery struct {
+	query  string
+	preset string
+	start  time.Time
+	end    time.Time
+	step   time.Duration
+}
+
+// parseMetricsQuery reads the query or preset and the time range of a metrics request. The range
+// ends at end (default now) and starts at start, or range (default 1h) before the end.
+func parseMetricsQuery(c *gin.Context, now time.Time) (metricsQuery, error) {
+	q := metricsQuery{query: c.Query("query"), preset: c.Query("preset"), end: now}
+	switch {
+	case q.query != "" && q.preset != "":
+		return q, fmt.Errorf("only one of query and preset may be set")
+	case q.preset != "":
+		preset, ok := prometheus.Presets[q.preset]
+		if !ok {
+			return q, fmt.Errorf("unknown preset %q", q.preset)
+		}
+		q.query = preset
+	case q.query == "":
+		return q, fmt.Errorf("either query or preset is required")
+	}
+
+	if raw := c.Query("end"); raw != "" {
+		end, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return q, fmt.Errorf("invalid end: %w", err)
+		}
+		q.end = end
+	}
+	q.start = q.end.Add(-defaultMetricsRange)
+	if raw := c.Query("range"); raw != "" {
+		duration, err := time.ParseDuration(raw)
+		if err != nil || duration <= 0 {
+			return q, fmt.Errorf("invalid range %q", raw)
+		}
+		q.start = q.end.Add(-duration)
+	}
+	if raw := c.Query("start"); raw != "" {
+		start, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return q, fmt.Errorf("invalid start: %w", err)
+		}
+		q.start = start
+	}
+	if !q.start.Before(q.end) {
+		return q, fmt.Errorf("start must be before end")
+	}
+
+	q.step = prometheus.Step(q.start, q.end)
+	if raw := c.Query("step"); raw != "" {
+		step, err := time.ParseDuration(raw)
+		if err != nil || step < time.Second {
+			return q, fmt.Errorf("invalid step %q, must be at least 1s", raw)
+		}
+		q.step = step
+	}
+	if points := q.end.Sub(q.start) / q.step; points > prometheus.MaxPoints {
+		return q, fmt.Errorf("range and step give %d points, at most %d are allowed", points, prometheus.MaxPoints)
+	}
+	return q, nil
+}
+
+// deploymentsInRange returns the deployments that were current at some point of [start, end],
+// newest first, so charts can mark deploys and bakes
+func deploymentsInRange(history []rolloutv1alpha1.DeploymentHistoryEntry, start, end time.Time) []api.Deployment {
+	deployments := []api.Deployment{}
+	for i, entry := range history {
+		if entry.Timestamp.After(end) {
+			continue
+		}
+		// A deployment is current until the next, newer one
+		if i > 0 && history[i-1].Timestamp.Time.Before(start) {
+			break
+		}
+		deployments = append(deployments, api.NewDeployment(entry))
+	}
+	return deployments
+}
+
+// getRolloutMetrics runs a range query over the pods of the rollout's workloads
+func getRolloutMetrics(c *gin.Context, metrics *prometheus.Client) {
+	if metrics == nil {
+		api.RespondErrorDetails(c, http.StatusNotFound, api.CodeNotFound, "Metrics are not enabled", "set PROMETHEUS_URL to enable metrics")
+		return
+	}
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return
+	}
+	q, err := parseMetricsQuery(c, time.Now())
+	if err != nil {
+		api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid metrics query", err)
+		return
+	}
+
+	namespace, name := c.Param("namespace"), c.Param("name")
+	rollout, err := k8sClient.GetRollout(c.Request.Context(), namespace, name)
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching rollout", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout", err)
+		return
+	}
+	workloads, err := k8sClient.GetRolloutWorkloads(c.Request.Context(), namespace, name)
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching rollout workloads", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout workloads", err)
+		return
+	}
+	if len(workloads) == 0 {
+		api.RespondErrorDetails(c, http.StatusNotFound, api.CodeNotFound, "Rollout has no workloads",
+			"no Deployment, StatefulSet or DaemonSet was found in the inventories of the rollout's Kustomizations")
+		return
+	}
+
+	query, err := prometheus.Render(q.query, prometheus.Selector(workloads), prometheus.RateInterval(q.step))
+	if err != nil {
+		api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid metrics query", err)
+		return
+	}
+	series, err := metrics.QueryRange(c.Request.Context(), query, q.start, q.end, q.step)
+	if err != nil {
+		if prometheus.IsBadQuery(err) {
+			api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid metrics query", err)
+			return
+		}
+		logging.FromContext(c).Error("Error querying Prometheus", "error", err)
+		api.RespondError(c, http.StatusBadGateway, api.CodeMetrics, "Failed to query Prometheus", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, api.MetricsResponse{
+		Query:       query,
+		Preset:      q.preset,
+		Start:       q.start,
+		End:         q.end,
+		Step:        q.step.String(),
+		Workloads:   workloads,
+		Series:      series,
+		Deployments: deploymentsInRange(rollout.Status.History, q.start, q.end),
+	})
+}
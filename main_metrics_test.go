@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseMetricsQuery(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	parse := func(rawQuery string) (metricsQuery, error) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/metrics?"+rawQuery, nil)
+		return parseMetricsQuery(c, now)
+	}
+
+	q, err := parse("preset=error-rate")
+	require.NoError(t, err)
+	assert.Contains(t, q.query, "http_requests_total")
+	assert.Equal(t, now.Add(-time.Hour), q.start)
+	assert.Equal(t, 15*time.Second, q.step)
+
+	q, err = parse("query=up%7B%24selector%7D&range=6h&step=1m")
+	require.NoError(t, err)
+	assert.Equal(t, "up{$selector}", q.query)
+	assert.Equal(t, now.Add(-6*time.Hour), q.start)
+	assert.Equal(t, time.Minute, q.step)
+
+	for _, rawQuery := range []string{"", "preset=unknown", "preset=cpu&query=up", "preset=cpu&range=-1h", "preset=cpu&start=2026-01-03T00:00:00Z", "preset=cpu&range=720h&step=1s"} {
+		_, err := parse(rawQuery)
+		assert.Error(t, err, rawQuery)
+	}
+}
+
+func TestDeploymentsInRange(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	entry := func(version string, at time.Time) rolloutv1alpha1.DeploymentHistoryEntry {
+		return rolloutv1alpha1.DeploymentHistoryEntry{Version: rolloutv1alpha1.VersionInfo{Tag: version}, Timestamp: metav1.NewTime(at)}
+	}
+	history := []rolloutv1alpha1.DeploymentHistoryEntry{
+		entry("v4", now.Add(time.Minute)),
+		entry("v3", now.Add(-30*time.Minute)),
+		entry("v2", now.Add(-3*time.Hour)),
+		entry("v1", now.Add(-5*time.Hour)),
+	}
+
+	var versions []string
+	for _, deployment := range deploymentsInRange(history, now.Add(-time.Hour), now) {
+		versions = append(versions, deployment.Version.Tag)
+	}
+	// v2 was current until v3 was deployed within the range, v1 was replaced before it
+	assert.Equal(t, []string{"v3", "v2"}, versions)
+}
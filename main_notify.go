@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/notify"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// defaultNotifyInterval is how often rollouts are checked for events to notify about
+	defaultNotifyInterval = time.Minute
+	// notifyConfigMapKey is the key of the NOTIFY_CONFIGMAP ConfigMap holding the routes
+	notifyConfigMapKey = "config.yaml"
+)
+
+// notifyConfigMapLoader returns a function reading the notification routes from the ConfigMap
+// named "namespace/name" by ref with the dashboard's service account
+func notifyConfigMapLoader(ref string) (func() (notify.Config, error), error) {
+	namespace, name, ok := strings.Cut(ref, "/")
+	if !ok || namespace == "" || name == "" {
+		return nil, fmt.Errorf("NOTIFY_CONFIGMAP must be namespace/name, got %q", ref)
+	}
+	return func() (notify.Config, error) {
+		k8sClient, err := kubernetes.GetDefaultClient()
+		if err != nil {
+			return notify.Config{}, err
+		}
+		configMap, err := k8sClient.GetConfigMap(context.Background(), namespace, name)
+		if err != nil {
+			return notify.Config{}, err
+		}
+		data, ok := configMap.Data[notifyConfigMapKey]
+		if !ok {
+			return notify.Config{}, fmt.Errorf("config map %s has no %s key", ref, notifyConfigMapKey)
+		}
+		return notify.ParseConfig([]byte(data))
+	}, nil
+}
+
+// rolloutEnvironments maps "namespace/rollout" to the environment name of the rollout's
+// Environment, for routing
+func rolloutEnvironments(environments []envv1alpha1.Environment) map[string]string {
+	environmentNames := make(map[string]string)
+	for _, env := range environments {
+		environmentNames[env.Namespace+"/"+env.Spec.RolloutRef.Name] = env.Spec.Environment
+	}
+	return environmentNames
+}
+
+// rolloutNotification returns a notification about a rollout with the rollout's routing filled in
+func rolloutNotification(rollout *rolloutv1alpha1.Rollout, environmentNames map[string]string, event, severity string) notify.Notification {
+	notification := notify.Notification{
+		Event:       event,
+		Severity:    severity,
+		Environment: environmentNames[rollout.Namespace+"/"+rollout.Name],
+		Namespace:   rollout.Namespace,
+		Rollout:     rollout.Name,
+	}
+	for _, route := range strings.Split(rollout.Annotations[kubernetes.NotifyRoutesAnnotation], ",") {
+		if route = strings.TrimSpace(route); route != "" {
+			notification.Routes = append(notification.Routes, route)
+		}
+	}
+	return notification
+}
+
+// rolloutFailureNotifications returns a critical notification for every rollout whose current
+// deployment failed baking. Environments map rollouts to their environment name for routing.
+func rolloutFailureNotifications(rollouts []rolloutv1alpha1.Rollout, environments []envv1alpha1.Environment) []notify.Notification {
+	environmentNames := rolloutEnvironments(environments)
+
+	var notifications []notify.Notification
+	for _, rollout := range rollouts {
+		if len(rollout.Status.History) == 0 {
+			continue
+		}
+		current := rollout.Status.History[0]
+		if current.BakeStatus == nil || *current.BakeStatus != rolloutv1alpha1.BakeStatusFailed {
+			continue
+		}
+
+		notification := rolloutNotification(&rollout, environmentNames, notify.EventBakeFailed, notify.SeverityCritical)
+		notification.Key = fmt.Sprintf("rollout-failed/%s/%s/%s", rollout.Namespace, rollout.Name, current.Version.Tag)
+		notification.Title = fmt.Sprintf("Rollout %s/%s failed to deploy %s", rollout.Namespace, rollout.Name, current.Version.Tag)
+		if current.BakeStatusMessage != nil {
+			notification.Message = *current.BakeStatusMessage
+		}
+		notifications = append(notifications, notification)
+	}
+	return notifications
+}
+
+// rolloutState is what the watcher remembers of a rollout to tell what changed since the last check
+type rolloutState struct {
+	// deployment identifies the current deployment by version and time
+	deployment  string
+	version     string
+	forceDeploy string
+	// blocked are the gates blocking the rollout
+	blocked map[string]bool
+}
+
+func newRolloutState(rollout *rolloutv1alpha1.Rollout) rolloutState {
+	state := rolloutState{forceDeploy: rollout.Annotations[kubernetes.ForceDeployAnnotation], blocked: map[string]bool{}}
+	if len(rollout.Status.History) > 0 {
+		current := rollout.Status.History[0]
+		state.version = current.Version.Tag
+		state.deployment = fmt.Sprintf("%s@%d", current.Version.Tag, current.Timestamp.Unix())
+	}
+	for _, gate := range rollout.Status.Gates {
+		if gate.Passing != nil && !*gate.Passing && !gate.BypassGates {
+			state.blocked[gate.Name] = true
+		}
+	}
+	return state
+}
+
+// rolloutChangeNotifications returns notifications for the lifecycle events of rollouts since
+// their states were recorded, and records their current states: new deployments, force deploys
+// and gates that started blocking. Rollouts seen for the first time are only recorded, so a
+// restart of the dashboard does not repeat old events.
+func rolloutChangeNotifications(states map[types.NamespacedName]rolloutState, rollouts []rolloutv1alpha1.Rollout, environments []envv1alpha1.Environment) []notify.Notification {
+	environmentNames := rolloutEnvironments(environments)
+	seen := map[types.NamespacedName]bool{}
+
+	var notifications []notify.Notification
+	for i := range rollouts {
+		rollout := &rollouts[i]
+		key := types.NamespacedName{Namespace: rollout.Namespace, Name: rollout.Name}
+		seen[key] = true
+		previous, known := states[key]
+		current := newRolloutState(rollout)
+		states[key] = current
+		if !known {
+			continue
+		}
+		name := rollout.Namespace + "/" + rollout.Name
+
+		if current.forceDeploy != "" && current.forceDeploy != previous.forceDeploy {
+			notification := rolloutNotification(rollout, environmentNames, notify.EventForceDeployed, notify.SeverityWarning)
+			notification.Key = fmt.Sprintf("force-deployed/%s/%s", name, current.forceDeploy)
+			notification.Title = fmt.Sprintf("Rollout %s is force deploying %s", name, current.forceDeploy)
+			if user := rollout.Annotations[kubernetes.DeployUserAnnotation]; user != "" {
+				notification.Title += " by " + user
+			}
+			notification.Message = rollout.Annotations[kubernetes.DeployMessageAnnotation]
+			notifications = append(notifications, notification)
+		}
+
+		if current.deployment != "" && current.deployment != previous.deployment {
+			entry := rollout.Status.History[0]
+			notification := rolloutNotification(rollout, environmentNames, notify.EventDeployed, notify.SeverityInfo)
+			notification.Key = fmt.Sprintf("deployed/%s/%s", name, current.deployment)
+			notification.Title = fmt.Sprintf("Rollout %s deployed %s", name, current.version)
+			if previous.version != "" && previous.version != current.version {
+				notification.Title += fmt.Sprintf(" (was %s)", previous.version)
+			}
+			var details []string
+			if entry.TriggeredBy != nil && entry.TriggeredBy.Name != "" {
+				details = append(details, "triggered by "+entry.TriggeredBy.Name)
+			}
+			if entry.Message != nil && *entry.Message != "" {
+				details = append(details, *entry.Message)
+			}
+			notification.Message = strings.Join(details, ": ")
+			notification.Time = entry.Timestamp.Time
+			notifications = append(notifications, notification)
+		}
+
+		for _, gate := range rollout.Status.Gates {
+			if !current.blocked[gate.Name] || previous.blocked[gate.Name] {
+				continue
+			}
+			notification := rolloutNotification(rollout, environmentNames, notify.EventGateBlocked, notify.SeverityWarning)
+			notification.Key = fmt.Sprintf("gate-blocked/%s/%s", name, gate.Name)
+			notification.Title = fmt.Sprintf("Gate %s is blocking rollout %s", gate.Name, name)
+			notification.Message = gate.Message
+			notifications = append(notifications, notification)
+		}
+	}
+
+	for key := range states {
+		if !seen[key] {
+			delete(states, key)
+		}
+	}
+	return notifications
+}
+
+// rolloutWatcher sends notifications about rollout lifecycle events: new deployments, force
+// deploys, gates that started blocking and failed bakes. Repeated notifications of the same
+// failure are suppressed by the notifier's dedup window.
+type rolloutWatcher struct {
+	notifier *notify.Notifier
+	// loadConfig re-reads the routes before every check, nil when they are only reloaded on request
+	loadConfig func() (notify.Config, error)
+	states     map[types.NamespacedName]rolloutState
+}
+
+func newRolloutWatcher(notifier *notify.Notifier, loadConfig func() (notify.Config, error)) *rolloutWatcher {
+	return &rolloutWatcher{notifier: notifier, loadConfig: loadConfig, states: map[types.NamespacedName]rolloutState{}}
+}
+
+// run checks the rollouts every interval until ctx is cancelled
+func (w *rolloutWatcher) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := w.check(ctx); err != nil {
+			slog.Warn("Failed to check rollouts for notifications", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *rolloutWatcher) check(ctx context.Context) error {
+	if w.loadConfig != nil {
+		// A broken config keeps the routes that were last loaded
+		if cfg, err := w.loadConfig(); err != nil {
+			slog.Warn("Failed to reload notification config", "error", err)
+		} else {
+			w.notifier.SetConfig(cfg)
+		}
+	}
+
+	k8sClient, err := kubernetes.GetDefaultClient()
+	if err != nil {
+		return err
+	}
+	rollouts, err := k8sClient.GetRolloutsAllNamespaces(ctx)
+	if err != nil {
+		return err
+	}
+	// Environments are optional, without them notifications are only routed by namespace and severity
+	var environments []envv1alpha1.Environment
+	if list, err := k8sClient.GetEnvironmentsAllNamespaces(ctx); err == nil {
+		environments = list.Items
+	}
+
+	notifications := rolloutChangeNotifications(w.states, rollouts.Items, environments)
+	notifications = append(notifications, rolloutFailureNotifications(rollouts.Items, environments)...)
+	for _, notification := range notifications {
+		w.notifier.Notify(notification)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"testing"
+
+	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+)
+
+func rolloutWithBakeStatus(namespace, name, tag, bakeStatus string) rolloutv1alpha1.Rollout {
+	return rolloutv1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Status: rolloutv1alpha1.RolloutStatus{
+			History: []rolloutv1alpha1.DeploymentHistoryEntry{{
+				Version:           rolloutv1alpha1.VersionInfo{Tag: tag},
+				BakeStatus:        ptr.To(bakeStatus),
+				BakeStatusMessage: ptr.To("health check failed"),
+			}},
+		},
+	}
+}
+
+func TestRolloutFailureNotifications(t *testing.T) {
+	rollouts := []rolloutv1alpha1.Rollout{
+		rolloutWithBakeStatus("prod", "app", "v2", rolloutv1alpha1.BakeStatusFailed),
+		rolloutWithBakeStatus("dev", "app", "v3", rolloutv1alpha1.BakeStatusFailed),
+		rolloutWithBakeStatus("prod", "healthy", "v1", rolloutv1alpha1.BakeStatusSucceeded),
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "new"}},
+	}
+	environments := []envv1alpha1.Environment{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "app"},
+		Spec: envv1alpha1.EnvironmentSpec{
+			Environment: "production",
+			RolloutRef:  corev1.LocalObjectReference{Name: "app"},
+		},
+	}}
+
+	notifications := rolloutFailureNotifications(rollouts, environments)
+	require.Len(t, notifications, 2)
+	assert.Equal(t, notify.Notification{
+		Key:         "rollout-failed/prod/app/v2",
+		Event:       notify.EventBakeFailed,
+		Severity:    notify.SeverityCritical,
+		Environment: "production",
+		Namespace:   "prod",
+		Rollout:     "app",
+		Title:       "Rollout prod/app failed to deploy v2",
+		Message:     "health check failed",
+	}, notifications[0])
+	assert.Equal(t, "rollout-failed/dev/app/v3", notifications[1].Key)
+	assert.Empty(t, notifications[1].Environment)
+}
+
+func TestRolloutChangeNotifications(t *testing.T) {
+	rollout := rolloutWithBakeStatus("prod", "app", "v1", rolloutv1alpha1.BakeStatusSucceeded)
+	rollout.Annotations = map[string]string{kubernetes.NotifyRoutesAnnotation: "payments, oncall"}
+	rollout.Status.Gates = []rolloutv1alpha1.RolloutGateStatusSummary{{Name: "freeze", Passing: ptr.To(true)}}
+	states := map[types.NamespacedName]rolloutState{}
+
+	// Rollouts seen for the first time are only recorded
+	assert.Empty(t, rolloutChangeNotifications(states, []rolloutv1alpha1.Rollout{rollout}, nil))
+	assert.Empty(t, rolloutChangeNotifications(states, []rolloutv1alpha1.Rollout{rollout}, nil))
+
+	rollout.Annotations[kubernetes.ForceDeployAnnotation] = "v2"
+	rollout.Annotations[kubernetes.DeployUserAnnotation] = "alice"
+	rollout.Annotations[kubernetes.DeployMessageAnnotation] = "hotfix"
+	rollout.Status.History = append([]rolloutv1alpha1.DeploymentHistoryEntry{{
+		Version:     rolloutv1alpha1.VersionInfo{Tag: "v2"},
+		Timestamp:   metav1.Now(),
+		TriggeredBy: &rolloutv1alpha1.TriggeredByInfo{Kind: "User", Name: "alice"},
+	}}, rollout.Status.History...)
+	rollout.Status.Gates[0] = rolloutv1alpha1.RolloutGateStatusSummary{Name: "freeze", Passing: ptr.To(false), Message: "change freeze"}
+
+	notifications := rolloutChangeNotifications(states, []rolloutv1alpha1.Rollout{rollout}, nil)
+	require.Len(t, notifications, 3)
+	assert.Equal(t, notify.EventForceDeployed, notifications[0].Event)
+	assert.Equal(t, "Rollout prod/app is force deploying v2 by alice", notifications[0].Title)
+	assert.Equal(t, "hotfix", notifications[0].Message)
+	assert.Equal(t, notify.EventDeployed, notifications[1].Event)
+	assert.Equal(t, "Rollout prod/app deployed v2 (was v1)", notifications[1].Title)
+	assert.Equal(t, "triggered by alice", notifications[1].Message)
+	assert.Equal(t, notify.EventGateBlocked, notifications[2].Event)
+	assert.Equal(t, "change freeze", notifications[2].Message)
+	assert.Equal(t, []string{"payments", "oncall"}, notifications[2].Routes)
+
+	// Nothing changed since
+	assert.Empty(t, rolloutChangeNotifications(states, []rolloutv1alpha1.Rollout{rollout}, nil))
+
+	// Deleted rollouts are forgotten
+	rolloutChangeNotifications(states, nil, nil)
+	assert.Empty(t, states)
+}
+
+func TestNotifyConfigMapLoader(t *testing.T) {
+	for _, ref := range []string{"notifications", "/notifications", "monitoring/"} {
+		_, err := notifyConfigMapLoader(ref)
+		assert.Error(t, err, ref)
+	}
+	_, err := notifyConfigMapLoader("monitoring/notifications")
+	assert.NoError(t, err)
+}
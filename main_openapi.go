@@ -0,0 +1,368 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/registryhook"
+	"github.com/kuberik/rollout-dashboard/pkg/terminal"
+)
+
+// waitQuery documents the parameters shared by actions that can wait for the controller
+var waitQuery = []api.QueryParameter{
+	{Name: "wait", Type: "boolean", Description: "Wait until the controller reports the version as deployed"},
+	{Name: "timeout", Description: "Maximum time to wait as a Go duration (default 30s, at most 2m)"},
+}
+
+// shareQuery documents the share link token accepted by the routes a share link grants access to
+var shareQuery = api.QueryParameter{Name: "share", Description: "Share link token granting read-only access without RBAC"}
+
+// fieldsQuery documents the field selection accepted by the rollout list and detail routes
+var fieldsQuery = api.QueryParameter{Name: "fields", Description: "Comma-separated JSON paths within each rollout, e.g. metadata.name,status.history[0], the response is pruned to"}
+
+// freezeQuery documents the override of deployment freeze windows accepted by actions changing rollouts
+var freezeQuery = api.QueryParameter{Name: overrideFreezeQuery, Type: "boolean", Description: "Change the rollout during a deployment freeze window, reserved for admin groups"}
+
+// apiOperations documents every v1 route and is used to generate the OpenAPI document
+// served at /api/v1/openapi.json. Keep it in sync when adding routes.
+var apiOperations = []api.Operation{
+	{Method: http.MethodGet, Path: "/api/health", OperationID: "getHealth", Summary: "Health check", Tags: []string{"system"},
+		Response: api.HealthResponse{}},
+	{Method: http.MethodPost, Path: "/api/webhooks/registry", OperationID: "receiveRegistryWebhook", Summary: "Reconcile the rollouts of an image a registry or CI pushed a tag of", Tags: []string{"system"},
+		Request: registryhook.Push{}, Response: api.RegistryWebhookResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/openapi.json", OperationID: "getOpenAPI", Summary: "OpenAPI document of this API", Tags: []string{"system"}},
+
+	{Method: http.MethodGet, Path: "/api/v1/rollouts", OperationID: "listRollouts", Summary: "List rollouts with their associated Flux resources", Tags: []string{"rollouts"},
+		Query: []api.QueryParameter{
+			{Name: "namespace", Description: "Namespace to list, or \"all\" (default)"},
+			{Name: "view", Description: "\"summary\" returns RolloutSummaryListResponse instead of raw resources"},
+			{Name: "format", Description: "\"ndjson\" (or Accept: application/x-ndjson) streams one StreamEntry per line instead"},
+			fieldsQuery,
+		},
+		Response: api.RolloutListResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/summary", OperationID: "getFleetSummary", Summary: "Count rollouts by health and list the most recently failed ones", Tags: []string{"rollouts"},
+		Query: []api.QueryParameter{
+			{Name: "namespace", Description: "Namespace to summarize, or \"all\" (default)"},
+			{Name: "failed", Type: "integer", Description: "Number of recently failed rollouts to list (default 10, at most 100)"},
+		},
+		Response: api.FleetSummaryResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name", OperationID: "getRollout", Summary: "Get a rollout and its related resources", Tags: []string{"rollouts"},
+		Query: []api.QueryParameter{shareQuery, fieldsQuery}, Response: api.RolloutDetailResponse{}},
+	{Method: http.MethodGet, Path: "/api/v2/rollouts", OperationID: "listRolloutsV2", Summary: "List rollouts as trimmed summaries", Tags: []string{"rollouts"},
+		Query: []api.QueryParameter{
+			{Name: "namespace", Description: "Namespace to list, or \"all\" (default)"},
+			{Name: "view", Description: "\"raw\" returns RolloutListResponse with the raw resources as in v1"},
+			{Name: "format", Description: "\"ndjson\" (or Accept: application/x-ndjson) streams one StreamEntry per line instead"},
+			fieldsQuery,
+		},
+		Response: api.RolloutSummaryListResponse{}},
+	{Method: http.MethodGet, Path: "/api/v2/rollouts/:namespace/:name", OperationID: "getRolloutV2", Summary: "Get a rollout and its related resources as trimmed models", Tags: []string{"rollouts"},
+		Query: []api.QueryParameter{
+			{Name: "view", Description: "\"raw\" returns RolloutDetailResponse with the raw resources as in v1"},
+			shareQuery,
+			fieldsQuery,
+		},
+		Response: api.RolloutDetailViewResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/rollouts/:namespace/:name/share", OperationID: "shareRollout", Summary: "Create a time-limited read-only link to a rollout", Tags: []string{"rollouts"},
+		Request: api.ShareRequest{}, Response: api.ShareResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/environments", OperationID: "listEnvironments", Summary: "List environments in the rollout's namespace", Tags: []string{"rollouts"},
+		Response: api.EnvironmentsResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/rollout-tests", OperationID: "listRolloutTests", Summary: "List RolloutTests of a Kruise rollout", Tags: []string{"rollouts"},
+		Response: api.RolloutTestsResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/rollout-tests/gates", OperationID: "getRolloutTestGates", Summary: "Show how the RolloutTests of a Kruise rollout gate its steps for the current canary revision", Tags: []string{"rollouts"},
+		Response: api.TestGatesResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/rollout-tests/history", OperationID: "getRolloutTestHistory", Summary: "List the runs of the RolloutTests of a Kruise rollout across versions", Tags: []string{"rollouts"},
+		Response: api.TestHistoryResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/rollout-tests/:namespace/:name/rerun", OperationID: "rerunRolloutTest", Summary: "Run a RolloutTest again by deleting its Job", Tags: []string{"actions"},
+		Query: []api.QueryParameter{freezeQuery}, Response: api.RolloutTestRerunResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollout-tests/:namespace/:name/report", OperationID: "getRolloutTestReport", Summary: "Parse the JUnit report of a RolloutTest run from its pods", Tags: []string{"rollouts"},
+		Query:    []api.QueryParameter{{Name: "job", Description: "Job of the run, default the test's current Job"}},
+		Response: api.TestReportResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/canary-analysis", OperationID: "getCanaryAnalysis", Summary: "Compare the canary and stable pods of a Kruise canary rollout step by step", Tags: []string{"rollouts"},
+		Response: api.CanaryAnalysisResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/health-checks", OperationID: "listHealthChecks", Summary: "List health checks selected by a rollout", Tags: []string{"rollouts"},
+		Response: api.HealthChecksResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/metrics", OperationID: "getRolloutMetrics", Summary: "Run a Prometheus range query over the pods of the rollout's workloads", Tags: []string{"workloads"},
+		Query: []api.QueryParameter{
+			{Name: "query", Description: "PromQL query selecting the rollout's pods with $selector, e.g. sum(rate(http_requests_total{$selector}[$interval]))"},
+			{Name: "preset", Description: "Named query instead of query: error-rate, request-rate, latency-p50, latency-p99, cpu, memory or restarts"},
+			{Name: "start", Description: "Start of the range in RFC 3339 (default range before end)"},
+			{Name: "end", Description: "End of the range in RFC 3339 (default now)"},
+			{Name: "range", Description: "Length of the range as a Go duration when start is not set (default 1h)"},
+			{Name: "step", Description: "Resolution as a Go duration (default about 250 points, at least 15s)"},
+		},
+		Response: api.MetricsResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/alerts", OperationID: "listRolloutAlerts", Summary: "List firing Alertmanager alerts about the rollout's workloads", Tags: []string{"workloads"},
+		Response: api.AlertsResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/health-checks", OperationID: "listAllHealthChecks", Summary: "List HealthChecks filtered by namespace, class and labels", Tags: []string{"health-checks"},
+		Query: []api.QueryParameter{
+			{Name: "namespace", Description: "Namespace to list, default all namespaces"},
+			{Name: "class", Description: "Only HealthChecks of this class, e.g. kustomization"},
+			{Name: "labelSelector", Description: "Kubernetes label selector, e.g. app=shop"},
+		},
+		Response: api.HealthCheckListResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/health-checks/:namespace/:name", OperationID: "getHealthCheck", Summary: "Get a HealthCheck with its recent status transitions and last error", Tags: []string{"health-checks"},
+		Response: api.HealthCheckDetailResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/events", OperationID: "listRolloutEvents", Summary: "List recent events of the rollout, its Flux objects, Deployments and test Jobs", Tags: []string{"rollouts"},
+		Response: api.EventsResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/history/reconstructed", OperationID: "getReconstructedHistory", Summary: "Deployment history extended with entries reconstructed from Flux and registry data", Tags: []string{"rollouts"},
+		Response: api.HistoryResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/flux-events/stream", OperationID: "streamFluxEvents", Summary: "Stream Warning events of the rollout's Flux objects", Tags: []string{"rollouts"},
+		Response: api.Event{}, Stream: true},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/readiness", OperationID: "getRolloutReadiness", Summary: "Summarize why the rollout's pods are not Ready", Tags: []string{"workloads"},
+		Response: api.ReadinessResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/schedules", OperationID: "listRolloutSchedules", Summary: "List schedules matching a rollout", Tags: []string{"schedules"},
+		Response: api.SchedulesResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/permissions", OperationID: "checkRolloutPermission", Summary: "Check whether the caller may perform a verb on a rollout", Tags: []string{"rollouts"},
+		Query:    []api.QueryParameter{{Name: "verb", Description: "Kubernetes verb to check (default update)"}},
+		Response: api.PermissionResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/permissions/all", OperationID: "checkRolloutPermissions", Summary: "Check the caller's permissions for all rollout actions", Tags: []string{"rollouts"},
+		Response: api.PermissionsResponse{}},
+
+	{Method: http.MethodGet, Path: "/api/v1/me", OperationID: "getCurrentUser", Summary: "Get the caller's identity and the dashboard actions they may perform", Tags: []string{"rollouts"},
+		Query:    []api.QueryParameter{{Name: "namespace", Description: "Namespace to report capabilities for (default default)"}},
+		Response: api.MeResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/admin/integrations", OperationID: "listIntegrations", Summary: "List the integrations and their last test and reload", Tags: []string{"admin"},
+		Response: api.IntegrationsResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/admin/streams", OperationID: "listStreams", Summary: "List the open event and log streams with their health", Tags: []string{"admin"},
+		Response: api.StreamsResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/admin/integrations/:integration/test", OperationID: "testIntegration", Summary: "Test an integration's credentials with a live call", Tags: []string{"admin"},
+		Response: api.IntegrationStatus{}},
+	{Method: http.MethodPost, Path: "/api/v1/admin/integrations/reload", OperationID: "reloadIntegrations", Summary: "Re-read integration credentials after a rotation", Tags: []string{"admin"},
+		Response: api.IntegrationsResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/environments/:environment/permissions", OperationID: "checkEnvironmentPermissions", Summary: "Check whether the caller may act on every rollout of an environment", Tags: []string{"rollouts"},
+		Response: api.EnvironmentPermissionsResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/environments/:environment/promote", OperationID: "promoteEnvironment", Summary: "Promote the healthy version of the source environment to every rollout of an environment", Tags: []string{"actions"},
+		Query: []api.QueryParameter{freezeQuery}, Request: api.PromoteRequest{}, Response: api.PromoteResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/environments/:environment/overrides", OperationID: "exportEnvironmentOverrides", Summary: "Export the pins and overrides of every rollout of an environment", Tags: []string{"actions"},
+		Response: api.OverridesDocument{}},
+	{Method: http.MethodPut, Path: "/api/v1/environments/:environment/overrides", OperationID: "applyEnvironmentOverrides", Summary: "Apply exported pins and overrides to the rollouts of an environment", Tags: []string{"actions"},
+		Query: []api.QueryParameter{
+			{Name: "dryRun", Type: "boolean", Description: "Only report the changes applying the document would make"},
+			freezeQuery,
+		},
+		Request: api.OverridesDocument{}, Response: api.OverridesApplyResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/environments/:environment/reconcile", OperationID: "reconcileEnvironment", Summary: "Reconcile an environment's Flux objects in dependency order, streaming plan, progress and done events", Tags: []string{"actions"},
+		Query: []api.QueryParameter{
+			{Name: "name", Description: "Only rollouts of Environments with this deployment name"},
+			{Name: "timeout", Description: "How long each wave may take to become ready as a Go duration (default 5m, at most 30m)"},
+		},
+		Response: kubernetes.SyncProgress{}, Stream: true},
+	{Method: http.MethodGet, Path: "/api/v1/applications/:name/environments", OperationID: "compareApplicationEnvironments", Summary: "Compare the versions an application runs across environments", Tags: []string{"rollouts"},
+		Response: api.ApplicationEnvironmentsResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/rollouts/:namespace/:name/pin", OperationID: "pinVersion", Summary: "Pin or unpin a rollout version, or propose it as a pull request for rollouts managed in git", Tags: []string{"actions"},
+		Query: []api.QueryParameter{freezeQuery}, Request: api.PinRequest{}, Response: api.RolloutResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/rollouts/:namespace/:name/force-deploy", OperationID: "forceDeploy", Summary: "Force deploy a version", Tags: []string{"actions"},
+		Query: append([]api.QueryParameter{freezeQuery}, waitQuery...), Request: api.ForceDeployRequest{}, Response: api.VersionChangeResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/rollouts/:namespace/:name/bypass-gates", OperationID: "bypassGates", Summary: "Allow a version to bypass gates", Tags: []string{"actions"},
+		Query: []api.QueryParameter{freezeQuery}, Request: api.BypassGatesRequest{}, Response: api.RolloutResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/rollouts/:namespace/:name/change-version", OperationID: "changeVersion", Summary: "Pin or force deploy a version, or propose the pin as a pull request for rollouts managed in git", Tags: []string{"actions"},
+		Query: append([]api.QueryParameter{freezeQuery}, waitQuery...), Request: api.ChangeVersionRequest{}, Response: api.VersionChangeResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/scheduled-changes", OperationID: "listScheduledChanges", Summary: "List version changes scheduled for later", Tags: []string{"actions"},
+		Query: []api.QueryParameter{
+			{Name: "namespace", Description: "only list the changes scheduled for rollouts in this namespace"},
+		},
+		Response: api.ScheduledChangeListResponse{}},
+	{Method: http.MethodDelete, Path: "/api/v1/rollouts/:namespace/:name/scheduled-change", OperationID: "cancelScheduledChange", Summary: "Cancel the version change scheduled for a rollout", Tags: []string{"actions"},
+		Response: api.RolloutResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/rollouts/:namespace/:name/unblock-failed", OperationID: "unblockFailed", Summary: "Unblock a rollout after a failed bake", Tags: []string{"actions"},
+		Query: []api.QueryParameter{freezeQuery}, Response: api.RolloutResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/rollouts/:namespace/:name/mark-successful", OperationID: "markSuccessful", Summary: "Mark the current deployment as successful", Tags: []string{"actions"},
+		Query: []api.QueryParameter{freezeQuery}, Request: api.MarkSuccessfulRequest{}, Response: api.RolloutResponse{}},
+	{Method: http.MethodPatch, Path: "/api/v1/rollouts/:namespace/:name/spec", OperationID: "patchRolloutSpec", Summary: "Change the bake time, deploy timeout, health check selector or releases ImagePolicy of a rollout", Tags: []string{"actions"},
+		Query: []api.QueryParameter{
+			{Name: "dryRun", Type: "boolean", Description: "Only validate the change with the API server"},
+			freezeQuery,
+		}, Request: api.RolloutSpecRequest{}, Response: api.RolloutSpecResponse{}},
+	{Method: http.MethodDelete, Path: "/api/v1/rollouts/:namespace/:name", OperationID: "deleteRollout", Summary: "Delete a rollout and optionally the gates, ImagePolicy and ImageRepository the dashboard created for it", Tags: []string{"actions"},
+		Query: []api.QueryParameter{
+			{Name: "cascade", Type: "boolean", Description: "Also delete the RolloutGates, ImagePolicy and ImageRepository the dashboard created for the rollout"},
+			{Name: "dryRun", Type: "boolean", Description: "Only list what would be deleted and whether the caller may"},
+			freezeQuery,
+		}, Response: api.DeleteRolloutResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/bake", OperationID: "getBakeProgress", Summary: "Get the bake progress of the current deployment with its ETA and the health checks that must stay healthy", Tags: []string{"rollouts"},
+		Response: api.BakeProgressResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/rollouts/:namespace/:name/extend-bake", OperationID: "extendBake", Summary: "Extend the bake of the current deployment before it is promoted", Tags: []string{"actions"},
+		Request: api.ExtendBakeRequest{}, Response: api.BakeExtensionResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/rollouts/:namespace/:name/reconcile", OperationID: "reconcile", Summary: "Reconcile the Flux resources of a rollout", Tags: []string{"actions"},
+		Query: []api.QueryParameter{
+			{Name: "withSource", Type: "boolean", Description: "Also reconcile the upstream sources and scan the ImageRepository first, like flux reconcile --with-source"},
+			freezeQuery,
+		}, Response: api.ReconcileResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/rollouts/:namespace/:name/helmreleases/:helmrelease/reconcile", OperationID: "reconcileHelmRelease", Summary: "Reconcile a HelmRelease deploying a rollout", Tags: []string{"actions"},
+		Query: []api.QueryParameter{freezeQuery}, Response: api.ReconcileResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/rollouts/:namespace/:name/helmreleases/:helmrelease/suspend", OperationID: "suspendHelmRelease", Summary: "Suspend the reconciliation of a HelmRelease deploying a rollout", Tags: []string{"actions"},
+		Response: api.HelmReleaseResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/rollouts/:namespace/:name/helmreleases/:helmrelease/resume", OperationID: "resumeHelmRelease", Summary: "Resume the reconciliation of a HelmRelease deploying a rollout", Tags: []string{"actions"},
+		Query: []api.QueryParameter{freezeQuery}, Response: api.HelmReleaseResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/kustomizations/:namespace/:name/suspend", OperationID: "suspendKustomization", Summary: "Suspend the reconciliation of a Kustomization", Tags: []string{"actions"},
+		Response: api.FluxSuspendResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/kustomizations/:namespace/:name/resume", OperationID: "resumeKustomization", Summary: "Resume the reconciliation of a Kustomization", Tags: []string{"actions"},
+		Query: []api.QueryParameter{freezeQuery}, Response: api.FluxSuspendResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/ocirepositories/:namespace/:name/suspend", OperationID: "suspendOCIRepository", Summary: "Suspend the reconciliation of a OCIRepository", Tags: []string{"actions"},
+		Response: api.FluxSuspendResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/ocirepositories/:namespace/:name/resume", OperationID: "resumeOCIRepository", Summary: "Resume the reconciliation of a OCIRepository", Tags: []string{"actions"},
+		Query: []api.QueryParameter{freezeQuery}, Response: api.FluxSuspendResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/imagerepositories/:namespace/:name/suspend", OperationID: "suspendImageRepository", Summary: "Suspend the reconciliation of a ImageRepository", Tags: []string{"actions"},
+		Response: api.FluxSuspendResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/imagerepositories/:namespace/:name/resume", OperationID: "resumeImageRepository", Summary: "Resume the reconciliation of a ImageRepository", Tags: []string{"actions"},
+		Query: []api.QueryParameter{freezeQuery}, Response: api.FluxSuspendResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/rollouts/:namespace/:name/continue", OperationID: "continueKruiseRollout", Summary: "Continue a paused Kruise or Argo rollout step", Tags: []string{"actions"},
+		Query: []api.QueryParameter{freezeQuery}, Request: api.ContinueRequest{}, Response: api.KruiseRolloutResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/progressive", OperationID: "getProgressiveRollout", Summary: "Get the Kruise or Argo rollout driving a rollout's workload", Tags: []string{"rollouts"},
+		Query: []api.QueryParameter{{Name: "pods", Type: "boolean", Description: "Include the workload's pods"}}, Response: api.ProgressiveRolloutResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/rollouts/:namespace/:name/progressive/continue", OperationID: "continueProgressiveRollout", Summary: "Continue a paused or waiting Kruise or Argo rollout", Tags: []string{"actions"},
+		Query: []api.QueryParameter{freezeQuery}, Response: api.ProgressiveRolloutResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/rollouts/:namespace/:name/progressive/pause", OperationID: "pauseProgressiveRollout", Summary: "Pause a Kruise or Argo rollout on its current step", Tags: []string{"actions"},
+		Response: api.ProgressiveRolloutResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/rollouts/:namespace/:name/progressive/abort", OperationID: "abortProgressiveRollout", Summary: "Abort an Argo rollout, moving traffic back to the stable version", Tags: []string{"actions"},
+		Response: api.ProgressiveRolloutResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/rollouts/:namespace/:name/progressive/step", OperationID: "setProgressiveRolloutStep", Summary: "Jump a Kruise or Argo rollout to a step", Tags: []string{"actions"},
+		Query: []api.QueryParameter{freezeQuery}, Request: api.StepRequest{}, Response: api.ProgressiveRolloutResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/gates/explain", OperationID: "explainRolloutGates", Summary: "Explain what holds the next version from the gates, health checks and bake", Tags: []string{"rollouts"},
+		Response: api.GateExplanationResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/pending-versions", OperationID: "getPendingVersions", Summary: "List the release candidates waiting to be deployed, whether gates allow each and the annotations they need", Tags: []string{"rollouts"},
+		Response: api.PendingVersionsResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/rollouts/:namespace/:name/gates", OperationID: "createRolloutGate", Summary: "Create a manual RolloutGate for a rollout", Tags: []string{"actions"},
+		Request: api.RolloutGateRequest{}, Response: api.RolloutGateResponse{}},
+	{Method: http.MethodPut, Path: "/api/v1/rollouts/:namespace/:name/gates/:gate", OperationID: "updateRolloutGate", Summary: "Replace whether a RolloutGate passes, its allowed versions and description", Tags: []string{"actions"},
+		Query: []api.QueryParameter{freezeQuery}, Request: api.RolloutGateRequest{}, Response: api.RolloutGateResponse{}},
+	{Method: http.MethodDelete, Path: "/api/v1/rollouts/:namespace/:name/gates/:gate", OperationID: "deleteRolloutGate", Summary: "Delete a RolloutGate of a rollout", Tags: []string{"actions"},
+		Query: []api.QueryParameter{freezeQuery}, Response: api.RolloutGateResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/bluegreen", OperationID: "getBlueGreenStatus", Summary: "Get the blue/green state of a Kruise rollout", Tags: []string{"rollouts"},
+		Response: api.BlueGreenResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/rollouts/:namespace/:name/bluegreen/switch-traffic", OperationID: "switchBlueGreenTraffic", Summary: "Switch production traffic of a blue/green Kruise rollout to the new version", Tags: []string{"actions"},
+		Query: []api.QueryParameter{freezeQuery}, Response: api.BlueGreenResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/rollouts/:namespace/:name/retry", OperationID: "retry", Summary: "Retry or skip failed rollout tests", Tags: []string{"actions"},
+		Query: []api.QueryParameter{freezeQuery}, Request: api.RetryRequest{}, Response: api.RetryResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/rollouts/batch", OperationID: "runBatch", Summary: "Run reconcile, unblock-failed, retry or mark-successful on many rollouts", Tags: []string{"actions"},
+		Query: []api.QueryParameter{freezeQuery}, Request: api.BatchRequest{}, Response: api.BatchResponse{}},
+
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/manifest/:version", OperationID: "getManifest", Summary: "Get the files of a release artifact", Tags: []string{"releases"},
+		Query: []api.QueryParameter{
+			{Name: "component", Description: "only the files of a component defined with the rollout.kuberik.com/components annotation"},
+		},
+		Response: api.ManifestResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/components", OperationID: "listComponents", Summary: "List the files of a monorepo release artifact by component", Tags: []string{"releases"},
+		Query: []api.QueryParameter{
+			{Name: "version", Description: "release to list, defaults to the deployed one"},
+		},
+		Response: api.ComponentsResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/components/diff", OperationID: "diffComponents", Summary: "Compare two releases of a monorepo artifact component by component", Tags: []string{"releases"},
+		Query: []api.QueryParameter{
+			{Name: "from", Description: "release to compare from, defaults to the previous deployment"},
+			{Name: "to", Description: "release to compare to, defaults to the current deployment"},
+			{Name: "component", Description: "only compare this component"},
+		},
+		Response: api.ComponentDiffResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/changelog", OperationID: "getChangelog", Summary: "List the releases between two versions with their revisions and descriptions", Tags: []string{"releases"},
+		Query: []api.QueryParameter{
+			{Name: "from", Description: "version to list from (exclusive), defaults to the deployed one"},
+			{Name: "to", Description: "version to list to (inclusive), defaults to the pinned version or the newest release candidate"},
+		},
+		Response: api.ChangelogResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/release-notes", OperationID: "getReleaseNotes", Summary: "Aggregate the commits, issues and test results of the releases between two versions", Tags: []string{"releases"},
+		Query: []api.QueryParameter{
+			{Name: "from", Description: "version to aggregate from (exclusive), defaults to the deployed one"},
+			{Name: "to", Description: "version to aggregate to (inclusive), defaults to the pinned version or the newest release candidate"},
+			{Name: "format", Description: "markdown to render the notes as Markdown"},
+		},
+		Response: api.ReleaseNotesResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/commit/:version", OperationID: "getCommit", Summary: "Get the commit a release was built from with its author and pull requests", Tags: []string{"releases"},
+		Response: api.CommitResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/validate/:version", OperationID: "validateManifests", Summary: "Server-side dry-run apply the manifests of a release before promoting it", Tags: []string{"releases"},
+		Response: api.ManifestValidationResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/mediatype/:version", OperationID: "getMediaType", Summary: "Get the artifact type of a release", Tags: []string{"releases"},
+		Response: api.MediaTypeResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/annotations/:version", OperationID: "getAnnotations", Summary: "Get the OCI annotations of a release", Tags: []string{"releases"},
+		Response: api.AnnotationsResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/channels", OperationID: "listChannels", Summary: "List channel tags and the releases they point at", Tags: []string{"releases"},
+		Response: api.ChannelsResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/channels/:channel", OperationID: "getChannel", Summary: "Resolve a channel tag to its digest and release", Tags: []string{"releases"},
+		Response: api.ChannelResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/rollouts/:namespace/:name/channel", OperationID: "trackChannel", Summary: "Follow a channel tag, or stop following one", Tags: []string{"actions"},
+		Query: []api.QueryParameter{freezeQuery}, Request: api.TrackChannelRequest{}, Response: api.TrackChannelResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/tags", OperationID: "listTags", Summary: "List all tags of the rollout's image repository", Tags: []string{"releases"},
+		Response: api.TagsResponse{}},
+
+	{Method: http.MethodGet, Path: "/api/v1/kustomizations/:namespace/:name/managed-resources", OperationID: "listManagedResources", Summary: "List resources managed by a Kustomization with the objects they own", Tags: []string{"kustomizations"},
+		Query: []api.QueryParameter{
+			{Name: "view", Description: "\"summary\" returns ManagedResourceSummaryListResponse without embedded objects"},
+			{Name: "drift", Description: "true dry-run applies the manifests built from the source artifact and flags resources edited in-cluster with a field-level diff"},
+		},
+		Response: api.ManagedResourcesResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/resources/:namespace/:kind/:name/yaml", OperationID: "getResourceYAML", Summary: "Get a resource from a Kustomization inventory as YAML without managed fields", Tags: []string{"kustomizations"},
+		Query: []api.QueryParameter{
+			{Name: "group", Description: "API group of the kind, empty for core kinds"},
+			{Name: "version", Description: "API version to read the resource with, by default the one in the inventory"},
+		}},
+	{Method: http.MethodGet, Path: "/api/v1/resources/:namespace/:kind/:name/describe", OperationID: "describeResource", Summary: "Describe a resource from a Kustomization inventory with its status, conditions and events", Tags: []string{"kustomizations"},
+		Query: []api.QueryParameter{
+			{Name: "group", Description: "API group of the kind, empty for core kinds"},
+			{Name: "version", Description: "API version to read the resource with, by default the one in the inventory"},
+		},
+		Response: api.ResourceDescribeResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/kustomizations/:namespace/:name/test", OperationID: "testKustomization", Summary: "Check whether a Kustomization has an inventory", Tags: []string{"kustomizations"},
+		Response: api.KustomizationTestResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/namespaces/:namespace/deployments/:name/children", OperationID: "getDeploymentChildren", Summary: "List ReplicaSets and pods of a Deployment", Tags: []string{"workloads"},
+		Response: api.DeploymentChildrenResponse{}},
+
+	{Method: http.MethodGet, Path: "/api/v1/schedules", OperationID: "listSchedules", Summary: "List rollout schedules", Tags: []string{"schedules"},
+		Query:    []api.QueryParameter{{Name: "namespace", Description: "Namespace to list, or \"all\" (default)"}},
+		Response: api.SchedulesResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/search", OperationID: "searchDeployedVersions", Summary: "Find rollouts running an image or source revision, or rank rollouts against a free-text query", Tags: []string{"rollouts"},
+		Query: []api.QueryParameter{
+			{Name: "q", Description: "Words that must all match the name, namespace, labels, annotations, image or deployed version of a rollout, e.g. checkout team=payments"},
+			{Name: "limit", Type: "integer", Description: "Number of rollouts to return for q (default 50, at most 200)"},
+			{Name: "image", Description: "Tag, digest or full image reference"},
+			{Name: "revision", Description: "Source commit SHA, abbreviations of at least 7 characters are accepted"},
+			{Name: "namespace", Description: "Namespace to search, or \"all\" (default)"},
+		},
+		Response: api.SearchResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/pods", OperationID: "listRolloutPods", Summary: "List the pods of a rollout's workloads and test jobs", Tags: []string{"workloads"},
+		Query: []api.QueryParameter{
+			{Name: "type", Description: "Only include pods of this workload type"},
+			{Name: "track", Description: "Only include workload pods on this track of a Kruise canary (canary or stable)"},
+		},
+		Response: api.PodsResponse{}},
+	{Method: http.MethodPost, Path: "/api/v1/pods/:namespace/:name/restart", OperationID: "restartPod", Summary: "Restart a pod by deleting it so its controller recreates it", Tags: []string{"actions"},
+		Response: api.PodRestartResponse{}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/pods/logs/download", OperationID: "downloadPodLogs", Summary: "Download the logs of the rollout's pods as a zip archive", Tags: []string{"workloads"},
+		Query: []api.QueryParameter{
+			{Name: "type", Description: "Only include pods of this workload type"},
+			{Name: "track", Description: "Only include workload pods on this track of a Kruise canary (canary or stable)"},
+			{Name: "previous", Type: "boolean", Description: "Also include logs of the previous instance of restarted containers"},
+			{Name: "tail", Description: "Only include the last lines of each log (tailLines is accepted as well)"},
+			{Name: "since", Description: "Only include logs newer than this duration, e.g. 15m"},
+			{Name: "sinceTime", Description: "Only include logs after this RFC 3339 timestamp"},
+			shareQuery,
+		}},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/exec", OperationID: "execInPod", Summary: "Open a WebSocket terminal in a container of one of the rollout's pods", Tags: []string{"workloads"},
+		Query: []api.QueryParameter{
+			{Name: "pod", Description: "Pod of the rollout to exec into (required)"},
+			{Name: "container", Description: "Container of the pod (default: kubectl default container, else the first)"},
+			{Name: "command", Description: "Command and arguments, repeated once per argument (default sh)"},
+			{Name: "tty", Type: "boolean", Description: "Allocate a TTY (default true)"},
+		},
+		Response: terminal.Message{}, Stream: true},
+	{Method: http.MethodGet, Path: "/api/v1/rollouts/:namespace/:name/pods/logs", OperationID: "streamPodLogs", Summary: "Stream pod logs", Tags: []string{"workloads"},
+		Query: []api.QueryParameter{
+			{Name: "type", Description: "Only stream pods of this workload type"},
+			{Name: "track", Description: "Only stream workload pods on this track of a Kruise canary (canary or stable)"},
+			{Name: "pod", Description: "Only stream this pod"},
+			{Name: "container", Description: "Container of the selected pod"},
+			{Name: "tail", Description: "Lines of history to start each container's stream with, or all (default 1000 unless since or sinceTime is given)"},
+			{Name: "since", Description: "Start with logs newer than this duration, e.g. 15m; a number is a Unix timestamp in milliseconds"},
+			{Name: "sinceTime", Description: "Start with logs after this RFC 3339 timestamp"},
+			{Name: "grep", Description: "Only stream lines containing this text (case insensitive, repeat to match any of several)"},
+			{Name: "exclude", Description: "Drop lines containing this text (case insensitive, repeatable)"},
+			{Name: "level", Description: "Only stream lines of at least this level (trace, debug, info, warn, error, fatal)"},
+			{Name: "mute", Description: "Do not stream this pod or pod/container (repeatable); can be changed with a control request"},
+			{Name: "solo", Description: "Only stream this pod or pod/container (repeatable); can be changed with a control request"},
+			shareQuery,
+		},
+		Response: api.LogLine{}, Stream: true},
+	{Method: http.MethodPost, Path: "/api/v1/rollouts/:namespace/:name/pods/logs/control", OperationID: "controlPodLogs", Summary: "Mute or solo pods and containers of a running pod logs stream", Tags: []string{"workloads"},
+		Request: api.LogControlRequest{}, Response: api.LogControlResponse{}},
+}
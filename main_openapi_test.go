@@ -0,0 +1,26 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIOperations(t *testing.T) {
+	operationIDs := map[string]bool{}
+	routes := map[string]bool{}
+	for _, op := range apiOperations {
+		assert.False(t, operationIDs[op.OperationID], "duplicate operationId %s", op.OperationID)
+		operationIDs[op.OperationID] = true
+		assert.False(t, routes[op.Method+" "+op.Path], "duplicate route %s %s", op.Method, op.Path)
+		routes[op.Method+" "+op.Path] = true
+	}
+
+	doc := api.NewDocument("Rollout Dashboard API", "1.0.0", apiOperations)
+	body, err := json.Marshal(doc)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `"/api/v1/rollouts/{namespace}/{name}/force-deploy"`)
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/freeze"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+)
+
+// maxOverridesDocumentSize bounds the overrides documents that can be applied
+const maxOverridesDocumentSize = 1 << 20
+
+// exportEnvironmentOverrides responds with the pins and overrides of every rollout of the
+// environment as an OverridesDocument. Rollouts without overrides are listed too, so applying the
+// document restores them unpinned.
+func exportEnvironmentOverrides(c *gin.Context) {
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return
+	}
+	environment := c.Param("environment")
+
+	targets, ok := environmentDeployments(c, k8sClient, environment)
+	if !ok {
+		return
+	}
+	exportedAt := time.Now().UTC()
+	document := api.OverridesDocument{
+		Kind:        api.OverridesKind,
+		Environment: environment,
+		ExportedAt:  &exportedAt,
+		Rollouts:    make([]kubernetes.RolloutOverrides, 0, len(targets)),
+	}
+	for _, target := range targets {
+		rollout, err := k8sClient.GetRollout(c.Request.Context(), target.Namespace, target.Name)
+		if err != nil {
+			logging.FromContext(c).Error("Error fetching rollout", "error", err)
+			api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout", err)
+			return
+		}
+		document.Rollouts = append(document.Rollouts, kubernetes.GetRolloutOverrides(rollout, target.DeploymentName))
+	}
+	c.JSON(http.StatusOK, document)
+}
+
+// applyEnvironmentOverrides applies an OverridesDocument, as YAML or JSON, to the rollouts of the
+// environment on behalf of user, or with ?dryRun=true only reports the changes it would make.
+// Entries match rollouts by namespace and name when the document was exported from the same
+// environment and by deployment name otherwise, which clones one environment's overrides into
+// another. Rollouts frozen by a window of freezes are not changed.
+func applyEnvironmentOverrides(c *gin.Context, user string, freezes *freeze.Policy) {
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return
+	}
+	environment := c.Param("environment")
+	dryRun := c.Query("dryRun") == "true"
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxOverridesDocumentSize))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		api.RespondError(c, http.StatusRequestEntityTooLarge, api.CodeBadRequest, "Request body too large", err)
+		return
+	}
+	if err != nil {
+		api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Failed to read request body", err)
+		return
+	}
+	var document api.OverridesDocument
+	if err := yaml.UnmarshalStrict(body, &document); err != nil {
+		api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid overrides document", err)
+		return
+	}
+	if document.Kind != "" && document.Kind != api.OverridesKind {
+		api.RespondErrorDetails(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid overrides document",
+			fmt.Sprintf("kind must be %s", api.OverridesKind))
+		return
+	}
+
+	targets, ok := environmentDeployments(c, k8sClient, environment)
+	if !ok {
+		return
+	}
+	frozen, ok := newFreezeCheck(c, k8sClient, freezes)
+	if !ok {
+		return
+	}
+	source := document.Environment
+	if source == "" {
+		source = environment
+	}
+	message := fmt.Sprintf("Applied overrides of environment %s", source)
+
+	response := api.OverridesApplyResponse{Environment: environment, DryRun: dryRun, Results: []api.OverridesResult{}}
+	logger := logging.FromContext(c).With("audit", true, "user", user)
+	for _, entry := range document.Rollouts {
+		entryName := entry.Namespace + "/" + entry.Name
+		var matched []kubernetes.EnvironmentRollout
+		for _, target := range targets {
+			if source == environment && target.Namespace == entry.Namespace && target.Name == entry.Name ||
+				source != environment && entry.DeploymentName != "" && target.DeploymentName == entry.DeploymentName {
+				matched = append(matched, target)
+			}
+		}
+		if len(matched) == 0 {
+			response.Failed++
+			response.Results = append(response.Results, api.OverridesResult{
+				Entry:   entryName,
+				Changes: []kubernetes.OverrideChange{},
+				Status:  http.StatusNotFound,
+				Code:    api.CodeNotFound,
+				Error:   fmt.Sprintf("no rollout of environment %q matches %s (deployment name %q)", environment, entryName, entry.DeploymentName),
+			})
+			continue
+		}
+
+		for _, target := range matched {
+			result := applyRolloutOverrides(c.Request.Context(), k8sClient, target, entry, dryRun, frozen, message)
+			result.Entry = entryName
+			switch {
+			case result.Code != "":
+				response.Failed++
+			case len(result.Changes) > 0:
+				response.Changed++
+			default:
+				response.Unchanged++
+			}
+			if result.Applied || result.Code != "" && !dryRun {
+				logger.Info("Applied overrides", "environment", environment, "from", source, "namespace", target.Namespace,
+					"rollout", target.Name, "changes", result.Changes, "error", result.Error)
+			}
+			response.Results = append(response.Results, result)
+		}
+	}
+
+	// Partial failures are reported per entry with 207 Multi-Status
+	status := http.StatusOK
+	if response.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, response)
+}
+
+// applyRolloutOverrides diffs a rollout's overrides with the wanted ones of entry and, unless
+// dryRun or there are no changes, applies them
+func applyRolloutOverrides(ctx context.Context, k8sClient *kubernetes.Client, target kubernetes.EnvironmentRollout, entry kubernetes.RolloutOverrides, dryRun bool, frozen freezeCheck, message string) api.OverridesResult {
+	ref := rolloutResourceRefs([]types.NamespacedName{target.NamespacedName})[0]
+	result := api.OverridesResult{Target: &ref, Changes: []kubernetes.OverrideChange{}, Status: http.StatusOK}
+	fail := func(err error) api.OverridesResult {
+		result.Status, result.Code = api.ClassifyError(err, http.StatusInternalServerError, api.CodeKubernetesAPI)
+		result.Error = err.Error()
+		return result
+	}
+
+	rollout, err := k8sClient.GetRollout(ctx, target.Namespace, target.Name)
+	if err != nil {
+		return fail(err)
+	}
+	result.Changes = kubernetes.DiffOverrides(kubernetes.GetRolloutOverrides(rollout, target.DeploymentName), entry)
+	if len(result.Changes) == 0 || dryRun {
+		return result
+	}
+	if err := frozen(ctx, target.Namespace, target.Name); err != nil {
+		return fail(err)
+	}
+	if _, err := k8sClient.ApplyOverrides(ctx, target.Namespace, target.Name, entry, message); err != nil {
+		return fail(err)
+	}
+	result.Applied = true
+	return result
+}
+
+// environmentDeployments returns the rollouts of the environment, responding with 404 when there
+// are none
+func environmentDeployments(c *gin.Context, k8sClient *kubernetes.Client, environment string) ([]kubernetes.EnvironmentRollout, bool) {
+	environments, err := k8sClient.GetEnvironmentsAllNamespaces(c.Request.Context())
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching environments", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch environments", err)
+		return nil, false
+	}
+	environments.Items = filterAnonymous(c, environments.Items, func(env envv1alpha1.Environment) string { return env.Namespace })
+	targets := kubernetes.EnvironmentDeployments(environments.Items, environment)
+	if len(targets) == 0 {
+		api.RespondErrorDetails(c, http.StatusNotFound, api.CodeNotFound, "No rollouts in environment",
+			fmt.Sprintf("no Environment with environment %q references a rollout", environment))
+		return nil, false
+	}
+	return targets, true
+}
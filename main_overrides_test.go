@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestEnvironmentOverrides(t *testing.T) {
+	environment := func(namespace, name, env, rollout string) *envv1alpha1.Environment {
+		return &envv1alpha1.Environment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name + "-" + env},
+			Spec:       envv1alpha1.EnvironmentSpec{Name: name, Environment: env, RolloutRef: corev1.LocalObjectReference{Name: rollout}},
+		}
+	}
+	pinned := "v1.4.0"
+	scheme, err := kubernetes.NewScheme()
+	require.NoError(t, err)
+	kubernetes.UseStaticClient(kubernetes.NewClientFrom(fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		environment("shop-staging", "api", "staging", "api"),
+		environment("shop-staging", "web", "staging", "web"),
+		environment("shop", "api", "production", "api"),
+		environment("shop", "web", "production", "web"),
+		&rolloutv1alpha1.Rollout{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop-staging", Name: "api", Annotations: map[string]string{kubernetes.BypassGatesAnnotation: "v1.4.0"}},
+			Spec:       rolloutv1alpha1.RolloutSpec{WantedVersion: &pinned},
+		},
+		&rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "shop-staging", Name: "web"}},
+		&rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api"}},
+		&rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web"}},
+	).Build(), nil))
+	defer kubernetes.UseStaticClient(nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/environments/staging/overrides", nil)
+	c.Params = gin.Params{{Key: "environment", Value: "staging"}}
+	exportEnvironmentOverrides(c)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var document api.OverridesDocument
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &document))
+	assert.Equal(t, api.OverridesKind, document.Kind)
+	require.Len(t, document.Rollouts, 2)
+	assert.Equal(t, "api", document.Rollouts[0].DeploymentName)
+	assert.Equal(t, "v1.4.0", *document.Rollouts[0].WantedVersion)
+	assert.Nil(t, document.Rollouts[1].WantedVersion)
+
+	apply := func(query, body string) (*httptest.ResponseRecorder, api.OverridesApplyResponse) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPut, "/api/v1/environments/production/overrides"+query, strings.NewReader(body))
+		c.Params = gin.Params{{Key: "environment", Value: "production"}}
+		applyEnvironmentOverrides(c, "alice@example.com", nil)
+		var response api.OverridesApplyResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w, response
+	}
+
+	// Cloning staging matches the production rollouts by deployment name
+	w, response := apply("?dryRun=true", w.Body.String())
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.True(t, response.DryRun)
+	assert.Equal(t, 1, response.Changed)
+	assert.Equal(t, 1, response.Unchanged)
+	assert.Equal(t, "shop", response.Results[0].Target.Namespace)
+	assert.Equal(t, []kubernetes.OverrideChange{
+		{Field: kubernetes.OverrideWantedVersion, To: "v1.4.0"},
+		{Field: kubernetes.OverrideBypassGates, To: "v1.4.0"},
+	}, response.Results[0].Changes)
+	assert.False(t, response.Results[0].Applied)
+	k8sClient, err := kubernetes.GetDefaultClient()
+	require.NoError(t, err)
+	rollout, err := k8sClient.GetRollout(c.Request.Context(), "shop", "api")
+	require.NoError(t, err)
+	assert.Nil(t, rollout.Spec.WantedVersion, "not applied in a dry run")
+
+	// Documents are YAML or JSON
+	w, response = apply("", `
+kind: EnvironmentOverrides
+environment: staging
+rollouts:
+  - {namespace: shop-staging, name: api, deploymentName: api, wantedVersion: v1.4.0}
+  - {namespace: shop-staging, name: worker, deploymentName: worker}
+`)
+	assert.Equal(t, http.StatusMultiStatus, w.Code, w.Body.String())
+	assert.Equal(t, 1, response.Changed)
+	assert.Equal(t, 1, response.Failed)
+	assert.True(t, response.Results[0].Applied)
+	assert.Equal(t, api.CodeNotFound, response.Results[1].Code)
+	rollout, err = k8sClient.GetRollout(c.Request.Context(), "shop", "api")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.4.0", *rollout.Spec.WantedVersion)
+
+	w, _ = apply("", `{"kind":"Rollout","rollouts":[]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/oncall"
+)
+
+// withOwnership adds the rollout's owners and who is on call for its team to the rollout detail.
+// It returns a copy, as the detail may be shared through the detail cache, and is applied after
+// the cache since on-call shifts change without the rollout changing.
+func withOwnership(ctx context.Context, owners *oncall.Directory, detail *api.RolloutDetailResponse) *api.RolloutDetailResponse {
+	if detail.Rollout == nil {
+		return detail
+	}
+	team := detail.Rollout.Annotations[kubernetes.TeamAnnotation]
+	owner := detail.Rollout.Annotations[kubernetes.OwnerAnnotation]
+	if team == "" && owner == "" {
+		return detail
+	}
+
+	out := *detail
+	out.Ownership = &api.Ownership{Team: team, Owner: owner}
+	if team != "" {
+		out.Ownership.OnCall = owners.Lookup(ctx, team)
+	}
+	return &out
+}
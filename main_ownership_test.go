@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/oncall"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWithOwnership(t *testing.T) {
+	cfg, err := oncall.ParseConfig([]byte(`teams: [{name: checkout, contacts: [{slack: "#checkout"}]}]`))
+	require.NoError(t, err)
+	owners := oncall.New(cfg)
+
+	cached := &api.RolloutDetailResponse{Rollout: &rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{
+		Annotations: map[string]string{"rollout.kuberik.com/team": "checkout", "rollout.kuberik.com/owner": "jane@example.com"},
+	}}}
+	detail := withOwnership(context.Background(), owners, cached)
+	require.NotNil(t, detail.Ownership)
+	assert.Equal(t, "jane@example.com", detail.Ownership.Owner)
+	require.NotNil(t, detail.Ownership.OnCall)
+	assert.Equal(t, "#checkout", detail.Ownership.OnCall.Contacts[0].Slack)
+	assert.Nil(t, cached.Ownership, "the cached detail is not modified")
+
+	// Without a directory only the annotations are shown
+	detail = withOwnership(context.Background(), nil, cached)
+	assert.Equal(t, "checkout", detail.Ownership.Team)
+	assert.Nil(t, detail.Ownership.OnCall)
+
+	unowned := &api.RolloutDetailResponse{Rollout: &rolloutv1alpha1.Rollout{}}
+	assert.Same(t, unowned, withOwnership(context.Background(), owners, unowned))
+}
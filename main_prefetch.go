@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	imagereflectorv1beta2 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	"github.com/google/go-containerregistry/pkg/crane"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/oci"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// defaultPrefetchInterval is how often ImageRepositories are checked for a new scan
+	defaultPrefetchInterval = 30 * time.Second
+	// defaultPrefetchTags is how many of the newest releases of a rollout are prefetched
+	defaultPrefetchTags = 10
+)
+
+// versionWarmer prefetches the manifests of the newest releases of every rollout whenever its
+// ImageRepository was scanned, so annotations and media types of the version picker are served
+// from the manifest cache even on a cold dashboard
+type versionWarmer struct {
+	tags int
+	// lastScan is the last seen scan time per ImageRepository; repositories seen for the first
+	// time count as freshly scanned
+	lastScan map[types.NamespacedName]time.Time
+	// prefetch warms the cache for one version; replaceable in tests
+	prefetch func(ctx context.Context, image, version string, opts ...crane.Option) error
+}
+
+func newVersionWarmer(tags int) *versionWarmer {
+	return &versionWarmer{
+		tags:     tags,
+		lastScan: map[types.NamespacedName]time.Time{},
+		// Annotations and media type are both read from the cached manifest
+		prefetch: func(ctx context.Context, image, version string, opts ...crane.Option) error {
+			_, err := oci.GetImageAnnotations(ctx, image, version, opts...)
+			return err
+		},
+	}
+}
+
+// prefetchTagsFromEnv reads PREFETCH_TAGS, the number of newest releases prefetched per rollout
+func prefetchTagsFromEnv() int {
+	if value, err := strconv.Atoi(os.Getenv("PREFETCH_TAGS")); err == nil && value >= 0 {
+		return value
+	}
+	return defaultPrefetchTags
+}
+
+// run checks for new scans every interval until ctx is cancelled. It uses the dashboard's
+// service account.
+func (w *versionWarmer) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := w.sync(ctx); err != nil {
+			slog.Warn("Failed to prefetch version metadata", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *versionWarmer) sync(ctx context.Context) error {
+	k8sClient, err := kubernetes.GetDefaultClient()
+	if err != nil {
+		return err
+	}
+	repositories, err := k8sClient.GetImageRepositoriesAllNamespaces(ctx)
+	if err != nil {
+		return err
+	}
+	scanned := w.scannedRepositories(repositories.Items)
+	if len(scanned) == 0 {
+		return nil
+	}
+
+	policies, err := k8sClient.GetImagePoliciesAllNamespaces(ctx)
+	if err != nil {
+		return err
+	}
+	rollouts, err := k8sClient.GetRolloutsAllNamespaces(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range rollouts.Items {
+		rollout := &rollouts.Items[i]
+		repository, ok := releasesRepository(rollout, policies.Items)
+		if !ok || !scanned[repository] {
+			continue
+		}
+		tags := newestReleases(rollout, w.tags)
+		if len(tags) == 0 {
+			continue
+		}
+		logger := slog.With("namespace", rollout.Namespace, "rollout", rollout.Name)
+
+		image, opts, err := rolloutRegistry(ctx, k8sClient, rollout)
+		if err != nil {
+			logger.Warn("Failed to get registry for prefetch", "error", err)
+			continue
+		}
+		for _, tag := range tags {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err := w.prefetch(ctx, image, tag, opts...); err != nil {
+				logger.Debug("Failed to prefetch version metadata", "version", tag, "error", err)
+			}
+		}
+		logger.Debug("Prefetched version metadata", "versions", len(tags))
+	}
+	return nil
+}
+
+// scannedRepositories returns the repositories scanned since the previous call and remembers
+// their scan times
+func (w *versionWarmer) scannedRepositories(repositories []imagereflectorv1beta2.ImageRepository) map[types.NamespacedName]bool {
+	scanned := map[types.NamespacedName]bool{}
+	seen := map[types.NamespacedName]bool{}
+	for _, repository := range repositories {
+		key := types.NamespacedName{Namespace: repository.Namespace, Name: repository.Name}
+		seen[key] = true
+		if repository.Status.LastScanResult == nil {
+			continue
+		}
+		scanTime := repository.Status.LastScanResult.ScanTime.Time
+		if last, ok := w.lastScan[key]; ok && !scanTime.After(last) {
+			continue
+		}
+		w.lastScan[key] = scanTime
+		scanned[key] = true
+	}
+	for key := range w.lastScan {
+		if !seen[key] {
+			delete(w.lastScan, key)
+		}
+	}
+	return scanned
+}
+
+// releasesRepository returns the ImageRepository behind the rollout's releases ImagePolicy
+func releasesRepository(rollout *rolloutv1alpha1.Rollout, policies []imagereflectorv1beta2.ImagePolicy) (types.NamespacedName, bool) {
+	for _, policy := range policies {
+		if policy.Namespace != rollout.Namespace || policy.Name != rollout.Spec.ReleasesImagePolicy.Name {
+			continue
+		}
+		namespace := policy.Spec.ImageRepositoryRef.Namespace
+		if namespace == "" {
+			namespace = policy.Namespace
+		}
+		return types.NamespacedName{Namespace: namespace, Name: policy.Spec.ImageRepositoryRef.Name}, true
+	}
+	return types.NamespacedName{}, false
+}
+
+// newestReleases returns the tags of up to n of the rollout's releases (candidates and available
+// releases), newest first by their creation time. Releases without a creation time come last.
+func newestReleases(rollout *rolloutv1alpha1.Rollout, n int) []string {
+	seen := map[string]bool{}
+	var releases []rolloutv1alpha1.VersionInfo
+	for _, list := range [][]rolloutv1alpha1.VersionInfo{rollout.Status.ReleaseCandidates, rollout.Status.AvailableReleases} {
+		for _, release := range list {
+			if !seen[release.Tag] {
+				seen[release.Tag] = true
+				releases = append(releases, release)
+			}
+		}
+	}
+
+	sort.SliceStable(releases, func(i, j int) bool {
+		a, b := releases[i].Created, releases[j].Created
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.After(b.Time)
+	})
+
+	tags := make([]string, 0, min(n, len(releases)))
+	for _, release := range releases[:min(n, len(releases))] {
+		tags = append(tags, release.Tag)
+	}
+	return tags
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	imagereflectorv1beta2 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func scannedRepository(name string, scanTime time.Time) imagereflectorv1beta2.ImageRepository {
+	return imagereflectorv1beta2.ImageRepository{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: name},
+		Status: imagereflectorv1beta2.ImageRepositoryStatus{
+			LastScanResult: &imagereflectorv1beta2.ScanResult{ScanTime: metav1.NewTime(scanTime)},
+		},
+	}
+}
+
+func TestVersionWarmer_ScannedRepositories(t *testing.T) {
+	w := newVersionWarmer(10)
+	scan := time.Now().Truncate(time.Second)
+	app := types.NamespacedName{Namespace: "apps", Name: "app"}
+	other := types.NamespacedName{Namespace: "apps", Name: "other"}
+
+	// Repositories seen for the first time count as scanned
+	assert.Equal(t, map[types.NamespacedName]bool{app: true, other: true}, w.scannedRepositories([]imagereflectorv1beta2.ImageRepository{
+		scannedRepository("app", scan), scannedRepository("other", scan),
+	}))
+
+	assert.Empty(t, w.scannedRepositories([]imagereflectorv1beta2.ImageRepository{
+		scannedRepository("app", scan), scannedRepository("other", scan),
+	}))
+
+	assert.Equal(t, map[types.NamespacedName]bool{other: true}, w.scannedRepositories([]imagereflectorv1beta2.ImageRepository{
+		scannedRepository("app", scan), scannedRepository("other", scan.Add(time.Minute)),
+	}))
+}
+
+func TestNewestReleases(t *testing.T) {
+	created := func(minutes int) *metav1.Time {
+		t := metav1.NewTime(time.Date(2025, 1, 1, 0, minutes, 0, 0, time.UTC))
+		return &t
+	}
+	rollout := &rolloutv1alpha1.Rollout{
+		Status: rolloutv1alpha1.RolloutStatus{
+			ReleaseCandidates: []rolloutv1alpha1.VersionInfo{{Tag: "1.2.0", Created: created(3)}},
+			AvailableReleases: []rolloutv1alpha1.VersionInfo{
+				{Tag: "1.0.0", Created: created(1)},
+				{Tag: "unknown"},
+				{Tag: "1.2.0", Created: created(3)},
+				{Tag: "1.1.0", Created: created(2)},
+			},
+		},
+	}
+
+	assert.Equal(t, []string{"1.2.0", "1.1.0", "1.0.0", "unknown"}, newestReleases(rollout, 10))
+	assert.Equal(t, []string{"1.2.0", "1.1.0"}, newestReleases(rollout, 2))
+}
+
+func TestReleasesRepository(t *testing.T) {
+	rollout := &rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "apps"}}
+	rollout.Spec.ReleasesImagePolicy.Name = "app"
+	policies := []imagereflectorv1beta2.ImagePolicy{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "app"},
+	}}
+	policies[0].Spec.ImageRepositoryRef.Name = "app-repo"
+
+	repository, ok := releasesRepository(rollout, policies)
+	assert.True(t, ok)
+	assert.Equal(t, types.NamespacedName{Namespace: "apps", Name: "app-repo"}, repository)
+
+	rollout.Spec.ReleasesImagePolicy.Name = "missing"
+	_, ok = releasesRepository(rollout, policies)
+	assert.False(t, ok)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+)
+
+// respondProgressiveError responds with the status matching an error of a progressive delivery
+// provider
+func respondProgressiveError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, kubernetes.ErrNoProgressiveRollout):
+		api.RespondError(c, http.StatusNotFound, api.CodeNotFound, "Progressive rollout not found", err)
+	case errors.Is(err, kubernetes.ErrActionUnsupported):
+		api.RespondError(c, http.StatusConflict, api.CodeConflict, message, err)
+	case errors.Is(err, kubernetes.ErrStepOutOfRange):
+		api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, message, err)
+	default:
+		logging.FromContext(c).Error("Error in progressive rollout", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, message, err)
+	}
+}
+
+// getProgressiveRollout returns the state of the Kruise or Argo rollout driving a rollout's
+// workload and, with ?pods=true, the workload's pods
+func getProgressiveRollout(c *gin.Context) {
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return
+	}
+	withPods, err := strconv.ParseBool(c.DefaultQuery("pods", "false"))
+	if err != nil {
+		api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid pods parameter", err)
+		return
+	}
+
+	provider, progressive, err := k8sClient.GetProgressiveRollout(c.Request.Context(), c.Param("namespace"), c.Param("name"))
+	if err != nil {
+		respondProgressiveError(c, "Failed to fetch progressive rollout", err)
+		return
+	}
+	response := api.ProgressiveRolloutResponse{Progressive: progressive}
+	if withPods {
+		pods, err := provider.Pods(c.Request.Context(), progressive.Namespace, progressive.Name)
+		if err != nil {
+			respondProgressiveError(c, "Failed to fetch workload pods", err)
+			return
+		}
+		response.Pods = make([]api.Pod, 0, len(pods))
+		for _, pod := range pods {
+			response.Pods = append(response.Pods, api.NewPod(pod, "pod"))
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// progressiveAction returns the handler continuing, pausing, aborting or jumping to a step of the
+// Kruise or Argo rollout driving a rollout's workload. The actions are audited.
+func progressiveAction(action string, requestUser func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k8sClient, ok := getK8sClient(c)
+		if !ok {
+			return
+		}
+		var req api.StepRequest
+		if action == "step" {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid request body", err)
+				return
+			}
+		}
+
+		ctx := c.Request.Context()
+		namespace, name := c.Param("namespace"), c.Param("name")
+		provider, _, err := k8sClient.GetProgressiveRollout(ctx, namespace, name)
+		if err != nil {
+			respondProgressiveError(c, "Failed to fetch progressive rollout", err)
+			return
+		}
+		switch action {
+		case "continue":
+			err = provider.Continue(ctx, namespace, name)
+		case "pause":
+			err = provider.Pause(ctx, namespace, name)
+		case "abort":
+			err = provider.Abort(ctx, namespace, name)
+		case "step":
+			err = provider.SetStep(ctx, namespace, name, req.Step)
+		}
+		if err != nil {
+			respondProgressiveError(c, "Failed to "+action+" progressive rollout", err)
+			return
+		}
+		logging.FromContext(c).Info("Progressive rollout action", "audit", true, "user", requestUser(c),
+			"namespace", namespace, "rollout", name, "provider", provider.Name(), "action", action, "step", req.Step)
+
+		progressive, err := provider.Get(ctx, namespace, name)
+		if err != nil {
+			respondProgressiveError(c, "Failed to fetch progressive rollout", err)
+			return
+		}
+		c.JSON(http.StatusOK, api.ProgressiveRolloutResponse{Progressive: progressive})
+	}
+}
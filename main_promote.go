@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// promote deploys the healthy version of the promotion's source rollout to its target with the
+// caller's credentials, so both reading the source and changing the target are subject to RBAC
+func promote(ctx context.Context, k8sClient *kubernetes.Client, promotion kubernetes.Promotion, pin bool, message string, frozen freezeCheck) api.PromotionResult {
+	result := api.PromotionResult{
+		Target:            rolloutResourceRefs([]types.NamespacedName{promotion.Target})[0],
+		SourceEnvironment: promotion.SourceEnvironment,
+		Status:            http.StatusOK,
+	}
+	fail := func(status int, code api.ErrorCode, err error) api.PromotionResult {
+		result.Status, result.Code = api.ClassifyError(err, status, code)
+		result.Error = err.Error()
+		return result
+	}
+
+	switch {
+	case errors.Is(promotion.Err, kubernetes.ErrNoSourceEnvironment):
+		return fail(http.StatusBadRequest, api.CodeBadRequest, promotion.Err)
+	case promotion.Err != nil:
+		return fail(http.StatusNotFound, api.CodeNotFound, promotion.Err)
+	}
+	result.Source = &rolloutResourceRefs([]types.NamespacedName{promotion.Source})[0]
+	if err := frozen(ctx, promotion.Target.Namespace, promotion.Target.Name); err != nil {
+		return fail(http.StatusInternalServerError, api.CodeKubernetesAPI, err)
+	}
+
+	source, err := k8sClient.GetRollout(ctx, promotion.Source.Namespace, promotion.Source.Name)
+	if err != nil {
+		return fail(http.StatusInternalServerError, api.CodeKubernetesAPI, err)
+	}
+	version, err := kubernetes.HealthyVersion(source)
+	if err != nil {
+		return fail(http.StatusConflict, api.CodeConflict, err)
+	}
+	result.Version = version
+	result.Chain = kubernetes.PromotionChain(source, promotion.SourceEnvironment, version)
+
+	if _, err := k8sClient.PromoteVersion(ctx, promotion.Target.Namespace, promotion.Target.Name, version, pin, message, result.Chain); err != nil {
+		return fail(http.StatusInternalServerError, api.CodeKubernetesAPI, err)
+	}
+	return result
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/freeze"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+	"github.com/kuberik/rollout-dashboard/pkg/server"
+	"github.com/kuberik/rollout-dashboard/pkg/streamhealth"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// defaultSyncWaveTimeout is how long the objects of a sync wave may take to become ready
+	defaultSyncWaveTimeout = 5 * time.Minute
+	// maxSyncWaveTimeout bounds the timeout callers may ask for
+	maxSyncWaveTimeout = 30 * time.Minute
+)
+
+// planSyncWaves orders the Flux objects of the rollouts into sync waves
+func planSyncWaves(ctx context.Context, k8sClient *kubernetes.Client, rollouts []types.NamespacedName) ([][]kubernetes.SyncTarget, error) {
+	targets, err := k8sClient.GetSyncTargets(ctx, rollouts)
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.SyncWaves(targets)
+}
+
+// syncPromoted reconciles the Flux objects of the rollouts that were promoted to in sync waves,
+// waiting for each wave
+func syncPromoted(ctx context.Context, k8sClient *kubernetes.Client, results []api.PromotionResult) *api.SyncSummary {
+	var rollouts []types.NamespacedName
+	for _, result := range results {
+		if result.Code == "" {
+			rollouts = append(rollouts, types.NamespacedName{Namespace: result.Target.Namespace, Name: result.Target.Name})
+		}
+	}
+	waves, err := planSyncWaves(ctx, k8sClient, rollouts)
+	if err != nil {
+		summary := api.NewSyncSummary(nil)
+		summary.Error = err.Error()
+		return summary
+	}
+	return api.NewSyncSummary(k8sClient.SyncInWaves(ctx, waves, defaultSyncWaveTimeout, func(kubernetes.SyncProgress) {}))
+}
+
+// streamEnvironmentReconcile reconciles the Flux objects of an environment's rollouts in sync
+// waves, streaming the plan, every phase change and a summary as Server-Sent Events watched by
+// streams. Rollouts frozen by freezes are left out, and the request is rejected with 423 when all
+// of them are. The summary is written to the audit log as done by user.
+func streamEnvironmentReconcile(c *gin.Context, k8sClient *kubernetes.Client, streams *streamhealth.Monitor, freezes *freeze.Policy, user string) {
+	environment := c.Param("environment")
+	timeout := defaultSyncWaveTimeout
+	if raw := c.Query("timeout"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 || parsed > maxSyncWaveTimeout {
+			api.RespondErrorDetails(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid timeout",
+				fmt.Sprintf("timeout must be a positive Go duration of at most %s", maxSyncWaveTimeout))
+			return
+		}
+		timeout = parsed
+	}
+
+	environments, err := k8sClient.GetEnvironmentsAllNamespaces(c.Request.Context())
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching environments", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch environments", err)
+		return
+	}
+	environments.Items = filterAnonymous(c, environments.Items, func(env envv1alpha1.Environment) string { return env.Namespace })
+	rollouts := kubernetes.EnvironmentRollouts(environments.Items, environment, c.Query("name"))
+	if len(rollouts) == 0 {
+		api.RespondErrorDetails(c, http.StatusNotFound, api.CodeNotFound, "No rollouts in environment",
+			fmt.Sprintf("no Environment with environment %q references a rollout", environment))
+		return
+	}
+	check, ok := newFreezeCheck(c, k8sClient, freezes)
+	if !ok {
+		return
+	}
+	var unfrozen, frozen []types.NamespacedName
+	var frozenErr error
+	for _, rollout := range rollouts {
+		err := check(c.Request.Context(), rollout.Namespace, rollout.Name)
+		switch {
+		case errors.Is(err, freeze.ErrFrozen):
+			frozen, frozenErr = append(frozen, rollout), err
+		case err != nil:
+			respondFreezeCheck(c, user, err)
+			return
+		default:
+			unfrozen = append(unfrozen, rollout)
+		}
+	}
+	if len(unfrozen) == 0 {
+		respondFreezeCheck(c, user, frozenErr)
+		return
+	}
+	rollouts = unfrozen
+
+	// The plan is made before switching to SSE so a cycle is a plain error response
+	waves, err := planSyncWaves(c.Request.Context(), k8sClient, rollouts)
+	switch {
+	case errors.Is(err, kubernetes.ErrDependencyCycle):
+		api.RespondError(c, http.StatusConflict, api.CodeConflict, "Flux objects cannot be ordered", err)
+		return
+	case err != nil:
+		logging.FromContext(c).Error("Error fetching Flux objects", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch Flux objects", err)
+		return
+	}
+
+	c.Header("Content-Type", sse.ContentType)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	ctx, cancel := server.StreamContext(c.Request.Context())
+	defer cancel()
+	ctx, stream := streams.Start(ctx, c, user)
+	defer stream.Close()
+
+	send := func(event string, data any) bool {
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return true
+		}
+		return stream.Send(sse.Event{Event: event, Data: string(encoded)}) == nil
+	}
+	plan := api.SyncPlan{Environment: environment, Rollouts: rolloutResourceRefs(rollouts), Waves: waves}
+	if len(frozen) > 0 {
+		plan.Frozen = rolloutResourceRefs(frozen)
+	}
+	if !send("plan", plan) {
+		return
+	}
+
+	progress := make(chan kubernetes.SyncProgress, 64)
+	done := make(chan []kubernetes.SyncProgress, 1)
+	go func() {
+		defer close(progress)
+		done <- k8sClient.SyncInWaves(ctx, waves, timeout, func(p kubernetes.SyncProgress) {
+			select {
+			case progress <- p:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stream.Send(sse.Event{Event: "ping", Data: "{}"})
+		case p, ok := <-progress:
+			if !ok {
+				summary := api.NewSyncSummary(<-done)
+				logging.FromContext(c).Info("Reconciled environment in sync waves", "audit", true, "user", user,
+					"environment", environment, "ready", summary.Ready, "failed", summary.Failed, "skipped", summary.Skipped)
+				send("done", summary)
+				return
+			}
+			if !send("progress", p) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/gitprovider"
+	"github.com/kuberik/rollout-dashboard/pkg/oci"
+	"github.com/kuberik/rollout-dashboard/pkg/releasenotes"
+)
+
+// getRolloutReleaseNotes aggregates the annotations, commits, JIRA issues and test results of the
+// releases a changelog covers, by default what the pending upgrade brings. With ?format=markdown
+// the notes are rendered as Markdown.
+func getRolloutReleaseNotes(c *gin.Context, builder *releasenotes.Builder, commits *gitprovider.Client) {
+	releases, ok := resolveChangelogRange(c)
+	if !ok {
+		return
+	}
+	var commit func(ctx context.Context, source, revision string) (*gitprovider.Commit, error)
+	if commits != nil {
+		commit = commits.GetCommit
+	}
+	notes := builder.Notes(releases.from, releases.to,
+		buildReleaseNotes(c.Request.Context(), builder, releases.versions, releases.annotations, commit), releases.omitted)
+	if c.Query("format") == "markdown" {
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(notes.Markdown()))
+		return
+	}
+	c.JSON(http.StatusOK, api.ReleaseNotesResponse{Notes: notes})
+}
+
+// buildReleaseNotes describes versions with at most changelogConcurrency releases looked up at
+// once, in the order of versions. A release whose annotations or commit cannot be read is listed
+// with the error. Commits are only looked up when commit is not nil.
+func buildReleaseNotes(ctx context.Context, builder *releasenotes.Builder, versions []string,
+	annotations func(ctx context.Context, version string) (map[string]string, error),
+	commit func(ctx context.Context, source, revision string) (*gitprovider.Commit, error)) []releasenotes.Release {
+	releases := make([]releasenotes.Release, len(versions))
+	sem := make(chan struct{}, changelogConcurrency)
+	var wg sync.WaitGroup
+	for i, version := range versions {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			values, err := annotations(ctx, version)
+			entry := oci.NewChangelogEntry(version, values)
+			if err != nil {
+				entry.Error = err.Error()
+			}
+			var found *gitprovider.Commit
+			var commitErr error
+			if commit != nil && entry.Source != "" && entry.Revision != "" {
+				found, commitErr = commit(ctx, entry.Source, entry.Revision)
+			}
+			releases[i] = builder.Release(entry, values, found)
+			if commitErr != nil {
+				releases[i].CommitError = commitErr.Error()
+			}
+		}()
+	}
+	wg.Wait()
+	return releases
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kuberik/rollout-dashboard/pkg/gitprovider"
+	"github.com/kuberik/rollout-dashboard/pkg/oci"
+	"github.com/kuberik/rollout-dashboard/pkg/releasenotes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildReleaseNotes(t *testing.T) {
+	annotations := func(_ context.Context, version string) (map[string]string, error) {
+		if version == "v1.2.0" {
+			return nil, errors.New("manifest unknown")
+		}
+		return map[string]string{
+			oci.SourceAnnotation:               "https://github.com/kuberik/shop",
+			oci.RevisionAnnotation:             "main@sha1:" + map[string]string{"v1.3.0": "aaaaaaa", "v1.1.0": "bbbbbbb"}[version],
+			releasenotes.TestResultsAnnotation: "passed=3",
+		}, nil
+	}
+	commit := func(_ context.Context, source, revision string) (*gitprovider.Commit, error) {
+		if revision == "main@sha1:bbbbbbb" {
+			return nil, errors.New("rate limited")
+		}
+		return &gitprovider.Commit{SHA: "aaaaaaa", Message: "SHOP-1: new cart"}, nil
+	}
+
+	releases := buildReleaseNotes(context.Background(), releasenotes.New(""), []string{"v1.3.0", "v1.2.0", "v1.1.0"}, annotations, commit)
+	require.Len(t, releases, 3)
+	assert.Equal(t, "aaaaaaa", releases[0].Commit.SHA)
+	assert.Equal(t, []releasenotes.Issue{{Key: "SHOP-1"}}, releases[0].Issues)
+	assert.Equal(t, &releasenotes.TestResults{Passed: 3}, releases[0].Tests)
+	assert.Equal(t, "manifest unknown", releases[1].Error)
+	assert.Nil(t, releases[1].Commit)
+	assert.Equal(t, "rate limited", releases[2].CommitError)
+
+	// Without a git provider only the annotations are read
+	releases = buildReleaseNotes(context.Background(), releasenotes.New(""), []string{"v1.3.0"}, annotations, nil)
+	assert.Nil(t, releases[0].Commit)
+	assert.Empty(t, releases[0].CommitError)
+}
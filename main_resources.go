@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+// resolveManagedResource resolves the resource named by the path and the group and version query
+// parameters to its REST mapping. Resources no Kustomization applied are not found, and the
+// caller must be allowed to get the resource. On failure it responds and returns false.
+func resolveManagedResource(c *gin.Context, k8sClient *kubernetes.Client) (*meta.RESTMapping, bool) {
+	ctx := c.Request.Context()
+	namespace, kind, name := c.Param("namespace"), c.Param("kind"), c.Param("name")
+
+	mapping, err := k8sClient.GetManagedResourceMapping(ctx, c.Query("group"), c.Query("version"), kind, namespace, name)
+	if errors.Is(err, kubernetes.ErrNotManaged) {
+		api.RespondError(c, http.StatusNotFound, api.CodeNotFound, "Resource not found", err)
+		return nil, false
+	}
+	if err != nil {
+		logging.FromContext(c).Error("Error resolving managed resource", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to resolve resource", err)
+		return nil, false
+	}
+
+	// Check up front, so the object is only read when the caller may read it themselves
+	allowed, err := k8sClient.CheckPermission(ctx, mapping.Resource.Group, mapping.Resource.Resource, "get", namespace, name)
+	if err != nil {
+		logging.FromContext(c).Error("Error checking permission", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to check permission", err)
+		return nil, false
+	}
+	if !allowed {
+		api.RespondErrorDetails(c, http.StatusForbidden, api.CodeForbidden, "Not allowed to read resource",
+			fmt.Sprintf("getting %s in namespace %s is not permitted", mapping.Resource.Resource, namespace))
+		return nil, false
+	}
+	return mapping, true
+}
+
+// getResourceYAML returns a resource from the inventory of a Kustomization as YAML, without its
+// managed fields. Resources no Kustomization applied are not served.
+func getResourceYAML(c *gin.Context) {
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return
+	}
+	mapping, ok := resolveManagedResource(c, k8sClient)
+	if !ok {
+		return
+	}
+
+	manifest, err := k8sClient.GetResourceYAML(c.Request.Context(), mapping.GroupVersionKind, c.Param("namespace"), c.Param("name"))
+	if apierrors.IsNotFound(err) {
+		api.RespondError(c, http.StatusNotFound, api.CodeNotFound, "Resource not found", err)
+		return
+	}
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching resource", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch resource", err)
+		return
+	}
+	c.Data(http.StatusOK, "application/yaml; charset=utf-8", manifest)
+}
+
+// describeResource returns the kstatus status, conditions and events of a resource from the
+// inventory of a Kustomization, like kubectl describe
+func describeResource(c *gin.Context) {
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return
+	}
+	mapping, ok := resolveManagedResource(c, k8sClient)
+	if !ok {
+		return
+	}
+
+	description, err := k8sClient.DescribeResource(c.Request.Context(), mapping.GroupVersionKind, c.Param("namespace"), c.Param("name"))
+	if apierrors.IsNotFound(err) {
+		api.RespondError(c, http.StatusNotFound, api.CodeNotFound, "Resource not found", err)
+		return
+	}
+	if err != nil {
+		logging.FromContext(c).Error("Error describing resource", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to describe resource", err)
+		return
+	}
+	c.JSON(http.StatusOK, api.NewResourceDescribeResponse(description))
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// deleteRollout returns the handler deleting a rollout and, with ?cascade=true, the gates,
+// ImagePolicy and ImageRepository the dashboard created for it. The caller must be allowed to
+// delete every object, otherwise nothing is deleted. With ?dryRun=true it only lists what would be
+// deleted and whether the caller may. Deletions are audited with the verified user.
+func deleteRollout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k8sClient, ok := getK8sClient(c)
+		if !ok {
+			return
+		}
+		namespace, name := c.Param("namespace"), c.Param("name")
+		cascade, dryRun := c.Query("cascade") == "true", c.Query("dryRun") == "true"
+
+		targets, err := k8sClient.PlanRolloutDeletion(c.Request.Context(), namespace, name, cascade)
+		switch {
+		case apierrors.IsNotFound(err):
+			api.RespondError(c, http.StatusNotFound, api.CodeNotFound, "Rollout not found", err)
+			return
+		case errors.Is(err, kubernetes.ErrManagedByKustomization):
+			api.RespondError(c, http.StatusConflict, api.CodeConflict, "Rollout cannot be deleted", err)
+			return
+		case err != nil:
+			logging.FromContext(c).Error("Error planning rollout deletion", "error", err)
+			api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to list objects to delete", err)
+			return
+		}
+
+		var denied []string
+		for i := range targets {
+			target := &targets[i]
+			target.Allowed, err = k8sClient.CheckPermission(c.Request.Context(), target.Group, target.Resource, "delete", target.Namespace, target.Name)
+			if err != nil {
+				logging.FromContext(c).Error("Error checking permission", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to check permission", err)
+				return
+			}
+			if !target.Allowed {
+				denied = append(denied, fmt.Sprintf("%s %s/%s", target.Kind, target.Namespace, target.Name))
+			}
+		}
+		response := api.DeleteRolloutResponse{DryRun: dryRun, Cascade: cascade, Targets: targets}
+		if dryRun {
+			c.JSON(http.StatusOK, response)
+			return
+		}
+		if len(denied) > 0 {
+			api.RespondErrorDetails(c, http.StatusForbidden, api.CodeForbidden, "Not allowed to delete rollout",
+				"deleting "+strings.Join(denied, ", ")+" is not permitted")
+			return
+		}
+
+		user, err := verifiedUser(c, k8sClient)
+		if err != nil {
+			logging.FromContext(c).Error("Error identifying user", "error", err)
+			api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to identify user", err)
+			return
+		}
+
+		status := http.StatusOK
+		if !k8sClient.DeleteTargets(c.Request.Context(), response.Targets) {
+			// Partial failures are reported per object with 207 Multi-Status
+			status = http.StatusMultiStatus
+		}
+		logging.FromContext(c).Info("Deleted rollout", "audit", true, "user", user,
+			"namespace", namespace, "rollout", name, "cascade", cascade, "objects", response.Targets)
+		c.JSON(status, response)
+	}
+}
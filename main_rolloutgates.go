@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// explainRolloutGates explains why the next version of a rollout is held, from its gates, its
+// health checks and the bake of its current version
+func explainRolloutGates(c *gin.Context) {
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return
+	}
+	explanation, err := k8sClient.ExplainRolloutGates(c.Request.Context(), c.Param("namespace"), c.Param("name"), time.Now())
+	if apierrors.IsNotFound(err) {
+		api.RespondError(c, http.StatusNotFound, api.CodeNotFound, "Rollout not found", err)
+		return
+	}
+	if err != nil {
+		logging.FromContext(c).Error("Error explaining rollout gates", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to explain rollout gates", err)
+		return
+	}
+	c.JSON(http.StatusOK, api.GateExplanationResponse{GateExplanation: *explanation})
+}
+
+// getPendingVersions lists the release candidates newer than the deployed version of a rollout,
+// whether its gates allow each and the annotations that would deploy it now
+func getPendingVersions(c *gin.Context) {
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return
+	}
+	pending, err := k8sClient.GetPendingVersions(c.Request.Context(), c.Param("namespace"), c.Param("name"), time.Now())
+	if apierrors.IsNotFound(err) {
+		api.RespondError(c, http.StatusNotFound, api.CodeNotFound, "Rollout not found", err)
+		return
+	}
+	if err != nil {
+		logging.FromContext(c).Error("Error listing pending versions", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to list pending versions", err)
+		return
+	}
+	c.JSON(http.StatusOK, api.PendingVersionsResponse{PendingVersions: *pending})
+}
+
+// authorizeGateAction checks up front whether the caller may perform verb on RolloutGates in the
+// namespace, so they get a clear answer instead of a failed request, and returns the verified user
+// to audit the action with. On failure it responds and ok is false.
+func authorizeGateAction(c *gin.Context, k8sClient *kubernetes.Client, verb, namespace, name string) (user string, ok bool) {
+	allowed, err := k8sClient.CheckPermission(c.Request.Context(), "kuberik.com", "rolloutgates", verb, namespace, name)
+	if err != nil {
+		logging.FromContext(c).Error("Error checking permission", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to check permission", err)
+		return "", false
+	}
+	if !allowed {
+		api.RespondErrorDetails(c, http.StatusForbidden, api.CodeForbidden, "Not allowed to "+verb+" rollout gate",
+			fmt.Sprintf("%s rolloutgates in namespace %s is not permitted", verb, namespace))
+		return "", false
+	}
+	user, err = verifiedUser(c, k8sClient)
+	if err != nil {
+		logging.FromContext(c).Error("Error identifying user", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to identify user", err)
+		return "", false
+	}
+	return user, true
+}
+
+// respondGateError responds with the status matching an error of a RolloutGate change
+func respondGateError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, kubernetes.ErrGateNotFound):
+		api.RespondError(c, http.StatusNotFound, api.CodeNotFound, "Rollout gate not found", err)
+	case apierrors.IsAlreadyExists(err), apierrors.IsConflict(err):
+		api.RespondError(c, http.StatusConflict, api.CodeConflict, message, err)
+	default:
+		logging.FromContext(c).Error("Error changing rollout gate", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, message, err)
+	}
+}
+
+func bindRolloutGateRequest(c *gin.Context) (api.RolloutGateRequest, bool) {
+	var req api.RolloutGateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid request body", err)
+		return req, false
+	}
+	return req, true
+}
+
+func rolloutGateSpec(req api.RolloutGateRequest) kubernetes.RolloutGateSpec {
+	return kubernetes.RolloutGateSpec{Passing: req.Passing, AllowedVersions: req.AllowedVersions, Description: req.Description}
+}
+
+// createRolloutGate returns the handler creating a manual RolloutGate for a rollout, e.g. to hold
+// it during a holiday freeze. Creations are audited with the verified user.
+func createRolloutGate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k8sClient, ok := getK8sClient(c)
+		if !ok {
+			return
+		}
+		req, ok := bindRolloutGateRequest(c)
+		if !ok {
+			return
+		}
+		if problems := validation.IsDNS1123Subdomain(req.Name); len(problems) > 0 {
+			api.RespondErrorDetails(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid rollout gate name", strings.Join(problems, "; "))
+			return
+		}
+		namespace, name := c.Param("namespace"), c.Param("name")
+		user, ok := authorizeGateAction(c, k8sClient, "create", namespace, "")
+		if !ok {
+			return
+		}
+		if _, err := k8sClient.GetRollout(c.Request.Context(), namespace, name); err != nil {
+			if apierrors.IsNotFound(err) {
+				api.RespondError(c, http.StatusNotFound, api.CodeNotFound, "Rollout not found", err)
+				return
+			}
+			logging.FromContext(c).Error("Error fetching rollout", "error", err)
+			api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout", err)
+			return
+		}
+
+		gate, err := k8sClient.CreateRolloutGate(c.Request.Context(), namespace, name, req.Name, rolloutGateSpec(req))
+		if err != nil {
+			respondGateError(c, "Failed to create rollout gate", err)
+			return
+		}
+		logging.FromContext(c).Info("Created rollout gate", "audit", true, "user", user,
+			"namespace", namespace, "rollout", name, "gate", gate.Name, "passing", gate.Spec.Passing, "description", req.Description)
+		c.JSON(http.StatusCreated, api.RolloutGateResponse{Gate: api.NewRolloutGate(*gate)})
+	}
+}
+
+// updateRolloutGate returns the handler replacing whether a RolloutGate of a rollout passes, the
+// versions it allows and its description. Updates are audited with the verified user.
+func updateRolloutGate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k8sClient, ok := getK8sClient(c)
+		if !ok {
+			return
+		}
+		req, ok := bindRolloutGateRequest(c)
+		if !ok {
+			return
+		}
+		namespace, name, gateName := c.Param("namespace"), c.Param("name"), c.Param("gate")
+		user, ok := authorizeGateAction(c, k8sClient, "update", namespace, gateName)
+		if !ok {
+			return
+		}
+
+		gate, err := k8sClient.UpdateRolloutGate(c.Request.Context(), namespace, name, gateName, rolloutGateSpec(req))
+		if err != nil {
+			respondGateError(c, "Failed to update rollout gate", err)
+			return
+		}
+		logging.FromContext(c).Info("Updated rollout gate", "audit", true, "user", user,
+			"namespace", namespace, "rollout", name, "gate", gateName, "passing", gate.Spec.Passing, "description", req.Description)
+		c.JSON(http.StatusOK, api.RolloutGateResponse{Gate: api.NewRolloutGate(*gate)})
+	}
+}
+
+// deleteRolloutGate returns the handler deleting a RolloutGate of a rollout and responding with
+// the deleted gate. Deletions are audited with the verified user.
+func deleteRolloutGate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k8sClient, ok := getK8sClient(c)
+		if !ok {
+			return
+		}
+		namespace, name, gateName := c.Param("namespace"), c.Param("name"), c.Param("gate")
+		user, ok := authorizeGateAction(c, k8sClient, "delete", namespace, gateName)
+		if !ok {
+			return
+		}
+
+		gate, err := k8sClient.DeleteRolloutGate(c.Request.Context(), namespace, name, gateName)
+		if err != nil {
+			respondGateError(c, "Failed to delete rollout gate", err)
+			return
+		}
+		logging.FromContext(c).Info("Deleted rollout gate", "audit", true, "user", user,
+			"namespace", namespace, "rollout", name, "gate", gateName)
+		c.JSON(http.StatusOK, api.RolloutGateResponse{Gate: api.NewRolloutGate(*gate)})
+	}
+}
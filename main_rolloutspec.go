@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// maxRolloutSpecPatchSize bounds the spec changes that can be applied
+const maxRolloutSpecPatchSize = 64 << 10
+
+// patchRolloutSpec returns the handler changing the editable spec fields of a rollout, see
+// kubernetes.EditableSpecFields, or with ?dryRun=true only validating the change with the API
+// server. Changes are audited.
+func patchRolloutSpec(requestUser func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k8sClient, ok := getK8sClient(c)
+		if !ok {
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxRolloutSpecPatchSize))
+		if err != nil {
+			api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Failed to read request body", err)
+			return
+		}
+		patch, err := kubernetes.ParseRolloutSpecPatch(body)
+		if err != nil {
+			api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid rollout spec change", err)
+			return
+		}
+		namespace, name := c.Param("namespace"), c.Param("name")
+		dryRun := c.Query("dryRun") == "true"
+
+		rollout, changed, err := k8sClient.PatchRolloutSpec(c.Request.Context(), namespace, name, patch, dryRun)
+		switch {
+		case errors.Is(err, kubernetes.ErrInvalidSpec), apierrors.IsInvalid(err), apierrors.IsBadRequest(err):
+			api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid rollout spec change", err)
+			return
+		case err != nil:
+			status, code := api.ClassifyError(err, http.StatusInternalServerError, api.CodeKubernetesAPI)
+			if status == http.StatusInternalServerError {
+				logging.FromContext(c).Error("Error patching rollout spec", "error", err)
+			}
+			api.RespondError(c, status, code, "Failed to change rollout spec", err)
+			return
+		}
+		if !dryRun {
+			logging.FromContext(c).Info("Changed rollout spec", "audit", true, "user", requestUser(c),
+				"namespace", namespace, "rollout", name, "fields", changed, "patch", patch)
+		}
+		c.JSON(http.StatusOK, api.RolloutSpecResponse{Rollout: rollout, DryRun: dryRun, Changed: changed})
+	}
+}
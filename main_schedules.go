@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/freeze"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const (
+	// defaultSchedulerInterval is how often scheduled version changes are checked for being due
+	defaultSchedulerInterval = 30 * time.Second
+	// schedulerLeaseName is the Lease the dashboard's replicas elect the scheduler with, so a
+	// scheduled change is applied once
+	schedulerLeaseName = "rollout-dashboard-scheduler"
+	// serviceAccountNamespaceFile holds the namespace of the pod's service account
+	serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+// schedulerNamespace returns the namespace of the scheduler Lease: POD_NAMESPACE, or the
+// namespace of the pod's service account. Empty string outside a cluster.
+func schedulerNamespace() string {
+	if namespace := os.Getenv("POD_NAMESPACE"); namespace != "" {
+		return namespace
+	}
+	data, err := os.ReadFile(serviceAccountNamespaceFile)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// runScheduler applies scheduled version changes once they are due and no window of freezes is
+// active, checking every interval until ctx is cancelled. In a cluster only the replica holding the scheduler Lease applies them; outside
+// one, e.g. during development, there is a single replica and no election.
+func runScheduler(ctx context.Context, interval time.Duration, freezes *freeze.Policy) {
+	namespace := schedulerNamespace()
+	if namespace == "" {
+		slog.Info("Running scheduler without leader election, the namespace of the dashboard is unknown")
+		applyScheduledChangesEvery(ctx, interval, freezes)
+		return
+	}
+	k8sClient, err := kubernetes.GetDefaultClient()
+	if err != nil {
+		slog.Error("Failed to start scheduler", "error", err)
+		return
+	}
+	identity, err := os.Hostname()
+	if err != nil {
+		slog.Error("Failed to start scheduler", "error", err)
+		return
+	}
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta:  metav1.ObjectMeta{Namespace: namespace, Name: schedulerLeaseName},
+		Client:     k8sClient.GetClientset().CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+	}
+	// RunOrDie returns when leadership is lost, so campaign again until shutdown
+	for ctx.Err() == nil {
+		leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			LeaseDuration:   15 * time.Second,
+			RenewDeadline:   10 * time.Second,
+			RetryPeriod:     2 * time.Second,
+			ReleaseOnCancel: true,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(ctx context.Context) {
+					slog.Info("Became the scheduler", "identity", identity)
+					applyScheduledChangesEvery(ctx, interval, freezes)
+				},
+				OnStoppedLeading: func() {
+					slog.Info("Stopped being the scheduler", "identity", identity)
+				},
+			},
+		})
+	}
+}
+
+func applyScheduledChangesEvery(ctx context.Context, interval time.Duration, freezes *freeze.Policy) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := applyScheduledChanges(ctx, time.Now(), freezes); err != nil {
+			slog.Warn("Failed to apply scheduled version changes", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// applyScheduledChanges applies the scheduled version changes that are due at now. Changes to
+// rollouts frozen by a window of freezes stay scheduled until the window ends.
+func applyScheduledChanges(ctx context.Context, now time.Time, freezes *freeze.Policy) error {
+	k8sClient, err := kubernetes.GetDefaultClient()
+	if err != nil {
+		return err
+	}
+	rollouts, err := k8sClient.GetRolloutsAllNamespaces(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range rollouts.Items {
+		rollout := &rollouts.Items[i]
+		if !kubernetes.ScheduledChangeDue(rollout, now) {
+			continue
+		}
+		logger := slog.With("namespace", rollout.Namespace, "rollout", rollout.Name)
+		if freezes != nil {
+			if err := freezes.Check(rollout.Namespace, rollout.Labels, now); err != nil {
+				logger.Info("Deferred scheduled version change", "reason", err)
+				continue
+			}
+		}
+		change, err := k8sClient.ApplyScheduledChange(ctx, rollout)
+		if err != nil {
+			logger.Warn("Failed to apply scheduled version change", "error", err)
+			continue
+		}
+		logger.Info("Applied scheduled version change", "audit", true, "user", change.User,
+			"version", change.Version, "pin", change.Pin, "scheduledFor", change.At.Time)
+	}
+	return nil
+}
+
+// newScheduledChanges lists the scheduled version changes of rollouts, soonest first
+func newScheduledChanges(rollouts []rolloutv1alpha1.Rollout) []api.ScheduledRolloutChange {
+	changes := []api.ScheduledRolloutChange{}
+	for i := range rollouts {
+		if change := kubernetes.GetScheduledChange(&rollouts[i]); change != nil {
+			changes = append(changes, api.ScheduledRolloutChange{Namespace: rollouts[i].Namespace, Name: rollouts[i].Name, ScheduledChange: *change})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].At.Before(&changes[j].At) })
+	return changes
+}
+
+// listScheduledChanges lists the version changes scheduled for the rollouts the caller can read
+func listScheduledChanges(c *gin.Context) {
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return
+	}
+
+	var rollouts *rolloutv1alpha1.RolloutList
+	var skippedNamespaces []kubernetes.SkippedNamespace
+	var err error
+	if namespace := c.Query("namespace"); namespace != "" {
+		rollouts, err = k8sClient.GetRollouts(c.Request.Context(), namespace)
+	} else {
+		rollouts, err = k8sClient.GetRolloutsAllNamespaces(c.Request.Context())
+		if apierrors.IsForbidden(err) {
+			rollouts, _, skippedNamespaces, err = listRolloutsInAccessibleNamespaces(c.Request.Context(), k8sClient)
+			if errors.Is(err, kubernetes.ErrNoAccessibleNamespaces) {
+				api.RespondError(c, http.StatusForbidden, api.CodeForbidden, "Not allowed to list rollouts in all namespaces", err)
+				return
+			}
+		}
+	}
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching rollouts", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollouts", err)
+		return
+	}
+	changes := filterAnonymous(c, newScheduledChanges(rollouts.Items), func(change api.ScheduledRolloutChange) string {
+		return change.Namespace
+	})
+	c.JSON(http.StatusOK, api.ScheduledChangeListResponse{Changes: changes, SkippedNamespaces: skippedNamespaces})
+}
+
+// cancelScheduledChange removes the version change scheduled for a rollout, audited with the
+// verified user
+func cancelScheduledChange(c *gin.Context) {
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return
+	}
+	namespace, name := c.Param("namespace"), c.Param("name")
+	user, err := verifiedUser(c, k8sClient)
+	if err != nil {
+		logging.FromContext(c).Error("Error identifying user", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to identify user", err)
+		return
+	}
+
+	if err := k8sClient.CancelScheduledChange(c.Request.Context(), namespace, name); err != nil {
+		logging.FromContext(c).Error("Error cancelling scheduled version change", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to cancel scheduled version change", err)
+		return
+	}
+	rollout, err := k8sClient.GetRollout(c.Request.Context(), namespace, name)
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching rollout", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout", err)
+		return
+	}
+	logging.FromContext(c).Info("Cancelled scheduled version change", "audit", true, "user", user, "namespace", namespace, "rollout", name)
+	c.JSON(http.StatusOK, api.RolloutResponse{Rollout: rollout})
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func scheduledRollout(t *testing.T, name, version string, at time.Time) *rolloutv1alpha1.Rollout {
+	t.Helper()
+	value, err := json.Marshal(kubernetes.ScheduledChange{Version: version, At: metav1.NewTime(at), User: "alice@example.com"})
+	require.NoError(t, err)
+	return &rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{
+		Namespace:   "shop",
+		Name:        name,
+		Annotations: map[string]string{kubernetes.ScheduledChangeAnnotation: string(value)},
+	}}
+}
+
+func TestApplyScheduledChanges(t *testing.T) {
+	now := time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC)
+	scheme, err := kubernetes.NewScheme()
+	require.NoError(t, err)
+	kubernetes.UseStaticClient(kubernetes.NewClientFrom(fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		scheduledRollout(t, "api", "v2", now.Add(-time.Minute)),
+		scheduledRollout(t, "web", "v3", now.Add(time.Hour)),
+	).Build(), nil))
+	defer kubernetes.UseStaticClient(nil)
+	k8sClient, err := kubernetes.GetDefaultClient()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	rollouts, err := k8sClient.GetRolloutsAllNamespaces(ctx)
+	require.NoError(t, err)
+	changes := newScheduledChanges(rollouts.Items)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "api", changes[0].Name, "soonest first")
+	assert.Equal(t, "alice@example.com", changes[0].User)
+
+	require.NoError(t, applyScheduledChanges(ctx, now, nil))
+	apiRollout, err := k8sClient.GetRollout(ctx, "shop", "api")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", apiRollout.Annotations[kubernetes.ForceDeployAnnotation])
+	assert.Equal(t, "alice@example.com", apiRollout.Annotations[kubernetes.DeployUserAnnotation])
+	assert.Nil(t, kubernetes.GetScheduledChange(apiRollout))
+
+	web, err := k8sClient.GetRollout(ctx, "shop", "web")
+	require.NoError(t, err)
+	assert.NotContains(t, web.Annotations, kubernetes.ForceDeployAnnotation)
+	assert.NotNil(t, kubernetes.GetScheduledChange(web))
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+	"github.com/kuberik/rollout-dashboard/pkg/search"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// defaultSearchResults is how many rollouts a search returns by default
+	defaultSearchResults = 50
+	// maxSearchResults bounds the rollouts a search can return
+	maxSearchResults = 200
+)
+
+// searchRollouts ranks the rollouts the request may see against ?q= by name, namespace, labels,
+// annotations, the image of their releases and the deployed version, so clients do not need to
+// filter the full rollout list
+func searchRollouts(c *gin.Context, k8sClient *kubernetes.Client) {
+	query := strings.TrimSpace(c.Query("q"))
+	limit := defaultSearchResults
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxSearchResults {
+			api.RespondErrorDetails(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid limit",
+				fmt.Sprintf("limit must be a number from 1 to %d", maxSearchResults))
+			return
+		}
+		limit = n
+	}
+
+	namespace := c.DefaultQuery("namespace", "all")
+	rollouts, related, skippedNamespaces, ok := listRequestRolloutsWithRelated(c, k8sClient, namespace, relatedKinds{imagePolicies: true, imageRepositories: true})
+	if !ok {
+		return
+	}
+	images := newRolloutImages(related.imagePolicies, related.imageRepositories)
+
+	documents := make([]search.Document, 0, len(rollouts.Items))
+	for i := range rollouts.Items {
+		rollout := &rollouts.Items[i]
+		document := search.Document{
+			Namespace:   rollout.Namespace,
+			Name:        rollout.Name,
+			Labels:      rollout.Labels,
+			Annotations: rollout.Annotations,
+		}
+		if _, repository := images.lookup(rollout); repository != nil {
+			document.Image = repository.Spec.Image
+		}
+		if len(rollout.Status.History) > 0 {
+			document.Version = rollout.Status.History[0].Version.Tag
+		}
+		documents = append(documents, document)
+	}
+
+	matches := search.Search(query, documents)
+	response := api.SearchResponse{
+		Query:             query,
+		Rollouts:          []api.RolloutSearchResult{},
+		Total:             len(matches),
+		SkippedNamespaces: skippedNamespaces,
+	}
+	for _, match := range matches[:min(limit, len(matches))] {
+		response.Rollouts = append(response.Rollouts, api.RolloutSearchResult{
+			Rollout: api.NewRolloutSummary(&rollouts.Items[match.Index]),
+			Score:   match.Score,
+			Matches: match.Matches,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// searchDeployedVersions lists the rollouts whose deployed version matches ?image= or ?revision=.
+// Like the rollout list, users without cluster-wide list permission and anonymous requests search
+// the namespaces they can access.
+func searchDeployedVersions(c *gin.Context, k8sClient *kubernetes.Client) {
+	image := c.Query("image")
+	revision := c.Query("revision")
+	if image == "" && revision == "" {
+		api.RespondErrorDetails(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid search query", "either q, image or revision query parameter is required")
+		return
+	}
+
+	namespace := c.DefaultQuery("namespace", "all")
+	if namespace == "all" || namespace == "*" {
+		namespace = ""
+	}
+
+	var results []kubernetes.DeploymentSearchResult
+	var skippedNamespaces []kubernetes.SkippedNamespace
+	var err error
+	if restricted := anonymousNamespaces(c); restricted != nil && namespace == "" {
+		results, skippedNamespaces, err = k8sClient.SearchDeployedVersionsInNamespaces(c.Request.Context(), restricted, image, revision)
+	} else {
+		results, err = k8sClient.SearchDeployedVersions(c.Request.Context(), namespace, image, revision)
+		if namespace == "" && apierrors.IsForbidden(err) {
+			var namespaces []string
+			namespaces, skippedNamespaces, err = k8sClient.AccessibleNamespaces(c.Request.Context(), "kuberik.com", "rollouts", namespaceAllowlist())
+			if errors.Is(err, kubernetes.ErrNoAccessibleNamespaces) {
+				api.RespondError(c, http.StatusForbidden, api.CodeForbidden, "Not allowed to search rollouts in all namespaces", err)
+				return
+			}
+			if err == nil {
+				var searchSkipped []kubernetes.SkippedNamespace
+				results, searchSkipped, err = k8sClient.SearchDeployedVersionsInNamespaces(c.Request.Context(), namespaces, image, revision)
+				skippedNamespaces = append(skippedNamespaces, searchSkipped...)
+				sort.Slice(skippedNamespaces, func(i, j int) bool {
+					return skippedNamespaces[i].Namespace < skippedNamespaces[j].Namespace
+				})
+			}
+		}
+	}
+	if err != nil {
+		logging.FromContext(c).Error("Error searching deployed versions", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to search deployed versions", err)
+		return
+	}
+
+	results = filterAnonymous(c, results, func(result kubernetes.DeploymentSearchResult) string {
+		return result.Namespace
+	})
+	c.JSON(http.StatusOK, api.SearchResponse{Results: results, SkippedNamespaces: skippedNamespaces})
+}
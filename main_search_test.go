@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	imagereflectorv1beta2 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	"github.com/fluxcd/pkg/apis/meta"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/search"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestSearchRollouts(t *testing.T) {
+	scheme, err := kubernetes.NewScheme()
+	require.NoError(t, err)
+	kubernetes.UseStaticClient(kubernetes.NewClientFrom(fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&rolloutv1alpha1.Rollout{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "checkout", Labels: map[string]string{"team": "payments"}},
+			Spec:       rolloutv1alpha1.RolloutSpec{ReleasesImagePolicy: corev1.LocalObjectReference{Name: "checkout"}},
+		},
+		&rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "checkout-worker", Labels: map[string]string{"team": "payments"}}},
+		&rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "frontend"}},
+		&imagereflectorv1beta2.ImagePolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "checkout"},
+			Spec:       imagereflectorv1beta2.ImagePolicySpec{ImageRepositoryRef: meta.NamespacedObjectReference{Name: "checkout"}},
+		},
+		&imagereflectorv1beta2.ImageRepository{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "checkout"},
+			Spec:       imagereflectorv1beta2.ImageRepositorySpec{Image: "ghcr.io/example/payments-api"},
+		},
+	).Build(), nil))
+	defer kubernetes.UseStaticClient(nil)
+	r := newRouter(nil, nil, nil, nil, newIntegrations(nil, nil, "", nil, nil, nil), nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	get := func(path string) (int, api.SearchResponse) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var response api.SearchResponse
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		}
+		return w.Code, response
+	}
+	names := func(response api.SearchResponse) []string {
+		var names []string
+		for _, result := range response.Rollouts {
+			names = append(names, result.Rollout.Namespace+"/"+result.Rollout.Name)
+		}
+		return names
+	}
+
+	code, response := get("/api/v1/search?q=checkout")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"shop/checkout", "shop/checkout-worker"}, names(response))
+	assert.Equal(t, 2, response.Total)
+
+	// The image is read from the ImageRepository of the rollout's ImagePolicy
+	code, response = get("/api/v1/search?q=payments-api")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"shop/checkout"}, names(response))
+	assert.Equal(t, []search.Match{{Field: search.FieldImage, Value: "ghcr.io/example/payments-api"}}, response.Rollouts[0].Matches)
+
+	code, response = get("/api/v1/search?q=team=payments&limit=1")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"shop/checkout"}, names(response))
+	assert.Equal(t, 2, response.Total)
+
+	code, _ = get("/api/v1/search?q=checkout&limit=0")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestSearchDeployedVersions_AccessibleNamespaces(t *testing.T) {
+	scheme, err := kubernetes.NewScheme()
+	require.NoError(t, err)
+	deployed := func(namespace string) *rolloutv1alpha1.Rollout {
+		return &rolloutv1alpha1.Rollout{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "api"},
+			Status: rolloutv1alpha1.RolloutStatus{History: []rolloutv1alpha1.DeploymentHistoryEntry{
+				{Version: rolloutv1alpha1.VersionInfo{Tag: "v1.2.3"}},
+			}},
+		}
+	}
+	// Listing across all namespaces and anything in secret is forbidden
+	kubernetes.UseStaticClient(kubernetes.NewClientFrom(fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "secret"}},
+		deployed("shop"), deployed("secret"),
+	).WithInterceptorFuncs(interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			listOpts := (&client.ListOptions{}).ApplyOptions(opts)
+			_, namespaceList := list.(*corev1.NamespaceList)
+			if (!namespaceList && listOpts.Namespace == "") || listOpts.Namespace == "secret" {
+				return apierrors.NewForbidden(schema.GroupResource{Resource: "rollouts"}, "", nil)
+			}
+			return c.List(ctx, list, opts...)
+		},
+	}).Build(), nil))
+	defer kubernetes.UseStaticClient(nil)
+	r := newRouter(nil, nil, nil, nil, newIntegrations(nil, nil, "", nil, nil, nil), nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/search?image=v1.2.3", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response api.SearchResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Results, 1)
+	assert.Equal(t, "shop", response.Results[0].Namespace)
+	assert.Equal(t, []kubernetes.SkippedNamespace{{Namespace: "secret", Reason: "forbidden"}}, response.SkippedNamespaces)
+}
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"time"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2"
+	imagereflectorv1beta2 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	fluxmeta "github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	"github.com/gin-gonic/gin"
+	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/share"
+	kruiserolloutv1beta1 "github.com/openkruise/kruise-rollout-api/rollouts/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// selfTestTimeout bounds each self test request; streams are cut off after their headers
+const selfTestTimeout = 5 * time.Second
+
+// selfTestCase is a request sent by the self test and the status it must be answered with.
+// Route is the registered route it covers, so routes without a case are reported.
+type selfTestCase struct {
+	method string
+	route  string
+	path   string
+	body   string
+	want   int
+	stream bool
+}
+
+// selfTestCases covers every route in apiOperations. Routes that need parts of a real cluster
+// or registry the fake clients cannot provide (access reviews and the OCI registry) must fail
+// cleanly with the given status instead of panicking or hanging.
+func selfTestCases() []selfTestCase {
+	const rollout = "/api/v1/rollouts/demo/app"
+	return []selfTestCase{
+		{method: http.MethodGet, route: "/api/health", want: http.StatusOK},
+		{method: http.MethodPost, route: "/api/webhooks/registry", body: `{"image":"ghcr.io/demo/app","tag":"v1.2.0"}`, want: http.StatusNotFound},
+		{method: http.MethodGet, route: "/api/v1/openapi.json", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/rollouts", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/rollouts", path: "/api/v1/rollouts?view=summary&namespace=demo", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/summary", path: "/api/v1/summary?namespace=demo", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/search", path: "/api/v1/search?q=demo", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name", path: rollout, want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name", path: "/api/v1/rollouts/demo/missing", want: http.StatusNotFound},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name", path: rollout + "?share=invalid", want: http.StatusUnauthorized},
+		{method: http.MethodGet, route: "/api/v2/rollouts", path: "/api/v2/rollouts?namespace=demo", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v2/rollouts", path: "/api/v2/rollouts?view=raw", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v2/rollouts", path: "/api/v2/rollouts?fields=namespace,name", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v2/rollouts", path: "/api/v2/rollouts?fields=name[", want: http.StatusBadRequest},
+		{method: http.MethodGet, route: "/api/v2/rollouts/:namespace/:name", path: "/api/v2/rollouts/demo/app", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v2/rollouts/:namespace/:name", path: "/api/v2/rollouts/demo/app?view=raw", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v2/rollouts/:namespace/:name", path: "/api/v2/rollouts/demo/missing", want: http.StatusNotFound},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/share", path: rollout + "/share", body: `{"ttl":"1h"}`, want: http.StatusInternalServerError},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/environments", path: rollout + "/environments", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/rollout-tests", path: rollout + "/rollout-tests", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/rollout-tests/gates", path: rollout + "/rollout-tests/gates", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/rollout-tests/history", path: rollout + "/rollout-tests/history", want: http.StatusOK},
+		{method: http.MethodPost, route: "/api/v1/rollout-tests/:namespace/:name/rerun", path: "/api/v1/rollout-tests/demo/app-smoke/rerun", want: http.StatusInternalServerError},
+		{method: http.MethodGet, route: "/api/v1/rollout-tests/:namespace/:name/report", path: "/api/v1/rollout-tests/demo/app-smoke/report", want: http.StatusNotFound},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/canary-analysis", path: rollout + "/canary-analysis", want: http.StatusNotFound},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/health-checks", path: rollout + "/health-checks", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/metrics", path: rollout + "/metrics?preset=error-rate", want: http.StatusNotFound},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/alerts", path: rollout + "/alerts", want: http.StatusNotFound},
+		{method: http.MethodGet, route: "/api/v1/health-checks", path: "/api/v1/health-checks?class=kustomization&labelSelector=app%3Dapp", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/health-checks", path: "/api/v1/health-checks?labelSelector=%3D%3D", want: http.StatusBadRequest},
+		{method: http.MethodGet, route: "/api/v1/health-checks/:namespace/:name", path: "/api/v1/health-checks/demo/app", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/health-checks/:namespace/:name", path: "/api/v1/health-checks/demo/missing", want: http.StatusNotFound},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/events", path: rollout + "/events", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/history/reconstructed", path: rollout + "/history/reconstructed", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/flux-events/stream", path: rollout + "/flux-events/stream", want: http.StatusOK, stream: true},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/readiness", path: rollout + "/readiness", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/schedules", path: rollout + "/schedules", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/permissions", path: rollout + "/permissions", want: http.StatusInternalServerError},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/permissions/all", path: rollout + "/permissions/all", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/me", path: "/api/v1/me?namespace=demo", want: http.StatusInternalServerError},
+		{method: http.MethodGet, route: "/api/v1/admin/integrations", path: "/api/v1/admin/integrations", want: http.StatusUnauthorized},
+		{method: http.MethodGet, route: "/api/v1/admin/streams", path: "/api/v1/admin/streams", want: http.StatusUnauthorized},
+		{method: http.MethodPost, route: "/api/v1/admin/integrations/:integration/test", path: "/api/v1/admin/integrations/oidc/test", want: http.StatusUnauthorized},
+		{method: http.MethodPost, route: "/api/v1/admin/integrations/reload", path: "/api/v1/admin/integrations/reload", want: http.StatusUnauthorized},
+		{method: http.MethodGet, route: "/api/v1/environments/:environment/permissions", path: "/api/v1/environments/production/permissions", want: http.StatusInternalServerError},
+		{method: http.MethodPost, route: "/api/v1/environments/:environment/promote", path: "/api/v1/environments/production/promote", body: `{"from":"production"}`, want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/environments/:environment/overrides", path: "/api/v1/environments/production/overrides", want: http.StatusOK},
+		{method: http.MethodPut, route: "/api/v1/environments/:environment/overrides", path: "/api/v1/environments/production/overrides?dryRun=true", body: `{"kind":"EnvironmentOverrides","environment":"production","rollouts":[{"namespace":"demo","name":"app","wantedVersion":"v1.0.0"}]}`, want: http.StatusOK},
+		{method: http.MethodPost, route: "/api/v1/environments/:environment/reconcile", path: "/api/v1/environments/production/reconcile?timeout=1s", want: http.StatusOK, stream: true},
+		{method: http.MethodPost, route: "/api/v1/environments/:environment/reconcile", path: "/api/v1/environments/staging/reconcile", want: http.StatusNotFound},
+		{method: http.MethodGet, route: "/api/v1/applications/:name/environments", path: "/api/v1/applications/app/environments", want: http.StatusOK},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/pin", path: rollout + "/pin", body: `{"version":"v1.2.0"}`, want: http.StatusOK},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/force-deploy", path: rollout + "/force-deploy", body: `{"version":"v1.2.0"}`, want: http.StatusOK},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/bypass-gates", path: rollout + "/bypass-gates", body: `{"version":"v1.2.0"}`, want: http.StatusOK},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/change-version", path: rollout + "/change-version", body: `{"version":"v1.2.0"}`, want: http.StatusOK},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/change-version", path: rollout + "/change-version", body: `{"version":"v1.2.0","scheduleAt":"2099-01-01T18:00:00Z"}`, want: http.StatusInternalServerError},
+		{method: http.MethodGet, route: "/api/v1/scheduled-changes", path: "/api/v1/scheduled-changes", want: http.StatusOK},
+		{method: http.MethodDelete, route: "/api/v1/rollouts/:namespace/:name/scheduled-change", path: rollout + "/scheduled-change", want: http.StatusInternalServerError},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/unblock-failed", path: rollout + "/unblock-failed", want: http.StatusOK},
+		{method: http.MethodDelete, route: "/api/v1/rollouts/:namespace/:name", path: rollout + "?cascade=true&dryRun=true", want: http.StatusInternalServerError},
+		{method: http.MethodDelete, route: "/api/v1/rollouts/:namespace/:name", path: "/api/v1/rollouts/demo/missing", want: http.StatusNotFound},
+		{method: http.MethodPatch, route: "/api/v1/rollouts/:namespace/:name/spec", path: rollout + "/spec?dryRun=true", body: `{"bakeTime":"2h","releasesImagePolicy":{"name":"app"}}`, want: http.StatusOK},
+		{method: http.MethodPatch, route: "/api/v1/rollouts/:namespace/:name/spec", path: rollout + "/spec", body: `{"wantedVersion":"v1.2.0"}`, want: http.StatusBadRequest},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/bake", path: rollout + "/bake", want: http.StatusOK},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/extend-bake", path: rollout + "/extend-bake", body: `{"duration":"30m","reason":"watch error rate"}`, want: http.StatusConflict},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/extend-bake", path: rollout + "/extend-bake", body: `{"duration":"-1h"}`, want: http.StatusBadRequest},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/mark-successful", path: rollout + "/mark-successful", body: `{}`, want: http.StatusOK},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/reconcile", path: rollout + "/reconcile", want: http.StatusOK},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/reconcile", path: rollout + "/reconcile?withSource=true", want: http.StatusOK},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/helmreleases/:helmrelease/reconcile", path: rollout + "/helmreleases/app-chart/reconcile", want: http.StatusOK},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/helmreleases/:helmrelease/reconcile", path: rollout + "/helmreleases/missing/reconcile", want: http.StatusNotFound},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/helmreleases/:helmrelease/suspend", path: rollout + "/helmreleases/app-chart/suspend", want: http.StatusInternalServerError},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/helmreleases/:helmrelease/resume", path: rollout + "/helmreleases/app-chart/resume", want: http.StatusInternalServerError},
+		{method: http.MethodPost, route: "/api/v1/kustomizations/:namespace/:name/suspend", path: "/api/v1/kustomizations/demo/app/suspend", want: http.StatusInternalServerError},
+		{method: http.MethodPost, route: "/api/v1/kustomizations/:namespace/:name/resume", path: "/api/v1/kustomizations/demo/app/resume", want: http.StatusInternalServerError},
+		{method: http.MethodPost, route: "/api/v1/ocirepositories/:namespace/:name/suspend", path: "/api/v1/ocirepositories/demo/app/suspend", want: http.StatusInternalServerError},
+		{method: http.MethodPost, route: "/api/v1/ocirepositories/:namespace/:name/resume", path: "/api/v1/ocirepositories/demo/app/resume", want: http.StatusInternalServerError},
+		{method: http.MethodPost, route: "/api/v1/imagerepositories/:namespace/:name/suspend", path: "/api/v1/imagerepositories/demo/app/suspend", want: http.StatusInternalServerError},
+		{method: http.MethodPost, route: "/api/v1/imagerepositories/:namespace/:name/resume", path: "/api/v1/imagerepositories/demo/app/resume", want: http.StatusInternalServerError},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/bluegreen", path: rollout + "/bluegreen", want: http.StatusOK},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/bluegreen/switch-traffic", path: rollout + "/bluegreen/switch-traffic", want: http.StatusOK},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/continue", path: rollout + "/continue", body: `{}`, want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/progressive", path: rollout + "/progressive?pods=true", want: http.StatusOK},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/progressive/pause", path: rollout + "/progressive/pause", want: http.StatusOK},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/progressive/continue", path: rollout + "/progressive/continue", want: http.StatusOK},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/progressive/abort", path: rollout + "/progressive/abort", want: http.StatusConflict},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/progressive/step", path: rollout + "/progressive/step", body: `{"step":2}`, want: http.StatusOK},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/progressive/step", path: rollout + "/progressive/step", body: `{"step":9}`, want: http.StatusBadRequest},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/gates/explain", path: rollout + "/gates/explain", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/pending-versions", path: rollout + "/pending-versions", want: http.StatusOK},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/gates", path: rollout + "/gates", body: `{"name":"Holiday_Freeze"}`, want: http.StatusBadRequest},
+		{method: http.MethodPut, route: "/api/v1/rollouts/:namespace/:name/gates/:gate", path: rollout + "/gates/app-gate", body: `{"passing":false}`, want: http.StatusInternalServerError},
+		{method: http.MethodDelete, route: "/api/v1/rollouts/:namespace/:name/gates/:gate", path: rollout + "/gates/app-gate", want: http.StatusInternalServerError},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/retry", path: rollout + "/retry", body: `{}`, want: http.StatusOK},
+		{method: http.MethodPost, route: "/api/v1/rollouts/batch", body: `{"items":[{"namespace":"demo","name":"app","action":"unblock-failed"},{"namespace":"demo","name":"app","action":"retry","params":{"testAction":"skip"}}]}`, want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/manifest/:version", path: rollout + "/manifest/v1.1.0", want: http.StatusInternalServerError},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/manifest/:version", path: rollout + "/manifest/v1.1.0?component=missing", want: http.StatusNotFound},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/components", path: rollout + "/components?version=v1.1.0", want: http.StatusInternalServerError},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/components/diff", path: rollout + "/components/diff?from=v1.0.0&to=v1.1.0", want: http.StatusInternalServerError},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/changelog", path: rollout + "/changelog?from=v1.0.0&to=v1.1.0", want: http.StatusInternalServerError},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/release-notes", path: rollout + "/release-notes?from=v1.0.0&to=v1.1.0", want: http.StatusInternalServerError},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/commit/:version", path: rollout + "/commit/v1.0.0", want: http.StatusNotFound},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/validate/:version", path: rollout + "/validate/v1.1.0", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/mediatype/:version", path: rollout + "/mediatype/v1.1.0", want: http.StatusInternalServerError},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/annotations/:version", path: rollout + "/annotations/v1.1.0", want: http.StatusInternalServerError},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/channels", path: rollout + "/channels", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/channels/:channel", path: rollout + "/channels/stable", want: http.StatusNotFound},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/channel", path: rollout + "/channel", body: `{"channel":null}`, want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/tags", path: rollout + "/tags", want: http.StatusInternalServerError},
+		{method: http.MethodGet, route: "/api/v1/kustomizations/:namespace/:name/managed-resources", path: "/api/v1/kustomizations/demo/app/managed-resources", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/resources/:namespace/:kind/:name/yaml", path: "/api/v1/resources/demo/Deployment/app/yaml?group=apps", want: http.StatusInternalServerError},
+		{method: http.MethodGet, route: "/api/v1/resources/:namespace/:kind/:name/yaml", path: "/api/v1/resources/demo/ConfigMap/app/yaml", want: http.StatusNotFound},
+		{method: http.MethodGet, route: "/api/v1/resources/:namespace/:kind/:name/describe", path: "/api/v1/resources/demo/ConfigMap/app/describe", want: http.StatusNotFound},
+		{method: http.MethodGet, route: "/api/v1/kustomizations/:namespace/:name/test", path: "/api/v1/kustomizations/demo/app/test", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/namespaces/:namespace/deployments/:name/children", path: "/api/v1/namespaces/demo/deployments/app/children", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/schedules", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/search", path: "/api/v1/search?image=demo/app", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/pods", path: rollout + "/pods", want: http.StatusOK},
+		{method: http.MethodPost, route: "/api/v1/pods/:namespace/:name/restart", path: "/api/v1/pods/demo/app-abc12/restart", want: http.StatusUnauthorized},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/pods/logs/download", path: rollout + "/pods/logs/download", want: http.StatusOK},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/exec", path: rollout + "/exec?pod=app-abc12", want: http.StatusUnauthorized},
+		{method: http.MethodGet, route: "/api/v1/rollouts/:namespace/:name/pods/logs", path: rollout + "/pods/logs", want: http.StatusOK, stream: true},
+		{method: http.MethodPost, route: "/api/v1/rollouts/:namespace/:name/pods/logs/control", path: rollout + "/pods/logs/control", body: `{"stream":"unknown","mute":["app-5d9f-x"]}`, want: http.StatusNotFound},
+	}
+}
+
+// selfTestObjects is the canned cluster state: a rollout "demo/app" with its image policy,
+// Flux resources, gate, environment, blue/green Kruise rollout and a running deployment
+func selfTestObjects() []client.Object {
+	deployed := metav1.NewTime(time.Now().Add(-time.Hour))
+	labels := map[string]string{"app": "app"}
+	podLabels := map[string]string{"app": "app", "pod-template-hash": "5d9f"}
+	return []client.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "demo"}},
+		&rolloutv1alpha1.Rollout{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "app"},
+			Spec:       rolloutv1alpha1.RolloutSpec{ReleasesImagePolicy: corev1.LocalObjectReference{Name: "app"}},
+			Status: rolloutv1alpha1.RolloutStatus{
+				History: []rolloutv1alpha1.DeploymentHistoryEntry{{
+					ID:         ptr.To[int64](1),
+					Version:    rolloutv1alpha1.VersionInfo{Tag: "v1.1.0"},
+					Timestamp:  deployed,
+					BakeStatus: ptr.To(rolloutv1alpha1.BakeStatusSucceeded),
+				}},
+				AvailableReleases: []rolloutv1alpha1.VersionInfo{{Tag: "v1.0.0"}, {Tag: "v1.1.0"}, {Tag: "v1.2.0"}},
+				ReleaseCandidates: []rolloutv1alpha1.VersionInfo{{Tag: "v1.2.0"}},
+				Gates:             []rolloutv1alpha1.RolloutGateStatusSummary{{Name: "app-gate", Passing: ptr.To(true)}},
+			},
+		},
+		&imagereflectorv1beta2.ImagePolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "app"},
+			Spec:       imagereflectorv1beta2.ImagePolicySpec{ImageRepositoryRef: fluxmeta.NamespacedObjectReference{Name: "app"}},
+		},
+		&imagereflectorv1beta2.ImageRepository{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "app"},
+			// A closed local port lets registry calls fail fast instead of reaching the network
+			Spec: imagereflectorv1beta2.ImageRepositorySpec{Image: "127.0.0.1:1/demo/app"},
+			Status: imagereflectorv1beta2.ImageRepositoryStatus{
+				LastScanResult: &imagereflectorv1beta2.ScanResult{ScanTime: deployed},
+			},
+		},
+		&kustomizev1.Kustomization{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "demo",
+				Name:        "app",
+				Annotations: map[string]string{"rollout.kuberik.com/substitute.APP_VERSION.from": "app"},
+			},
+			Status: kustomizev1.KustomizationStatus{
+				Inventory: &kustomizev1.ResourceInventory{Entries: []kustomizev1.ResourceRef{{ID: "demo_app_apps_Deployment", Version: "v1"}}},
+			},
+		},
+		&sourcev1.OCIRepository{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "demo",
+				Name:        "app",
+				Annotations: map[string]string{"rollout.kuberik.com/rollout": "app"},
+			},
+		},
+		&helmv2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "app-chart"},
+			Spec:       helmv2.HelmReleaseSpec{ChartRef: &helmv2.CrossNamespaceSourceReference{Kind: sourcev1.OCIRepositoryKind, Name: "app"}},
+		},
+		&rolloutv1alpha1.HealthCheck{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "app", Labels: labels},
+			Spec:       rolloutv1alpha1.HealthCheckSpec{Class: ptr.To("kustomization")},
+			Status: rolloutv1alpha1.HealthCheckStatus{
+				Status:         rolloutv1alpha1.HealthStatusHealthy,
+				LastChangeTime: &deployed,
+			},
+		},
+		&rolloutv1alpha1.RolloutGate{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "app-gate"},
+			Spec:       rolloutv1alpha1.RolloutGateSpec{RolloutRef: &corev1.LocalObjectReference{Name: "app"}, Passing: ptr.To(true)},
+		},
+		&kruiserolloutv1beta1.Rollout{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "app"},
+			Spec: kruiserolloutv1beta1.RolloutSpec{
+				WorkloadRef: kruiserolloutv1beta1.ObjectRef{APIVersion: "apps/v1", Kind: "Deployment", Name: "app"},
+				Strategy: kruiserolloutv1beta1.RolloutStrategy{
+					BlueGreen: &kruiserolloutv1beta1.BlueGreenStrategy{
+						Steps: []kruiserolloutv1beta1.CanaryStep{
+							{TrafficRoutingStrategy: kruiserolloutv1beta1.TrafficRoutingStrategy{Traffic: ptr.To("0%")}},
+							{TrafficRoutingStrategy: kruiserolloutv1beta1.TrafficRoutingStrategy{Traffic: ptr.To("100%")}},
+						},
+						TrafficRoutings: []kruiserolloutv1beta1.TrafficRoutingRef{{Service: "app"}},
+					},
+				},
+			},
+			// Paused before the traffic switch
+			Status: kruiserolloutv1beta1.RolloutStatus{
+				BlueGreenStatus: &kruiserolloutv1beta1.BlueGreenStatus{
+					CommonStatus: kruiserolloutv1beta1.CommonStatus{
+						CurrentStepIndex: 1,
+						CurrentStepState: kruiserolloutv1beta1.CanaryStepStatePaused,
+					},
+				},
+			},
+		},
+		&envv1alpha1.Environment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "app-production"},
+			Spec: envv1alpha1.EnvironmentSpec{
+				Name:        "app",
+				Environment: "production",
+				RolloutRef:  corev1.LocalObjectReference{Name: "app"},
+			},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "app", UID: "deployment-uid", Labels: labels},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "127.0.0.1:1/demo/app:v1.1.0"}}},
+				},
+			},
+		},
+		&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "demo", Name: "app-5d9f", UID: "replicaset-uid", Labels: podLabels,
+				OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "app", UID: "deployment-uid", Controller: ptr.To(true)}},
+			},
+			Spec: appsv1.ReplicaSetSpec{Selector: &metav1.LabelSelector{MatchLabels: labels}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "demo", Name: "app-abc12", Labels: podLabels,
+				OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "app-5d9f", UID: "replicaset-uid", Controller: ptr.To(true)}},
+			},
+			Spec:   corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "127.0.0.1:1/demo/app:v1.1.0"}}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+	}
+}
+
+// runSelfTest serves the API from a fake cluster with canned data, sends a request to every
+// route and reports the results to w. It returns the process exit code: 0 when every route
+// answered as expected.
+func runSelfTest(w io.Writer) int {
+	scheme, err := kubernetes.NewScheme()
+	if err != nil {
+		fmt.Fprintf(w, "FAIL setup: %v\n", err)
+		return 1
+	}
+	objects := selfTestObjects()
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objects...).
+		WithStatusSubresource(&rolloutv1alpha1.Rollout{}, &kruiserolloutv1beta1.Rollout{}).
+		Build()
+	// Core objects are served by the clientset too, for events, logs and direct API reads
+	var coreObjects []runtime.Object
+	for _, obj := range objects {
+		switch obj.(type) {
+		case *corev1.Namespace, *corev1.Pod, *appsv1.Deployment, *appsv1.ReplicaSet:
+			coreObjects = append(coreObjects, obj)
+		}
+	}
+	fakeClientset := k8sfake.NewClientset(coreObjects...)
+	kubernetes.UseStaticClient(kubernetes.NewClientFrom(fakeClient, fakeClientset))
+	defer kubernetes.UseStaticClient(nil)
+
+	// Every request comes from the same client, so the mutation rate limit must not interfere
+	os.Setenv("RATE_LIMIT_MUTATIONS_PER_MINUTE", "0")
+	gin.SetMode(gin.ReleaseMode)
+	shares := share.NewSigner(share.Config{Secret: []byte("selftest-share-link-secret-000000"), MaxTTL: share.DefaultTTL})
+	srv := httptest.NewServer(newRouter(nil, shares, nil, nil, newIntegrations(nil, nil, "", nil, nil, nil), nil, nil, nil, nil, nil, nil, nil, nil, nil))
+	defer srv.Close()
+
+	failures := 0
+	covered := map[string]bool{}
+	for _, tc := range selfTestCases() {
+		covered[tc.method+" "+tc.route] = true
+		path := tc.path
+		if path == "" {
+			path = tc.route
+		}
+		status, err := selfTestRequest(srv.URL, tc.method, path, tc.body, tc.stream)
+		switch {
+		case err != nil:
+			failures++
+			fmt.Fprintf(w, "FAIL %s %s: %v\n", tc.method, path, err)
+		case status != tc.want:
+			failures++
+			fmt.Fprintf(w, "FAIL %s %s: status %d, want %d\n", tc.method, path, status, tc.want)
+		default:
+			fmt.Fprintf(w, "ok   %s %s %d\n", tc.method, path, status)
+		}
+	}
+	for _, op := range apiOperations {
+		if !covered[op.Method+" "+op.Path] {
+			failures++
+			fmt.Fprintf(w, "FAIL %s %s: route is not covered by the self test\n", op.Method, op.Path)
+		}
+	}
+
+	if failures > 0 {
+		fmt.Fprintf(w, "self test failed: %d failures\n", failures)
+		return 1
+	}
+	fmt.Fprintln(w, "self test passed")
+	return 0
+}
+
+// selfTestRequest sends one request and returns the response status. Streams are closed as soon
+// as their headers arrive.
+func selfTestRequest(baseURL, method, path, body string, stream bool) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), selfTestTimeout)
+	defer cancel()
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, baseURL+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if !stream {
+		if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+			return 0, fmt.Errorf("failed to read response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
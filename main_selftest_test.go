@@ -0,0 +1,14 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunSelfTest(t *testing.T) {
+	var out bytes.Buffer
+	assert.Equal(t, 0, runSelfTest(&out), out.String())
+	assert.Contains(t, out.String(), "self test passed")
+}
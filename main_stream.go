@@ -0,0 +1,119 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+)
+
+// streamRolloutList writes a rollout list as newline-delimited JSON: the extra column definitions
+// and the rollouts first, so clients can render them before the associated Flux resources arrive,
+// and the skipped namespaces last
+func streamRolloutList(c *gin.Context, response api.RolloutListResponse) {
+	stream := api.NewNDJSONWriter(c)
+	write := func(kind string, object any) bool {
+		if err := stream.Write(kind, object); err != nil {
+			logging.FromContext(c).Debug("Stopped streaming rollouts", "error", err)
+			return false
+		}
+		return true
+	}
+
+	for _, column := range response.Columns {
+		if !write(api.StreamKindColumn, column) {
+			return
+		}
+	}
+	if response.Rollouts != nil {
+		for i := range response.Rollouts.Items {
+			if !write(api.StreamKindRollout, &response.Rollouts.Items[i]) {
+				return
+			}
+		}
+	}
+	if response.ImagePolicies != nil {
+		for i := range response.ImagePolicies.Items {
+			if !write(api.StreamKindImagePolicy, &response.ImagePolicies.Items[i]) {
+				return
+			}
+		}
+	}
+	if response.ImageRepositories != nil {
+		for i := range response.ImageRepositories.Items {
+			if !write(api.StreamKindImageRepository, &response.ImageRepositories.Items[i]) {
+				return
+			}
+		}
+	}
+	if response.Kustomizations != nil {
+		for i := range response.Kustomizations.Items {
+			if !write(api.StreamKindKustomization, &response.Kustomizations.Items[i]) {
+				return
+			}
+		}
+	}
+	if response.OCIRepositories != nil {
+		for i := range response.OCIRepositories.Items {
+			if !write(api.StreamKindOCIRepository, &response.OCIRepositories.Items[i]) {
+				return
+			}
+		}
+	}
+	if !writeColumnValues(write, response.ColumnValues) {
+		return
+	}
+	for _, skipped := range response.SkippedNamespaces {
+		if !write(api.StreamKindSkippedNamespace, skipped) {
+			return
+		}
+	}
+}
+
+// streamRolloutSummaries writes the summary view of a rollout list as newline-delimited JSON
+func streamRolloutSummaries(c *gin.Context, response api.RolloutSummaryListResponse) {
+	stream := api.NewNDJSONWriter(c)
+	write := func(kind string, object any) bool {
+		if err := stream.Write(kind, object); err != nil {
+			logging.FromContext(c).Debug("Stopped streaming rollouts", "error", err)
+			return false
+		}
+		return true
+	}
+	for _, column := range response.Columns {
+		if !write(api.StreamKindColumn, column) {
+			return
+		}
+	}
+	for _, summary := range response.Rollouts {
+		if !write(api.StreamKindRolloutSummary, summary) {
+			return
+		}
+	}
+	if !writeColumnValues(write, response.ColumnValues) {
+		return
+	}
+	for _, skipped := range response.SkippedNamespaces {
+		if !write(api.StreamKindSkippedNamespace, skipped) {
+			return
+		}
+	}
+}
+
+// writeColumnValues writes the extra column values of each rollout, ordered by namespace/name
+func writeColumnValues(write func(kind string, object any) bool, values map[string]map[string]string) bool {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		namespace, name, _ := strings.Cut(key, "/")
+		if !write(api.StreamKindColumnValues, api.RolloutColumnValues{Namespace: namespace, Name: name, Values: values[key]}) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestStreamRollouts(t *testing.T) {
+	scheme, err := kubernetes.NewScheme()
+	require.NoError(t, err)
+	kubernetes.UseStaticClient(kubernetes.NewClientFrom(fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api"}},
+		&rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web"}},
+	).Build(), nil))
+	defer kubernetes.UseStaticClient(nil)
+	r := newRouter(nil, nil, nil, nil, newIntegrations(nil, nil, "", nil, nil, nil), nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	stream := func(path string) map[string][]string {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", api.NDJSONContentType)
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, api.NDJSONContentType, w.Header().Get("Content-Type"))
+
+		names := map[string][]string{}
+		scanner := bufio.NewScanner(w.Body)
+		for scanner.Scan() {
+			var entry struct {
+				Kind   string `json:"kind"`
+				Object struct {
+					Name     string `json:"name"`
+					Metadata struct {
+						Name string `json:"name"`
+					} `json:"metadata"`
+				} `json:"object"`
+			}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+			names[entry.Kind] = append(names[entry.Kind], entry.Object.Name+entry.Object.Metadata.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, map[string][]string{api.StreamKindRollout: {"api", "web"}}, stream("/api/v1/rollouts"))
+	assert.Equal(t, map[string][]string{api.StreamKindRolloutSummary: {"api", "web"}}, stream("/api/v1/rollouts?view=summary&namespace=shop"))
+}
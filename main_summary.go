@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+)
+
+const (
+	// defaultRecentFailures is how many recently failed rollouts the fleet summary lists by default
+	defaultRecentFailures = 10
+	// maxRecentFailures bounds the failed rollouts a fleet summary can list
+	maxRecentFailures = 100
+)
+
+// getFleetSummary counts the rollouts the request may see by health and lists the most recently
+// failed ones, so the landing page does not need the full rollout list
+func getFleetSummary(c *gin.Context) {
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return
+	}
+
+	recentFailures := defaultRecentFailures
+	if value := c.Query("failed"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 || n > maxRecentFailures {
+			api.RespondErrorDetails(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid failed",
+				fmt.Sprintf("failed must be a number from 0 to %d", maxRecentFailures))
+			return
+		}
+		recentFailures = n
+	}
+
+	rollouts, _, skippedNamespaces, ok := listRequestRollouts(c, k8sClient, c.DefaultQuery("namespace", "all"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, api.FleetSummaryResponse{
+		FleetSummary:      api.NewFleetSummary(rollouts.Items, recentFailures),
+		SkippedNamespaces: skippedNamespaces,
+	})
+}
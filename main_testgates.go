@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	openkruisev1alpha1 "github.com/kuberik/openkruise-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/freeze"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// getRolloutTestGates shows which step each RolloutTest of a Kruise rollout is bound to, whether
+// it has run for the current canary revision and whether it holds the current step, so a canary
+// stuck at a step can be explained from one response
+func getRolloutTestGates(c *gin.Context) {
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return
+	}
+	namespace, name := c.Param("namespace"), c.Param("name")
+
+	kruiseRollout, err := k8sClient.GetKruiseRollout(c.Request.Context(), namespace, name)
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching kruise rollout", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch kruise rollout", err)
+		return
+	}
+	rolloutTests, err := k8sClient.GetRolloutTestsByRolloutName(c.Request.Context(), namespace, name)
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching rollout tests", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout tests", err)
+		return
+	}
+	c.JSON(http.StatusOK, api.TestGatesResponse{TestGates: kubernetes.EvaluateTestGates(kruiseRollout, rolloutTests.Items)})
+}
+
+// rerunRolloutTest returns the handler running a RolloutTest again, e.g. after a flaky failure,
+// by deleting its Job and resetting it so the openkruise-controller creates a new one. The caller
+// must be allowed to delete Jobs and to patch the test, which keeps the replaced run in its
+// history, and its status. A rerun can let the Kruise rollout continue, so it is rejected while a
+// freeze window covers the rollout of the same name. Reruns are audited.
+func rerunRolloutTest(freezes *freeze.Policy, requestUser func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k8sClient, ok := getK8sClient(c)
+		if !ok {
+			return
+		}
+		namespace, name := c.Param("namespace"), c.Param("name")
+
+		if freezes != nil {
+			test, err := k8sClient.GetRolloutTest(c.Request.Context(), namespace, name)
+			switch {
+			case apierrors.IsNotFound(err):
+				api.RespondError(c, http.StatusNotFound, api.CodeNotFound, "Rollout test not found", err)
+				return
+			case err != nil:
+				logging.FromContext(c).Error("Error fetching rollout test", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout test", err)
+				return
+			}
+			if !checkRolloutUnfrozen(c, freezes, requestUser(c), namespace, test.Spec.RolloutName) {
+				return
+			}
+		}
+
+		for _, permission := range []struct{ group, resource, verb, name string }{
+			{"batch", "jobs", "delete", ""},
+			{openkruisev1alpha1.GroupVersion.Group, "rollouttests", "patch", name},
+			{openkruisev1alpha1.GroupVersion.Group, "rollouttests/status", "update", name},
+		} {
+			allowed, err := k8sClient.CheckPermission(c.Request.Context(), permission.group, permission.resource, permission.verb, namespace, permission.name)
+			if err != nil {
+				logging.FromContext(c).Error("Error checking permission", "error", err)
+				api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to check permission", err)
+				return
+			}
+			if !allowed {
+				api.RespondErrorDetails(c, http.StatusForbidden, api.CodeForbidden, "Not allowed to rerun rollout test",
+					permission.verb+" "+permission.resource+" in "+namespace+" is not permitted")
+				return
+			}
+		}
+
+		rolloutTest, deletedJobs, err := k8sClient.RerunRolloutTest(c.Request.Context(), namespace, name)
+		switch {
+		case errors.Is(err, kubernetes.ErrTestNotRun), errors.Is(err, kubernetes.ErrRolloutStalled):
+			api.RespondError(c, http.StatusConflict, api.CodeConflict, "Rollout test cannot be rerun", err)
+			return
+		case err != nil:
+			logging.FromContext(c).Error("Error rerunning rollout test", "error", err)
+			api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to rerun rollout test", err)
+			return
+		}
+		logging.FromContext(c).Info("Reran rollout test", "audit", true, "user", requestUser(c),
+			"namespace", namespace, "rolloutTest", name, "deletedJobs", deletedJobs)
+		c.JSON(http.StatusOK, api.RolloutTestRerunResponse{RolloutTest: rolloutTest, DeletedJobs: deletedJobs})
+	}
+}
+
+// getRolloutTestReport returns the JUnit report of a RolloutTest's current Job, or of the Job
+// named by ?job=, so the failed test case is shown instead of the pod logs
+func getRolloutTestReport(c *gin.Context) {
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return
+	}
+	report, err := k8sClient.GetRolloutTestReport(c.Request.Context(), c.Param("namespace"), c.Param("name"), c.Query("job"))
+	switch {
+	case errors.Is(err, kubernetes.ErrNoTestReport):
+		api.RespondError(c, http.StatusNotFound, api.CodeNotFound, "Test report not found", err)
+		return
+	case err != nil:
+		logging.FromContext(c).Error("Error fetching test report", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch test report", err)
+		return
+	}
+	c.JSON(http.StatusOK, api.TestReportResponse{TestReport: *report})
+}
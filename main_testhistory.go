@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+)
+
+// defaultTestHistoryInterval is how often finished RolloutTest runs are recorded. It must be
+// shorter than a canary step, after which the openkruise-controller deletes the run's Job.
+const defaultTestHistoryInterval = time.Minute
+
+// recordTestHistory periodically records the finished runs of all RolloutTests in their
+// rollout.kuberik.com/test-history annotation, see kubernetes.RecordTestRuns
+func recordTestHistory(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			k8sClient, err := kubernetes.GetDefaultClient()
+			if err != nil {
+				slog.Warn("Failed to record rollout test runs", "error", err)
+				continue
+			}
+			recorded, err := k8sClient.RecordTestRuns(ctx)
+			if err != nil {
+				slog.Warn("Failed to record rollout test runs", "error", err)
+			}
+			if recorded > 0 {
+				slog.Info("Recorded rollout test runs", "runs", recorded)
+			}
+		}
+	}
+}
+
+// getRolloutTestHistory lists the runs of each RolloutTest of a Kruise rollout across versions,
+// so a test failing on every recent version can be told apart from a flaky one
+func getRolloutTestHistory(c *gin.Context) {
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return
+	}
+	history, err := k8sClient.GetRolloutTestHistory(c.Request.Context(), c.Param("namespace"), c.Param("name"))
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching rollout test history", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout test history", err)
+		return
+	}
+	c.JSON(http.StatusOK, api.TestHistoryResponse{Tests: history})
+}
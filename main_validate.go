@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+	"github.com/kuberik/rollout-dashboard/pkg/oci"
+)
+
+// validateRolloutVersion server-side dry-run applies the manifests every Kustomization of the
+// rollout would apply with a candidate version, before it is promoted. Kustomizations sourcing
+// the rollout's OCIRepository are built from the release artifact of the version, the others
+// from their current source with the version substituted for the variables they take from the
+// rollout.
+func validateRolloutVersion(c *gin.Context) {
+	k8sClient, ok := getK8sClient(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	namespace, name, version := c.Param("namespace"), c.Param("name"), c.Param("version")
+
+	rollout, err := k8sClient.GetRollout(ctx, namespace, name)
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching rollout", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch rollout", err)
+		return
+	}
+	kustomizations, err := k8sClient.GetKustomizationsByRolloutAnnotation(ctx, namespace, name)
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching kustomizations", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch kustomizations", err)
+		return
+	}
+	if len(kustomizations.Items) == 0 {
+		api.RespondErrorDetails(c, http.StatusNotFound, api.CodeNotFound, "No Kustomizations found",
+			"no Kustomization sources the rollout's OCIRepository or substitutes its version")
+		return
+	}
+	ociRepositories, err := k8sClient.GetOCIRepositoriesByRolloutAnnotation(ctx, namespace, name)
+	if err != nil {
+		logging.FromContext(c).Error("Error fetching OCI repositories", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to fetch OCI repositories", err)
+		return
+	}
+	releaseSources := map[string]bool{}
+	for _, repository := range ociRepositories.Items {
+		releaseSources[repository.Name] = true
+	}
+
+	response := api.ManifestValidationResponse{Version: version, Valid: true, Kustomizations: []kubernetes.KustomizationValidation{}}
+	var artifact map[string][]byte
+	for i := range kustomizations.Items {
+		kustomization := &kustomizations.Items[i]
+		opts := []kubernetes.ValidateOption{kubernetes.WithSubstitutions(kubernetes.RolloutSubstitutions(kustomization, name, version))}
+		if kustomization.Spec.SourceRef.Kind == "OCIRepository" && releaseSources[kustomization.Spec.SourceRef.Name] {
+			if artifact == nil {
+				image, registryOpts, err := rolloutRegistry(ctx, k8sClient, rollout)
+				if err != nil {
+					api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to get image repository", err)
+					return
+				}
+				files, err := oci.GetImageContents(ctx, image, version, registryOpts...)
+				if err != nil {
+					logging.FromContext(c).Error("Error fetching image contents", "error", err)
+					api.RespondError(c, http.StatusInternalServerError, api.CodeRegistry, "Failed to fetch image contents", err)
+					return
+				}
+				artifact = map[string][]byte{}
+				for _, file := range files {
+					artifact[file.Name] = file.Content
+				}
+			}
+			opts = append(opts, kubernetes.WithArtifactFiles(artifact))
+		}
+
+		validation := k8sClient.ValidateKustomization(ctx, kustomization, opts...)
+		response.Valid = response.Valid && validation.Valid()
+		response.Kustomizations = append(response.Kustomizations, validation)
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/freeze"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/logging"
+	"github.com/kuberik/rollout-dashboard/pkg/registryhook"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// maxWebhookBodySize bounds the payloads registries and CI may send
+const maxWebhookBodySize = 1 << 20
+
+// receiveRegistryWebhook reconciles the rollouts of the images a registry or CI announces a push
+// of. The caller has no Kubernetes credentials, so the request is authenticated by its signature
+// and the rollouts are reconciled with the service account. Rollouts frozen by freezes are not
+// reconciled; the webhook cannot override a freeze.
+func receiveRegistryWebhook(c *gin.Context, hook *registryhook.Verifier, freezes *freeze.Policy) {
+	if hook == nil {
+		api.RespondErrorDetails(c, http.StatusNotFound, api.CodeNotFound, "Registry webhook is not enabled", "set REGISTRY_WEBHOOK_SECRET to enable the registry webhook")
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBodySize))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		api.RespondError(c, http.StatusRequestEntityTooLarge, api.CodeBadRequest, "Request body too large", err)
+		return
+	}
+	if err != nil {
+		api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Failed to read request body", err)
+		return
+	}
+	if err := hook.Verify(body, c.GetHeader(registryhook.SignatureHeader)); err != nil {
+		logging.FromContext(c).Warn("Rejected registry webhook", "error", err)
+		api.RespondError(c, http.StatusUnauthorized, api.CodeUnauthorized, "Invalid signature", err)
+		return
+	}
+	pushes, err := registryhook.ParsePushes(body)
+	if err != nil {
+		api.RespondError(c, http.StatusBadRequest, api.CodeBadRequest, "Invalid request body", err)
+		return
+	}
+
+	k8sClient, err := kubernetes.GetDefaultClient()
+	if err != nil {
+		logging.FromContext(c).Error("Failed to get Kubernetes client", "error", err)
+		api.RespondError(c, http.StatusInternalServerError, api.CodeClientInit, "Failed to initialize Kubernetes client", err)
+		return
+	}
+	var rollouts []types.NamespacedName
+	for _, push := range pushes {
+		matched, err := k8sClient.GetRolloutsByImage(c.Request.Context(), func(image string) bool {
+			return registryhook.SameRepository(image, push.Image)
+		})
+		if err != nil {
+			logging.FromContext(c).Error("Error finding rollouts of pushed image", "image", push.Image, "error", err)
+			api.RespondError(c, http.StatusInternalServerError, api.CodeKubernetesAPI, "Failed to find rollouts of pushed image", err)
+			return
+		}
+		for _, rollout := range matched {
+			if !slices.Contains(rollouts, rollout) {
+				rollouts = append(rollouts, rollout)
+			}
+		}
+	}
+
+	frozen := policyFreezeCheck(k8sClient, freezes, time.Now())
+	response := api.RegistryWebhookResponse{Pushes: pushes, Rollouts: []api.RegistryWebhookResult{}}
+	for _, rollout := range rollouts {
+		result := api.RegistryWebhookResult{Namespace: rollout.Namespace, Name: rollout.Name}
+		err := frozen(c.Request.Context(), rollout.Namespace, rollout.Name)
+		if err == nil {
+			// The pushed image is only found by scanning the rollout's ImageRepository
+			_, result.Results, err = k8sClient.ReconcileAllFluxResources(c.Request.Context(), rollout.Namespace, rollout.Name, true)
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		response.Rollouts = append(response.Rollouts, result)
+		logging.FromContext(c).Info("Reconciled rollout for pushed image", "audit", true, "user", "registry-webhook",
+			"namespace", rollout.Namespace, "rollout", rollout.Name, "pushes", pushes, "error", result.Error)
+	}
+	if len(rollouts) == 0 {
+		logging.FromContext(c).Info("No rollouts for pushed image", "pushes", pushes)
+	}
+	c.JSON(http.StatusOK, response)
+}
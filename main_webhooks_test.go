@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	imagereflectorv1beta2 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/gin-gonic/gin"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"github.com/kuberik/rollout-dashboard/pkg/freeze"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/registryhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReceiveRegistryWebhook(t *testing.T) {
+	scheme, err := kubernetes.NewScheme()
+	require.NoError(t, err)
+	kubernetes.UseStaticClient(kubernetes.NewClientFrom(fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&imagereflectorv1beta2.ImageRepository{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api"},
+			Spec:       imagereflectorv1beta2.ImageRepositorySpec{Image: "ghcr.io/shop/api"},
+		},
+		&imagereflectorv1beta2.ImagePolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api"},
+			Spec:       imagereflectorv1beta2.ImagePolicySpec{ImageRepositoryRef: meta.NamespacedObjectReference{Name: "api"}},
+		},
+		&rolloutv1alpha1.Rollout{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api"},
+			Spec:       rolloutv1alpha1.RolloutSpec{ReleasesImagePolicy: corev1.LocalObjectReference{Name: "api"}},
+		},
+	).Build(), nil))
+	defer kubernetes.UseStaticClient(nil)
+
+	hook := registryhook.New([]byte("registry-webhook-secret"))
+	var freezes *freeze.Policy
+	receive := func(body, signature string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/webhooks/registry", strings.NewReader(body))
+		c.Request.Header.Set(registryhook.SignatureHeader, signature)
+		receiveRegistryWebhook(c, hook, freezes)
+		return w
+	}
+
+	body := `{"image":"ghcr.io/shop/api","tag":"v1.2.0"}`
+	w := receive(body, hook.Sign([]byte(body)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response api.RegistryWebhookResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Rollouts, 1)
+	assert.Equal(t, "api", response.Rollouts[0].Name)
+	assert.True(t, response.Rollouts[0].Results["ImageRepository/shop/api"].Succeeded)
+
+	assert.Equal(t, http.StatusUnauthorized, receive(body, "sha256=00").Code)
+	assert.Equal(t, http.StatusBadRequest, receive(`{}`, hook.Sign([]byte(`{}`))).Code)
+
+	// Pushes of images without rollouts are accepted
+	body = `{"image":"ghcr.io/shop/web","tag":"v1"}`
+	w = receive(body, hook.Sign([]byte(body)))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"pushes":[{"image":"ghcr.io/shop/web","tag":"v1"}],"rollouts":[]}`, w.Body.String())
+
+	// Frozen rollouts are not reconciled, and the webhook cannot override the freeze
+	cfg, err := freeze.ParseConfig([]byte(`
+windows:
+  - name: weekend
+    schedule: "* * * * *"
+    duration: 1h
+    namespaces: [shop]
+`))
+	require.NoError(t, err)
+	freezes = freeze.New(cfg)
+	body = `{"image":"ghcr.io/shop/api","tag":"v1.3.0"}`
+	w = receive(body, hook.Sign([]byte(body)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	response = api.RegistryWebhookResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Rollouts, 1)
+	assert.Contains(t, response.Rollouts[0].Error, "weekend")
+	assert.Empty(t, response.Rollouts[0].Results)
+}
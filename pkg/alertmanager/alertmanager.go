@@ -0,0 +1,212 @@
+// Package alertmanager reads firing alerts from Alertmanager and matches them to the workloads of
+// a rollout, so open alerts are visible before a deployment is marked successful
+package alertmanager
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+)
+
+// requestTimeout bounds a single request to Alertmanager
+const requestTimeout = 30 * time.Second
+
+// workloadLabels are the labels kube-state-metrics and the Kubernetes monitoring mixins name a
+// workload with, by kind
+var workloadLabels = map[string]string{
+	"Deployment":  "deployment",
+	"StatefulSet": "statefulset",
+	"DaemonSet":   "daemonset",
+}
+
+// Client reads alerts from the Alertmanager API v2
+type Client struct {
+	url string
+	// tokenFile holds a bearer token, re-read on every request so rotated tokens are picked up
+	tokenFile string
+	http      *http.Client
+}
+
+// New creates a client for the Alertmanager at url, authenticating with the bearer token in
+// tokenFile unless it is empty
+func New(url, tokenFile string) *Client {
+	return &Client{url: strings.TrimSuffix(url, "/"), tokenFile: tokenFile, http: &http.Client{Timeout: requestTimeout}}
+}
+
+// FromEnv creates a client for ALERTMANAGER_URL, nil when it is not set
+func FromEnv() *Client {
+	url := os.Getenv("ALERTMANAGER_URL")
+	if url == "" {
+		return nil
+	}
+	return New(url, os.Getenv("ALERTMANAGER_BEARER_TOKEN_FILE"))
+}
+
+// URL returns the address of Alertmanager
+func (c *Client) URL() string {
+	return c.url
+}
+
+// Alert is a firing alert
+type Alert struct {
+	Name        string            `json:"name"`
+	Severity    string            `json:"severity,omitempty"`
+	Summary     string            `json:"summary,omitempty"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+	Fingerprint string            `json:"fingerprint"`
+	// GeneratorURL links to the expression that fired the alert
+	GeneratorURL string `json:"generatorURL,omitempty"`
+	// Workload is the workload the alert is about, nil for alerts about the whole namespace
+	Workload *kubernetes.Workload `json:"workload,omitempty"`
+}
+
+// gettableAlert is an alert as returned by GET /api/v2/alerts
+type gettableAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	Fingerprint  string            `json:"fingerprint"`
+	GeneratorURL string            `json:"generatorURL"`
+}
+
+// GetAlerts returns the firing alerts in the namespaces that are neither silenced nor
+// inhibited
+func (c *Client) GetAlerts(ctx context.Context, namespaces []string) ([]Alert, error) {
+	quoted := make([]string, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		quoted = append(quoted, regexp.QuoteMeta(namespace))
+	}
+	params := url.Values{
+		"active":    {"true"},
+		"silenced":  {"false"},
+		"inhibited": {"false"},
+		"filter":    {fmt.Sprintf(`namespace=~"%s"`, strings.ReplaceAll(strings.Join(quoted, "|"), `\`, `\\`))},
+	}
+	var gettable []gettableAlert
+	if err := c.get(ctx, "/api/v2/alerts", params, &gettable); err != nil {
+		return nil, err
+	}
+
+	alerts := make([]Alert, 0, len(gettable))
+	for _, alert := range gettable {
+		alerts = append(alerts, Alert{
+			Name:         alert.Labels["alertname"],
+			Severity:     alert.Labels["severity"],
+			Summary:      cmp.Or(alert.Annotations["summary"], alert.Annotations["description"], alert.Annotations["message"]),
+			Labels:       alert.Labels,
+			Annotations:  alert.Annotations,
+			StartsAt:     alert.StartsAt,
+			Fingerprint:  alert.Fingerprint,
+			GeneratorURL: alert.GeneratorURL,
+		})
+	}
+	return alerts, nil
+}
+
+// Version returns the version of Alertmanager, checking it is reachable with the configured token
+func (c *Client) Version(ctx context.Context) (string, error) {
+	var status struct {
+		VersionInfo struct {
+			Version string `json:"version"`
+		} `json:"versionInfo"`
+	}
+	if err := c.get(ctx, "/api/v2/status", nil, &status); err != nil {
+		return "", err
+	}
+	return status.VersionInfo.Version, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, params url.Values, data any) error {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	endpoint := c.url + path
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if c.tokenFile != "" {
+		token, err := os.ReadFile(c.tokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read bearer token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(data); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// Match returns the alerts about the workloads: alerts naming one of them with a workload label
+// (e.g. deployment="web") or one of their pods, and alerts in their namespaces that name no
+// workload or pod at all. Alerts about other workloads are left out. The result is sorted by
+// start, newest first.
+func Match(alerts []Alert, workloads []kubernetes.Workload) []Alert {
+	namespaces := map[string]bool{}
+	for _, workload := range workloads {
+		namespaces[workload.Namespace] = true
+	}
+
+	matched := []Alert{}
+	for _, alert := range alerts {
+		namespace := alert.Labels["namespace"]
+		if !namespaces[namespace] {
+			continue
+		}
+		if !namesWorkload(alert.Labels) {
+			matched = append(matched, alert)
+			continue
+		}
+		index := slices.IndexFunc(workloads, func(workload kubernetes.Workload) bool {
+			if label, ok := workloadLabels[workload.Kind]; ok && alert.Labels[label] == workload.Name && namespace == workload.Namespace {
+				return true
+			}
+			return alert.Labels["pod"] != "" && workload.OwnsPod(namespace, alert.Labels["pod"])
+		})
+		if index >= 0 {
+			alert.Workload = &workloads[index]
+			matched = append(matched, alert)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].StartsAt.After(matched[j].StartsAt)
+	})
+	return matched
+}
+
+// namesWorkload reports whether alert labels name a workload or pod
+func namesWorkload(labels map[string]string) bool {
+	if labels["pod"] != "" {
+		return true
+	}
+	for _, label := range workloadLabels {
+		if labels[label] != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package alertmanager
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAlerts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v2/alerts", r.URL.Path)
+		assert.Equal(t, `namespace=~"shop|web\\.io"`, r.URL.Query().Get("filter"))
+		assert.Equal(t, "false", r.URL.Query().Get("silenced"))
+		w.Write([]byte(`[{"labels":{"alertname":"HighErrorRate","severity":"critical","namespace":"shop","deployment":"web"},
+			"annotations":{"description":"5% of requests fail"},"startsAt":"2026-01-02T15:00:00Z","fingerprint":"abc"}]`))
+	}))
+	defer srv.Close()
+
+	alerts, err := New(srv.URL, "").GetAlerts(context.Background(), []string{"shop", "web.io"})
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "HighErrorRate", alerts[0].Name)
+	assert.Equal(t, "critical", alerts[0].Severity)
+	assert.Equal(t, "5% of requests fail", alerts[0].Summary)
+	assert.Equal(t, time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC), alerts[0].StartsAt)
+}
+
+func TestMatch(t *testing.T) {
+	start := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	alert := func(name string, age time.Duration, labels map[string]string) Alert {
+		return Alert{Name: name, Labels: labels, StartsAt: start.Add(-age)}
+	}
+	workloads := []kubernetes.Workload{
+		{Kind: "Deployment", Namespace: "shop", Name: "web"},
+		{Kind: "StatefulSet", Namespace: "shop", Name: "db"},
+	}
+
+	matched := Match([]Alert{
+		alert("HighErrorRate", time.Hour, map[string]string{"namespace": "shop", "deployment": "web"}),
+		alert("PodCrashLooping", time.Minute, map[string]string{"namespace": "shop", "pod": "db-0"}),
+		alert("QuotaExceeded", 2*time.Hour, map[string]string{"namespace": "shop"}),
+		alert("OtherDeployment", 0, map[string]string{"namespace": "shop", "deployment": "cart"}),
+		alert("OtherPod", 0, map[string]string{"namespace": "shop", "pod": "webhook-5d9f8b7c4-x2x7q"}),
+		alert("OtherNamespace", 0, map[string]string{"namespace": "payments", "deployment": "web"}),
+	}, workloads)
+
+	var names []string
+	for _, alert := range matched {
+		names = append(names, alert.Name)
+	}
+	// Newest first, alerts about other workloads and namespaces are left out
+	assert.Equal(t, []string{"PodCrashLooping", "HighErrorRate", "QuotaExceeded"}, names)
+	assert.Equal(t, &workloads[1], matched[0].Workload)
+	assert.Equal(t, &workloads[0], matched[1].Workload)
+	assert.Nil(t, matched[2].Workload)
+}
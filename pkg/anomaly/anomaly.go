@@ -0,0 +1,306 @@
+package anomaly
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Config holds the thresholds above which mutating activity is reported. A zero threshold
+// disables the corresponding check.
+type Config struct {
+	// Window is the sliding window the thresholds apply to
+	Window time.Duration
+	// UserActions is the number of mutating requests by one user within Window
+	UserActions int
+	// NamespaceActions is the number of mutating requests in one namespace within Window
+	NamespaceActions int
+	// DistinctTargets is the number of different objects one user applies the same action to
+	// within Window, e.g. bypass-gates on many rollouts
+	DistinctTargets int
+	// WebhookURL receives alerts as JSON when set
+	WebhookURL string
+}
+
+// ConfigFromEnv reads the thresholds from the environment:
+//   - ANOMALY_WINDOW: sliding window (default 5m)
+//   - ANOMALY_USER_ACTIONS: mutating requests per user (default 30, 0 disables)
+//   - ANOMALY_NAMESPACE_ACTIONS: mutating requests per namespace (default 60, 0 disables)
+//   - ANOMALY_DISTINCT_TARGETS: objects one user applies the same action to (default 10, 0 disables)
+//   - ANOMALY_WEBHOOK_URL: URL alerts are POSTed to as JSON (default unset)
+func ConfigFromEnv() Config {
+	window := 5 * time.Minute
+	if parsed, err := time.ParseDuration(os.Getenv("ANOMALY_WINDOW")); err == nil && parsed > 0 {
+		window = parsed
+	}
+	return Config{
+		Window:           window,
+		UserActions:      envInt("ANOMALY_USER_ACTIONS", 30),
+		NamespaceActions: envInt("ANOMALY_NAMESPACE_ACTIONS", 60),
+		DistinctTargets:  envInt("ANOMALY_DISTINCT_TARGETS", 10),
+		WebhookURL:       os.Getenv("ANOMALY_WEBHOOK_URL"),
+	}
+}
+
+// Kinds of anomalies
+const (
+	KindUserRate        = "user_rate"
+	KindNamespaceRate   = "namespace_rate"
+	KindDistinctTargets = "distinct_targets"
+	// KindTest marks alerts sent to check the webhook works, see TestWebhook
+	KindTest = "test"
+)
+
+// Alert describes a spike of mutating activity
+type Alert struct {
+	Kind      string    `json:"kind"`
+	User      string    `json:"user,omitempty"`
+	Namespace string    `json:"namespace,omitempty"`
+	Action    string    `json:"action,omitempty"`
+	Count     int       `json:"count"`
+	Threshold int       `json:"threshold"`
+	Window    string    `json:"window"`
+	Message   string    `json:"message"`
+	Time      time.Time `json:"time"`
+}
+
+// Detector tracks mutating requests and raises an alert when a threshold is crossed. Each
+// anomaly is reported once per window, so a sustained spike does not flood the alert sinks.
+type Detector struct {
+	cfg      Config
+	userFunc func(c *gin.Context) string
+	now      func() time.Time
+	notify   func(Alert)
+
+	mu        sync.Mutex
+	events    map[string][]time.Time
+	targets   map[string]map[string]time.Time
+	alerted   map[string]time.Time
+	lastSweep time.Time
+}
+
+// New creates a detector identifying users with userFunc, falling back to the client IP. userFunc
+// is called after the request was handled, so it can read what later middleware stored.
+// Alerts are written to the audit log and, when configured, sent to the webhook.
+func New(cfg Config, userFunc func(c *gin.Context) string) *Detector {
+	d := &Detector{
+		cfg:      cfg,
+		userFunc: userFunc,
+		now:      time.Now,
+		events:   map[string][]time.Time{},
+		targets:  map[string]map[string]time.Time{},
+		alerted:  map[string]time.Time{},
+	}
+	d.notify = d.send
+	return d
+}
+
+// targetsKey is the gin context key of the objects set with SetTargets
+const targetsKey = "anomaly.targets"
+
+// Target is one of several objects a request acts on
+type Target struct {
+	Namespace string
+	Name      string
+	// Action names what is done to the object, recorded after the route
+	Action string
+}
+
+// SetTargets records that the request acts on several objects, e.g. a batch, so the detector
+// counts every one of them as a mutating request instead of the request itself
+func SetTargets(c *gin.Context, targets []Target) {
+	c.Set(targetsKey, targets)
+}
+
+// Middleware records mutating API requests (anything but GET, HEAD and OPTIONS below /api/).
+// Requests are recorded whether or not they succeed: a burst of denied attempts is as
+// suspicious as a burst of successful ones.
+func (d *Detector) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if !isMutation(c.Request) || c.FullPath() == "" {
+			return
+		}
+
+		user := ""
+		if d.userFunc != nil {
+			user = d.userFunc(c)
+		}
+		if user == "" {
+			user = "ip:" + c.ClientIP()
+		}
+		targets := []Target{{Namespace: c.Param("namespace"), Name: c.Param("name")}}
+		if value, ok := c.Get(targetsKey); ok {
+			targets = value.([]Target)
+		}
+		for _, target := range targets {
+			action := c.FullPath()
+			if target.Action != "" {
+				action += " " + target.Action
+			}
+			for _, alert := range d.record(user, target.Namespace, action, target.Namespace+"/"+target.Name) {
+				d.notify(alert)
+			}
+		}
+	}
+}
+
+// record adds a request and returns the alerts it triggers
+func (d *Detector) record(user, namespace, action, target string) []Alert {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	d.sweep(now)
+
+	var alerts []Alert
+	if count := d.add("user:"+user, now); d.cfg.UserActions > 0 && count > d.cfg.UserActions {
+		alerts = d.raise(alerts, now, Alert{
+			Kind: KindUserRate, User: user, Count: count, Threshold: d.cfg.UserActions,
+			Message: fmt.Sprintf("%s sent %d mutating requests", user, count),
+		})
+	}
+	if namespace != "" {
+		if count := d.add("namespace:"+namespace, now); d.cfg.NamespaceActions > 0 && count > d.cfg.NamespaceActions {
+			alerts = d.raise(alerts, now, Alert{
+				Kind: KindNamespaceRate, Namespace: namespace, Count: count, Threshold: d.cfg.NamespaceActions,
+				Message: fmt.Sprintf("%d mutating requests in namespace %s", count, namespace),
+			})
+		}
+	}
+	if target != "/" && d.cfg.DistinctTargets > 0 {
+		key := user + "|" + action
+		if d.targets[key] == nil {
+			d.targets[key] = map[string]time.Time{}
+		}
+		d.targets[key][target] = now
+		if count := len(d.targets[key]); count > d.cfg.DistinctTargets {
+			alerts = d.raise(alerts, now, Alert{
+				Kind: KindDistinctTargets, User: user, Action: action, Count: count, Threshold: d.cfg.DistinctTargets,
+				Message: fmt.Sprintf("%s applied %s to %d different objects", user, action, count),
+			})
+		}
+	}
+	return alerts
+}
+
+// add records an event for key and returns the number of events within the window
+func (d *Detector) add(key string, now time.Time) int {
+	d.events[key] = append(d.events[key], now)
+	return len(d.events[key])
+}
+
+// raise appends the alert unless the same anomaly was already reported within the window
+func (d *Detector) raise(alerts []Alert, now time.Time, alert Alert) []Alert {
+	key := alert.Kind + "|" + alert.User + "|" + alert.Namespace + "|" + alert.Action
+	if last, ok := d.alerted[key]; ok && now.Sub(last) < d.cfg.Window {
+		return alerts
+	}
+	d.alerted[key] = now
+	alert.Window = d.cfg.Window.String()
+	alert.Time = now
+	return append(alerts, alert)
+}
+
+// sweep drops events that left the window. Callers hold d.mu.
+func (d *Detector) sweep(now time.Time) {
+	cutoff := now.Add(-d.cfg.Window)
+	for key, times := range d.events {
+		i := 0
+		for i < len(times) && !times[i].After(cutoff) {
+			i++
+		}
+		if i == len(times) {
+			delete(d.events, key)
+		} else {
+			d.events[key] = times[i:]
+		}
+	}
+	for key, targets := range d.targets {
+		for target, seen := range targets {
+			if !seen.After(cutoff) {
+				delete(targets, target)
+			}
+		}
+		if len(targets) == 0 {
+			delete(d.targets, key)
+		}
+	}
+	if now.Sub(d.lastSweep) > d.cfg.Window {
+		for key, at := range d.alerted {
+			if now.Sub(at) >= d.cfg.Window {
+				delete(d.alerted, key)
+			}
+		}
+		d.lastSweep = now
+	}
+}
+
+// send writes the alert to the audit log and posts it to the webhook
+func (d *Detector) send(alert Alert) {
+	slog.Warn("Unusual spike of mutating requests", "audit", true, "kind", alert.Kind, "user", alert.User,
+		"namespace", alert.Namespace, "action", alert.Action, "count", alert.Count, "threshold", alert.Threshold,
+		"window", alert.Window)
+
+	if d.cfg.WebhookURL == "" {
+		return
+	}
+	go func() {
+		if err := postWebhook(d.cfg.WebhookURL, alert); err != nil {
+			slog.Error("Failed to send anomaly alert", "error", err)
+		}
+	}()
+}
+
+// TestWebhook posts a test alert to the webhook and reports whether it was accepted
+func TestWebhook(url string, now time.Time) error {
+	return postWebhook(url, Alert{Kind: KindTest, Message: "Test alert from the rollout dashboard", Time: now})
+}
+
+func postWebhook(url string, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post alert: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+func isMutation(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return strings.HasPrefix(r.URL.Path, "/api/")
+}
+
+func envInt(name string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(name)); err == nil && value >= 0 {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,143 @@
+package anomaly
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDetector(cfg Config) (*Detector, *[]Alert, *time.Time) {
+	d := New(cfg, func(c *gin.Context) string { return c.GetHeader("X-Test-User") })
+	now := time.Now()
+	d.now = func() time.Time { return now }
+	var alerts []Alert
+	d.notify = func(a Alert) { alerts = append(alerts, a) }
+	return d, &alerts, &now
+}
+
+func newTestRouter(d *Detector) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(d.Middleware())
+	r.GET("/api/v1/rollouts/:namespace/:name", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.POST("/api/v1/rollouts/:namespace/:name/bypass-gates", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func do(r *gin.Engine, method, path, user string) {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("X-Test-User", user)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestDetector_DistinctTargets(t *testing.T) {
+	d, alerts, _ := newTestDetector(Config{Window: time.Minute, DistinctTargets: 3})
+	r := newTestRouter(d)
+
+	// Repeating the action on the same rollout and reads do not count
+	for i := 0; i < 5; i++ {
+		do(r, http.MethodPost, "/api/v1/rollouts/apps/app-0/bypass-gates", "alice")
+		do(r, http.MethodGet, fmt.Sprintf("/api/v1/rollouts/apps/app-%d", i), "alice")
+	}
+	require.Empty(t, *alerts)
+
+	for i := 1; i < 6; i++ {
+		do(r, http.MethodPost, fmt.Sprintf("/api/v1/rollouts/apps/app-%d/bypass-gates", i), "alice")
+	}
+	require.Len(t, *alerts, 1, "a spike is reported once per window")
+	alert := (*alerts)[0]
+	assert.Equal(t, KindDistinctTargets, alert.Kind)
+	assert.Equal(t, "alice", alert.User)
+	assert.Equal(t, "/api/v1/rollouts/:namespace/:name/bypass-gates", alert.Action)
+	assert.Equal(t, 4, alert.Count)
+}
+
+func TestDetector_Targets(t *testing.T) {
+	d, alerts, _ := newTestDetector(Config{Window: time.Minute, UserActions: 8, DistinctTargets: 3})
+	r := newTestRouter(d)
+	r.POST("/api/v1/rollouts/batch", func(c *gin.Context) {
+		var targets []Target
+		for i := 0; i < 5; i++ {
+			targets = append(targets, Target{Namespace: "apps", Name: fmt.Sprintf("app-%d", i), Action: "bypass-gates"})
+		}
+		SetTargets(c, targets)
+		c.Status(http.StatusOK)
+	})
+
+	// One request acting on five rollouts counts five times
+	do(r, http.MethodPost, "/api/v1/rollouts/batch", "alice")
+	require.Len(t, *alerts, 1)
+	alert := (*alerts)[0]
+	assert.Equal(t, KindDistinctTargets, alert.Kind)
+	assert.Equal(t, "/api/v1/rollouts/batch bypass-gates", alert.Action)
+	assert.Equal(t, 4, alert.Count)
+
+	do(r, http.MethodPost, "/api/v1/rollouts/batch", "alice")
+	require.Len(t, *alerts, 2)
+	assert.Equal(t, KindUserRate, (*alerts)[1].Kind)
+}
+
+func TestDetector_UserAndNamespaceRate(t *testing.T) {
+	d, alerts, now := newTestDetector(Config{Window: time.Minute, UserActions: 2, NamespaceActions: 3})
+	r := newTestRouter(d)
+
+	do(r, http.MethodPost, "/api/v1/rollouts/apps/app/bypass-gates", "alice")
+	do(r, http.MethodPost, "/api/v1/rollouts/apps/app/bypass-gates", "bob")
+	do(r, http.MethodPost, "/api/v1/rollouts/apps/app/bypass-gates", "alice")
+	require.Empty(t, *alerts)
+
+	do(r, http.MethodPost, "/api/v1/rollouts/apps/app/bypass-gates", "alice")
+	require.Len(t, *alerts, 2)
+	assert.Equal(t, KindUserRate, (*alerts)[0].Kind)
+	assert.Equal(t, KindNamespaceRate, (*alerts)[1].Kind)
+	assert.Equal(t, "apps", (*alerts)[1].Namespace)
+
+	// Events leave the window
+	*now = now.Add(2 * time.Minute)
+	*alerts = nil
+	do(r, http.MethodPost, "/api/v1/rollouts/apps/app/bypass-gates", "alice")
+	assert.Empty(t, *alerts)
+}
+
+func TestPostWebhook(t *testing.T) {
+	received := make(chan Alert, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert Alert
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&alert))
+		received <- alert
+	}))
+	defer srv.Close()
+
+	require.NoError(t, postWebhook(srv.URL, Alert{Kind: KindUserRate, User: "alice", Count: 31}))
+	assert.Equal(t, "alice", (<-received).User)
+
+	require.NoError(t, TestWebhook(srv.URL, time.Now()))
+	assert.Equal(t, KindTest, (<-received).Kind)
+}
+
+func TestDetector_UserFromLaterMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	d := New(Config{Window: time.Minute, UserActions: 1}, func(c *gin.Context) string { return c.GetString("verified") })
+	var alerts []Alert
+	d.notify = func(a Alert) { alerts = append(alerts, a) }
+
+	// The user is only known once verification further down the chain ran
+	r := gin.New()
+	r.Use(d.Middleware())
+	r.POST("/api/v1/rollouts/:namespace/:name/bypass-gates", func(c *gin.Context) {
+		c.Set("verified", c.GetHeader("X-Test-User"))
+		c.Status(http.StatusOK)
+	})
+
+	do(r, http.MethodPost, "/api/v1/rollouts/apps/app/bypass-gates", "alice")
+	do(r, http.MethodPost, "/api/v1/rollouts/apps/app/bypass-gates", "alice")
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "alice", alerts[0].User)
+}
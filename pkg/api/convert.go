@@ -0,0 +1,856 @@
+package api
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2"
+	imagereflectorv1beta2 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	fluxmeta "github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
+	openkruisev1alpha1 "github.com/kuberik/openkruise-controller/api/v1alpha1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	kruiserolloutv1beta1 "github.com/openkruise/kruise-rollout-api/rollouts/v1beta1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const bypassGatesAnnotation = "rollout.kuberik.com/bypass-gates"
+
+// NewVersionInfo converts a rollout-controller VersionInfo
+func NewVersionInfo(v rolloutv1alpha1.VersionInfo) VersionInfo {
+	return VersionInfo{
+		Tag:      v.Tag,
+		Digest:   deref(v.Digest),
+		Version:  deref(v.Version),
+		Revision: deref(v.Revision),
+		Created:  timePtr(v.Created),
+	}
+}
+
+// NewGateStatus converts a gate summary from the rollout status
+func NewGateStatus(g rolloutv1alpha1.RolloutGateStatusSummary) GateStatus {
+	return GateStatus{
+		Name:            g.Name,
+		Passing:         g.Passing,
+		AllowedVersions: g.AllowedVersions,
+		Message:         g.Message,
+		Bypassed:        g.BypassGates,
+	}
+}
+
+// NewDeployment converts a deployment history entry
+func NewDeployment(entry rolloutv1alpha1.DeploymentHistoryEntry) Deployment {
+	deployment := Deployment{
+		ID:                entry.ID,
+		Version:           NewVersionInfo(entry.Version),
+		DeployedAt:        entry.Timestamp.Time,
+		Message:           deref(entry.Message),
+		BakeStatus:        deref(entry.BakeStatus),
+		BakeStatusMessage: deref(entry.BakeStatusMessage),
+		BakeStartTime:     timePtr(entry.BakeStartTime),
+		BakeEndTime:       timePtr(entry.BakeEndTime),
+	}
+	if entry.TriggeredBy != nil {
+		deployment.TriggeredBy = entry.TriggeredBy.Name
+	}
+	for _, check := range entry.FailedHealthChecks {
+		deployment.FailedHealthChecks = append(deployment.FailedHealthChecks, check.Namespace+"/"+check.Name)
+	}
+	return deployment
+}
+
+// NewRolloutSummary converts a Rollout into its list representation.
+// Only the current deployment is included, the full history is available from the detail endpoint.
+func NewRolloutSummary(rollout *rolloutv1alpha1.Rollout) RolloutSummary {
+	summary := RolloutSummary{
+		Namespace:          rollout.Namespace,
+		Name:               rollout.Name,
+		Title:              deref(rollout.Status.Title),
+		Description:        deref(rollout.Status.Description),
+		Source:             deref(rollout.Status.Source),
+		ArtifactType:       deref(rollout.Status.ArtifactType),
+		ImagePolicy:        rollout.Spec.ReleasesImagePolicy.Name,
+		WantedVersion:      deref(rollout.Spec.WantedVersion),
+		Channel:            kubernetes.RolloutChannel(rollout),
+		BypassGatesVersion: rollout.Annotations[bypassGatesAnnotation],
+		ReleaseCandidates:  len(rollout.Status.ReleaseCandidates),
+		CreatedAt:          rollout.CreationTimestamp.Time,
+	}
+	if len(rollout.Status.History) > 0 {
+		current := NewDeployment(rollout.Status.History[0])
+		summary.Current = &current
+	}
+	if len(rollout.Status.ReleaseCandidates) > 0 {
+		latest := NewVersionInfo(rollout.Status.ReleaseCandidates[0])
+		summary.LatestCandidate = &latest
+	}
+	for _, gate := range rollout.Status.Gates {
+		summary.Gates = append(summary.Gates, NewGateStatus(gate))
+	}
+	for _, condition := range rollout.Status.Conditions {
+		summary.Conditions = append(summary.Conditions, NewCondition(condition))
+	}
+	return summary
+}
+
+// NewGateDependency converts a resolved gate dependency. Upstream rollouts missing from rollouts
+// are left out.
+func NewGateDependency(dependency kubernetes.GateDependency, rollouts map[types.NamespacedName]*rolloutv1alpha1.Rollout) GateDependency {
+	converted := GateDependency{
+		Gate:         dependency.Gate,
+		Environment:  dependency.Environment,
+		Relationship: dependency.Relationship,
+		Rollouts:     []RolloutSummary{},
+	}
+	if dependency.Deployment != nil {
+		current := NewDeployment(*dependency.Deployment)
+		converted.Current = &current
+	}
+	for _, ref := range dependency.Rollouts {
+		if rollout, ok := rollouts[ref]; ok {
+			converted.Rollouts = append(converted.Rollouts, NewRolloutSummary(rollout))
+		}
+	}
+	return converted
+}
+
+// NewRolloutSummaries converts a list of Rollouts
+func NewRolloutSummaries(rollouts []rolloutv1alpha1.Rollout) []RolloutSummary {
+	summaries := make([]RolloutSummary, 0, len(rollouts))
+	for i := range rollouts {
+		summaries = append(summaries, NewRolloutSummary(&rollouts[i]))
+	}
+	return summaries
+}
+
+// RolloutHealth classifies a rollout for the fleet summary as one of the RolloutHealth constants
+func RolloutHealth(rollout *rolloutv1alpha1.Rollout) string {
+	var current *rolloutv1alpha1.DeploymentHistoryEntry
+	if len(rollout.Status.History) > 0 {
+		current = &rollout.Status.History[0]
+	}
+	bakeStatus := ""
+	if current != nil {
+		bakeStatus = deref(current.BakeStatus)
+	}
+	if bakeStatus == rolloutv1alpha1.BakeStatusFailed {
+		return RolloutHealthFailedBake
+	}
+	for _, gate := range rollout.Status.Gates {
+		if gate.Passing != nil && !*gate.Passing && !gate.BypassGates {
+			return RolloutHealthBlockedByGate
+		}
+	}
+	if bakeStatus == rolloutv1alpha1.BakeStatusDeploying || bakeStatus == rolloutv1alpha1.BakeStatusInProgress {
+		return RolloutHealthProgressing
+	}
+	if rollout.Spec.WantedVersion != nil {
+		return RolloutHealthPinned
+	}
+	// A rollout that has not deployed yet, or has a newer candidate, is about to deploy
+	if current == nil || len(rollout.Status.ReleaseCandidates) > 0 && rollout.Status.ReleaseCandidates[0].Tag != current.Version.Tag {
+		return RolloutHealthProgressing
+	}
+	return RolloutHealthUpToDate
+}
+
+// NewFleetSummary counts rollouts by health and lists at most recentFailures of the rollouts whose
+// current bake failed
+func NewFleetSummary(rollouts []rolloutv1alpha1.Rollout, recentFailures int) FleetSummary {
+	summary := FleetSummary{Total: len(rollouts), RecentlyFailed: []FailedRollout{}}
+	for i := range rollouts {
+		rollout := &rollouts[i]
+		switch RolloutHealth(rollout) {
+		case RolloutHealthFailedBake:
+			summary.FailedBake++
+			current := rollout.Status.History[0]
+			failed := FailedRollout{
+				Namespace:  rollout.Namespace,
+				Name:       rollout.Name,
+				Title:      deref(rollout.Status.Title),
+				FailedAt:   current.Timestamp.Time,
+				Deployment: NewDeployment(current),
+			}
+			if current.BakeEndTime != nil {
+				failed.FailedAt = current.BakeEndTime.Time
+			}
+			summary.RecentlyFailed = append(summary.RecentlyFailed, failed)
+		case RolloutHealthBlockedByGate:
+			summary.BlockedByGate++
+		case RolloutHealthProgressing:
+			summary.Progressing++
+		case RolloutHealthPinned:
+			summary.Pinned++
+		default:
+			summary.UpToDate++
+		}
+	}
+	sort.SliceStable(summary.RecentlyFailed, func(i, j int) bool {
+		return summary.RecentlyFailed[i].FailedAt.After(summary.RecentlyFailed[j].FailedAt)
+	})
+	if len(summary.RecentlyFailed) > recentFailures {
+		summary.RecentlyFailed = summary.RecentlyFailed[:recentFailures]
+	}
+	return summary
+}
+
+// NewCondition converts a Kubernetes condition
+func NewCondition(condition metav1.Condition) Condition {
+	return Condition{
+		Type:               condition.Type,
+		Status:             string(condition.Status),
+		Reason:             condition.Reason,
+		Message:            condition.Message,
+		LastTransitionTime: condition.LastTransitionTime.Time,
+	}
+}
+
+// NewManagedResource converts a managed resource status, dropping the embedded object
+func NewManagedResource(resource kubernetes.ManagedResourceStatus) ManagedResource {
+	managed := ManagedResource{
+		Namespace:  resource.Namespace,
+		Name:       resource.Name,
+		Status:     resource.Status,
+		Message:    resource.Message,
+		Drifted:    resource.Drifted,
+		DriftError: resource.DriftError,
+	}
+	for _, diff := range resource.Diff {
+		managed.Diff = append(managed.Diff, FieldDiff{Path: diff.Path, Live: diff.Live, Desired: diff.Desired})
+	}
+	// GroupVersionKind is formatted as "<group>/<version>/<kind>", with an empty group for core types
+	if parts := strings.SplitN(resource.GroupVersionKind, "/", 3); len(parts) == 3 {
+		managed.Group, managed.Version, managed.Kind = parts[0], parts[1], parts[2]
+	}
+	if !resource.LastModified.IsZero() {
+		lastModified := resource.LastModified
+		managed.LastModified = &lastModified
+	}
+	for _, child := range resource.Children {
+		managed.Children = append(managed.Children, NewManagedResource(child))
+	}
+	return managed
+}
+
+// NewManagedResources converts a list of managed resource statuses
+func NewManagedResources(resources []kubernetes.ManagedResourceStatus) []ManagedResource {
+	managed := make([]ManagedResource, 0, len(resources))
+	for _, resource := range resources {
+		managed = append(managed, NewManagedResource(resource))
+	}
+	return managed
+}
+
+// NewResourceDescribeResponse converts the description of a resource
+func NewResourceDescribeResponse(description *kubernetes.ResourceDescription) ResourceDescribeResponse {
+	gvk := description.Object.GroupVersionKind()
+	response := ResourceDescribeResponse{
+		Group:      gvk.Group,
+		Version:    gvk.Version,
+		Kind:       gvk.Kind,
+		Namespace:  description.Object.GetNamespace(),
+		Name:       description.Object.GetName(),
+		Status:     description.Status,
+		Message:    description.Message,
+		Conditions: make([]Condition, 0, len(description.Conditions)),
+		Events:     make([]Event, 0, len(description.Events)),
+	}
+	for _, condition := range description.Conditions {
+		response.Conditions = append(response.Conditions, NewCondition(condition))
+	}
+	for _, event := range description.Events {
+		response.Events = append(response.Events, NewEvent(event))
+	}
+	return response
+}
+
+// NewEvent converts a Kubernetes event, falling back to the newer events API fields
+// when the legacy timestamps and source are not set
+func NewEvent(event corev1.Event) Event {
+	out := Event{
+		Type:           event.Type,
+		Reason:         event.Reason,
+		Message:        event.Message,
+		Kind:           event.InvolvedObject.Kind,
+		Namespace:      event.InvolvedObject.Namespace,
+		Name:           event.InvolvedObject.Name,
+		Source:         event.Source.Component,
+		Count:          event.Count,
+		FirstTimestamp: event.FirstTimestamp.Time,
+		LastTimestamp:  event.LastTimestamp.Time,
+	}
+	if out.Source == "" {
+		out.Source = event.ReportingController
+	}
+	if out.FirstTimestamp.IsZero() {
+		out.FirstTimestamp = event.EventTime.Time
+	}
+	if out.LastTimestamp.IsZero() {
+		out.LastTimestamp = out.FirstTimestamp
+		if event.Series != nil {
+			out.LastTimestamp = event.Series.LastObservedTime.Time
+			out.Count = event.Series.Count
+		}
+	}
+	return out
+}
+
+// NewPod converts a pod, reporting the state of its init and regular containers
+func NewPod(pod corev1.Pod, podType string) Pod {
+	out := Pod{
+		Namespace:  pod.Namespace,
+		Name:       pod.Name,
+		Type:       podType,
+		Phase:      string(pod.Status.Phase),
+		Node:       pod.Spec.NodeName,
+		PodIP:      pod.Status.PodIP,
+		CreatedAt:  pod.CreationTimestamp.Time,
+		Containers: []PodContainer{},
+	}
+	if pod.Status.StartTime != nil {
+		startedAt := pod.Status.StartTime.Time
+		out.StartedAt = &startedAt
+	}
+	if owner := metav1.GetControllerOf(&pod); owner != nil {
+		out.Owner = owner.Kind + "/" + owner.Name
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			out.Ready = condition.Status == corev1.ConditionTrue
+		}
+	}
+
+	addContainers := func(containers []corev1.Container, statuses []corev1.ContainerStatus, init bool) {
+		for _, container := range containers {
+			c := PodContainer{Name: container.Name, Init: init, Image: container.Image}
+			for _, status := range statuses {
+				if status.Name != container.Name {
+					continue
+				}
+				// The status reports the resolved image once it has been pulled
+				if status.Image != "" {
+					c.Image = status.Image
+				}
+				c.ImageID = status.ImageID
+				c.Ready = status.Ready
+				c.RestartCount = status.RestartCount
+				switch {
+				case status.State.Waiting != nil:
+					c.State, c.Reason = "waiting", status.State.Waiting.Reason
+				case status.State.Running != nil:
+					c.State = "running"
+				case status.State.Terminated != nil:
+					c.State, c.Reason = "terminated", status.State.Terminated.Reason
+				}
+			}
+			out.Restarts += c.RestartCount
+			if !init {
+				out.TotalContainers++
+				if c.Ready {
+					out.ReadyContainers++
+				}
+			}
+			out.Containers = append(out.Containers, c)
+		}
+	}
+	addContainers(pod.Spec.InitContainers, pod.Status.InitContainerStatuses, true)
+	addContainers(pod.Spec.Containers, pod.Status.ContainerStatuses, false)
+	return out
+}
+
+// NewBlueGreenStatus converts a Kruise rollout using the blue/green strategy, returning nil for
+// canary rollouts or nil
+func NewBlueGreenStatus(rollout *kruiserolloutv1beta1.Rollout) *BlueGreenStatus {
+	if rollout == nil || !kubernetes.IsBlueGreen(rollout) {
+		return nil
+	}
+	out := &BlueGreenStatus{
+		Name:            rollout.Name,
+		Phase:           string(rollout.Status.Phase),
+		Message:         rollout.Status.Message,
+		StableService:   kubernetes.BlueGreenStableService(rollout),
+		PreviewService:  kubernetes.BlueGreenPreviewService(rollout),
+		TotalSteps:      int32(len(rollout.Spec.Strategy.BlueGreen.Steps)),
+		SwitchStep:      kubernetes.BlueGreenSwitchStep(rollout),
+		TrafficSwitched: kubernetes.BlueGreenTrafficSwitched(rollout),
+	}
+	if status := rollout.Status.BlueGreenStatus; status != nil {
+		out.StableRevision = status.StableRevision
+		out.UpdatedRevision = status.UpdatedRevision
+		out.UpdatedReplicas = status.UpdatedReplicas
+		out.UpdatedReadyReplicas = status.UpdatedReadyReplicas
+		out.CurrentStep = status.CurrentStepIndex
+		out.StepState = string(status.CurrentStepState)
+		out.AwaitingSwitch = !out.TrafficSwitched &&
+			status.CurrentStepState == kruiserolloutv1beta1.CanaryStepStatePaused &&
+			status.CurrentStepIndex+1 == out.SwitchStep
+	}
+	return out
+}
+
+// NewMigrations groups migration Jobs, newest first, into migrations by namespace and
+// migration name and resolves the version each run belongs to from the rollout's history
+func NewMigrations(jobs []batchv1.Job, history []rolloutv1alpha1.DeploymentHistoryEntry) []Migration {
+	migrations := []Migration{}
+	index := map[string]int{}
+	for i := range jobs {
+		job := &jobs[i]
+		key := job.Namespace + "/" + job.Labels[kubernetes.MigrationLabel]
+		if _, ok := index[key]; !ok {
+			index[key] = len(migrations)
+			migrations = append(migrations, Migration{Name: job.Labels[kubernetes.MigrationLabel], Namespace: job.Namespace})
+		}
+		status, message := kubernetes.MigrationJobStatus(job)
+		run := MigrationRun{
+			Job:       job.Name,
+			Version:   kubernetes.MigrationVersion(job, history),
+			Status:    status,
+			Message:   message,
+			StartTime: timePtr(job.Status.StartTime),
+		}
+		// Failed Jobs have no completion time, they end with their Failed condition
+		end := timePtr(job.Status.CompletionTime)
+		for _, condition := range job.Status.Conditions {
+			if end == nil && condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+				end = &condition.LastTransitionTime.Time
+			}
+		}
+		run.CompletionTime = end
+		if run.StartTime != nil && end != nil {
+			duration := int64(end.Sub(*run.StartTime).Seconds())
+			run.DurationSeconds = &duration
+		}
+		m := &migrations[index[key]]
+		m.Runs = append(m.Runs, run)
+	}
+	return migrations
+}
+
+// NewHistoryEntries converts history records, keeping their order
+func NewHistoryEntries(records []kubernetes.HistoryRecord) []HistoryEntry {
+	entries := make([]HistoryEntry, 0, len(records))
+	for _, record := range records {
+		entry := HistoryEntry{
+			Version:         record.Version,
+			Digest:          record.Digest,
+			DeployedAt:      record.DeployedAt,
+			ReleasedAt:      record.ReleasedAt,
+			Sources:         record.Sources,
+			Reconstructed:   record.Reconstructed,
+			BakeStatus:      record.BakeStatus,
+			ReconcileStatus: record.ReconcileStatus,
+		}
+		if !record.LastSeenAt.IsZero() {
+			lastSeenAt := record.LastSeenAt
+			entry.LastSeenAt = &lastSeenAt
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// NewHealthCheckTransitions converts the transitions of a HealthCheck
+func NewHealthCheckTransitions(transitions []kubernetes.HealthCheckTransition) []HealthCheckTransition {
+	out := make([]HealthCheckTransition, 0, len(transitions))
+	for _, transition := range transitions {
+		out = append(out, HealthCheckTransition{
+			Time:    transition.Time,
+			Status:  string(transition.Status),
+			Reason:  transition.Reason,
+			Message: transition.Message,
+			Source:  transition.Source,
+			Rollout: transition.Rollout,
+		})
+	}
+	return out
+}
+
+// NewHealthCheckError converts the last error of a HealthCheck, nil if it has none
+func NewHealthCheckError(lastError *kubernetes.HealthCheckError) *HealthCheckError {
+	if lastError == nil {
+		return nil
+	}
+	return &HealthCheckError{Time: lastError.Time, Message: lastError.Message}
+}
+
+// NewSyncSummary counts the final phases of sync results
+func NewSyncSummary(results []kubernetes.SyncProgress) *SyncSummary {
+	summary := &SyncSummary{Results: results}
+	if summary.Results == nil {
+		summary.Results = []kubernetes.SyncProgress{}
+	}
+	for _, result := range results {
+		switch result.Phase {
+		case kubernetes.SyncPhaseReady:
+			summary.Ready++
+		case kubernetes.SyncPhaseSkipped:
+			summary.Skipped++
+		default:
+			summary.Failed++
+		}
+	}
+	return summary
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func timePtr(t *metav1.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	return &t.Time
+}
+
+// lastAppliedAnnotation holds a copy of the whole object and is left out of trimmed views
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// NewRolloutView converts a Rollout for the v2 rollout details
+func NewRolloutView(rollout *rolloutv1alpha1.Rollout) RolloutView {
+	view := RolloutView{
+		RolloutSummary: NewRolloutSummary(rollout),
+		Labels:         rollout.Labels,
+		History:        make([]Deployment, 0, len(rollout.Status.History)),
+		Candidates:     make([]VersionInfo, 0, len(rollout.Status.ReleaseCandidates)),
+	}
+	for key, value := range rollout.Annotations {
+		if key == lastAppliedAnnotation {
+			continue
+		}
+		if view.Annotations == nil {
+			view.Annotations = map[string]string{}
+		}
+		view.Annotations[key] = value
+	}
+	for _, entry := range rollout.Status.History {
+		view.History = append(view.History, NewDeployment(entry))
+	}
+	for _, candidate := range rollout.Status.ReleaseCandidates {
+		view.Candidates = append(view.Candidates, NewVersionInfo(candidate))
+	}
+	return view
+}
+
+// NewKustomizations converts a list of Flux Kustomizations, nil when the list is nil
+func NewKustomizations(list *kustomizev1.KustomizationList) []Kustomization {
+	if list == nil {
+		return nil
+	}
+	out := make([]Kustomization, 0, len(list.Items))
+	for _, kustomization := range list.Items {
+		source := kustomization.Spec.SourceRef
+		sourceNamespace := source.Namespace
+		if sourceNamespace == "" {
+			sourceNamespace = kustomization.Namespace
+		}
+		converted := Kustomization{
+			Namespace:             kustomization.Namespace,
+			Name:                  kustomization.Name,
+			Path:                  kustomization.Spec.Path,
+			Source:                source.Kind + "/" + sourceNamespace + "/" + source.Name,
+			Suspended:             kustomization.Spec.Suspend,
+			LastAppliedRevision:   kustomization.Status.LastAppliedRevision,
+			LastAttemptedRevision: kustomization.Status.LastAttemptedRevision,
+			Conditions:            newConditions(kustomization.Status.Conditions),
+		}
+		if kustomization.Status.Inventory != nil {
+			converted.Resources = len(kustomization.Status.Inventory.Entries)
+		}
+		out = append(out, converted)
+	}
+	return out
+}
+
+// NewHelmReleases converts a list of Flux HelmReleases, nil when the list is nil
+func NewHelmReleases(list *helmv2.HelmReleaseList) []HelmRelease {
+	if list == nil {
+		return nil
+	}
+	out := make([]HelmRelease, 0, len(list.Items))
+	for _, helmRelease := range list.Items {
+		converted := HelmRelease{
+			Namespace:                  helmRelease.Namespace,
+			Name:                       helmRelease.Name,
+			Suspended:                  helmRelease.Spec.Suspend,
+			LastAttemptedRevision:      helmRelease.Status.LastAttemptedRevision,
+			LastAttemptedReleaseAction: string(helmRelease.Status.LastAttemptedReleaseAction),
+			Failures:                   helmRelease.Status.Failures,
+			Conditions:                 newConditions(helmRelease.Status.Conditions),
+		}
+		if chartRef := helmRelease.Spec.ChartRef; chartRef != nil {
+			chartNamespace := chartRef.Namespace
+			if chartNamespace == "" {
+				chartNamespace = helmRelease.Namespace
+			}
+			converted.Chart = chartRef.Kind + "/" + chartNamespace + "/" + chartRef.Name
+		} else if helmRelease.Spec.Chart != nil {
+			converted.Chart = helmRelease.Spec.Chart.Spec.Chart
+		}
+		if latest := helmRelease.Status.History.Latest(); latest != nil {
+			converted.ChartVersion = latest.ChartVersion
+			converted.AppVersion = latest.AppVersion
+			converted.Revision = latest.Version
+			converted.Status = latest.Status
+			if !latest.LastDeployed.IsZero() {
+				converted.LastDeployed = timePtr(&latest.LastDeployed)
+			}
+		}
+		out = append(out, converted)
+	}
+	return out
+}
+
+// NewOCIRepositories converts a list of Flux OCIRepositories, nil when the list is nil
+func NewOCIRepositories(list *sourcev1.OCIRepositoryList) []OCIRepository {
+	if list == nil {
+		return nil
+	}
+	out := make([]OCIRepository, 0, len(list.Items))
+	for _, repository := range list.Items {
+		converted := OCIRepository{
+			Namespace:  repository.Namespace,
+			Name:       repository.Name,
+			URL:        repository.Spec.URL,
+			Conditions: newConditions(repository.Status.Conditions),
+		}
+		if ref := repository.Spec.Reference; ref != nil {
+			converted.Tag, converted.SemVer, converted.Digest = ref.Tag, ref.SemVer, ref.Digest
+		}
+		if repository.Status.Artifact != nil {
+			converted.Revision = repository.Status.Artifact.Revision
+		}
+		out = append(out, converted)
+	}
+	return out
+}
+
+// NewFluxStatus rolls up the Ready and Healthy conditions of the Flux objects behind a rollout,
+// nil when there are none. Objects whose lists are nil are left out.
+func NewFluxStatus(kustomizations *kustomizev1.KustomizationList, helmReleases *helmv2.HelmReleaseList, ociRepositories *sourcev1.OCIRepositoryList, imagePolicy *imagereflectorv1beta2.ImagePolicy) *FluxStatus {
+	var objects []FluxObjectStatus
+	if kustomizations != nil {
+		for _, kustomization := range kustomizations.Items {
+			object := newFluxObjectStatus(kustomizev1.KustomizationKind, kustomization.ObjectMeta, kustomization.Status.Conditions, fluxmeta.HealthyCondition)
+			object.Suspended = kustomization.Spec.Suspend
+			object.Revision = kustomization.Status.LastAppliedRevision
+			objects = append(objects, object)
+		}
+	}
+	if helmReleases != nil {
+		for _, helmRelease := range helmReleases.Items {
+			object := newFluxObjectStatus(helmv2.HelmReleaseKind, helmRelease.ObjectMeta, helmRelease.Status.Conditions)
+			object.Suspended = helmRelease.Spec.Suspend
+			if latest := helmRelease.Status.History.Latest(); latest != nil {
+				object.Revision = latest.ChartVersion
+			}
+			objects = append(objects, object)
+		}
+	}
+	if ociRepositories != nil {
+		for _, repository := range ociRepositories.Items {
+			object := newFluxObjectStatus(sourcev1.OCIRepositoryKind, repository.ObjectMeta, repository.Status.Conditions)
+			object.Suspended = repository.Spec.Suspend
+			if repository.Status.Artifact != nil {
+				object.Revision = repository.Status.Artifact.Revision
+			}
+			objects = append(objects, object)
+		}
+	}
+	if imagePolicy != nil {
+		object := newFluxObjectStatus(imagereflectorv1beta2.ImagePolicyKind, imagePolicy.ObjectMeta, imagePolicy.Status.Conditions)
+		if imagePolicy.Status.LatestRef != nil {
+			object.Revision = imagePolicy.Status.LatestRef.String()
+		}
+		objects = append(objects, object)
+	}
+	if len(objects) == 0 {
+		return nil
+	}
+
+	status := &FluxStatus{Ready: true, Objects: objects}
+	for _, object := range objects {
+		if object.Ready != string(metav1.ConditionTrue) {
+			status.Ready = false
+		}
+		// Objects that are still progressing have not failed
+		if object.Ready != string(metav1.ConditionFalse) && object.Healthy != string(metav1.ConditionFalse) {
+			continue
+		}
+		reason := object.Reason
+		if object.Message != "" {
+			reason += ": " + object.Message
+		}
+		status.Failures = append(status.Failures, object.Kind+" "+object.Namespace+"/"+object.Name+": "+reason)
+	}
+	return status
+}
+
+// newFluxObjectStatus reads the Ready condition, and the extra condition types, of a Flux object
+func newFluxObjectStatus(kind string, objectMeta metav1.ObjectMeta, conditions []metav1.Condition, extra ...string) FluxObjectStatus {
+	object := FluxObjectStatus{Kind: kind, Namespace: objectMeta.Namespace, Name: objectMeta.Name, Ready: string(metav1.ConditionUnknown)}
+	for _, conditionType := range append([]string{fluxmeta.ReadyCondition}, extra...) {
+		status := string(metav1.ConditionUnknown)
+		condition := apimeta.FindStatusCondition(conditions, conditionType)
+		if condition != nil {
+			status = string(condition.Status)
+		}
+		if conditionType == fluxmeta.ReadyCondition {
+			object.Ready = status
+		} else {
+			object.Healthy = status
+		}
+		if condition != nil && condition.Status != metav1.ConditionTrue && object.Reason == "" {
+			object.Reason, object.Message = condition.Reason, condition.Message
+		}
+	}
+	return object
+}
+
+// NewRolloutGates converts a list of RolloutGates, nil when the list is nil
+func NewRolloutGates(list *rolloutv1alpha1.RolloutGateList) []RolloutGate {
+	if list == nil {
+		return nil
+	}
+	out := make([]RolloutGate, 0, len(list.Items))
+	for _, gate := range list.Items {
+		out = append(out, NewRolloutGate(gate))
+	}
+	return out
+}
+
+// NewRolloutGate converts a RolloutGate
+func NewRolloutGate(gate rolloutv1alpha1.RolloutGate) RolloutGate {
+	converted := RolloutGate{
+		Name:        gate.Name,
+		Passing:     gate.Spec.Passing,
+		Description: gate.Annotations[kubernetes.GateDescriptionAnnotation],
+	}
+	if gate.Spec.AllowedVersions != nil {
+		converted.AllowedVersions = *gate.Spec.AllowedVersions
+	}
+	return converted
+}
+
+// NewEnvironment converts an Environment, nil when it is nil
+func NewEnvironment(environment *envv1alpha1.Environment) *Environment {
+	if environment == nil {
+		return nil
+	}
+	out := &Environment{
+		Namespace:      environment.Namespace,
+		Name:           environment.Name,
+		Deployment:     environment.Spec.Name,
+		Environment:    environment.Spec.Environment,
+		CurrentVersion: environment.Status.CurrentVersion,
+		Environments:   []EnvironmentDeployment{},
+	}
+	if relationship := environment.Spec.Relationship; relationship != nil {
+		out.Upstream, out.Relationship = relationship.Environment, string(relationship.Type)
+	}
+	for _, info := range environment.Status.EnvironmentInfos {
+		deployment := EnvironmentDeployment{Environment: info.Environment, URL: info.EnvironmentURL}
+		if relationship := info.Relationship; relationship != nil {
+			deployment.Upstream, deployment.Relationship = relationship.Environment, string(relationship.Type)
+		}
+		if len(info.History) > 0 {
+			current := NewDeployment(info.History[0])
+			deployment.Current = &current
+		}
+		out.Environments = append(out.Environments, deployment)
+	}
+	return out
+}
+
+// NewKruiseRollout converts a Kruise rollout, nil when it is nil
+func NewKruiseRollout(rollout *kruiserolloutv1beta1.Rollout) *KruiseRollout {
+	if rollout == nil {
+		return nil
+	}
+	out := &KruiseRollout{
+		Name:    rollout.Name,
+		Phase:   string(rollout.Status.Phase),
+		Message: rollout.Status.Message,
+	}
+	switch strategy := rollout.Spec.Strategy; {
+	case strategy.BlueGreen != nil:
+		out.Strategy = "blueGreen"
+		out.TotalSteps = int32(len(strategy.BlueGreen.Steps))
+		if status := rollout.Status.BlueGreenStatus; status != nil {
+			out.UpdatedRevision, out.StableRevision = status.UpdatedRevision, status.StableRevision
+			out.CurrentStep, out.CurrentStepState = status.CurrentStepIndex, string(status.CurrentStepState)
+		}
+	case strategy.Canary != nil:
+		out.Strategy = "canary"
+		out.TotalSteps = int32(len(strategy.Canary.Steps))
+		if status := rollout.Status.CanaryStatus; status != nil {
+			out.UpdatedRevision, out.StableRevision = status.CanaryRevision, status.StableRevision
+			out.CurrentStep, out.CurrentStepState = status.CurrentStepIndex, string(status.CurrentStepState)
+		}
+	}
+	return out
+}
+
+// NewRolloutTests converts a list of RolloutTests, nil when the list is nil
+func NewRolloutTests(list *openkruisev1alpha1.RolloutTestList) []RolloutTest {
+	if list == nil {
+		return nil
+	}
+	out := make([]RolloutTest, 0, len(list.Items))
+	for _, test := range list.Items {
+		out = append(out, RolloutTest{
+			Name:             test.Name,
+			Step:             test.Spec.StepIndex,
+			Phase:            string(test.Status.Phase),
+			ObservedRevision: test.Status.ObservedCanaryRevision,
+			JobName:          test.Status.JobName,
+			RetryCount:       test.Status.RetryCount,
+			Conditions:       newConditions(test.Status.Conditions),
+		})
+	}
+	return out
+}
+
+// NewRolloutDetailView converts the rollout details into the trimmed v2 response
+func NewRolloutDetailView(detail *RolloutDetailResponse) RolloutDetailViewResponse {
+	view := RolloutDetailViewResponse{
+		Kustomizations:    NewKustomizations(detail.Kustomizations),
+		HelmReleases:      NewHelmReleases(detail.HelmReleases),
+		OCIRepositories:   NewOCIRepositories(detail.OCIRepositories),
+		RolloutGates:      NewRolloutGates(detail.RolloutGates),
+		Environment:       NewEnvironment(detail.Environment),
+		KruiseRollout:     NewKruiseRollout(detail.KruiseRollout),
+		RolloutTests:      NewRolloutTests(detail.RolloutTests),
+		ImageRepoScanTime: detail.ImageRepoScanTime,
+		BlueGreen:         detail.BlueGreen,
+		GateDependencies:  detail.GateDependencies,
+		Migrations:        detail.Migrations,
+		FluxStatus:        detail.FluxStatus,
+		Ownership:         detail.Ownership,
+		Links:             detail.Links,
+	}
+	if detail.Rollout != nil {
+		rollout := NewRolloutView(detail.Rollout)
+		view.Rollout = &rollout
+	}
+	return view
+}
+
+func newConditions(conditions []metav1.Condition) []Condition {
+	var out []Condition
+	for _, condition := range conditions {
+		out = append(out, NewCondition(condition))
+	}
+	return out
+}
@@ -0,0 +1,457 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2"
+	imagereflectorv1beta2 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	kruiserolloutv1beta1 "github.com/openkruise/kruise-rollout-api/rollouts/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestNewRolloutSummary(t *testing.T) {
+	deployedAt := metav1.NewTime(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	passing := true
+	rollout := &rolloutv1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "apps",
+			Name:        "hello-world",
+			Annotations: map[string]string{bypassGatesAnnotation: "v1.2.0"},
+			ManagedFields: []metav1.ManagedFieldsEntry{
+				{Manager: "rollout-controller", Operation: metav1.ManagedFieldsOperationUpdate},
+			},
+		},
+		Spec: rolloutv1alpha1.RolloutSpec{
+			ReleasesImagePolicy: corev1.LocalObjectReference{Name: "hello-world"},
+			WantedVersion:       strPtr("v1.1.0"),
+		},
+		Status: rolloutv1alpha1.RolloutStatus{
+			Title: strPtr("Hello World"),
+			History: []rolloutv1alpha1.DeploymentHistoryEntry{
+				{
+					Version:     rolloutv1alpha1.VersionInfo{Tag: "v1.1.0", Digest: strPtr("sha256:abc"), Revision: strPtr("main@sha1:deadbeef")},
+					Timestamp:   deployedAt,
+					TriggeredBy: &rolloutv1alpha1.TriggeredByInfo{Kind: "User", Name: "alice"},
+					BakeStatus:  strPtr("Failed"),
+					FailedHealthChecks: []rolloutv1alpha1.FailedHealthCheck{
+						{Namespace: "apps", Name: "error-rate"},
+					},
+				},
+				{Version: rolloutv1alpha1.VersionInfo{Tag: "v1.0.0"}},
+			},
+			ReleaseCandidates: []rolloutv1alpha1.VersionInfo{{Tag: "v1.2.0"}, {Tag: "v1.1.1"}},
+			Gates: []rolloutv1alpha1.RolloutGateStatusSummary{
+				{Name: "staging", Passing: &passing, AllowedVersions: []string{"v1.2.0"}},
+			},
+		},
+	}
+
+	summary := NewRolloutSummary(rollout)
+
+	assert.Equal(t, "apps", summary.Namespace)
+	assert.Equal(t, "hello-world", summary.Name)
+	assert.Equal(t, "Hello World", summary.Title)
+	assert.Equal(t, "hello-world", summary.ImagePolicy)
+	assert.Equal(t, "v1.1.0", summary.WantedVersion)
+	assert.Equal(t, "v1.2.0", summary.BypassGatesVersion)
+	assert.Equal(t, 2, summary.ReleaseCandidates)
+	require.NotNil(t, summary.LatestCandidate)
+	assert.Equal(t, "v1.2.0", summary.LatestCandidate.Tag)
+
+	require.NotNil(t, summary.Current)
+	assert.Equal(t, "v1.1.0", summary.Current.Version.Tag)
+	assert.Equal(t, "sha256:abc", summary.Current.Version.Digest)
+	assert.Equal(t, "main@sha1:deadbeef", summary.Current.Version.Revision)
+	assert.Equal(t, deployedAt.Time, summary.Current.DeployedAt)
+	assert.Equal(t, "alice", summary.Current.TriggeredBy)
+	assert.Equal(t, "Failed", summary.Current.BakeStatus)
+	assert.Equal(t, []string{"apps/error-rate"}, summary.Current.FailedHealthChecks)
+
+	require.Len(t, summary.Gates, 1)
+	assert.Equal(t, "staging", summary.Gates[0].Name)
+	assert.True(t, *summary.Gates[0].Passing)
+
+	// The summary must not leak object metadata noise such as managedFields
+	body, err := json.Marshal(summary)
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), "managedFields")
+}
+
+func TestNewRolloutSummaryWithoutHistory(t *testing.T) {
+	summary := NewRolloutSummary(&rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Name: "new"}})
+
+	assert.Nil(t, summary.Current)
+	assert.Nil(t, summary.LatestCandidate)
+	assert.Zero(t, summary.ReleaseCandidates)
+}
+
+func TestNewFleetSummary(t *testing.T) {
+	failing, passing := false, true
+	at := func(hour int) metav1.Time { return metav1.NewTime(time.Date(2025, 1, 2, hour, 0, 0, 0, time.UTC)) }
+	rollout := func(name, bakeStatus string, modify func(*rolloutv1alpha1.Rollout)) rolloutv1alpha1.Rollout {
+		r := rolloutv1alpha1.Rollout{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: name},
+			Status: rolloutv1alpha1.RolloutStatus{
+				History: []rolloutv1alpha1.DeploymentHistoryEntry{
+					{Version: rolloutv1alpha1.VersionInfo{Tag: "v1"}, Timestamp: at(1), BakeStatus: strPtr(bakeStatus)},
+				},
+				ReleaseCandidates: []rolloutv1alpha1.VersionInfo{{Tag: "v1"}},
+			},
+		}
+		if modify != nil {
+			modify(&r)
+		}
+		return r
+	}
+	rollouts := []rolloutv1alpha1.Rollout{
+		rollout("failed-early", rolloutv1alpha1.BakeStatusFailed, nil),
+		rollout("failed-late", rolloutv1alpha1.BakeStatusFailed, func(r *rolloutv1alpha1.Rollout) {
+			end := at(5)
+			r.Status.History[0].BakeEndTime = &end
+			// A failed bake outranks a blocking gate
+			r.Status.Gates = []rolloutv1alpha1.RolloutGateStatusSummary{{Name: "staging", Passing: &failing}}
+		}),
+		rollout("blocked", rolloutv1alpha1.BakeStatusSucceeded, func(r *rolloutv1alpha1.Rollout) {
+			r.Status.Gates = []rolloutv1alpha1.RolloutGateStatusSummary{{Name: "staging", Passing: &failing}}
+		}),
+		rollout("bypassed", rolloutv1alpha1.BakeStatusSucceeded, func(r *rolloutv1alpha1.Rollout) {
+			r.Status.Gates = []rolloutv1alpha1.RolloutGateStatusSummary{{Name: "staging", Passing: &failing, BypassGates: true}, {Name: "tests", Passing: &passing}}
+		}),
+		rollout("baking", rolloutv1alpha1.BakeStatusInProgress, nil),
+		rollout("candidate", rolloutv1alpha1.BakeStatusSucceeded, func(r *rolloutv1alpha1.Rollout) {
+			r.Status.ReleaseCandidates = []rolloutv1alpha1.VersionInfo{{Tag: "v2"}, {Tag: "v1"}}
+		}),
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "new"}},
+		rollout("pinned", rolloutv1alpha1.BakeStatusSucceeded, func(r *rolloutv1alpha1.Rollout) {
+			r.Spec.WantedVersion = strPtr("v1")
+			r.Status.ReleaseCandidates = []rolloutv1alpha1.VersionInfo{{Tag: "v2"}, {Tag: "v1"}}
+		}),
+	}
+
+	summary := NewFleetSummary(rollouts, 10)
+	assert.Equal(t, 8, summary.Total)
+	assert.Equal(t, 2, summary.FailedBake)
+	assert.Equal(t, 1, summary.BlockedByGate)
+	assert.Equal(t, 3, summary.Progressing)
+	assert.Equal(t, 1, summary.Pinned)
+	assert.Equal(t, 1, summary.UpToDate)
+	require.Len(t, summary.RecentlyFailed, 2)
+	assert.Equal(t, "failed-late", summary.RecentlyFailed[0].Name)
+	assert.Equal(t, at(5).Time, summary.RecentlyFailed[0].FailedAt)
+	assert.Equal(t, "failed-early", summary.RecentlyFailed[1].Name)
+	assert.Equal(t, at(1).Time, summary.RecentlyFailed[1].FailedAt)
+	assert.Equal(t, "v1", summary.RecentlyFailed[1].Deployment.Version.Tag)
+
+	limited := NewFleetSummary(rollouts, 1)
+	assert.Equal(t, 2, limited.FailedBake)
+	require.Len(t, limited.RecentlyFailed, 1)
+	assert.Equal(t, "failed-late", limited.RecentlyFailed[0].Name)
+
+	empty := NewFleetSummary(nil, 10)
+	assert.Zero(t, empty.Total)
+	assert.NotNil(t, empty.RecentlyFailed)
+}
+
+func TestNewManagedResource(t *testing.T) {
+	modified := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("Core resources have an empty group", func(t *testing.T) {
+		resource := NewManagedResource(kubernetes.ManagedResourceStatus{
+			GroupVersionKind: "/v1/Service",
+			Namespace:        "apps",
+			Name:             "hello-world",
+			Status:           "Current",
+			LastModified:     modified,
+			Object:           &unstructured.Unstructured{Object: map[string]interface{}{"kind": "Service"}},
+		})
+
+		assert.Equal(t, "", resource.Group)
+		assert.Equal(t, "v1", resource.Version)
+		assert.Equal(t, "Service", resource.Kind)
+		assert.Equal(t, "Current", resource.Status)
+		require.NotNil(t, resource.LastModified)
+		assert.Equal(t, modified, *resource.LastModified)
+	})
+
+	t.Run("Missing resources have no modification time", func(t *testing.T) {
+		resource := NewManagedResource(kubernetes.ManagedResourceStatus{
+			GroupVersionKind: "apps/v1/Deployment",
+			Name:             "hello-world",
+			Status:           "NotFound",
+		})
+
+		assert.Equal(t, "apps", resource.Group)
+		assert.Equal(t, "Deployment", resource.Kind)
+		assert.Nil(t, resource.LastModified)
+	})
+}
+
+func TestNewPod(t *testing.T) {
+	controller := true
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "apps",
+			Name:            "hello-world-abc",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "hello-world-5d8f", Controller: &controller}},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:       "node-1",
+			InitContainers: []corev1.Container{{Name: "migrate", Image: "migrate:1.0.0"}},
+			Containers: []corev1.Container{
+				{Name: "app", Image: "hello-world:1.0.0"},
+				{Name: "sidecar", Image: "proxy:2"},
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}},
+			InitContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "migrate",
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Completed"}},
+			}},
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "app", Image: "hello-world:1.0.0", Ready: true, RestartCount: 1, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+				{Name: "sidecar", Image: "proxy:2", RestartCount: 3, State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+			},
+		},
+	}
+
+	got := NewPod(pod, "pod")
+	assert.Equal(t, "Running", got.Phase)
+	assert.False(t, got.Ready)
+	assert.Equal(t, 1, got.ReadyContainers)
+	assert.Equal(t, 2, got.TotalContainers)
+	assert.Equal(t, int32(4), got.Restarts)
+	assert.Equal(t, "ReplicaSet/hello-world-5d8f", got.Owner)
+	require.Len(t, got.Containers, 3)
+	assert.Equal(t, PodContainer{Name: "migrate", Init: true, Image: "migrate:1.0.0", State: "terminated", Reason: "Completed"}, got.Containers[0])
+	assert.Equal(t, "CrashLoopBackOff", got.Containers[2].Reason)
+}
+
+func TestNewMigrations(t *testing.T) {
+	start := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	job := func(name, migration string, conditions ...batchv1.JobCondition) batchv1.Job {
+		return batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: name, Labels: map[string]string{kubernetes.MigrationLabel: migration}},
+			Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Image: "shop:" + name}},
+			}}},
+			Status: batchv1.JobStatus{StartTime: &metav1.Time{Time: start}, Conditions: conditions},
+		}
+	}
+	failed := batchv1.JobCondition{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "BackoffLimitExceeded", LastTransitionTime: metav1.NewTime(start.Add(90 * time.Second))}
+	succeeded := job("v1", "db", batchv1.JobCondition{Type: batchv1.JobComplete, Status: corev1.ConditionTrue})
+	succeeded.Status.CompletionTime = &metav1.Time{Time: start.Add(time.Minute)}
+	history := []rolloutv1alpha1.DeploymentHistoryEntry{{Version: rolloutv1alpha1.VersionInfo{Tag: "v2"}}, {Version: rolloutv1alpha1.VersionInfo{Tag: "v1"}}}
+
+	migrations := NewMigrations([]batchv1.Job{job("v2", "db", failed), job("v2", "cache"), succeeded}, history)
+	require.Len(t, migrations, 2)
+
+	assert.Equal(t, "db", migrations[0].Name)
+	require.Len(t, migrations[0].Runs, 2)
+	assert.Equal(t, "v2", migrations[0].Runs[0].Version)
+	assert.Equal(t, "Failed", migrations[0].Runs[0].Status)
+	assert.Equal(t, "BackoffLimitExceeded", migrations[0].Runs[0].Message)
+	assert.Equal(t, int64(90), *migrations[0].Runs[0].DurationSeconds)
+	assert.Equal(t, "v1", migrations[0].Runs[1].Version)
+	assert.Equal(t, "Succeeded", migrations[0].Runs[1].Status)
+	assert.Equal(t, int64(60), *migrations[0].Runs[1].DurationSeconds)
+
+	assert.Equal(t, "cache", migrations[1].Name)
+	assert.Equal(t, "Running", migrations[1].Runs[0].Status)
+	assert.Nil(t, migrations[1].Runs[0].DurationSeconds)
+}
+
+func TestNewRolloutDetailView(t *testing.T) {
+	passing := true
+	managedFields := []metav1.ManagedFieldsEntry{{Manager: "kustomize-controller", Operation: metav1.ManagedFieldsOperationApply}}
+	detail := &RolloutDetailResponse{
+		Rollout: &rolloutv1alpha1.Rollout{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "apps", Name: "web", ManagedFields: managedFields,
+				Labels: map[string]string{"team": "payments"},
+				Annotations: map[string]string{
+					"rollout.kuberik.com/team": "payments",
+					lastAppliedAnnotation:      `{"kind":"Rollout"}`,
+				},
+			},
+			Status: rolloutv1alpha1.RolloutStatus{
+				History: []rolloutv1alpha1.DeploymentHistoryEntry{
+					{Version: rolloutv1alpha1.VersionInfo{Tag: "v2"}},
+					{Version: rolloutv1alpha1.VersionInfo{Tag: "v1"}},
+				},
+				ReleaseCandidates: []rolloutv1alpha1.VersionInfo{{Tag: "v3"}},
+			},
+		},
+		Kustomizations: &kustomizev1.KustomizationList{Items: []kustomizev1.Kustomization{{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "web", ManagedFields: managedFields},
+			Spec: kustomizev1.KustomizationSpec{
+				Path:      "./deploy",
+				SourceRef: kustomizev1.CrossNamespaceSourceReference{Kind: "OCIRepository", Name: "web"},
+			},
+			Status: kustomizev1.KustomizationStatus{
+				LastAppliedRevision: "v2@sha256:abc",
+				Inventory:           &kustomizev1.ResourceInventory{Entries: []kustomizev1.ResourceRef{{ID: "apps_web_apps_Deployment"}}},
+			},
+		}}},
+		OCIRepositories: &sourcev1.OCIRepositoryList{Items: []sourcev1.OCIRepository{{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "web"},
+			Spec:       sourcev1.OCIRepositorySpec{URL: "oci://ghcr.io/example/web", Reference: &sourcev1.OCIRepositoryRef{Tag: "v2"}},
+		}}},
+		RolloutGates: &rolloutv1alpha1.RolloutGateList{Items: []rolloutv1alpha1.RolloutGate{{
+			ObjectMeta: metav1.ObjectMeta{Name: "staging"},
+			Spec:       rolloutv1alpha1.RolloutGateSpec{Passing: &passing, AllowedVersions: &[]string{"v2"}},
+		}}},
+		KruiseRollout: &kruiserolloutv1beta1.Rollout{
+			ObjectMeta: metav1.ObjectMeta{Name: "web"},
+			Spec: kruiserolloutv1beta1.RolloutSpec{Strategy: kruiserolloutv1beta1.RolloutStrategy{
+				Canary: &kruiserolloutv1beta1.CanaryStrategy{Steps: []kruiserolloutv1beta1.CanaryStep{{}, {}}},
+			}},
+			Status: kruiserolloutv1beta1.RolloutStatus{CanaryStatus: &kruiserolloutv1beta1.CanaryStatus{
+				CommonStatus:   kruiserolloutv1beta1.CommonStatus{CurrentStepIndex: 1, StableRevision: "stable"},
+				CanaryRevision: "canary",
+			}},
+		},
+	}
+
+	view := NewRolloutDetailView(detail)
+
+	require.NotNil(t, view.Rollout)
+	assert.Equal(t, "web", view.Rollout.Name)
+	assert.Equal(t, map[string]string{"team": "payments"}, view.Rollout.Labels)
+	assert.Equal(t, map[string]string{"rollout.kuberik.com/team": "payments"}, view.Rollout.Annotations)
+	require.Len(t, view.Rollout.History, 2)
+	assert.Equal(t, "v1", view.Rollout.History[1].Version.Tag)
+	assert.Equal(t, []VersionInfo{{Tag: "v3"}}, view.Rollout.Candidates)
+
+	assert.Equal(t, []Kustomization{{
+		Namespace: "apps", Name: "web", Path: "./deploy", Source: "OCIRepository/apps/web",
+		LastAppliedRevision: "v2@sha256:abc", Resources: 1,
+	}}, view.Kustomizations)
+	assert.Equal(t, []OCIRepository{{Namespace: "apps", Name: "web", URL: "oci://ghcr.io/example/web", Tag: "v2"}}, view.OCIRepositories)
+	assert.Equal(t, []RolloutGate{{Name: "staging", Passing: &passing, AllowedVersions: []string{"v2"}}}, view.RolloutGates)
+	assert.Equal(t, &KruiseRollout{
+		Name: "web", Strategy: "canary", UpdatedRevision: "canary", StableRevision: "stable", CurrentStep: 1, TotalSteps: 2,
+	}, view.KruiseRollout)
+	assert.Nil(t, view.Environment)
+	assert.Nil(t, view.RolloutTests)
+
+	body, err := json.Marshal(view)
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), "managedFields")
+	assert.NotContains(t, string(body), lastAppliedAnnotation)
+}
+
+func TestNewHelmReleases(t *testing.T) {
+	deployed := metav1.NewTime(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	helmReleases := NewHelmReleases(&helmv2.HelmReleaseList{Items: []helmv2.HelmRelease{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "web"},
+			Spec: helmv2.HelmReleaseSpec{
+				Suspend:  true,
+				ChartRef: &helmv2.CrossNamespaceSourceReference{Kind: sourcev1.OCIRepositoryKind, Name: "web-chart"},
+			},
+			Status: helmv2.HelmReleaseStatus{
+				History: helmv2.Snapshots{
+					{Version: 3, ChartVersion: "1.3.0", AppVersion: "v3", Status: "deployed", LastDeployed: deployed},
+					{Version: 2, ChartVersion: "1.2.0", Status: "superseded"},
+				},
+				LastAttemptedRevision:      "1.3.0",
+				LastAttemptedReleaseAction: helmv2.ReleaseActionUpgrade,
+				Failures:                   1,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "redis"},
+			Spec: helmv2.HelmReleaseSpec{Chart: &helmv2.HelmChartTemplate{
+				Spec: helmv2.HelmChartTemplateSpec{Chart: "redis"},
+			}},
+		},
+	}})
+	require.Len(t, helmReleases, 2)
+
+	web := helmReleases[0]
+	assert.Equal(t, "OCIRepository/apps/web-chart", web.Chart)
+	assert.True(t, web.Suspended)
+	assert.Equal(t, "1.3.0", web.ChartVersion)
+	assert.Equal(t, "v3", web.AppVersion)
+	assert.Equal(t, 3, web.Revision)
+	assert.Equal(t, "deployed", web.Status)
+	require.NotNil(t, web.LastDeployed)
+	assert.True(t, deployed.Time.Equal(*web.LastDeployed))
+	assert.Equal(t, "upgrade", web.LastAttemptedReleaseAction)
+	assert.Equal(t, int64(1), web.Failures)
+
+	// Releases never installed have no latest release
+	redis := helmReleases[1]
+	assert.Equal(t, "redis", redis.Chart)
+	assert.Empty(t, redis.Status)
+	assert.Nil(t, redis.LastDeployed)
+
+	assert.Nil(t, NewHelmReleases(nil))
+}
+
+func TestNewFluxStatus(t *testing.T) {
+	condition := func(conditionType string, status metav1.ConditionStatus, reason, message string) metav1.Condition {
+		return metav1.Condition{Type: conditionType, Status: status, Reason: reason, Message: message}
+	}
+	kustomizations := &kustomizev1.KustomizationList{Items: []kustomizev1.Kustomization{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "web"},
+			Status: kustomizev1.KustomizationStatus{
+				LastAppliedRevision: "v1.2.0@sha256:abc",
+				Conditions: []metav1.Condition{
+					condition("Ready", metav1.ConditionFalse, "HealthCheckFailed", "timeout waiting for: [Deployment/apps/web]"),
+					condition("Healthy", metav1.ConditionFalse, "HealthCheckFailed", "timeout waiting for: [Deployment/apps/web]"),
+				},
+			},
+		},
+		// Not reconciled yet, so not ready but not failed either
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "web-config"}, Spec: kustomizev1.KustomizationSpec{Suspend: true}},
+	}}
+	ociRepositories := &sourcev1.OCIRepositoryList{Items: []sourcev1.OCIRepository{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "web"},
+		Status: sourcev1.OCIRepositoryStatus{
+			Conditions: []metav1.Condition{condition("Ready", metav1.ConditionTrue, "Succeeded", "stored artifact")},
+		},
+	}}}
+	imagePolicy := &imagereflectorv1beta2.ImagePolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "web"},
+		Status: imagereflectorv1beta2.ImagePolicyStatus{
+			LatestRef:  &imagereflectorv1beta2.ImageRef{Name: "ghcr.io/shop/web", Tag: "v1.3.0"},
+			Conditions: []metav1.Condition{condition("Ready", metav1.ConditionTrue, "Succeeded", "")},
+		},
+	}
+
+	status := NewFluxStatus(kustomizations, nil, ociRepositories, imagePolicy)
+	require.NotNil(t, status)
+	assert.False(t, status.Ready)
+	assert.Equal(t, []FluxObjectStatus{
+		{Kind: "Kustomization", Namespace: "apps", Name: "web", Ready: "False", Healthy: "False", Reason: "HealthCheckFailed",
+			Message: "timeout waiting for: [Deployment/apps/web]", Revision: "v1.2.0@sha256:abc"},
+		{Kind: "Kustomization", Namespace: "apps", Name: "web-config", Ready: "Unknown", Healthy: "Unknown", Suspended: true},
+		{Kind: "OCIRepository", Namespace: "apps", Name: "web", Ready: "True"},
+		{Kind: "ImagePolicy", Namespace: "apps", Name: "web", Ready: "True", Revision: "ghcr.io/shop/web:v1.3.0"},
+	}, status.Objects)
+	assert.Equal(t, []string{"Kustomization apps/web: HealthCheckFailed: timeout waiting for: [Deployment/apps/web]"}, status.Failures)
+
+	status = NewFluxStatus(nil, nil, ociRepositories, nil)
+	require.NotNil(t, status)
+	assert.True(t, status.Ready)
+	assert.Empty(t, status.Failures)
+
+	assert.Nil(t, NewFluxStatus(nil, &helmv2.HelmReleaseList{}, nil, nil))
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/freeze"
+	"github.com/kuberik/rollout-dashboard/pkg/requestid"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// ErrorCode is a stable, machine-readable identifier for a class of API errors
+type ErrorCode string
+
+const (
+	// CodeBadRequest means the request was malformed or failed validation
+	CodeBadRequest ErrorCode = "BAD_REQUEST"
+	// CodeUnauthorized means the caller's credentials were missing or rejected
+	CodeUnauthorized ErrorCode = "UNAUTHORIZED"
+	// CodeForbidden means the caller lacks RBAC permission for the operation
+	CodeForbidden ErrorCode = "FORBIDDEN"
+	// CodeNotFound means a referenced Kubernetes object does not exist
+	CodeNotFound ErrorCode = "NOT_FOUND"
+	// CodeConflict means the object was modified concurrently or is in a conflicting state
+	CodeConflict ErrorCode = "CONFLICT"
+	// CodeClientInit means a Kubernetes client could not be created for the request
+	CodeClientInit ErrorCode = "CLIENT_INIT_FAILED"
+	// CodeKubernetesAPI means a call to the Kubernetes API server failed
+	CodeKubernetesAPI ErrorCode = "KUBERNETES_API_ERROR"
+	// CodeRegistry means a call to an OCI registry failed
+	CodeRegistry ErrorCode = "REGISTRY_ERROR"
+	// CodeMetrics means a query to Prometheus failed
+	CodeMetrics ErrorCode = "METRICS_ERROR"
+	// CodeAlerts means a request to Alertmanager failed
+	CodeAlerts ErrorCode = "ALERTS_ERROR"
+	// CodeGitProvider means a request to GitHub or GitLab failed
+	CodeGitProvider ErrorCode = "GIT_PROVIDER_ERROR"
+	// CodeFrozen means the change was rejected during a deployment freeze window
+	CodeFrozen ErrorCode = "DEPLOYMENT_FROZEN"
+	// CodeRateLimited means the caller exceeded a rate or concurrency limit
+	CodeRateLimited ErrorCode = "RATE_LIMITED"
+	// CodeInternal is used for unexpected server-side failures
+	CodeInternal ErrorCode = "INTERNAL_ERROR"
+)
+
+// ErrorResponse is the body returned for every failed API request
+type ErrorResponse struct {
+	Code      ErrorCode `json:"code"`
+	Message   string    `json:"message"`
+	Details   string    `json:"details,omitempty"`
+	RequestID string    `json:"requestId,omitempty"`
+}
+
+// RespondError aborts the request with a standardized error body.
+// Kubernetes API errors (not found, forbidden, conflict, unauthorized) override the given
+// status and code so clients can tell them apart from genuine server failures.
+func RespondError(c *gin.Context, status int, code ErrorCode, message string, err error) {
+	details := ""
+	if err != nil {
+		details = err.Error()
+		status, code = ClassifyError(err, status, code)
+	}
+	RespondErrorDetails(c, status, code, message, details)
+}
+
+// ClassifyError returns the status and code RespondError responds with for err, e.g. for results
+// of batch operations reported per item
+func ClassifyError(err error, status int, code ErrorCode) (int, ErrorCode) {
+	if k8sStatus, k8sCode, ok := classifyKubernetesError(err); ok {
+		return k8sStatus, k8sCode
+	}
+	if errors.Is(err, freeze.ErrFrozen) {
+		return http.StatusLocked, CodeFrozen
+	}
+	return status, code
+}
+
+// RespondErrorDetails aborts the request with a standardized error body carrying free-form details
+func RespondErrorDetails(c *gin.Context, status int, code ErrorCode, message, details string) {
+	c.AbortWithStatusJSON(status, ErrorResponse{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: requestid.FromContext(c),
+	})
+}
+
+// classifyKubernetesError maps well-known Kubernetes API status errors to HTTP status and code
+func classifyKubernetesError(err error) (int, ErrorCode, bool) {
+	var statusErr apierrors.APIStatus
+	if !errors.As(err, &statusErr) {
+		return 0, "", false
+	}
+	switch {
+	case apierrors.IsNotFound(err):
+		return http.StatusNotFound, CodeNotFound, true
+	case apierrors.IsForbidden(err):
+		return http.StatusForbidden, CodeForbidden, true
+	case apierrors.IsUnauthorized(err):
+		return http.StatusUnauthorized, CodeUnauthorized, true
+	case apierrors.IsConflict(err):
+		return http.StatusConflict, CodeConflict, true
+	}
+	return 0, "", false
+}
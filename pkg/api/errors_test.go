@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/freeze"
+	"github.com/kuberik/rollout-dashboard/pkg/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestRespondError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(err error, header string) (*httptest.ResponseRecorder, ErrorResponse) {
+		r := gin.New()
+		r.Use(requestid.Middleware())
+		r.GET("/test", func(c *gin.Context) {
+			RespondError(c, http.StatusInternalServerError, CodeKubernetesAPI, "Failed to get rollout", err)
+		})
+
+		req, _ := http.NewRequest("GET", "/test", nil)
+		if header != "" {
+			req.Header.Set(requestid.HeaderName, header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var body ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w, body
+	}
+
+	t.Run("Generic errors keep the given status and code", func(t *testing.T) {
+		w, body := serve(errors.New("connection refused"), "")
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, CodeKubernetesAPI, body.Code)
+		assert.Equal(t, "Failed to get rollout", body.Message)
+		assert.Equal(t, "connection refused", body.Details)
+		assert.NotEmpty(t, body.RequestID)
+		assert.Equal(t, body.RequestID, w.Header().Get(requestid.HeaderName))
+	})
+
+	t.Run("Kubernetes not found maps to 404", func(t *testing.T) {
+		err := apierrors.NewNotFound(schema.GroupResource{Group: "kuberik.com", Resource: "rollouts"}, "app")
+		w, body := serve(err, "abc-123")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, CodeNotFound, body.Code)
+		assert.Equal(t, "abc-123", body.RequestID)
+	})
+
+	t.Run("Kubernetes forbidden maps to 403", func(t *testing.T) {
+		err := apierrors.NewForbidden(schema.GroupResource{Resource: "rollouts"}, "app", errors.New("denied"))
+		w, body := serve(fmt.Errorf("failed to get rollout: %w", err), "")
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, CodeForbidden, body.Code)
+	})
+
+	t.Run("Deployment freezes map to 423", func(t *testing.T) {
+		w, body := serve(&freeze.FrozenError{Window: freeze.ActiveWindow{Name: "weekend"}}, "")
+
+		assert.Equal(t, http.StatusLocked, w.Code)
+		assert.Equal(t, CodeFrozen, body.Code)
+	})
+
+	t.Run("Malformed incoming request IDs are replaced", func(t *testing.T) {
+		_, body := serve(errors.New("boom"), "bad id\nwith newline")
+
+		assert.NotEqual(t, "bad id\nwith newline", body.RequestID)
+		assert.Len(t, body.RequestID, 32)
+	})
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ETag builds a weak entity tag of a response from the resourceVersions of the objects it is
+// built from, so an unchanged response is recognized without serializing it. Data that is not
+// read from objects is added as JSON, which must then be small.
+type ETag struct {
+	hash hash.Hash
+}
+
+// NewETag starts the entity tag of the response to a request. The path, query and requested
+// format are part of it, so each representation of a resource has its own tag.
+func NewETag(c *gin.Context) *ETag {
+	e := &ETag{hash: sha256.New()}
+	fmt.Fprintf(e.hash, "%s?%s ndjson=%t\n", c.Request.URL.Path, c.Request.URL.RawQuery, WantsNDJSON(c))
+	return e
+}
+
+// AddObjects adds Kubernetes objects and lists by the UID and resourceVersion of each object.
+// Nil objects are skipped.
+func (e *ETag) AddObjects(objects ...runtime.Object) {
+	for _, object := range objects {
+		if object == nil || reflect.ValueOf(object).Kind() == reflect.Pointer && reflect.ValueOf(object).IsNil() {
+			fmt.Fprintln(e.hash, "nil")
+			continue
+		}
+		if meta.IsListType(object) {
+			_ = meta.EachListItem(object, func(item runtime.Object) error {
+				e.addObject(item)
+				return nil
+			})
+			fmt.Fprintln(e.hash, "end")
+			continue
+		}
+		e.addObject(object)
+	}
+}
+
+func (e *ETag) addObject(object runtime.Object) {
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(e.hash, "%s/%s %s %s\n", accessor.GetNamespace(), accessor.GetName(), accessor.GetUID(), accessor.GetResourceVersion())
+}
+
+// AddJSON adds data that is not read from objects, e.g. derived or configured values
+func (e *ETag) AddJSON(values ...any) {
+	for _, value := range values {
+		data, _ := json.Marshal(value)
+		e.hash.Write(data)
+		fmt.Fprintln(e.hash)
+	}
+}
+
+// String returns the weak entity tag
+func (e *ETag) String() string {
+	return `W/"` + hex.EncodeToString(e.hash.Sum(nil)[:16]) + `"`
+}
+
+// NotModified sets the ETag header of a response and answers 304 Not Modified when the request's
+// If-None-Match names the tag, in which case the handler must not write a body
+func NotModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	for _, candidate := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		candidate = strings.TrimSpace(candidate)
+		// If-None-Match uses the weak comparison
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
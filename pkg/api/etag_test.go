@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tag := func(target string, objects ...*rolloutv1alpha1.Rollout) string {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+		list := &rolloutv1alpha1.RolloutList{}
+		for _, object := range objects {
+			list.Items = append(list.Items, *object)
+		}
+		etag := NewETag(c)
+		etag.AddObjects(list, (*rolloutv1alpha1.Rollout)(nil))
+		return etag.String()
+	}
+	rollout := func(resourceVersion string) *rolloutv1alpha1.Rollout {
+		return &rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api", ResourceVersion: resourceVersion}}
+	}
+
+	etag := tag("/api/v1/rollouts", rollout("1"))
+	assert.Regexp(t, `^W/"[0-9a-f]{32}"$`, etag)
+	assert.Equal(t, etag, tag("/api/v1/rollouts", rollout("1")))
+	assert.NotEqual(t, etag, tag("/api/v1/rollouts", rollout("2")), "a changed object changes the tag")
+	assert.NotEqual(t, etag, tag("/api/v1/rollouts?view=summary", rollout("1")), "each representation has its own tag")
+	assert.NotEqual(t, etag, tag("/api/v1/rollouts"))
+
+	for ifNoneMatch, notModified := range map[string]bool{
+		"":                   false,
+		`W/"other"`:          false,
+		etag:                 true,
+		etag[2:]:             true,
+		`W/"other", ` + etag: true,
+		"*":                  true,
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/rollouts", nil)
+		c.Request.Header.Set("If-None-Match", ifNoneMatch)
+		assert.Equal(t, notModified, NotModified(c, etag), ifNoneMatch)
+		c.Writer.WriteHeaderNow()
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+		if notModified {
+			assert.Equal(t, http.StatusNotModified, w.Code)
+		}
+	}
+}
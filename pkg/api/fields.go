@@ -0,0 +1,194 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxFields bounds the paths a fields parameter may select
+const maxFields = 100
+
+// Fields are the JSON paths a sparse response is pruned to, parsed from a fields query parameter
+// like metadata.name,status.history[0]. A path selects an object key per segment; a segment on an
+// array selects the key in every element, unless it names an element by index.
+type Fields []fieldPath
+
+type fieldPath []fieldSegment
+
+type fieldSegment struct {
+	key string
+	// index selects one element of the array at key, -1 selects every element
+	index int
+}
+
+// ParseFields parses comma-separated field paths. Empty values give no fields.
+func ParseFields(value string) (Fields, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	var fields Fields
+	for _, field := range strings.Split(value, ",") {
+		path, err := parseFieldPath(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, path)
+	}
+	if len(fields) > maxFields {
+		return nil, fmt.Errorf("at most %d fields can be selected", maxFields)
+	}
+	return fields, nil
+}
+
+func parseFieldPath(field string) (fieldPath, error) {
+	var path fieldPath
+	for _, segment := range strings.Split(field, ".") {
+		key, index := segment, -1
+		if open := strings.IndexByte(segment, '['); open >= 0 {
+			if !strings.HasSuffix(segment, "]") {
+				return nil, fmt.Errorf("field %q: unterminated index in %q", field, segment)
+			}
+			n, err := strconv.Atoi(segment[open+1 : len(segment)-1])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("field %q: index in %q must be a non-negative number", field, segment)
+			}
+			key, index = segment[:open], n
+		}
+		if key == "" {
+			return nil, fmt.Errorf("field %q: empty key", field)
+		}
+		path = append(path, fieldSegment{key: key, index: index})
+	}
+	return path, nil
+}
+
+// RequestFields parses the request's fields query parameter. It responds with the error and
+// returns false when the parameter is invalid or asks to prune a streamed response, which is
+// written as it is read.
+func RequestFields(c *gin.Context) (Fields, bool) {
+	fields, err := ParseFields(c.Query("fields"))
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, CodeBadRequest, "Invalid fields", err)
+		return nil, false
+	}
+	if fields != nil && WantsNDJSON(c) {
+		RespondErrorDetails(c, http.StatusBadRequest, CodeBadRequest, "Invalid fields", "fields cannot be selected in newline-delimited JSON responses")
+		return nil, false
+	}
+	return fields, true
+}
+
+// RespondJSON responds with v, pruned to the fields within the resources at base when fields are
+// selected, e.g. within each rollout of a list at rollouts.items. Everything outside base is left
+// out of a pruned response.
+func RespondJSON(c *gin.Context, status int, v any, base string, fields Fields) {
+	if fields == nil {
+		c.JSON(status, v)
+		return
+	}
+	pruned, err := fields.Prune(v, base)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, CodeInternal, "Failed to select fields", err)
+		return
+	}
+	c.JSON(status, pruned)
+}
+
+// Prune returns the JSON value of v with only the fields within the resources at base
+func (f Fields) Prune(v any, base string) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	basePath, err := parseFieldPath(base)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]fieldPath, len(f))
+	for i, path := range f {
+		paths[i] = append(append(fieldPath{}, basePath...), path...)
+	}
+	pruned, ok := prune(value, paths)
+	if !ok {
+		return map[string]any{}, nil
+	}
+	return pruned, nil
+}
+
+// prune keeps the parts of a JSON value selected by paths and reports whether any was selected.
+// Arrays keep their selected elements, even when none is.
+func prune(value any, paths []fieldPath) (any, bool) {
+	for _, path := range paths {
+		if len(path) == 0 {
+			return value, true
+		}
+	}
+	switch value := value.(type) {
+	case map[string]any:
+		byKey := map[string][]fieldPath{}
+		for _, path := range paths {
+			byKey[path[0].key] = append(byKey[path[0].key], path)
+		}
+		pruned := map[string]any{}
+		for key, keyPaths := range byKey {
+			child, ok := value[key]
+			if !ok {
+				continue
+			}
+			if child, ok := pruneKey(child, keyPaths); ok {
+				pruned[key] = child
+			}
+		}
+		return pruned, len(pruned) > 0
+	case []any:
+		pruned := []any{}
+		for _, element := range value {
+			if element, ok := prune(element, paths); ok {
+				pruned = append(pruned, element)
+			}
+		}
+		return pruned, true
+	}
+	// Paths continuing into a scalar select nothing
+	return nil, false
+}
+
+// pruneKey prunes the value of an object key by the paths starting at the key
+func pruneKey(value any, paths []fieldPath) (any, bool) {
+	elements, isArray := value.([]any)
+	var all []fieldPath
+	indexed := map[int][]fieldPath{}
+	for _, path := range paths {
+		if path[0].index < 0 {
+			all = append(all, path[1:])
+		} else if isArray {
+			indexed[path[0].index] = append(indexed[path[0].index], path[1:])
+		}
+	}
+	if len(indexed) == 0 {
+		if len(all) == 0 {
+			return nil, false
+		}
+		return prune(value, all)
+	}
+	pruned := []any{}
+	for i, element := range elements {
+		elementPaths := append(append([]fieldPath{}, all...), indexed[i]...)
+		if len(elementPaths) == 0 {
+			continue
+		}
+		if element, ok := prune(element, elementPaths); ok {
+			pruned = append(pruned, element)
+		}
+	}
+	return pruned, true
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldsPrune(t *testing.T) {
+	var response any
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"rollouts": {"items": [
+			{"metadata": {"name": "api", "namespace": "shop"}, "status": {"history": [{"version": "2"}, {"version": "1"}]}},
+			{"metadata": {"name": "web", "namespace": "shop"}, "status": {}}
+		]},
+		"skippedNamespaces": [{"namespace": "secret"}]
+	}`), &response))
+
+	for value, want := range map[string]string{
+		"metadata.name": `{"rollouts":{"items":[{"metadata":{"name":"api"}},{"metadata":{"name":"web"}}]}}`,
+		"metadata.name,status.history[0]": `{"rollouts":{"items":[
+			{"metadata":{"name":"api"},"status":{"history":[{"version":"2"}]}},
+			{"metadata":{"name":"web"}}]}}`,
+		// Arrays without an index select the key in every element
+		"status.history.version": `{"rollouts":{"items":[{"status":{"history":[{"version":"2"},{"version":"1"}]}}]}}`,
+		"status.history[1].version,metadata": `{"rollouts":{"items":[
+			{"metadata":{"name":"api","namespace":"shop"},"status":{"history":[{"version":"1"}]}},
+			{"metadata":{"name":"web","namespace":"shop"}}]}}`,
+		"metadata.name.first": `{"rollouts":{"items":[]}}`,
+		"missing":             `{"rollouts":{"items":[]}}`,
+	} {
+		fields, err := ParseFields(value)
+		require.NoError(t, err, value)
+		pruned, err := fields.Prune(response, "rollouts.items")
+		require.NoError(t, err, value)
+		data, err := json.Marshal(pruned)
+		require.NoError(t, err)
+		assert.JSONEq(t, want, string(data), value)
+	}
+
+	fields, err := ParseFields("")
+	require.NoError(t, err)
+	assert.Nil(t, fields)
+	for _, invalid := range []string{"metadata..name", "history[", "history[-1]", "history[x]", "[0]", "name,"} {
+		_, err := ParseFields(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,411 @@
+package api
+
+import (
+	"time"
+)
+
+// The types in this file form the v1 response contract of the dashboard API. They are populated
+// by the converters in convert.go so that upstream CRD schema changes do not leak to clients.
+// Fields may be added, but existing fields must not be renamed or change meaning.
+
+// VersionInfo describes a single release of a rollout
+type VersionInfo struct {
+	Tag      string     `json:"tag"`
+	Digest   string     `json:"digest,omitempty"`
+	Version  string     `json:"version,omitempty"`
+	Revision string     `json:"revision,omitempty"`
+	Created  *time.Time `json:"created,omitempty"`
+}
+
+// GateStatus describes whether a gate currently allows the rollout to progress
+type GateStatus struct {
+	Name            string   `json:"name"`
+	Passing         *bool    `json:"passing,omitempty"`
+	AllowedVersions []string `json:"allowedVersions,omitempty"`
+	Message         string   `json:"message,omitempty"`
+	Bypassed        bool     `json:"bypassed,omitempty"`
+}
+
+// GateDependency explains a gate driven by another environment's rollouts, e.g. production
+// waiting for versions that succeeded in staging
+type GateDependency struct {
+	Gate string `json:"gate"`
+	// Environment is the environment the gate depends on and Relationship is After or Parallel
+	Environment  string `json:"environment"`
+	Relationship string `json:"relationship"`
+	// Current is the latest deployment of that environment as reported by the gate's Environment
+	Current *Deployment `json:"current,omitempty"`
+	// Rollouts are the upstream rollouts found in this cluster with their current state
+	Rollouts []RolloutSummary `json:"rollouts"`
+}
+
+// Condition is a trimmed Kubernetes condition
+type Condition struct {
+	Type               string    `json:"type"`
+	Status             string    `json:"status"`
+	Reason             string    `json:"reason,omitempty"`
+	Message            string    `json:"message,omitempty"`
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+}
+
+// Deployment describes the most recent deployment of a rollout
+type Deployment struct {
+	ID                 *int64      `json:"id,omitempty"`
+	Version            VersionInfo `json:"version"`
+	DeployedAt         time.Time   `json:"deployedAt"`
+	Message            string      `json:"message,omitempty"`
+	TriggeredBy        string      `json:"triggeredBy,omitempty"`
+	BakeStatus         string      `json:"bakeStatus,omitempty"`
+	BakeStatusMessage  string      `json:"bakeStatusMessage,omitempty"`
+	BakeStartTime      *time.Time  `json:"bakeStartTime,omitempty"`
+	BakeEndTime        *time.Time  `json:"bakeEndTime,omitempty"`
+	FailedHealthChecks []string    `json:"failedHealthChecks,omitempty"`
+}
+
+// Migration is a migration Job of a rollout, see kubernetes.MigrationLabel, with its runs
+type Migration struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// Runs are the Jobs of the migration still in the cluster, newest first
+	Runs []MigrationRun `json:"runs"`
+}
+
+// MigrationRun is one Job of a migration and the version it ran for
+type MigrationRun struct {
+	Job     string `json:"job"`
+	Version string `json:"version,omitempty"`
+	// Status is Pending, Running, Succeeded or Failed
+	Status          string     `json:"status"`
+	Message         string     `json:"message,omitempty"`
+	StartTime       *time.Time `json:"startTime,omitempty"`
+	CompletionTime  *time.Time `json:"completionTime,omitempty"`
+	DurationSeconds *int64     `json:"durationSeconds,omitempty"`
+}
+
+// HistoryEntry is a deployment of a rollout. Entries with Reconstructed set are not from the
+// rollout's status history but pieced together from Flux and registry data, so they are a best
+// effort: their times are when the deployment was seen, not when it happened.
+type HistoryEntry struct {
+	Version         string     `json:"version"`
+	Digest          string     `json:"digest,omitempty"`
+	DeployedAt      time.Time  `json:"deployedAt"`
+	LastSeenAt      *time.Time `json:"lastSeenAt,omitempty"`
+	ReleasedAt      *time.Time `json:"releasedAt,omitempty"`
+	Sources         []string   `json:"sources"`
+	Reconstructed   bool       `json:"reconstructed"`
+	BakeStatus      string     `json:"bakeStatus,omitempty"`
+	ReconcileStatus string     `json:"reconcileStatus,omitempty"`
+}
+
+// HealthCheckTransition is a status change of a HealthCheck. Source says where it was recorded:
+// the HealthCheck's status (only its latest change), its events, or a rollout whose bake failed on
+// it (Rollout is then set).
+type HealthCheckTransition struct {
+	Time    time.Time `json:"time"`
+	Status  string    `json:"status,omitempty"`
+	Reason  string    `json:"reason,omitempty"`
+	Message string    `json:"message,omitempty"`
+	Source  string    `json:"source"`
+	Rollout string    `json:"rollout,omitempty"`
+}
+
+// HealthCheckError is the most recent error of a HealthCheck
+type HealthCheckError struct {
+	Time    *time.Time `json:"time,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+// RolloutSummary is a compact view of a Rollout suitable for list pages
+type RolloutSummary struct {
+	Namespace          string       `json:"namespace"`
+	Name               string       `json:"name"`
+	Title              string       `json:"title,omitempty"`
+	Description        string       `json:"description,omitempty"`
+	Source             string       `json:"source,omitempty"`
+	ArtifactType       string       `json:"artifactType,omitempty"`
+	ImagePolicy        string       `json:"imagePolicy,omitempty"`
+	WantedVersion      string       `json:"wantedVersion,omitempty"`
+	Channel            string       `json:"channel,omitempty"`
+	BypassGatesVersion string       `json:"bypassGatesVersion,omitempty"`
+	Current            *Deployment  `json:"current,omitempty"`
+	LatestCandidate    *VersionInfo `json:"latestCandidate,omitempty"`
+	ReleaseCandidates  int          `json:"releaseCandidates"`
+	Gates              []GateStatus `json:"gates,omitempty"`
+	Conditions         []Condition  `json:"conditions,omitempty"`
+	CreatedAt          time.Time    `json:"createdAt"`
+}
+
+// Health of a rollout as counted by the fleet summary. A rollout has the first health that applies:
+// its current bake failed, a gate blocks it, it is deploying or baking a version or will deploy a
+// newer release candidate, its version is pinned with wantedVersion, or it is up to date.
+const (
+	RolloutHealthFailedBake    = "failedBake"
+	RolloutHealthBlockedByGate = "blockedByGate"
+	RolloutHealthProgressing   = "progressing"
+	RolloutHealthPinned        = "pinned"
+	RolloutHealthUpToDate      = "upToDate"
+)
+
+// FleetSummary counts rollouts by health for the landing page
+type FleetSummary struct {
+	Total         int `json:"total"`
+	Progressing   int `json:"progressing"`
+	BlockedByGate int `json:"blockedByGate"`
+	FailedBake    int `json:"failedBake"`
+	UpToDate      int `json:"upToDate"`
+	Pinned        int `json:"pinned"`
+	// RecentlyFailed are the rollouts whose current bake failed, most recent failure first
+	RecentlyFailed []FailedRollout `json:"recentlyFailed"`
+}
+
+// FailedRollout is a rollout whose current deployment failed its bake
+type FailedRollout struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Title     string `json:"title,omitempty"`
+	// FailedAt is when the bake ended, or when the version was deployed if the end is not recorded
+	FailedAt   time.Time  `json:"failedAt"`
+	Deployment Deployment `json:"deployment"`
+}
+
+// ManagedResource describes an object from a Kustomization inventory and its computed status
+type ManagedResource struct {
+	Group        string     `json:"group,omitempty"`
+	Version      string     `json:"version"`
+	Kind         string     `json:"kind"`
+	Namespace    string     `json:"namespace,omitempty"`
+	Name         string     `json:"name"`
+	Status       string     `json:"status"`
+	Message      string     `json:"message,omitempty"`
+	LastModified *time.Time `json:"lastModified,omitempty"`
+	// Drifted, Diff and DriftError are only set when drift detection was requested
+	Drifted    bool        `json:"drifted,omitempty"`
+	Diff       []FieldDiff `json:"diff,omitempty"`
+	DriftError string      `json:"driftError,omitempty"`
+	// Children are the objects owned by this one, e.g. the ReplicaSets of a Deployment
+	Children []ManagedResource `json:"children,omitempty"`
+}
+
+// FieldDiff is a field of a managed resource whose live value differs from the desired manifests.
+// Live or Desired is omitted when the field is missing on that side.
+type FieldDiff struct {
+	Path    string `json:"path"`
+	Live    any    `json:"live,omitempty"`
+	Desired any    `json:"desired,omitempty"`
+}
+
+// Event is a trimmed Kubernetes event
+type Event struct {
+	Type           string    `json:"type"`
+	Reason         string    `json:"reason"`
+	Message        string    `json:"message"`
+	Kind           string    `json:"kind"`
+	Namespace      string    `json:"namespace"`
+	Name           string    `json:"name"`
+	Source         string    `json:"source,omitempty"`
+	Count          int32     `json:"count,omitempty"`
+	FirstTimestamp time.Time `json:"firstTimestamp"`
+	LastTimestamp  time.Time `json:"lastTimestamp"`
+}
+
+// Pod is a trimmed pod of a rollout's workloads or test jobs
+type Pod struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Type is "pod" for workload pods and "test" for RolloutTest job pods, Track "canary" or
+	// "stable" for workload pods during a Kruise canary
+	Type            string         `json:"type"`
+	Track           string         `json:"track,omitempty"`
+	Phase           string         `json:"phase"`
+	Ready           bool           `json:"ready"`
+	ReadyContainers int            `json:"readyContainers"`
+	TotalContainers int            `json:"totalContainers"`
+	Restarts        int32          `json:"restarts"`
+	Node            string         `json:"node,omitempty"`
+	PodIP           string         `json:"podIP,omitempty"`
+	Owner           string         `json:"owner,omitempty"`
+	CreatedAt       time.Time      `json:"createdAt"`
+	StartedAt       *time.Time     `json:"startedAt,omitempty"`
+	Containers      []PodContainer `json:"containers"`
+}
+
+// PodContainer is the state of a single (init) container of a pod
+type PodContainer struct {
+	Name         string `json:"name"`
+	Init         bool   `json:"init,omitempty"`
+	Image        string `json:"image"`
+	ImageID      string `json:"imageID,omitempty"`
+	Ready        bool   `json:"ready"`
+	RestartCount int32  `json:"restartCount"`
+	// State is "waiting", "running" or "terminated"; Reason explains waiting and terminated states
+	State  string `json:"state,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// BlueGreenStatus describes a Kruise rollout using the blue/green strategy in terms of its two
+// environments instead of canary steps
+type BlueGreenStatus struct {
+	Name                 string `json:"name"`
+	Phase                string `json:"phase,omitempty"`
+	Message              string `json:"message,omitempty"`
+	StableService        string `json:"stableService,omitempty"`
+	PreviewService       string `json:"previewService,omitempty"`
+	StableRevision       string `json:"stableRevision,omitempty"`
+	UpdatedRevision      string `json:"updatedRevision,omitempty"`
+	UpdatedReplicas      int32  `json:"updatedReplicas"`
+	UpdatedReadyReplicas int32  `json:"updatedReadyReplicas"`
+	CurrentStep          int32  `json:"currentStep"`
+	TotalSteps           int32  `json:"totalSteps"`
+	StepState            string `json:"stepState,omitempty"`
+	SwitchStep           int32  `json:"switchStep"`
+	TrafficSwitched      bool   `json:"trafficSwitched"`
+	// AwaitingSwitch is true while the new version is up and paused before traffic moves to it
+	AwaitingSwitch bool `json:"awaitingSwitch"`
+}
+
+// RolloutView is a Rollout as shown by the v2 rollout details: its summary with its labels,
+// annotations, full history and release candidates
+type RolloutView struct {
+	RolloutSummary
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// History is newest first; Current is its first entry
+	History []Deployment `json:"history"`
+	// Candidates are the releases the rollout may deploy, newest first
+	Candidates []VersionInfo `json:"candidates"`
+}
+
+// Kustomization is a trimmed Flux Kustomization
+type Kustomization struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Path      string `json:"path,omitempty"`
+	// Source is the Kind/namespace/name of the source the manifests come from
+	Source                string      `json:"source"`
+	Suspended             bool        `json:"suspended"`
+	LastAppliedRevision   string      `json:"lastAppliedRevision,omitempty"`
+	LastAttemptedRevision string      `json:"lastAttemptedRevision,omitempty"`
+	Resources             int         `json:"resources"`
+	Conditions            []Condition `json:"conditions,omitempty"`
+}
+
+// HelmRelease is a trimmed Flux HelmRelease
+type HelmRelease struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Chart is the chart of a chart template, or the Kind/namespace/name of the referenced chart
+	Chart     string `json:"chart"`
+	Suspended bool   `json:"suspended"`
+	// ChartVersion, AppVersion, Revision, Status and LastDeployed describe the latest Helm release
+	ChartVersion string     `json:"chartVersion,omitempty"`
+	AppVersion   string     `json:"appVersion,omitempty"`
+	Revision     int        `json:"revision,omitempty"`
+	Status       string     `json:"status,omitempty"`
+	LastDeployed *time.Time `json:"lastDeployed,omitempty"`
+	// LastAttemptedRevision is the chart version last attempted, with the action it was attempted by
+	LastAttemptedRevision      string      `json:"lastAttemptedRevision,omitempty"`
+	LastAttemptedReleaseAction string      `json:"lastAttemptedReleaseAction,omitempty"`
+	Failures                   int64       `json:"failures,omitempty"`
+	Conditions                 []Condition `json:"conditions,omitempty"`
+}
+
+// OCIRepository is a trimmed Flux OCIRepository
+type OCIRepository struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	Tag       string `json:"tag,omitempty"`
+	SemVer    string `json:"semver,omitempty"`
+	Digest    string `json:"digest,omitempty"`
+	// Revision is the revision of the artifact last fetched
+	Revision   string      `json:"revision,omitempty"`
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// FluxStatus rolls up the conditions of the Flux objects behind a rollout into one answer to
+// whether they are ready and, if not, why
+type FluxStatus struct {
+	// Ready is set when every object is Ready
+	Ready   bool               `json:"ready"`
+	Objects []FluxObjectStatus `json:"objects"`
+	// Failures are the reasons of the objects that are not ready, e.g.
+	// "Kustomization apps/web: HealthCheckFailed: timeout waiting for: [Deployment/apps/web status: 'InProgress']"
+	Failures []string `json:"failures,omitempty"`
+}
+
+// FluxObjectStatus is the readiness of a Kustomization, HelmRelease, OCIRepository or ImagePolicy
+type FluxObjectStatus struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Ready and Healthy are the statuses of the Ready and Healthy conditions, Unknown when unset;
+	// only Kustomizations report Healthy
+	Ready   string `json:"ready"`
+	Healthy string `json:"healthy,omitempty"`
+	// Reason and Message explain the first condition that is not True
+	Reason    string `json:"reason,omitempty"`
+	Message   string `json:"message,omitempty"`
+	Suspended bool   `json:"suspended"`
+	// Revision is the last applied revision, the fetched artifact's revision or the latest image
+	Revision string `json:"revision,omitempty"`
+}
+
+// RolloutGate is a gate of a rollout as set by its controller
+type RolloutGate struct {
+	Name            string   `json:"name"`
+	Passing         *bool    `json:"passing,omitempty"`
+	AllowedVersions []string `json:"allowedVersions,omitempty"`
+	// Description explains why a gate created in the dashboard exists
+	Description string `json:"description,omitempty"`
+}
+
+// Environment is the Environment of a rollout and the deployments it reports of other
+// environments
+type Environment struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Deployment is the deployment name shared by the rollouts of all environments
+	Deployment     string `json:"deployment"`
+	Environment    string `json:"environment,omitempty"`
+	Upstream       string `json:"upstream,omitempty"`
+	Relationship   string `json:"relationship,omitempty"`
+	CurrentVersion string `json:"currentVersion,omitempty"`
+	// Environments are the environments of the deployment as reported by the backend
+	Environments []EnvironmentDeployment `json:"environments"`
+}
+
+// EnvironmentDeployment is an environment of a deployment and its latest deployment
+type EnvironmentDeployment struct {
+	Environment  string      `json:"environment"`
+	URL          string      `json:"url,omitempty"`
+	Upstream     string      `json:"upstream,omitempty"`
+	Relationship string      `json:"relationship,omitempty"`
+	Current      *Deployment `json:"current,omitempty"`
+}
+
+// KruiseRollout is a trimmed Kruise rollout with the progress of its canary or blue/green steps
+type KruiseRollout struct {
+	Name string `json:"name"`
+	// Strategy is "canary" or "blueGreen"
+	Strategy string `json:"strategy"`
+	Phase    string `json:"phase,omitempty"`
+	Message  string `json:"message,omitempty"`
+	// UpdatedRevision is the revision being rolled out: the canary or the new blue/green revision
+	UpdatedRevision  string `json:"updatedRevision,omitempty"`
+	StableRevision   string `json:"stableRevision,omitempty"`
+	CurrentStep      int32  `json:"currentStep"`
+	CurrentStepState string `json:"currentStepState,omitempty"`
+	TotalSteps       int32  `json:"totalSteps"`
+}
+
+// RolloutTest is a trimmed RolloutTest of a Kruise rollout
+type RolloutTest struct {
+	Name string `json:"name"`
+	// Step is the 1-based step the test runs at
+	Step             int32       `json:"step"`
+	Phase            string      `json:"phase,omitempty"`
+	ObservedRevision string      `json:"observedRevision,omitempty"`
+	JobName          string      `json:"jobName,omitempty"`
+	RetryCount       int32       `json:"retryCount,omitempty"`
+	Conditions       []Condition `json:"conditions,omitempty"`
+}
@@ -0,0 +1,301 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Operation documents a single HTTP endpoint. Request and Response hold zero values of the body
+// types; their JSON schemas are derived by reflection so the document follows the Go types.
+type Operation struct {
+	Method      string
+	Path        string // gin route syntax, e.g. /api/rollouts/:namespace/:name
+	OperationID string
+	Summary     string
+	Tags        []string
+	Query       []QueryParameter
+	Request     any
+	Response    any
+	// Stream marks Server-Sent Events endpoints; Response then describes the event payload
+	Stream bool
+}
+
+// QueryParameter documents an optional query string parameter
+type QueryParameter struct {
+	Name        string
+	Description string
+	Type        string
+}
+
+// Document is an OpenAPI 3 document
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info holds the API title and version
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem maps lower-case HTTP methods to operations
+type PathItem map[string]*OpenAPIOperation
+
+// Components holds the shared schemas referenced from operations
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// OpenAPIOperation is the serialized form of an Operation
+type OpenAPIOperation struct {
+	OperationID string              `json:"operationId"`
+	Summary     string              `json:"summary,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes the JSON body of an operation
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes a response of an operation
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a request or response body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is the subset of JSON Schema used by the generated document
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// NewDocument generates an OpenAPI document for the given operations
+func NewDocument(title, version string, operations []Operation) *Document {
+	gen := &schemaGenerator{schemas: map[string]*Schema{}}
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    Info{Title: title, Version: version},
+		Paths:   map[string]PathItem{},
+	}
+	errorSchema := gen.schemaFor(reflect.TypeOf(ErrorResponse{}))
+
+	for _, op := range operations {
+		path, params := openAPIPath(op.Path)
+		for _, q := range op.Query {
+			qType := q.Type
+			if qType == "" {
+				qType = "string"
+			}
+			params = append(params, Parameter{Name: q.Name, In: "query", Description: q.Description, Schema: &Schema{Type: qType}})
+		}
+
+		out := &OpenAPIOperation{
+			OperationID: op.OperationID,
+			Summary:     op.Summary,
+			Tags:        op.Tags,
+			Parameters:  params,
+			Responses: map[string]Response{
+				"default": {
+					Description: "Error",
+					Content:     map[string]MediaType{"application/json": {Schema: errorSchema}},
+				},
+			},
+		}
+		if op.Request != nil {
+			out.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{"application/json": {Schema: gen.schemaFor(reflect.TypeOf(op.Request))}},
+			}
+		}
+		success := Response{Description: "OK"}
+		if op.Response != nil {
+			contentType := "application/json"
+			if op.Stream {
+				contentType = "text/event-stream"
+				success.Description = "Server-Sent Events stream; the schema describes the data of each event"
+			}
+			success.Content = map[string]MediaType{contentType: {Schema: gen.schemaFor(reflect.TypeOf(op.Response))}}
+		}
+		out.Responses["200"] = success
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = PathItem{}
+		}
+		doc.Paths[path][strings.ToLower(op.Method)] = out
+	}
+
+	doc.Components.Schemas = gen.schemas
+	return doc
+}
+
+// Handler serves the document as JSON
+func (d *Document) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, d)
+	}
+}
+
+// openAPIPath converts gin path parameters (":name") to OpenAPI templates ("{name}")
+func openAPIPath(path string) (string, []Parameter) {
+	var params []Parameter
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			name := segment[1:]
+			segments[i] = "{" + name + "}"
+			params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// openAPISchemaTyper is implemented by Kubernetes types with a custom JSON representation
+// (metav1.Time, resource.Quantity, intstr.IntOrString, ...)
+type openAPISchemaTyper interface {
+	OpenAPISchemaType() []string
+	OpenAPISchemaFormat() string
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	schemaTyperType   = reflect.TypeOf((*openAPISchemaTyper)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	packagePath       = reflect.TypeOf(Schema{}).PkgPath()
+)
+
+type schemaGenerator struct {
+	schemas map[string]*Schema
+}
+
+func (g *schemaGenerator) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case reflect.PointerTo(t).Implements(schemaTyperType):
+		typer := reflect.New(t).Interface().(openAPISchemaTyper)
+		types := typer.OpenAPISchemaType()
+		if len(types) != 1 {
+			return &Schema{}
+		}
+		return &Schema{Type: types[0], Format: typer.OpenAPISchemaFormat()}
+	case reflect.PointerTo(t).Implements(jsonMarshalerType):
+		// Custom marshalling (unstructured objects, raw JSON) cannot be described by reflection
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := schemaName(t)
+		if _, ok := g.schemas[name]; !ok {
+			// Register before recursing so self-referencing types terminate
+			g.schemas[name] = &Schema{}
+			*g.schemas[name] = *g.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	default:
+		return &Schema{}
+	}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.addFields(schema, t)
+	return schema
+}
+
+func (g *schemaGenerator) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		// Embedded structs without a JSON name are flattened, as encoding/json does
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			g.addFields(schema, fieldType)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = g.schemaFor(field.Type)
+		if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Pointer {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// schemaName returns the component name of a type. Types of this package use their plain name,
+// others are qualified by package path to avoid clashes between CRD API groups.
+func schemaName(t reflect.Type) string {
+	if t.PkgPath() == packagePath {
+		return t.Name()
+	}
+	return strings.ReplaceAll(t.PkgPath(), "/", ".") + "." + t.Name()
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type selfReferencing struct {
+	Name     string             `json:"name"`
+	Children []*selfReferencing `json:"children,omitempty"`
+}
+
+func TestNewDocument(t *testing.T) {
+	doc := NewDocument("Test API", "1.0.0", []Operation{
+		{
+			Method:      http.MethodPost,
+			Path:        "/api/rollouts/:namespace/:name/pin",
+			OperationID: "pinVersion",
+			Query:       []QueryParameter{{Name: "wait", Type: "boolean"}},
+			Request:     PinRequest{},
+			Response:    RolloutResponse{},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/api/rollouts/:namespace/:name",
+			OperationID: "getRollout",
+			Response:    RolloutDetailResponse{},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/api/rollouts/:namespace/:name/pods/logs",
+			OperationID: "streamPodLogs",
+			Response:    LogLine{},
+			Stream:      true,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/api/tree",
+			OperationID: "getTree",
+			Response:    selfReferencing{},
+		},
+	})
+
+	t.Run("Path parameters are converted to templates", func(t *testing.T) {
+		item, ok := doc.Paths["/api/rollouts/{namespace}/{name}/pin"]
+		require.True(t, ok)
+		op := item["post"]
+		require.NotNil(t, op)
+		assert.Equal(t, "pinVersion", op.OperationID)
+
+		require.Len(t, op.Parameters, 3)
+		assert.Equal(t, Parameter{Name: "namespace", In: "path", Required: true, Schema: &Schema{Type: "string"}}, op.Parameters[0])
+		assert.Equal(t, "name", op.Parameters[1].Name)
+		assert.Equal(t, "query", op.Parameters[2].In)
+		assert.Equal(t, "boolean", op.Parameters[2].Schema.Type)
+	})
+
+	t.Run("Request and response bodies reference component schemas", func(t *testing.T) {
+		op := doc.Paths["/api/rollouts/{namespace}/{name}/pin"]["post"]
+		require.NotNil(t, op.RequestBody)
+		assert.Equal(t, "#/components/schemas/PinRequest", op.RequestBody.Content["application/json"].Schema.Ref)
+		assert.Equal(t, "#/components/schemas/RolloutResponse", op.Responses["200"].Content["application/json"].Schema.Ref)
+		assert.Equal(t, "#/components/schemas/ErrorResponse", op.Responses["default"].Content["application/json"].Schema.Ref)
+
+		pin := doc.Components.Schemas["PinRequest"]
+		require.NotNil(t, pin)
+		assert.Equal(t, "string", pin.Properties["version"].Type)
+		assert.Equal(t, []string{"explanation"}, pin.Required)
+	})
+
+	t.Run("Upstream types are qualified and Kubernetes times are strings", func(t *testing.T) {
+		name := "github.com.kuberik.rollout-controller.api.v1alpha1.Rollout"
+		rollout := doc.Components.Schemas[name]
+		require.NotNil(t, rollout)
+		// ObjectMeta is embedded inline, so metadata is a property rather than flattened fields
+		require.Contains(t, rollout.Properties, "metadata")
+		require.Contains(t, rollout.Properties, "kind")
+
+		meta := doc.Components.Schemas["k8s.io.apimachinery.pkg.apis.meta.v1.ObjectMeta"]
+		require.NotNil(t, meta)
+		assert.Equal(t, &Schema{Type: "string", Format: "date-time"}, meta.Properties["creationTimestamp"])
+	})
+
+	t.Run("Streams use the event stream content type", func(t *testing.T) {
+		op := doc.Paths["/api/rollouts/{namespace}/{name}/pods/logs"]["get"]
+		require.NotNil(t, op)
+		assert.Contains(t, op.Responses["200"].Content, "text/event-stream")
+	})
+
+	t.Run("Self-referencing types terminate", func(t *testing.T) {
+		tree := doc.Components.Schemas["selfReferencing"]
+		require.NotNil(t, tree)
+		assert.Equal(t, "#/components/schemas/selfReferencing", tree.Properties["children"].Items.Ref)
+	})
+
+	_, err := json.Marshal(doc)
+	require.NoError(t, err)
+}
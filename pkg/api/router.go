@@ -0,0 +1,156 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// VersionHeader reports the API version that served a request
+	VersionHeader = "X-API-Version"
+	// DeprecationHeader marks responses of unversioned paths, whose successor is linked in the
+	// Link header with rel="successor-version"
+	DeprecationHeader = "Deprecation"
+
+	versionContextKey = "api_version"
+)
+
+// VersionedRouter serves each API version under its own prefix (/api/v1, /api/v2, ...).
+// A version can inherit the routes of an earlier one so that introducing a breaking response
+// shape only requires new handlers for the endpoints that change, while older versions keep
+// being served unchanged.
+type VersionedRouter struct {
+	group    *gin.RouterGroup
+	versions []*Version
+	// legacy is the version also served without a version prefix
+	legacy *Version
+}
+
+// Version collects the routes of a single API version
+type Version struct {
+	name     string
+	inherits *Version
+	routes   []route
+}
+
+type route struct {
+	method   string
+	path     string
+	handlers []gin.HandlerFunc
+}
+
+// NewVersionedRouter creates a router registering versions below the given group
+func NewVersionedRouter(group *gin.RouterGroup) *VersionedRouter {
+	return &VersionedRouter{group: group}
+}
+
+// Version declares a new API version. When inherits is set, every route of that version that is
+// not redefined here is also served by the new version.
+func (r *VersionedRouter) Version(name string, inherits *Version) *Version {
+	v := &Version{name: name, inherits: inherits}
+	r.versions = append(r.versions, v)
+	return v
+}
+
+// Legacy serves the routes of v also without a version prefix (/api/rollouts), as they were before
+// the API was versioned, so clients that have not moved to a versioned path keep working. These
+// responses are marked deprecated and link to the versioned path.
+func (r *VersionedRouter) Legacy(v *Version) {
+	r.legacy = v
+}
+
+// Register adds the routes of all declared versions to the underlying group.
+// It must be called once, after all routes have been declared.
+func (r *VersionedRouter) Register() {
+	for _, v := range r.versions {
+		group := r.group.Group("/"+v.name, versionMiddleware(v.name))
+		for _, rt := range v.allRoutes() {
+			group.Handle(rt.method, rt.path, rt.handlers...)
+		}
+	}
+	if r.legacy != nil {
+		group := r.group.Group("", legacyMiddleware(r.group.BasePath(), r.legacy.name))
+		for _, rt := range r.legacy.allRoutes() {
+			group.Handle(rt.method, rt.path, rt.handlers...)
+		}
+	}
+}
+
+// Name returns the version name, e.g. "v1"
+func (v *Version) Name() string {
+	return v.name
+}
+
+// Handle declares a route of this version
+func (v *Version) Handle(method, path string, handlers ...gin.HandlerFunc) {
+	v.routes = append(v.routes, route{method: method, path: path, handlers: handlers})
+}
+
+// GET declares a GET route of this version
+func (v *Version) GET(path string, handlers ...gin.HandlerFunc) {
+	v.Handle(http.MethodGet, path, handlers...)
+}
+
+// POST declares a POST route of this version
+func (v *Version) POST(path string, handlers ...gin.HandlerFunc) {
+	v.Handle(http.MethodPost, path, handlers...)
+}
+
+// PUT declares a PUT route of this version
+func (v *Version) PUT(path string, handlers ...gin.HandlerFunc) {
+	v.Handle(http.MethodPut, path, handlers...)
+}
+
+// PATCH declares a PATCH route of this version
+func (v *Version) PATCH(path string, handlers ...gin.HandlerFunc) {
+	v.Handle(http.MethodPatch, path, handlers...)
+}
+
+// DELETE declares a DELETE route of this version
+func (v *Version) DELETE(path string, handlers ...gin.HandlerFunc) {
+	v.Handle(http.MethodDelete, path, handlers...)
+}
+
+// allRoutes returns the routes of this version followed by inherited routes it does not override
+func (v *Version) allRoutes() []route {
+	seen := map[string]bool{}
+	var routes []route
+	for current := v; current != nil; current = current.inherits {
+		for _, rt := range current.routes {
+			key := rt.method + " " + rt.path
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			routes = append(routes, rt)
+		}
+	}
+	return routes
+}
+
+func versionMiddleware(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(versionContextKey, name)
+		c.Header(VersionHeader, name)
+		c.Next()
+	}
+}
+
+func legacyMiddleware(basePath, name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(versionContextKey, name)
+		c.Header(VersionHeader, name)
+		c.Header(DeprecationHeader, "true")
+		successor := basePath + "/" + name + strings.TrimPrefix(c.Request.URL.Path, basePath)
+		c.Header("Link", "<"+successor+`>; rel="successor-version"`)
+		c.Next()
+	}
+}
+
+// VersionFromContext returns the API version serving the request, or empty string outside
+// versioned routes
+func VersionFromContext(c *gin.Context) string {
+	return c.GetString(versionContextKey)
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionedRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	versions := NewVersionedRouter(r.Group("/api"))
+	v1 := versions.Version("v1", nil)
+	v1.GET("/rollouts", func(c *gin.Context) { c.String(http.StatusOK, "v1 rollouts") })
+	v1.GET("/tags", func(c *gin.Context) { c.String(http.StatusOK, "v1 tags from "+VersionFromContext(c)) })
+	v2 := versions.Version("v2", v1)
+	v2.GET("/rollouts", func(c *gin.Context) { c.String(http.StatusOK, "v2 rollouts") })
+	versions.Register()
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Each version serves its own handlers", func(t *testing.T) {
+		w := get("/api/v1/rollouts")
+		assert.Equal(t, "v1 rollouts", w.Body.String())
+		assert.Equal(t, "v1", w.Header().Get(VersionHeader))
+
+		w = get("/api/v2/rollouts")
+		assert.Equal(t, "v2 rollouts", w.Body.String())
+		assert.Equal(t, "v2", w.Header().Get(VersionHeader))
+	})
+
+	t.Run("Unchanged routes are inherited", func(t *testing.T) {
+		w := get("/api/v2/tags")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "v1 tags from v2", w.Body.String())
+	})
+
+	t.Run("Unversioned paths are not served", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, get("/api/rollouts").Code)
+	})
+}
+
+func TestVersionedRouterLegacy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	versions := NewVersionedRouter(r.Group("/api"))
+	v1 := versions.Version("v1", nil)
+	v1.GET("/rollouts/:name", func(c *gin.Context) { c.String(http.StatusOK, c.Param("name")+" from "+VersionFromContext(c)) })
+	v2 := versions.Version("v2", v1)
+	v2.GET("/tags", func(c *gin.Context) { c.String(http.StatusOK, "v2 tags") })
+	versions.Legacy(v1)
+	versions.Register()
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Unversioned paths serve the legacy version as deprecated", func(t *testing.T) {
+		w := get("/api/rollouts/web?view=summary")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "web from v1", w.Body.String())
+		assert.Equal(t, "v1", w.Header().Get(VersionHeader))
+		assert.Equal(t, "true", w.Header().Get(DeprecationHeader))
+		assert.Equal(t, `</api/v1/rollouts/web>; rel="successor-version"`, w.Header().Get("Link"))
+	})
+
+	t.Run("Versioned paths are not deprecated", func(t *testing.T) {
+		w := get("/api/v1/rollouts/web")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get(DeprecationHeader))
+	})
+
+	t.Run("Routes of later versions are not served unversioned", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, get("/api/tags").Code)
+	})
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NDJSONContentType is the media type of newline-delimited JSON responses
+const NDJSONContentType = "application/x-ndjson"
+
+// Kinds of the StreamEntry lines of a streamed rollout list
+const (
+	StreamKindRollout          = "Rollout"
+	StreamKindRolloutSummary   = "RolloutSummary"
+	StreamKindImagePolicy      = "ImagePolicy"
+	StreamKindImageRepository  = "ImageRepository"
+	StreamKindKustomization    = "Kustomization"
+	StreamKindOCIRepository    = "OCIRepository"
+	StreamKindSkippedNamespace = "SkippedNamespace"
+	// StreamKindColumn lines define the extra columns before any rollout, StreamKindColumnValues
+	// lines carry their values after the rollouts
+	StreamKindColumn       = "Column"
+	StreamKindColumnValues = "ColumnValues"
+)
+
+// StreamEntry is one line of a newline-delimited JSON list response: a single item of the list
+// and the kind that tells the client how to decode it
+type StreamEntry struct {
+	Kind   string `json:"kind"`
+	Object any    `json:"object"`
+}
+
+// WantsNDJSON reports whether a request asks for a newline-delimited JSON response, either with
+// ?format=ndjson or by accepting application/x-ndjson explicitly. Wildcard Accept headers keep the
+// single JSON document.
+func WantsNDJSON(c *gin.Context) bool {
+	if c.Query("format") == "ndjson" {
+		return true
+	}
+	for _, accepted := range strings.Split(c.GetHeader("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted)); err == nil && mediaType == NDJSONContentType {
+			return true
+		}
+	}
+	return false
+}
+
+// NDJSONWriter streams a list response one StreamEntry per line, flushing every line so clients
+// render items as they arrive and the server never holds the whole marshaled list in memory
+type NDJSONWriter struct {
+	writer  gin.ResponseWriter
+	encoder *json.Encoder
+}
+
+// NewNDJSONWriter starts a 200 newline-delimited JSON response
+func NewNDJSONWriter(c *gin.Context) *NDJSONWriter {
+	c.Header("Content-Type", NDJSONContentType)
+	c.Status(http.StatusOK)
+	encoder := json.NewEncoder(c.Writer)
+	encoder.SetEscapeHTML(false)
+	return &NDJSONWriter{writer: c.Writer, encoder: encoder}
+}
+
+// Write sends object as one line of the given kind. It fails once the client has gone away.
+func (w *NDJSONWriter) Write(kind string, object any) error {
+	if err := w.encoder.Encode(StreamEntry{Kind: kind, Object: object}); err != nil {
+		return err
+	}
+	w.writer.Flush()
+	return nil
+}
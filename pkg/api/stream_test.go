@@ -0,0 +1,55 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNDJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for accept, wants := range map[string]bool{
+		"":                     false,
+		"*/*":                  false,
+		"application/json":     false,
+		"application/x-ndjson": true,
+		"application/json, application/x-ndjson;q=0.9": true,
+	} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/rollouts", nil)
+		c.Request.Header.Set("Accept", accept)
+		assert.Equal(t, wants, WantsNDJSON(c), accept)
+	}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/rollouts?format=ndjson", nil)
+	assert.True(t, WantsNDJSON(c))
+
+	w := httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	stream := NewNDJSONWriter(c)
+	require.NoError(t, stream.Write(StreamKindRolloutSummary, RolloutSummary{Namespace: "shop", Name: "api"}))
+	require.NoError(t, stream.Write(StreamKindRolloutSummary, RolloutSummary{Namespace: "shop", Name: "<web>"}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, NDJSONContentType, w.Header().Get("Content-Type"))
+	assert.True(t, w.Flushed)
+
+	var names []string
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var entry struct {
+			Kind   string         `json:"kind"`
+			Object RolloutSummary `json:"object"`
+		}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		assert.Equal(t, StreamKindRolloutSummary, entry.Kind)
+		names = append(names, entry.Object.Name)
+	}
+	assert.Equal(t, []string{"api", "<web>"}, names)
+}
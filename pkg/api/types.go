@@ -0,0 +1,998 @@
+package api
+
+import (
+	"time"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2"
+	imagereflectorv1beta2 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
+	openkruisev1alpha1 "github.com/kuberik/openkruise-controller/api/v1alpha1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/alertmanager"
+	"github.com/kuberik/rollout-dashboard/pkg/columns"
+	"github.com/kuberik/rollout-dashboard/pkg/gitops"
+	"github.com/kuberik/rollout-dashboard/pkg/gitprovider"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/kuberik/rollout-dashboard/pkg/links"
+	"github.com/kuberik/rollout-dashboard/pkg/oci"
+	"github.com/kuberik/rollout-dashboard/pkg/oncall"
+	"github.com/kuberik/rollout-dashboard/pkg/prometheus"
+	"github.com/kuberik/rollout-dashboard/pkg/registryhook"
+	"github.com/kuberik/rollout-dashboard/pkg/releasenotes"
+	"github.com/kuberik/rollout-dashboard/pkg/search"
+	"github.com/kuberik/rollout-dashboard/pkg/streamhealth"
+	kruiserolloutv1beta1 "github.com/openkruise/kruise-rollout-api/rollouts/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Request and response bodies of the HTTP endpoints. Responses embedding upstream CRDs serialize
+// them unchanged; the trimmed models in models.go are used where a summary view is requested.
+
+// HealthResponse is returned by GET /api/health
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+// RolloutListResponse is returned by GET /api/rollouts
+type RolloutListResponse struct {
+	Rollouts          *rolloutv1alpha1.RolloutList               `json:"rollouts"`
+	ImagePolicies     *imagereflectorv1beta2.ImagePolicyList     `json:"imagePolicies"`
+	ImageRepositories *imagereflectorv1beta2.ImageRepositoryList `json:"imageRepositories"`
+	Kustomizations    *kustomizev1.KustomizationList             `json:"kustomizations"`
+	OCIRepositories   *sourcev1.OCIRepositoryList                `json:"ociRepositories"`
+	// SkippedNamespaces is set when the caller may not list rollouts cluster-wide: the listing then
+	// covers the namespaces the caller can access and names the ones left out
+	SkippedNamespaces []kubernetes.SkippedNamespace `json:"skippedNamespaces,omitempty"`
+	// Columns are the extra columns configured with COLUMNS_CONFIG, ColumnValues their values by
+	// namespace/name of the rollout
+	Columns      []columns.Definition         `json:"columns,omitempty"`
+	ColumnValues map[string]map[string]string `json:"columnValues,omitempty"`
+}
+
+// RolloutSummaryListResponse is returned by GET /api/rollouts?view=summary
+type RolloutSummaryListResponse struct {
+	Rollouts          []RolloutSummary              `json:"rollouts"`
+	SkippedNamespaces []kubernetes.SkippedNamespace `json:"skippedNamespaces,omitempty"`
+	Columns           []columns.Definition          `json:"columns,omitempty"`
+	ColumnValues      map[string]map[string]string  `json:"columnValues,omitempty"`
+}
+
+// RolloutColumnValues is a streamed line with the extra column values of one rollout
+type RolloutColumnValues struct {
+	Namespace string            `json:"namespace"`
+	Name      string            `json:"name"`
+	Values    map[string]string `json:"values"`
+}
+
+// FleetSummaryResponse is returned by GET /api/summary
+type FleetSummaryResponse struct {
+	FleetSummary
+	SkippedNamespaces []kubernetes.SkippedNamespace `json:"skippedNamespaces,omitempty"`
+}
+
+// RolloutDetailResponse is returned by GET /api/rollouts/{namespace}/{name}
+type RolloutDetailResponse struct {
+	Rollout           *rolloutv1alpha1.Rollout            `json:"rollout"`
+	Kustomizations    *kustomizev1.KustomizationList      `json:"kustomizations"`
+	HelmReleases      *helmv2.HelmReleaseList             `json:"helmReleases"`
+	OCIRepositories   *sourcev1.OCIRepositoryList         `json:"ociRepositories"`
+	RolloutGates      *rolloutv1alpha1.RolloutGateList    `json:"rolloutGates"`
+	Environment       *envv1alpha1.Environment            `json:"environment"`
+	KruiseRollout     *kruiserolloutv1beta1.Rollout       `json:"kruiseRollout"`
+	RolloutTests      *openkruisev1alpha1.RolloutTestList `json:"rolloutTests"`
+	ImageRepoScanTime string                              `json:"imageRepoScanTime"`
+	// BlueGreen is only set when the Kruise rollout uses the blue/green strategy
+	BlueGreen *BlueGreenStatus `json:"blueGreen,omitempty"`
+	// GateDependencies lists the gates that wait for other environments and the upstream rollouts
+	GateDependencies []GateDependency `json:"gateDependencies,omitempty"`
+	// Migrations lists the migration Jobs of the Kustomizations with their runs per version
+	Migrations []Migration `json:"migrations,omitempty"`
+	// FluxStatus rolls up the readiness of the Kustomizations, HelmReleases, OCIRepositories and
+	// ImagePolicy behind the rollout
+	FluxStatus *FluxStatus `json:"fluxStatus,omitempty"`
+	// Ownership is only set when the rollout has a team or owner annotation
+	Ownership *Ownership `json:"ownership,omitempty"`
+	// Links are the rollout's quick links, e.g. its runbook, dashboard and logs
+	Links []links.Link `json:"links,omitempty"`
+}
+
+// RolloutDetailViewResponse is returned by GET /api/v2/rollouts/{namespace}/{name}: the rollout
+// details with trimmed models instead of the raw resources of RolloutDetailResponse
+type RolloutDetailViewResponse struct {
+	Rollout           *RolloutView     `json:"rollout"`
+	Kustomizations    []Kustomization  `json:"kustomizations"`
+	HelmReleases      []HelmRelease    `json:"helmReleases"`
+	OCIRepositories   []OCIRepository  `json:"ociRepositories"`
+	RolloutGates      []RolloutGate    `json:"rolloutGates"`
+	Environment       *Environment     `json:"environment"`
+	KruiseRollout     *KruiseRollout   `json:"kruiseRollout"`
+	RolloutTests      []RolloutTest    `json:"rolloutTests"`
+	ImageRepoScanTime string           `json:"imageRepoScanTime"`
+	BlueGreen         *BlueGreenStatus `json:"blueGreen,omitempty"`
+	GateDependencies  []GateDependency `json:"gateDependencies,omitempty"`
+	Migrations        []Migration      `json:"migrations,omitempty"`
+	FluxStatus        *FluxStatus      `json:"fluxStatus,omitempty"`
+	Ownership         *Ownership       `json:"ownership,omitempty"`
+	Links             []links.Link     `json:"links,omitempty"`
+}
+
+// Ownership is who owns a rollout and who to escalate to during a bad deploy
+type Ownership struct {
+	Team  string `json:"team,omitempty"`
+	Owner string `json:"owner,omitempty"`
+	// OnCall is the team's contacts and current on-call, unset when the directory does not know the team
+	OnCall *oncall.OnCall `json:"onCall,omitempty"`
+}
+
+// EnvironmentsResponse lists the environments in a rollout's namespace
+type EnvironmentsResponse struct {
+	Environments *envv1alpha1.EnvironmentList `json:"environments"`
+}
+
+// TestGatesResponse shows how the RolloutTests of a Kruise rollout gate its steps
+type TestGatesResponse struct {
+	kubernetes.TestGates
+}
+
+// TestHistoryResponse lists the runs of the RolloutTests of a Kruise rollout across versions
+type TestHistoryResponse struct {
+	Tests []kubernetes.TestHistory `json:"tests"`
+}
+
+// TestReportResponse is the JUnit report of a run of a RolloutTest
+type TestReportResponse struct {
+	kubernetes.TestReport
+}
+
+// RolloutTestRerunResponse is returned when a RolloutTest was reset to run again. DeletedJobs are
+// the Jobs of its previous run.
+type RolloutTestRerunResponse struct {
+	RolloutTest *openkruisev1alpha1.RolloutTest `json:"rolloutTest"`
+	DeletedJobs []string                        `json:"deletedJobs"`
+}
+
+// GateExplanationResponse explains what holds the next version of a rollout
+type GateExplanationResponse struct {
+	kubernetes.GateExplanation
+}
+
+// BakeProgressResponse is the bake progress of a rollout's current deployment
+type BakeProgressResponse struct {
+	kubernetes.BakeProgress
+}
+
+// PendingVersionsResponse lists the release candidates of a rollout waiting to be deployed
+type PendingVersionsResponse struct {
+	kubernetes.PendingVersions
+}
+
+// RolloutTestsResponse lists the RolloutTests of a Kruise rollout
+type RolloutTestsResponse struct {
+	RolloutTests  *openkruisev1alpha1.RolloutTestList `json:"rolloutTests"`
+	KruiseRollout *kruiserolloutv1beta1.Rollout       `json:"kruiseRollout"`
+}
+
+// RolloutResponse wraps a rollout updated by an action
+type RolloutResponse struct {
+	Rollout *rolloutv1alpha1.Rollout `json:"rollout"`
+}
+
+// RolloutSpecRequest documents the body changing the editable spec fields of a rollout. The body
+// is parsed by kubernetes.ParseRolloutSpecPatch, so fields left out keep their value and null
+// clears them.
+type RolloutSpecRequest struct {
+	BakeTime            *string                                    `json:"bakeTime,omitempty"`
+	DeployTimeout       *string                                    `json:"deployTimeout,omitempty"`
+	HealthCheckSelector *rolloutv1alpha1.HealthCheckSelectorConfig `json:"healthCheckSelector,omitempty"`
+	ReleasesImagePolicy *corev1.LocalObjectReference               `json:"releasesImagePolicy,omitempty"`
+}
+
+// RolloutSpecResponse is a rollout after its spec was changed, or would be with DryRun, and the
+// fields that changed
+type RolloutSpecResponse struct {
+	Rollout *rolloutv1alpha1.Rollout `json:"rollout"`
+	DryRun  bool                     `json:"dryRun"`
+	Changed []string                 `json:"changed"`
+}
+
+// DeleteRolloutResponse lists the objects deleted with a rollout, or with DryRun those that would
+// be
+type DeleteRolloutResponse struct {
+	DryRun  bool                        `json:"dryRun"`
+	Cascade bool                        `json:"cascade"`
+	Targets []kubernetes.DeletionTarget `json:"targets"`
+}
+
+// HelmReleaseResponse wraps a HelmRelease updated by an action
+type HelmReleaseResponse struct {
+	HelmRelease *helmv2.HelmRelease `json:"helmRelease"`
+}
+
+// FluxSuspendResponse identifies a Flux object suspended or resumed by an action
+type FluxSuspendResponse struct {
+	Object    ResourceRef `json:"object"`
+	Suspended bool        `json:"suspended"`
+}
+
+// BakeExtensionResponse is returned when the bake of the current deployment was extended
+type BakeExtensionResponse struct {
+	Rollout       *rolloutv1alpha1.Rollout  `json:"rollout"`
+	BakeExtension *kubernetes.BakeExtension `json:"bakeExtension"`
+	// BakeEndsAt is when the extended bake ends, unless it has not started yet
+	BakeEndsAt *time.Time `json:"bakeEndsAt,omitempty"`
+}
+
+// VersionChangeResponse is returned by version-changing actions. Acknowledged is only set when
+// the request asked to wait for the controller.
+type VersionChangeResponse struct {
+	Rollout      *rolloutv1alpha1.Rollout `json:"rollout"`
+	Acknowledged *bool                    `json:"acknowledged,omitempty"`
+}
+
+// GitOpsChangeResponse is returned with 202 Accepted when a version change of a rollout whose
+// Rollout resource lives in git was proposed as a pull or merge request instead of applied
+type GitOpsChangeResponse struct {
+	Proposal *gitops.Proposal `json:"proposal"`
+}
+
+// KruiseRolloutResponse wraps a Kruise rollout updated by an action. Rollout is nil when the
+// rollout is driven by Argo Rollouts, whose state is in Progressive.
+type KruiseRolloutResponse struct {
+	Rollout     *kruiserolloutv1beta1.Rollout  `json:"rollout"`
+	Progressive *kubernetes.ProgressiveRollout `json:"progressive,omitempty"`
+}
+
+// ProgressiveRolloutResponse is the state of the Kruise or Argo rollout driving a rollout's
+// workload, with the workload's pods when they were requested
+type ProgressiveRolloutResponse struct {
+	Progressive *kubernetes.ProgressiveRollout `json:"progressive"`
+	Pods        []Pod                          `json:"pods,omitempty"`
+}
+
+// StepRequest jumps a progressive rollout to a 1-based step
+type StepRequest struct {
+	Step int32 `json:"step" binding:"required"`
+}
+
+// BlueGreenResponse is returned by the blue/green endpoints of a Kruise rollout
+type BlueGreenResponse struct {
+	BlueGreen *BlueGreenStatus `json:"blueGreen"`
+}
+
+// ReadinessResponse explains why the pods of a rollout are not Ready
+type ReadinessResponse struct {
+	Readiness *kubernetes.ReadinessSummary `json:"readiness"`
+}
+
+// ChannelStatus describes where a channel tag currently points
+type ChannelStatus struct {
+	Name   string `json:"name"`
+	Digest string `json:"digest,omitempty"`
+	// Release is the rollout release with the channel's digest, if the controller knows it
+	Release *VersionInfo `json:"release,omitempty"`
+	// Deployed reports whether Release is the currently deployed version
+	Deployed bool `json:"deployed"`
+	// Tracking reports whether the rollout follows this channel automatically
+	Tracking bool `json:"tracking"`
+	// InSync reports whether a tracking rollout is pinned to the channel's current release
+	InSync bool   `json:"inSync"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ChannelsResponse lists the channels of a rollout's image repository
+type ChannelsResponse struct {
+	// Tracking is the channel the rollout follows, empty when it does not follow one
+	Tracking string          `json:"tracking,omitempty"`
+	Channels []ChannelStatus `json:"channels"`
+}
+
+// ChannelResponse describes a single channel
+type ChannelResponse struct {
+	Channel ChannelStatus `json:"channel"`
+}
+
+// TrackChannelRequest makes a rollout follow a channel, or stops following when Channel is nil
+type TrackChannelRequest struct {
+	Channel *string `json:"channel"`
+}
+
+// TrackChannelResponse is returned after changing the tracked channel
+type TrackChannelResponse struct {
+	Rollout *rolloutv1alpha1.Rollout `json:"rollout"`
+	Channel *ChannelStatus           `json:"channel,omitempty"`
+}
+
+// PinRequest pins the rollout to a version, or clears the pin when Version is nil
+type PinRequest struct {
+	Version     *string `json:"version"`
+	Explanation string  `json:"explanation"`
+}
+
+// ForceDeployRequest asks the controller to deploy a version regardless of ordering
+type ForceDeployRequest struct {
+	Version string `json:"version" binding:"required"`
+	Message string `json:"message"`
+}
+
+// BypassGatesRequest allows a version to be deployed without waiting for gates
+type BypassGatesRequest struct {
+	Version string `json:"version" binding:"required"`
+}
+
+// RolloutGateRequest creates or replaces a manual RolloutGate of a rollout. Name is only read when
+// creating the gate. Passing defaults to true and no AllowedVersions allows every version.
+type RolloutGateRequest struct {
+	Name            string    `json:"name,omitempty"`
+	Passing         *bool     `json:"passing,omitempty"`
+	AllowedVersions *[]string `json:"allowedVersions,omitempty"`
+	// Description explains why the gate exists, e.g. "holiday freeze"
+	Description string `json:"description,omitempty"`
+}
+
+// RolloutGateResponse is a RolloutGate created or updated in the dashboard
+type RolloutGateResponse struct {
+	Gate RolloutGate `json:"gate"`
+}
+
+// ChangeVersionRequest pins or force-deploys a version in a single update
+type ChangeVersionRequest struct {
+	Version string `json:"version" binding:"required"`
+	Pin     bool   `json:"pin"`
+	Message string `json:"message"`
+	// ScheduleAt queues the change for a later time instead of applying it right away
+	ScheduleAt *time.Time `json:"scheduleAt,omitempty"`
+}
+
+// ScheduledRolloutChange is a version change scheduled for a rollout
+type ScheduledRolloutChange struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	kubernetes.ScheduledChange
+}
+
+// ScheduledChangeListResponse is returned by GET /api/scheduled-changes, soonest first
+type ScheduledChangeListResponse struct {
+	Changes           []ScheduledRolloutChange      `json:"changes"`
+	SkippedNamespaces []kubernetes.SkippedNamespace `json:"skippedNamespaces,omitempty"`
+}
+
+// MarkSuccessfulRequest marks the current deployment's bake as succeeded
+type MarkSuccessfulRequest struct {
+	Message string `json:"message"`
+}
+
+// ExtendBakeRequest extends the bake of the current deployment, e.g. to observe it longer before
+// it is promoted to the next environment
+type ExtendBakeRequest struct {
+	// Duration is added to the bake time, as a Go duration such as 30m
+	Duration string `json:"duration" binding:"required"`
+	// Version must be the current deployment's, unless empty
+	Version string `json:"version"`
+	Reason  string `json:"reason"`
+}
+
+// ContinueRequest continues a paused Kruise rollout step
+type ContinueRequest struct {
+	KuberikRolloutName string `json:"kuberikRolloutName"`
+}
+
+// RetryRequest retries or skips failed rollout tests. KruiseRolloutName is legacy and ignored.
+type RetryRequest struct {
+	KruiseRolloutName string `json:"kruiseRolloutName"`
+	TestAction        string `json:"testAction"`
+}
+
+// RetryResponse reports the retry mode that was requested
+type RetryResponse struct {
+	Status string `json:"status"`
+	Action string `json:"action"`
+}
+
+// ShareRequest creates a share link. TTL is a duration such as "24h" and defaults to a day.
+type ShareRequest struct {
+	TTL string `json:"ttl"`
+}
+
+// ShareResponse contains a link granting read-only access to the rollout's details and logs until
+// ExpiresAt. URL is the rollout detail endpoint with the token, relative to the dashboard.
+type ShareResponse struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// BatchRequest runs an action on several rollouts, e.g. to reconcile or unblock every rollout
+// affected by an outage
+type BatchRequest struct {
+	Items []BatchItem `json:"items" binding:"required"`
+}
+
+// BatchItem is one action on one rollout. Action is one of reconcile, unblock-failed, retry and
+// mark-successful; Params carries the action's request fields, e.g. withSource for reconcile,
+// testAction for retry and message for mark-successful.
+type BatchItem struct {
+	Namespace string            `json:"namespace" binding:"required"`
+	Name      string            `json:"name" binding:"required"`
+	Action    string            `json:"action" binding:"required"`
+	Params    map[string]string `json:"params,omitempty"`
+}
+
+// BatchResult is the outcome of one BatchItem: Status is the HTTP status the action's own endpoint
+// would have responded with, Code and Error are set when it failed
+type BatchResult struct {
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	Action    string    `json:"action"`
+	Status    int       `json:"status"`
+	Code      ErrorCode `json:"code,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// BatchResponse lists the results in the order of the request's items
+type BatchResponse struct {
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
+
+// ReconcileResponse is returned after Flux reconciliation was requested. Results holds the
+// outcome per resource keyed by "Kind/namespace/name".
+type ReconcileResponse struct {
+	Message          string                                `json:"message"`
+	PreviousScanTime string                                `json:"previousScanTime"`
+	Results          map[string]kubernetes.ReconcileResult `json:"results"`
+}
+
+// RegistryWebhookResult is the reconciliation requested for a rollout of a pushed image
+type RegistryWebhookResult struct {
+	Namespace string                                `json:"namespace"`
+	Name      string                                `json:"name"`
+	Results   map[string]kubernetes.ReconcileResult `json:"results,omitempty"`
+	Error     string                                `json:"error,omitempty"`
+}
+
+// RegistryWebhookResponse is returned to registries and CI announcing pushed tags
+type RegistryWebhookResponse struct {
+	Pushes   []registryhook.Push     `json:"pushes"`
+	Rollouts []RegistryWebhookResult `json:"rollouts"`
+}
+
+// ManifestValidationResponse reports whether the manifests of a release would be accepted by the
+// cluster, per Kustomization deploying the rollout
+type ManifestValidationResponse struct {
+	Version        string                               `json:"version"`
+	Valid          bool                                 `json:"valid"`
+	Kustomizations []kubernetes.KustomizationValidation `json:"kustomizations"`
+}
+
+// ManifestResponse contains the files of a release artifact keyed by path
+type ManifestResponse struct {
+	Files map[string]string `json:"files"`
+}
+
+// ComponentFiles lists the files of a component of a release artifact
+type ComponentFiles struct {
+	oci.Component
+	Files []string `json:"files"`
+}
+
+// ComponentsResponse lists the files of a release artifact by the components configured with the
+// rollout's components annotation
+type ComponentsResponse struct {
+	Version    string           `json:"version"`
+	Components []ComponentFiles `json:"components"`
+	// Unassigned are the files outside all components
+	Unassigned []string `json:"unassigned"`
+}
+
+// ComponentDiff lists the files of a component that changed between two releases
+type ComponentDiff struct {
+	oci.Component
+	Files []oci.FileDiff `json:"files"`
+}
+
+// ComponentDiffResponse compares two releases of an artifact component by component
+type ComponentDiffResponse struct {
+	From       string          `json:"from"`
+	To         string          `json:"to"`
+	Components []ComponentDiff `json:"components"`
+	// Unassigned are the changed files outside all components
+	Unassigned []oci.FileDiff `json:"unassigned"`
+}
+
+// ChangelogResponse lists the releases after From up to and including To, newest first
+type ChangelogResponse struct {
+	From    string               `json:"from"`
+	To      string               `json:"to"`
+	Entries []oci.ChangelogEntry `json:"entries"`
+	// Omitted counts the oldest releases left out of a changelog that grew too long
+	Omitted int `json:"omitted,omitempty"`
+}
+
+// ReleaseNotesResponse aggregates what the releases between two versions bring
+type ReleaseNotesResponse struct {
+	releasenotes.Notes
+}
+
+// CommitResponse is the commit a release was built from
+type CommitResponse struct {
+	Version string `json:"version"`
+	// Source and Revision are the release's org.opencontainers.image annotations naming the commit
+	Source   string              `json:"source"`
+	Revision string              `json:"revision"`
+	Commit   *gitprovider.Commit `json:"commit"`
+}
+
+// MediaTypeResponse contains the artifact type of a release
+type MediaTypeResponse struct {
+	MediaType string `json:"mediaType"`
+}
+
+// AnnotationsResponse contains the OCI manifest annotations of a release
+type AnnotationsResponse struct {
+	Annotations map[string]string `json:"annotations"`
+}
+
+// TagsResponse lists all tags of the rollout's image repository
+type TagsResponse struct {
+	Tags []string `json:"tags"`
+}
+
+// ManagedResourcesResponse lists the resources in a Kustomization inventory
+type ManagedResourcesResponse struct {
+	ManagedResources []kubernetes.ManagedResourceStatus `json:"managedResources"`
+	Debug            ManagedResourcesDebug              `json:"debug"`
+}
+
+// ResourceDescribeResponse is what kubectl describe shows about a resource in a Kustomization
+// inventory: its kstatus status, its conditions and its events, newest first
+type ResourceDescribeResponse struct {
+	Group      string      `json:"group,omitempty"`
+	Version    string      `json:"version"`
+	Kind       string      `json:"kind"`
+	Namespace  string      `json:"namespace,omitempty"`
+	Name       string      `json:"name"`
+	Status     string      `json:"status"`
+	Message    string      `json:"message,omitempty"`
+	Conditions []Condition `json:"conditions"`
+	Events     []Event     `json:"events"`
+}
+
+// ManagedResourcesDebug describes the Kustomization inventory the resources were read from
+type ManagedResourcesDebug struct {
+	HasInventory     bool     `json:"hasInventory"`
+	InventoryEntries []string `json:"inventoryEntries"`
+}
+
+// ManagedResourceSummaryListResponse is returned by the managed resources endpoint with view=summary
+type ManagedResourceSummaryListResponse struct {
+	ManagedResources []ManagedResource `json:"managedResources"`
+}
+
+// KustomizationTestResponse reports whether a Kustomization has an inventory
+type KustomizationTestResponse struct {
+	Name         string `json:"name"`
+	Namespace    string `json:"namespace"`
+	HasInventory bool   `json:"hasInventory"`
+}
+
+// PodInfo describes a pod owned by a ReplicaSet
+type PodInfo struct {
+	Name        string   `json:"name"`
+	Namespace   string   `json:"namespace"`
+	Phase       string   `json:"phase"`
+	Ready       bool     `json:"ready"`
+	Terminating bool     `json:"terminating"`
+	Restarts    int32    `json:"restarts"`
+	Node        string   `json:"node"`
+	Age         string   `json:"age"`
+	Images      []string `json:"images"`
+	Message     string   `json:"message,omitempty"`
+}
+
+// ReplicaSetInfo describes a ReplicaSet owned by a Deployment
+type ReplicaSetInfo struct {
+	Name            string    `json:"name"`
+	Namespace       string    `json:"namespace"`
+	Replicas        int32     `json:"replicas"`
+	ReadyReplicas   int32     `json:"readyReplicas"`
+	DesiredReplicas int32     `json:"desiredReplicas"`
+	IsCurrentRS     bool      `json:"isCurrentRS"`
+	Pods            []PodInfo `json:"pods"`
+}
+
+// DeploymentInfo contains the replica counts of a Deployment
+type DeploymentInfo struct {
+	Name              string `json:"name"`
+	Namespace         string `json:"namespace"`
+	Replicas          int32  `json:"replicas"`
+	ReadyReplicas     int32  `json:"readyReplicas"`
+	UpdatedReplicas   int32  `json:"updatedReplicas"`
+	AvailableReplicas int32  `json:"availableReplicas"`
+}
+
+// DeploymentChildrenResponse lists the ReplicaSets and pods of a Deployment
+type DeploymentChildrenResponse struct {
+	ReplicaSets []ReplicaSetInfo `json:"replicaSets"`
+	Deployment  DeploymentInfo   `json:"deployment"`
+}
+
+// ResourceRef identifies the resource a permission check was made against
+type ResourceRef struct {
+	APIGroup  string `json:"apiGroup"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// PermissionResponse reports whether the caller may perform a verb on a rollout
+type PermissionResponse struct {
+	Allowed  bool        `json:"allowed"`
+	Verb     string      `json:"verb"`
+	Resource ResourceRef `json:"resource"`
+}
+
+// MeResponse describes the caller as the API server authenticates them and which dashboard
+// actions they may perform in Namespace. Incomplete is set when the authorizer could not list all
+// of the caller's rules, so denied actions may still be allowed.
+type MeResponse struct {
+	Username       string          `json:"username"`
+	Groups         []string        `json:"groups"`
+	ServiceAccount bool            `json:"serviceAccount"`
+	Namespace      string          `json:"namespace"`
+	Capabilities   map[string]bool `json:"capabilities"`
+	Incomplete     bool            `json:"incomplete"`
+}
+
+// PermissionsResponse reports the caller's permissions for all rollout actions
+type PermissionsResponse struct {
+	Permissions map[string]bool `json:"permissions"`
+	Resource    ResourceRef     `json:"resource"`
+}
+
+// EnvironmentPermissionsResponse reports whether the caller may perform a verb on every rollout
+// of an environment, and which rollouts they lack permission for
+type EnvironmentPermissionsResponse struct {
+	Environment string        `json:"environment"`
+	Verb        string        `json:"verb"`
+	Allowed     bool          `json:"allowed"`
+	Rollouts    []ResourceRef `json:"rollouts"`
+	Denied      []ResourceRef `json:"denied"`
+}
+
+// PromoteRequest promotes the healthy version of a source environment's rollouts to the rollouts
+// of the target environment
+type PromoteRequest struct {
+	// From is the source environment, by default the one the target Environments' relationship names
+	From string `json:"from,omitempty"`
+	// Name limits the promotion to Environments with this deployment name (spec.name)
+	Name string `json:"name,omitempty"`
+	// Mode is pin (default) or force-deploy
+	Mode    string `json:"mode,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Reconcile reconciles the promoted rollouts' Flux objects in dependency order afterwards and
+	// waits for them to become ready
+	Reconcile bool `json:"reconcile,omitempty"`
+}
+
+// PromotionResult is the outcome of promoting to one rollout of the target environment: Status is
+// the HTTP status of the promotion, Code and Error are set when it failed
+type PromotionResult struct {
+	Target            ResourceRef               `json:"target"`
+	Source            *ResourceRef              `json:"source,omitempty"`
+	SourceEnvironment string                    `json:"sourceEnvironment,omitempty"`
+	Version           string                    `json:"version,omitempty"`
+	Chain             []kubernetes.PromotionHop `json:"chain,omitempty"`
+	Status            int                       `json:"status"`
+	Code              ErrorCode                 `json:"code,omitempty"`
+	Error             string                    `json:"error,omitempty"`
+}
+
+// PromoteResponse lists the promotions to every rollout of the environment
+type PromoteResponse struct {
+	Environment string            `json:"environment"`
+	Promoted    int               `json:"promoted"`
+	Failed      int               `json:"failed"`
+	Results     []PromotionResult `json:"results"`
+	// Reconcile is the outcome of reconciling the promoted rollouts, when requested
+	Reconcile *SyncSummary `json:"reconcile,omitempty"`
+}
+
+// OverridesKind is the kind of OverridesDocument
+const OverridesKind = "EnvironmentOverrides"
+
+// OverridesDocument is a declarative export of the pins and overrides of an environment's
+// rollouts, which can be applied again later, e.g. after restoring a cluster, or to another
+// environment to clone it
+type OverridesDocument struct {
+	Kind        string                        `json:"kind"`
+	Environment string                        `json:"environment"`
+	ExportedAt  *time.Time                    `json:"exportedAt,omitempty"`
+	Rollouts    []kubernetes.RolloutOverrides `json:"rollouts"`
+}
+
+// OverridesResult is the outcome of applying the overrides of a document entry to a rollout
+type OverridesResult struct {
+	// Target is unset when no rollout of the environment matches the entry
+	Target  *ResourceRef                `json:"target,omitempty"`
+	Entry   string                      `json:"entry"`
+	Changes []kubernetes.OverrideChange `json:"changes"`
+	Applied bool                        `json:"applied"`
+	Status  int                         `json:"status"`
+	Code    ErrorCode                   `json:"code,omitempty"`
+	Error   string                      `json:"error,omitempty"`
+}
+
+// OverridesApplyResponse lists the changes applying an OverridesDocument made, or with dryRun
+// would make, to the rollouts of an environment
+type OverridesApplyResponse struct {
+	Environment string            `json:"environment"`
+	DryRun      bool              `json:"dryRun"`
+	Changed     int               `json:"changed"`
+	Unchanged   int               `json:"unchanged"`
+	Failed      int               `json:"failed"`
+	Results     []OverridesResult `json:"results"`
+}
+
+// SyncPlan lists the waves an environment's Flux objects are reconciled in: sources first, then
+// Kustomizations after the ones they depend on
+type SyncPlan struct {
+	Environment string                    `json:"environment"`
+	Rollouts    []ResourceRef             `json:"rollouts"`
+	Waves       [][]kubernetes.SyncTarget `json:"waves"`
+	// Frozen are the rollouts left out because a deployment freeze window is active for them
+	Frozen []ResourceRef `json:"frozen,omitempty"`
+}
+
+// SyncSummary is the final phase of every object reconciled in sync waves
+type SyncSummary struct {
+	Ready   int                       `json:"ready"`
+	Failed  int                       `json:"failed"`
+	Skipped int                       `json:"skipped"`
+	Results []kubernetes.SyncProgress `json:"results"`
+	// Error is set when the objects could not be ordered, e.g. because of a dependency cycle
+	Error string `json:"error,omitempty"`
+}
+
+// ApplicationRollout is a rollout of an application and the version it runs
+type ApplicationRollout struct {
+	Namespace  string     `json:"namespace"`
+	Name       string     `json:"name"`
+	Version    string     `json:"version,omitempty"`
+	DeployedAt *time.Time `json:"deployedAt,omitempty"`
+	BakeStatus string     `json:"bakeStatus,omitempty"`
+	// Error is set when the rollout could not be read, e.g. for lack of permissions
+	Error string `json:"error,omitempty"`
+}
+
+// ApplicationEnvironment is the state of an application in one environment
+type ApplicationEnvironment struct {
+	Environment  string `json:"environment"`
+	Upstream     string `json:"upstream,omitempty"`
+	Relationship string `json:"relationship,omitempty"`
+	// Version is the version deployed last in the environment
+	Version        string     `json:"version,omitempty"`
+	LastDeployedAt *time.Time `json:"lastDeployedAt,omitempty"`
+	BakeStatus     string     `json:"bakeStatus,omitempty"`
+	// Remote is set for environments without rollouts in this cluster, reported by the
+	// application's Environments
+	Remote   bool                 `json:"remote,omitempty"`
+	Rollouts []ApplicationRollout `json:"rollouts"`
+	// Drift is set when the environment runs another version than its upstream or its rollouts
+	// run different versions
+	Drift           bool   `json:"drift"`
+	UpstreamVersion string `json:"upstreamVersion,omitempty"`
+}
+
+// ApplicationEnvironmentsResponse compares the versions an application runs across environments,
+// in promotion order
+type ApplicationEnvironmentsResponse struct {
+	Application  string                   `json:"application"`
+	Environments []ApplicationEnvironment `json:"environments"`
+	// Versions are the distinct versions running, in the order of Environments; InSync is set when
+	// there is only one
+	Versions []string `json:"versions"`
+	InSync   bool     `json:"inSync"`
+}
+
+// IntegrationTarget is the outcome of a test call to one endpoint of an integration. Target never
+// contains credentials, e.g. webhook URLs are reduced to scheme and host.
+type IntegrationTarget struct {
+	Target string `json:"target"`
+	OK     bool   `json:"ok"`
+	Error  string `json:"error,omitempty"`
+}
+
+// IntegrationTest is the outcome of a live test of an integration
+type IntegrationTest struct {
+	TestedAt time.Time           `json:"testedAt"`
+	OK       bool                `json:"ok"`
+	Targets  []IntegrationTarget `json:"targets"`
+}
+
+// IntegrationStatus describes an integration without its credentials
+type IntegrationStatus struct {
+	Name       string `json:"name"`
+	Configured bool   `json:"configured"`
+	// Detail summarizes the configuration, e.g. the number of notification routes
+	Detail string `json:"detail,omitempty"`
+	// Reloadable is set when a reload re-reads the integration's credentials
+	Reloadable  bool             `json:"reloadable"`
+	ReloadedAt  *time.Time       `json:"reloadedAt,omitempty"`
+	ReloadError string           `json:"reloadError,omitempty"`
+	LastTest    *IntegrationTest `json:"lastTest,omitempty"`
+}
+
+// IntegrationsResponse lists the integrations of the dashboard
+type IntegrationsResponse struct {
+	Integrations []IntegrationStatus `json:"integrations"`
+}
+
+// StreamsResponse lists the open Server-Sent Events streams with their health
+type StreamsResponse struct {
+	Streams []streamhealth.Status `json:"streams"`
+}
+
+// MetricsResponse is a Prometheus range query over the pods of a rollout's workloads
+type MetricsResponse struct {
+	// Query is the query as run, with the selector of the rollout's pods
+	Query     string                `json:"query"`
+	Preset    string                `json:"preset,omitempty"`
+	Start     time.Time             `json:"start"`
+	End       time.Time             `json:"end"`
+	Step      string                `json:"step"`
+	Workloads []kubernetes.Workload `json:"workloads"`
+	Series    []prometheus.Series   `json:"series"`
+	// Deployments are the deployments current at some point of the range, newest first
+	Deployments []Deployment `json:"deployments"`
+}
+
+// CanaryAnalysisResponse is the progress of a Kruise canary rollout with its canary and stable pods
+// compared
+type CanaryAnalysisResponse struct {
+	kubernetes.CanaryAnalysis
+	// Metrics is set when Prometheus is configured and a canary is in progress
+	Metrics *CanaryMetrics `json:"metrics,omitempty"`
+	// MetricsError is set when the metrics could not be compared
+	MetricsError string `json:"metricsError,omitempty"`
+}
+
+// CanaryMetrics compares metrics of the canary and the stable pods over the same range
+type CanaryMetrics struct {
+	Start       time.Time          `json:"start"`
+	End         time.Time          `json:"end"`
+	Step        string             `json:"step"`
+	Comparisons []CanaryComparison `json:"comparisons"`
+}
+
+// CanaryComparison is a metrics preset run over the canary and over the stable pods
+type CanaryComparison struct {
+	Preset string              `json:"preset"`
+	Canary []prometheus.Series `json:"canary"`
+	Stable []prometheus.Series `json:"stable"`
+}
+
+// AlertsResponse lists the firing alerts about a rollout's workloads
+type AlertsResponse struct {
+	Workloads []kubernetes.Workload `json:"workloads"`
+	// Alerts are the alerts naming one of the workloads or their pods, and alerts about their
+	// namespaces as a whole, newest first
+	Alerts []alertmanager.Alert `json:"alerts"`
+	Firing int                  `json:"firing"`
+}
+
+// HealthChecksResponse lists the HealthChecks selected by a rollout
+type HealthChecksResponse struct {
+	HealthChecks []rolloutv1alpha1.HealthCheck `json:"healthChecks"`
+	Debug        HealthChecksDebug             `json:"debug"`
+}
+
+// HealthCheckListResponse lists HealthChecks filtered by namespace, class and labels
+type HealthCheckListResponse struct {
+	HealthChecks []rolloutv1alpha1.HealthCheck `json:"healthChecks"`
+}
+
+// HealthCheckDetailResponse is a HealthCheck with its recent status transitions, newest first, and
+// its last error
+type HealthCheckDetailResponse struct {
+	HealthCheck rolloutv1alpha1.HealthCheck `json:"healthCheck"`
+	Transitions []HealthCheckTransition     `json:"transitions"`
+	LastError   *HealthCheckError           `json:"lastError,omitempty"`
+}
+
+// HealthChecksDebug describes how HealthChecks were selected
+type HealthChecksDebug struct {
+	RolloutNamespace       string `json:"rolloutNamespace"`
+	HasHealthCheckSelector bool   `json:"hasHealthCheckSelector"`
+	HasNamespaceSelector   *bool  `json:"hasNamespaceSelector,omitempty"`
+	NamespaceSelectorType  string `json:"namespaceSelectorType,omitempty"`
+}
+
+// PodsResponse lists the pods of a rollout's workloads and test jobs
+type PodsResponse struct {
+	Pods []Pod `json:"pods"`
+}
+
+// PodRestartResponse identifies a pod deleted to be recreated by its controller
+type PodRestartResponse struct {
+	Pod   ResourceRef `json:"pod"`
+	Owner string      `json:"owner"`
+}
+
+// EventsResponse lists the events related to a rollout
+type EventsResponse struct {
+	Events []corev1.Event `json:"events"`
+}
+
+// HistoryResponse is the deployment history of a rollout, newest first, extended with
+// reconstructed entries where the rollout's status history is short or was reset
+type HistoryResponse struct {
+	// Reconstructed counts the entries that are reconstructed rather than recorded by the rollout
+	Reconstructed int            `json:"reconstructed"`
+	Entries       []HistoryEntry `json:"entries"`
+}
+
+// SchedulesResponse lists rollout schedules
+type SchedulesResponse struct {
+	RolloutSchedules        *rolloutv1alpha1.RolloutScheduleList        `json:"rolloutSchedules"`
+	ClusterRolloutSchedules *rolloutv1alpha1.ClusterRolloutScheduleList `json:"clusterRolloutSchedules"`
+}
+
+// SearchResponse lists the rollouts matching a search: for ?image= or ?revision= those whose
+// deployed version matches in Results, for ?q= the rollouts ranked by how well they match in
+// Rollouts
+type SearchResponse struct {
+	Results []kubernetes.DeploymentSearchResult `json:"results"`
+	Query   string                              `json:"query,omitempty"`
+	// Rollouts are the best ranked of the Total rollouts matching Query, up to the limit
+	Rollouts          []RolloutSearchResult         `json:"rollouts,omitempty"`
+	Total             int                           `json:"total,omitempty"`
+	SkippedNamespaces []kubernetes.SkippedNamespace `json:"skippedNamespaces,omitempty"`
+}
+
+// RolloutSearchResult is a rollout matching a search query with the fields it matched
+type RolloutSearchResult struct {
+	Rollout RolloutSummary `json:"rollout"`
+	Score   int            `json:"score"`
+	Matches []search.Match `json:"matches"`
+}
+
+// LogStreamStarted is the payload of the "stream" event that starts a pod logs stream of all
+// pods; ID addresses the stream in LogControlRequests
+type LogStreamStarted struct {
+	ID string `json:"id"`
+}
+
+// LogControlRequest changes which sources a running pod logs stream sends. Sources are pods
+// ("pod") or containers ("pod/container"); Mute and Solo replace the previous selection. While any
+// source is soloed only soloed sources are sent, otherwise all but the muted ones.
+type LogControlRequest struct {
+	Stream string   `json:"stream" binding:"required"`
+	Mute   []string `json:"mute,omitempty"`
+	Solo   []string `json:"solo,omitempty"`
+}
+
+// LogControlResponse echoes the selection now applied to the stream
+type LogControlResponse struct {
+	Stream string   `json:"stream"`
+	Mute   []string `json:"mute"`
+	Solo   []string `json:"solo"`
+}
+
+// LogLine is the payload of "log" events on the pod logs stream. Timestamp (unix milliseconds)
+// and namespace are only set when streaming all pods of a rollout.
+type LogLine struct {
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	Type      string `json:"type"`
+	// Track is "canary" or "stable" for workload pods during a Kruise canary
+	Track     string `json:"track,omitempty"`
+	Line      string `json:"line"`
+	Timestamp int64  `json:"timestamp,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	// Truncated is set when the line exceeded LOG_MAX_LINE_LENGTH; OriginalLength is its size in bytes
+	Truncated      bool `json:"truncated,omitempty"`
+	OriginalLength int  `json:"originalLength,omitempty"`
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var errNotJWT = errors.New("token is not a JWT")
+
+// UsernameFromToken returns a human-readable identity from a JWT's claims for logging purposes.
+// The token signature is NOT verified, so the result must never be used for authorization.
+// Returns empty string if the token is not a JWT or carries no identity claim.
+func UsernameFromToken(token string) string {
+	claims, err := decodeClaims(token)
+	if err != nil {
+		return ""
+	}
+	for _, key := range []string{"email", "preferred_username", "sub"} {
+		if value, ok := claims[key].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// decodeClaims decodes the payload segment of a JWT without verifying its signature
+func decodeClaims(token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errNotJWT
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	claims := map[string]any{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
@@ -1,6 +1,9 @@
 package auth
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -8,6 +11,9 @@ import (
 
 const TokenContextKey = "oidc_token"
 
+// IdentityContextKey holds the *Identity of a verified token, see VerifyTokenMiddleware
+const IdentityContextKey = "oidc_identity"
+
 // ExtractTokenMiddleware extracts OIDC token from request headers or cookies
 // Envoy Gateway typically sets the token in:
 // 1. Authorization header (Bearer token)
@@ -16,6 +22,7 @@ const TokenContextKey = "oidc_token"
 func ExtractTokenMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var token string
+		var source string
 
 		// With forwardAccessToken: true in SecurityPolicy, Envoy Gateway forwards the access token
 		// in the standard Authorization header. This is the preferred method.
@@ -29,6 +36,7 @@ func ExtractTokenMiddleware() gin.HandlerFunc {
 		if token == "" {
 			if cookie, err := c.Cookie(IdTokenCookie); err == nil && cookie != "" {
 				token = cookie
+				source = "cookie:IdToken"
 			}
 		}
 
@@ -37,6 +45,7 @@ func ExtractTokenMiddleware() gin.HandlerFunc {
 		if token == "" {
 			if cookie, err := c.Cookie(idTokenCookie); err == nil && cookie != "" {
 				token = cookie
+				source = "cookie:id_token"
 			}
 		}
 
@@ -44,6 +53,7 @@ func ExtractTokenMiddleware() gin.HandlerFunc {
 		if token == "" {
 			if cookie, err := c.Cookie(accessTokenCookie); err == nil && cookie != "" {
 				token = cookie
+				source = "cookie:access_token"
 			}
 		}
 
@@ -55,6 +65,7 @@ func ExtractTokenMiddleware() gin.HandlerFunc {
 				parts := strings.SplitN(authHeader, " ", 2)
 				if len(parts) == 2 && strings.ToLower(parts[0]) == "bearer" {
 					token = parts[1]
+					source = "header:Authorization"
 				}
 			}
 		}
@@ -62,6 +73,11 @@ func ExtractTokenMiddleware() gin.HandlerFunc {
 		// Store token in context if found
 		if token != "" {
 			c.Set(TokenContextKey, token)
+
+			// Never log the token itself; claims are only logged when explicitly enabled
+			if logTokenClaims {
+				slog.Debug("Extracted OIDC token", "source", source, "token", RedactToken(token), "claims", TokenClaimsForLogging(token))
+			}
 		}
 
 		c.Next()
@@ -78,3 +94,50 @@ func GetTokenFromContext(c *gin.Context) string {
 	}
 	return ""
 }
+
+// VerifyTokenMiddleware verifies the token found by ExtractTokenMiddleware with v and stores the
+// identity it carries in the context. Requests with a token that fails verification are passed to
+// reject, which must write the error response; requests without a token are left to the handlers.
+func VerifyTokenMiddleware(v *Verifier, reject func(c *gin.Context, err error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := GetTokenFromContext(c)
+		if token == "" {
+			c.Next()
+			return
+		}
+		identity, err := v.Verify(c.Request.Context(), token)
+		if err != nil {
+			reject(c, err)
+			c.Abort()
+			return
+		}
+		c.Set(IdentityContextKey, identity)
+		c.Next()
+	}
+}
+
+// GetIdentityFromContext returns the identity of the request's verified token, or nil when the
+// token was not verified
+func GetIdentityFromContext(c *gin.Context) *Identity {
+	if identity, exists := c.Get(IdentityContextKey); exists {
+		if id, ok := identity.(*Identity); ok {
+			return id
+		}
+	}
+	return nil
+}
+
+// ClientKey identifies the client of a request for per-client accounting such as rate limits.
+// It is the verified username when VerifyTokenMiddleware ran, otherwise a hash of the raw token,
+// since unverified claims can be forged to spend someone else's budget. Requests without a token
+// get an empty key.
+func ClientKey(c *gin.Context) string {
+	if identity := GetIdentityFromContext(c); identity != nil && identity.Username != "" {
+		return "user:" + identity.Username
+	}
+	if token := GetTokenFromContext(c); token != "" {
+		sum := sha256.Sum256([]byte(token))
+		return "token:" + hex.EncodeToString(sum[:8])
+	}
+	return ""
+}
@@ -1,12 +1,16 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestExtractTokenMiddleware(t *testing.T) {
@@ -68,3 +72,73 @@ func TestExtractTokenMiddleware(t *testing.T) {
 		assert.Equal(t, "cookie-token", w.Body.String())
 	})
 }
+
+func TestVerifyTokenMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	srv := newTestIssuer(t, rsaJWK("rsa", key))
+	v := NewVerifier(VerifierConfig{IssuerURL: srv.URL, ClientID: "dashboard", UsernameClaim: "sub", GroupsClaim: "groups"})
+
+	r := gin.New()
+	r.Use(ExtractTokenMiddleware(), VerifyTokenMiddleware(v, func(c *gin.Context, err error) {
+		c.String(http.StatusUnauthorized, "rejected")
+	}))
+	r.GET("/test", func(c *gin.Context) {
+		if identity := GetIdentityFromContext(c); identity != nil {
+			c.String(http.StatusOK, identity.Username)
+			return
+		}
+		c.String(http.StatusOK, "anonymous")
+	})
+
+	request := func(token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/test", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(signToken(t, "RS256", "rsa", key, map[string]any{
+		"iss": srv.URL, "aud": "dashboard", "exp": time.Now().Add(time.Hour).Unix(), "sub": "jane",
+	}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "jane", w.Body.String())
+
+	w = request(signToken(t, "RS256", "rsa", key, map[string]any{
+		"iss": srv.URL, "aud": "dashboard", "exp": time.Now().Add(-time.Hour).Unix(), "sub": "jane",
+	}))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "rejected", w.Body.String())
+
+	w = request("")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "anonymous", w.Body.String())
+}
+
+func TestClientKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newContext := func(token string, identity *Identity) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		if token != "" {
+			c.Set(TokenContextKey, token)
+		}
+		if identity != nil {
+			c.Set(IdentityContextKey, identity)
+		}
+		return c
+	}
+
+	assert.Equal(t, "", ClientKey(newContext("", nil)))
+	assert.Equal(t, "user:jane", ClientKey(newContext("token-a", &Identity{Username: "jane"})))
+
+	// Unverified tokens are told apart by their hash, never by the claims they carry
+	a := ClientKey(newContext("token-a", nil))
+	assert.Regexp(t, "^token:[0-9a-f]{16}$", a)
+	assert.NotContains(t, a, "token-a")
+	assert.Equal(t, a, ClientKey(newContext("token-a", nil)))
+	assert.NotEqual(t, a, ClientKey(newContext("token-b", nil)))
+}
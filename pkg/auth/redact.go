@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+// Redacted is the placeholder written in place of sensitive values
+const Redacted = "[REDACTED]"
+
+// sensitiveHeaders lists request headers that carry credentials
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+
+// logTokenClaims enables the opt-in debug mode that logs the claims of extracted tokens.
+// It is controlled by the AUTH_DEBUG_CLAIMS environment variable.
+var logTokenClaims, _ = strconv.ParseBool(os.Getenv("AUTH_DEBUG_CLAIMS"))
+
+// RedactToken returns a representation of a token that is safe to log.
+// Only the token length is kept so that different tokens can still be told apart in debugging.
+func RedactToken(token string) string {
+	if token == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s(len=%d)", Redacted, len(token))
+}
+
+// RedactHeaders returns a copy of the headers with credential-bearing values replaced
+func RedactHeaders(headers http.Header) http.Header {
+	redacted := headers.Clone()
+	for _, name := range sensitiveHeaders {
+		if _, exists := redacted[http.CanonicalHeaderKey(name)]; exists {
+			redacted.Set(name, Redacted)
+		}
+	}
+	return redacted
+}
+
+// TokenClaimsForLogging returns the identity-related claims of a JWT for debug logging.
+// The raw JWT is never included. Returns nil if the token cannot be decoded.
+func TokenClaimsForLogging(token string) map[string]any {
+	claims, err := decodeClaims(token)
+	if err != nil {
+		return nil
+	}
+	safe := map[string]any{}
+	for _, key := range []string{"iss", "sub", "aud", "exp", "iat", "email", "preferred_username", "groups"} {
+		if value, ok := claims[key]; ok {
+			safe[key] = value
+		}
+	}
+	return safe
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactToken(t *testing.T) {
+	assert.Equal(t, "", RedactToken(""))
+	assert.Equal(t, "[REDACTED](len=6)", RedactToken("secret"))
+}
+
+func TestRedactHeaders(t *testing.T) {
+	headers := http.Header{}
+	headers.Set("Authorization", "Bearer secret")
+	headers.Set("Cookie", "IdToken=secret")
+	headers.Set("Accept", "application/json")
+
+	redacted := RedactHeaders(headers)
+
+	assert.Equal(t, Redacted, redacted.Get("Authorization"))
+	assert.Equal(t, Redacted, redacted.Get("Cookie"))
+	assert.Equal(t, "application/json", redacted.Get("Accept"))
+	assert.Equal(t, "Bearer secret", headers.Get("Authorization"), "original headers must not be modified")
+}
+
+func TestTokenClaimsForLogging(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"alice","email":"alice@example.com","nonce":"n"}`))
+	token := "eyJhbGciOiJub25lIn0." + payload + ".sig"
+
+	claims := TokenClaimsForLogging(token)
+
+	assert.Equal(t, "alice", claims["sub"])
+	assert.Equal(t, "alice@example.com", claims["email"])
+	assert.NotContains(t, claims, "nonce")
+	assert.Nil(t, TokenClaimsForLogging("not-a-jwt"))
+	assert.Equal(t, "alice@example.com", UsernameFromToken(token))
+}
@@ -0,0 +1,354 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrInvalidToken is returned when a token fails verification
+var ErrInvalidToken = errors.New("invalid token")
+
+const (
+	// clockSkew is the leeway allowed when checking exp
+	clockSkew = time.Minute
+	// minKeyRefreshInterval bounds how often unknown key IDs trigger a JWKS refresh
+	minKeyRefreshInterval = time.Minute
+	// minRetryInterval is how long requests to the issuer fail fast after the first failure; it
+	// doubles with every further failure up to minKeyRefreshInterval
+	minRetryInterval = time.Second
+)
+
+// defaultSigningAlgs are accepted when the issuer does not advertise its signing algorithms
+var defaultSigningAlgs = []string{oidc.RS256, oidc.RS384, oidc.RS512, oidc.ES256, oidc.ES384, oidc.ES512}
+
+var (
+	// errIssuerUnavailable is wrapped by errors of failed requests to the issuer
+	errIssuerUnavailable = errors.New("issuer unavailable")
+	// errRefetchThrottled is returned for a refetch of the signing keys within minKeyRefreshInterval
+	errRefetchThrottled = errors.New("signing keys were fetched less than a minute ago")
+)
+
+// Identity is the user a verified token belongs to, as used for impersonation
+type Identity struct {
+	Username string
+	Groups   []string
+}
+
+// VerifierConfig configures OIDC token verification, mirroring the kube-apiserver --oidc-* flags
+type VerifierConfig struct {
+	IssuerURL      string
+	ClientID       string
+	UsernameClaim  string
+	UsernamePrefix string
+	GroupsClaim    string
+	GroupsPrefix   string
+}
+
+// VerifierConfigFromEnv reads the OIDC_* environment variables. It returns nil when no issuer
+// is configured, which leaves tokens unverified.
+func VerifierConfigFromEnv() (*VerifierConfig, error) {
+	cfg := &VerifierConfig{
+		IssuerURL:      os.Getenv("OIDC_ISSUER_URL"),
+		ClientID:       os.Getenv("OIDC_CLIENT_ID"),
+		UsernameClaim:  os.Getenv("OIDC_USERNAME_CLAIM"),
+		UsernamePrefix: os.Getenv("OIDC_USERNAME_PREFIX"),
+		GroupsClaim:    os.Getenv("OIDC_GROUPS_CLAIM"),
+		GroupsPrefix:   os.Getenv("OIDC_GROUPS_PREFIX"),
+	}
+	if cfg.IssuerURL == "" && cfg.ClientID == "" {
+		return nil, nil
+	}
+	if cfg.UsernameClaim == "" {
+		cfg.UsernameClaim = "sub"
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	if cfg.IssuerURL == "" || cfg.ClientID == "" {
+		return nil, fmt.Errorf("OIDC_ISSUER_URL and OIDC_CLIENT_ID are both required to verify tokens")
+	}
+	if !strings.HasPrefix(cfg.IssuerURL, "https://") {
+		return nil, fmt.Errorf("OIDC_ISSUER_URL must be an https URL")
+	}
+	return cfg, nil
+}
+
+// Verifier verifies OIDC ID tokens against the issuer's published signing keys
+type Verifier struct {
+	cfg VerifierConfig
+	now func() time.Time
+	// client fetches the discovery document and signing keys, see issuerTransport
+	client *http.Client
+
+	discovery singleflight.Group
+	mu        sync.Mutex
+	issuer    *discoveredIssuer
+}
+
+// discoveredIssuer is what discovery found out about the issuer
+type discoveredIssuer struct {
+	jwksURL string
+	keys    oidc.KeySet
+	algs    []string
+}
+
+// NewVerifier returns a verifier for tokens issued by cfg.IssuerURL to cfg.ClientID. Signing keys
+// are discovered on first use and refreshed when a token names an unknown key.
+func NewVerifier(cfg VerifierConfig) *Verifier {
+	v := &Verifier{cfg: cfg, now: time.Now}
+	v.client = v.newClient()
+	return v
+}
+
+func (v *Verifier) newClient() *http.Client {
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &issuerTransport{
+			next:    http.DefaultTransport,
+			now:     func() time.Time { return v.now() },
+			fetched: map[string]time.Time{},
+		},
+	}
+}
+
+// Verify checks the token's signature, issuer, audience and validity period and returns the
+// identity from its claims. Errors wrap ErrInvalidToken unless the issuer could not be discovered
+// or its signing keys could not be fetched.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Identity, error) {
+	issuer, err := v.discovered(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover issuer: %w", err)
+	}
+
+	keys := &requestKeySet{KeySet: issuer.keys}
+	idToken, err := oidc.NewVerifier(v.cfg.IssuerURL, keys, &oidc.Config{
+		ClientID:             v.cfg.ClientID,
+		SupportedSigningAlgs: issuer.algs,
+		Now:                  func() time.Time { return v.now().Add(-clockSkew) },
+	}).Verify(ctx, token)
+	if keys.fetchErr != nil {
+		return nil, fmt.Errorf("failed to fetch signing keys: %w", keys.fetchErr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	claims := map[string]any{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims: %w", ErrInvalidToken, err)
+	}
+	return v.identity(claims)
+}
+
+func (v *Verifier) identity(claims map[string]any) (*Identity, error) {
+	username, _ := claims[v.cfg.UsernameClaim].(string)
+	if username == "" {
+		return nil, fmt.Errorf("%w: claim %q is missing", ErrInvalidToken, v.cfg.UsernameClaim)
+	}
+	// Like the API server, an email is only trusted once the provider has verified it
+	if v.cfg.UsernameClaim == "email" {
+		if verified, ok := claims["email_verified"].(bool); ok && !verified {
+			return nil, fmt.Errorf("%w: email %q is not verified", ErrInvalidToken, username)
+		}
+	}
+
+	identity := &Identity{Username: v.cfg.UsernamePrefix + username}
+	switch groups := claims[v.cfg.GroupsClaim].(type) {
+	case string:
+		identity.Groups = []string{v.cfg.GroupsPrefix + groups}
+	case []any:
+		for _, g := range groups {
+			if s, ok := g.(string); ok && s != "" {
+				identity.Groups = append(identity.Groups, v.cfg.GroupsPrefix+s)
+			}
+		}
+	}
+	return identity, nil
+}
+
+// Refresh rediscovers the issuer and fetches its signing keys, e.g. to check the issuer can be
+// reached or to pick up rotated keys right away. It returns the number of usable keys. On failure
+// the previous keys are kept.
+func (v *Verifier) Refresh(ctx context.Context) (int, error) {
+	// A new client skips the backoff of earlier failures, which is what an explicit refresh is for
+	client := v.newClient()
+	issuer, err := v.discover(ctx, client)
+	if err != nil {
+		return 0, fmt.Errorf("failed to discover issuer: %w", err)
+	}
+	// Keys are counted with a plain request, so the new key set still fetches them on first use
+	keys, err := countSigningKeys(ctx, issuer.jwksURL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.client, v.issuer = client, issuer
+	return keys, nil
+}
+
+// discovered returns the discovered issuer, discovering it on first use. Concurrent callers share
+// one discovery and no lock is held while it runs.
+func (v *Verifier) discovered(ctx context.Context) (*discoveredIssuer, error) {
+	v.mu.Lock()
+	issuer, client := v.issuer, v.client
+	v.mu.Unlock()
+	if issuer != nil {
+		return issuer, nil
+	}
+
+	result, err, _ := v.discovery.Do("", func() (any, error) {
+		// Not bound to the first caller's request, whose cancellation would fail all callers
+		issuer, err := v.discover(context.WithoutCancel(ctx), client)
+		if err != nil {
+			return nil, err
+		}
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		if v.issuer == nil {
+			v.issuer = issuer
+		}
+		return v.issuer, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*discoveredIssuer), nil
+}
+
+func (v *Verifier) discover(ctx context.Context, client *http.Client) (*discoveredIssuer, error) {
+	provider, err := oidc.NewProvider(oidc.ClientContext(ctx, client), v.cfg.IssuerURL)
+	if err != nil {
+		return nil, err
+	}
+	var metadata struct {
+		JWKSURL string   `json:"jwks_uri"`
+		Algs    []string `json:"id_token_signing_alg_values_supported"`
+	}
+	if err := provider.Claims(&metadata); err != nil {
+		return nil, err
+	}
+	if metadata.JWKSURL == "" {
+		return nil, fmt.Errorf("discovery document has no jwks_uri")
+	}
+
+	algs := defaultSigningAlgs
+	if len(metadata.Algs) > 0 {
+		algs = metadata.Algs
+	}
+	return &discoveredIssuer{
+		jwksURL: metadata.JWKSURL,
+		// The key set keeps the client but not the deadline of ctx, so later fetches are not cut short
+		keys: oidc.NewRemoteKeySet(oidc.ClientContext(context.Background(), client), metadata.JWKSURL),
+		algs: algs,
+	}, nil
+}
+
+// countSigningKeys fetches the key set at jwksURL and counts the keys usable for signatures
+func countSigningKeys(ctx context.Context, jwksURL string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := (&http.Client{Timeout: 10 * time.Second}).Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("GET %s: unexpected status %s", jwksURL, resp.Status)
+	}
+	var set struct {
+		Keys []struct {
+			Use string `json:"use"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return 0, fmt.Errorf("GET %s: %w", jwksURL, err)
+	}
+	keys := 0
+	for _, key := range set.Keys {
+		if key.Use == "" || key.Use == "sig" {
+			keys++
+		}
+	}
+	return keys, nil
+}
+
+// requestKeySet remembers why the signing keys could not be fetched for one verification, which
+// oidc.IDTokenVerifier only reports as text
+type requestKeySet struct {
+	oidc.KeySet
+	fetchErr error
+}
+
+func (k *requestKeySet) VerifySignature(ctx context.Context, jwt string) ([]byte, error) {
+	payload, err := k.KeySet.VerifySignature(ctx, jwt)
+	if errors.Is(err, errIssuerUnavailable) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		k.fetchErr = err
+	}
+	return payload, err
+}
+
+// issuerTransport guards the issuer against tokens the verifier cannot check with its cached keys:
+// after a failed request, requests fail fast with the same error for a backoff that doubles with
+// every failure, and a URL that was fetched successfully is refetched at most once per
+// minKeyRefreshInterval, so tokens naming unknown keys are rejected without a request.
+type issuerTransport struct {
+	next http.RoundTripper
+	now  func() time.Time
+
+	mu       sync.Mutex
+	failure  error
+	failures int
+	retryAt  time.Time
+	fetched  map[string]time.Time
+}
+
+func (t *issuerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	url := req.URL.String()
+	t.mu.Lock()
+	if t.failure != nil && t.now().Before(t.retryAt) {
+		err := t.failure
+		t.mu.Unlock()
+		return nil, err
+	}
+	if fetchedAt, ok := t.fetched[url]; ok && t.now().Sub(fetchedAt) < minKeyRefreshInterval {
+		t.mu.Unlock()
+		return nil, errRefetchThrottled
+	}
+	t.mu.Unlock()
+
+	resp, err := t.next.RoundTrip(req)
+	if err == nil && resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		err = fmt.Errorf("GET %s: unexpected status %s", url, resp.Status)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err != nil {
+		t.failures++
+		t.failure = fmt.Errorf("%w: %w", errIssuerUnavailable, err)
+		backoff := minRetryInterval
+		for i := 1; i < t.failures && backoff < minKeyRefreshInterval; i++ {
+			backoff *= 2
+		}
+		t.retryAt = t.now().Add(min(backoff, minKeyRefreshInterval))
+		return nil, t.failure
+	}
+	t.failure, t.failures = nil, 0
+	t.fetched[url] = t.now()
+	return resp, nil
+}
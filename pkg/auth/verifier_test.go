@@ -0,0 +1,240 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestIssuer serves OIDC discovery and a JWKS with the given keys
+func newTestIssuer(t *testing.T, keys ...map[string]string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"issuer": srv.URL, "jwks_uri": srv.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+	})
+	return srv
+}
+
+func rsaJWK(kid string, key *rsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func signToken(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]any) string {
+	t.Helper()
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		require.NoError(t, err)
+		size := (k.Curve.Params().BitSize + 7) / 8
+		signature = append(r.FillBytes(make([]byte, size)), s.FillBytes(make([]byte, size))...)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func ecJWK(kid, crv string, key *ecdsa.PrivateKey) map[string]string {
+	size := (key.Curve.Params().BitSize + 7) / 8
+	return map[string]string{
+		"kty": "EC",
+		"kid": kid,
+		"crv": crv,
+		"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size))),
+		"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size))),
+	}
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	srv := newTestIssuer(t, rsaJWK("rsa", rsaKey), ecJWK("ec", "P-256", ecKey))
+
+	v := NewVerifier(VerifierConfig{
+		IssuerURL:      srv.URL,
+		ClientID:       "dashboard",
+		UsernameClaim:  "email",
+		UsernamePrefix: "oidc:",
+		GroupsClaim:    "groups",
+		GroupsPrefix:   "oidc:",
+	})
+	claims := map[string]any{
+		"iss":    srv.URL,
+		"aud":    []string{"other", "dashboard"},
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"email":  "jane@example.com",
+		"groups": []string{"developers", "oncall"},
+	}
+
+	identity, err := v.Verify(context.Background(), signToken(t, "RS256", "rsa", rsaKey, claims))
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{Username: "oidc:jane@example.com", Groups: []string{"oidc:developers", "oidc:oncall"}}, identity)
+
+	identity, err = v.Verify(context.Background(), signToken(t, "ES256", "ec", ecKey, claims))
+	require.NoError(t, err)
+	assert.Equal(t, "oidc:jane@example.com", identity.Username)
+}
+
+func TestVerifier_Refresh(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	srv := newTestIssuer(t, rsaJWK("a", key), rsaJWK("b", key))
+
+	keys, err := NewVerifier(VerifierConfig{IssuerURL: srv.URL, ClientID: "dashboard"}).Refresh(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, keys)
+
+	_, err = NewVerifier(VerifierConfig{IssuerURL: srv.URL + "/other", ClientID: "dashboard"}).Refresh(context.Background())
+	assert.Error(t, err)
+}
+
+func TestVerifier_Verify_Rejected(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	srv := newTestIssuer(t, rsaJWK("rsa", key), ecJWK("p384", "P-384", p384Key))
+	v := NewVerifier(VerifierConfig{IssuerURL: srv.URL, ClientID: "dashboard", UsernameClaim: "sub", GroupsClaim: "groups"})
+
+	valid := func() map[string]any {
+		return map[string]any{"iss": srv.URL, "aud": "dashboard", "exp": time.Now().Add(time.Hour).Unix(), "sub": "jane"}
+	}
+	with := func(key string, value any) map[string]any {
+		claims := valid()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+
+	for name, token := range map[string]string{
+		"not a JWT":       "opaque-token",
+		"wrong key":       signToken(t, "RS256", "rsa", otherKey, valid()),
+		"unknown key":     signToken(t, "RS256", "other", key, valid()),
+		"alg none":        signToken(t, "none", "rsa", key, valid()),
+		"alg of curve":    signToken(t, "ES256", "p384", p384Key, valid()),
+		"alg of key type": signToken(t, "ES256", "rsa", p384Key, valid()),
+		"wrong issuer":    signToken(t, "RS256", "rsa", key, with("iss", "https://evil.example.com")),
+		"wrong audience":  signToken(t, "RS256", "rsa", key, with("aud", "other")),
+		"expired":         signToken(t, "RS256", "rsa", key, with("exp", time.Now().Add(-time.Hour).Unix())),
+		"no expiry":       signToken(t, "RS256", "rsa", key, with("exp", nil)),
+		"not yet valid":   signToken(t, "RS256", "rsa", key, with("nbf", time.Now().Add(time.Hour).Unix())),
+		"missing subject": signToken(t, "RS256", "rsa", key, with("sub", nil)),
+	} {
+		_, err := v.Verify(context.Background(), token)
+		assert.ErrorIs(t, err, ErrInvalidToken, name)
+	}
+}
+
+func TestVerifier_IssuerBackoff(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	var keyRequests atomic.Int32
+	var failing atomic.Bool
+	failing.Store(true)
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"issuer": srv.URL, "jwks_uri": srv.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		keyRequests.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{rsaJWK("rsa", key)}})
+	})
+
+	v := NewVerifier(VerifierConfig{IssuerURL: srv.URL, ClientID: "dashboard", UsernameClaim: "sub"})
+	now := time.Now()
+	v.now = func() time.Time { return now }
+	token := func(kid string) string {
+		return signToken(t, "RS256", kid, key, map[string]any{"iss": srv.URL, "aud": "dashboard", "exp": now.Add(time.Hour).Unix(), "sub": "jane"})
+	}
+
+	// An unreachable issuer is not the token's fault, and is not asked again right away
+	_, err = v.Verify(context.Background(), token("rsa"))
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrInvalidToken)
+	_, err = v.Verify(context.Background(), token("rsa"))
+	assert.NotErrorIs(t, err, ErrInvalidToken)
+	assert.Equal(t, int32(1), keyRequests.Load())
+
+	failing.Store(false)
+	now = now.Add(minRetryInterval)
+	identity, err := v.Verify(context.Background(), token("rsa"))
+	require.NoError(t, err)
+	assert.Equal(t, "jane", identity.Username)
+	assert.Equal(t, int32(2), keyRequests.Load())
+
+	// Unknown keys are only looked up once per minKeyRefreshInterval
+	for range 3 {
+		_, err = v.Verify(context.Background(), token("unknown"))
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	}
+	assert.Equal(t, int32(2), keyRequests.Load())
+}
+
+func TestVerifierConfigFromEnv(t *testing.T) {
+	t.Setenv("OIDC_ISSUER_URL", "")
+	t.Setenv("OIDC_CLIENT_ID", "")
+	cfg, err := VerifierConfigFromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, cfg, "verification is disabled without an issuer")
+
+	t.Setenv("OIDC_ISSUER_URL", "https://issuer.example.com")
+	_, err = VerifierConfigFromEnv()
+	assert.Error(t, err, "client ID is required")
+
+	t.Setenv("OIDC_ISSUER_URL", "http://issuer.example.com")
+	t.Setenv("OIDC_CLIENT_ID", "dashboard")
+	_, err = VerifierConfigFromEnv()
+	assert.Error(t, err, "issuer must use https")
+
+	t.Setenv("OIDC_ISSUER_URL", "https://issuer.example.com")
+	cfg, err = VerifierConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, &VerifierConfig{IssuerURL: "https://issuer.example.com", ClientID: "dashboard", UsernameClaim: "sub", GroupsClaim: "groups"}, cfg)
+}
@@ -0,0 +1,180 @@
+// Package chaos injects latency and errors into API requests, so loading and degraded states of
+// the frontend can be tested against a realistic backend. It is meant for development only.
+package chaos
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Header names the faults injected into a response, e.g. "latency=1.2s,error"
+const Header = "X-Chaos-Injected"
+
+// Config holds the fault injection rules. It is read from a YAML or JSON file:
+//
+//	rules:
+//	  - path: /api/v1/rollouts/:namespace/:name
+//	    latency: 500ms
+//	    jitter: 1s
+//	  - method: POST
+//	    path: /api/v1/rollouts/*
+//	    errorRate: 0.2
+//	    status: 503
+type Config struct {
+	// Rules are evaluated in order; the first rule matching a request applies
+	Rules []Rule `json:"rules"`
+}
+
+// Rule injects faults into the requests of matching routes
+type Rule struct {
+	// Method limits the rule to one HTTP method; empty matches all
+	Method string `json:"method,omitempty"`
+	// Path is a route as registered, e.g. /api/v1/rollouts/:namespace/:name. A trailing * matches
+	// every route with that prefix; empty or * matches all routes.
+	Path string `json:"path,omitempty"`
+	// Latency delays every matching request
+	Latency *metav1.Duration `json:"latency,omitempty"`
+	// Jitter adds a random delay of up to this duration on top of Latency
+	Jitter *metav1.Duration `json:"jitter,omitempty"`
+	// ErrorRate is the fraction of matching requests, between 0 and 1, that fail with Status
+	// instead of reaching the handler
+	ErrorRate float64 `json:"errorRate,omitempty"`
+	// Status of injected errors (default 503)
+	Status int `json:"status,omitempty"`
+}
+
+// ConfigFromEnv reads the configuration file named by CHAOS_CONFIG. Without it nil is returned
+// and no faults are injected.
+func ConfigFromEnv() (*Config, error) {
+	path := os.Getenv("CHAOS_CONFIG")
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chaos config: %w", err)
+	}
+	cfg, err := ParseConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// ParseConfig parses and validates a YAML or JSON configuration
+func ParseConfig(data []byte) (Config, error) {
+	var cfg Config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to parse chaos config: %w", err)
+	}
+	for i := range cfg.Rules {
+		rule := &cfg.Rules[i]
+		if rule.ErrorRate < 0 || rule.ErrorRate > 1 {
+			return Config{}, fmt.Errorf("rule %d: errorRate must be between 0 and 1", i)
+		}
+		if rule.Status == 0 {
+			rule.Status = http.StatusServiceUnavailable
+		}
+		if rule.Status < 400 || rule.Status > 599 {
+			return Config{}, fmt.Errorf("rule %d: status must be an error status", i)
+		}
+		if (rule.Latency != nil && rule.Latency.Duration < 0) || (rule.Jitter != nil && rule.Jitter.Duration < 0) {
+			return Config{}, fmt.Errorf("rule %d: latency and jitter must not be negative", i)
+		}
+		rule.Method = strings.ToUpper(rule.Method)
+	}
+	return cfg, nil
+}
+
+// Injector applies Config to requests
+type Injector struct {
+	cfg Config
+	// random returns a number in [0, 1)
+	random func() float64
+	sleep  func(c *gin.Context, d time.Duration)
+}
+
+// New creates an injector
+func New(cfg Config) *Injector {
+	return &Injector{cfg: cfg, random: rand.Float64, sleep: sleepContext}
+}
+
+// Middleware delays and fails requests as configured. Requests that match no rule and requests
+// to unknown routes are passed through.
+func (i *Injector) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rule := i.match(c.Request.Method, c.FullPath())
+		if rule == nil {
+			c.Next()
+			return
+		}
+
+		var injected []string
+		delay := time.Duration(0)
+		if rule.Latency != nil {
+			delay = rule.Latency.Duration
+		}
+		if rule.Jitter != nil && rule.Jitter.Duration > 0 {
+			delay += time.Duration(i.random() * float64(rule.Jitter.Duration))
+		}
+		if delay > 0 {
+			injected = append(injected, "latency="+delay.Round(time.Millisecond).String())
+			i.sleep(c, delay)
+		}
+
+		if rule.ErrorRate > 0 && i.random() < rule.ErrorRate {
+			injected = append(injected, "error")
+			c.Header(Header, strings.Join(injected, ","))
+			api.RespondErrorDetails(c, rule.Status, api.CodeInternal, "Injected failure",
+				"the request failed on purpose, see CHAOS_CONFIG")
+			c.Abort()
+			return
+		}
+		if len(injected) > 0 {
+			c.Header(Header, strings.Join(injected, ","))
+		}
+		c.Next()
+	}
+}
+
+func (i *Injector) match(method, route string) *Rule {
+	if route == "" {
+		return nil
+	}
+	for n := range i.cfg.Rules {
+		rule := &i.cfg.Rules[n]
+		if rule.Method != "" && rule.Method != method {
+			continue
+		}
+		if matchPath(rule.Path, route) {
+			return rule
+		}
+	}
+	return nil
+}
+
+func matchPath(pattern, route string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(route, prefix)
+	}
+	return pattern == "" || pattern == route
+}
+
+// sleepContext waits for d or until the client goes away
+func sleepContext(c *gin.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-c.Request.Context().Done():
+	}
+}
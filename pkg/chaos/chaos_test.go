@@ -0,0 +1,93 @@
+package chaos
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`
+rules:
+  - method: post
+    path: /api/v1/rollouts/*
+    errorRate: 0.5
+  - path: /api/v1/rollouts
+    latency: 200ms
+    jitter: 1s
+`))
+	require.NoError(t, err)
+	require.Len(t, cfg.Rules, 2)
+	assert.Equal(t, "POST", cfg.Rules[0].Method)
+	assert.Equal(t, http.StatusServiceUnavailable, cfg.Rules[0].Status)
+	assert.Equal(t, 200*time.Millisecond, cfg.Rules[1].Latency.Duration)
+
+	for name, data := range map[string]string{
+		"error rate above 1": "rules: [{errorRate: 1.5}]",
+		"success status":     "rules: [{errorRate: 0.5, status: 200}]",
+		"negative latency":   "rules: [{latency: -1s}]",
+		"unknown field":      "rules: [{delay: 1s}]",
+	} {
+		_, err := ParseConfig([]byte(data))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestInjector_Middleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg, err := ParseConfig([]byte(`
+rules:
+  - method: POST
+    path: /api/v1/rollouts/*
+    errorRate: 0.5
+    status: 500
+  - path: /api/v1/rollouts/:namespace/:name
+    latency: 100ms
+    jitter: 1s
+`))
+	require.NoError(t, err)
+
+	injector := New(cfg)
+	random := 0.0
+	injector.random = func() float64 { return random }
+	var slept time.Duration
+	injector.sleep = func(c *gin.Context, d time.Duration) { slept = d }
+
+	r := gin.New()
+	r.Use(injector.Middleware())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/api/v1/rollouts", ok)
+	r.GET("/api/v1/rollouts/:namespace/:name", ok)
+	r.POST("/api/v1/rollouts/:namespace/:name/pin", ok)
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	// Requests the rules don't match are passed through
+	w := serve(http.MethodGet, "/api/v1/rollouts")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(Header))
+
+	random = 0.5
+	w = serve(http.MethodGet, "/api/v1/rollouts/shop/api")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 600*time.Millisecond, slept)
+	assert.Equal(t, "latency=600ms", w.Header().Get(Header))
+
+	random = 0.4
+	w = serve(http.MethodPost, "/api/v1/rollouts/shop/api/pin")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "error", w.Header().Get(Header))
+
+	random = 0.6
+	w = serve(http.MethodPost, "/api/v1/rollouts/shop/api/pin")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
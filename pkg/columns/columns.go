@@ -0,0 +1,213 @@
+// Package columns projects operator-defined metadata of rollouts, read from their labels,
+// annotations or fields and those of the resources they reference, into extra columns of the
+// fleet view
+package columns
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// Resources a column reads its value from
+const (
+	SourceRollout         = "rollout"
+	SourceImagePolicy     = "imagePolicy"
+	SourceImageRepository = "imageRepository"
+)
+
+// Config holds the extra columns of the fleet view. It is read from a YAML or JSON file:
+//
+//	columns:
+//	  - name: tier
+//	    label: example.com/tier
+//	  - name: cost-center
+//	    title: Cost center
+//	    annotation: example.com/cost-center
+//	  - name: image
+//	    source: imageRepository
+//	    field: .spec.image
+type Config struct {
+	Columns []Column `json:"columns"`
+}
+
+// Column is an extra column whose value is read from exactly one of a label, an annotation or a
+// field of its source
+type Column struct {
+	Name string `json:"name"`
+	// Title defaults to the name
+	Title string `json:"title,omitempty"`
+	// Source is the resource the value is read from: rollout (default), or the imagePolicy or
+	// imageRepository providing the rollout's releases
+	Source     string `json:"source,omitempty"`
+	Label      string `json:"label,omitempty"`
+	Annotation string `json:"annotation,omitempty"`
+	// Field is a kubectl JSONPath expression, e.g. .spec.wantedVersion or {.status.history[0].version.tag}
+	Field string `json:"field,omitempty"`
+}
+
+// Definition is a column as shown to clients
+type Definition struct {
+	Name  string `json:"name"`
+	Title string `json:"title"`
+}
+
+// ConfigFromEnv reads the configuration file named by COLUMNS_CONFIG. Without it the fleet view
+// has no extra columns.
+func ConfigFromEnv() (Config, error) {
+	path := os.Getenv("COLUMNS_CONFIG")
+	if path == "" {
+		return Config{}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read columns config: %w", err)
+	}
+	return ParseConfig(data)
+}
+
+// ParseConfig parses and validates a YAML or JSON configuration
+func ParseConfig(data []byte) (Config, error) {
+	var cfg Config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to parse columns config: %w", err)
+	}
+	names := map[string]bool{}
+	for i, column := range cfg.Columns {
+		if column.Name == "" {
+			return Config{}, fmt.Errorf("column %d: name is required", i)
+		}
+		if names[column.Name] {
+			return Config{}, fmt.Errorf("column %s: defined more than once", column.Name)
+		}
+		names[column.Name] = true
+		switch column.Source {
+		case "", SourceRollout, SourceImagePolicy, SourceImageRepository:
+		default:
+			return Config{}, fmt.Errorf("column %s: unknown source %q", column.Name, column.Source)
+		}
+		set := 0
+		for _, value := range []string{column.Label, column.Annotation, column.Field} {
+			if value != "" {
+				set++
+			}
+		}
+		if set != 1 {
+			return Config{}, fmt.Errorf("column %s: exactly one of label, annotation and field is required", column.Name)
+		}
+		if column.Field != "" {
+			if _, err := parseField(column.Field); err != nil {
+				return Config{}, fmt.Errorf("column %s: invalid field: %w", column.Name, err)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// Projector reads the values of the configured columns. A nil Projector has no columns.
+type Projector struct {
+	columns []Column
+}
+
+// New creates the projector of a validated configuration
+func New(cfg Config) *Projector {
+	p := &Projector{}
+	for _, c := range cfg.Columns {
+		if c.Source == "" {
+			c.Source = SourceRollout
+		}
+		p.columns = append(p.columns, c)
+	}
+	return p
+}
+
+// Columns returns the definitions of the columns in the order of the configuration
+func (p *Projector) Columns() []Definition {
+	if p == nil {
+		return nil
+	}
+	definitions := make([]Definition, 0, len(p.columns))
+	for _, c := range p.columns {
+		title := c.Title
+		if title == "" {
+			title = c.Name
+		}
+		definitions = append(definitions, Definition{Name: c.Name, Title: title})
+	}
+	return definitions
+}
+
+// Reads reports whether a column reads from source, so referenced resources are only fetched
+// when needed
+func (p *Projector) Reads(source string) bool {
+	if p == nil {
+		return false
+	}
+	for _, c := range p.columns {
+		if c.Source == source {
+			return true
+		}
+	}
+	return false
+}
+
+// Project returns the column values of a rollout from the objects of each source, keyed by column
+// name. Sources without an object, e.g. a rollout without an ImagePolicy, and fields that do not
+// exist give no value.
+func (p *Projector) Project(sources map[string]client.Object) map[string]string {
+	if p == nil {
+		return nil
+	}
+	values := map[string]string{}
+	var contents map[string]map[string]any
+	for _, c := range p.columns {
+		object := sources[c.Source]
+		if object == nil {
+			continue
+		}
+		var value string
+		switch {
+		case c.Label != "":
+			value = object.GetLabels()[c.Label]
+		case c.Annotation != "":
+			value = object.GetAnnotations()[c.Annotation]
+		default:
+			if contents == nil {
+				contents = map[string]map[string]any{}
+			}
+			content, ok := contents[c.Source]
+			if !ok {
+				content, _ = runtime.DefaultUnstructuredConverter.ToUnstructured(object)
+				contents[c.Source] = content
+			}
+			// Parsed per use since a JSONPath keeps state while executing
+			field, err := parseField(c.Field)
+			var out bytes.Buffer
+			if err == nil && field.Execute(&out, content) == nil {
+				value = out.String()
+			}
+		}
+		if value != "" {
+			values[c.Name] = value
+		}
+	}
+	return values
+}
+
+// parseField compiles a JSONPath expression, adding the braces kubectl makes optional
+func parseField(field string) (*jsonpath.JSONPath, error) {
+	if !strings.HasPrefix(field, "{") {
+		field = "{" + field + "}"
+	}
+	path := jsonpath.New("column").AllowMissingKeys(true)
+	if err := path.Parse(field); err != nil {
+		return nil, err
+	}
+	return path, nil
+}
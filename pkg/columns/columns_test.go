@@ -0,0 +1,81 @@
+package columns
+
+import (
+	"testing"
+
+	imagereflectorv1beta2 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`
+columns:
+  - name: tier
+    label: example.com/tier
+  - name: image
+    source: imageRepository
+    field: .spec.image
+`))
+	require.NoError(t, err)
+	assert.Len(t, cfg.Columns, 2)
+
+	for _, invalid := range []string{
+		`columns: [{label: example.com/tier}]`,
+		`columns: [{name: tier, label: a}, {name: tier, label: b}]`,
+		`columns: [{name: tier}]`,
+		`columns: [{name: tier, label: a, annotation: b}]`,
+		`columns: [{name: tier, source: kustomization, label: a}]`,
+		`columns: [{name: tier, field: "{.spec["}]`,
+		`columns: [{name: tier, label: a, color: red}]`,
+	} {
+		_, err := ParseConfig([]byte(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestProject(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`
+columns:
+  - name: tier
+    title: Tier
+    label: example.com/tier
+  - name: cost-center
+    annotation: example.com/cost-center
+  - name: wanted
+    field: .spec.wantedVersion
+  - name: missing
+    field: "{.status.history[0].version.tag}"
+  - name: image
+    source: imageRepository
+    field: .spec.image
+  - name: policy
+    source: imagePolicy
+    label: example.com/policy
+`))
+	require.NoError(t, err)
+	projector := New(cfg)
+	assert.Equal(t, []Definition{
+		{Name: "tier", Title: "Tier"}, {Name: "cost-center", Title: "cost-center"}, {Name: "wanted", Title: "wanted"},
+		{Name: "missing", Title: "missing"}, {Name: "image", Title: "image"}, {Name: "policy", Title: "policy"},
+	}, projector.Columns())
+	assert.True(t, projector.Reads(SourceImageRepository))
+
+	rollout := &rolloutv1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop",
+			Labels:      map[string]string{"example.com/tier": "gold"},
+			Annotations: map[string]string{"example.com/cost-center": "cc-42"}},
+		Spec: rolloutv1alpha1.RolloutSpec{WantedVersion: ptr.To("v1.2.3")},
+	}
+	repository := &imagereflectorv1beta2.ImageRepository{Spec: imagereflectorv1beta2.ImageRepositorySpec{Image: "ghcr.io/example/web"}}
+	values := projector.Project(map[string]client.Object{SourceRollout: rollout, SourceImageRepository: repository})
+	assert.Equal(t, map[string]string{"tier": "gold", "cost-center": "cc-42", "wanted": "v1.2.3", "image": "ghcr.io/example/web"}, values)
+
+	var none *Projector
+	assert.Nil(t, none.Columns())
+	assert.Nil(t, none.Project(map[string]client.Object{SourceRollout: rollout}))
+}
@@ -0,0 +1,154 @@
+package cors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Config is the cross-origin policy of the API. CORS is disabled when AllowedOrigins is empty,
+// which keeps the default same-origin deployment unchanged.
+type Config struct {
+	// AllowedOrigins lists origins such as "https://backstage.example.com". "*" allows any
+	// origin and "https://*.example.com" any subdomain.
+	AllowedOrigins []string
+	// AllowedHeaders are the request headers a cross-origin client may send
+	AllowedHeaders []string
+	// ExposedHeaders are response headers readable by cross-origin clients
+	ExposedHeaders []string
+	// AllowCredentials allows cookies and HTTP authentication on cross-origin requests
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache preflight responses
+	MaxAge time.Duration
+}
+
+// ErrWildcardCredentials is returned for policies allowing credentials from wildcard origins, which
+// would let any matching site act as the signed-in user
+var ErrWildcardCredentials = errors.New("wildcard origins cannot be allowed with credentials")
+
+var (
+	defaultAllowedHeaders = []string{"Authorization", "Content-Type", "X-Request-ID", "If-None-Match"}
+	defaultExposedHeaders = []string{"X-Request-ID", "X-API-Version", "ETag"}
+	allowedMethods        = strings.Join([]string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions}, ", ")
+)
+
+// ConfigFromEnv reads the policy from the environment:
+//   - CORS_ALLOWED_ORIGINS: comma-separated origins, empty disables CORS
+//   - CORS_ALLOWED_HEADERS: comma-separated request headers (default Authorization, Content-Type,
+//     X-Request-ID, If-None-Match)
+//   - CORS_ALLOW_CREDENTIALS: true to allow credentials, only from origins listed without wildcards
+//   - CORS_MAX_AGE: preflight cache duration (default 10m)
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		AllowedOrigins: splitList(os.Getenv("CORS_ALLOWED_ORIGINS")),
+		AllowedHeaders: splitList(os.Getenv("CORS_ALLOWED_HEADERS")),
+		ExposedHeaders: defaultExposedHeaders,
+		MaxAge:         10 * time.Minute,
+	}
+	if len(cfg.AllowedHeaders) == 0 {
+		cfg.AllowedHeaders = defaultAllowedHeaders
+	}
+	cfg.AllowCredentials, _ = strconv.ParseBool(os.Getenv("CORS_ALLOW_CREDENTIALS"))
+	if maxAge, err := time.ParseDuration(os.Getenv("CORS_MAX_AGE")); err == nil {
+		cfg.MaxAge = maxAge
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// Validate rejects wildcard origins when credentials are allowed
+func (c Config) Validate() error {
+	if !c.AllowCredentials {
+		return nil
+	}
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.Contains(allowed, "://*.") {
+			return fmt.Errorf("%w: %s", ErrWildcardCredentials, allowed)
+		}
+	}
+	return nil
+}
+
+// Enabled reports whether any cross-origin requests are allowed
+func (c Config) Enabled() bool {
+	return len(c.AllowedOrigins) > 0
+}
+
+// AllowsOrigin reports whether the origin matches the policy
+func (c Config) AllowsOrigin(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	for _, allowed := range c.AllowedOrigins {
+		switch {
+		case allowed == "*":
+			return true
+		case strings.EqualFold(allowed, origin):
+			return true
+		case strings.Contains(allowed, "://*."):
+			scheme, domain, _ := strings.Cut(allowed, "://*.")
+			if strings.HasPrefix(origin, scheme+"://") && strings.HasSuffix(strings.ToLower(origin), "."+strings.ToLower(domain)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Middleware applies the policy. Preflight requests from allowed origins are answered directly;
+// requests from other origins are passed through without CORS headers, so browsers block them.
+func Middleware(cfg Config) gin.HandlerFunc {
+	allowedHeaders := strings.Join(cfg.AllowedHeaders, ", ")
+	exposedHeaders := strings.Join(cfg.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		if !cfg.Enabled() {
+			c.Next()
+			return
+		}
+
+		origin := c.GetHeader("Origin")
+		c.Writer.Header().Add("Vary", "Origin")
+		if !cfg.AllowsOrigin(origin) {
+			c.Next()
+			return
+		}
+
+		// The origin is echoed rather than "*", which browsers refuse together with credentials.
+		// Credentials are only allowed for origins listed without wildcards, see Validate.
+		c.Header("Access-Control-Allow-Origin", origin)
+		if cfg.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			c.Header("Access-Control-Allow-Methods", allowedMethods)
+			c.Header("Access-Control-Allow-Headers", allowedHeaders)
+			c.Header("Access-Control-Max-Age", maxAge)
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Header("Access-Control-Expose-Headers", exposedHeaders)
+		c.Next()
+	}
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,92 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRouter(cfg Config) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware(cfg))
+	r.GET("/api/v1/rollouts", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_ORIGINS", " https://backstage.example.com, https://*.example.org ")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+
+	_, err := ConfigFromEnv()
+	assert.ErrorIs(t, err, ErrWildcardCredentials)
+
+	t.Setenv("CORS_ALLOWED_ORIGINS", " https://backstage.example.com, https://dev.example.org ")
+	cfg, err := ConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://backstage.example.com", "https://dev.example.org"}, cfg.AllowedOrigins)
+	assert.Equal(t, defaultAllowedHeaders, cfg.AllowedHeaders)
+	assert.True(t, cfg.AllowCredentials)
+
+	// Wildcards are fine without credentials
+	t.Setenv("CORS_ALLOWED_ORIGINS", "*")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "false")
+	cfg, err = ConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*"}, cfg.AllowedOrigins)
+}
+
+func TestAllowsOrigin(t *testing.T) {
+	cfg := Config{AllowedOrigins: []string{"https://backstage.example.com", "https://*.example.org"}}
+
+	assert.True(t, cfg.AllowsOrigin("https://backstage.example.com"))
+	assert.True(t, cfg.AllowsOrigin("https://dev.example.org"))
+	assert.False(t, cfg.AllowsOrigin("http://dev.example.org"))
+	assert.False(t, cfg.AllowsOrigin("https://example.org.evil.com"))
+	assert.False(t, cfg.AllowsOrigin(""))
+	assert.True(t, Config{AllowedOrigins: []string{"*"}}.AllowsOrigin("https://anything"))
+}
+
+func TestMiddleware_Preflight(t *testing.T) {
+	r := newTestRouter(Config{AllowedOrigins: []string{"https://backstage.example.com"}, AllowedHeaders: defaultAllowedHeaders})
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/v1/rollouts", nil)
+	req.Header.Set("Origin", "https://backstage.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://backstage.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+}
+
+func TestMiddleware_DisallowedOrigin(t *testing.T) {
+	r := newTestRouter(Config{AllowedOrigins: []string{"https://backstage.example.com"}})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/rollouts", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestMiddleware_Disabled(t *testing.T) {
+	r := newTestRouter(Config{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/rollouts", nil)
+	req.Header.Set("Origin", "https://backstage.example.com")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Vary"))
+}
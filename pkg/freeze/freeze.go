@@ -0,0 +1,211 @@
+// Package freeze decides whether changes to rollouts are frozen by a deployment freeze window,
+// e.g. over the weekend or the holidays.
+package freeze
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+)
+
+// maxDuration bounds how long a window lasts after each start of its schedule
+const maxDuration = 31 * 24 * time.Hour
+
+// ErrFrozen is wrapped by the errors of changes rejected during a freeze window
+var ErrFrozen = errors.New("deployment freeze in effect")
+
+// Config are the freeze windows. It is read from a YAML or JSON file:
+//
+//	adminGroups: [platform-admins]
+//	windows:
+//	  - name: weekend
+//	    schedule: "0 18 * * FRI"
+//	    duration: 62h
+//	    timeZone: Europe/Berlin
+//	    message: No deploys over the weekend
+//	  - name: payments-month-end
+//	    schedule: "0 0 28 * *"
+//	    duration: 96h
+//	    namespaces: [payments]
+//	    selector:
+//	      matchLabels: {tier: critical}
+type Config struct {
+	// AdminGroups may override an active freeze window
+	AdminGroups []string `json:"adminGroups,omitempty"`
+	Windows     []Window `json:"windows"`
+}
+
+// Window freezes the rollouts it selects for Duration after every start of its Schedule. A window
+// without namespaces and selector freezes every rollout.
+type Window struct {
+	Name string `json:"name"`
+	// Schedule is a five-field cron expression of the window's starts
+	Schedule string          `json:"schedule"`
+	Duration metav1.Duration `json:"duration"`
+	// TimeZone the schedule is evaluated in, UTC by default
+	TimeZone string `json:"timeZone,omitempty"`
+	// Namespaces limits the window to rollouts in these namespaces
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Selector limits the window to rollouts with matching labels
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// Message tells users why changes are frozen
+	Message string `json:"message,omitempty"`
+
+	schedule *cronSchedule
+	location *time.Location
+	selector labels.Selector
+}
+
+// ActiveWindow is a window freezing a rollout
+type ActiveWindow struct {
+	Name    string    `json:"name"`
+	Message string    `json:"message,omitempty"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+}
+
+// FrozenError rejects a change during an active window
+type FrozenError struct {
+	Window ActiveWindow
+}
+
+func (e *FrozenError) Error() string {
+	message := fmt.Sprintf("%s: window %s until %s", ErrFrozen, e.Window.Name, e.Window.End.Format(time.RFC3339))
+	if e.Window.Message != "" {
+		message += ": " + e.Window.Message
+	}
+	return message
+}
+
+func (e *FrozenError) Unwrap() error {
+	return ErrFrozen
+}
+
+// ConfigFromEnv reads the configuration file named by FREEZE_CONFIG. Without it there are no
+// windows and nothing is frozen.
+func ConfigFromEnv() (Config, error) {
+	path := os.Getenv("FREEZE_CONFIG")
+	if path == "" {
+		return Config{}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read freeze config: %w", err)
+	}
+	return ParseConfig(data)
+}
+
+// ParseConfig parses and validates a YAML or JSON configuration
+func ParseConfig(data []byte) (Config, error) {
+	var cfg Config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to parse freeze config: %w", err)
+	}
+	names := map[string]bool{}
+	for i := range cfg.Windows {
+		window := &cfg.Windows[i]
+		if window.Name == "" {
+			return Config{}, fmt.Errorf("window %d: name is required", i)
+		}
+		if names[window.Name] {
+			return Config{}, fmt.Errorf("window %s: defined more than once", window.Name)
+		}
+		names[window.Name] = true
+		if window.Duration.Duration < time.Minute || window.Duration.Duration > maxDuration {
+			return Config{}, fmt.Errorf("window %s: duration must be between 1m and %s", window.Name, maxDuration)
+		}
+		var err error
+		if window.schedule, err = parseCron(window.Schedule); err != nil {
+			return Config{}, fmt.Errorf("window %s: invalid schedule: %w", window.Name, err)
+		}
+		if window.location, err = time.LoadLocation(window.TimeZone); err != nil {
+			return Config{}, fmt.Errorf("window %s: invalid time zone: %w", window.Name, err)
+		}
+		if window.Selector != nil {
+			if window.selector, err = metav1.LabelSelectorAsSelector(window.Selector); err != nil {
+				return Config{}, fmt.Errorf("window %s: invalid selector: %w", window.Name, err)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// Policy decides whether changes to a rollout are frozen by the windows of a Config
+type Policy struct {
+	cfg Config
+}
+
+// New returns the policy of a configuration returned by ParseConfig or ConfigFromEnv
+func New(cfg Config) *Policy {
+	return &Policy{cfg: cfg}
+}
+
+// UsesLabels reports whether a window selects rollouts by their labels, so Active needs them
+func (p *Policy) UsesLabels() bool {
+	for _, window := range p.cfg.Windows {
+		if window.selector != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// CanOverride reports whether a user in groups may change rollouts during an active window
+func (p *Policy) CanOverride(groups []string) bool {
+	for _, group := range groups {
+		if slices.Contains(p.cfg.AdminGroups, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// Active returns the windows freezing the rollout in namespace with rolloutLabels at now, the one
+// ending last first
+func (p *Policy) Active(namespace string, rolloutLabels map[string]string, now time.Time) []ActiveWindow {
+	var active []ActiveWindow
+	for _, window := range p.cfg.Windows {
+		if len(window.Namespaces) > 0 && !slices.Contains(window.Namespaces, namespace) {
+			continue
+		}
+		if window.selector != nil && !window.selector.Matches(labels.Set(rolloutLabels)) {
+			continue
+		}
+		if start, ok := window.lastStart(now); ok {
+			active = append(active, ActiveWindow{
+				Name:    window.Name,
+				Message: window.Message,
+				Start:   start,
+				End:     start.Add(window.Duration.Duration),
+			})
+		}
+	}
+	slices.SortStableFunc(active, func(a, b ActiveWindow) int { return b.End.Compare(a.End) })
+	return active
+}
+
+// Check returns a FrozenError when a window freezes the rollout at now
+func (p *Policy) Check(namespace string, rolloutLabels map[string]string, now time.Time) error {
+	if active := p.Active(namespace, rolloutLabels, now); len(active) > 0 {
+		return &FrozenError{Window: active[0]}
+	}
+	return nil
+}
+
+// lastStart returns the latest start of the window that is still in effect at now
+func (w *Window) lastStart(now time.Time) (time.Time, bool) {
+	minute := now.In(w.location).Truncate(time.Minute)
+	for elapsed := time.Duration(0); elapsed < w.Duration.Duration; elapsed += time.Minute {
+		start := minute.Add(-elapsed)
+		if w.schedule.matches(start) && start.Add(w.Duration.Duration).After(now) {
+			return start, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,106 @@
+package freeze
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `
+adminGroups: [platform-admins]
+windows:
+  - name: weekend
+    schedule: "0 18 * * FRI"
+    duration: 62h
+    timeZone: Europe/Berlin
+    message: No deploys over the weekend
+  - name: payments-month-end
+    schedule: "0 0 28 * *"
+    duration: 96h
+    namespaces: [payments]
+    selector:
+      matchLabels: {tier: critical}
+`
+
+func TestPolicy(t *testing.T) {
+	cfg, err := ParseConfig([]byte(testConfig))
+	require.NoError(t, err)
+	policy := New(cfg)
+	assert.True(t, policy.UsesLabels())
+	assert.True(t, policy.CanOverride([]string{"developers", "platform-admins"}))
+	assert.False(t, policy.CanOverride([]string{"developers"}))
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	friday := time.Date(2026, time.October, 16, 18, 0, 0, 0, berlin)
+
+	assert.Empty(t, policy.Active("shop", nil, friday.Add(-time.Minute)))
+	active := policy.Active("shop", nil, friday.Add(30*time.Hour))
+	require.Len(t, active, 1)
+	assert.Equal(t, "weekend", active[0].Name)
+	assert.True(t, active[0].Start.Equal(friday))
+	assert.True(t, active[0].End.Equal(time.Date(2026, time.October, 19, 8, 0, 0, 0, berlin)))
+	assert.Empty(t, policy.Active("shop", nil, time.Date(2026, time.October, 19, 8, 0, 0, 0, berlin)))
+
+	monthEnd := time.Date(2026, time.October, 29, 12, 0, 0, 0, time.UTC)
+	assert.Empty(t, policy.Active("payments", map[string]string{"tier": "batch"}, monthEnd))
+	assert.Empty(t, policy.Active("shop", map[string]string{"tier": "critical"}, monthEnd))
+	err = policy.Check("payments", map[string]string{"tier": "critical"}, monthEnd)
+	assert.True(t, errors.Is(err, ErrFrozen))
+	assert.Contains(t, err.Error(), "window payments-month-end until 2026-11-01T00:00:00Z")
+	assert.NoError(t, policy.Check("payments", nil, monthEnd))
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	for name, config := range map[string]string{
+		"missing name":     `windows: [{schedule: "* * * * *", duration: 1h}]`,
+		"duplicate name":   `windows: [{name: a, schedule: "* * * * *", duration: 1h}, {name: a, schedule: "* * * * *", duration: 1h}]`,
+		"no duration":      `windows: [{name: a, schedule: "* * * * *"}]`,
+		"invalid schedule": `windows: [{name: a, schedule: "0 25 * * *", duration: 1h}]`,
+		"invalid timezone": `windows: [{name: a, schedule: "* * * * *", duration: 1h, timeZone: Mars/Olympus}]`,
+		"unknown field":    `windows: [{name: a, schedule: "* * * * *", duration: 1h, cron: x}]`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseConfig([]byte(config))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCronSchedule(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		// October 2026 starts on a Thursday
+		return time.Date(2026, time.October, day, hour, minute, 0, 0, time.UTC)
+	}
+	for _, tc := range []struct {
+		expr    string
+		time    time.Time
+		matches bool
+	}{
+		{"*/15 * * * *", at(1, 10, 45), true},
+		{"*/15 * * * *", at(1, 10, 46), false},
+		{"0 8-18/2 * * *", at(1, 12, 0), true},
+		{"0 8-18/2 * * *", at(1, 13, 0), false},
+		{"0 0 * * MON-FRI", at(5, 0, 0), true},
+		{"0 0 * * MON-FRI", at(4, 0, 0), false},
+		{"0 0 * * 7", at(4, 0, 0), true},
+		{"0 0 * OCT *", at(4, 0, 0), true},
+		{"0 0 * jan,feb *", at(4, 0, 0), false},
+		// Both day fields restricted: either matches
+		{"0 0 1 * SUN", at(1, 0, 0), true},
+		{"0 0 1 * SUN", at(11, 0, 0), true},
+		{"0 0 1 * SUN", at(12, 0, 0), false},
+	} {
+		schedule, err := parseCron(tc.expr)
+		require.NoError(t, err, tc.expr)
+		assert.Equal(t, tc.matches, schedule.matches(tc.time), "%s at %s", tc.expr, tc.time)
+	}
+
+	for _, expr := range []string{"* * * *", "60 * * * *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "* * * FOO *"} {
+		_, err := parseCron(expr)
+		assert.Error(t, err, expr)
+	}
+}
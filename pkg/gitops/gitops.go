@@ -0,0 +1,279 @@
+// Package gitops writes version changes of rollouts whose Rollout resources live in git back to
+// the repository as pull or merge requests, for clusters where the dashboard must not patch
+// resources that Flux applies
+package gitops
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kuberik/rollout-dashboard/pkg/gitprovider"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// defaultPatch pins the rollout by setting spec.wantedVersion
+const defaultPatch = "spec:\n  wantedVersion: $version\n"
+
+// ErrRolloutNotInFile is returned when the file of a write-back target has no Rollout of the name
+var ErrRolloutNotInFile = errors.New("rollout not found in file")
+
+// pathVariable matches the placeholders of path templates
+var pathVariable = regexp.MustCompile(`\$(namespace|name)\b`)
+
+// Config holds where the Rollout resources of rollouts live in git. It is read from a YAML or JSON
+// file:
+//
+//	writeBack:
+//	  - repository: https://github.com/example/fleet
+//	    branch: main
+//	    path: clusters/production/$namespace/$name/rollout.yaml
+//	    namespaces: [shop, payments]
+//	  - repository: https://gitlab.example.com/platform/apps
+//	    path: $namespace/values.yaml
+//	    patch: |
+//	      spec:
+//	        wantedVersion: $version
+type Config struct {
+	WriteBack []Target `json:"writeBack"`
+}
+
+// Target is a repository holding Rollout resources. Its path may use the placeholders $namespace
+// and $name. Patch is a YAML merge patch applied to the Rollout in the file, in which $version is
+// replaced by the version to pin; when unpinning, fields referencing $version are removed. It
+// defaults to setting spec.wantedVersion.
+type Target struct {
+	Repository string `json:"repository"`
+	// Branch is the branch Flux applies, the repository's default branch when empty
+	Branch string `json:"branch,omitempty"`
+	Path   string `json:"path"`
+	// Namespaces limits the target to rollouts in these namespaces, all when empty
+	Namespaces []string `json:"namespaces,omitempty"`
+	Patch      string   `json:"patch,omitempty"`
+}
+
+// Proposal is a version change of a rollout proposed as a pull or merge request
+type Proposal struct {
+	Repository  string                     `json:"repository"`
+	Base        string                     `json:"base,omitempty"`
+	Branch      string                     `json:"branch"`
+	Path        string                     `json:"path"`
+	PullRequest *gitprovider.ChangeRequest `json:"pullRequest"`
+}
+
+// ConfigFromEnv reads the configuration file named by GITOPS_CONFIG. Without it every rollout is
+// changed in the cluster.
+func ConfigFromEnv() (Config, error) {
+	path := os.Getenv("GITOPS_CONFIG")
+	if path == "" {
+		return Config{}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read gitops config: %w", err)
+	}
+	return ParseConfig(data)
+}
+
+// ParseConfig parses and validates a YAML or JSON configuration
+func ParseConfig(data []byte) (Config, error) {
+	var cfg Config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to parse gitops config: %w", err)
+	}
+	for i, target := range cfg.WriteBack {
+		if target.Repository == "" || target.Path == "" {
+			return Config{}, fmt.Errorf("writeBack %d: repository and path are required", i)
+		}
+		if _, err := expandPatch(target.patch(), nil); err != nil {
+			return Config{}, fmt.Errorf("writeBack %d: %w", i, err)
+		}
+	}
+	return cfg, nil
+}
+
+func (t Target) patch() string {
+	if t.Patch == "" {
+		return defaultPatch
+	}
+	return t.Patch
+}
+
+// WriteBack proposes version changes of the configured rollouts on GitHub or GitLab
+type WriteBack struct {
+	cfg     Config
+	changes *gitprovider.Client
+}
+
+// New creates the write-back of a configuration, proposing changes with changes
+func New(cfg Config, changes *gitprovider.Client) *WriteBack {
+	return &WriteBack{cfg: cfg, changes: changes}
+}
+
+// Target returns where the Rollout resource of a rollout lives in git, nil when the rollout is
+// changed in the cluster. A nil WriteBack has no targets.
+func (w *WriteBack) Target(namespace string) *Target {
+	if w == nil {
+		return nil
+	}
+	for i, target := range w.cfg.WriteBack {
+		if len(target.Namespaces) == 0 || slices.Contains(target.Namespaces, namespace) {
+			return &w.cfg.WriteBack[i]
+		}
+	}
+	return nil
+}
+
+// ProposeVersion opens a pull or merge request pinning the rollout to version, or unpinning it
+// when version is nil, in the file of target. Message and user are recorded in its description.
+func (w *WriteBack) ProposeVersion(ctx context.Context, target *Target, namespace, name string, version *string, message, user string) (*Proposal, error) {
+	patch, err := expandPatch(target.patch(), version)
+	if err != nil {
+		return nil, err
+	}
+	path := pathVariable.ReplaceAllStringFunc(target.Path, func(placeholder string) string {
+		if placeholder == "$namespace" {
+			return namespace
+		}
+		return name
+	})
+	title, suffix := fmt.Sprintf("Unpin %s/%s", namespace, name), "unpin"
+	if version != nil {
+		title, suffix = fmt.Sprintf("Pin %s/%s to %s", namespace, name, *version), *version
+	}
+	description := message
+	if user != "" {
+		description = strings.TrimSpace(description + "\n\nRequested by " + user + " in the rollout dashboard.")
+	}
+	proposal := &Proposal{
+		Repository: target.Repository,
+		Base:       target.Branch,
+		Branch:     fmt.Sprintf("rollout-dashboard/%s/%s/%s-%d", namespace, name, suffix, time.Now().Unix()),
+		Path:       path,
+	}
+	proposal.PullRequest, err = w.changes.ProposeChange(ctx, gitprovider.FileChange{
+		Repository: target.Repository,
+		Base:       target.Branch,
+		Branch:     proposal.Branch,
+		Path:       path,
+		Edit: func(content []byte) ([]byte, error) {
+			return EditRollout(content, namespace, name, patch)
+		},
+		Message:     title,
+		Title:       title,
+		Description: description,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return proposal, nil
+}
+
+// expandPatch parses a patch template, replacing $version in its values by version. Without a
+// version, the fields whose values reference it are removed.
+func expandPatch(template string, version *string) (map[string]any, error) {
+	var patch map[string]any
+	if err := yaml.Unmarshal([]byte(template), &patch); err != nil {
+		return nil, fmt.Errorf("invalid patch: %w", err)
+	}
+	if patch == nil {
+		return nil, errors.New("invalid patch: must be a mapping")
+	}
+	var expand func(value any) any
+	expand = func(value any) any {
+		switch value := value.(type) {
+		case map[string]any:
+			for key, field := range value {
+				value[key] = expand(field)
+			}
+		case string:
+			if !strings.Contains(value, "$version") {
+				return value
+			}
+			if version == nil {
+				return nil
+			}
+			return strings.ReplaceAll(value, "$version", *version)
+		}
+		return value
+	}
+	return expand(patch).(map[string]any), nil
+}
+
+// EditRollout applies a merge patch to the kuberik.com Rollout named name in a YAML file of one or
+// more documents, keeping the comments and formatting of the rest of the file. Rollouts in other
+// namespaces than namespace are not changed, nor are other documents.
+func EditRollout(content []byte, namespace, name string, patch map[string]any) ([]byte, error) {
+	var out bytes.Buffer
+	readWriter := &kio.ByteReadWriter{Reader: bytes.NewReader(content), Writer: &out, PreserveSeqIndent: true}
+	nodes, err := readWriter.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse file: %w", err)
+	}
+	found := false
+	for _, node := range nodes {
+		if node.GetKind() != "Rollout" || !strings.HasPrefix(node.GetApiVersion(), "kuberik.com/") || node.GetName() != name {
+			continue
+		}
+		if ns := node.GetNamespace(); ns != "" && ns != namespace {
+			continue
+		}
+		if err := mergeNode(node, patch); err != nil {
+			return nil, err
+		}
+		found = true
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s/%s", ErrRolloutNotInFile, namespace, name)
+	}
+	if err := readWriter.Write(nodes); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// mergeNode applies a merge patch to a mapping node: nil values remove fields, mappings are merged
+// and scalars replace the field
+func mergeNode(node *kyaml.RNode, patch map[string]any) error {
+	keys := make([]string, 0, len(patch))
+	for key := range patch {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		switch value := patch[key].(type) {
+		case nil:
+			if _, err := node.Pipe(kyaml.Clear(key)); err != nil {
+				return err
+			}
+		case map[string]any:
+			child, err := node.Pipe(kyaml.LookupCreate(kyaml.MappingNode, key))
+			if err != nil {
+				return err
+			}
+			if err := mergeNode(child, value); err != nil {
+				return err
+			}
+		case []any:
+			return fmt.Errorf("invalid patch: %s: lists cannot be merged", key)
+		case string:
+			if err := node.PipeE(kyaml.SetField(key, kyaml.NewStringRNode(value))); err != nil {
+				return err
+			}
+		default:
+			if err := node.PipeE(kyaml.SetField(key, kyaml.NewScalarRNode(fmt.Sprint(value)))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package gitops
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kuberik/rollout-dashboard/pkg/gitprovider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+const rolloutFile = `# Checkout service
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+---
+apiVersion: kuberik.com/v1alpha1
+kind: Rollout
+metadata:
+  name: app
+  namespace: shop
+spec:
+  releasesImagePolicy:
+    name: app # tracks semver
+  healthCheckSelector:
+    selector:
+      matchLabels:
+        app: app
+`
+
+func TestEditRollout(t *testing.T) {
+	patch, err := expandPatch(defaultPatch, ptr.To("1.2.0"))
+	require.NoError(t, err)
+	edited, err := EditRollout([]byte(rolloutFile), "shop", "app", patch)
+	require.NoError(t, err)
+	assert.Equal(t, `# Checkout service
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+---
+apiVersion: kuberik.com/v1alpha1
+kind: Rollout
+metadata:
+  name: app
+  namespace: shop
+spec:
+  releasesImagePolicy:
+    name: app # tracks semver
+  healthCheckSelector:
+    selector:
+      matchLabels:
+        app: app
+  wantedVersion: 1.2.0
+`, string(edited))
+
+	// Versions that would parse as numbers stay strings
+	patch, err = expandPatch(defaultPatch, ptr.To("2.0"))
+	require.NoError(t, err)
+	numeric, err := EditRollout([]byte(rolloutFile), "shop", "app", patch)
+	require.NoError(t, err)
+	assert.Contains(t, string(numeric), `wantedVersion: "2.0"`)
+
+	// Unpinning removes the field again
+	patch, err = expandPatch(defaultPatch, nil)
+	require.NoError(t, err)
+	unpinned, err := EditRollout(edited, "shop", "app", patch)
+	require.NoError(t, err)
+	assert.Equal(t, rolloutFile, string(unpinned))
+
+	_, err = EditRollout([]byte(rolloutFile), "payments", "app", patch)
+	assert.ErrorIs(t, err, ErrRolloutNotInFile)
+}
+
+func TestProposeVersionGitHub(t *testing.T) {
+	var committed []byte
+	var pull map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v3/repos/example/fleet/contents/clusters/shop/app.yaml":
+			assert.Equal(t, "main", r.URL.Query().Get("ref"))
+			json.NewEncoder(w).Encode(map[string]string{"content": base64.StdEncoding.EncodeToString([]byte(rolloutFile)), "sha": "f1"})
+		case "GET /api/v3/repos/example/fleet/git/ref/heads/main":
+			w.Write([]byte(`{"object":{"sha":"c0ffee1"}}`))
+		case "POST /api/v3/repos/example/fleet/git/refs":
+			var ref map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&ref))
+			assert.Equal(t, "c0ffee1", ref["sha"])
+			w.WriteHeader(http.StatusCreated)
+		case "PUT /api/v3/repos/example/fleet/contents/clusters/shop/app.yaml":
+			var commit map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&commit))
+			assert.Equal(t, "f1", commit["sha"])
+			committed, _ = base64.StdEncoding.DecodeString(commit["content"])
+		case "POST /api/v3/repos/example/fleet/pulls":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&pull))
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"number":12,"title":"Pin shop/app to 1.2.0","html_url":"https://github.example.com/example/fleet/pull/12","state":"open"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	github, err := gitprovider.NewGitHub(srv.URL, "")
+	require.NoError(t, err)
+	cfg, err := ParseConfig([]byte("writeBack:\n- repository: " + srv.URL + "/example/fleet\n  branch: main\n  path: clusters/$namespace/$name.yaml\n  namespaces: [shop]\n"))
+	require.NoError(t, err)
+	writeBack := New(cfg, gitprovider.New(github))
+	assert.Nil(t, writeBack.Target("payments"))
+	target := writeBack.Target("shop")
+	require.NotNil(t, target)
+
+	proposal, err := writeBack.ProposeVersion(context.Background(), target, "shop", "app", ptr.To("1.2.0"), "Hotfix for checkout", "ada")
+	require.NoError(t, err)
+	assert.Equal(t, "clusters/shop/app.yaml", proposal.Path)
+	assert.Regexp(t, `^rollout-dashboard/shop/app/1\.2\.0-\d+$`, proposal.Branch)
+	assert.Equal(t, "https://github.example.com/example/fleet/pull/12", proposal.PullRequest.URL)
+	assert.Contains(t, string(committed), "wantedVersion: 1.2.0")
+	assert.Equal(t, proposal.Branch, pull["head"])
+	assert.Equal(t, "main", pull["base"])
+	assert.Equal(t, "Hotfix for checkout\n\nRequested by ada in the rollout dashboard.", pull["body"])
+
+	// Unpinning a rollout the file does not pin changes nothing
+	_, err = writeBack.ProposeVersion(context.Background(), target, "shop", "app", nil, "", "")
+	assert.ErrorIs(t, err, gitprovider.ErrNoChange)
+}
+
+func TestParseConfig(t *testing.T) {
+	for _, config := range []string{
+		"writeBack:\n- path: a.yaml\n",
+		"writeBack:\n- repository: https://github.com/example/fleet\n",
+		"writeBack:\n- repository: https://github.com/example/fleet\n  path: a.yaml\n  patch: '- a'\n",
+		"writeBack:\n- repository: https://github.com/example/fleet\n  path: a.yaml\n  branches: [main]\n",
+	} {
+		_, err := ParseConfig([]byte(config))
+		assert.Error(t, err, config)
+	}
+}
@@ -0,0 +1,192 @@
+package gitprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var (
+	// ErrFileNotFound is returned when the file to change does not exist on the base branch
+	ErrFileNotFound = errors.New("file not found")
+	// ErrNoChange is returned when editing the file leaves it as it is
+	ErrNoChange = errors.New("file is already up to date")
+)
+
+// FileChange is a change of a single file, proposed as a pull or merge request
+type FileChange struct {
+	// Repository is an HTTPS or SSH clone URL, see GetCommit
+	Repository string
+	// Base is the branch to change, the repository's default branch when empty
+	Base string
+	// Branch is created from Base for the change
+	Branch string
+	Path   string
+	// Edit returns the changed content of the file
+	Edit        func(content []byte) ([]byte, error)
+	Message     string
+	Title       string
+	Description string
+}
+
+// ProposeChange commits a change of a file to a new branch and opens a pull request (GitHub) or
+// merge request (GitLab) to merge it into the base branch
+func (c *Client) ProposeChange(ctx context.Context, change FileChange) (*ChangeRequest, error) {
+	provider, repository, err := c.repository(change.Repository)
+	if err != nil {
+		return nil, err
+	}
+	if provider.kind == GitLab {
+		return c.gitlabProposeChange(ctx, provider, repository, change)
+	}
+	return c.githubProposeChange(ctx, provider, repository, change)
+}
+
+// editFile decodes the base64 content of a file, as both providers return it, and edits it
+func editFile(change FileChange, encoded string) ([]byte, error) {
+	content, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(encoded, "\n", ""))
+	if err != nil {
+		return nil, err
+	}
+	edited, err := change.Edit(content)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(content, edited) {
+		return nil, ErrNoChange
+	}
+	return edited, nil
+}
+
+func (c *Client) githubProposeChange(ctx context.Context, provider Provider, repository string, change FileChange) (*ChangeRequest, error) {
+	base := provider.api + "/repos/" + repository
+	if change.Base == "" {
+		var info struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if _, err := c.request(ctx, provider, http.MethodGet, base, nil, &info); err != nil {
+			return nil, err
+		}
+		change.Base = info.DefaultBranch
+	}
+
+	var file struct {
+		Content string `json:"content"`
+		SHA     string `json:"sha"`
+	}
+	contents := base + "/contents/" + escapePath(change.Path)
+	status, err := c.request(ctx, provider, http.MethodGet, contents+"?ref="+url.QueryEscape(change.Base), nil, &file)
+	if status == http.StatusNotFound {
+		return nil, ErrFileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	edited, err := editFile(change, file.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	var ref struct {
+		Object struct {
+			SHA string `json:"sha"`
+		} `json:"object"`
+	}
+	if _, err := c.request(ctx, provider, http.MethodGet, base+"/git/ref/heads/"+escapePath(change.Base), nil, &ref); err != nil {
+		return nil, err
+	}
+	branch := map[string]string{"ref": "refs/heads/" + change.Branch, "sha": ref.Object.SHA}
+	if _, err := c.request(ctx, provider, http.MethodPost, base+"/git/refs", branch, nil); err != nil {
+		return nil, err
+	}
+	commit := map[string]string{
+		"message": change.Message,
+		"content": base64.StdEncoding.EncodeToString(edited),
+		"sha":     file.SHA,
+		"branch":  change.Branch,
+	}
+	if _, err := c.request(ctx, provider, http.MethodPut, contents, commit, nil); err != nil {
+		return nil, err
+	}
+
+	var pull struct {
+		Number  int    `json:"number"`
+		Title   string `json:"title"`
+		HTMLURL string `json:"html_url"`
+		State   string `json:"state"`
+	}
+	request := map[string]string{"title": change.Title, "head": change.Branch, "base": change.Base, "body": change.Description}
+	if _, err := c.request(ctx, provider, http.MethodPost, base+"/pulls", request, &pull); err != nil {
+		return nil, err
+	}
+	return &ChangeRequest{Number: pull.Number, Title: pull.Title, URL: pull.HTMLURL, State: pull.State}, nil
+}
+
+func (c *Client) gitlabProposeChange(ctx context.Context, provider Provider, repository string, change FileChange) (*ChangeRequest, error) {
+	base := provider.api + "/projects/" + url.PathEscape(repository)
+	if change.Base == "" {
+		var info struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if _, err := c.request(ctx, provider, http.MethodGet, base, nil, &info); err != nil {
+			return nil, err
+		}
+		change.Base = info.DefaultBranch
+	}
+
+	var file struct {
+		Content string `json:"content"`
+	}
+	status, err := c.request(ctx, provider, http.MethodGet, base+"/repository/files/"+url.PathEscape(change.Path)+"?ref="+url.QueryEscape(change.Base), nil, &file)
+	if status == http.StatusNotFound {
+		return nil, ErrFileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	edited, err := editFile(change, file.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	commit := map[string]any{
+		"branch":         change.Branch,
+		"start_branch":   change.Base,
+		"commit_message": change.Message,
+		"actions":        []map[string]string{{"action": "update", "file_path": change.Path, "content": string(edited)}},
+	}
+	if _, err := c.request(ctx, provider, http.MethodPost, base+"/repository/commits", commit, nil); err != nil {
+		return nil, err
+	}
+
+	var mergeRequest struct {
+		IID    int    `json:"iid"`
+		Title  string `json:"title"`
+		WebURL string `json:"web_url"`
+		State  string `json:"state"`
+	}
+	request := map[string]any{
+		"source_branch":        change.Branch,
+		"target_branch":        change.Base,
+		"title":                change.Title,
+		"description":          change.Description,
+		"remove_source_branch": true,
+	}
+	if _, err := c.request(ctx, provider, http.MethodPost, base+"/merge_requests", request, &mergeRequest); err != nil {
+		return nil, err
+	}
+	return &ChangeRequest{Number: mergeRequest.IID, Title: mergeRequest.Title, URL: mergeRequest.WebURL, State: mergeRequest.State}, nil
+}
+
+// escapePath escapes each segment of a slash separated path
+func escapePath(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,403 @@
+// Package gitprovider resolves the commit a release was built from, as named by its
+// org.opencontainers.image.source and revision annotations, to its message, author and pull
+// requests on GitHub or GitLab, so versions can be shown as changes instead of SHAs. It also
+// proposes changes of files as pull or merge requests, see ProposeChange.
+package gitprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// requestTimeout bounds a single request to a provider
+	requestTimeout = 30 * time.Second
+	// maxCachedCommits bounds the commits kept in memory; commits never change, so entries only
+	// leave the cache to make room
+	maxCachedCommits = 1000
+
+	defaultGitHubURL = "https://github.com"
+	defaultGitLabURL = "https://gitlab.com"
+)
+
+// Providers of a Commit
+const (
+	GitHub = "github"
+	GitLab = "gitlab"
+)
+
+var (
+	// ErrUnsupportedSource is returned for sources that are not on a configured provider
+	ErrUnsupportedSource = errors.New("source is not a repository of a configured git provider")
+	// ErrInvalidRevision is returned for revisions that do not name a commit SHA
+	ErrInvalidRevision = errors.New("revision does not name a commit")
+	// ErrCommitNotFound is returned when the provider does not know the commit
+	ErrCommitNotFound = errors.New("commit not found")
+)
+
+// sha matches abbreviated and full SHA-1 and SHA-256 commit hashes
+var sha = regexp.MustCompile(`^[0-9a-fA-F]{7,64}$`)
+
+// Commit is a commit with its author and the pull or merge requests that contain it
+type Commit struct {
+	Provider   string `json:"provider"`
+	Repository string `json:"repository"`
+	SHA        string `json:"sha"`
+	// Title is the first line of Message
+	Title      string          `json:"title"`
+	Message    string          `json:"message"`
+	Author     Author          `json:"author"`
+	AuthoredAt *time.Time      `json:"authoredAt,omitempty"`
+	URL        string          `json:"url"`
+	Changes    []ChangeRequest `json:"changes"`
+}
+
+// Author is who wrote a commit
+type Author struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+	// Login is the provider account, when the provider knows it
+	Login string `json:"login,omitempty"`
+}
+
+// ChangeRequest is a GitHub pull request or GitLab merge request
+type ChangeRequest struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	State  string `json:"state"`
+}
+
+// Provider is a GitHub or GitLab instance
+type Provider struct {
+	kind string
+	// host is the web host of repositories, e.g. github.com
+	host string
+	// api is the REST API endpoint
+	api string
+	// tokenFile holds an access token, re-read on every request so rotated tokens are picked up
+	tokenFile string
+}
+
+// Client looks up commits on the configured providers
+type Client struct {
+	providers []Provider
+	http      *http.Client
+
+	mu    sync.Mutex
+	cache map[string]*Commit
+}
+
+// New creates a client for the providers
+func New(providers ...Provider) *Client {
+	return &Client{providers: providers, http: &http.Client{Timeout: requestTimeout}, cache: map[string]*Commit{}}
+}
+
+// NewGitHub configures GitHub at baseURL (https://github.com or a GitHub Enterprise Server),
+// authenticating with the token in tokenFile unless it is empty
+func NewGitHub(baseURL, tokenFile string) (Provider, error) {
+	base, err := parseBaseURL(baseURL)
+	if err != nil {
+		return Provider{}, err
+	}
+	api := base.Scheme + "://api.github.com"
+	if base.Host != "github.com" {
+		api = base.String() + "/api/v3"
+	}
+	return Provider{kind: GitHub, host: base.Host, api: api, tokenFile: tokenFile}, nil
+}
+
+// NewGitLab configures GitLab at baseURL (https://gitlab.com or a self-managed instance),
+// authenticating with the token in tokenFile unless it is empty
+func NewGitLab(baseURL, tokenFile string) (Provider, error) {
+	base, err := parseBaseURL(baseURL)
+	if err != nil {
+		return Provider{}, err
+	}
+	return Provider{kind: GitLab, host: base.Host, api: base.String() + "/api/v4", tokenFile: tokenFile}, nil
+}
+
+func parseBaseURL(raw string) (*url.URL, error) {
+	base, err := url.Parse(strings.TrimSuffix(raw, "/"))
+	if err != nil || base.Host == "" || base.Scheme != "http" && base.Scheme != "https" {
+		return nil, fmt.Errorf("invalid git provider URL %q", raw)
+	}
+	return base, nil
+}
+
+// FromEnv creates a client for the providers configured in the environment, nil when there are
+// none. GitHub is configured by GITHUB_URL (default https://github.com) or GITHUB_TOKEN_FILE,
+// GitLab by GITLAB_URL (default https://gitlab.com) or GITLAB_TOKEN_FILE.
+func FromEnv() (*Client, error) {
+	var providers []Provider
+	for _, configure := range []struct {
+		urlEnv, tokenEnv, defaultURL string
+		provider                     func(baseURL, tokenFile string) (Provider, error)
+	}{
+		{"GITHUB_URL", "GITHUB_TOKEN_FILE", defaultGitHubURL, NewGitHub},
+		{"GITLAB_URL", "GITLAB_TOKEN_FILE", defaultGitLabURL, NewGitLab},
+	} {
+		baseURL, tokenFile := os.Getenv(configure.urlEnv), os.Getenv(configure.tokenEnv)
+		if baseURL == "" && tokenFile == "" {
+			continue
+		}
+		if baseURL == "" {
+			baseURL = configure.defaultURL
+		}
+		provider, err := configure.provider(baseURL, tokenFile)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+	if len(providers) == 0 {
+		return nil, nil
+	}
+	return New(providers...), nil
+}
+
+// ParseRevision returns the commit SHA of a revision annotation, which is either a plain SHA or,
+// as written by Flux, <branch or tag>@sha1:<SHA>
+func ParseRevision(revision string) (string, error) {
+	if i := strings.LastIndex(revision, ":"); i >= 0 {
+		revision = revision[i+1:]
+	} else if i := strings.LastIndex(revision, "@"); i >= 0 {
+		revision = revision[i+1:]
+	}
+	if !sha.MatchString(revision) {
+		return "", ErrInvalidRevision
+	}
+	return strings.ToLower(revision), nil
+}
+
+// GetCommit looks up the commit revision of the repository at source, an HTTPS or SSH clone URL
+// such as https://github.com/org/repo or git@gitlab.com:group/subgroup/repo.git
+func (c *Client) GetCommit(ctx context.Context, source, revision string) (*Commit, error) {
+	commitSHA, err := ParseRevision(revision)
+	if err != nil {
+		return nil, err
+	}
+	provider, repository, err := c.repository(source)
+	if err != nil {
+		return nil, err
+	}
+
+	key := provider.host + "/" + repository + "@" + commitSHA
+	c.mu.Lock()
+	cached, ok := c.cache[key]
+	c.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	var commit *Commit
+	switch provider.kind {
+	case GitHub:
+		commit, err = c.githubCommit(ctx, provider, repository, commitSHA)
+	case GitLab:
+		commit, err = c.gitlabCommit(ctx, provider, repository, commitSHA)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.cache) >= maxCachedCommits {
+		for evicted := range c.cache {
+			delete(c.cache, evicted)
+			break
+		}
+	}
+	c.cache[key] = commit
+	return commit, nil
+}
+
+// repository returns the provider hosting source and the repository's path on it
+func (c *Client) repository(source string) (Provider, string, error) {
+	host, path := "", ""
+	if rest, ok := strings.CutPrefix(source, "git@"); ok {
+		host, path, _ = strings.Cut(rest, ":")
+	} else if parsed, err := url.Parse(source); err == nil {
+		host, path = parsed.Host, parsed.Path
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	for _, provider := range c.providers {
+		if provider.host != host || strings.Count(path, "/") < 1 {
+			continue
+		}
+		if provider.kind == GitHub {
+			// Paths below a GitHub repository, e.g. /tree/main/deploy, are not part of its name
+			parts := strings.SplitN(path, "/", 3)
+			path = parts[0] + "/" + parts[1]
+		} else if before, _, ok := strings.Cut(path, "/-/"); ok {
+			path = before
+		}
+		return provider, path, nil
+	}
+	return Provider{}, "", fmt.Errorf("%w: %s", ErrUnsupportedSource, source)
+}
+
+func (c *Client) githubCommit(ctx context.Context, provider Provider, repository, commitSHA string) (*Commit, error) {
+	var response struct {
+		SHA     string `json:"sha"`
+		HTMLURL string `json:"html_url"`
+		Commit  struct {
+			Message string `json:"message"`
+			Author  struct {
+				Name  string    `json:"name"`
+				Email string    `json:"email"`
+				Date  time.Time `json:"date"`
+			} `json:"author"`
+		} `json:"commit"`
+		Author *struct {
+			Login string `json:"login"`
+		} `json:"author"`
+	}
+	base := provider.api + "/repos/" + repository + "/commits/" + commitSHA
+	if err := c.get(ctx, provider, base, &response); err != nil {
+		return nil, err
+	}
+	commit := &Commit{
+		Provider:   GitHub,
+		Repository: repository,
+		SHA:        response.SHA,
+		Message:    response.Commit.Message,
+		Author:     Author{Name: response.Commit.Author.Name, Email: response.Commit.Author.Email},
+		URL:        response.HTMLURL,
+		Changes:    []ChangeRequest{},
+	}
+	commit.Title, _, _ = strings.Cut(commit.Message, "\n")
+	if !response.Commit.Author.Date.IsZero() {
+		commit.AuthoredAt = &response.Commit.Author.Date
+	}
+	if response.Author != nil {
+		commit.Author.Login = response.Author.Login
+	}
+
+	var pulls []struct {
+		Number  int    `json:"number"`
+		Title   string `json:"title"`
+		HTMLURL string `json:"html_url"`
+		State   string `json:"state"`
+	}
+	if err := c.get(ctx, provider, base+"/pulls", &pulls); err != nil {
+		return nil, err
+	}
+	for _, pull := range pulls {
+		commit.Changes = append(commit.Changes, ChangeRequest{Number: pull.Number, Title: pull.Title, URL: pull.HTMLURL, State: pull.State})
+	}
+	return commit, nil
+}
+
+func (c *Client) gitlabCommit(ctx context.Context, provider Provider, repository, commitSHA string) (*Commit, error) {
+	var response struct {
+		ID           string    `json:"id"`
+		Title        string    `json:"title"`
+		Message      string    `json:"message"`
+		AuthorName   string    `json:"author_name"`
+		AuthorEmail  string    `json:"author_email"`
+		AuthoredDate time.Time `json:"authored_date"`
+		WebURL       string    `json:"web_url"`
+	}
+	base := provider.api + "/projects/" + url.PathEscape(repository) + "/repository/commits/" + commitSHA
+	if err := c.get(ctx, provider, base, &response); err != nil {
+		return nil, err
+	}
+	commit := &Commit{
+		Provider:   GitLab,
+		Repository: repository,
+		SHA:        response.ID,
+		Title:      response.Title,
+		Message:    response.Message,
+		Author:     Author{Name: response.AuthorName, Email: response.AuthorEmail},
+		URL:        response.WebURL,
+		Changes:    []ChangeRequest{},
+	}
+	if !response.AuthoredDate.IsZero() {
+		commit.AuthoredAt = &response.AuthoredDate
+	}
+
+	var mergeRequests []struct {
+		IID    int    `json:"iid"`
+		Title  string `json:"title"`
+		WebURL string `json:"web_url"`
+		State  string `json:"state"`
+	}
+	if err := c.get(ctx, provider, base+"/merge_requests", &mergeRequests); err != nil {
+		return nil, err
+	}
+	for _, mr := range mergeRequests {
+		commit.Changes = append(commit.Changes, ChangeRequest{Number: mr.IID, Title: mr.Title, URL: mr.WebURL, State: mr.State})
+	}
+	return commit, nil
+}
+
+func (c *Client) get(ctx context.Context, provider Provider, endpoint string, data any) error {
+	status, err := c.request(ctx, provider, http.MethodGet, endpoint, nil, data)
+	if status == http.StatusNotFound || status == http.StatusUnprocessableEntity {
+		// GitHub answers 422 for SHAs that do not resolve to a commit
+		return ErrCommitNotFound
+	}
+	return err
+}
+
+// request sends body, unless nil, as JSON to the provider and decodes a successful response into
+// data, unless nil. It returns the response status, also when it is not successful.
+func (c *Client) request(ctx context.Context, provider Provider, method, endpoint string, body, data any) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if provider.tokenFile != "" {
+		token, err := os.ReadFile(provider.tokenFile)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read %s token: %w", provider.kind, err)
+		}
+		if provider.kind == GitLab {
+			req.Header.Set("PRIVATE-TOKEN", strings.TrimSpace(string(token)))
+		} else {
+			req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+		}
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s from %s", resp.Status, provider.kind)
+	}
+	if data == nil {
+		return resp.StatusCode, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(data); err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,180 @@
+package gitprovider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRevision(t *testing.T) {
+	for revision, expected := range map[string]string{
+		"4f2a9c1":                    "4f2a9c1",
+		"4F2A9C1D":                   "4f2a9c1d",
+		"main@sha1:4f2a9c1d":         "4f2a9c1d",
+		"v1.2.3@sha256:4f2a9c1d0e":   "4f2a9c1d0e",
+		"refs/heads/main@4f2a9c1d0e": "4f2a9c1d0e",
+	} {
+		sha, err := ParseRevision(revision)
+		require.NoError(t, err, revision)
+		assert.Equal(t, expected, sha, revision)
+	}
+	for _, revision := range []string{"", "main", "abc", "main@sha1:xyz1234"} {
+		_, err := ParseRevision(revision)
+		assert.ErrorIs(t, err, ErrInvalidRevision, revision)
+	}
+}
+
+func TestRepository(t *testing.T) {
+	github, err := NewGitHub("https://github.com", "")
+	require.NoError(t, err)
+	gitlab, err := NewGitLab("https://gitlab.example.com/", "")
+	require.NoError(t, err)
+	client := New(github, gitlab)
+
+	for source, expected := range map[string]string{
+		"https://github.com/kuberik/shop":                   "kuberik/shop",
+		"https://github.com/kuberik/shop.git":               "kuberik/shop",
+		"https://github.com/kuberik/shop/tree/main/deploy":  "kuberik/shop",
+		"git@github.com:kuberik/shop.git":                   "kuberik/shop",
+		"https://gitlab.example.com/group/sub/shop":         "group/sub/shop",
+		"https://gitlab.example.com/group/shop/-/tree/main": "group/shop",
+	} {
+		_, repository, err := client.repository(source)
+		require.NoError(t, err, source)
+		assert.Equal(t, expected, repository, source)
+	}
+	for _, source := range []string{"https://bitbucket.org/kuberik/shop", "https://github.com/kuberik", "not a url"} {
+		_, _, err := client.repository(source)
+		assert.ErrorIs(t, err, ErrUnsupportedSource, source)
+	}
+}
+
+func TestGetCommitGitHub(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/api/v3/repos/kuberik/shop/commits/4f2a9c1":
+			w.Write([]byte(`{"sha":"4f2a9c1d0e","html_url":"https://github.example.com/kuberik/shop/commit/4f2a9c1d0e",
+				"commit":{"message":"Fix checkout totals\n\nRounding was off.","author":{"name":"Ada","email":"ada@example.com","date":"2026-01-02T15:00:00Z"}},
+				"author":{"login":"ada"}}`))
+		case "/api/v3/repos/kuberik/shop/commits/4f2a9c1/pulls":
+			w.Write([]byte(`[{"number":42,"title":"Fix checkout totals","html_url":"https://github.example.com/kuberik/shop/pull/42","state":"closed"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("secret\n"), 0o600))
+	github, err := NewGitHub(srv.URL, tokenFile)
+	require.NoError(t, err)
+	client := New(github)
+
+	source := srv.URL + "/kuberik/shop"
+	commit, err := client.GetCommit(context.Background(), source, "main@sha1:4f2a9c1")
+	require.NoError(t, err)
+	assert.Equal(t, GitHub, commit.Provider)
+	assert.Equal(t, "kuberik/shop", commit.Repository)
+	assert.Equal(t, "4f2a9c1d0e", commit.SHA)
+	assert.Equal(t, "Fix checkout totals", commit.Title)
+	assert.Equal(t, Author{Name: "Ada", Email: "ada@example.com", Login: "ada"}, commit.Author)
+	assert.Equal(t, time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC), *commit.AuthoredAt)
+	assert.Equal(t, []ChangeRequest{{Number: 42, Title: "Fix checkout totals", URL: "https://github.example.com/kuberik/shop/pull/42", State: "closed"}}, commit.Changes)
+
+	// Commits are cached
+	_, err = client.GetCommit(context.Background(), source, "4f2a9c1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+
+	_, err = client.GetCommit(context.Background(), source, "0000000")
+	assert.ErrorIs(t, err, ErrCommitNotFound)
+}
+
+func TestGetCommitGitLab(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("PRIVATE-TOKEN"))
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/group%2Fsub%2Fshop/repository/commits/4f2a9c1":
+			w.Write([]byte(`{"id":"4f2a9c1d0e","title":"Fix checkout totals","message":"Fix checkout totals\n","author_name":"Ada",
+				"author_email":"ada@example.com","authored_date":"2026-01-02T15:00:00Z","web_url":"https://gitlab.example.com/group/sub/shop/-/commit/4f2a9c1d0e"}`))
+		case "/api/v4/projects/group%2Fsub%2Fshop/repository/commits/4f2a9c1/merge_requests":
+			w.Write([]byte(`[{"iid":7,"title":"Fix checkout totals","web_url":"https://gitlab.example.com/group/sub/shop/-/merge_requests/7","state":"merged"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	gitlab, err := NewGitLab(srv.URL, "")
+	require.NoError(t, err)
+	commit, err := New(gitlab).GetCommit(context.Background(), srv.URL+"/group/sub/shop.git", "4f2a9c1")
+	require.NoError(t, err)
+	assert.Equal(t, GitLab, commit.Provider)
+	assert.Equal(t, "group/sub/shop", commit.Repository)
+	assert.Equal(t, "Fix checkout totals", commit.Title)
+	assert.Equal(t, Author{Name: "Ada", Email: "ada@example.com"}, commit.Author)
+	assert.Equal(t, []ChangeRequest{{Number: 7, Title: "Fix checkout totals", URL: "https://gitlab.example.com/group/sub/shop/-/merge_requests/7", State: "merged"}}, commit.Changes)
+}
+
+func TestProposeChangeGitLab(t *testing.T) {
+	var commit, mergeRequest map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.EscapedPath() {
+		case "GET /api/v4/projects/group%2Fapps":
+			w.Write([]byte(`{"default_branch":"main"}`))
+		case "GET /api/v4/projects/group%2Fapps/repository/files/shop%2Frollout.yaml":
+			assert.Equal(t, "main", r.URL.Query().Get("ref"))
+			w.Write([]byte(`{"content":"` + base64.StdEncoding.EncodeToString([]byte("version: 1\n")) + `"}`))
+		case "POST /api/v4/projects/group%2Fapps/repository/commits":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&commit))
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{}`))
+		case "POST /api/v4/projects/group%2Fapps/merge_requests":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&mergeRequest))
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"iid":3,"title":"Pin","web_url":"https://gitlab.example.com/group/apps/-/merge_requests/3","state":"opened"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	gitlab, err := NewGitLab(srv.URL, "")
+	require.NoError(t, err)
+	client := New(gitlab)
+	change := FileChange{
+		Repository: srv.URL + "/group/apps",
+		Branch:     "pin",
+		Path:       "shop/rollout.yaml",
+		Edit:       func([]byte) ([]byte, error) { return []byte("version: 2\n"), nil },
+		Message:    "Pin",
+		Title:      "Pin",
+	}
+	request, err := client.ProposeChange(context.Background(), change)
+	require.NoError(t, err)
+	assert.Equal(t, &ChangeRequest{Number: 3, Title: "Pin", URL: "https://gitlab.example.com/group/apps/-/merge_requests/3", State: "opened"}, request)
+	assert.Equal(t, "main", commit["start_branch"])
+	assert.Equal(t, []any{map[string]any{"action": "update", "file_path": "shop/rollout.yaml", "content": "version: 2\n"}}, commit["actions"])
+	assert.Equal(t, "pin", mergeRequest["source_branch"])
+	assert.Equal(t, "main", mergeRequest["target_branch"])
+
+	change.Edit = func(content []byte) ([]byte, error) { return content, nil }
+	_, err = client.ProposeChange(context.Background(), change)
+	assert.ErrorIs(t, err, ErrNoChange)
+
+	change.Path = "missing.yaml"
+	_, err = client.ProposeChange(context.Background(), change)
+	assert.ErrorIs(t, err, ErrFileNotFound)
+}
@@ -0,0 +1,240 @@
+// Package junit parses the JUnit XML reports most test runners write into suites and cases, so
+// the test case that failed is shown instead of the raw logs of a test pod
+package junit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Outcomes of a test case
+const (
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusError   = "error"
+	StatusSkipped = "skipped"
+)
+
+const (
+	// maxDetails bounds the failure output kept per test case
+	maxDetails = 16 << 10
+	// maxReportSize bounds a decompressed report
+	maxReportSize = 10 << 20
+)
+
+// ErrNotJUnit is returned for data that is not a JUnit XML report
+var ErrNotJUnit = errors.New("not a JUnit XML report")
+
+// Report is a parsed JUnit report. The counts are derived from the test cases, not from the
+// attributes of the report, which many runners leave out.
+type Report struct {
+	Tests    int `json:"tests"`
+	Failures int `json:"failures"`
+	Errors   int `json:"errors"`
+	Skipped  int `json:"skipped"`
+	// Time is the duration of the suites in seconds
+	Time   float64 `json:"time"`
+	Suites []Suite `json:"suites"`
+}
+
+// Suite is a test suite of a report. Nested suites are flattened into the report.
+type Suite struct {
+	Name      string  `json:"name"`
+	Tests     int     `json:"tests"`
+	Failures  int     `json:"failures"`
+	Errors    int     `json:"errors"`
+	Skipped   int     `json:"skipped"`
+	Time      float64 `json:"time"`
+	Timestamp string  `json:"timestamp,omitempty"`
+	Cases     []Case  `json:"cases"`
+}
+
+// Case is a test case and its outcome. Message, Type and Details describe a failure, error or
+// skip.
+type Case struct {
+	Name      string  `json:"name"`
+	ClassName string  `json:"className,omitempty"`
+	Time      float64 `json:"time"`
+	Status    string  `json:"status"`
+	Message   string  `json:"message,omitempty"`
+	Type      string  `json:"type,omitempty"`
+	Details   string  `json:"details,omitempty"`
+}
+
+type xmlSuite struct {
+	Name      string     `xml:"name,attr"`
+	Time      string     `xml:"time,attr"`
+	Timestamp string     `xml:"timestamp,attr"`
+	Cases     []xmlCase  `xml:"testcase"`
+	Suites    []xmlSuite `xml:"testsuite"`
+}
+
+type xmlCase struct {
+	Name      string     `xml:"name,attr"`
+	ClassName string     `xml:"classname,attr"`
+	Time      string     `xml:"time,attr"`
+	Failure   *xmlResult `xml:"failure"`
+	Error     *xmlResult `xml:"error"`
+	Skipped   *xmlResult `xml:"skipped"`
+}
+
+type xmlResult struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// Decode parses a report that is either JUnit XML or, to fit a container's termination message
+// of at most 4096 bytes, gzip-compressed XML encoded as base64
+func Decode(data []byte) (*Report, error) {
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("<")) {
+		return Parse(data)
+	}
+	compressed, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return nil, ErrNotJUnit
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, ErrNotJUnit
+	}
+	xmlData, err := io.ReadAll(io.LimitReader(reader, maxReportSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress report: %w", err)
+	}
+	return Parse(xmlData)
+}
+
+// Parse parses a JUnit XML report with a testsuites or a single testsuite root element
+func Parse(data []byte) (*Report, error) {
+	root, err := rootElement(data)
+	if err != nil {
+		return nil, err
+	}
+	var suites []xmlSuite
+	switch root {
+	case "testsuites":
+		var doc struct {
+			Suites []xmlSuite `xml:"testsuite"`
+		}
+		if err := xml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("invalid JUnit report: %w", err)
+		}
+		suites = doc.Suites
+	case "testsuite":
+		var suite xmlSuite
+		if err := xml.Unmarshal(data, &suite); err != nil {
+			return nil, fmt.Errorf("invalid JUnit report: %w", err)
+		}
+		suites = []xmlSuite{suite}
+	default:
+		return nil, fmt.Errorf("%w: root element is %s", ErrNotJUnit, root)
+	}
+
+	report := &Report{Suites: []Suite{}}
+	for _, suite := range suites {
+		report.addSuite(suite)
+	}
+	return report, nil
+}
+
+// Merge combines reports, e.g. of the containers of a pod, into one
+func Merge(reports ...*Report) *Report {
+	merged := &Report{Suites: []Suite{}}
+	for _, report := range reports {
+		merged.Tests += report.Tests
+		merged.Failures += report.Failures
+		merged.Errors += report.Errors
+		merged.Skipped += report.Skipped
+		merged.Time += report.Time
+		merged.Suites = append(merged.Suites, report.Suites...)
+	}
+	return merged
+}
+
+// rootElement returns the name of the first element of an XML document
+func rootElement(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", ErrNotJUnit
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+// addSuite adds a suite and the suites nested in it to the report
+func (r *Report) addSuite(parsed xmlSuite) {
+	suite := Suite{Name: parsed.Name, Timestamp: parsed.Timestamp, Cases: []Case{}}
+	caseTime := 0.0
+	for _, parsedCase := range parsed.Cases {
+		testCase := newCase(parsedCase)
+		caseTime += testCase.Time
+		suite.Tests++
+		switch testCase.Status {
+		case StatusFailed:
+			suite.Failures++
+		case StatusError:
+			suite.Errors++
+		case StatusSkipped:
+			suite.Skipped++
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+	suite.Time = parseTime(parsed.Time)
+	if parsed.Time == "" {
+		suite.Time = caseTime
+	}
+	if len(suite.Cases) > 0 || len(parsed.Suites) == 0 {
+		r.Tests += suite.Tests
+		r.Failures += suite.Failures
+		r.Errors += suite.Errors
+		r.Skipped += suite.Skipped
+		r.Time += suite.Time
+		r.Suites = append(r.Suites, suite)
+	}
+	for _, nested := range parsed.Suites {
+		r.addSuite(nested)
+	}
+}
+
+func newCase(parsed xmlCase) Case {
+	testCase := Case{Name: parsed.Name, ClassName: parsed.ClassName, Time: parseTime(parsed.Time), Status: StatusPassed}
+	var result *xmlResult
+	switch {
+	case parsed.Failure != nil:
+		testCase.Status, result = StatusFailed, parsed.Failure
+	case parsed.Error != nil:
+		testCase.Status, result = StatusError, parsed.Error
+	case parsed.Skipped != nil:
+		testCase.Status, result = StatusSkipped, parsed.Skipped
+	}
+	if result != nil {
+		testCase.Message, testCase.Type = result.Message, result.Type
+		testCase.Details = strings.TrimSpace(result.Text)
+		if len(testCase.Details) > maxDetails {
+			testCase.Details = testCase.Details[:maxDetails]
+		}
+	}
+	return testCase
+}
+
+// parseTime parses a duration in seconds, which some runners format with thousands separators
+func parseTime(value string) float64 {
+	seconds, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", ""), 64)
+	if err != nil {
+		return 0
+	}
+	return seconds
+}
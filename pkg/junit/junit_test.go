@@ -0,0 +1,89 @@
+package junit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const report = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="checkout" tests="3" time="1,204.5" timestamp="2024-05-01T12:00:00">
+    <testcase name="pays by card" classname="checkout.Payment" time="0.5"/>
+    <testcase name="applies coupon" classname="checkout.Coupon" time="1.25">
+      <failure message="expected 90, got 100" type="AssertionError">
+        coupon_test.go:42: expected 90, got 100
+      </failure>
+    </testcase>
+    <testcase name="ships abroad" classname="checkout.Shipping">
+      <skipped message="no carrier sandbox"/>
+    </testcase>
+  </testsuite>
+  <testsuite name="api">
+    <testsuite name="api.orders">
+      <testcase name="lists orders" time="0.25"><error message="connection refused"/></testcase>
+    </testsuite>
+  </testsuite>
+</testsuites>`
+
+func TestParse(t *testing.T) {
+	parsed, err := Parse([]byte(report))
+	require.NoError(t, err)
+	assert.Equal(t, 4, parsed.Tests)
+	assert.Equal(t, 1, parsed.Failures)
+	assert.Equal(t, 1, parsed.Errors)
+	assert.Equal(t, 1, parsed.Skipped)
+	assert.InDelta(t, 1204.75, parsed.Time, 0.001)
+
+	// The empty parent of a nested suite is left out
+	require.Len(t, parsed.Suites, 2)
+	checkout := parsed.Suites[0]
+	assert.Equal(t, "checkout", checkout.Name)
+	assert.Equal(t, "2024-05-01T12:00:00", checkout.Timestamp)
+	assert.Equal(t, Case{
+		Name: "applies coupon", ClassName: "checkout.Coupon", Time: 1.25, Status: StatusFailed,
+		Message: "expected 90, got 100", Type: "AssertionError", Details: "coupon_test.go:42: expected 90, got 100",
+	}, checkout.Cases[1])
+	assert.Equal(t, StatusSkipped, checkout.Cases[2].Status)
+
+	orders := parsed.Suites[1]
+	assert.Equal(t, "api.orders", orders.Name)
+	assert.Equal(t, 0.25, orders.Time)
+	assert.Equal(t, StatusError, orders.Cases[0].Status)
+
+	single, err := Parse([]byte(`<testsuite name="smoke"><testcase name="up"/></testsuite>`))
+	require.NoError(t, err)
+	assert.Equal(t, 1, single.Tests)
+	assert.Equal(t, StatusPassed, single.Suites[0].Cases[0].Status)
+
+	_, err = Parse([]byte(`<html><body/></html>`))
+	assert.ErrorIs(t, err, ErrNotJUnit)
+}
+
+func TestDecode(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	_, err := writer.Write([]byte(report))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	decoded, err := Decode([]byte(base64.StdEncoding.EncodeToString(compressed.Bytes()) + "\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 4, decoded.Tests)
+
+	decoded, err = Decode([]byte("\n" + report))
+	require.NoError(t, err)
+	assert.Equal(t, 4, decoded.Tests)
+
+	// Termination messages falling back to the logs are not reports
+	_, err = Decode([]byte("panic: test failed"))
+	assert.ErrorIs(t, err, ErrNotJUnit)
+
+	merged := Merge(decoded, decoded)
+	assert.Equal(t, 8, merged.Tests)
+	assert.Len(t, merged.Suites, 4)
+}
@@ -0,0 +1,105 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Annotations the dashboard sets on Rollouts to override the controller's decisions
+const (
+	ForceDeployAnnotation   = "rollout.kuberik.com/force-deploy"
+	BypassGatesAnnotation   = "rollout.kuberik.com/bypass-gates"
+	UnblockFailedAnnotation = "rollout.kuberik.com/unblock-failed"
+	DeployMessageAnnotation = "rollout.kuberik.com/deploy-message"
+	DeployUserAnnotation    = "rollout.kuberik.com/deploy-user"
+)
+
+// Annotations users set on Rollouts to name who owns them. The team is looked up in the on-call
+// directory, the owner is free-form, e.g. a person or a mailing list.
+const (
+	TeamAnnotation  = "rollout.kuberik.com/team"
+	OwnerAnnotation = "rollout.kuberik.com/owner"
+)
+
+// NotifyRoutesAnnotation names, comma separated, the notification routes that receive every
+// notification about a rollout in addition to the routes matching it
+const NotifyRoutesAnnotation = "rollout.kuberik.com/notify-routes"
+
+// ComponentsAnnotation maps the logical apps of a monorepo release artifact to the directories
+// holding their manifests, as comma separated name=path pairs
+const ComponentsAnnotation = "rollout.kuberik.com/components"
+
+// StaleAnnotations returns the override annotations of a rollout that have done their job: the
+// force-deploy and bypass-gates annotations once their version was deployed and its bake succeeded,
+// and the unblock-failed annotation once the latest deployment's bake succeeded, in each case
+// longer than gracePeriod before now. The deploy message and user set with a force deploy are
+// stale together with it.
+func StaleAnnotations(rollout *rolloutv1alpha1.Rollout, gracePeriod time.Duration, now time.Time) []string {
+	cutoff := now.Add(-gracePeriod)
+	var stale []string
+	if version := rollout.Annotations[ForceDeployAnnotation]; version != "" && bakedBefore(rollout.Status.History, version, cutoff) {
+		stale = append(stale, ForceDeployAnnotation)
+		for _, key := range []string{DeployMessageAnnotation, DeployUserAnnotation} {
+			// A pinned version keeps the message it was deployed with
+			if _, ok := rollout.Annotations[key]; ok && rollout.Spec.WantedVersion == nil {
+				stale = append(stale, key)
+			}
+		}
+	}
+	if version := rollout.Annotations[BypassGatesAnnotation]; version != "" && bakedBefore(rollout.Status.History, version, cutoff) {
+		stale = append(stale, BypassGatesAnnotation)
+	}
+	if _, ok := rollout.Annotations[UnblockFailedAnnotation]; ok && len(rollout.Status.History) > 0 &&
+		bakedBefore(rollout.Status.History[:1], rollout.Status.History[0].Version.Tag, cutoff) {
+		stale = append(stale, UnblockFailedAnnotation)
+	}
+	return stale
+}
+
+// bakedBefore reports whether version was deployed and its bake succeeded before cutoff
+func bakedBefore(history []rolloutv1alpha1.DeploymentHistoryEntry, version string, cutoff time.Time) bool {
+	for _, entry := range history {
+		if entry.Version.Tag != version || entry.BakeStatus == nil || *entry.BakeStatus != rolloutv1alpha1.BakeStatusSucceeded {
+			continue
+		}
+		finished := entry.Timestamp.Time
+		if entry.BakeEndTime != nil {
+			finished = entry.BakeEndTime.Time
+		}
+		if finished.Before(cutoff) {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveRolloutAnnotations removes annotations from a rollout with a merge patch that carries
+// nothing else
+func (c *Client) RemoveRolloutAnnotations(ctx context.Context, namespace, name string, keys []string) error {
+	patch := &unstructured.Unstructured{}
+	patch.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   "kuberik.com",
+		Version: "v1alpha1",
+		Kind:    "Rollout",
+	})
+	patch.SetNamespace(namespace)
+	patch.SetName(name)
+
+	// A null value deletes the key in a JSON merge patch
+	annotations := map[string]any{}
+	for _, key := range keys {
+		annotations[key] = nil
+	}
+	patch.Object["metadata"].(map[string]any)["annotations"] = annotations
+
+	if err := c.client.Patch(ctx, patch, client.Merge, client.FieldOwner("rollout-dashboard")); err != nil {
+		return fmt.Errorf("failed to remove rollout annotations: %w", err)
+	}
+	return nil
+}
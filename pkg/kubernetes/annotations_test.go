@@ -0,0 +1,66 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestStaleAnnotations(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	succeeded := rolloutv1alpha1.BakeStatusSucceeded
+	inProgress := rolloutv1alpha1.BakeStatusInProgress
+	baked := func(tag string, status *string, ago time.Duration) rolloutv1alpha1.DeploymentHistoryEntry {
+		end := metav1.NewTime(now.Add(-ago))
+		return rolloutv1alpha1.DeploymentHistoryEntry{Version: rolloutv1alpha1.VersionInfo{Tag: tag}, BakeStatus: status, BakeEndTime: &end}
+	}
+	rollout := func(annotations map[string]string, history ...rolloutv1alpha1.DeploymentHistoryEntry) *rolloutv1alpha1.Rollout {
+		return &rolloutv1alpha1.Rollout{
+			ObjectMeta: metav1.ObjectMeta{Annotations: annotations},
+			Status:     rolloutv1alpha1.RolloutStatus{History: history},
+		}
+	}
+
+	// Baked long enough ago: the override and its message are removed
+	assert.Equal(t, []string{ForceDeployAnnotation, DeployMessageAnnotation, BypassGatesAnnotation, UnblockFailedAnnotation},
+		StaleAnnotations(rollout(map[string]string{
+			ForceDeployAnnotation:   "v2",
+			DeployMessageAnnotation: "hotfix",
+			BypassGatesAnnotation:   "v2",
+			UnblockFailedAnnotation: "true",
+		}, baked("v2", &succeeded, 2*time.Hour), baked("v1", &succeeded, 3*time.Hour)), time.Hour, now))
+
+	// Within the grace period, still baking, or not deployed yet: kept
+	assert.Empty(t, StaleAnnotations(rollout(map[string]string{ForceDeployAnnotation: "v2"}, baked("v2", &succeeded, 30*time.Minute)), time.Hour, now))
+	assert.Empty(t, StaleAnnotations(rollout(map[string]string{BypassGatesAnnotation: "v2"}, baked("v2", &inProgress, 2*time.Hour)), time.Hour, now))
+	assert.Empty(t, StaleAnnotations(rollout(map[string]string{ForceDeployAnnotation: "v3"}, baked("v2", &succeeded, 2*time.Hour)), time.Hour, now))
+
+	// Unblocking only concerns the latest deployment
+	assert.Empty(t, StaleAnnotations(rollout(map[string]string{UnblockFailedAnnotation: "true"},
+		baked("v2", &inProgress, 0), baked("v1", &succeeded, 3*time.Hour)), time.Hour, now))
+}
+
+func TestRemoveRolloutAnnotations(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, rolloutv1alpha1.AddToScheme(scheme))
+	c := &Client{client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(&rolloutv1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api", Annotations: map[string]string{
+			ForceDeployAnnotation: "v2",
+			"team":                "payments",
+		}},
+	}).Build()}
+
+	require.NoError(t, c.RemoveRolloutAnnotations(context.Background(), "shop", "api", []string{ForceDeployAnnotation}))
+
+	rollout := &rolloutv1alpha1.Rollout{}
+	require.NoError(t, c.client.Get(context.Background(), client.ObjectKey{Namespace: "shop", Name: "api"}, rollout))
+	assert.Equal(t, map[string]string{"team": "payments"}, rollout.Annotations)
+}
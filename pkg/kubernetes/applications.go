@@ -0,0 +1,109 @@
+package kubernetes
+
+import (
+	"sort"
+
+	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ApplicationEnvironment is an environment an application is deployed to. Applications are
+// identified by the deployment name (spec.name) their Environments share across environments and
+// namespaces.
+type ApplicationEnvironment struct {
+	Environment string
+	// Upstream is the environment this one relates to and Relationship how (After or Parallel)
+	Upstream     string
+	Relationship string
+	// Rollouts are the application's rollouts of the environment in this cluster
+	Rollouts []types.NamespacedName
+	// Deployment is the latest deployment of an environment without rollouts in this cluster, as
+	// reported by the status of one of the application's Environments
+	Deployment *rolloutv1alpha1.DeploymentHistoryEntry
+}
+
+// ApplicationEnvironments returns the environments of an application in promotion order:
+// environments without upstream first, each followed by the environments relating to it. Ties
+// and environments whose upstream is unknown are sorted by name.
+func ApplicationEnvironments(environments []envv1alpha1.Environment, application string) []ApplicationEnvironment {
+	byName := map[string]*ApplicationEnvironment{}
+	get := func(name string) *ApplicationEnvironment {
+		if byName[name] == nil {
+			byName[name] = &ApplicationEnvironment{Environment: name}
+		}
+		return byName[name]
+	}
+
+	for _, env := range environments {
+		if env.Spec.Name != application || env.Spec.Environment == "" {
+			continue
+		}
+		appEnv := get(env.Spec.Environment)
+		if env.Spec.Relationship != nil && appEnv.Upstream == "" {
+			appEnv.Upstream = env.Spec.Relationship.Environment
+			appEnv.Relationship = string(env.Spec.Relationship.Type)
+		}
+	}
+	if len(byName) == 0 {
+		return nil
+	}
+	for name, appEnv := range byName {
+		appEnv.Rollouts = EnvironmentRollouts(environments, name, application)
+	}
+
+	// Environments in other clusters are only known from the statuses of the local Environments
+	for _, env := range environments {
+		if env.Spec.Name != application {
+			continue
+		}
+		for _, info := range env.Status.EnvironmentInfos {
+			if info.Environment == "" || len(info.History) == 0 {
+				continue
+			}
+			appEnv := get(info.Environment)
+			if len(appEnv.Rollouts) > 0 {
+				continue
+			}
+			if appEnv.Upstream == "" && info.Relationship != nil {
+				appEnv.Upstream = info.Relationship.Environment
+				appEnv.Relationship = string(info.Relationship.Type)
+			}
+			if appEnv.Deployment == nil || info.History[0].Timestamp.After(appEnv.Deployment.Timestamp.Time) {
+				appEnv.Deployment = info.History[0].DeepCopy()
+			}
+		}
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var ordered []ApplicationEnvironment
+	added := map[string]bool{}
+	var add func(name string)
+	add = func(name string) {
+		if added[name] {
+			return
+		}
+		added[name] = true
+		ordered = append(ordered, *byName[name])
+		for _, follower := range names {
+			if byName[follower].Upstream == name {
+				add(follower)
+			}
+		}
+	}
+	for _, name := range names {
+		if upstream := byName[name].Upstream; upstream == "" || byName[upstream] == nil {
+			add(name)
+		}
+	}
+	// Environments in a relationship cycle have no root
+	for _, name := range names {
+		add(name)
+	}
+	return ordered
+}
@@ -0,0 +1,43 @@
+package kubernetes
+
+import (
+	"testing"
+
+	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApplicationEnvironments_Order(t *testing.T) {
+	environment := func(name, env, upstream string) envv1alpha1.Environment {
+		e := envv1alpha1.Environment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: name + "-" + env},
+			Spec: envv1alpha1.EnvironmentSpec{
+				Name:        name,
+				Environment: env,
+				RolloutRef:  corev1.LocalObjectReference{Name: name + "-" + env},
+			},
+		}
+		if upstream != "" {
+			e.Spec.Relationship = &envv1alpha1.EnvironmentRelationship{Environment: upstream, Type: envv1alpha1.RelationshipTypeAfter}
+		}
+		return e
+	}
+	environments := []envv1alpha1.Environment{
+		environment("shop", "production", "staging"),
+		environment("shop", "canary", "staging"),
+		environment("shop", "staging", "dev"),
+		environment("shop", "dev", ""),
+		environment("shop", "a", "b"),
+		environment("shop", "b", "a"),
+		environment("billing", "dev", ""),
+	}
+
+	var names []string
+	for _, env := range ApplicationEnvironments(environments, "shop") {
+		names = append(names, env.Environment)
+	}
+	assert.Equal(t, []string{"dev", "staging", "canary", "production", "a", "b"}, names)
+	assert.Nil(t, ApplicationEnvironments(environments, "unknown"))
+}
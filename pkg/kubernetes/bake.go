@@ -0,0 +1,163 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// BakeExtensionAnnotation records the extensions of the current deployment's bake as JSON, see
+// BakeExtension. The controller only knows spec.bakeTime, so extending a bake raises it and the
+// annotation keeps what it was before, to be restored once the bake is over.
+const BakeExtensionAnnotation = "rollout.kuberik.com/bake-extension"
+
+var (
+	// ErrNotBaking is returned when extending the bake of a rollout whose latest deployment is not
+	// deploying or baking
+	ErrNotBaking = errors.New("latest deployment is not baking")
+	// ErrVersionNotBaking is returned when the version to extend is not the latest deployment's
+	ErrVersionNotBaking = errors.New("version is not the latest deployment")
+)
+
+// BakeExtension is the value of BakeExtensionAnnotation
+type BakeExtension struct {
+	// Version is the deployment whose bake was extended
+	Version string `json:"version"`
+	// OriginalBakeTime is spec.bakeTime before the first extension, nil when none was set
+	OriginalBakeTime *metav1.Duration `json:"originalBakeTime,omitempty"`
+	// Extensions are the extensions in the order they were made
+	Extensions []BakeExtensionEntry `json:"extensions"`
+}
+
+// BakeExtensionEntry is a single extension of a bake
+type BakeExtensionEntry struct {
+	Duration metav1.Duration `json:"duration"`
+	User     string          `json:"user,omitempty"`
+	Reason   string          `json:"reason,omitempty"`
+	At       metav1.Time     `json:"at"`
+}
+
+// Total returns the time added to the bake by all extensions
+func (e *BakeExtension) Total() time.Duration {
+	var total time.Duration
+	for _, extension := range e.Extensions {
+		total += extension.Duration.Duration
+	}
+	return total
+}
+
+// GetBakeExtension returns the recorded bake extension of a rollout, nil when there is none or
+// the annotation cannot be parsed
+func GetBakeExtension(rollout *rolloutv1alpha1.Rollout) *BakeExtension {
+	raw := rollout.Annotations[BakeExtensionAnnotation]
+	if raw == "" {
+		return nil
+	}
+	extension := &BakeExtension{}
+	if err := json.Unmarshal([]byte(raw), extension); err != nil {
+		return nil
+	}
+	return extension
+}
+
+// ExtendBake adds duration to the bake of the rollout's latest deployment by raising spec.bakeTime,
+// recording the acting user and reason in BakeExtensionAnnotation. Unless version is empty it must
+// be the latest deployment's. Extensions of the same deployment add up.
+func (c *Client) ExtendBake(ctx context.Context, namespace, name, version string, duration time.Duration, reason string) (*rolloutv1alpha1.Rollout, error) {
+	rollout := &rolloutv1alpha1.Rollout{}
+	if err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, rollout); err != nil {
+		return nil, fmt.Errorf("failed to get rollout: %w", err)
+	}
+	if len(rollout.Status.History) == 0 {
+		return nil, ErrNotBaking
+	}
+	latest := rollout.Status.History[0]
+	if version != "" && version != latest.Version.Tag {
+		return nil, fmt.Errorf("%w: %s is deployed", ErrVersionNotBaking, latest.Version.Tag)
+	}
+	if !isBaking(latest) {
+		return nil, ErrNotBaking
+	}
+
+	patchBase := rollout.DeepCopy()
+	extension := GetBakeExtension(rollout)
+	switch {
+	case extension == nil:
+		extension = &BakeExtension{Version: latest.Version.Tag, OriginalBakeTime: rollout.Spec.BakeTime}
+	case extension.Version != latest.Version.Tag:
+		// The extension of an earlier deployment was not restored yet, so spec.bakeTime still
+		// includes it
+		extension = &BakeExtension{Version: latest.Version.Tag, OriginalBakeTime: extension.OriginalBakeTime}
+	}
+	username := c.actingUser(ctx)
+	extension.Extensions = append(extension.Extensions, BakeExtensionEntry{
+		Duration: metav1.Duration{Duration: duration},
+		User:     username,
+		Reason:   reason,
+		At:       metav1.Now(),
+	})
+	value, err := json.Marshal(extension)
+	if err != nil {
+		return nil, err
+	}
+
+	var bakeTime time.Duration
+	if extension.OriginalBakeTime != nil {
+		bakeTime = extension.OriginalBakeTime.Duration
+	}
+	rollout.Spec.BakeTime = &metav1.Duration{Duration: bakeTime + extension.Total()}
+	if rollout.Annotations == nil {
+		rollout.Annotations = map[string]string{}
+	}
+	rollout.Annotations[BakeExtensionAnnotation] = string(value)
+	if username != "" {
+		rollout.Annotations[ChangedByAnnotation] = username
+	}
+	if err := c.client.Patch(ctx, rollout, client.MergeFrom(patchBase), client.FieldOwner("rollout-dashboard")); err != nil {
+		return nil, fmt.Errorf("failed to extend bake: %w", err)
+	}
+	return rollout, nil
+}
+
+// BakeExtensionOver reports whether the bake a rollout's extension was made for is over: the
+// deployment finished baking or a newer version was deployed
+func BakeExtensionOver(rollout *rolloutv1alpha1.Rollout) bool {
+	extension := GetBakeExtension(rollout)
+	if extension == nil {
+		return rollout.Annotations[BakeExtensionAnnotation] != ""
+	}
+	if len(rollout.Status.History) == 0 {
+		return false
+	}
+	latest := rollout.Status.History[0]
+	return latest.Version.Tag != extension.Version || !isBaking(latest)
+}
+
+// RestoreBakeTime sets spec.bakeTime back to what it was before the bake was extended and removes
+// BakeExtensionAnnotation
+func (c *Client) RestoreBakeTime(ctx context.Context, rollout *rolloutv1alpha1.Rollout) error {
+	patchBase := rollout.DeepCopy()
+	updated := rollout.DeepCopy()
+	if extension := GetBakeExtension(rollout); extension != nil {
+		updated.Spec.BakeTime = extension.OriginalBakeTime
+	}
+	delete(updated.Annotations, BakeExtensionAnnotation)
+	if err := c.client.Patch(ctx, updated, client.MergeFrom(patchBase), client.FieldOwner("rollout-dashboard")); err != nil {
+		return fmt.Errorf("failed to restore bake time: %w", err)
+	}
+	return nil
+}
+
+// isBaking reports whether a deployment is still deploying or baking, so its bake time applies
+func isBaking(entry rolloutv1alpha1.DeploymentHistoryEntry) bool {
+	if entry.BakeStatus == nil {
+		return false
+	}
+	return *entry.BakeStatus == rolloutv1alpha1.BakeStatusDeploying || *entry.BakeStatus == rolloutv1alpha1.BakeStatusInProgress
+}
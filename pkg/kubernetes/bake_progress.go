@@ -0,0 +1,115 @@
+package kubernetes
+
+import (
+	"context"
+	"time"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// BakeProgress is the bake of a rollout's latest deployment, with the times the client would
+// otherwise derive from the raw status
+type BakeProgress struct {
+	Version string `json:"version,omitempty"`
+	Status  string `json:"status,omitempty"`
+	Message string `json:"message,omitempty"`
+	// DeployedAt is when the version was deployed. Its bake starts once its health checks are
+	// healthy, unless DeployTimeout passes first.
+	DeployedAt     *time.Time       `json:"deployedAt,omitempty"`
+	DeployTimeout  *metav1.Duration `json:"deployTimeout,omitempty"`
+	DeployDeadline *time.Time       `json:"deployDeadline,omitempty"`
+	StartedAt      *time.Time       `json:"startedAt,omitempty"`
+	EndedAt        *time.Time       `json:"endedAt,omitempty"`
+	// BakeTime is the configured bake time, including extensions of this bake
+	BakeTime  *metav1.Duration `json:"bakeTime,omitempty"`
+	Extension *BakeExtension   `json:"extension,omitempty"`
+	// EndsAt is when a bake in progress succeeds unless a health check fails before, the
+	// remaining seconds count down to it
+	EndsAt           *time.Time `json:"endsAt,omitempty"`
+	ElapsedSeconds   *int64     `json:"elapsedSeconds,omitempty"`
+	RemainingSeconds *int64     `json:"remainingSeconds,omitempty"`
+	// HealthChecks have to stay healthy for the bake to succeed
+	HealthChecks []BakeHealthCheck `json:"healthChecks"`
+}
+
+// BakeHealthCheck is a HealthCheck selected by the rollout
+type BakeHealthCheck struct {
+	Namespace      string     `json:"namespace"`
+	Name           string     `json:"name"`
+	Status         string     `json:"status,omitempty"`
+	Message        string     `json:"message,omitempty"`
+	Healthy        bool       `json:"healthy"`
+	LastChangeTime *time.Time `json:"lastChangeTime,omitempty"`
+}
+
+// GetBakeProgress returns the bake progress of a rollout, see ComputeBakeProgress
+func (c *Client) GetBakeProgress(ctx context.Context, namespace, name string, now time.Time) (*BakeProgress, error) {
+	rollout, err := c.GetRollout(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	healthChecks, err := c.GetHealthChecksBySelector(ctx, namespace, rollout.Spec.HealthCheckSelector)
+	if err != nil {
+		return nil, err
+	}
+	progress := ComputeBakeProgress(rollout, healthChecks, now)
+	return &progress, nil
+}
+
+// ComputeBakeProgress computes the bake progress of a rollout's latest deployment. The elapsed
+// time runs until the bake ended, the remaining time and ETA are only set while it is in progress
+// with a bake time configured.
+func ComputeBakeProgress(rollout *rolloutv1alpha1.Rollout, healthChecks []rolloutv1alpha1.HealthCheck, now time.Time) BakeProgress {
+	progress := BakeProgress{BakeTime: rollout.Spec.BakeTime, DeployTimeout: rollout.Spec.DeployTimeout, HealthChecks: []BakeHealthCheck{}}
+	for _, healthCheck := range healthChecks {
+		evaluation := BakeHealthCheck{
+			Namespace: healthCheck.Namespace,
+			Name:      healthCheck.Name,
+			Status:    string(healthCheck.Status.Status),
+			Message:   ptr.Deref(healthCheck.Status.Message, ""),
+			Healthy:   healthCheck.Status.Status == rolloutv1alpha1.HealthStatusHealthy,
+		}
+		if healthCheck.Status.LastChangeTime != nil {
+			evaluation.LastChangeTime = &healthCheck.Status.LastChangeTime.Time
+		}
+		progress.HealthChecks = append(progress.HealthChecks, evaluation)
+	}
+	if len(rollout.Status.History) == 0 {
+		return progress
+	}
+
+	latest := rollout.Status.History[0]
+	progress.Version = latest.Version.Tag
+	progress.Status = ptr.Deref(latest.BakeStatus, "")
+	progress.Message = ptr.Deref(latest.BakeStatusMessage, "")
+	deployedAt := latest.Timestamp.Time
+	progress.DeployedAt = &deployedAt
+	if extension := GetBakeExtension(rollout); extension != nil && extension.Version == latest.Version.Tag {
+		progress.Extension = extension
+	}
+	if progress.Status == rolloutv1alpha1.BakeStatusDeploying && rollout.Spec.DeployTimeout != nil {
+		deadline := deployedAt.Add(rollout.Spec.DeployTimeout.Duration)
+		progress.DeployDeadline = &deadline
+	}
+	if latest.BakeStartTime == nil {
+		return progress
+	}
+
+	startedAt := latest.BakeStartTime.Time
+	progress.StartedAt = &startedAt
+	until := now
+	if latest.BakeEndTime != nil {
+		endedAt := latest.BakeEndTime.Time
+		progress.EndedAt = &endedAt
+		until = endedAt
+	}
+	progress.ElapsedSeconds = ptr.To(int64(max(until.Sub(startedAt), 0) / time.Second))
+	if progress.Status == rolloutv1alpha1.BakeStatusInProgress && rollout.Spec.BakeTime != nil {
+		endsAt := startedAt.Add(rollout.Spec.BakeTime.Duration)
+		progress.EndsAt = &endsAt
+		progress.RemainingSeconds = ptr.To(int64(max(endsAt.Sub(now), 0) / time.Second))
+	}
+	return progress
+}
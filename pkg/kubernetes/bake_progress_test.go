@@ -0,0 +1,52 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestComputeBakeProgress(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	healthChecks := []rolloutv1alpha1.HealthCheck{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "errors"}, Status: rolloutv1alpha1.HealthCheckStatus{Status: rolloutv1alpha1.HealthStatusHealthy, Message: ptr.To("0.1% errors")}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "latency"}, Status: rolloutv1alpha1.HealthCheckStatus{Status: rolloutv1alpha1.HealthStatusPending}},
+	}
+
+	rollout := explainRollout(rolloutv1alpha1.BakeStatusInProgress, nil)
+	rollout.Status.History[0].Timestamp = metav1.Time{Time: now.Add(-30 * time.Minute)}
+	progress := ComputeBakeProgress(rollout, healthChecks, now)
+	assert.Equal(t, "1.0.0", progress.Version)
+	assert.Equal(t, now.Add(-30*time.Minute), *progress.DeployedAt)
+	assert.Equal(t, now.Add(-20*time.Minute), *progress.StartedAt)
+	assert.Equal(t, time.Hour, progress.BakeTime.Duration)
+	assert.Equal(t, int64(20*60), *progress.ElapsedSeconds)
+	assert.Equal(t, int64(40*60), *progress.RemainingSeconds)
+	assert.Equal(t, now.Add(40*time.Minute), *progress.EndsAt)
+	assert.Equal(t, []BakeHealthCheck{
+		{Namespace: "ns", Name: "errors", Status: "Healthy", Message: "0.1% errors", Healthy: true},
+		{Namespace: "ns", Name: "latency", Status: "Pending"},
+	}, progress.HealthChecks)
+
+	// A finished bake has no ETA, its elapsed time stops at its end
+	rollout = explainRollout(rolloutv1alpha1.BakeStatusSucceeded, nil)
+	rollout.Status.History[0].BakeEndTime = &metav1.Time{Time: now.Add(-5 * time.Minute)}
+	progress = ComputeBakeProgress(rollout, nil, now)
+	assert.Equal(t, int64(15*60), *progress.ElapsedSeconds)
+	assert.Nil(t, progress.RemainingSeconds)
+	assert.Nil(t, progress.EndsAt)
+
+	// A deploying version has a deadline for its bake to start
+	rollout = explainRollout(rolloutv1alpha1.BakeStatusDeploying, nil)
+	rollout.Status.History[0].Timestamp = metav1.Time{Time: now}
+	rollout.Status.History[0].BakeStartTime = nil
+	rollout.Spec.DeployTimeout = &metav1.Duration{Duration: 10 * time.Minute}
+	progress = ComputeBakeProgress(rollout, nil, now)
+	assert.Equal(t, now.Add(10*time.Minute), *progress.DeployDeadline)
+	assert.Nil(t, progress.StartedAt)
+	assert.Nil(t, progress.ElapsedSeconds)
+}
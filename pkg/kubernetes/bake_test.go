@@ -0,0 +1,61 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestExtendBake(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, rolloutv1alpha1.AddToScheme(scheme))
+	c := &Client{client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(&rolloutv1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api"},
+		Spec:       rolloutv1alpha1.RolloutSpec{BakeTime: &metav1.Duration{Duration: time.Hour}},
+		Status: rolloutv1alpha1.RolloutStatus{History: []rolloutv1alpha1.DeploymentHistoryEntry{
+			{Version: rolloutv1alpha1.VersionInfo{Tag: "v2"}, BakeStatus: ptr.To(rolloutv1alpha1.BakeStatusInProgress)},
+		}},
+	}).WithStatusSubresource(&rolloutv1alpha1.Rollout{}).Build()}
+	ctx := context.Background()
+
+	_, err := c.ExtendBake(ctx, "shop", "api", "v1", time.Hour, "")
+	assert.ErrorIs(t, err, ErrVersionNotBaking)
+
+	_, err = c.ExtendBake(ctx, "shop", "api", "v2", 30*time.Minute, "error rate looks off")
+	require.NoError(t, err)
+	rollout, err := c.ExtendBake(ctx, "shop", "api", "", 15*time.Minute, "")
+	require.NoError(t, err)
+
+	// Extensions add up on top of the original bake time
+	assert.Equal(t, 105*time.Minute, rollout.Spec.BakeTime.Duration)
+	extension := GetBakeExtension(rollout)
+	require.NotNil(t, extension)
+	assert.Equal(t, "v2", extension.Version)
+	assert.Equal(t, time.Hour, extension.OriginalBakeTime.Duration)
+	require.Len(t, extension.Extensions, 2)
+	assert.Equal(t, "error rate looks off", extension.Extensions[0].Reason)
+	assert.False(t, BakeExtensionOver(rollout))
+
+	// Once the bake is over the original bake time is restored
+	rollout.Status.History[0].BakeStatus = ptr.To(rolloutv1alpha1.BakeStatusSucceeded)
+	assert.True(t, BakeExtensionOver(rollout))
+	require.NoError(t, c.RestoreBakeTime(ctx, rollout))
+	require.NoError(t, c.client.Get(ctx, client.ObjectKey{Namespace: "shop", Name: "api"}, rollout))
+	assert.Equal(t, time.Hour, rollout.Spec.BakeTime.Duration)
+	assert.NotContains(t, rollout.Annotations, BakeExtensionAnnotation)
+
+	// A finished bake cannot be extended
+	rollout.Status.History[0].BakeStatus = ptr.To(rolloutv1alpha1.BakeStatusFailed)
+	require.NoError(t, c.client.Status().Update(ctx, rollout))
+	_, err = c.ExtendBake(ctx, "shop", "api", "", time.Hour, "")
+	assert.ErrorIs(t, err, ErrNotBaking)
+}
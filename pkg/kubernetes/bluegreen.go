@@ -0,0 +1,121 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	kruiserolloutv1beta1 "github.com/openkruise/kruise-rollout-api/rollouts/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	// ErrNotBlueGreen is returned for blue/green actions on rollouts using a canary strategy
+	ErrNotBlueGreen = errors.New("rollout does not use a blue/green strategy")
+	// ErrTrafficAlreadySwitched is returned when traffic already points at the new version
+	ErrTrafficAlreadySwitched = errors.New("traffic has already been switched to the new version")
+	// ErrNotWaitingForSwitch is returned when the rollout is not paused before the traffic switch
+	ErrNotWaitingForSwitch = errors.New("rollout is not paused waiting for a traffic switch")
+)
+
+// IsBlueGreen reports whether a Kruise rollout uses the blue/green strategy
+func IsBlueGreen(rollout *kruiserolloutv1beta1.Rollout) bool {
+	return rollout.Spec.Strategy.BlueGreen != nil
+}
+
+// BlueGreenSwitchStep returns the 1-based index of the step that routes all traffic to the new
+// version. Blue/green rollouts usually first scale up the new version without traffic and then
+// shift 100% of it in a later step; without such a step traffic moves on the last step.
+func BlueGreenSwitchStep(rollout *kruiserolloutv1beta1.Rollout) int32 {
+	if !IsBlueGreen(rollout) {
+		return 0
+	}
+	steps := rollout.Spec.Strategy.BlueGreen.Steps
+	for i, step := range steps {
+		if step.Traffic != nil && strings.TrimSpace(*step.Traffic) == "100%" {
+			return int32(i + 1)
+		}
+	}
+	return int32(len(steps))
+}
+
+// BlueGreenPreviewService returns the service selecting the new version's pods, or empty string
+// when Kruise is configured not to generate one
+func BlueGreenPreviewService(rollout *kruiserolloutv1beta1.Rollout) string {
+	if !IsBlueGreen(rollout) || rollout.Spec.Strategy.BlueGreen.DisableGenerateCanaryService {
+		return ""
+	}
+	if stable := BlueGreenStableService(rollout); stable != "" {
+		// Kruise generates "<service>-canary" next to the stable service
+		return stable + "-canary"
+	}
+	return ""
+}
+
+// BlueGreenStableService returns the service receiving production traffic
+func BlueGreenStableService(rollout *kruiserolloutv1beta1.Rollout) string {
+	if !IsBlueGreen(rollout) {
+		return ""
+	}
+	for _, routing := range rollout.Spec.Strategy.BlueGreen.TrafficRoutings {
+		if routing.Service != "" {
+			return routing.Service
+		}
+	}
+	return ""
+}
+
+// BlueGreenTrafficSwitched reports whether the rollout has reached the traffic switch step
+func BlueGreenTrafficSwitched(rollout *kruiserolloutv1beta1.Rollout) bool {
+	status := rollout.Status.BlueGreenStatus
+	if status == nil {
+		return false
+	}
+	switchStep := BlueGreenSwitchStep(rollout)
+	if status.CurrentStepIndex > switchStep {
+		return true
+	}
+	if status.CurrentStepIndex < switchStep {
+		return false
+	}
+	// On the switch step itself traffic only moves once the pods have been upgraded
+	switch status.CurrentStepState {
+	case kruiserolloutv1beta1.CanaryStepStateInit, kruiserolloutv1beta1.CanaryStepStateUpgrade:
+		return false
+	default:
+		return true
+	}
+}
+
+// SwitchBlueGreenTraffic continues a blue/green rollout paused before its traffic switch step,
+// moving production traffic from the stable to the new version
+func (c *Client) SwitchBlueGreenTraffic(ctx context.Context, namespace, name string) (*kruiserolloutv1beta1.Rollout, error) {
+	rollout, err := c.GetKruiseRollout(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if !IsBlueGreen(rollout) {
+		return nil, ErrNotBlueGreen
+	}
+	if BlueGreenTrafficSwitched(rollout) {
+		return nil, ErrTrafficAlreadySwitched
+	}
+	status := rollout.Status.BlueGreenStatus
+	if status == nil || status.CurrentStepState != kruiserolloutv1beta1.CanaryStepStatePaused || status.CurrentStepIndex+1 != BlueGreenSwitchStep(rollout) {
+		return nil, ErrNotWaitingForSwitch
+	}
+	return c.ContinueKruiseRollout(ctx, namespace, name)
+}
+
+// kruiseStepStatusField returns the status field holding step progress for the rollout's strategy
+func kruiseStepStatusField(ctx context.Context, c client.Client, namespace, name string) (string, error) {
+	rollout := &kruiserolloutv1beta1.Rollout{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, rollout); err != nil {
+		return "", fmt.Errorf("failed to get kruise rollout: %w", err)
+	}
+	if IsBlueGreen(rollout) {
+		return "blueGreenStatus", nil
+	}
+	return "canaryStatus", nil
+}
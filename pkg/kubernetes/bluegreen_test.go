@@ -0,0 +1,116 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	kruiserolloutv1beta1 "github.com/openkruise/kruise-rollout-api/rollouts/v1beta1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newKruiseTestClient(t *testing.T, initial ...client.Object) *Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, kruiserolloutv1beta1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(initial...).
+		WithStatusSubresource(&kruiserolloutv1beta1.Rollout{}).
+		Build()
+	return &Client{client: c}
+}
+
+func blueGreenRollout(stepIndex int32, state kruiserolloutv1beta1.CanaryStepState) *kruiserolloutv1beta1.Rollout {
+	return &kruiserolloutv1beta1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "ns"},
+		Spec: kruiserolloutv1beta1.RolloutSpec{
+			Strategy: kruiserolloutv1beta1.RolloutStrategy{
+				BlueGreen: &kruiserolloutv1beta1.BlueGreenStrategy{
+					Steps: []kruiserolloutv1beta1.CanaryStep{
+						{TrafficRoutingStrategy: kruiserolloutv1beta1.TrafficRoutingStrategy{Traffic: ptr.To("0%")}},
+						{TrafficRoutingStrategy: kruiserolloutv1beta1.TrafficRoutingStrategy{Traffic: ptr.To("100%")}},
+					},
+					TrafficRoutings: []kruiserolloutv1beta1.TrafficRoutingRef{{Service: "app"}},
+				},
+			},
+		},
+		Status: kruiserolloutv1beta1.RolloutStatus{
+			BlueGreenStatus: &kruiserolloutv1beta1.BlueGreenStatus{
+				CommonStatus: kruiserolloutv1beta1.CommonStatus{
+					CurrentStepIndex: stepIndex,
+					CurrentStepState: state,
+				},
+			},
+		},
+	}
+}
+
+func TestBlueGreenHelpers(t *testing.T) {
+	rollout := blueGreenRollout(1, kruiserolloutv1beta1.CanaryStepStatePaused)
+	require.True(t, IsBlueGreen(rollout))
+	require.Equal(t, int32(2), BlueGreenSwitchStep(rollout))
+	require.Equal(t, "app", BlueGreenStableService(rollout))
+	require.Equal(t, "app-canary", BlueGreenPreviewService(rollout))
+	require.False(t, BlueGreenTrafficSwitched(rollout))
+
+	rollout.Spec.Strategy.BlueGreen.DisableGenerateCanaryService = true
+	require.Empty(t, BlueGreenPreviewService(rollout))
+
+	require.False(t, BlueGreenTrafficSwitched(blueGreenRollout(2, kruiserolloutv1beta1.CanaryStepStateUpgrade)))
+	require.True(t, BlueGreenTrafficSwitched(blueGreenRollout(2, kruiserolloutv1beta1.CanaryStepStateTrafficRouting)))
+}
+
+func TestSwitchBlueGreenTraffic(t *testing.T) {
+	cli := newKruiseTestClient(t, blueGreenRollout(1, kruiserolloutv1beta1.CanaryStepStatePaused))
+
+	updated, err := cli.SwitchBlueGreenTraffic(context.Background(), "ns", "app")
+	require.NoError(t, err)
+	require.Equal(t, kruiserolloutv1beta1.CanaryStepStateReady, updated.Status.BlueGreenStatus.CurrentStepState)
+	require.Nil(t, updated.Status.CanaryStatus)
+}
+
+func TestSwitchBlueGreenTraffic_Rejected(t *testing.T) {
+	canary := &kruiserolloutv1beta1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Name: "canary", Namespace: "ns"},
+		Spec: kruiserolloutv1beta1.RolloutSpec{
+			Strategy: kruiserolloutv1beta1.RolloutStrategy{Canary: &kruiserolloutv1beta1.CanaryStrategy{}},
+		},
+	}
+	switched := blueGreenRollout(2, kruiserolloutv1beta1.CanaryStepStatePaused)
+	switched.Name = "switched"
+	upgrading := blueGreenRollout(1, kruiserolloutv1beta1.CanaryStepStateUpgrade)
+	upgrading.Name = "upgrading"
+	cli := newKruiseTestClient(t, canary, switched, upgrading)
+
+	_, err := cli.SwitchBlueGreenTraffic(context.Background(), "ns", "canary")
+	require.ErrorIs(t, err, ErrNotBlueGreen)
+	_, err = cli.SwitchBlueGreenTraffic(context.Background(), "ns", "switched")
+	require.ErrorIs(t, err, ErrTrafficAlreadySwitched)
+	_, err = cli.SwitchBlueGreenTraffic(context.Background(), "ns", "upgrading")
+	require.ErrorIs(t, err, ErrNotWaitingForSwitch)
+}
+
+func TestContinueKruiseRollout_Canary(t *testing.T) {
+	rollout := &kruiserolloutv1beta1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "ns"},
+		Spec: kruiserolloutv1beta1.RolloutSpec{
+			Strategy: kruiserolloutv1beta1.RolloutStrategy{Canary: &kruiserolloutv1beta1.CanaryStrategy{}},
+		},
+		Status: kruiserolloutv1beta1.RolloutStatus{
+			CanaryStatus: &kruiserolloutv1beta1.CanaryStatus{
+				CommonStatus: kruiserolloutv1beta1.CommonStatus{CurrentStepState: kruiserolloutv1beta1.CanaryStepStatePaused},
+			},
+		},
+	}
+	cli := newKruiseTestClient(t, rollout)
+
+	updated, err := cli.ContinueKruiseRollout(context.Background(), "ns", "app")
+	require.NoError(t, err)
+	require.Equal(t, kruiserolloutv1beta1.CanaryStepStateReady, updated.Status.CanaryStatus.CurrentStepState)
+	require.Nil(t, updated.Status.BlueGreenStatus)
+}
@@ -0,0 +1,252 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	kruiserolloutv1beta1 "github.com/openkruise/kruise-rollout-api/rollouts/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrNotCanary is returned for canary analysis of rollouts using a blue/green strategy
+var ErrNotCanary = errors.New("rollout does not use a canary strategy")
+
+// stepStartedAtAnnotation and stepReadyAtAnnotation are set by the step gate of the
+// openkruise-controller on the Kruise rollout for its current step
+const (
+	stepStartedAtAnnotation = "internal.rollout.kuberik.io/step-%d-started-at"
+	stepReadyAtAnnotation   = "internal.rollout.kuberik.io/step-%d-ready-at"
+)
+
+// Revision labels carried by the pods of a workload. Deployments label pods with the hash of their
+// ReplicaSet, StatefulSets and CloneSets with their controller revision.
+var revisionLabels = []string{"pod-template-hash", "controller-revision-hash"}
+
+// Tracks of the workload pods of a Kruise canary rollout in progress
+const (
+	TrackCanary = "canary"
+	TrackStable = "stable"
+)
+
+// States of a CanaryStepAnalysis
+const (
+	StepCompleted = "completed"
+	StepCurrent   = "current"
+	StepPending   = "pending"
+)
+
+// CanaryAnalysis is the progress of a canary rollout and how its canary pods compare to the stable
+// ones
+type CanaryAnalysis struct {
+	// CanaryRevision is the pod template hash of the canary pods, StableRevision Kruise's revision
+	// of the stable ones
+	CanaryRevision   string `json:"canaryRevision,omitempty"`
+	StableRevision   string `json:"stableRevision,omitempty"`
+	CurrentStep      int32  `json:"currentStep"`
+	CurrentStepState string `json:"currentStepState,omitempty"`
+	Message          string `json:"message,omitempty"`
+	// StartedAt is when the canary started progressing
+	StartedAt *time.Time           `json:"startedAt,omitempty"`
+	Steps     []CanaryStepAnalysis `json:"steps"`
+	Pods      CanaryPods           `json:"pods"`
+}
+
+// CanaryStepAnalysis is a step of a canary rollout
+type CanaryStepAnalysis struct {
+	// Step is the 1-based index of the step
+	Step     int32  `json:"step"`
+	Traffic  string `json:"traffic,omitempty"`
+	Replicas string `json:"replicas,omitempty"`
+	// PauseSeconds is how long the step pauses before moving on, nil for a manual approval
+	PauseSeconds *int32 `json:"pauseSeconds,omitempty"`
+	State        string `json:"state"`
+	// StartedAt, ReadyAt and Duration are only known for the current step: when it started, when
+	// it became ready for approval and how long it has been running
+	StartedAt *time.Time `json:"startedAt,omitempty"`
+	ReadyAt   *time.Time `json:"readyAt,omitempty"`
+	Duration  string     `json:"duration,omitempty"`
+}
+
+// CanaryPods counts the ready pods of the canary and the stable revision
+type CanaryPods struct {
+	Canary PodCount `json:"canary"`
+	Stable PodCount `json:"stable"`
+}
+
+// PodCount counts pods and the ready ones among them
+type PodCount struct {
+	Total int `json:"total"`
+	Ready int `json:"ready"`
+}
+
+// AnalyzeCanary describes the steps of a canary rollout at now and splits pods into those of the
+// canary and the stable revision
+func AnalyzeCanary(rollout *kruiserolloutv1beta1.Rollout, pods []corev1.Pod, now time.Time) (*CanaryAnalysis, error) {
+	canary := rollout.Spec.Strategy.Canary
+	if canary == nil {
+		return nil, ErrNotCanary
+	}
+	analysis := &CanaryAnalysis{Steps: []CanaryStepAnalysis{}}
+	status := rollout.Status.CanaryStatus
+	if status != nil {
+		analysis.CanaryRevision = status.PodTemplateHash
+		analysis.StableRevision = status.StableRevision
+		analysis.CurrentStep = status.CurrentStepIndex
+		analysis.CurrentStepState = string(status.CurrentStepState)
+		analysis.Message = status.Message
+	}
+	for _, condition := range rollout.Status.Conditions {
+		if condition.Type == kruiserolloutv1beta1.RolloutConditionProgressing && condition.Status == corev1.ConditionTrue {
+			startedAt := condition.LastTransitionTime.Time
+			analysis.StartedAt = &startedAt
+		}
+	}
+
+	for i, step := range canary.Steps {
+		index := int32(i + 1)
+		stepAnalysis := CanaryStepAnalysis{Step: index, PauseSeconds: step.Pause.Duration, State: StepPending}
+		if step.Traffic != nil {
+			stepAnalysis.Traffic = *step.Traffic
+		}
+		if step.Replicas != nil {
+			stepAnalysis.Replicas = step.Replicas.String()
+		}
+		switch {
+		case analysis.CurrentStep == 0:
+		case index < analysis.CurrentStep ||
+			index == analysis.CurrentStep && analysis.CurrentStepState == string(kruiserolloutv1beta1.CanaryStepStateCompleted):
+			stepAnalysis.State = StepCompleted
+		case index == analysis.CurrentStep:
+			stepAnalysis.State = StepCurrent
+			stepAnalysis.StartedAt = annotationTime(rollout, stepStartedAtAnnotation, index)
+			if stepAnalysis.StartedAt == nil && index == 1 {
+				// The first step starts with the canary
+				stepAnalysis.StartedAt = analysis.StartedAt
+			}
+			stepAnalysis.ReadyAt = annotationTime(rollout, stepReadyAtAnnotation, index)
+			if stepAnalysis.StartedAt != nil {
+				stepAnalysis.Duration = now.Sub(*stepAnalysis.StartedAt).Round(time.Second).String()
+			}
+		}
+		analysis.Steps = append(analysis.Steps, stepAnalysis)
+	}
+
+	for _, pod := range pods {
+		revision := podRevision(pod)
+		if revision == "" {
+			continue
+		}
+		// Kruise computes the stable revision differently from the pod labels, so every pod that
+		// is not a canary counts as stable
+		count := &analysis.Pods.Stable
+		if revision == analysis.CanaryRevision {
+			count = &analysis.Pods.Canary
+		}
+		count.Total++
+		if podReady(&pod) {
+			count.Ready++
+		}
+	}
+	return analysis, nil
+}
+
+// GetKruiseWorkloadPods lists the pods selected by the workload a Kruise rollout manages
+func (c *Client) GetKruiseWorkloadPods(ctx context.Context, rollout *kruiserolloutv1beta1.Rollout) ([]corev1.Pod, error) {
+	ref := rollout.Spec.WorkloadRef
+	workload := &unstructured.Unstructured{}
+	workload.SetGroupVersionKind(schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind))
+	if err := c.client.Get(ctx, client.ObjectKey{Namespace: rollout.Namespace, Name: ref.Name}, workload); err != nil {
+		return nil, fmt.Errorf("failed to get workload %s %s: %w", ref.Kind, ref.Name, err)
+	}
+	matchLabels, _, err := unstructured.NestedStringMap(workload.Object, "spec", "selector", "matchLabels")
+	if err != nil || len(matchLabels) == 0 {
+		return nil, fmt.Errorf("workload %s %s has no label selector", ref.Kind, ref.Name)
+	}
+	pods, err := c.GetPodsBySelector(ctx, rollout.Namespace, labels.SelectorFromSet(matchLabels))
+	if err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+// CanaryTracks tells the canary pods of a Kruise canary rollout in progress from the stable ones. A
+// nil CanaryTracks puts every pod on no track.
+type CanaryTracks struct {
+	// Revision is the pod template hash of the canary pods, the hash of the canary ReplicaSet
+	Revision string
+	// RolloutID is the rollout ID Kruise labels the pods of each batch with, if the rollout sets one
+	RolloutID string
+}
+
+// GetCanaryTracks returns the tracks of the pods of a rollout, nil when it has no Kruise canary
+// rollout in progress
+func (c *Client) GetCanaryTracks(ctx context.Context, namespace, name string) (*CanaryTracks, error) {
+	rollout := &kruiserolloutv1beta1.Rollout{}
+	if err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, rollout); err != nil {
+		if notInstalledOrFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get kruise rollout: %w", err)
+	}
+	return NewCanaryTracks(rollout), nil
+}
+
+// NewCanaryTracks returns the tracks of a Kruise rollout, nil unless it is a canary in progress
+func NewCanaryTracks(rollout *kruiserolloutv1beta1.Rollout) *CanaryTracks {
+	status := rollout.Status.CanaryStatus
+	if rollout.Spec.Strategy.Canary == nil || status == nil || status.PodTemplateHash == "" ||
+		rollout.Status.Phase != kruiserolloutv1beta1.RolloutPhaseProgressing {
+		return nil
+	}
+	return &CanaryTracks{Revision: status.PodTemplateHash, RolloutID: status.ObservedRolloutID}
+}
+
+// RevisionTrack returns the track of the pods of a revision, e.g. of a ReplicaSet's pod template
+// hash
+func (t *CanaryTracks) RevisionTrack(revision string) string {
+	switch {
+	case t == nil:
+		return ""
+	case revision == t.Revision:
+		return TrackCanary
+	default:
+		return TrackStable
+	}
+}
+
+// PodTrack returns the track of a pod. Pods Kruise labelled with a batch of the current rollout ID
+// are canary pods, others are told apart by their revision.
+func (t *CanaryTracks) PodTrack(pod corev1.Pod) string {
+	if t == nil {
+		return ""
+	}
+	if t.RolloutID != "" && pod.Labels[kruiserolloutv1beta1.RolloutIDLabel] == t.RolloutID &&
+		pod.Labels[kruiserolloutv1beta1.RolloutBatchIDLabel] != "" {
+		return TrackCanary
+	}
+	return t.RevisionTrack(podRevision(pod))
+}
+
+// podRevision returns the revision a pod belongs to
+func podRevision(pod corev1.Pod) string {
+	for _, label := range revisionLabels {
+		if revision := pod.Labels[label]; revision != "" {
+			return revision
+		}
+	}
+	return ""
+}
+
+func annotationTime(rollout *kruiserolloutv1beta1.Rollout, format string, step int32) *time.Time {
+	parsed, err := time.Parse(time.RFC3339, rollout.Annotations[fmt.Sprintf(format, step)])
+	if err != nil {
+		return nil
+	}
+	return &parsed
+}
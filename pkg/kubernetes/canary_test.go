@@ -0,0 +1,118 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	kruiserolloutv1beta1 "github.com/openkruise/kruise-rollout-api/rollouts/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+)
+
+func revisionPod(name, revision string, ready bool) corev1.Pod {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"pod-template-hash": revision}},
+		Status:     corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}},
+	}
+}
+
+func TestAnalyzeCanary(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	rollout := &kruiserolloutv1beta1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop", Annotations: map[string]string{
+			"internal.rollout.kuberik.io/step-2-started-at": "2026-01-02T14:50:00Z",
+			"internal.rollout.kuberik.io/step-2-ready-at":   "2026-01-02T14:55:00Z",
+		}},
+		Spec: kruiserolloutv1beta1.RolloutSpec{Strategy: kruiserolloutv1beta1.RolloutStrategy{Canary: &kruiserolloutv1beta1.CanaryStrategy{
+			Steps: []kruiserolloutv1beta1.CanaryStep{
+				{TrafficRoutingStrategy: kruiserolloutv1beta1.TrafficRoutingStrategy{Traffic: ptr.To("5%")}, Replicas: ptr.To(intstr.FromInt32(1)), Pause: kruiserolloutv1beta1.RolloutPause{Duration: ptr.To(int32(60))}},
+				{TrafficRoutingStrategy: kruiserolloutv1beta1.TrafficRoutingStrategy{Traffic: ptr.To("50%")}, Replicas: ptr.To(intstr.FromString("50%"))},
+				{Replicas: ptr.To(intstr.FromString("100%"))},
+			},
+		}}},
+		Status: kruiserolloutv1beta1.RolloutStatus{
+			Conditions: []kruiserolloutv1beta1.RolloutCondition{{
+				Type: kruiserolloutv1beta1.RolloutConditionProgressing, Status: corev1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(now.Add(-30 * time.Minute)),
+			}},
+			CanaryStatus: &kruiserolloutv1beta1.CanaryStatus{
+				CommonStatus: kruiserolloutv1beta1.CommonStatus{
+					PodTemplateHash: "new", StableRevision: "stable-rev", CurrentStepIndex: 2, CurrentStepState: kruiserolloutv1beta1.CanaryStepStatePaused,
+				},
+			},
+		},
+	}
+	pods := []corev1.Pod{
+		revisionPod("web-new-a", "new", true),
+		revisionPod("web-new-b", "new", false),
+		revisionPod("web-old-a", "old", true),
+		revisionPod("web-old-b", "old", true),
+		{ObjectMeta: metav1.ObjectMeta{Name: "unlabeled"}},
+	}
+
+	analysis, err := AnalyzeCanary(rollout, pods, now)
+	require.NoError(t, err)
+	assert.Equal(t, "new", analysis.CanaryRevision)
+	assert.Equal(t, now.Add(-30*time.Minute), *analysis.StartedAt)
+	assert.Equal(t, CanaryPods{Canary: PodCount{Total: 2, Ready: 1}, Stable: PodCount{Total: 2, Ready: 2}}, analysis.Pods)
+
+	require.Len(t, analysis.Steps, 3)
+	assert.Equal(t, CanaryStepAnalysis{Step: 1, Traffic: "5%", Replicas: "1", PauseSeconds: ptr.To(int32(60)), State: StepCompleted}, analysis.Steps[0])
+	current := analysis.Steps[1]
+	assert.Equal(t, StepCurrent, current.State)
+	assert.Equal(t, "50%", current.Replicas)
+	assert.Equal(t, time.Date(2026, 1, 2, 14, 50, 0, 0, time.UTC), *current.StartedAt)
+	assert.Equal(t, time.Date(2026, 1, 2, 14, 55, 0, 0, time.UTC), *current.ReadyAt)
+	assert.Equal(t, "10m0s", current.Duration)
+	assert.Equal(t, StepPending, analysis.Steps[2].State)
+
+	// Without a step gate annotation the first step starts with the canary
+	rollout.Status.CanaryStatus.CurrentStepIndex = 1
+	analysis, err = AnalyzeCanary(rollout, nil, now)
+	require.NoError(t, err)
+	assert.Equal(t, "30m0s", analysis.Steps[0].Duration)
+
+	_, err = AnalyzeCanary(blueGreenRollout(1, kruiserolloutv1beta1.CanaryStepStatePaused), nil, now)
+	assert.ErrorIs(t, err, ErrNotCanary)
+}
+
+func TestCanaryTracks(t *testing.T) {
+	rollout := &kruiserolloutv1beta1.Rollout{
+		Spec: kruiserolloutv1beta1.RolloutSpec{Strategy: kruiserolloutv1beta1.RolloutStrategy{Canary: &kruiserolloutv1beta1.CanaryStrategy{}}},
+		Status: kruiserolloutv1beta1.RolloutStatus{
+			Phase: kruiserolloutv1beta1.RolloutPhaseProgressing,
+			CanaryStatus: &kruiserolloutv1beta1.CanaryStatus{
+				CommonStatus: kruiserolloutv1beta1.CommonStatus{PodTemplateHash: "new", ObservedRolloutID: "v2"},
+			},
+		},
+	}
+	tracks := NewCanaryTracks(rollout)
+	require.NotNil(t, tracks)
+	assert.Equal(t, TrackCanary, tracks.RevisionTrack("new"))
+	assert.Equal(t, TrackStable, tracks.RevisionTrack("old"))
+	assert.Equal(t, TrackCanary, tracks.PodTrack(revisionPod("web-new", "new", true)))
+	assert.Equal(t, TrackStable, tracks.PodTrack(revisionPod("web-old", "old", true)))
+
+	// Pods Kruise labelled with a batch of the current rollout are canary pods
+	batched := revisionPod("web-batched", "rev-2", true)
+	batched.Labels[kruiserolloutv1beta1.RolloutIDLabel] = "v2"
+	batched.Labels[kruiserolloutv1beta1.RolloutBatchIDLabel] = "1"
+	assert.Equal(t, TrackCanary, tracks.PodTrack(batched))
+	batched.Labels[kruiserolloutv1beta1.RolloutIDLabel] = "v1"
+	assert.Equal(t, TrackStable, tracks.PodTrack(batched))
+
+	// Without a canary in progress pods are on no track
+	rollout.Status.Phase = kruiserolloutv1beta1.RolloutPhaseHealthy
+	tracks = NewCanaryTracks(rollout)
+	assert.Nil(t, tracks)
+	assert.Empty(t, tracks.PodTrack(revisionPod("web-new", "new", true)))
+	assert.Nil(t, NewCanaryTracks(blueGreenRollout(1, kruiserolloutv1beta1.CanaryStepStatePaused)))
+}
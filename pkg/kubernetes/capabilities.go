@@ -0,0 +1,70 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DashboardAction is the access a dashboard action needs
+type DashboardAction struct {
+	APIGroup string
+	Resource string
+	Verb     string
+}
+
+// DashboardActions maps the dashboard's actions to the access their endpoints need, so the UI can
+// hide what the user may not do
+var DashboardActions = map[string]DashboardAction{
+	"view":           {APIGroup: "kuberik.com", Resource: "rollouts", Verb: "get"},
+	"pin":            {APIGroup: "kuberik.com", Resource: "rollouts", Verb: "update"},
+	"changeVersion":  {APIGroup: "kuberik.com", Resource: "rollouts", Verb: "update"},
+	"forceDeploy":    {APIGroup: "kuberik.com", Resource: "rollouts", Verb: "patch"},
+	"bypassGates":    {APIGroup: "kuberik.com", Resource: "rollouts", Verb: "patch"},
+	"unblockFailed":  {APIGroup: "kuberik.com", Resource: "rollouts", Verb: "patch"},
+	"retry":          {APIGroup: "kuberik.com", Resource: "rollouts", Verb: "patch"},
+	"trackChannel":   {APIGroup: "kuberik.com", Resource: "rollouts", Verb: "patch"},
+	"markSuccessful": {APIGroup: "kuberik.com", Resource: "rollouts/status", Verb: "update"},
+	"extendBake":     {APIGroup: "kuberik.com", Resource: "rollouts", Verb: "patch"},
+	"createGate":     {APIGroup: "kuberik.com", Resource: "rolloutgates", Verb: "create"},
+	"updateGate":     {APIGroup: "kuberik.com", Resource: "rolloutgates", Verb: "update"},
+	"deleteGate":     {APIGroup: "kuberik.com", Resource: "rolloutgates", Verb: "delete"},
+	"continue":       {APIGroup: "rollouts.kruise.io", Resource: "rollouts/status", Verb: "patch"},
+	"pause":          {APIGroup: "rollouts.kruise.io", Resource: "rollouts", Verb: "patch"},
+	"continueArgo":   {APIGroup: "argoproj.io", Resource: "rollouts/status", Verb: "patch"},
+	"pauseArgo":      {APIGroup: "argoproj.io", Resource: "rollouts", Verb: "patch"},
+	"reconcile":      {APIGroup: "kustomize.toolkit.fluxcd.io", Resource: "kustomizations", Verb: "patch"},
+	"suspendHelm":    {APIGroup: "helm.toolkit.fluxcd.io", Resource: "helmreleases", Verb: "patch"},
+	"restartPod":     {APIGroup: "", Resource: "pods", Verb: "delete"},
+	"exec":           {APIGroup: "", Resource: "pods/exec", Verb: "create"},
+	"logs":           {APIGroup: "", Resource: "pods/log", Verb: "get"},
+}
+
+// GetCapabilities reports which DashboardActions the current user may perform on any object of
+// their kind in namespace, from a SelfSubjectRulesReview. Rules restricted to resource names are
+// not counted. The review is incomplete when the authorizer cannot enumerate all rules (e.g. a
+// webhook authorizer); actions may then be allowed even though they are reported as denied.
+func (c *Client) GetCapabilities(ctx context.Context, namespace string) (map[string]bool, bool, error) {
+	clientset, err := c.userClientset()
+	if err != nil {
+		return nil, false, err
+	}
+	review, err := clientset.AuthorizationV1().SelfSubjectRulesReviews().Create(ctx, &authorizationv1.SelfSubjectRulesReview{
+		Spec: authorizationv1.SelfSubjectRulesReviewSpec{Namespace: namespace},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create SelfSubjectRulesReview: %w", err)
+	}
+	return CapabilitiesFromRules(review.Status.ResourceRules), review.Status.Incomplete, nil
+}
+
+// CapabilitiesFromRules reports which DashboardActions resource rules grant
+func CapabilitiesFromRules(rules []authorizationv1.ResourceRule) map[string]bool {
+	capabilities := make(map[string]bool, len(DashboardActions))
+	for name, action := range DashboardActions {
+		capabilities[name] = RulesAllow(rules, action.APIGroup, action.Resource, action.Verb)
+	}
+	return capabilities
+}
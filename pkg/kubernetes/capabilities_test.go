@@ -0,0 +1,25 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+)
+
+func TestCapabilitiesFromRules(t *testing.T) {
+	capabilities := CapabilitiesFromRules([]authorizationv1.ResourceRule{
+		{APIGroups: []string{"kuberik.com"}, Resources: []string{"rollouts"}, Verbs: []string{"get", "list", "patch"}},
+		{APIGroups: []string{""}, Resources: []string{"pods/log"}, Verbs: []string{"*"}},
+		// Rules for named objects don't grant the action on every rollout
+		{APIGroups: []string{"kuberik.com"}, Resources: []string{"rollouts"}, Verbs: []string{"update"}, ResourceNames: []string{"api"}},
+	})
+
+	assert.Len(t, capabilities, len(DashboardActions))
+	assert.True(t, capabilities["view"])
+	assert.True(t, capabilities["forceDeploy"])
+	assert.True(t, capabilities["logs"])
+	assert.False(t, capabilities["pin"])
+	assert.False(t, capabilities["markSuccessful"])
+	assert.False(t, capabilities["exec"])
+}
@@ -0,0 +1,94 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ChannelAnnotation marks a rollout as tracking a channel tag (stable, canary, nightly, ...).
+// The rollout is pinned to the release the channel points at and re-pinned when it moves.
+const ChannelAnnotation = "rollout.kuberik.com/channel"
+
+// DefaultChannels are the channel tags looked up when none are configured
+var DefaultChannels = []string{"stable", "canary", "nightly"}
+
+// RolloutChannel returns the channel tracked by the rollout, or empty string
+func RolloutChannel(rollout *rolloutv1alpha1.Rollout) string {
+	return rollout.Annotations[ChannelAnnotation]
+}
+
+// FindReleaseByDigest returns the release of the rollout with the given digest, looking at
+// available releases, release candidates and deployment history. Channel tags are moving aliases
+// and never returned themselves.
+func FindReleaseByDigest(rollout *rolloutv1alpha1.Rollout, digest string, channels []string) *rolloutv1alpha1.VersionInfo {
+	if digest == "" {
+		return nil
+	}
+	isChannel := map[string]bool{}
+	for _, channel := range channels {
+		isChannel[channel] = true
+	}
+	match := func(v rolloutv1alpha1.VersionInfo) bool {
+		return v.Digest != nil && *v.Digest == digest && !isChannel[v.Tag]
+	}
+
+	for _, releases := range [][]rolloutv1alpha1.VersionInfo{rollout.Status.AvailableReleases, rollout.Status.ReleaseCandidates} {
+		for i := range releases {
+			if match(releases[i]) {
+				return &releases[i]
+			}
+		}
+	}
+	for i := range rollout.Status.History {
+		if match(rollout.Status.History[i].Version) {
+			return &rollout.Status.History[i].Version
+		}
+	}
+	return nil
+}
+
+// SetRolloutChannel makes the rollout track a channel and pins it to version, the release the
+// channel currently points at. An empty channel stops tracking and clears the pin.
+func (c *Client) SetRolloutChannel(ctx context.Context, namespace, name, channel, version string) (*rolloutv1alpha1.Rollout, error) {
+	patch := &unstructured.Unstructured{}
+	patch.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   "kuberik.com",
+		Version: "v1alpha1",
+		Kind:    "Rollout",
+	})
+	patch.SetNamespace(namespace)
+	patch.SetName(name)
+
+	annotations := map[string]any{ChannelAnnotation: nil}
+	var wantedVersion any
+	if channel != "" {
+		annotations[ChannelAnnotation] = channel
+		annotations["rollout.kuberik.com/deploy-message"] = fmt.Sprintf("Tracking channel %s", channel)
+		wantedVersion = version
+	}
+	patch.Object["metadata"].(map[string]any)["annotations"] = annotations
+	patch.Object["spec"] = map[string]any{"wantedVersion": wantedVersion}
+	attributeChange(patch, c.actingUser(ctx), true)
+
+	if err := c.client.Patch(ctx, patch, client.Merge, client.FieldOwner("rollout-dashboard")); err != nil {
+		return nil, fmt.Errorf("failed to set rollout channel: %w", err)
+	}
+	return c.GetRollout(ctx, namespace, name)
+}
+
+// stopChannelTracking adds the removal of the channel annotation to a rollout merge patch, so a
+// manual version change is not undone by the channel tracker
+func stopChannelTracking(patch *unstructured.Unstructured) {
+	metadata := patch.Object["metadata"].(map[string]any)
+	annotations, _ := metadata["annotations"].(map[string]any)
+	if annotations == nil {
+		annotations = map[string]any{}
+	}
+	annotations[ChannelAnnotation] = nil
+	metadata["annotations"] = annotations
+}
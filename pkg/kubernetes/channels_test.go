@@ -0,0 +1,66 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestFindReleaseByDigest(t *testing.T) {
+	rollout := &rolloutv1alpha1.Rollout{
+		Status: rolloutv1alpha1.RolloutStatus{
+			AvailableReleases: []rolloutv1alpha1.VersionInfo{
+				{Tag: "stable", Digest: ptr.To("sha256:b")},
+				{Tag: "1.1.0", Digest: ptr.To("sha256:b")},
+			},
+			History: []rolloutv1alpha1.DeploymentHistoryEntry{
+				{Version: rolloutv1alpha1.VersionInfo{Tag: "1.0.0", Digest: ptr.To("sha256:a")}},
+			},
+		},
+	}
+
+	release := FindReleaseByDigest(rollout, "sha256:b", DefaultChannels)
+	require.NotNil(t, release)
+	require.Equal(t, "1.1.0", release.Tag)
+
+	release = FindReleaseByDigest(rollout, "sha256:a", DefaultChannels)
+	require.NotNil(t, release)
+	require.Equal(t, "1.0.0", release.Tag)
+
+	require.Nil(t, FindReleaseByDigest(rollout, "sha256:c", DefaultChannels))
+	require.Nil(t, FindReleaseByDigest(rollout, "", DefaultChannels))
+}
+
+func TestSetRolloutChannel(t *testing.T) {
+	rollout := &rolloutv1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "ns"},
+	}
+	cli := newTestClient(t, rollout)
+
+	got, err := cli.SetRolloutChannel(context.Background(), "ns", "app", "stable", "1.1.0")
+	require.NoError(t, err)
+	require.Equal(t, "stable", RolloutChannel(got))
+	require.Equal(t, "1.1.0", ptr.Deref(got.Spec.WantedVersion, ""))
+	require.Equal(t, "Tracking channel stable", got.Annotations["rollout.kuberik.com/deploy-message"])
+
+	got, err = cli.SetRolloutChannel(context.Background(), "ns", "app", "", "")
+	require.NoError(t, err)
+	require.Empty(t, RolloutChannel(got))
+	require.Nil(t, got.Spec.WantedVersion)
+}
+
+func TestChangeVersion_StopsChannelTracking(t *testing.T) {
+	rollout := &rolloutv1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "ns", Annotations: map[string]string{ChannelAnnotation: "stable"}},
+	}
+	cli := newTestClient(t, rollout)
+
+	got, err := cli.ChangeVersion(context.Background(), "ns", "app", "1.0.0", true, "rollback")
+	require.NoError(t, err)
+	require.Empty(t, RolloutChannel(got))
+	require.Equal(t, "rollback", got.Annotations["rollout.kuberik.com/deploy-message"])
+}
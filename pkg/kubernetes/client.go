@@ -2,16 +2,21 @@ package kubernetes
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 	"time"
 
+	"golang.org/x/sync/errgroup"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -21,95 +26,57 @@ import (
 	"sigs.k8s.io/cli-utils/pkg/object"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	helmv2 "github.com/fluxcd/helm-controller/api/v2"
 	imagereflectorv1beta2 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
 	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
 	openkruisev1alpha1 "github.com/kuberik/openkruise-controller/api/v1alpha1"
 	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/auth"
 	kruiserolloutv1beta1 "github.com/openkruise/kruise-rollout-api/rollouts/v1beta1"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 type Client struct {
 	client    client.Client
 	config    *rest.Config // Store REST config for SelfSubjectAccessReview
-	clientset *kubernetes.Clientset
+	clientset kubernetes.Interface
 }
 
 // GetClientset returns the Kubernetes clientset for direct API access
-func (c *Client) GetClientset() *kubernetes.Clientset {
+func (c *Client) GetClientset() kubernetes.Interface {
 	return c.clientset
 }
 
-// NewClient creates a Kubernetes client using service account credentials (in-cluster) or kubeconfig
-func NewClient() (*Client, error) {
-	return NewClientWithToken("")
-}
-
-// NewClientWithToken creates a Kubernetes client using the provided OIDC token
-// If token is empty, falls back to service account credentials (in-cluster) or kubeconfig
-func NewClientWithToken(token string) (*Client, error) {
-	var config *rest.Config
-	var err error
-
-	// If token is provided, use it for authentication
-	if token != "" {
-		// First try in-cluster config to get the API server URL and CA
-		inClusterConfig, err := rest.InClusterConfig()
-		if err != nil {
-			// If in-cluster config fails, try local kubeconfig
-			var kubeconfig string
-			if home := homedir.HomeDir(); home != "" {
-				kubeconfig = filepath.Join(home, ".kube", "config")
-			} else {
-				kubeconfig = os.Getenv("KUBECONFIG")
-			}
-
-			inClusterConfig, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
-			if err != nil {
-				return nil, fmt.Errorf("failed to get kubeconfig: %w", err)
-			}
-		}
-
-		// Create config with OIDC token
-		config = &rest.Config{
-			Host:            inClusterConfig.Host,
-			APIPath:         inClusterConfig.APIPath,
-			ContentConfig:   inClusterConfig.ContentConfig,
-			BearerToken:     token,
-			BearerTokenFile: "", // Clear BearerTokenFile when using BearerToken
-			TLSClientConfig: inClusterConfig.TLSClientConfig,
-			UserAgent:       inClusterConfig.UserAgent,
-			QPS:             inClusterConfig.QPS,
-			Burst:           inClusterConfig.Burst,
-			Timeout:         inClusterConfig.Timeout,
-		}
-	} else {
-		// No token provided, use default authentication (service account or kubeconfig)
-		// First try in-cluster config
-		config, err = rest.InClusterConfig()
-		if err != nil {
-			// If in-cluster config fails, try local kubeconfig
-			var kubeconfig string
-			if home := homedir.HomeDir(); home != "" {
-				kubeconfig = filepath.Join(home, ".kube", "config")
-			} else {
-				kubeconfig = os.Getenv("KUBECONFIG")
-			}
-
-			config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
-			if err != nil {
-				return nil, fmt.Errorf("failed to get kubeconfig: %w", err)
-			}
-		}
+// CredentialKey identifies the credentials of the client without exposing them, so results can be
+// cached per caller without sharing them across identities
+func (c *Client) CredentialKey() string {
+	if c.config == nil {
+		return ""
 	}
+	if impersonate := c.config.Impersonate; impersonate.UserName != "" {
+		groups := append([]string(nil), impersonate.Groups...)
+		sort.Strings(groups)
+		sum := sha256.Sum256([]byte("impersonate\x00" + impersonate.UserName + "\x00" + strings.Join(groups, "\x00")))
+		return hex.EncodeToString(sum[:])
+	}
+	if c.config.BearerToken == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(c.config.BearerToken))
+	return hex.EncodeToString(sum[:])
+}
 
+// NewScheme returns a scheme with all API types the dashboard reads
+func NewScheme() (*runtime.Scheme, error) {
 	scheme := runtime.NewScheme()
 
 	// Add core Kubernetes scheme (includes v1.Secret, v1.Pod, etc.)
@@ -121,6 +88,10 @@ func NewClientWithToken(token string) (*Client, error) {
 		return nil, fmt.Errorf("failed to add apps scheme: %w", err)
 	}
 
+	if err := batchv1.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to add batch scheme: %w", err)
+	}
+
 	if err := envv1alpha1.AddToScheme(scheme); err != nil {
 		return nil, fmt.Errorf("failed to add environment scheme: %w", err)
 	}
@@ -138,14 +109,101 @@ func NewClientWithToken(token string) (*Client, error) {
 	if err := kustomizev1.AddToScheme(scheme); err != nil {
 		return nil, fmt.Errorf("failed to add kustomize scheme: %w", err)
 	}
+	if err := helmv2.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to add helm scheme: %w", err)
+	}
 	if err := sourcev1.AddToScheme(scheme); err != nil {
 		return nil, fmt.Errorf("failed to add source scheme: %w", err)
 	}
 	if err := kruiserolloutv1beta1.AddToScheme(scheme); err != nil {
 		return nil, fmt.Errorf("failed to add kruise rollout scheme: %w", err)
 	}
+	return scheme, nil
+}
+
+// NewClientFrom wraps existing clients, e.g. fake ones. The REST config is not set, so access
+// reviews and exec are unavailable.
+func NewClientFrom(cl client.Client, clientset kubernetes.Interface) *Client {
+	return &Client{client: cl, clientset: clientset}
+}
+
+// NewClient creates a Kubernetes client using service account credentials (in-cluster) or kubeconfig
+func NewClient() (*Client, error) {
+	return NewClientWithToken("")
+}
+
+// NewClientWithToken creates a Kubernetes client using the provided OIDC token
+// If token is empty, falls back to service account credentials (in-cluster) or kubeconfig
+func NewClientWithToken(token string) (*Client, error) {
+	config, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// If token is provided, use it for authentication
+	if token != "" {
+		// Create config with OIDC token, keeping the API server URL and CA
+		config = &rest.Config{
+			Host:            config.Host,
+			APIPath:         config.APIPath,
+			ContentConfig:   config.ContentConfig,
+			BearerToken:     token,
+			BearerTokenFile: "", // Clear BearerTokenFile when using BearerToken
+			TLSClientConfig: config.TLSClientConfig,
+			UserAgent:       config.UserAgent,
+			QPS:             config.QPS,
+			Burst:           config.Burst,
+			Timeout:         config.Timeout,
+		}
+	}
+
+	return newClientForConfig(config)
+}
 
-	cl, err := client.New(config, client.Options{Scheme: scheme})
+// NewImpersonatingClient creates a Kubernetes client that authenticates with the service account
+// credentials and acts as the given user through impersonation, so the user's RBAC applies
+func NewImpersonatingClient(identity auth.Identity) (*Client, error) {
+	config, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	config = rest.CopyConfig(config)
+	config.Impersonate = rest.ImpersonationConfig{
+		UserName: identity.Username,
+		Groups:   identity.Groups,
+	}
+	return newClientForConfig(config)
+}
+
+// loadConfig returns the service account configuration (in-cluster) or else the local kubeconfig
+func loadConfig() (*rest.Config, error) {
+	config, err := rest.InClusterConfig()
+	if err == nil {
+		return config, nil
+	}
+
+	// If in-cluster config fails, try local kubeconfig
+	var kubeconfig string
+	if home := homedir.HomeDir(); home != "" {
+		kubeconfig = filepath.Join(home, ".kube", "config")
+	} else {
+		kubeconfig = os.Getenv("KUBECONFIG")
+	}
+
+	config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubeconfig: %w", err)
+	}
+	return config, nil
+}
+
+func newClientForConfig(config *rest.Config) (*Client, error) {
+	scheme, err := NewScheme()
+	if err != nil {
+		return nil, err
+	}
+
+	cl, err := client.NewWithWatch(config, client.Options{Scheme: scheme})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create client: %w", err)
 	}
@@ -213,14 +271,11 @@ func (c *Client) UpdateRolloutVersion(ctx context.Context, namespace, name strin
 		annotations["rollout.kuberik.com/deploy-message"] = explanation
 	}
 
-	// Get user info and set deploy-user annotation if available and not a service account
-	if username, isServiceAccount, err := c.GetCurrentUserIdentity(ctx); err == nil && !isServiceAccount && username != "" {
-		annotations["rollout.kuberik.com/deploy-user"] = username
-	}
-
 	if len(annotations) > 0 {
 		patch.SetAnnotations(annotations)
 	}
+	stopChannelTracking(patch)
+	attributeChange(patch, c.actingUser(ctx), true)
 
 	// Use server-side apply to update the wantedVersion field and annotations
 	// This ensures proper field ownership and prevents conflicts
@@ -240,6 +295,12 @@ func (c *Client) UpdateRolloutVersion(ctx context.Context, namespace, name strin
 
 // ContinueKruiseRollout updates the currentStepState of an OpenKruise rollout to continue the rollout
 func (c *Client) ContinueKruiseRollout(ctx context.Context, namespace, name string) (*kruiserolloutv1beta1.Rollout, error) {
+	// Blue/green rollouts track step progress in blueGreenStatus instead of canaryStatus
+	statusField, err := kruiseStepStatusField(ctx, c.client, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create an unstructured patch object with the status.currentStepState field
 	patch := &unstructured.Unstructured{}
 	patch.SetGroupVersionKind(schema.GroupVersionKind{
@@ -252,7 +313,7 @@ func (c *Client) ContinueKruiseRollout(ctx context.Context, namespace, name stri
 
 	// Set the currentStepState to StepReady to continue the rollout
 	patch.Object["status"] = map[string]any{
-		"canaryStatus": map[string]any{
+		statusField: map[string]any{
 			"currentStepState": kruiserolloutv1beta1.CanaryStepStateReady,
 		},
 	}
@@ -356,6 +417,9 @@ func (c *Client) SetRetryAnnotation(ctx context.Context, namespace, name, mode s
 		rollout.Annotations = map[string]string{}
 	}
 	rollout.Annotations[rolloutv1alpha1.RetryAnnotation] = ""
+	if username := c.actingUser(ctx); username != "" {
+		rollout.Annotations[ChangedByAnnotation] = username
+	}
 	if mode == openkruisev1alpha1.RetryModeSkip {
 		rollout.Annotations[openkruisev1alpha1.RetryModeAnnotation] = mode
 	} else {
@@ -452,6 +516,7 @@ func (c *Client) AddBypassGatesAnnotation(ctx context.Context, namespace, name s
 	patch.SetAnnotations(map[string]string{
 		"rollout.kuberik.com/bypass-gates": version,
 	})
+	attributeChange(patch, c.actingUser(ctx), false)
 
 	// Use server-side apply to update only the annotation
 	if err := c.client.Patch(ctx, patch, client.Merge, client.FieldOwner("rollout-dashboard")); err != nil {
@@ -491,12 +556,8 @@ func (c *Client) AddForceDeployAnnotation(ctx context.Context, namespace, name s
 		annotations["rollout.kuberik.com/deploy-message"] = message
 	}
 
-	// Get user info and set deploy-user annotation if available and not a service account
-	if username, isServiceAccount, err := c.GetCurrentUserIdentity(ctx); err == nil && !isServiceAccount && username != "" {
-		annotations["rollout.kuberik.com/deploy-user"] = username
-	}
-
 	patch.SetAnnotations(annotations)
+	attributeChange(patch, c.actingUser(ctx), true)
 
 	// Use server-side apply to update only the annotation
 	if err := c.client.Patch(ctx, patch, client.Merge, client.FieldOwner("rollout-dashboard")); err != nil {
@@ -517,25 +578,30 @@ func (c *Client) AddForceDeployAnnotation(ctx context.Context, namespace, name s
 // When pin is false, it adds the force-deploy annotation for the version and clears spec.wantedVersion
 // in the same server-side apply operation, optionally setting a deploy message.
 func (c *Client) ChangeVersion(ctx context.Context, namespace, name string, version string, pin bool, message string) (*rolloutv1alpha1.Rollout, error) {
-	patch := &unstructured.Unstructured{}
-	patch.SetGroupVersionKind(schema.GroupVersionKind{
-		Group:   "kuberik.com",
-		Version: "v1alpha1",
-		Kind:    "Rollout",
-	})
-	patch.SetNamespace(namespace)
-	patch.SetName(name)
+	patch := changeVersionPatch(namespace, name, version, pin, message)
+	attributeChange(patch, c.actingUser(ctx), true)
+
+	if err := c.client.Patch(ctx, patch, client.Merge, client.FieldOwner("rollout-dashboard")); err != nil {
+		return nil, fmt.Errorf("failed to change version using server-side apply: %w", err)
+	}
+
+	updatedRollout := &rolloutv1alpha1.Rollout{}
+	if err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, updatedRollout); err != nil {
+		return nil, fmt.Errorf("failed to get updated rollout: %w", err)
+	}
+
+	return updatedRollout, nil
+}
+
+// changeVersionPatch is the merge patch of ChangeVersion, without the acting user
+func changeVersionPatch(namespace, name string, version string, pin bool, message string) *unstructured.Unstructured {
+	patch := rolloutPatch(namespace, name)
 
 	annotations := map[string]string{}
 	if message != "" {
 		annotations["rollout.kuberik.com/deploy-message"] = message
 	}
 
-	// Get user info and set deploy-user annotation if available and not a service account
-	if username, isServiceAccount, err := c.GetCurrentUserIdentity(ctx); err == nil && !isServiceAccount && username != "" {
-		annotations["rollout.kuberik.com/deploy-user"] = username
-	}
-
 	if pin {
 		// Pin: set wantedVersion to the specified version
 		patch.Object["spec"] = map[string]any{
@@ -552,17 +618,8 @@ func (c *Client) ChangeVersion(ctx context.Context, namespace, name string, vers
 	if len(annotations) > 0 {
 		patch.SetAnnotations(annotations)
 	}
-
-	if err := c.client.Patch(ctx, patch, client.Merge, client.FieldOwner("rollout-dashboard")); err != nil {
-		return nil, fmt.Errorf("failed to change version using server-side apply: %w", err)
-	}
-
-	updatedRollout := &rolloutv1alpha1.Rollout{}
-	if err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, updatedRollout); err != nil {
-		return nil, fmt.Errorf("failed to get updated rollout: %w", err)
-	}
-
-	return updatedRollout, nil
+	stopChannelTracking(patch)
+	return patch
 }
 
 // AddUnblockFailedAnnotation adds the rollout.kuberik.com/unblock-failed annotation to a rollout
@@ -582,6 +639,7 @@ func (c *Client) AddUnblockFailedAnnotation(ctx context.Context, namespace, name
 	patch.SetAnnotations(map[string]string{
 		"rollout.kuberik.com/unblock-failed": "true",
 	})
+	attributeChange(patch, c.actingUser(ctx), false)
 
 	// Use server-side apply to update only the annotation
 	if err := c.client.Patch(ctx, patch, client.Merge, client.FieldOwner("rollout-dashboard")); err != nil {
@@ -616,18 +674,20 @@ func (c *Client) MarkDeploymentSuccessful(ctx context.Context, namespace, name s
 	latestEntry.BakeStatus = k8sptr.To(rolloutv1alpha1.BakeStatusSucceeded)
 	latestEntry.BakeEndTime = &now
 
-	// Create status message with fixed prefix
-	statusMessage := "Deployment manually marked as successful by user"
+	// Create status message with fixed prefix, naming the user when known
+	username := c.actingUser(ctx)
+	prefix := "Deployment manually marked as successful by user"
+	if username != "" && !IsServiceAccount(username) {
+		prefix = fmt.Sprintf("Deployment manually marked as successful by %s", username)
+	}
+	statusMessage := prefix
 	if message != "" {
-		statusMessage = fmt.Sprintf("Deployment manually marked as successful by user: %s", message)
+		statusMessage = fmt.Sprintf("%s: %s", prefix, message)
 	}
 	latestEntry.BakeStatusMessage = &statusMessage
 
 	// Update the Ready condition
-	readyConditionMessage := "Deployment manually marked as successful by user"
-	if message != "" {
-		readyConditionMessage = fmt.Sprintf("Deployment manually marked as successful by user: %s", message)
-	}
+	readyConditionMessage := statusMessage
 
 	// Initialize conditions slice if nil
 	if rollout.Status.Conditions == nil {
@@ -650,6 +710,18 @@ func (c *Client) MarkDeploymentSuccessful(ctx context.Context, namespace, name s
 		return nil, fmt.Errorf("failed to update rollout status: %w", err)
 	}
 
+	// The status subresource ignores metadata, so the change is attributed separately
+	if username != "" {
+		patch := &unstructured.Unstructured{}
+		patch.SetGroupVersionKind(schema.GroupVersionKind{Group: "kuberik.com", Version: "v1alpha1", Kind: "Rollout"})
+		patch.SetNamespace(namespace)
+		patch.SetName(name)
+		attributeChange(patch, username, false)
+		if err := c.client.Patch(ctx, patch, client.Merge, client.FieldOwner("rollout-dashboard")); err != nil {
+			slog.Warn("Failed to record who marked the deployment successful", "namespace", namespace, "rollout", name, "error", err)
+		}
+	}
+
 	return rollout, nil
 }
 
@@ -661,6 +733,14 @@ func (c *Client) GetSecret(ctx context.Context, namespace, name string) (*corev1
 	return secret, nil
 }
 
+func (c *Client) GetConfigMap(ctx context.Context, namespace, name string) (*corev1.ConfigMap, error) {
+	configMap := &corev1.ConfigMap{}
+	if err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, configMap); err != nil {
+		return nil, fmt.Errorf("failed to get config map: %w", err)
+	}
+	return configMap, nil
+}
+
 func (c *Client) GetImagePolicies(ctx context.Context, namespace string) (*imagereflectorv1beta2.ImagePolicyList, error) {
 	imagePolicies := &imagereflectorv1beta2.ImagePolicyList{}
 	if err := c.client.List(ctx, imagePolicies, client.InNamespace(namespace)); err != nil {
@@ -745,6 +825,48 @@ func (c *Client) GetImageRepository(ctx context.Context, namespace, name string)
 	return imageRepository, nil
 }
 
+// GetRolloutsByImage returns the rollouts whose ImagePolicy references an ImageRepository with
+// an image matched by match, sorted by namespace and name
+func (c *Client) GetRolloutsByImage(ctx context.Context, match func(image string) bool) ([]types.NamespacedName, error) {
+	imageRepositories, err := c.GetImageRepositoriesAllNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	repositories := map[types.NamespacedName]bool{}
+	for _, repository := range imageRepositories.Items {
+		if match(repository.Spec.Image) {
+			repositories[types.NamespacedName{Namespace: repository.Namespace, Name: repository.Name}] = true
+		}
+	}
+	if len(repositories) == 0 {
+		return nil, nil
+	}
+
+	imagePolicies, err := c.GetImagePoliciesAllNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	policies := map[types.NamespacedName]bool{}
+	for _, policy := range imagePolicies.Items {
+		if repositories[types.NamespacedName{Namespace: policy.Namespace, Name: policy.Spec.ImageRepositoryRef.Name}] {
+			policies[types.NamespacedName{Namespace: policy.Namespace, Name: policy.Name}] = true
+		}
+	}
+
+	rollouts, err := c.GetRolloutsAllNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var matched []types.NamespacedName
+	for _, rollout := range rollouts.Items {
+		if policies[types.NamespacedName{Namespace: rollout.Namespace, Name: rollout.Spec.ReleasesImagePolicy.Name}] {
+			matched = append(matched, types.NamespacedName{Namespace: rollout.Namespace, Name: rollout.Name})
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].String() < matched[j].String() })
+	return matched, nil
+}
+
 func (c *Client) GetKustomizationsByRolloutAnnotation(ctx context.Context, namespace, rolloutName string) (*kustomizev1.KustomizationList, error) {
 	kustomizations := &kustomizev1.KustomizationList{}
 	if err := c.client.List(ctx, kustomizations, client.InNamespace(namespace)); err != nil {
@@ -767,6 +889,7 @@ func (c *Client) GetKustomizationsByRolloutAnnotation(ctx context.Context, names
 	// or reference OCIRepositories that have rollout annotations
 	filteredKustomizations := &kustomizev1.KustomizationList{}
 	for _, kustomization := range kustomizations.Items {
+		matched := false
 		// Check for rollout.kuberik.com/substitute.<variable>.from: <rollout> annotation
 		// This format allows kustomizations to specify which rollout they get variables from
 		// Example: rollout.kuberik.com/substitute.HELLO_WORLD_VERSION.from: "hello-world-app"
@@ -774,7 +897,7 @@ func (c *Client) GetKustomizationsByRolloutAnnotation(ctx context.Context, names
 			if strings.HasPrefix(annotationKey, "rollout.kuberik.com/substitute.") &&
 				strings.HasSuffix(annotationKey, ".from") &&
 				annotationValue == rolloutName {
-				filteredKustomizations.Items = append(filteredKustomizations.Items, kustomization)
+				matched = true
 				break
 			}
 		}
@@ -783,6 +906,9 @@ func (c *Client) GetKustomizationsByRolloutAnnotation(ctx context.Context, names
 		if kustomization.Spec.SourceRef.Kind == "OCIRepository" &&
 			kustomization.Spec.SourceRef.Name != "" &&
 			ociRepoNames[kustomization.Spec.SourceRef.Name] {
+			matched = true
+		}
+		if matched {
 			filteredKustomizations.Items = append(filteredKustomizations.Items, kustomization)
 		}
 	}
@@ -832,9 +958,20 @@ type ManagedResourceStatus struct {
 	Message          string                     `json:"message"`
 	LastModified     time.Time                  `json:"lastModified"`
 	Object           *unstructured.Unstructured `json:"object"`
+	// Drifted, Diff and DriftError are only set with WithDriftDetection
+	Drifted    bool        `json:"drifted,omitempty"`
+	Diff       []FieldDiff `json:"diff,omitempty"`
+	DriftError string      `json:"driftError,omitempty"`
+	// Children are the objects owned by this one, only set with WithResourceTree
+	Children []ManagedResourceStatus `json:"children,omitempty"`
 }
 
-func (c *Client) GetKustomizationManagedResources(ctx context.Context, namespace, name string) ([]ManagedResourceStatus, error) {
+func (c *Client) GetKustomizationManagedResources(ctx context.Context, namespace, name string, opts ...ManagedResourcesOption) ([]ManagedResourceStatus, error) {
+	options := managedResourcesOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	// Get the Kustomization
 	kustomization := &kustomizev1.Kustomization{}
 	if err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, kustomization); err != nil {
@@ -843,11 +980,11 @@ func (c *Client) GetKustomizationManagedResources(ctx context.Context, namespace
 
 	// Get the inventory from the Kustomization status
 	if kustomization.Status.Inventory == nil {
-		fmt.Printf("Kustomization %s/%s has no inventory\n", namespace, name)
+		slog.Debug("Kustomization has no inventory", "namespace", namespace, "kustomization", name)
 		return []ManagedResourceStatus{}, nil
 	}
 
-	fmt.Printf("Kustomization %s/%s inventory has %d entries\n", namespace, name, len(kustomization.Status.Inventory.Entries))
+	slog.Debug("Kustomization inventory loaded", "namespace", namespace, "kustomization", name, "entries", len(kustomization.Status.Inventory.Entries))
 
 	var managedResources []ManagedResourceStatus
 
@@ -857,7 +994,7 @@ func (c *Client) GetKustomizationManagedResources(ctx context.Context, namespace
 		// Use Flux's object.ParseObjMetadata to parse the inventory ID
 		objMetadata, err := object.ParseObjMetadata(entry.ID)
 		if err != nil {
-			fmt.Printf("Failed to parse inventory entry %s: %v\n", entry.ID, err)
+			slog.Warn("Failed to parse inventory entry", "entry", entry.ID, "error", err)
 			continue
 		}
 
@@ -871,7 +1008,7 @@ func (c *Client) GetKustomizationManagedResources(ctx context.Context, namespace
 
 		err = c.client.Get(ctx, client.ObjectKey{Namespace: objMetadata.Namespace, Name: objMetadata.Name}, obj)
 		if err != nil {
-			fmt.Printf("Failed to get resource %s/%s: %v\n", objMetadata.Namespace, objMetadata.Name, err)
+			slog.Debug("Failed to get managed resource", "namespace", objMetadata.Namespace, "name", objMetadata.Name, "error", err)
 			// Resource not found or error
 			managedResources = append(managedResources, ManagedResourceStatus{
 				GroupVersionKind: fmt.Sprintf("%s/%s/%s", objMetadata.GroupKind.Group, entry.Version, objMetadata.GroupKind.Kind),
@@ -926,6 +1063,13 @@ func (c *Client) GetKustomizationManagedResources(ctx context.Context, namespace
 		return managedResources[i].LastModified.After(managedResources[j].LastModified)
 	})
 
+	if options.detectDrift {
+		c.DetectDrift(ctx, kustomization, managedResources)
+	}
+	if options.resourceTree {
+		c.AddResourceChildren(ctx, managedResources)
+	}
+
 	return managedResources, nil
 }
 
@@ -946,13 +1090,13 @@ func (c *Client) GetHealthChecksBySelector(ctx context.Context, namespace string
 		nsSelector, err := metav1.LabelSelectorAsSelector(selector.NamespaceSelector)
 		if err != nil {
 			// If we can't parse the namespace selector, log the error and default to same namespace
-			fmt.Printf("Failed to parse namespace selector: %v, defaulting to same namespace\n", err)
+			slog.Warn("Failed to parse namespace selector, defaulting to same namespace", "error", err)
 			namespaces = []string{namespace}
 		} else {
 			// Get all namespaces and filter by the selector
 			namespaceList := &corev1.NamespaceList{}
 			if err := c.client.List(ctx, namespaceList); err != nil {
-				fmt.Printf("Failed to list namespaces: %v, defaulting to same namespace\n", err)
+				slog.Warn("Failed to list namespaces, defaulting to same namespace", "error", err)
 				namespaces = []string{namespace}
 			} else {
 				// Filter namespaces by the selector
@@ -977,7 +1121,7 @@ func (c *Client) GetHealthChecksBySelector(ctx context.Context, namespace string
 	for _, ns := range namespaces {
 		healthCheckList := &rolloutv1alpha1.HealthCheckList{}
 		if err := c.client.List(ctx, healthCheckList, client.InNamespace(ns)); err != nil {
-			fmt.Printf("Failed to list health checks in namespace %s: %v\n", ns, err)
+			slog.Warn("Failed to list health checks", "namespace", ns, "error", err)
 			continue // Skip this namespace if there's an error
 		}
 
@@ -1002,7 +1146,7 @@ func matchesSelector(hc *rolloutv1alpha1.HealthCheck, selector *rolloutv1alpha1.
 	sel, err := metav1.LabelSelectorAsSelector(selector.Selector)
 	if err != nil {
 		// If we can't parse the selector, log the error and return false
-		fmt.Printf("Failed to parse label selector: %v\n", err)
+		slog.Warn("Failed to parse label selector", "error", err)
 		return false
 	}
 
@@ -1017,75 +1161,105 @@ func matchesSelector(hc *rolloutv1alpha1.HealthCheck, selector *rolloutv1alpha1.
 
 // ReconcileKustomization adds the reconcile annotation to trigger a reconciliation
 func (c *Client) ReconcileKustomization(ctx context.Context, namespace, name string) error {
-	kustomization := &kustomizev1.Kustomization{}
-	if err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, kustomization); err != nil {
-		return fmt.Errorf("failed to get kustomization: %w", err)
+	if err := c.requestReconcile(ctx, kustomizev1.GroupVersion.WithKind(kustomizev1.KustomizationKind), namespace, name); err != nil {
+		return fmt.Errorf("failed to patch kustomization: %w", err)
 	}
-
-	// Add the reconcile annotation with current timestamp
-	if kustomization.Annotations == nil {
-		kustomization.Annotations = make(map[string]string)
-	}
-	kustomization.Annotations["reconcile.fluxcd.io/requestedAt"] = fmt.Sprintf("%d", time.Now().Unix())
-
-	if err := c.client.Update(ctx, kustomization); err != nil {
-		return fmt.Errorf("failed to update kustomization: %w", err)
-	}
-
 	return nil
 }
 
 // ReconcileOCIRepository adds the reconcile annotation to trigger a reconciliation
 func (c *Client) ReconcileOCIRepository(ctx context.Context, namespace, name string) error {
-	ociRepository := &sourcev1.OCIRepository{}
-	if err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, ociRepository); err != nil {
-		return fmt.Errorf("failed to get OCI repository: %w", err)
-	}
-
-	// Add the reconcile annotation with current timestamp
-	if ociRepository.Annotations == nil {
-		ociRepository.Annotations = make(map[string]string)
-	}
-	ociRepository.Annotations["reconcile.fluxcd.io/requestedAt"] = fmt.Sprintf("%d", time.Now().Unix())
-
-	if err := c.client.Update(ctx, ociRepository); err != nil {
-		return fmt.Errorf("failed to update OCI repository: %w", err)
+	if err := c.requestReconcile(ctx, sourcev1.GroupVersion.WithKind(sourcev1.OCIRepositoryKind), namespace, name); err != nil {
+		return fmt.Errorf("failed to patch OCI repository: %w", err)
 	}
-
 	return nil
 }
 
 // ReconcileImageRepository adds the reconcile annotation to trigger a reconciliation
 func (c *Client) ReconcileImageRepository(ctx context.Context, namespace, name string) error {
-	imageRepository := &imagereflectorv1beta2.ImageRepository{}
-	if err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, imageRepository); err != nil {
-		return fmt.Errorf("failed to get image repository: %w", err)
+	if err := c.requestReconcile(ctx, imagereflectorv1beta2.GroupVersion.WithKind(imagereflectorv1beta2.ImageRepositoryKind), namespace, name); err != nil {
+		return fmt.Errorf("failed to patch image repository: %w", err)
 	}
+	return nil
+}
 
-	// Add the reconcile annotation with current timestamp
-	if imageRepository.Annotations == nil {
-		imageRepository.Annotations = make(map[string]string)
-	}
-	imageRepository.Annotations["reconcile.fluxcd.io/requestedAt"] = fmt.Sprintf("%d", time.Now().Unix())
+// requestReconcile sets the Flux reconcile.fluxcd.io/requestedAt annotation with a JSON merge
+// patch that carries nothing else. Unlike a read-modify-write update it has no resourceVersion
+// precondition, so it cannot conflict with the controller updating the object at the same time.
+func (c *Client) requestReconcile(ctx context.Context, gvk schema.GroupVersionKind, namespace, name string) error {
+	return c.requestReconcileAt(ctx, gvk, namespace, name, fmt.Sprintf("%d", time.Now().Unix()))
+}
 
-	if err := c.client.Update(ctx, imageRepository); err != nil {
-		return fmt.Errorf("failed to update image repository: %w", err)
-	}
+// maxConcurrentReconciles bounds the reconcile requests sent at once for a rollout
+const maxConcurrentReconciles = 8
 
-	return nil
+// ReconcileResult is the outcome of requesting reconciliation of a single Flux object
+type ReconcileResult struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Succeeded bool   `json:"succeeded"`
+	Error     string `json:"error,omitempty"`
+}
+
+// reconcilableSourceKinds are the Flux sources the source-controller reconciles, which
+// Kustomizations and HelmReleases may take their artifacts from
+var reconcilableSourceKinds = map[string]schema.GroupVersionKind{
+	sourcev1.GitRepositoryKind:  sourcev1.GroupVersion.WithKind(sourcev1.GitRepositoryKind),
+	sourcev1.OCIRepositoryKind:  sourcev1.GroupVersion.WithKind(sourcev1.OCIRepositoryKind),
+	sourcev1.BucketKind:         sourcev1.GroupVersion.WithKind(sourcev1.BucketKind),
+	sourcev1.HelmRepositoryKind: sourcev1.GroupVersion.WithKind(sourcev1.HelmRepositoryKind),
+	sourcev1.HelmChartKind:      sourcev1.GroupVersion.WithKind(sourcev1.HelmChartKind),
 }
 
-// ReconcileAllFluxResources reconciles all associated Flux resources for a rollout
-// Returns the previous scanTime of the ImageRepository (if found) so the caller can detect completion
-func (c *Client) ReconcileAllFluxResources(ctx context.Context, namespace, rolloutName string) (previousScanTime string, err error) {
+// ReconcileAllFluxResources requests reconciliation of the Kustomizations, HelmReleases and
+// OCIRepositories associated with a rollout concurrently. With withSource, like flux reconcile
+// --with-source, the upstream sources of the Kustomizations and HelmReleases and the scan of the
+// ImageRepository behind the rollout's ImagePolicy are requested first. A failing object does not
+// stop the others; the outcome of each is returned keyed by "Kind/namespace/name". An error is only
+// returned when the objects could not be determined or ctx was cancelled.
+// previousScanTime is the ImageRepository's last scan time (if scanned and found) so the caller can
+// detect completion.
+func (c *Client) ReconcileAllFluxResources(ctx context.Context, namespace, rolloutName string, withSource bool) (previousScanTime string, results map[string]ReconcileResult, err error) {
+	type target struct {
+		result    ReconcileResult
+		reconcile func(ctx context.Context, namespace, name string) error
+	}
+	var sources, targets []target
+	keys := map[string]bool{}
+	add := func(list *[]target, kind, namespace, name string, reconcile func(ctx context.Context, namespace, name string) error) {
+		key := kind + "/" + namespace + "/" + name
+		if keys[key] {
+			return
+		}
+		keys[key] = true
+		*list = append(*list, target{result: ReconcileResult{Kind: kind, Namespace: namespace, Name: name}, reconcile: reconcile})
+	}
+	// addSource adds the upstream source a Kustomization or HelmRelease references
+	addSource := func(kind, refNamespace, namespace, name string) {
+		gvk, ok := reconcilableSourceKinds[kind]
+		if !withSource || !ok || name == "" {
+			return
+		}
+		if refNamespace != "" {
+			namespace = refNamespace
+		}
+		add(&sources, kind, namespace, name, func(ctx context.Context, namespace, name string) error {
+			if err := c.requestReconcile(ctx, gvk, namespace, name); err != nil {
+				return fmt.Errorf("failed to patch %s: %w", kind, err)
+			}
+			return nil
+		})
+	}
+
 	// Get the rollout to find its ImagePolicy reference
 	rollout, err := c.GetRollout(ctx, namespace, rolloutName)
 	if err != nil {
-		return "", fmt.Errorf("failed to get rollout: %w", err)
+		return "", nil, fmt.Errorf("failed to get rollout: %w", err)
 	}
 
-	// Reconcile the ImageRepository referenced by the rollout's ImagePolicy
-	if rollout.Spec.ReleasesImagePolicy.Name != "" {
+	// The ImageRepository referenced by the rollout's ImagePolicy
+	if withSource && rollout.Spec.ReleasesImagePolicy.Name != "" {
 		imagePolicy, err := c.GetImagePolicy(ctx, namespace, rollout.Spec.ReleasesImagePolicy.Name)
 		if err == nil && imagePolicy.Spec.ImageRepositoryRef.Name != "" {
 			// Get the ImageRepository to capture the previous scan time
@@ -1093,41 +1267,81 @@ func (c *Client) ReconcileAllFluxResources(ctx context.Context, namespace, rollo
 			if err == nil && imageRepo.Status.LastScanResult != nil {
 				previousScanTime = imageRepo.Status.LastScanResult.ScanTime.Format(time.RFC3339)
 			}
-			// Reconcile the ImageRepository
-			if err := c.ReconcileImageRepository(ctx, namespace, imagePolicy.Spec.ImageRepositoryRef.Name); err != nil {
-				// Log but don't fail - other resources can still be reconciled
-				fmt.Printf("Warning: failed to reconcile image repository %s: %v\n", imagePolicy.Spec.ImageRepositoryRef.Name, err)
-			}
+			add(&sources, imagereflectorv1beta2.ImageRepositoryKind, namespace, imagePolicy.Spec.ImageRepositoryRef.Name, c.ReconcileImageRepository)
 		}
 	}
 
-	// Get associated Kustomizations
-	kustomizations, err := c.GetKustomizationsByRolloutAnnotation(ctx, namespace, rolloutName)
+	// Get associated OCIRepositories, which are the rollout's own sources and always reconciled;
+	// with withSource they are requested with the other sources
+	ociRepositories, err := c.GetOCIRepositoriesByRolloutAnnotation(ctx, namespace, rolloutName)
 	if err != nil {
-		return previousScanTime, fmt.Errorf("failed to get kustomizations: %w", err)
+		return previousScanTime, nil, fmt.Errorf("failed to get OCI repositories: %w", err)
+	}
+	ociTargets := &targets
+	if withSource {
+		ociTargets = &sources
+	}
+	for _, ociRepository := range ociRepositories.Items {
+		add(ociTargets, sourcev1.OCIRepositoryKind, ociRepository.Namespace, ociRepository.Name, c.ReconcileOCIRepository)
 	}
 
-	// Get associated OCIRepositories
-	ociRepositories, err := c.GetOCIRepositoriesByRolloutAnnotation(ctx, namespace, rolloutName)
+	// Get associated Kustomizations
+	kustomizations, err := c.GetKustomizationsByRolloutAnnotation(ctx, namespace, rolloutName)
 	if err != nil {
-		return previousScanTime, fmt.Errorf("failed to get OCI repositories: %w", err)
+		return previousScanTime, nil, fmt.Errorf("failed to get kustomizations: %w", err)
 	}
-
-	// Reconcile all Kustomizations
 	for _, kustomization := range kustomizations.Items {
-		if err := c.ReconcileKustomization(ctx, kustomization.Namespace, kustomization.Name); err != nil {
-			return previousScanTime, fmt.Errorf("failed to reconcile kustomization %s: %w", kustomization.Name, err)
-		}
+		ref := kustomization.Spec.SourceRef
+		addSource(ref.Kind, ref.Namespace, kustomization.Namespace, ref.Name)
+		add(&targets, kustomizev1.KustomizationKind, kustomization.Namespace, kustomization.Name, c.ReconcileKustomization)
 	}
 
-	// Reconcile all OCIRepositories
-	for _, ociRepository := range ociRepositories.Items {
-		if err := c.ReconcileOCIRepository(ctx, ociRepository.Namespace, ociRepository.Name); err != nil {
-			return previousScanTime, fmt.Errorf("failed to reconcile OCI repository %s: %w", ociRepository.Name, err)
+	// Get associated HelmReleases
+	helmReleases, err := c.GetHelmReleasesByRolloutAnnotation(ctx, namespace, rolloutName)
+	if err != nil {
+		return previousScanTime, nil, fmt.Errorf("failed to get helm releases: %w", err)
+	}
+	for _, helmRelease := range helmReleases.Items {
+		if ref := helmRelease.Spec.ChartRef; ref != nil {
+			addSource(ref.Kind, ref.Namespace, helmRelease.Namespace, ref.Name)
+		} else if chart := helmRelease.Spec.Chart; chart != nil {
+			ref := chart.Spec.SourceRef
+			addSource(ref.Kind, ref.Namespace, helmRelease.Namespace, ref.Name)
+		}
+		add(&targets, helmv2.HelmReleaseKind, helmRelease.Namespace, helmRelease.Name, c.ReconcileHelmRelease)
+	}
+
+	// Sources are requested before the objects consuming their artifacts
+	for _, phase := range [][]target{sources, targets} {
+		var group errgroup.Group
+		group.SetLimit(maxConcurrentReconciles)
+		for i := range phase {
+			group.Go(func() error {
+				t := &phase[i]
+				if err := ctx.Err(); err != nil {
+					t.result.Error = err.Error()
+					return nil
+				}
+				if err := t.reconcile(ctx, t.result.Namespace, t.result.Name); err != nil {
+					slog.Warn("Failed to reconcile Flux resource", "kind", t.result.Kind, "namespace", t.result.Namespace, "name", t.result.Name, "error", err)
+					t.result.Error = err.Error()
+					return nil
+				}
+				t.result.Succeeded = true
+				return nil
+			})
+		}
+		group.Wait()
+		if err := ctx.Err(); err != nil {
+			return previousScanTime, nil, err
 		}
 	}
 
-	return previousScanTime, nil
+	results = make(map[string]ReconcileResult, len(sources)+len(targets))
+	for _, t := range append(sources, targets...) {
+		results[t.result.Kind+"/"+t.result.Namespace+"/"+t.result.Name] = t.result
+	}
+	return previousScanTime, results, nil
 }
 
 // GetRolloutGatesByRolloutReference fetches RolloutGates that reference a specific rollout
@@ -1172,6 +1386,15 @@ func (c *Client) GetAllRolloutTests(ctx context.Context, namespace string) (*ope
 	return rolloutTests, nil
 }
 
+// GetRolloutTest fetches a RolloutTest by namespace and name
+func (c *Client) GetRolloutTest(ctx context.Context, namespace, name string) (*openkruisev1alpha1.RolloutTest, error) {
+	test := &openkruisev1alpha1.RolloutTest{}
+	if err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, test); err != nil {
+		return nil, fmt.Errorf("failed to get rollout test: %w", err)
+	}
+	return test, nil
+}
+
 // GetRolloutTestsByRolloutName fetches RolloutTests that reference a specific KruiseRollout by name
 func (c *Client) GetRolloutTestsByRolloutName(ctx context.Context, namespace, rolloutName string) (*openkruisev1alpha1.RolloutTestList, error) {
 	rolloutTests := &openkruisev1alpha1.RolloutTestList{}
@@ -1225,6 +1448,40 @@ func (c *Client) GetEnvironments(ctx context.Context, namespace string) (*envv1a
 	return environments, nil
 }
 
+// GetEnvironmentsAllNamespaces fetches Environments across all namespaces
+func (c *Client) GetEnvironmentsAllNamespaces(ctx context.Context) (*envv1alpha1.EnvironmentList, error) {
+	environments := &envv1alpha1.EnvironmentList{}
+	if err := c.client.List(ctx, environments); err != nil {
+		return nil, fmt.Errorf("failed to list environments across all namespaces: %w", err)
+	}
+	return environments, nil
+}
+
+// EnvironmentRollouts returns the rollouts managed by Environments of the given environment name
+// (e.g. "production"), optionally limited to one deployment name (spec.name). Each rollout is
+// returned once, sorted by namespace and name.
+func EnvironmentRollouts(environments []envv1alpha1.Environment, environment, deploymentName string) []types.NamespacedName {
+	seen := map[types.NamespacedName]bool{}
+	rollouts := []types.NamespacedName{}
+	for _, env := range environments {
+		if env.Spec.Environment != environment || env.Spec.RolloutRef.Name == "" {
+			continue
+		}
+		if deploymentName != "" && env.Spec.Name != deploymentName {
+			continue
+		}
+		ref := types.NamespacedName{Namespace: env.Namespace, Name: env.Spec.RolloutRef.Name}
+		if !seen[ref] {
+			seen[ref] = true
+			rollouts = append(rollouts, ref)
+		}
+	}
+	sort.Slice(rollouts, func(i, j int) bool {
+		return rollouts[i].String() < rollouts[j].String()
+	})
+	return rollouts
+}
+
 // GetPodsBySelector lists pods matching the given label selector
 func (c *Client) GetPodsBySelector(ctx context.Context, namespace string, selector labels.Selector) (*corev1.PodList, error) {
 	pods := &corev1.PodList{}
@@ -1373,96 +1630,6 @@ func (c *Client) GetClusterRolloutSchedule(ctx context.Context, name string) (*r
 	return schedule, nil
 }
 
-// GetEventsForRollout collects events relevant to a rollout:
-// 1. Events for the Rollout object itself
-// 2. Events for all Deployments found via kustomizations linked to the rollout
-// 3. Events for ReplicaSets owned by those Deployments
-func (c *Client) GetEventsForRollout(ctx context.Context, namespace, rolloutName string) ([]corev1.Event, error) {
-	cutoff := time.Now().Add(-2 * time.Hour)
-	var allEvents []corev1.Event
-
-	fetchEvents := func(ns, name string) error {
-		eventList, err := c.clientset.CoreV1().Events(ns).List(ctx, metav1.ListOptions{
-			FieldSelector: "involvedObject.name=" + name,
-		})
-		if err != nil {
-			return err
-		}
-		for _, ev := range eventList.Items {
-			if ev.LastTimestamp.After(cutoff) {
-				allEvents = append(allEvents, ev)
-			}
-		}
-		return nil
-	}
-
-	// Find Deployments via kustomizations linked to the rollout
-	kustomizations, err := c.GetKustomizationsByRolloutAnnotation(ctx, namespace, rolloutName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get kustomizations: %w", err)
-	}
-
-	for _, kustomization := range kustomizations.Items {
-		resources, err := c.GetKustomizationManagedResources(ctx, kustomization.Namespace, kustomization.Name)
-		if err != nil {
-			fmt.Printf("Warning: failed to get managed resources for kustomization %s: %v\n", kustomization.Name, err)
-			continue
-		}
-		for _, resource := range resources {
-			if !strings.Contains(resource.GroupVersionKind, "apps/v1/Deployment") {
-				continue
-			}
-			if err := fetchEvents(resource.Namespace, resource.Name); err != nil {
-				fmt.Printf("Warning: failed to get events for deployment %s/%s: %v\n", resource.Namespace, resource.Name, err)
-			}
-			if resource.Object == nil {
-				continue
-			}
-			deploymentUID := string(resource.Object.GetUID())
-			rsList, err := c.clientset.AppsV1().ReplicaSets(resource.Namespace).List(ctx, metav1.ListOptions{})
-			if err != nil {
-				fmt.Printf("Warning: failed to list replicasets in %s: %v\n", resource.Namespace, err)
-				continue
-			}
-			for _, rs := range rsList.Items {
-				for _, ownerRef := range rs.OwnerReferences {
-					if string(ownerRef.UID) == deploymentUID {
-						if err := fetchEvents(rs.Namespace, rs.Name); err != nil {
-							fmt.Printf("Warning: failed to get events for replicaset %s/%s: %v\n", rs.Namespace, rs.Name, err)
-						}
-						break
-					}
-				}
-			}
-		}
-	}
-
-	// Deduplicate by message+reason+involvedObject
-	type dedupeKey struct{ message, reason, objName, objKind string }
-	seen := make(map[dedupeKey]int)
-	var deduped []corev1.Event
-	for _, ev := range allEvents {
-		k := dedupeKey{ev.Message, ev.Reason, ev.InvolvedObject.Name, ev.InvolvedObject.Kind}
-		if idx, exists := seen[k]; exists {
-			if ev.LastTimestamp.After(deduped[idx].LastTimestamp.Time) {
-				deduped[idx] = ev
-			}
-		} else {
-			seen[k] = len(deduped)
-			deduped = append(deduped, ev)
-		}
-	}
-
-	sort.Slice(deduped, func(i, j int) bool {
-		return deduped[i].LastTimestamp.After(deduped[j].LastTimestamp.Time)
-	})
-
-	if len(deduped) > 50 {
-		deduped = deduped[:50]
-	}
-	return deduped, nil
-}
-
 // GetRolloutSchedulesByRollout gets RolloutSchedules that match a specific rollout
 func (c *Client) GetRolloutSchedulesByRollout(ctx context.Context, namespace, rolloutName string, rolloutLabels map[string]string) (*rolloutv1alpha1.RolloutScheduleList, error) {
 	schedules := &rolloutv1alpha1.RolloutScheduleList{}
@@ -1520,3 +1687,43 @@ func (c *Client) GetClusterRolloutSchedulesByRollout(ctx context.Context, namesp
 
 	return matchingSchedules, nil
 }
+
+// WaitForVersionAcknowledged polls the rollout until the controller reports the given version as the
+// current deployment (status.history[0]) or the timeout expires. Only a deployment after previous,
+// the current deployment when the change was made (nil if there was none), counts, so redeploying
+// the current version is not mistaken for an acknowledgement. It returns the last observed rollout
+// and whether the version was acknowledged in time.
+func (c *Client) WaitForVersionAcknowledged(ctx context.Context, namespace, name, version string, previous *rolloutv1alpha1.DeploymentHistoryEntry, timeout time.Duration) (*rolloutv1alpha1.Rollout, bool, error) {
+	var latest *rolloutv1alpha1.Rollout
+	err := wait.PollUntilContextTimeout(ctx, time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		rollout, err := c.GetRollout(ctx, namespace, name)
+		if err != nil {
+			return false, err
+		}
+		latest = rollout
+		if len(rollout.Status.History) == 0 {
+			return false, nil
+		}
+		current := rollout.Status.History[0]
+		return current.Version.Tag == version && deployedAfter(current, previous), nil
+	})
+	if err != nil {
+		if wait.Interrupted(err) {
+			return latest, false, nil
+		}
+		return latest, false, err
+	}
+	return latest, true, nil
+}
+
+// deployedAfter reports whether entry is a later deployment than previous, by history ID when both
+// have one and by timestamp otherwise
+func deployedAfter(entry rolloutv1alpha1.DeploymentHistoryEntry, previous *rolloutv1alpha1.DeploymentHistoryEntry) bool {
+	if previous == nil {
+		return true
+	}
+	if entry.ID != nil && previous.ID != nil {
+		return *entry.ID > *previous.ID
+	}
+	return entry.Timestamp.After(previous.Timestamp.Time)
+}
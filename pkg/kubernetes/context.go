@@ -2,11 +2,13 @@ package kubernetes
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
 	"sync"
 
 	"github.com/gin-gonic/gin"
 	"github.com/kuberik/rollout-dashboard/pkg/auth"
+	"github.com/kuberik/rollout-dashboard/pkg/share"
 )
 
 var (
@@ -14,29 +16,74 @@ var (
 	defaultClient *Client
 	defaultOnce   sync.Once
 	defaultErr    error
+
+	// staticClient replaces every client when set, see UseStaticClient
+	staticClient *Client
+
+	// impersonate enables impersonation mode, see UseImpersonation
+	impersonate bool
 )
 
+// UseImpersonation switches to impersonation mode: instead of passing the user's token to the API
+// server, requests are sent with the service account credentials and impersonate the user and
+// groups of the token as verified by auth.VerifyTokenMiddleware. For clusters whose API server does
+// not accept OIDC tokens; the service account needs the impersonate verb on users and groups.
+func UseImpersonation(enabled bool) {
+	impersonate = enabled
+}
+
+// UseStaticClient makes GetClientFromContext and GetDefaultClient return c regardless of the
+// request's token, e.g. to serve the API from a fake cluster in the self test
+func UseStaticClient(c *Client) {
+	staticClient = c
+}
+
 // GetClientFromContext gets a Kubernetes client from the Gin context
-// If an OIDC token is present in the context, it uses that token for authentication
-// Otherwise, it falls back to the default client (service account credentials)
+// If an OIDC token is present in the context, it uses that token for authentication, or in
+// impersonation mode the verified identity it carries; unverified tokens are then rejected with an
+// error wrapping auth.ErrInvalidToken.
+// Otherwise, it falls back to the default client (service account credentials), as it also does
+// for requests authorized by a share link, see share.Signer.Middleware.
 func GetClientFromContext(c *gin.Context) (*Client, error) {
+	if staticClient != nil {
+		return staticClient, nil
+	}
+
+	// Share links grant read access to one rollout to users without RBAC of their own
+	if claims := share.FromContext(c); claims != nil {
+		slog.Debug("Using default service account client for share link", "path", c.Request.URL.Path, "sharedBy", claims.CreatedBy)
+		return GetDefaultClient()
+	}
+
 	// Try to get token from context
 	token := auth.GetTokenFromContext(c)
 
+	if token != "" && impersonate {
+		identity := auth.GetIdentityFromContext(c)
+		if identity == nil {
+			return nil, fmt.Errorf("%w: token was not verified", auth.ErrInvalidToken)
+		}
+		slog.Debug("Creating Kubernetes client impersonating user", "path", c.Request.URL.Path, "user", identity.Username)
+		return NewImpersonatingClient(*identity)
+	}
+
 	// If token is present, create a new client with that token
 	if token != "" {
-		log.Printf("[K8s Client Debug] Creating client with OIDC token (token length: %d, path: %s)", len(token), c.Request.URL.Path)
+		slog.Debug("Creating Kubernetes client with OIDC token", "path", c.Request.URL.Path)
 		return NewClientWithToken(token)
 	}
 
 	// Otherwise, use the default client
-	log.Printf("[K8s Client Debug] No OIDC token found, using default service account client (path: %s)", c.Request.URL.Path)
+	slog.Debug("No OIDC token found, using default service account client", "path", c.Request.URL.Path)
 	return GetDefaultClient()
 }
 
 // GetDefaultClient returns the default Kubernetes client (using service account credentials)
 // This is lazily initialized on first use
 func GetDefaultClient() (*Client, error) {
+	if staticClient != nil {
+		return staticClient, nil
+	}
 	defaultOnce.Do(func() {
 		defaultClient, defaultErr = NewClient()
 	})
@@ -0,0 +1,83 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// maxDescribeEvents caps the number of events returned when describing a resource
+const maxDescribeEvents = 50
+
+// ResourceDescription is what kubectl describe shows about a resource: its kstatus status, the
+// conditions it reports and the events reported for it
+type ResourceDescription struct {
+	Object     *unstructured.Unstructured
+	Status     string
+	Message    string
+	Conditions []metav1.Condition
+	// Events are the retained events of the resource, newest first
+	Events []corev1.Event
+}
+
+// DescribeResource reads a resource with its status, conditions and events
+func (c *Client) DescribeResource(ctx context.Context, gvk schema.GroupVersionKind, namespace, name string) (*ResourceDescription, error) {
+	if c.clientset == nil {
+		return nil, fmt.Errorf("clientset not initialized")
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	if err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, obj); err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", gvk.Kind, err)
+	}
+
+	description := &ResourceDescription{Object: obj}
+	if result, err := status.Compute(obj); err != nil {
+		description.Status = "Error"
+		description.Message = fmt.Sprintf("Error computing status: %v", err)
+	} else {
+		description.Status = string(result.Status)
+		description.Message = result.Message
+	}
+	description.Conditions = objectConditions(obj)
+
+	selector := fields.Set{"involvedObject.kind": gvk.Kind, "involvedObject.name": name}.AsSelector().String()
+	events, err := c.clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	objects := RolloutObjects{{Kind: gvk.Kind, Namespace: namespace, Name: name}: {}}
+	description.Events = MergeRolloutEvents(objects, events.Items, time.Time{}, maxDescribeEvents)
+	return description, nil
+}
+
+// objectConditions returns the conditions in an object's status. Conditions that are not shaped
+// like metav1.Condition, e.g. those of Deployments, keep the fields they have in common with it.
+func objectConditions(obj *unstructured.Unstructured) []metav1.Condition {
+	raw, found, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil || !found {
+		return nil
+	}
+	conditions := make([]metav1.Condition, 0, len(raw))
+	for _, item := range raw {
+		values, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		var condition metav1.Condition
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(values, &condition); err != nil {
+			continue
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions
+}
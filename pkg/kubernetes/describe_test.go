@@ -0,0 +1,70 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDescribeResource(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	transition := metav1.NewTime(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "web", Generation: 1},
+		Spec:       appsv1.DeploymentSpec{Replicas: ptr.To(int32(2))},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 1,
+			Replicas:           2,
+			UpdatedReplicas:    2,
+			ReadyReplicas:      1,
+			AvailableReplicas:  1,
+			Conditions: []appsv1.DeploymentCondition{{
+				Type: appsv1.DeploymentAvailable, Status: corev1.ConditionFalse, Reason: "MinimumReplicasUnavailable",
+				Message: "Deployment does not have minimum availability.", LastTransitionTime: transition,
+			}},
+		},
+	}
+	event := func(name, kind, objName, reason string, minutesAgo int) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: "ns", Name: name},
+			InvolvedObject: corev1.ObjectReference{Kind: kind, Namespace: "ns", Name: objName},
+			Reason:         reason,
+			LastTimestamp:  metav1.NewTime(time.Now().Add(-time.Duration(minutesAgo) * time.Minute)),
+		}
+	}
+	c := &Client{
+		client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(deployment).Build(),
+		clientset: k8sfake.NewSimpleClientset(
+			event("scaled", "Deployment", "web", "ScalingReplicaSet", 10),
+			event("unhealthy", "Deployment", "web", "Unhealthy", 1),
+			event("other", "Deployment", "api", "ScalingReplicaSet", 1),
+			event("service", "Service", "web", "Created", 1),
+		),
+	}
+
+	description, err := c.DescribeResource(context.Background(), appsv1.SchemeGroupVersion.WithKind("Deployment"), "ns", "web")
+	require.NoError(t, err)
+	assert.Equal(t, "InProgress", description.Status)
+	require.Len(t, description.Conditions, 1)
+	condition := description.Conditions[0]
+	assert.Equal(t, "Available", condition.Type)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, "MinimumReplicasUnavailable", condition.Reason)
+	assert.Equal(t, "Deployment does not have minimum availability.", condition.Message)
+	assert.True(t, condition.LastTransitionTime.Equal(&transition))
+	require.Len(t, description.Events, 2)
+	assert.Equal(t, "Unhealthy", description.Events[0].Reason)
+	assert.Equal(t, "ScalingReplicaSet", description.Events[1].Reason)
+}
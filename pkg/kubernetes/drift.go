@@ -0,0 +1,635 @@
+package kubernetes
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	fluxmeta "github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// fluxFieldManager is the field manager kustomize-controller applies with. Dry runs use it so
+	// the result only differs from the live object where another manager changed Flux's fields.
+	fluxFieldManager = "kustomize-controller"
+	// maxArtifactSize caps both the downloaded and the extracted size of a source artifact
+	maxArtifactSize = 100 << 20
+	// maxFieldDiffs caps the fields reported per drifted resource
+	maxFieldDiffs = 50
+	// substituteAnnotation disables post-build substitution for a single resource when "disabled"
+	substituteAnnotation = "kustomize.toolkit.fluxcd.io/substitute"
+	// redactedValue replaces Secret values in diffs
+	redactedValue = "(redacted)"
+)
+
+// kustomizationFiles are the file names kustomize recognizes as a kustomization
+var kustomizationFiles = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// FieldDiff is a field whose live value differs from the one Flux would apply. Live or Desired
+// is nil when the field is missing on that side.
+type FieldDiff struct {
+	Path    string `json:"path"`
+	Live    any    `json:"live,omitempty"`
+	Desired any    `json:"desired,omitempty"`
+}
+
+// ManagedResourcesOption configures GetKustomizationManagedResources
+type ManagedResourcesOption func(*managedResourcesOptions)
+
+type managedResourcesOptions struct {
+	detectDrift  bool
+	resourceTree bool
+}
+
+// WithDriftDetection compares every managed resource with the manifests built from the
+// Kustomization's source artifact, flagging the ones edited in-cluster. See DetectDrift.
+func WithDriftDetection() ManagedResourcesOption {
+	return func(o *managedResourcesOptions) {
+		o.detectDrift = true
+	}
+}
+
+// DetectDrift builds the Kustomization's desired manifests and server-side dry-run applies each
+// of them as kustomize-controller would. Resources whose dry-run result differs from the live
+// object have drifted and get a field-level diff. Resources that cannot be compared get a
+// DriftError instead; when the manifests cannot be built at all every resource gets it.
+func (c *Client) DetectDrift(ctx context.Context, kustomization *kustomizev1.Kustomization, resources []ManagedResourceStatus) {
+	desired, err := c.BuildKustomization(ctx, kustomization)
+	if err != nil {
+		for i := range resources {
+			resources[i].DriftError = err.Error()
+		}
+		return
+	}
+
+	for i := range resources {
+		resource := &resources[i]
+		group, kind := splitGroupKind(resource.GroupVersionKind)
+		obj := findObject(desired, group, kind, resource.Namespace, resource.Name)
+		if obj == nil {
+			resource.DriftError = "not found in the source artifact, it will be pruned or orphaned"
+			continue
+		}
+		if resource.Object == nil {
+			// Deleted in-cluster, kustomize-controller recreates it on the next reconcile
+			resource.Drifted = true
+			continue
+		}
+		if kind == "Secret" && group == "" && kustomization.Spec.Decryption != nil {
+			resource.DriftError = "encrypted in the source artifact"
+			continue
+		}
+
+		applied := obj.DeepCopy()
+		if applied.GetNamespace() == "" && resource.Namespace != "" {
+			applied.SetNamespace(resource.Namespace)
+		}
+		err := c.client.Patch(ctx, applied, client.Apply, client.DryRunAll, client.FieldOwner(fluxFieldManager), client.ForceOwnership)
+		if err != nil {
+			resource.DriftError = fmt.Sprintf("dry-run apply failed: %v", err)
+			continue
+		}
+		diff := DiffObjects(resource.Object, applied)
+		if kind == "Secret" && group == "" {
+			redactSecretDiff(diff)
+		}
+		resource.Drifted = len(diff) > 0
+		resource.Diff = diff
+	}
+}
+
+// BuildKustomization builds the manifests kustomize-controller applies for the Kustomization from
+// its source's current artifact, including the Kustomization's overlays, Flux's ownership labels
+// and post-build substitutions
+func (c *Client) BuildKustomization(ctx context.Context, kustomization *kustomizev1.Kustomization) ([]*unstructured.Unstructured, error) {
+	dir, err := os.MkdirTemp("", "kustomization-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := c.fetchSourceArtifact(ctx, kustomization, dir); err != nil {
+		return nil, err
+	}
+	vars, err := c.substitutionVars(ctx, kustomization)
+	if err != nil {
+		return nil, err
+	}
+	return BuildManifests(dir, kustomization, vars)
+}
+
+// fetchSourceArtifact downloads the artifact of the Kustomization's source and extracts it to dir
+func (c *Client) fetchSourceArtifact(ctx context.Context, kustomization *kustomizev1.Kustomization, dir string) error {
+	ref := kustomization.Spec.SourceRef
+	key := client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}
+	if key.Namespace == "" {
+		key.Namespace = kustomization.Namespace
+	}
+
+	var source interface {
+		client.Object
+		GetArtifact() *fluxmeta.Artifact
+	}
+	switch ref.Kind {
+	case sourcev1.GitRepositoryKind:
+		source = &sourcev1.GitRepository{}
+	case sourcev1.OCIRepositoryKind:
+		source = &sourcev1.OCIRepository{}
+	case sourcev1.BucketKind:
+		source = &sourcev1.Bucket{}
+	default:
+		return fmt.Errorf("unsupported source kind %q", ref.Kind)
+	}
+	if err := c.client.Get(ctx, key, source); err != nil {
+		return fmt.Errorf("failed to get source %s %s: %w", ref.Kind, key, err)
+	}
+	artifact := source.GetArtifact()
+	if artifact == nil {
+		return fmt.Errorf("source %s %s has no artifact", ref.Kind, key)
+	}
+
+	data, err := downloadArtifact(ctx, artifact)
+	if err != nil {
+		return fmt.Errorf("failed to download artifact of %s %s: %w", ref.Kind, key, err)
+	}
+	if err := extractTarGz(data, dir); err != nil {
+		return fmt.Errorf("failed to extract artifact of %s %s: %w", ref.Kind, key, err)
+	}
+	return nil
+}
+
+// downloadArtifact fetches the artifact from source-controller and verifies its sha256 digest
+func downloadArtifact(ctx context.Context, artifact *fluxmeta.Artifact) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, artifact.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxArtifactSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxArtifactSize {
+		return nil, fmt.Errorf("artifact exceeds %d bytes", maxArtifactSize)
+	}
+	if expected, ok := strings.CutPrefix(artifact.Digest, "sha256:"); ok {
+		sum := sha256.Sum256(data)
+		if actual := hex.EncodeToString(sum[:]); actual != expected {
+			return nil, fmt.Errorf("digest mismatch: expected sha256:%s, got sha256:%s", expected, actual)
+		}
+	}
+	return data, nil
+}
+
+// extractTarGz extracts the regular files and directories of a tar.gz archive into dir. Entries
+// escaping dir and links are skipped.
+func extractTarGz(data []byte, dir string) error {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	var extracted int64
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target, err := securePath(dir, header.Name)
+		if err != nil {
+			continue
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			extracted += header.Size
+			if extracted > maxArtifactSize {
+				return fmt.Errorf("extracted artifact exceeds %d bytes", maxArtifactSize)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, io.LimitReader(tr, header.Size))
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// securePath joins name to root, failing if the result escapes root
+func securePath(root, name string) (string, error) {
+	target := filepath.Join(root, filepath.FromSlash(name))
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q escapes the artifact", name)
+	}
+	return target, nil
+}
+
+// substitutionVars collects the Kustomization's post-build variables, nil when it has none.
+// Inline variables take precedence over the ones from ConfigMaps and Secrets.
+func (c *Client) substitutionVars(ctx context.Context, kustomization *kustomizev1.Kustomization) (map[string]string, error) {
+	postBuild := kustomization.Spec.PostBuild
+	if postBuild == nil {
+		return nil, nil
+	}
+	vars := map[string]string{}
+	for _, ref := range postBuild.SubstituteFrom {
+		key := client.ObjectKey{Namespace: kustomization.Namespace, Name: ref.Name}
+		switch ref.Kind {
+		case "ConfigMap":
+			configMap := &corev1.ConfigMap{}
+			if err := c.client.Get(ctx, key, configMap); err != nil {
+				if apierrors.IsNotFound(err) && ref.Optional {
+					continue
+				}
+				return nil, fmt.Errorf("failed to get substitution ConfigMap %s: %w", key, err)
+			}
+			maps.Copy(vars, configMap.Data)
+		case "Secret":
+			secret := &corev1.Secret{}
+			if err := c.client.Get(ctx, key, secret); err != nil {
+				if apierrors.IsNotFound(err) && ref.Optional {
+					continue
+				}
+				return nil, fmt.Errorf("failed to get substitution Secret %s: %w", key, err)
+			}
+			for k, v := range secret.Data {
+				vars[k] = string(v)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported substitution source kind %q", ref.Kind)
+		}
+	}
+	maps.Copy(vars, postBuild.Substitute)
+	return vars, nil
+}
+
+// BuildManifests runs kustomize on the Kustomization's path inside the extracted artifact at root.
+// Like kustomize-controller it generates a kustomization for plain manifest directories, applies
+// the Kustomization's overlays and ownership labels, and substitutes vars unless vars is nil.
+func BuildManifests(root string, kustomization *kustomizev1.Kustomization, vars map[string]string) ([]*unstructured.Unstructured, error) {
+	path, err := securePath(root, kustomization.Spec.Path)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("path %q not found in the artifact", kustomization.Spec.Path)
+	}
+	if !hasKustomization(path) {
+		if err := generateKustomization(path); err != nil {
+			return nil, err
+		}
+	}
+
+	overlayDir, err := os.MkdirTemp(root, "overlay-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(overlayDir)
+	overlay, err := overlayKustomization(overlayDir, path, kustomization)
+	if err != nil {
+		return nil, err
+	}
+	data, err := yaml.Marshal(overlay)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(overlayDir, "kustomization.yaml"), data, 0o644); err != nil {
+		return nil, err
+	}
+
+	resMap, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(filesys.MakeFsOnDisk(), overlayDir)
+	if err != nil {
+		return nil, fmt.Errorf("kustomize build failed: %w", err)
+	}
+	objects := make([]*unstructured.Unstructured, 0, resMap.Size())
+	for _, res := range resMap.Resources() {
+		manifest, err := res.AsYAML()
+		if err != nil {
+			return nil, err
+		}
+		if vars != nil && res.GetAnnotations()[substituteAnnotation] != "disabled" {
+			manifest = []byte(substituteVariables(string(manifest), vars))
+		}
+		manifestJSON, err := yaml.YAMLToJSON(manifest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s %s after substitution: %w", res.GetKind(), res.GetName(), err)
+		}
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(manifestJSON); err != nil {
+			return nil, fmt.Errorf("failed to parse %s %s after substitution: %w", res.GetKind(), res.GetName(), err)
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// overlayKustomization is a kustomization in overlayDir applying the Kustomization's namespace,
+// name affixes, patches, images, components and common metadata to the base at path
+func overlayKustomization(overlayDir, path string, kustomization *kustomizev1.Kustomization) (map[string]any, error) {
+	spec := kustomization.Spec
+	base, err := filepath.Rel(overlayDir, path)
+	if err != nil {
+		return nil, err
+	}
+	overlay := map[string]any{
+		"apiVersion": "kustomize.config.k8s.io/v1beta1",
+		"kind":       "Kustomization",
+		"resources":  []string{filepath.ToSlash(base)},
+	}
+	if spec.TargetNamespace != "" {
+		overlay["namespace"] = spec.TargetNamespace
+	}
+	if spec.NamePrefix != "" {
+		overlay["namePrefix"] = spec.NamePrefix
+	}
+	if spec.NameSuffix != "" {
+		overlay["nameSuffix"] = spec.NameSuffix
+	}
+	if len(spec.Patches) > 0 {
+		overlay["patches"] = spec.Patches
+	}
+	if len(spec.Images) > 0 {
+		overlay["images"] = spec.Images
+	}
+
+	var components []string
+	for _, component := range spec.Components {
+		componentPath, err := securePath(path, component)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(componentPath); err != nil && spec.IgnoreMissingComponents {
+			continue
+		}
+		rel, err := filepath.Rel(overlayDir, componentPath)
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, filepath.ToSlash(rel))
+	}
+	if len(components) > 0 {
+		overlay["components"] = components
+	}
+
+	// kustomize-controller labels everything it applies with its owner
+	labels := map[string]string{
+		"kustomize.toolkit.fluxcd.io/name":      kustomization.Name,
+		"kustomize.toolkit.fluxcd.io/namespace": kustomization.Namespace,
+	}
+	if spec.CommonMetadata != nil {
+		maps.Copy(labels, spec.CommonMetadata.Labels)
+		if len(spec.CommonMetadata.Annotations) > 0 {
+			overlay["commonAnnotations"] = spec.CommonMetadata.Annotations
+		}
+	}
+	overlay["labels"] = []map[string]any{{"pairs": labels}}
+	return overlay, nil
+}
+
+func hasKustomization(dir string) bool {
+	for _, name := range kustomizationFiles {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// generateKustomization writes a kustomization listing the manifests under dir, including
+// subdirectories with their own kustomization as a whole, like kustomize-controller does for
+// paths without one
+func generateKustomization(dir string) error {
+	var resources []string
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && hasKustomization(path) {
+				resources = append(resources, filepath.ToSlash(rel))
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := filepath.Ext(path); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		// Skip YAML that is not a Kubernetes manifest, e.g. Helm values or CI config
+		if bytes.Contains(data, []byte("apiVersion:")) && bytes.Contains(data, []byte("kind:")) {
+			resources = append(resources, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(map[string]any{
+		"apiVersion": "kustomize.config.k8s.io/v1beta1",
+		"kind":       "Kustomization",
+		"resources":  resources,
+	})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "kustomization.yaml"), data, 0o644)
+}
+
+// variablePattern matches ${var}, ${var:=default}, ${var:-default} and the escaped $${var}
+var variablePattern = regexp.MustCompile(`\$(\$)?\{([_a-zA-Z][_a-zA-Z0-9]*)(?:(:?[=-])([^}]*))?\}`)
+
+// substituteVariables replaces the variables in text like Flux's post-build substitution.
+// Undefined variables without a default become empty.
+func substituteVariables(text string, vars map[string]string) string {
+	return variablePattern.ReplaceAllStringFunc(text, func(match string) string {
+		groups := variablePattern.FindStringSubmatch(match)
+		if groups[1] != "" {
+			return match[1:]
+		}
+		value, ok := vars[groups[2]]
+		switch groups[3] {
+		case "-", "=":
+			if !ok {
+				return groups[4]
+			}
+		case ":-", ":=":
+			if value == "" {
+				return groups[4]
+			}
+		}
+		return value
+	})
+}
+
+// DiffObjects lists the fields that differ between the live object and the desired one, ignoring
+// status and the metadata the API server maintains. Paths look like
+// spec.template.spec.containers[0].image and map keys containing dots are bracketed, e.g.
+// metadata.labels[app.kubernetes.io/name]. At most maxFieldDiffs fields are returned.
+func DiffObjects(live, desired *unstructured.Unstructured) []FieldDiff {
+	var diffs []FieldDiff
+	diffValues("", withoutServerFields(live.Object), withoutServerFields(desired.Object), &diffs)
+	if len(diffs) > maxFieldDiffs {
+		diffs = diffs[:maxFieldDiffs]
+	}
+	return diffs
+}
+
+// withoutServerFields returns a shallow copy of obj without the fields that always differ
+func withoutServerFields(obj map[string]any) map[string]any {
+	out := maps.Clone(obj)
+	delete(out, "status")
+	if metadata, ok := out["metadata"].(map[string]any); ok {
+		metadata = maps.Clone(metadata)
+		for _, field := range []string{"managedFields", "resourceVersion", "generation", "uid", "creationTimestamp", "selfLink"} {
+			delete(metadata, field)
+		}
+		out["metadata"] = metadata
+	}
+	return out
+}
+
+func diffValues(path string, live, desired any, diffs *[]FieldDiff) {
+	liveMap, liveIsMap := live.(map[string]any)
+	desiredMap, desiredIsMap := desired.(map[string]any)
+	if liveIsMap && desiredIsMap {
+		keys := slices.Collect(maps.Keys(liveMap))
+		for key := range desiredMap {
+			if _, ok := liveMap[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			diffValues(fieldPath(path, key), liveMap[key], desiredMap[key], diffs)
+		}
+		return
+	}
+
+	liveList, liveIsList := live.([]any)
+	desiredList, desiredIsList := desired.([]any)
+	if liveIsList && desiredIsList {
+		for i := range max(len(liveList), len(desiredList)) {
+			var liveItem, desiredItem any
+			if i < len(liveList) {
+				liveItem = liveList[i]
+			}
+			if i < len(desiredList) {
+				desiredItem = desiredList[i]
+			}
+			diffValues(path+"["+strconv.Itoa(i)+"]", liveItem, desiredItem, diffs)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(live, desired) {
+		*diffs = append(*diffs, FieldDiff{Path: path, Live: live, Desired: desired})
+	}
+}
+
+func fieldPath(path, key string) string {
+	if strings.ContainsAny(key, "./[]") {
+		return path + "[" + key + "]"
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// redactSecretDiff hides Secret values while keeping which keys drifted
+func redactSecretDiff(diffs []FieldDiff) {
+	for i := range diffs {
+		if !strings.HasPrefix(diffs[i].Path, "data") && !strings.HasPrefix(diffs[i].Path, "stringData") {
+			continue
+		}
+		if diffs[i].Live != nil {
+			diffs[i].Live = redactedValue
+		}
+		if diffs[i].Desired != nil {
+			diffs[i].Desired = redactedValue
+		}
+	}
+}
+
+// splitGroupKind parses the group and kind of a ManagedResourceStatus GroupVersionKind
+func splitGroupKind(gvk string) (string, string) {
+	parts := strings.SplitN(gvk, "/", 3)
+	if len(parts) != 3 {
+		return "", gvk
+	}
+	return parts[0], parts[2]
+}
+
+// findObject returns the object with the given identity. Objects without a namespace match any
+// namespace, as kustomize leaves it unset when the Kustomization sets no target namespace.
+func findObject(objects []*unstructured.Unstructured, group, kind, namespace, name string) *unstructured.Unstructured {
+	for _, obj := range objects {
+		gvk := obj.GroupVersionKind()
+		if gvk.Group != group || gvk.Kind != kind || obj.GetName() != name {
+			continue
+		}
+		if obj.GetNamespace() == "" || obj.GetNamespace() == namespace {
+			return obj
+		}
+	}
+	return nil
+}
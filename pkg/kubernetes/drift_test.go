@@ -0,0 +1,147 @@
+package kubernetes
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	"github.com/fluxcd/pkg/apis/kustomize"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestBuildManifests(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "apps", "shop")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "deployment.yaml"), []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: shop
+spec:
+  replicas: ${REPLICAS:=1}
+  template:
+    spec:
+      containers:
+        - name: shop
+          image: registry.example.com/shop:v1
+          env:
+            - name: REGION
+              value: ${REGION}
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "values.yaml"), []byte("replicas: 3\n"), 0o644))
+
+	kustomization := &kustomizev1.Kustomization{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "flux-system", Name: "shop"},
+		Spec: kustomizev1.KustomizationSpec{
+			Path:            "./apps/shop",
+			TargetNamespace: "shop",
+			Images:          []kustomize.Image{{Name: "registry.example.com/shop", NewTag: "v2"}},
+			Patches: []kustomize.Patch{{
+				Patch:  `[{"op": "add", "path": "/metadata/annotations", "value": {"team": "checkout"}}]`,
+				Target: &kustomize.Selector{Kind: "Deployment"},
+			}},
+			PostBuild: &kustomizev1.PostBuild{Substitute: map[string]string{"REGION": "eu"}},
+		},
+	}
+	objects, err := BuildManifests(root, kustomization, map[string]string{"REGION": "eu"})
+	require.NoError(t, err)
+	require.Len(t, objects, 1, "values.yaml is not a manifest")
+
+	deployment := objects[0]
+	assert.Equal(t, "shop", deployment.GetNamespace())
+	assert.Equal(t, "checkout", deployment.GetAnnotations()["team"])
+	assert.Equal(t, "shop", deployment.GetLabels()["kustomize.toolkit.fluxcd.io/name"])
+	assert.Equal(t, "flux-system", deployment.GetLabels()["kustomize.toolkit.fluxcd.io/namespace"])
+	replicas, _, _ := unstructured.NestedInt64(deployment.Object, "spec", "replicas")
+	assert.Equal(t, int64(1), replicas)
+	containers, _, _ := unstructured.NestedSlice(deployment.Object, "spec", "template", "spec", "containers")
+	container := containers[0].(map[string]any)
+	assert.Equal(t, "registry.example.com/shop:v2", container["image"])
+	assert.Equal(t, "eu", container["env"].([]any)[0].(map[string]any)["value"])
+
+	kustomization.Spec.Path = "../outside"
+	_, err = BuildManifests(root, kustomization, nil)
+	assert.Error(t, err)
+}
+
+func TestSubstituteVariables(t *testing.T) {
+	vars := map[string]string{"ENV": "prod", "EMPTY": ""}
+	assert.Equal(t, "prod", substituteVariables("${ENV}", vars))
+	assert.Equal(t, "", substituteVariables("${MISSING}", vars))
+	assert.Equal(t, "fallback", substituteVariables("${MISSING:=fallback}", vars))
+	assert.Equal(t, "", substituteVariables("${EMPTY-fallback}", vars))
+	assert.Equal(t, "fallback", substituteVariables("${EMPTY:-fallback}", vars))
+	assert.Equal(t, "${ENV} $ENV", substituteVariables("$${ENV} $ENV", vars))
+}
+
+func TestDiffObjects(t *testing.T) {
+	live := &unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{
+			"name":            "shop",
+			"resourceVersion": "42",
+			"labels":          map[string]any{"app.kubernetes.io/name": "shop", "edited": "by-hand"},
+		},
+		"spec": map[string]any{
+			"replicas": int64(5),
+			"containers": []any{
+				map[string]any{"name": "shop", "image": "shop:v2"},
+				map[string]any{"name": "debug", "image": "busybox"},
+			},
+		},
+		"status": map[string]any{"readyReplicas": int64(5)},
+	}}
+	desired := &unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{
+			"name":            "shop",
+			"resourceVersion": "43",
+			"labels":          map[string]any{"app.kubernetes.io/name": "shop"},
+		},
+		"spec": map[string]any{
+			"replicas": int64(3),
+			"containers": []any{
+				map[string]any{"name": "shop", "image": "shop:v2"},
+			},
+		},
+	}}
+
+	assert.Equal(t, []FieldDiff{
+		{Path: "metadata.labels.edited", Live: "by-hand"},
+		{Path: "spec.containers[1]", Live: map[string]any{"name": "debug", "image": "busybox"}},
+		{Path: "spec.replicas", Live: int64(5), Desired: int64(3)},
+	}, DiffObjects(live, desired))
+	assert.Empty(t, DiffObjects(live, live.DeepCopy()))
+
+	diff := []FieldDiff{{Path: "data[password]", Live: "aHVudGVyMg==", Desired: "c2VjcmV0"}, {Path: "type", Live: "Opaque"}}
+	redactSecretDiff(diff)
+	assert.Equal(t, redactedValue, diff[0].Live)
+	assert.Equal(t, redactedValue, diff[0].Desired)
+	assert.Equal(t, "Opaque", diff[1].Live)
+}
+
+func TestExtractTarGz(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string]string{"apps/shop.yaml": "kind: Deployment", "../escape.yaml": "kind: Secret"} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "artifact")
+	require.NoError(t, extractTarGz(buf.Bytes(), dir))
+	data, err := os.ReadFile(filepath.Join(dir, "apps", "shop.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "kind: Deployment", string(data))
+	assert.NoFileExists(t, filepath.Join(root, "escape.yaml"))
+}
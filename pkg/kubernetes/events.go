@@ -0,0 +1,151 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// rolloutEventsWindow is how far back events are returned for a rollout
+	rolloutEventsWindow = 2 * time.Hour
+	// maxRolloutEvents caps the number of events returned for a rollout
+	maxRolloutEvents = 50
+)
+
+// GetEventsForRollout collects the recent events of everything taking part in a rollout:
+//  1. the Rollout and its Flux objects (Kustomizations, OCIRepositories and other sources, image automation)
+//  2. Deployments managed by the rollout's Kustomizations and the ReplicaSets they own
+//  3. Kruise Rollouts managed by those Kustomizations, their RolloutTests and the test Jobs
+//
+// Events are merged, deduplicated and sorted newest first.
+func (c *Client) GetEventsForRollout(ctx context.Context, namespace, rolloutName string) ([]corev1.Event, error) {
+	if c.clientset == nil {
+		return nil, fmt.Errorf("clientset not initialized")
+	}
+
+	objects, err := c.GetRolloutObjects(ctx, namespace, rolloutName)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.addWorkloadObjects(ctx, namespace, rolloutName, objects); err != nil {
+		return nil, err
+	}
+
+	// Events can only be matched to objects in namespaces we list, one request per namespace
+	// instead of one per object
+	var events []corev1.Event
+	for _, ns := range objects.Namespaces() {
+		eventList, err := c.clientset.CoreV1().Events(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			slog.Warn("Failed to list events", "namespace", ns, "error", err)
+			continue
+		}
+		events = append(events, eventList.Items...)
+	}
+
+	return MergeRolloutEvents(objects, events, time.Now().Add(-rolloutEventsWindow), maxRolloutEvents), nil
+}
+
+// addWorkloadObjects adds the Deployments, ReplicaSets, Kruise Rollouts, RolloutTests and test
+// Jobs of a rollout to objects. Failures to inspect a single Kustomization are logged and skipped.
+func (c *Client) addWorkloadObjects(ctx context.Context, namespace, rolloutName string, objects RolloutObjects) error {
+	kustomizations, err := c.GetKustomizationsByRolloutAnnotation(ctx, namespace, rolloutName)
+	if err != nil {
+		return fmt.Errorf("failed to get kustomizations: %w", err)
+	}
+
+	// Tests reference the Kruise Rollout by name, which usually matches the rollout's name
+	testedRollouts := map[string][]string{namespace: {rolloutName}}
+	deploymentUIDs := map[string][]string{}
+	for _, kustomization := range kustomizations.Items {
+		resources, err := c.GetKustomizationManagedResources(ctx, kustomization.Namespace, kustomization.Name)
+		if err != nil {
+			slog.Warn("Failed to get managed resources for kustomization", "kustomization", kustomization.Name, "error", err)
+			continue
+		}
+		for _, resource := range resources {
+			switch {
+			case strings.Contains(resource.GroupVersionKind, "apps/v1/Deployment"):
+				objects[ObjectRef{Kind: "Deployment", Namespace: resource.Namespace, Name: resource.Name}] = struct{}{}
+				if resource.Object != nil {
+					deploymentUIDs[resource.Namespace] = append(deploymentUIDs[resource.Namespace], string(resource.Object.GetUID()))
+				}
+			case strings.HasPrefix(resource.GroupVersionKind, "rollouts.kruise.io/") && strings.HasSuffix(resource.GroupVersionKind, "/Rollout"):
+				objects[ObjectRef{Kind: "Rollout", Namespace: resource.Namespace, Name: resource.Name}] = struct{}{}
+				testedRollouts[resource.Namespace] = append(testedRollouts[resource.Namespace], resource.Name)
+			}
+		}
+	}
+
+	for ns, uids := range deploymentUIDs {
+		replicaSets, err := c.GetReplicaSets(ctx, ns)
+		if err != nil {
+			slog.Warn("Failed to list replicasets", "namespace", ns, "error", err)
+			continue
+		}
+		for _, rs := range replicaSets.Items {
+			for _, ownerRef := range rs.OwnerReferences {
+				if slices.Contains(uids, string(ownerRef.UID)) {
+					objects[ObjectRef{Kind: "ReplicaSet", Namespace: rs.Namespace, Name: rs.Name}] = struct{}{}
+					break
+				}
+			}
+		}
+	}
+
+	for ns, rolloutNames := range testedRollouts {
+		rolloutTests, err := c.GetRolloutTests(ctx, ns)
+		if err != nil {
+			slog.Warn("Failed to list rollout tests", "namespace", ns, "error", err)
+			continue
+		}
+		for _, test := range rolloutTests.Items {
+			if !slices.Contains(rolloutNames, test.Spec.RolloutName) {
+				continue
+			}
+			objects[ObjectRef{Kind: "RolloutTest", Namespace: test.Namespace, Name: test.Name}] = struct{}{}
+			if test.Status.JobName != "" {
+				objects[ObjectRef{Kind: "Job", Namespace: test.Namespace, Name: test.Status.JobName}] = struct{}{}
+			}
+		}
+	}
+	return nil
+}
+
+// MergeRolloutEvents keeps the events reported for one of objects since cutoff, collapses
+// repeats of the same reason and message for an object into the most recent one, and returns
+// at most limit events, newest first
+func MergeRolloutEvents(objects RolloutObjects, events []corev1.Event, cutoff time.Time, limit int) []corev1.Event {
+	type dedupeKey struct{ message, reason, objNamespace, objName, objKind string }
+	seen := make(map[dedupeKey]int)
+	var merged []corev1.Event
+	for _, ev := range events {
+		if !objects.Matches(&ev) || !eventTime(&ev).After(cutoff) {
+			continue
+		}
+		k := dedupeKey{ev.Message, ev.Reason, ev.InvolvedObject.Namespace, ev.InvolvedObject.Name, ev.InvolvedObject.Kind}
+		if idx, exists := seen[k]; exists {
+			if eventTime(&ev).After(eventTime(&merged[idx])) {
+				merged[idx] = ev
+			}
+			continue
+		}
+		seen[k] = len(merged)
+		merged = append(merged, ev)
+	}
+
+	slices.SortStableFunc(merged, func(a, b corev1.Event) int {
+		return eventTime(&b).Compare(eventTime(&a))
+	})
+	if limit > 0 && len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged
+}
@@ -0,0 +1,86 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	openkruisev1alpha1 "github.com/kuberik/openkruise-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testEvent(kind, name, reason string, at time.Time) corev1.Event {
+	return corev1.Event{
+		InvolvedObject: corev1.ObjectReference{Kind: kind, Namespace: "apps", Name: name},
+		Reason:         reason,
+		Message:        reason + " happened",
+		LastTimestamp:  metav1.NewTime(at),
+	}
+}
+
+func TestMergeRolloutEvents(t *testing.T) {
+	now := time.Now()
+	objects := RolloutObjects{
+		{Kind: "Kustomization", Namespace: "apps", Name: "app"}: {},
+		{Kind: "Job", Namespace: "apps", Name: "app-test"}:      {},
+	}
+	events := []corev1.Event{
+		testEvent("Kustomization", "app", "ReconciliationFailed", now.Add(-10*time.Minute)),
+		testEvent("Kustomization", "app", "ReconciliationFailed", now.Add(-time.Minute)),
+		testEvent("Job", "app-test", "BackoffLimitExceeded", now.Add(-5*time.Minute)),
+		testEvent("Job", "other", "Completed", now),
+		testEvent("Job", "app-test", "SuccessfulCreate", now.Add(-3*time.Hour)),
+	}
+	// Events recorded through events.k8s.io only carry EventTime
+	events = append(events, corev1.Event{
+		InvolvedObject: corev1.ObjectReference{Kind: "Job", Namespace: "apps", Name: "app-test"},
+		Reason:         "Started",
+		EventTime:      metav1.NewMicroTime(now.Add(-2 * time.Minute)),
+	})
+
+	merged := MergeRolloutEvents(objects, events, now.Add(-2*time.Hour), 10)
+	require.Len(t, merged, 3)
+	assert.Equal(t, "ReconciliationFailed", merged[0].Reason)
+	assert.Equal(t, now.Add(-time.Minute).Unix(), merged[0].LastTimestamp.Unix())
+	assert.Equal(t, "Started", merged[1].Reason)
+	assert.Equal(t, "BackoffLimitExceeded", merged[2].Reason)
+
+	assert.Len(t, MergeRolloutEvents(objects, events, now.Add(-2*time.Hour), 1), 1)
+}
+
+func TestAddWorkloadObjects_TestJobs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kustomizev1.AddToScheme(scheme))
+	require.NoError(t, sourcev1.AddToScheme(scheme))
+	require.NoError(t, openkruisev1alpha1.AddToScheme(scheme))
+
+	test := &openkruisev1alpha1.RolloutTest{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "smoke"},
+		Spec:       openkruisev1alpha1.RolloutTestSpec{RolloutName: "app"},
+	}
+	unrelated := &openkruisev1alpha1.RolloutTest{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "other"},
+		Spec:       openkruisev1alpha1.RolloutTestSpec{RolloutName: "other"},
+	}
+	c := &Client{client: fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(test, unrelated).
+		WithStatusSubresource(test).
+		Build()}
+	test.Status.JobName = "smoke-1"
+	require.NoError(t, c.client.Status().Update(context.Background(), test))
+
+	objects := RolloutObjects{}
+	require.NoError(t, c.addWorkloadObjects(context.Background(), "apps", "app", objects))
+	assert.Equal(t, RolloutObjects{
+		{Kind: "RolloutTest", Namespace: "apps", Name: "smoke"}: {},
+		{Kind: "Job", Namespace: "apps", Name: "smoke-1"}:       {},
+	}, objects)
+}
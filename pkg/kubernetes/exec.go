@@ -0,0 +1,88 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// ErrContainerNotFound is returned when an exec session names a container the pod does not have
+var ErrContainerNotFound = errors.New("container not found in pod")
+
+// defaultContainerAnnotation names the container kubectl uses when none is given
+const defaultContainerAnnotation = "kubectl.kubernetes.io/default-container"
+
+// CheckPodExecPermission checks whether the current user may create pods/exec for a pod
+func (c *Client) CheckPodExecPermission(ctx context.Context, namespace, name string) (bool, error) {
+	clientset, err := c.userClientset()
+	if err != nil {
+		return false, err
+	}
+	return reviewAccess(ctx, clientset, authorizationv1.ResourceAttributes{
+		Namespace:   namespace,
+		Verb:        "create",
+		Resource:    "pods",
+		Subresource: "exec",
+		Name:        name,
+	})
+}
+
+// ExecContainer resolves the container an exec session runs in. An empty name selects the
+// container from the kubectl default-container annotation, or else the first container.
+func ExecContainer(pod *corev1.Pod, name string) (string, error) {
+	if name == "" {
+		name = pod.Annotations[defaultContainerAnnotation]
+	}
+	if name == "" && len(pod.Spec.Containers) > 0 {
+		return pod.Spec.Containers[0].Name, nil
+	}
+	for _, container := range pod.Spec.Containers {
+		if container.Name == name {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrContainerNotFound, name)
+}
+
+// ExecInPod runs command in a container of a pod and connects it to streams until the command
+// exits or ctx is cancelled. The WebSocket exec protocol is preferred; API servers that do not
+// support it are spoken to over SPDY. A non-zero exit code is returned as an error with an
+// ExitStatus method.
+func (c *Client) ExecInPod(ctx context.Context, namespace, name, container string, command []string, streams remotecommand.StreamOptions) error {
+	req := c.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(name).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdin:     streams.Stdin != nil,
+			Stdout:    streams.Stdout != nil,
+			Stderr:    streams.Stderr != nil,
+			TTY:       streams.Tty,
+		}, scheme.ParameterCodec)
+
+	websocketExec, err := remotecommand.NewWebSocketExecutor(c.config, "GET", req.URL().String())
+	if err != nil {
+		return fmt.Errorf("failed to create websocket executor: %w", err)
+	}
+	spdyExec, err := remotecommand.NewSPDYExecutor(c.config, "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("failed to create SPDY executor: %w", err)
+	}
+	exec, err := remotecommand.NewFallbackExecutor(websocketExec, spdyExec, func(err error) bool {
+		return httpstream.IsUpgradeFailure(err) || httpstream.IsHTTPSProxyError(err)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create executor: %w", err)
+	}
+
+	return exec.StreamWithContext(ctx, streams)
+}
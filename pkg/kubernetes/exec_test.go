@@ -0,0 +1,29 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestExecContainer(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "istio-proxy"}, {Name: "app"}}}}
+
+	container, err := ExecContainer(pod, "")
+	require.NoError(t, err)
+	require.Equal(t, "istio-proxy", container)
+
+	container, err = ExecContainer(pod, "app")
+	require.NoError(t, err)
+	require.Equal(t, "app", container)
+
+	_, err = ExecContainer(pod, "missing")
+	require.ErrorIs(t, err, ErrContainerNotFound)
+
+	pod.ObjectMeta = metav1.ObjectMeta{Annotations: map[string]string{defaultContainerAnnotation: "app"}}
+	container, err = ExecContainer(pod, "")
+	require.NoError(t, err)
+	require.Equal(t, "app", container)
+}
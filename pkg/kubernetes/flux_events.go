@@ -0,0 +1,276 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	// FluxSystemNamespace is where Flux controllers and cluster-wide sources live
+	FluxSystemNamespace = "flux-system"
+
+	// fluxEventsRefreshInterval controls how often the set of objects associated with a
+	// rollout is recomputed while streaming, so new Kustomizations or sources are picked up
+	fluxEventsRefreshInterval = time.Minute
+	// fluxEventsBacklog is how far back existing Warning events are replayed when a stream starts
+	fluxEventsBacklog = time.Hour
+	// fluxEventsRetryInterval is the delay before re-establishing a failed watch
+	fluxEventsRetryInterval = 5 * time.Second
+)
+
+// ObjectRef identifies an object events can be reported for
+type ObjectRef struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// RolloutObjects is the set of objects associated with a rollout
+type RolloutObjects map[ObjectRef]struct{}
+
+// Namespaces returns the namespaces containing associated objects
+func (o RolloutObjects) Namespaces() []string {
+	seen := map[string]bool{}
+	var namespaces []string
+	for ref := range o {
+		if !seen[ref.Namespace] {
+			seen[ref.Namespace] = true
+			namespaces = append(namespaces, ref.Namespace)
+		}
+	}
+	return namespaces
+}
+
+// Matches reports whether the event was reported for one of the objects
+func (o RolloutObjects) Matches(event *corev1.Event) bool {
+	_, ok := o[ObjectRef{
+		Kind:      event.InvolvedObject.Kind,
+		Namespace: event.InvolvedObject.Namespace,
+		Name:      event.InvolvedObject.Name,
+	}]
+	return ok
+}
+
+// GetRolloutObjects collects the Flux objects (and the Rollout itself) whose events describe the
+// rollout's delivery pipeline: the Rollout, its ImagePolicy and ImageRepository, associated
+// Kustomizations and OCIRepositories, and the sources referenced by those Kustomizations.
+func (c *Client) GetRolloutObjects(ctx context.Context, namespace, rolloutName string) (RolloutObjects, error) {
+	rollout, err := c.GetRollout(ctx, namespace, rolloutName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rollout: %w", err)
+	}
+
+	objects := RolloutObjects{
+		{Kind: "Rollout", Namespace: namespace, Name: rolloutName}: {},
+	}
+
+	if policyName := rollout.Spec.ReleasesImagePolicy.Name; policyName != "" {
+		objects[ObjectRef{Kind: "ImagePolicy", Namespace: namespace, Name: policyName}] = struct{}{}
+		if imagePolicy, err := c.GetImagePolicy(ctx, namespace, policyName); err == nil && imagePolicy.Spec.ImageRepositoryRef.Name != "" {
+			repoNamespace := imagePolicy.Spec.ImageRepositoryRef.Namespace
+			if repoNamespace == "" {
+				repoNamespace = namespace
+			}
+			objects[ObjectRef{Kind: "ImageRepository", Namespace: repoNamespace, Name: imagePolicy.Spec.ImageRepositoryRef.Name}] = struct{}{}
+		}
+	}
+
+	ociRepositories, err := c.GetOCIRepositoriesByRolloutAnnotation(ctx, namespace, rolloutName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OCI repositories: %w", err)
+	}
+	for _, repo := range ociRepositories.Items {
+		objects[ObjectRef{Kind: "OCIRepository", Namespace: repo.Namespace, Name: repo.Name}] = struct{}{}
+	}
+
+	kustomizations, err := c.GetKustomizationsByRolloutAnnotation(ctx, namespace, rolloutName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kustomizations: %w", err)
+	}
+	for _, kustomization := range kustomizations.Items {
+		objects[ObjectRef{Kind: "Kustomization", Namespace: kustomization.Namespace, Name: kustomization.Name}] = struct{}{}
+
+		sourceRef := kustomization.Spec.SourceRef
+		if sourceRef.Name == "" {
+			continue
+		}
+		sourceNamespace := sourceRef.Namespace
+		if sourceNamespace == "" {
+			sourceNamespace = kustomization.Namespace
+		}
+		objects[ObjectRef{Kind: sourceRef.Kind, Namespace: sourceNamespace, Name: sourceRef.Name}] = struct{}{}
+	}
+
+	return objects, nil
+}
+
+// StreamRolloutWarningEvents watches Warning events in flux-system and the namespaces of the
+// rollout's associated objects, calling send for each event reported for one of those objects.
+// Warning events from the last hour are replayed first. It blocks until ctx is cancelled.
+func (c *Client) StreamRolloutWarningEvents(ctx context.Context, namespace, rolloutName string, send func(corev1.Event)) error {
+	if c.clientset == nil {
+		return fmt.Errorf("clientset not initialized")
+	}
+
+	objects, err := c.GetRolloutObjects(ctx, namespace, rolloutName)
+	if err != nil {
+		return err
+	}
+
+	var (
+		mu       sync.RWMutex
+		sendMu   sync.Mutex
+		wg       sync.WaitGroup
+		watching = map[string]bool{}
+	)
+	matches := func(event *corev1.Event) bool {
+		mu.RLock()
+		defer mu.RUnlock()
+		return objects.Matches(event)
+	}
+	deliver := func(event corev1.Event) {
+		sendMu.Lock()
+		defer sendMu.Unlock()
+		send(event)
+	}
+	watchNamespaces := func(namespaces []string) {
+		for _, ns := range namespaces {
+			if watching[ns] {
+				continue
+			}
+			watching[ns] = true
+			wg.Add(1)
+			go func(ns string) {
+				defer wg.Done()
+				c.watchWarningEvents(ctx, ns, matches, deliver)
+			}(ns)
+		}
+	}
+
+	watchNamespaces(append([]string{FluxSystemNamespace}, objects.Namespaces()...))
+
+	ticker := time.NewTicker(fluxEventsRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return nil
+		case <-ticker.C:
+			refreshed, err := c.GetRolloutObjects(ctx, namespace, rolloutName)
+			if err != nil {
+				slog.Warn("Failed to refresh rollout objects for event stream", "namespace", namespace, "rollout", rolloutName, "error", err)
+				continue
+			}
+			mu.Lock()
+			objects = refreshed
+			mu.Unlock()
+			watchNamespaces(refreshed.Namespaces())
+		}
+	}
+}
+
+// watchWarningEvents replays recent Warning events in a namespace and then watches for new ones,
+// re-listing when the watch expires. It returns when ctx is cancelled.
+func (c *Client) watchWarningEvents(ctx context.Context, namespace string, matches func(*corev1.Event) bool, send func(corev1.Event)) {
+	listOpts := metav1.ListOptions{FieldSelector: "type=" + corev1.EventTypeWarning}
+	resourceVersion := ""
+	replay := true
+
+	for ctx.Err() == nil {
+		if resourceVersion == "" {
+			events, err := c.clientset.CoreV1().Events(namespace).List(ctx, listOpts)
+			if err != nil {
+				slog.Debug("Failed to list warning events", "namespace", namespace, "error", err)
+				if apierrors.IsForbidden(err) {
+					// The caller cannot read events here, retrying will not help
+					return
+				}
+				sleepContext(ctx, fluxEventsRetryInterval)
+				continue
+			}
+			if replay {
+				cutoff := time.Now().Add(-fluxEventsBacklog)
+				for i := range events.Items {
+					if eventTime(&events.Items[i]).After(cutoff) && matches(&events.Items[i]) {
+						send(events.Items[i])
+					}
+				}
+				replay = false
+			}
+			resourceVersion = events.ResourceVersion
+		}
+
+		opts := listOpts
+		opts.ResourceVersion = resourceVersion
+		watcher, err := c.clientset.CoreV1().Events(namespace).Watch(ctx, opts)
+		if err != nil {
+			slog.Debug("Failed to watch warning events", "namespace", namespace, "error", err)
+			resourceVersion = ""
+			sleepContext(ctx, fluxEventsRetryInterval)
+			continue
+		}
+		resourceVersion = c.consumeEventWatch(ctx, watcher, resourceVersion, matches, send)
+	}
+}
+
+// consumeEventWatch forwards matching events until the watch closes and returns the resource
+// version to resume from, or empty string if the watch expired and a re-list is needed
+func (c *Client) consumeEventWatch(ctx context.Context, watcher watch.Interface, resourceVersion string, matches func(*corev1.Event) bool, send func(corev1.Event)) string {
+	defer watcher.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return resourceVersion
+		case result, ok := <-watcher.ResultChan():
+			if !ok {
+				return resourceVersion
+			}
+			switch result.Type {
+			case watch.Error:
+				if err := apierrors.FromObject(result.Object); apierrors.IsGone(err) || apierrors.IsResourceExpired(err) {
+					return ""
+				}
+				return resourceVersion
+			case watch.Added, watch.Modified:
+				event, ok := result.Object.(*corev1.Event)
+				if !ok {
+					continue
+				}
+				resourceVersion = event.ResourceVersion
+				if matches(event) {
+					send(*event)
+				}
+			}
+		}
+	}
+}
+
+// eventTime returns the most recent timestamp recorded on an event
+func eventTime(event *corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case event.Series != nil && !event.Series.LastObservedTime.IsZero():
+		return event.Series.LastObservedTime.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
@@ -0,0 +1,75 @@
+package kubernetes
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	imagereflectorv1beta2 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetRolloutObjects(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, rolloutv1alpha1.AddToScheme(scheme))
+	require.NoError(t, imagereflectorv1beta2.AddToScheme(scheme))
+	require.NoError(t, kustomizev1.AddToScheme(scheme))
+	require.NoError(t, sourcev1.AddToScheme(scheme))
+
+	objects := []client.Object{
+		&rolloutv1alpha1.Rollout{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "hello-world"},
+			Spec:       rolloutv1alpha1.RolloutSpec{ReleasesImagePolicy: corev1.LocalObjectReference{Name: "hello-world"}},
+		},
+		&imagereflectorv1beta2.ImagePolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "hello-world"},
+			Spec: imagereflectorv1beta2.ImagePolicySpec{
+				ImageRepositoryRef: meta.NamespacedObjectReference{Name: "hello-world-repo"},
+			},
+		},
+		&kustomizev1.Kustomization{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "apps",
+				Name:        "hello-world",
+				Annotations: map[string]string{"rollout.kuberik.com/substitute.VERSION.from": "hello-world"},
+			},
+			Spec: kustomizev1.KustomizationSpec{
+				SourceRef: kustomizev1.CrossNamespaceSourceReference{Kind: "GitRepository", Name: "fleet", Namespace: "flux-system"},
+			},
+		},
+		&kustomizev1.Kustomization{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "unrelated"},
+		},
+	}
+	c := &Client{client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()}
+
+	got, err := c.GetRolloutObjects(context.Background(), "apps", "hello-world")
+	require.NoError(t, err)
+
+	assert.Equal(t, RolloutObjects{
+		{Kind: "Rollout", Namespace: "apps", Name: "hello-world"}:              {},
+		{Kind: "ImagePolicy", Namespace: "apps", Name: "hello-world"}:          {},
+		{Kind: "ImageRepository", Namespace: "apps", Name: "hello-world-repo"}: {},
+		{Kind: "Kustomization", Namespace: "apps", Name: "hello-world"}:        {},
+		{Kind: "GitRepository", Namespace: "flux-system", Name: "fleet"}:       {},
+	}, got)
+
+	namespaces := got.Namespaces()
+	sort.Strings(namespaces)
+	assert.Equal(t, []string{"apps", "flux-system"}, namespaces)
+
+	event := &corev1.Event{InvolvedObject: corev1.ObjectReference{Kind: "GitRepository", Namespace: "flux-system", Name: "fleet"}}
+	assert.True(t, got.Matches(event))
+	event.InvolvedObject.Name = "other"
+	assert.False(t, got.Matches(event))
+}
@@ -0,0 +1,44 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	imagereflectorv1beta2 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	fluxmeta "github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SuspendableFluxKinds are the Flux objects behind rollouts that can be suspended and resumed on
+// their own, keyed by their API resource. HelmReleases are suspended through their rollout, see
+// SuspendHelmRelease.
+var SuspendableFluxKinds = map[string]schema.GroupVersionKind{
+	"kustomizations":    kustomizev1.GroupVersion.WithKind(kustomizev1.KustomizationKind),
+	"ocirepositories":   sourcev1.GroupVersion.WithKind(sourcev1.OCIRepositoryKind),
+	"imagerepositories": imagereflectorv1beta2.GroupVersion.WithKind(imagereflectorv1beta2.ImageRepositoryKind),
+}
+
+// SuspendFluxObject suspends or resumes the reconciliation of a Flux object by patching
+// spec.suspend, like flux suspend and flux resume. Resuming also requests a reconciliation so
+// changes made while suspended are applied right away.
+func (c *Client) SuspendFluxObject(ctx context.Context, gvk schema.GroupVersionKind, namespace, name string, suspend bool) error {
+	patch := &unstructured.Unstructured{}
+	patch.SetGroupVersionKind(gvk)
+	patch.SetNamespace(namespace)
+	patch.SetName(name)
+	if err := unstructured.SetNestedField(patch.Object, suspend, "spec", "suspend"); err != nil {
+		return err
+	}
+	if !suspend {
+		patch.SetAnnotations(map[string]string{fluxmeta.ReconcileRequestAnnotation: fmt.Sprintf("%d", time.Now().Unix())})
+	}
+	if err := c.client.Patch(ctx, patch, client.Merge, client.FieldOwner("rollout-dashboard")); err != nil {
+		return fmt.Errorf("failed to patch %s: %w", gvk.Kind, err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	imagereflectorv1beta2 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSuspendFluxObject(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, kustomizev1.AddToScheme(scheme))
+	require.NoError(t, imagereflectorv1beta2.AddToScheme(scheme))
+	c := &Client{client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&kustomizev1.Kustomization{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "web"},
+			Spec:       kustomizev1.KustomizationSpec{Path: "./deploy"},
+		},
+		&imagereflectorv1beta2.ImageRepository{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "web"},
+			Spec:       imagereflectorv1beta2.ImageRepositorySpec{Image: "ghcr.io/acme/web"},
+		},
+	).Build()}
+
+	require.NoError(t, c.SuspendFluxObject(ctx, SuspendableFluxKinds["kustomizations"], "apps", "web", true))
+	kustomization := &kustomizev1.Kustomization{}
+	require.NoError(t, c.client.Get(ctx, client.ObjectKey{Namespace: "apps", Name: "web"}, kustomization))
+	assert.True(t, kustomization.Spec.Suspend)
+	assert.Equal(t, "./deploy", kustomization.Spec.Path)
+	assert.Empty(t, kustomization.Annotations[meta.ReconcileRequestAnnotation])
+
+	// Resuming requests a reconciliation
+	require.NoError(t, c.SuspendFluxObject(ctx, SuspendableFluxKinds["kustomizations"], "apps", "web", false))
+	require.NoError(t, c.client.Get(ctx, client.ObjectKey{Namespace: "apps", Name: "web"}, kustomization))
+	assert.False(t, kustomization.Spec.Suspend)
+	assert.NotEmpty(t, kustomization.Annotations[meta.ReconcileRequestAnnotation])
+
+	require.NoError(t, c.SuspendFluxObject(ctx, SuspendableFluxKinds["imagerepositories"], "apps", "web", true))
+	repository := &imagereflectorv1beta2.ImageRepository{}
+	require.NoError(t, c.client.Get(ctx, client.ObjectKey{Namespace: "apps", Name: "web"}, repository))
+	assert.True(t, repository.Spec.Suspend)
+	assert.Equal(t, "ghcr.io/acme/web", repository.Spec.Image)
+
+	err := c.SuspendFluxObject(ctx, SuspendableFluxKinds["kustomizations"], "apps", "missing", true)
+	assert.True(t, apierrors.IsNotFound(err))
+}
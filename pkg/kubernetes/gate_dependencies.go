@@ -0,0 +1,102 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GateDependency is a RolloutGate driven by the deployments of another environment, e.g. the
+// production gate that only passes versions which were deployed to staging
+type GateDependency struct {
+	Gate string
+	// Environment is the related environment name and Relationship how the gate's environment
+	// relates to it (After or Parallel)
+	Environment  string
+	Relationship string
+	// Deployment is the related environment's latest deployment as reported by the gate's
+	// Environment, which also covers environments in other clusters
+	Deployment *rolloutv1alpha1.DeploymentHistoryEntry
+	// Rollouts are the rollouts of the related environment in this cluster
+	Rollouts []types.NamespacedName
+}
+
+// GateDependencies resolves which environment and rollouts drive each gate. Gates are managed by
+// an Environment with a relationship; the upstream rollouts are those of Environments with the
+// same deployment name (spec.name) in the related environment. Gates without such an
+// Environment are skipped.
+func GateDependencies(gates []rolloutv1alpha1.RolloutGate, environments []envv1alpha1.Environment) []GateDependency {
+	var dependencies []GateDependency
+	for _, gate := range gates {
+		owner := gateEnvironment(gate, environments)
+		if owner == nil || owner.Spec.Relationship == nil {
+			continue
+		}
+		relationship := owner.Spec.Relationship
+		dependency := GateDependency{
+			Gate:         gate.Name,
+			Environment:  relationship.Environment,
+			Relationship: string(relationship.Type),
+		}
+		for _, info := range owner.Status.EnvironmentInfos {
+			if info.Environment == relationship.Environment && len(info.History) > 0 {
+				dependency.Deployment = info.History[0].DeepCopy()
+				break
+			}
+		}
+		dependency.Rollouts = EnvironmentRollouts(environments, relationship.Environment, owner.Spec.Name)
+		dependencies = append(dependencies, dependency)
+	}
+	sort.Slice(dependencies, func(i, j int) bool {
+		return dependencies[i].Gate < dependencies[j].Gate
+	})
+	return dependencies
+}
+
+// gateEnvironment returns the Environment managing a gate, either as its owner or by the gate
+// reference in the Environment's status
+func gateEnvironment(gate rolloutv1alpha1.RolloutGate, environments []envv1alpha1.Environment) *envv1alpha1.Environment {
+	for i := range environments {
+		env := &environments[i]
+		if env.Namespace != gate.Namespace {
+			continue
+		}
+		for _, ref := range gate.OwnerReferences {
+			if ref.Kind == "Environment" && ref.UID == env.UID {
+				return env
+			}
+		}
+		if env.Status.RolloutGateRef != nil && env.Status.RolloutGateRef.Name == gate.Name {
+			return env
+		}
+	}
+	return nil
+}
+
+// GetUpstreamRollouts fetches the rollouts gate dependencies refer to. Rollouts that no longer
+// exist are left out.
+func (c *Client) GetUpstreamRollouts(ctx context.Context, dependencies []GateDependency) (map[types.NamespacedName]*rolloutv1alpha1.Rollout, error) {
+	rollouts := map[types.NamespacedName]*rolloutv1alpha1.Rollout{}
+	for _, dependency := range dependencies {
+		for _, ref := range dependency.Rollouts {
+			if _, ok := rollouts[ref]; ok {
+				continue
+			}
+			rollout := &rolloutv1alpha1.Rollout{}
+			if err := c.client.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, rollout); err != nil {
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				return nil, fmt.Errorf("failed to get upstream rollout %s: %w", ref, err)
+			}
+			rollouts[ref] = rollout
+		}
+	}
+	return rollouts, nil
+}
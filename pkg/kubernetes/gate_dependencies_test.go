@@ -0,0 +1,80 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestGateDependencies(t *testing.T) {
+	production := testEnvironment("prod", "shop", "production", "shop")
+	production.UID = "prod-uid"
+	production.Spec.Relationship = &envv1alpha1.EnvironmentRelationship{
+		Environment: "staging",
+		Type:        envv1alpha1.RelationshipTypeAfter,
+	}
+	production.Status.EnvironmentInfos = []envv1alpha1.EnvironmentInfo{{
+		Environment: "staging",
+		History:     []rolloutv1alpha1.DeploymentHistoryEntry{{Version: rolloutv1alpha1.VersionInfo{Tag: "v2"}}},
+	}}
+	// Referenced from the status only
+	canary := testEnvironment("prod", "billing", "production", "billing")
+	canary.Spec.Relationship = &envv1alpha1.EnvironmentRelationship{Environment: "staging", Type: envv1alpha1.RelationshipTypeParallel}
+	canary.Status.RolloutGateRef = &corev1.LocalObjectReference{Name: "billing-gate"}
+
+	environments := []envv1alpha1.Environment{
+		production,
+		canary,
+		testEnvironment("staging", "shop", "staging", "shop"),
+		testEnvironment("staging-eu", "shop", "staging", "shop-eu"),
+		testEnvironment("staging", "billing", "staging", "billing"),
+		testEnvironment("dev", "shop", "dev", "shop"),
+	}
+	gates := []rolloutv1alpha1.RolloutGate{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "shop-gate", OwnerReferences: []metav1.OwnerReference{
+			{Kind: "Environment", Name: production.Name, UID: "prod-uid"},
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "billing-gate"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "manual"}},
+	}
+
+	dependencies := GateDependencies(gates, environments)
+	require.Len(t, dependencies, 2)
+
+	assert.Equal(t, "billing-gate", dependencies[0].Gate)
+	assert.Equal(t, "Parallel", dependencies[0].Relationship)
+	assert.Nil(t, dependencies[0].Deployment)
+	assert.Equal(t, []types.NamespacedName{{Namespace: "staging", Name: "billing"}}, dependencies[0].Rollouts)
+
+	assert.Equal(t, "shop-gate", dependencies[1].Gate)
+	assert.Equal(t, "staging", dependencies[1].Environment)
+	assert.Equal(t, "After", dependencies[1].Relationship)
+	require.NotNil(t, dependencies[1].Deployment)
+	assert.Equal(t, "v2", dependencies[1].Deployment.Version.Tag)
+	assert.Equal(t, []types.NamespacedName{
+		{Namespace: "staging-eu", Name: "shop-eu"},
+		{Namespace: "staging", Name: "shop"},
+	}, dependencies[1].Rollouts)
+}
+
+func TestGetUpstreamRollouts(t *testing.T) {
+	c := newTestClient(t, &rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "staging", Name: "shop"}})
+
+	rollouts, err := c.GetUpstreamRollouts(context.Background(), []GateDependency{{
+		Gate: "shop-gate",
+		Rollouts: []types.NamespacedName{
+			{Namespace: "staging", Name: "shop"},
+			{Namespace: "staging", Name: "deleted"},
+		},
+	}})
+	require.NoError(t, err)
+	require.Len(t, rollouts, 1)
+	assert.Equal(t, "shop", rollouts[types.NamespacedName{Namespace: "staging", Name: "shop"}].Name)
+}
@@ -0,0 +1,208 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"k8s.io/utils/ptr"
+)
+
+// GateExplanation explains why the next version of a rollout is, or is not, deployed yet: what
+// each gate, health check and the bake of the current version contribute
+type GateExplanation struct {
+	CurrentVersion string `json:"currentVersion,omitempty"`
+	// NextVersion is the pinned version or the newest release candidate, empty when the rollout is
+	// up to date
+	NextVersion string `json:"nextVersion,omitempty"`
+	// Blocked reports whether anything holds the next version, Reasons says what, in the order
+	// they have to be resolved
+	Blocked      bool                    `json:"blocked"`
+	Reasons      []string                `json:"reasons"`
+	Gates        []GateEvaluation        `json:"gates"`
+	HealthChecks []HealthCheckEvaluation `json:"healthChecks"`
+	Bake         BakeEvaluation          `json:"bake"`
+}
+
+// GateEvaluation is a RolloutGate evaluated for the next version
+type GateEvaluation struct {
+	Name            string   `json:"name"`
+	Passing         *bool    `json:"passing,omitempty"`
+	AllowedVersions []string `json:"allowedVersions,omitempty"`
+	Message         string   `json:"message,omitempty"`
+	Bypassed        bool     `json:"bypassed,omitempty"`
+	Blocking        bool     `json:"blocking"`
+	Reason          string   `json:"reason,omitempty"`
+}
+
+// HealthCheckEvaluation is a HealthCheck selected by the rollout. Health checks decide whether
+// the current version bakes successfully, so they only block while it is being deployed or baked.
+type HealthCheckEvaluation struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Status    string `json:"status,omitempty"`
+	Message   string `json:"message,omitempty"`
+	Blocking  bool   `json:"blocking"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// BakeEvaluation is the bake of the current version
+type BakeEvaluation struct {
+	Version   string     `json:"version,omitempty"`
+	Status    string     `json:"status,omitempty"`
+	Message   string     `json:"message,omitempty"`
+	StartedAt *time.Time `json:"startedAt,omitempty"`
+	// EndsAt is when a bake in progress succeeds unless a health check fails before
+	EndsAt   *time.Time `json:"endsAt,omitempty"`
+	Blocking bool       `json:"blocking"`
+	Reason   string     `json:"reason,omitempty"`
+}
+
+// ExplainRolloutGates evaluates the gates, health checks and bake of a rollout, see ExplainGates
+func (c *Client) ExplainRolloutGates(ctx context.Context, namespace, name string, now time.Time) (*GateExplanation, error) {
+	rollout, err := c.GetRollout(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	gates, err := c.GetRolloutGatesByRolloutReference(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	healthChecks, err := c.GetHealthChecksBySelector(ctx, namespace, rollout.Spec.HealthCheckSelector)
+	if err != nil {
+		return nil, err
+	}
+	explanation := ExplainGates(rollout, gates.Items, healthChecks, now)
+	return &explanation, nil
+}
+
+// ExplainGates evaluates what holds the next version of a rollout the way the rollout controller
+// decides whether to deploy it: the bake of the current version has to end, a failed bake has to
+// be unblocked, and every gate has to pass and allow the version unless gates are bypassed for it.
+// A force-deployed version is held by nothing.
+func ExplainGates(rollout *rolloutv1alpha1.Rollout, gates []rolloutv1alpha1.RolloutGate, healthChecks []rolloutv1alpha1.HealthCheck, now time.Time) GateExplanation {
+	var next string
+	switch {
+	case rollout.Spec.WantedVersion != nil:
+		next = *rollout.Spec.WantedVersion
+	case len(rollout.Status.ReleaseCandidates) > 0:
+		next = rollout.Status.ReleaseCandidates[0].Tag
+	}
+	return explainVersion(rollout, gates, healthChecks, next, now)
+}
+
+// explainVersion evaluates what holds version of the rollout, see ExplainGates
+func explainVersion(rollout *rolloutv1alpha1.Rollout, gates []rolloutv1alpha1.RolloutGate, healthChecks []rolloutv1alpha1.HealthCheck, version string, now time.Time) GateExplanation {
+	explanation := GateExplanation{NextVersion: version, Reasons: []string{}, Gates: []GateEvaluation{}, HealthChecks: []HealthCheckEvaluation{}}
+	var current *rolloutv1alpha1.DeploymentHistoryEntry
+	if len(rollout.Status.History) > 0 {
+		current = &rollout.Status.History[0]
+		explanation.CurrentVersion = current.Version.Tag
+	}
+	if explanation.NextVersion == explanation.CurrentVersion {
+		explanation.NextVersion = ""
+	}
+	pending := explanation.NextVersion != ""
+	forced := pending && rollout.Annotations[ForceDeployAnnotation] == explanation.NextVersion
+	bypassed := pending && rollout.Annotations[BypassGatesAnnotation] == explanation.NextVersion
+
+	baking := false
+	if current != nil {
+		explanation.Bake, baking = evaluateBake(rollout, current, now)
+		explanation.Bake.Blocking = pending && !forced && explanation.Bake.Blocking
+		if explanation.Bake.Blocking {
+			explanation.Reasons = append(explanation.Reasons, explanation.Bake.Reason)
+		}
+	}
+
+	for _, healthCheck := range healthChecks {
+		evaluation := HealthCheckEvaluation{
+			Namespace: healthCheck.Namespace,
+			Name:      healthCheck.Name,
+			Status:    string(healthCheck.Status.Status),
+			Message:   ptr.Deref(healthCheck.Status.Message, ""),
+		}
+		if baking && healthCheck.Status.Status != rolloutv1alpha1.HealthStatusHealthy {
+			status := evaluation.Status
+			if status == "" {
+				status = "not reporting"
+			}
+			evaluation.Reason = fmt.Sprintf("health check %s/%s is %s while %s bakes", healthCheck.Namespace, healthCheck.Name, status, current.Version.Tag)
+			evaluation.Blocking = pending && !forced
+			if evaluation.Blocking {
+				explanation.Reasons = append(explanation.Reasons, evaluation.Reason)
+			}
+		}
+		explanation.HealthChecks = append(explanation.HealthChecks, evaluation)
+	}
+
+	messages := map[string]string{}
+	for _, summary := range rollout.Status.Gates {
+		messages[summary.Name] = summary.Message
+	}
+	for _, gate := range gates {
+		evaluation := GateEvaluation{Name: gate.Name, Passing: gate.Spec.Passing, Message: messages[gate.Name]}
+		if gate.Spec.AllowedVersions != nil {
+			evaluation.AllowedVersions = *gate.Spec.AllowedVersions
+		}
+		switch {
+		case gate.Spec.Passing != nil && !*gate.Spec.Passing:
+			evaluation.Reason = fmt.Sprintf("gate %s is not passing", gate.Name)
+		case pending && gate.Spec.AllowedVersions != nil && !slices.Contains(*gate.Spec.AllowedVersions, explanation.NextVersion):
+			evaluation.Reason = fmt.Sprintf("gate %s does not allow %s", gate.Name, explanation.NextVersion)
+		}
+		if evaluation.Reason != "" && evaluation.Message != "" {
+			evaluation.Reason += ": " + evaluation.Message
+		}
+		evaluation.Bypassed = evaluation.Reason != "" && (bypassed || forced)
+		evaluation.Blocking = pending && evaluation.Reason != "" && !evaluation.Bypassed
+		if evaluation.Blocking {
+			explanation.Reasons = append(explanation.Reasons, evaluation.Reason)
+		}
+		explanation.Gates = append(explanation.Gates, evaluation)
+	}
+
+	explanation.Blocked = len(explanation.Reasons) > 0
+	return explanation
+}
+
+// evaluateBake explains whether the bake of the current deployment holds the next version and
+// reports whether it is still deploying or baking
+func evaluateBake(rollout *rolloutv1alpha1.Rollout, current *rolloutv1alpha1.DeploymentHistoryEntry, now time.Time) (BakeEvaluation, bool) {
+	bake := BakeEvaluation{
+		Version: current.Version.Tag,
+		Status:  ptr.Deref(current.BakeStatus, ""),
+		Message: ptr.Deref(current.BakeStatusMessage, ""),
+	}
+	if current.BakeStartTime != nil {
+		startedAt := current.BakeStartTime.Time
+		bake.StartedAt = &startedAt
+	}
+
+	switch bake.Status {
+	case rolloutv1alpha1.BakeStatusDeploying:
+		bake.Blocking = true
+		bake.Reason = fmt.Sprintf("%s is still deploying, its bake starts once its health checks are healthy", current.Version.Tag)
+	case rolloutv1alpha1.BakeStatusInProgress:
+		bake.Blocking = true
+		bake.Reason = fmt.Sprintf("%s is still baking", current.Version.Tag)
+		if bake.StartedAt != nil && rollout.Spec.BakeTime != nil {
+			endsAt := bake.StartedAt.Add(rollout.Spec.BakeTime.Duration)
+			bake.EndsAt = &endsAt
+			if remaining := endsAt.Sub(now); remaining > 0 {
+				bake.Reason += fmt.Sprintf(" for another %s", remaining.Round(time.Second))
+			}
+		}
+	case rolloutv1alpha1.BakeStatusFailed:
+		if _, unblocked := rollout.Annotations[UnblockFailedAnnotation]; !unblocked {
+			bake.Blocking = true
+			bake.Reason = fmt.Sprintf("the bake of %s failed and has to be retried or unblocked", current.Version.Tag)
+			if bake.Message != "" {
+				bake.Reason += ": " + bake.Message
+			}
+		}
+	}
+	return bake, isBaking(*current)
+}
@@ -0,0 +1,88 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func explainRollout(bakeStatus string, annotations map[string]string) *rolloutv1alpha1.Rollout {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	return &rolloutv1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "app", Annotations: annotations},
+		Spec:       rolloutv1alpha1.RolloutSpec{BakeTime: &metav1.Duration{Duration: time.Hour}},
+		Status: rolloutv1alpha1.RolloutStatus{
+			History: []rolloutv1alpha1.DeploymentHistoryEntry{{
+				Version:       rolloutv1alpha1.VersionInfo{Tag: "1.0.0"},
+				BakeStatus:    ptr.To(bakeStatus),
+				BakeStartTime: &metav1.Time{Time: now.Add(-20 * time.Minute)},
+			}},
+			ReleaseCandidates: []rolloutv1alpha1.VersionInfo{{Tag: "1.1.0"}},
+			Gates:             []rolloutv1alpha1.RolloutGateStatusSummary{{Name: "freeze", Message: "holiday freeze"}},
+		},
+	}
+}
+
+func explainGate(name string, passing bool, allowed ...string) rolloutv1alpha1.RolloutGate {
+	gate := rolloutv1alpha1.RolloutGate{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+		Spec:       rolloutv1alpha1.RolloutGateSpec{RolloutRef: &corev1.LocalObjectReference{Name: "app"}, Passing: ptr.To(passing)},
+	}
+	if allowed != nil {
+		gate.Spec.AllowedVersions = &allowed
+	}
+	return gate
+}
+
+func TestExplainGates(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	gates := []rolloutv1alpha1.RolloutGate{
+		explainGate("freeze", false),
+		explainGate("staging", true, "1.0.0"),
+		explainGate("smoke", true),
+	}
+	healthChecks := []rolloutv1alpha1.HealthCheck{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "errors"}, Status: rolloutv1alpha1.HealthCheckStatus{Status: rolloutv1alpha1.HealthStatusUnhealthy}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "latency"}, Status: rolloutv1alpha1.HealthCheckStatus{Status: rolloutv1alpha1.HealthStatusHealthy}},
+	}
+
+	explanation := ExplainGates(explainRollout(rolloutv1alpha1.BakeStatusInProgress, nil), gates, healthChecks, now)
+	assert.Equal(t, "1.0.0", explanation.CurrentVersion)
+	assert.Equal(t, "1.1.0", explanation.NextVersion)
+	assert.True(t, explanation.Blocked)
+	assert.Equal(t, []string{
+		"1.0.0 is still baking for another 40m0s",
+		"health check ns/errors is Unhealthy while 1.0.0 bakes",
+		"gate freeze is not passing: holiday freeze",
+		"gate staging does not allow 1.1.0",
+	}, explanation.Reasons)
+	assert.Equal(t, now.Add(40*time.Minute), *explanation.Bake.EndsAt)
+	assert.False(t, explanation.HealthChecks[1].Blocking)
+	assert.False(t, explanation.Gates[2].Blocking)
+
+	// Once baked, bypassing the gates for the version releases it
+	explanation = ExplainGates(explainRollout(rolloutv1alpha1.BakeStatusSucceeded, map[string]string{BypassGatesAnnotation: "1.1.0"}), gates, healthChecks, now)
+	assert.False(t, explanation.Blocked)
+	assert.Empty(t, explanation.Reasons)
+	assert.True(t, explanation.Gates[0].Bypassed)
+	assert.False(t, explanation.HealthChecks[0].Blocking)
+
+	// A failed bake holds the next version until it is unblocked
+	explanation = ExplainGates(explainRollout(rolloutv1alpha1.BakeStatusFailed, nil), nil, nil, now)
+	assert.Equal(t, []string{"the bake of 1.0.0 failed and has to be retried or unblocked"}, explanation.Reasons)
+	explanation = ExplainGates(explainRollout(rolloutv1alpha1.BakeStatusFailed, map[string]string{UnblockFailedAnnotation: "true"}), nil, nil, now)
+	assert.False(t, explanation.Blocked)
+
+	// Nothing is held when the rollout is up to date
+	rollout := explainRollout(rolloutv1alpha1.BakeStatusInProgress, nil)
+	rollout.Status.ReleaseCandidates = nil
+	explanation = ExplainGates(rollout, gates, healthChecks, now)
+	assert.Empty(t, explanation.NextVersion)
+	assert.False(t, explanation.Blocked)
+	assert.Equal(t, "gate freeze is not passing: holiday freeze", explanation.Gates[0].Reason)
+}
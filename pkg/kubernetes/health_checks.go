@@ -0,0 +1,193 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// maxHealthCheckTransitions caps the transitions returned for a HealthCheck
+const maxHealthCheckTransitions = 20
+
+// Sources a HealthCheck transition was derived from
+const (
+	TransitionSourceStatus  = "Status"
+	TransitionSourceEvent   = "Event"
+	TransitionSourceRollout = "Rollout"
+)
+
+// HealthCheckTransition is a change of a HealthCheck's status. The HealthCheck only keeps its
+// current status, so earlier transitions come from its events and from the bakes of rollouts
+// that failed on it.
+type HealthCheckTransition struct {
+	Time    time.Time
+	Status  rolloutv1alpha1.HealthStatus
+	Reason  string
+	Message string
+	Source  string
+	// Rollout is the namespace/name of the rollout whose bake failed, for Rollout transitions
+	Rollout string
+}
+
+// HealthCheckError is the most recent error of a HealthCheck
+type HealthCheckError struct {
+	Time    *time.Time
+	Message string
+}
+
+// GetHealthCheck returns a single HealthCheck
+func (c *Client) GetHealthCheck(ctx context.Context, namespace, name string) (*rolloutv1alpha1.HealthCheck, error) {
+	healthCheck := &rolloutv1alpha1.HealthCheck{}
+	if err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, healthCheck); err != nil {
+		return nil, fmt.Errorf("failed to get health check: %w", err)
+	}
+	return healthCheck, nil
+}
+
+// ListHealthChecks returns the HealthChecks in namespace (all namespaces when empty) matching
+// selector and, unless empty, of the given class
+func (c *Client) ListHealthChecks(ctx context.Context, namespace string, selector labels.Selector, class string) ([]rolloutv1alpha1.HealthCheck, error) {
+	healthCheckList := &rolloutv1alpha1.HealthCheckList{}
+	opts := []client.ListOption{client.MatchingLabelsSelector{Selector: selector}}
+	if namespace != "" {
+		opts = append(opts, client.InNamespace(namespace))
+	}
+	if err := c.client.List(ctx, healthCheckList, opts...); err != nil {
+		return nil, fmt.Errorf("failed to list health checks: %w", err)
+	}
+
+	healthChecks := []rolloutv1alpha1.HealthCheck{}
+	for _, healthCheck := range healthCheckList.Items {
+		if class != "" && (healthCheck.Spec.Class == nil || *healthCheck.Spec.Class != class) {
+			continue
+		}
+		healthChecks = append(healthChecks, healthCheck)
+	}
+	sort.Slice(healthChecks, func(i, j int) bool {
+		if healthChecks[i].Namespace != healthChecks[j].Namespace {
+			return healthChecks[i].Namespace < healthChecks[j].Namespace
+		}
+		return healthChecks[i].Name < healthChecks[j].Name
+	})
+	return healthChecks, nil
+}
+
+// GetHealthCheckTransitions collects the recent status transitions of a HealthCheck from its
+// status, its events and the history of the rollouts whose bakes it failed. Rollouts are read
+// cluster-wide, as rollouts may select HealthChecks in other namespaces, falling back to the
+// HealthCheck's namespace when the caller may not list them all.
+func (c *Client) GetHealthCheckTransitions(ctx context.Context, healthCheck *rolloutv1alpha1.HealthCheck) ([]HealthCheckTransition, error) {
+	if c.clientset == nil {
+		return nil, fmt.Errorf("clientset not initialized")
+	}
+
+	var events []corev1.Event
+	eventList, err := c.clientset.CoreV1().Events(healthCheck.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		slog.Warn("Failed to list health check events", "namespace", healthCheck.Namespace, "healthCheck", healthCheck.Name, "error", err)
+	} else {
+		events = eventList.Items
+	}
+
+	rollouts, err := c.GetRolloutsAllNamespaces(ctx)
+	if err != nil {
+		rollouts, err = c.GetRollouts(ctx, healthCheck.Namespace)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return HealthCheckTransitions(healthCheck, events, rollouts.Items), nil
+}
+
+// HealthCheckTransitions merges the transitions of a HealthCheck recorded by its status, its
+// events and the bake failures in the rollouts' history, newest first
+func HealthCheckTransitions(healthCheck *rolloutv1alpha1.HealthCheck, events []corev1.Event, rollouts []rolloutv1alpha1.Rollout) []HealthCheckTransition {
+	var transitions []HealthCheckTransition
+	if status := healthCheck.Status; status.LastChangeTime != nil {
+		transition := HealthCheckTransition{Time: status.LastChangeTime.Time, Status: status.Status, Source: TransitionSourceStatus}
+		if status.Message != nil {
+			transition.Message = *status.Message
+		}
+		transitions = append(transitions, transition)
+	}
+
+	for i := range events {
+		event := &events[i]
+		involved := event.InvolvedObject
+		if involved.Kind != "HealthCheck" || involved.Namespace != healthCheck.Namespace || involved.Name != healthCheck.Name {
+			continue
+		}
+		transition := HealthCheckTransition{Time: eventTime(event), Reason: event.Reason, Message: event.Message, Source: TransitionSourceEvent}
+		if event.Type == corev1.EventTypeWarning {
+			transition.Status = rolloutv1alpha1.HealthStatusUnhealthy
+		}
+		transitions = append(transitions, transition)
+	}
+
+	for _, rollout := range rollouts {
+		for _, entry := range rollout.Status.History {
+			for _, failed := range entry.FailedHealthChecks {
+				if failed.Namespace != healthCheck.Namespace || failed.Name != healthCheck.Name {
+					continue
+				}
+				transition := HealthCheckTransition{
+					Time:    entry.Timestamp.Time,
+					Status:  rolloutv1alpha1.HealthStatusUnhealthy,
+					Reason:  "BakeFailed",
+					Source:  TransitionSourceRollout,
+					Rollout: rollout.Namespace + "/" + rollout.Name,
+				}
+				if entry.BakeEndTime != nil {
+					transition.Time = entry.BakeEndTime.Time
+				}
+				if failed.Message != nil {
+					transition.Message = *failed.Message
+				}
+				transitions = append(transitions, transition)
+			}
+		}
+	}
+
+	sort.SliceStable(transitions, func(i, j int) bool {
+		return transitions[i].Time.After(transitions[j].Time)
+	})
+	if len(transitions) > maxHealthCheckTransitions {
+		transitions = transitions[:maxHealthCheckTransitions]
+	}
+	return transitions
+}
+
+// LastHealthCheckError returns the HealthCheck's most recent error, nil if it never failed. The
+// time comes from the HealthCheck's status, the message from the status while it is unhealthy and
+// otherwise from the newest unhealthy transition.
+func LastHealthCheckError(healthCheck *rolloutv1alpha1.HealthCheck, transitions []HealthCheckTransition) *HealthCheckError {
+	var lastError *HealthCheckError
+	if status := healthCheck.Status; status.LastErrorTime != nil {
+		errorTime := status.LastErrorTime.Time
+		lastError = &HealthCheckError{Time: &errorTime}
+		if status.Status == rolloutv1alpha1.HealthStatusUnhealthy && status.Message != nil {
+			lastError.Message = *status.Message
+			return lastError
+		}
+	}
+	for _, transition := range transitions {
+		if transition.Status != rolloutv1alpha1.HealthStatusUnhealthy {
+			continue
+		}
+		if lastError == nil {
+			transitionTime := transition.Time
+			lastError = &HealthCheckError{Time: &transitionTime}
+		}
+		lastError.Message = transition.Message
+		break
+	}
+	return lastError
+}
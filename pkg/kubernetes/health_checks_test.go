@@ -0,0 +1,69 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestHealthCheckTransitions(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	at := func(ago time.Duration) metav1.Time { return metav1.NewTime(now.Add(-ago)) }
+	healthCheck := &rolloutv1alpha1.HealthCheck{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api"},
+		Status: rolloutv1alpha1.HealthCheckStatus{
+			Status:         rolloutv1alpha1.HealthStatusHealthy,
+			LastChangeTime: ptr.To(at(time.Minute)),
+			LastErrorTime:  ptr.To(at(time.Hour)),
+		},
+	}
+	events := []corev1.Event{
+		{
+			InvolvedObject: corev1.ObjectReference{Kind: "HealthCheck", Namespace: "shop", Name: "api"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "Unhealthy",
+			Message:        "deployment api has 0/3 ready replicas",
+			LastTimestamp:  at(time.Hour),
+		},
+		{
+			InvolvedObject: corev1.ObjectReference{Kind: "HealthCheck", Namespace: "shop", Name: "web"},
+			Type:           corev1.EventTypeWarning,
+			LastTimestamp:  at(time.Minute),
+		},
+	}
+	rollouts := []rolloutv1alpha1.Rollout{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api"},
+		Status: rolloutv1alpha1.RolloutStatus{History: []rolloutv1alpha1.DeploymentHistoryEntry{
+			{Timestamp: at(30 * time.Minute)},
+			{
+				Timestamp:          at(3 * time.Hour),
+				BakeEndTime:        ptr.To(at(2 * time.Hour)),
+				FailedHealthChecks: []rolloutv1alpha1.FailedHealthCheck{{Namespace: "shop", Name: "api", Message: ptr.To("error rate 12%")}},
+			},
+		}},
+	}}
+
+	transitions := HealthCheckTransitions(healthCheck, events, rollouts)
+	require.Len(t, transitions, 3)
+	assert.Equal(t, TransitionSourceStatus, transitions[0].Source)
+	assert.Equal(t, rolloutv1alpha1.HealthStatusHealthy, transitions[0].Status)
+	assert.Equal(t, TransitionSourceEvent, transitions[1].Source)
+	assert.Equal(t, rolloutv1alpha1.HealthStatusUnhealthy, transitions[1].Status)
+	assert.Equal(t, TransitionSourceRollout, transitions[2].Source)
+	assert.Equal(t, "shop/api", transitions[2].Rollout)
+	assert.Equal(t, now.Add(-2*time.Hour), transitions[2].Time)
+
+	lastError := LastHealthCheckError(healthCheck, transitions)
+	require.NotNil(t, lastError)
+	assert.Equal(t, now.Add(-time.Hour), *lastError.Time)
+	assert.Equal(t, "deployment api has 0/3 ready replicas", lastError.Message)
+
+	healthCheck.Status.LastErrorTime = nil
+	assert.Nil(t, LastHealthCheckError(healthCheck, transitions[:1]))
+}
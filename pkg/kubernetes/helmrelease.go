@@ -0,0 +1,83 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GetHelmReleasesByRolloutAnnotation fetches the HelmReleases deploying a rollout: those with the
+// rollout annotation or a substitute annotation naming it, like Kustomizations, and those whose
+// chart comes from an OCIRepository of the rollout. Clusters without helm-controller have none.
+func (c *Client) GetHelmReleasesByRolloutAnnotation(ctx context.Context, namespace, rolloutName string) (*helmv2.HelmReleaseList, error) {
+	helmReleases := &helmv2.HelmReleaseList{}
+	if err := c.client.List(ctx, helmReleases, client.InNamespace(namespace)); err != nil {
+		if meta.IsNoMatchError(err) {
+			return &helmv2.HelmReleaseList{}, nil
+		}
+		return nil, fmt.Errorf("failed to list helm releases: %w", err)
+	}
+
+	ociRepositories, err := c.GetOCIRepositoriesByRolloutAnnotation(ctx, namespace, rolloutName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OCI repositories: %w", err)
+	}
+	ociRepoNames := make(map[string]bool)
+	for _, ociRepo := range ociRepositories.Items {
+		ociRepoNames[ociRepo.Name] = true
+	}
+
+	filtered := &helmv2.HelmReleaseList{}
+	for _, helmRelease := range helmReleases.Items {
+		if helmReleaseDeploysRollout(&helmRelease, rolloutName, ociRepoNames) {
+			filtered.Items = append(filtered.Items, helmRelease)
+		}
+	}
+	return filtered, nil
+}
+
+func helmReleaseDeploysRollout(helmRelease *helmv2.HelmRelease, rolloutName string, ociRepoNames map[string]bool) bool {
+	if helmRelease.Annotations["rollout.kuberik.com/rollout"] == rolloutName {
+		return true
+	}
+	for key, value := range helmRelease.Annotations {
+		if strings.HasPrefix(key, "rollout.kuberik.com/substitute.") && strings.HasSuffix(key, ".from") && value == rolloutName {
+			return true
+		}
+	}
+	chartRef := helmRelease.Spec.ChartRef
+	return chartRef != nil && chartRef.Kind == sourcev1.OCIRepositoryKind &&
+		(chartRef.Namespace == "" || chartRef.Namespace == helmRelease.Namespace) && ociRepoNames[chartRef.Name]
+}
+
+// GetHelmRelease fetches a HelmRelease by name
+func (c *Client) GetHelmRelease(ctx context.Context, namespace, name string) (*helmv2.HelmRelease, error) {
+	helmRelease := &helmv2.HelmRelease{}
+	if err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, helmRelease); err != nil {
+		return nil, fmt.Errorf("failed to get helm release: %w", err)
+	}
+	return helmRelease, nil
+}
+
+// ReconcileHelmRelease adds the reconcile annotation to trigger a reconciliation
+func (c *Client) ReconcileHelmRelease(ctx context.Context, namespace, name string) error {
+	if err := c.requestReconcile(ctx, helmv2.GroupVersion.WithKind(helmv2.HelmReleaseKind), namespace, name); err != nil {
+		return fmt.Errorf("failed to patch helm release: %w", err)
+	}
+	return nil
+}
+
+// SuspendHelmRelease suspends or resumes the reconciliation of a HelmRelease, like flux suspend
+// and flux resume, and returns the updated HelmRelease. Resuming also requests a reconciliation so
+// changes made while suspended are applied right away.
+func (c *Client) SuspendHelmRelease(ctx context.Context, namespace, name string, suspend bool) (*helmv2.HelmRelease, error) {
+	if err := c.SuspendFluxObject(ctx, helmv2.GroupVersion.WithKind(helmv2.HelmReleaseKind), namespace, name, suspend); err != nil {
+		return nil, err
+	}
+	return c.GetHelmRelease(ctx, namespace, name)
+}
@@ -0,0 +1,95 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2"
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestGetHelmReleasesByRolloutAnnotation(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, helmv2.AddToScheme(scheme))
+	require.NoError(t, sourcev1.AddToScheme(scheme))
+
+	c := &Client{client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&helmv2.HelmRelease{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "apps", Name: "annotated", Annotations: map[string]string{"rollout.kuberik.com/rollout": "web"},
+		}},
+		&helmv2.HelmRelease{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "apps", Name: "substituted", Annotations: map[string]string{"rollout.kuberik.com/substitute.TAG.from": "web"},
+		}},
+		&helmv2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "chart"},
+			Spec:       helmv2.HelmReleaseSpec{ChartRef: &helmv2.CrossNamespaceSourceReference{Kind: sourcev1.OCIRepositoryKind, Name: "web-chart"}},
+		},
+		&helmv2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "other"},
+			Spec:       helmv2.HelmReleaseSpec{ChartRef: &helmv2.CrossNamespaceSourceReference{Kind: sourcev1.OCIRepositoryKind, Name: "other-chart"}},
+		},
+		&helmv2.HelmRelease{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "elsewhere", Name: "annotated", Annotations: map[string]string{"rollout.kuberik.com/rollout": "web"},
+		}},
+		&sourcev1.OCIRepository{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "apps", Name: "web-chart", Annotations: map[string]string{"rollout.kuberik.com/rollout": "web"},
+		}},
+		&sourcev1.OCIRepository{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "other-chart"}},
+	).Build()}
+
+	helmReleases, err := c.GetHelmReleasesByRolloutAnnotation(context.Background(), "apps", "web")
+	require.NoError(t, err)
+	var names []string
+	for _, helmRelease := range helmReleases.Items {
+		names = append(names, helmRelease.Name)
+	}
+	assert.ElementsMatch(t, []string{"annotated", "substituted", "chart"}, names)
+
+	// Clusters without helm-controller have no HelmReleases
+	c = &Client{client: fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			if _, ok := list.(*helmv2.HelmReleaseList); ok {
+				return &apimeta.NoKindMatchError{GroupKind: schema.GroupKind{Group: helmv2.GroupVersion.Group, Kind: helmv2.HelmReleaseKind}}
+			}
+			return c.List(ctx, list, opts...)
+		},
+	}).Build()}
+	helmReleases, err = c.GetHelmReleasesByRolloutAnnotation(context.Background(), "apps", "web")
+	require.NoError(t, err)
+	assert.Empty(t, helmReleases.Items)
+}
+
+func TestSuspendHelmRelease(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, helmv2.AddToScheme(scheme))
+	c := &Client{client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(&helmv2.HelmRelease{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "web", Labels: map[string]string{"team": "web"}},
+		Spec:       helmv2.HelmReleaseSpec{ReleaseName: "web"},
+	}).Build()}
+
+	helmRelease, err := c.SuspendHelmRelease(context.Background(), "apps", "web", true)
+	require.NoError(t, err)
+	assert.True(t, helmRelease.Spec.Suspend)
+	assert.Equal(t, "web", helmRelease.Spec.ReleaseName)
+	assert.Equal(t, "web", helmRelease.Labels["team"])
+	assert.Empty(t, helmRelease.Annotations[meta.ReconcileRequestAnnotation])
+
+	// Resuming requests a reconciliation
+	helmRelease, err = c.SuspendHelmRelease(context.Background(), "apps", "web", false)
+	require.NoError(t, err)
+	assert.False(t, helmRelease.Spec.Suspend)
+	assert.NotEmpty(t, helmRelease.Annotations[meta.ReconcileRequestAnnotation])
+
+	_, err = c.SuspendHelmRelease(context.Background(), "apps", "missing", true)
+	assert.Error(t, err)
+}
@@ -0,0 +1,234 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Sources a history record was derived from
+const (
+	HistorySourceRollout       = "Rollout"
+	HistorySourceKustomization = "Kustomization"
+	HistorySourceEvent         = "Event"
+	HistorySourceRegistry      = "Registry"
+)
+
+// artifactRevisionPattern finds OCI artifact revisions ("tag@sha256:hex") in event messages, e.g.
+// "stored artifact for digest 'v1.2.3@sha256:...'"
+var artifactRevisionPattern = regexp.MustCompile(`'([^'\s]+@sha256:[0-9a-f]+)'`)
+
+// HistoryRecord is a deployment of a version, either from the rollout's status history or
+// reconstructed from what Flux and the registry recorded about it
+type HistoryRecord struct {
+	Version string
+	Digest  string
+	// DeployedAt is when the version was deployed or, for reconstructed records, first seen
+	DeployedAt time.Time
+	// LastSeenAt is when a reconstructed deployment was last seen
+	LastSeenAt time.Time
+	// ReleasedAt is when the version was created in the registry, if known
+	ReleasedAt *time.Time
+	Sources    []string
+	// Reconstructed is set for records not taken from the rollout's status history
+	Reconstructed bool
+	// BakeStatus is the bake status recorded by the rollout, ReconcileStatus the last Kustomization
+	// reconciliation status seen for a reconstructed deployment
+	BakeStatus      string
+	ReconcileStatus string
+}
+
+type historyObservation struct {
+	version string
+	digest  string
+	at      time.Time
+	source  string
+	status  string
+}
+
+// ReconstructHistory merges the rollout's status history with the deployments that can be
+// reconstructed from the revisions its Kustomizations and their events recorded, newest first.
+// The status history is authoritative: deployments are only reconstructed for the time before its
+// oldest entry, so a short or reset history is extended into the past. Revisions are resolved to
+// versions by their tag or, for digest-only revisions, by the digests of the rollout's releases.
+func ReconstructHistory(rollout *rolloutv1alpha1.Rollout, kustomizations []kustomizev1.Kustomization, events []corev1.Event) []HistoryRecord {
+	releases := map[string]rolloutv1alpha1.VersionInfo{}
+	tagsByDigest := map[string]string{}
+	for _, versions := range [][]rolloutv1alpha1.VersionInfo{rollout.Status.AvailableReleases, rollout.Status.ReleaseCandidates} {
+		for _, v := range versions {
+			releases[v.Tag] = v
+			if v.Digest != nil {
+				tagsByDigest[*v.Digest] = v.Tag
+			}
+		}
+	}
+	for _, entry := range rollout.Status.History {
+		if _, ok := releases[entry.Version.Tag]; !ok || entry.Version.Created != nil {
+			releases[entry.Version.Tag] = entry.Version
+		}
+	}
+
+	var observations []historyObservation
+	observe := func(revision string, at time.Time, source, status string) {
+		version, digest, ok := resolveRevision(revision, tagsByDigest)
+		if ok && !at.IsZero() {
+			observations = append(observations, historyObservation{version: version, digest: digest, at: at, source: source, status: status})
+		}
+	}
+	for _, kustomization := range kustomizations {
+		for _, snapshot := range kustomization.Status.History {
+			revision := snapshot.Metadata["revision"]
+			observe(revision, snapshot.FirstReconciled.Time, HistorySourceKustomization, snapshot.LastReconciledStatus)
+			observe(revision, snapshot.LastReconciled.Time, HistorySourceKustomization, snapshot.LastReconciledStatus)
+		}
+	}
+	for i := range events {
+		event := &events[i]
+		if event.Type != corev1.EventTypeNormal {
+			continue
+		}
+		revision := eventRevision(event)
+		if !event.FirstTimestamp.IsZero() {
+			observe(revision, event.FirstTimestamp.Time, HistorySourceEvent, "")
+		}
+		observe(revision, eventTime(event), HistorySourceEvent, "")
+	}
+	sort.SliceStable(observations, func(i, j int) bool {
+		return observations[i].at.Before(observations[j].at)
+	})
+
+	var records []HistoryRecord
+	for _, entry := range rollout.Status.History {
+		record := HistoryRecord{
+			Version:    entry.Version.Tag,
+			DeployedAt: entry.Timestamp.Time,
+			Sources:    []string{HistorySourceRollout},
+		}
+		if entry.BakeStatus != nil {
+			record.BakeStatus = *entry.BakeStatus
+		}
+		if entry.Version.Digest != nil {
+			record.Digest = *entry.Version.Digest
+		}
+		records = append(records, record)
+	}
+	var since time.Time
+	if len(records) > 0 {
+		since = records[len(records)-1].DeployedAt
+	}
+
+	// Consecutive observations of the same version belong to the same deployment
+	var reconstructed []HistoryRecord
+	for _, o := range observations {
+		if !since.IsZero() && !o.at.Before(since) {
+			continue
+		}
+		if n := len(reconstructed); n > 0 && reconstructed[n-1].Version == o.version {
+			last := &reconstructed[n-1]
+			last.LastSeenAt = o.at
+			if last.Digest == "" {
+				last.Digest = o.digest
+			}
+			if o.status != "" {
+				last.ReconcileStatus = o.status
+			}
+			if !slices.Contains(last.Sources, o.source) {
+				last.Sources = append(last.Sources, o.source)
+			}
+			continue
+		}
+		reconstructed = append(reconstructed, HistoryRecord{
+			Version:         o.version,
+			Digest:          o.digest,
+			DeployedAt:      o.at,
+			LastSeenAt:      o.at,
+			Sources:         []string{o.source},
+			Reconstructed:   true,
+			ReconcileStatus: o.status,
+		})
+	}
+	// The deployment the status history starts with was already seen before a reset recorded it
+	// again, it is not a deployment of its own
+	if n := len(reconstructed); n > 0 && len(records) > 0 && reconstructed[n-1].Version == records[len(records)-1].Version {
+		reconstructed = reconstructed[:n-1]
+	}
+	slices.Reverse(reconstructed)
+	records = append(records, reconstructed...)
+
+	for i := range records {
+		if created := releases[records[i].Version].Created; created != nil {
+			releasedAt := created.Time
+			records[i].ReleasedAt = &releasedAt
+		}
+	}
+	return records
+}
+
+// resolveRevision returns the version and digest of a Flux artifact revision: "tag@sha256:hex" or
+// a digest-only "sha256:hex" matched against the rollout's releases. Other revisions, e.g. Git
+// commits, are not versions of the rollout.
+func resolveRevision(revision string, tagsByDigest map[string]string) (version, digest string, ok bool) {
+	tag, digest, found := strings.Cut(revision, "@")
+	if !found {
+		tag, digest = "", revision
+	}
+	if !strings.HasPrefix(digest, "sha256:") {
+		return "", "", false
+	}
+	if tag == "" {
+		tag = tagsByDigest[digest]
+	}
+	return tag, digest, tag != ""
+}
+
+// eventRevision returns the artifact revision a Flux event reports, from its revision annotation
+// (e.g. kustomize.toolkit.fluxcd.io/revision) or its message
+func eventRevision(event *corev1.Event) string {
+	for key, value := range event.Annotations {
+		if strings.HasSuffix(key, "/revision") {
+			return value
+		}
+	}
+	if match := artifactRevisionPattern.FindStringSubmatch(event.Message); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// GetRolloutHistoryEvents returns the Normal events of the rollout's Flux objects still kept by
+// the cluster, regardless of their age
+func (c *Client) GetRolloutHistoryEvents(ctx context.Context, namespace, rolloutName string) ([]corev1.Event, error) {
+	if c.clientset == nil {
+		return nil, fmt.Errorf("clientset not initialized")
+	}
+
+	objects, err := c.GetRolloutObjects(ctx, namespace, rolloutName)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []corev1.Event
+	for _, ns := range objects.Namespaces() {
+		eventList, err := c.clientset.CoreV1().Events(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			slog.Warn("Failed to list events", "namespace", ns, "error", err)
+			continue
+		}
+		for _, event := range eventList.Items {
+			if event.Type == corev1.EventTypeNormal && objects.Matches(&event) {
+				events = append(events, event)
+			}
+		}
+	}
+	return events, nil
+}
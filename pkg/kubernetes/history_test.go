@@ -0,0 +1,86 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	"github.com/fluxcd/pkg/apis/meta"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReconstructHistory(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	at := func(hoursAgo int) time.Time { return now.Add(-time.Duration(hoursAgo) * time.Hour) }
+	digest := func(s string) *string { return &s }
+	succeeded := rolloutv1alpha1.BakeStatusSucceeded
+
+	// The history was reset 5 hours ago and only knows v3 and v4
+	rollout := &rolloutv1alpha1.Rollout{Status: rolloutv1alpha1.RolloutStatus{
+		History: []rolloutv1alpha1.DeploymentHistoryEntry{
+			{Version: rolloutv1alpha1.VersionInfo{Tag: "v4"}, Timestamp: metav1.NewTime(at(1)), BakeStatus: &succeeded},
+			{Version: rolloutv1alpha1.VersionInfo{Tag: "v3"}, Timestamp: metav1.NewTime(at(5))},
+		},
+		AvailableReleases: []rolloutv1alpha1.VersionInfo{
+			{Tag: "v1", Digest: digest("sha256:aaa"), Created: &metav1.Time{Time: at(48)}},
+		},
+	}}
+	kustomizations := []kustomizev1.Kustomization{{Status: kustomizev1.KustomizationStatus{History: meta.History{
+		{Metadata: map[string]string{"revision": "v3@sha256:ccc"}, FirstReconciled: metav1.NewTime(at(6)), LastReconciled: metav1.NewTime(at(2)), LastReconciledStatus: "ReconciliationSucceeded"},
+		{Metadata: map[string]string{"revision": "v2@sha256:bbb"}, FirstReconciled: metav1.NewTime(at(20)), LastReconciled: metav1.NewTime(at(10)), LastReconciledStatus: "HealthCheckFailed"},
+		{Metadata: map[string]string{"revision": "main@sha1:123"}, FirstReconciled: metav1.NewTime(at(30)), LastReconciled: metav1.NewTime(at(30))},
+	}}}}
+	events := []corev1.Event{
+		// Digest-only revision resolved by the releases
+		{Type: corev1.EventTypeNormal, ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"kustomize.toolkit.fluxcd.io/revision": "sha256:aaa"}}, LastTimestamp: metav1.NewTime(at(24))},
+		{Type: corev1.EventTypeNormal, Message: "stored artifact for digest 'v2@sha256:bbb'", LastTimestamp: metav1.NewTime(at(21))},
+		{Type: corev1.EventTypeWarning, Message: "failed to pull 'v9@sha256:fff'", LastTimestamp: metav1.NewTime(at(15))},
+	}
+
+	records := ReconstructHistory(rollout, kustomizations, events)
+	require.Len(t, records, 4)
+
+	assert.Equal(t, "v4", records[0].Version)
+	assert.False(t, records[0].Reconstructed)
+	assert.Equal(t, succeeded, records[0].BakeStatus)
+	assert.Equal(t, "v3", records[1].Version)
+	assert.False(t, records[1].Reconstructed)
+
+	// v3 was seen before the reset recorded it, it is not reconstructed a second time
+	assert.Equal(t, "v2", records[2].Version)
+	assert.True(t, records[2].Reconstructed)
+	assert.Equal(t, at(21), records[2].DeployedAt)
+	assert.Equal(t, at(10), records[2].LastSeenAt)
+	assert.Equal(t, "sha256:bbb", records[2].Digest)
+	assert.Equal(t, "HealthCheckFailed", records[2].ReconcileStatus)
+	assert.Equal(t, []string{HistorySourceEvent, HistorySourceKustomization}, records[2].Sources)
+
+	assert.Equal(t, "v1", records[3].Version)
+	assert.True(t, records[3].Reconstructed)
+	assert.Equal(t, at(24), records[3].DeployedAt)
+	require.NotNil(t, records[3].ReleasedAt)
+	assert.Equal(t, at(48), *records[3].ReleasedAt)
+}
+
+func TestReconstructHistory_Rollback(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	snapshot := func(revision string, first, last time.Duration) meta.Snapshot {
+		return meta.Snapshot{Metadata: map[string]string{"revision": revision}, FirstReconciled: metav1.NewTime(now.Add(-first)), LastReconciled: metav1.NewTime(now.Add(-last))}
+	}
+	kustomizations := []kustomizev1.Kustomization{{Status: kustomizev1.KustomizationStatus{History: meta.History{
+		snapshot("v1@sha256:aaa", 3*time.Hour, 0),
+		snapshot("v2@sha256:bbb", 2*time.Hour, time.Hour),
+	}}}}
+
+	records := ReconstructHistory(&rolloutv1alpha1.Rollout{}, kustomizations, nil)
+	var versions []string
+	for _, record := range records {
+		versions = append(versions, record.Version)
+		assert.True(t, record.Reconstructed)
+	}
+	assert.Equal(t, []string{"v1", "v2", "v1"}, versions)
+}
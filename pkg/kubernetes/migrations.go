@@ -0,0 +1,142 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// MigrationLabel marks Jobs that run migrations, e.g. database schema changes, as part of a
+// deploy. The value names the migration, so Jobs of different versions with the same value in a
+// namespace are runs of the same migration.
+const MigrationLabel = "rollout.kuberik.com/migration"
+
+// Migration job states
+const (
+	MigrationPending   = "Pending"
+	MigrationRunning   = "Running"
+	MigrationSucceeded = "Succeeded"
+	MigrationFailed    = "Failed"
+)
+
+var jobGroupKind = schema.GroupKind{Group: "batch", Kind: "Job"}
+
+// GetMigrationJobs returns the migration Jobs of a rollout: the Jobs labeled with MigrationLabel
+// in the inventories of its Kustomizations, plus earlier runs of the same migrations that are
+// still present in their namespaces, e.g. because pruning is disabled for them. Jobs are sorted
+// newest first.
+func (c *Client) GetMigrationJobs(ctx context.Context, kustomizations []kustomizev1.Kustomization) ([]batchv1.Job, error) {
+	// Migration names per namespace, from the Jobs currently applied
+	migrations := map[string]map[string]bool{}
+	for _, kustomization := range kustomizations {
+		if kustomization.Status.Inventory == nil {
+			continue
+		}
+		for _, entry := range kustomization.Status.Inventory.Entries {
+			objMetadata, err := object.ParseObjMetadata(entry.ID)
+			if err != nil || objMetadata.GroupKind != jobGroupKind {
+				continue
+			}
+			job := &batchv1.Job{}
+			if err := c.client.Get(ctx, client.ObjectKey{Namespace: objMetadata.Namespace, Name: objMetadata.Name}, job); err != nil {
+				// Finished Jobs may have been removed by their TTL
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				return nil, fmt.Errorf("failed to get job %s/%s: %w", objMetadata.Namespace, objMetadata.Name, err)
+			}
+			name := job.Labels[MigrationLabel]
+			if name == "" || name == "false" {
+				continue
+			}
+			if migrations[job.Namespace] == nil {
+				migrations[job.Namespace] = map[string]bool{}
+			}
+			migrations[job.Namespace][name] = true
+		}
+	}
+
+	var jobs []batchv1.Job
+	for namespace, names := range migrations {
+		list := &batchv1.JobList{}
+		if err := c.client.List(ctx, list, client.InNamespace(namespace), client.HasLabels{MigrationLabel}); err != nil {
+			return nil, fmt.Errorf("failed to list migration jobs: %w", err)
+		}
+		for _, job := range list.Items {
+			if names[job.Labels[MigrationLabel]] {
+				jobs = append(jobs, job)
+			}
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].CreationTimestamp.Equal(&jobs[j].CreationTimestamp) {
+			return jobs[j].CreationTimestamp.Before(&jobs[i].CreationTimestamp)
+		}
+		return jobs[i].Namespace+"/"+jobs[i].Name < jobs[j].Namespace+"/"+jobs[j].Name
+	})
+	return jobs, nil
+}
+
+// MigrationJobStatus returns the state of a migration Job and, for failed Jobs, why it failed
+func MigrationJobStatus(job *batchv1.Job) (string, string) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return MigrationSucceeded, ""
+		case batchv1.JobFailed:
+			return MigrationFailed, condition.Message
+		}
+	}
+	if job.Status.Active > 0 || job.Status.StartTime != nil {
+		return MigrationRunning, ""
+	}
+	return MigrationPending, ""
+}
+
+// MigrationVersion returns the rollout version a migration Job ran for: the deployed version
+// whose tag one of its container images uses, or else the version deployed when the Job was
+// created. history is newest first, as in the rollout status.
+func MigrationVersion(job *batchv1.Job, history []rolloutv1alpha1.DeploymentHistoryEntry) string {
+	spec := job.Spec.Template.Spec
+	for _, container := range append(append([]corev1.Container(nil), spec.InitContainers...), spec.Containers...) {
+		tag := imageTag(container.Image)
+		if tag == "" {
+			continue
+		}
+		for _, entry := range history {
+			if entry.Version.Tag == tag {
+				return tag
+			}
+		}
+	}
+	for _, entry := range history {
+		if !job.CreationTimestamp.Before(&entry.Timestamp) {
+			return entry.Version.Tag
+		}
+	}
+	return ""
+}
+
+// imageTag returns the tag of an image reference, ignoring any digest
+func imageTag(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	slash := strings.LastIndex(image, "/")
+	colon := strings.LastIndex(image, ":")
+	if colon <= slash {
+		return ""
+	}
+	return image[colon+1:]
+}
@@ -0,0 +1,93 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func migrationJob(name, migration string, created time.Time, image string) *batchv1.Job {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: name, CreationTimestamp: metav1.NewTime(created)},
+		Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "migrate", Image: image}},
+		}}},
+	}
+	if migration != "" {
+		job.Labels = map[string]string{MigrationLabel: migration}
+	}
+	return job
+}
+
+func TestGetMigrationJobs(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	scheme := runtime.NewScheme()
+	require.NoError(t, batchv1.AddToScheme(scheme))
+	c := &Client{client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		migrationJob("db-v2", "db", now, "shop:v2"),
+		migrationJob("db-v1", "db", now.Add(-time.Hour), "shop:v1"),
+		migrationJob("cache-v1", "cache", now, "shop:v1"),
+		migrationJob("seed", "", now, "shop:v2"),
+	).Build()}
+
+	jobs, err := c.GetMigrationJobs(context.Background(), []kustomizev1.Kustomization{{
+		Status: kustomizev1.KustomizationStatus{Inventory: &kustomizev1.ResourceInventory{Entries: []kustomizev1.ResourceRef{
+			{ID: "shop_db-v2_batch_Job", Version: "v1"},
+			{ID: "shop_seed_batch_Job", Version: "v1"},
+			{ID: "shop_pruned_batch_Job", Version: "v1"},
+			{ID: "shop_shop_apps_Deployment", Version: "v1"},
+		}}},
+	}})
+	require.NoError(t, err)
+
+	var names []string
+	for _, job := range jobs {
+		names = append(names, job.Name)
+	}
+	// Earlier runs of the db migration are included, unlabeled and other migrations' Jobs are not
+	assert.Equal(t, []string{"db-v2", "db-v1"}, names)
+}
+
+func TestMigrationJobStatus(t *testing.T) {
+	job := &batchv1.Job{}
+	status, _ := MigrationJobStatus(job)
+	assert.Equal(t, MigrationPending, status)
+
+	job.Status.Active = 1
+	status, _ = MigrationJobStatus(job)
+	assert.Equal(t, MigrationRunning, status)
+
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "BackoffLimitExceeded"}}
+	status, message := MigrationJobStatus(job)
+	assert.Equal(t, MigrationFailed, status)
+	assert.Equal(t, "BackoffLimitExceeded", message)
+
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+	status, _ = MigrationJobStatus(job)
+	assert.Equal(t, MigrationSucceeded, status)
+}
+
+func TestMigrationVersion(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	history := []rolloutv1alpha1.DeploymentHistoryEntry{
+		{Version: rolloutv1alpha1.VersionInfo{Tag: "v2"}, Timestamp: metav1.NewTime(now.Add(-time.Hour))},
+		{Version: rolloutv1alpha1.VersionInfo{Tag: "v1"}, Timestamp: metav1.NewTime(now.Add(-2 * time.Hour))},
+	}
+
+	// The image tag wins over the creation time
+	assert.Equal(t, "v1", MigrationVersion(migrationJob("a", "db", now, "registry:5000/shop:v1"), history))
+	assert.Equal(t, "v1", MigrationVersion(migrationJob("b", "db", now, "registry:5000/shop:v1@sha256:abc"), history))
+	assert.Equal(t, "v2", MigrationVersion(migrationJob("c", "db", now, "migrate:latest"), history))
+	assert.Equal(t, "v1", MigrationVersion(migrationJob("d", "db", now.Add(-90*time.Minute), "registry:5000/migrate"), history))
+	assert.Equal(t, "", MigrationVersion(migrationJob("e", "db", now.Add(-3*time.Hour), "migrate"), history))
+}
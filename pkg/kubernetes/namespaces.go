@@ -0,0 +1,160 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrNoAccessibleNamespaces is returned when the namespaces a user may access cannot be
+// enumerated: the user may not list namespaces and no allowlist is configured
+var ErrNoAccessibleNamespaces = errors.New("cannot enumerate namespaces: listing namespaces is forbidden and no namespace allowlist is configured")
+
+// maxConcurrentNamespaceRequests bounds the per-namespace reviews and lists sent at once
+const maxConcurrentNamespaceRequests = 8
+
+// SkippedNamespace is a namespace left out of a multi-namespace listing and why
+type SkippedNamespace struct {
+	Namespace string `json:"namespace"`
+	Reason    string `json:"reason"`
+}
+
+// AccessibleNamespaces returns the namespaces in which the current user may list the given
+// resource, for users without cluster-wide list permission. Candidate namespaces come from
+// allowlist or, when it is empty, from listing namespaces. Each candidate is checked with a
+// SelfSubjectRulesReview; namespaces whose review denies listing are returned as skipped, while
+// a failed or incomplete review keeps the namespace so the list itself decides.
+func (c *Client) AccessibleNamespaces(ctx context.Context, apiGroup, resource string, allowlist []string) ([]string, []SkippedNamespace, error) {
+	candidates := allowlist
+	if len(candidates) == 0 {
+		namespaces := &corev1.NamespaceList{}
+		if err := c.client.List(ctx, namespaces); err != nil {
+			if apierrors.IsForbidden(err) {
+				return nil, nil, ErrNoAccessibleNamespaces
+			}
+			return nil, nil, fmt.Errorf("failed to list namespaces: %w", err)
+		}
+		for _, ns := range namespaces.Items {
+			candidates = append(candidates, ns.Name)
+		}
+	}
+
+	clientset, err := c.userClientset()
+	if err != nil {
+		// Without a REST config there is nothing to review; the per-namespace lists decide
+		return candidates, nil, nil
+	}
+
+	allowed := make([]bool, len(candidates))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(maxConcurrentNamespaceRequests)
+	for i, namespace := range candidates {
+		g.Go(func() error {
+			review, err := clientset.AuthorizationV1().SelfSubjectRulesReviews().Create(gctx, &authorizationv1.SelfSubjectRulesReview{
+				Spec: authorizationv1.SelfSubjectRulesReviewSpec{Namespace: namespace},
+			}, metav1.CreateOptions{})
+			allowed[i] = err != nil || review.Status.Incomplete || RulesAllow(review.Status.ResourceRules, apiGroup, resource, "list")
+			return nil
+		})
+	}
+	_ = g.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var namespaces []string
+	var skipped []SkippedNamespace
+	for i, namespace := range candidates {
+		if allowed[i] {
+			namespaces = append(namespaces, namespace)
+		} else {
+			skipped = append(skipped, SkippedNamespace{Namespace: namespace, Reason: "forbidden"})
+		}
+	}
+	return namespaces, skipped, nil
+}
+
+// RulesAllow reports whether resource rules from a SelfSubjectRulesReview grant verb on resource
+func RulesAllow(rules []authorizationv1.ResourceRule, apiGroup, resource, verb string) bool {
+	for _, rule := range rules {
+		if matchesRule(rule.APIGroups, apiGroup) && matchesRule(rule.Resources, resource) &&
+			matchesRule(rule.Verbs, verb) && len(rule.ResourceNames) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesRule(values []string, value string) bool {
+	for _, v := range values {
+		if v == "*" || v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ListAcrossNamespaces lists objects in each namespace and merges them into list, sorted by
+// namespace. Namespaces whose list fails are returned as skipped instead of failing the whole
+// listing; only context cancellation is returned as an error.
+func (c *Client) ListAcrossNamespaces(ctx context.Context, list client.ObjectList, namespaces []string) ([]SkippedNamespace, error) {
+	sorted := append([]string(nil), namespaces...)
+	sort.Strings(sorted)
+
+	items := make([][]runtime.Object, len(sorted))
+	var mu sync.Mutex
+	var skipped []SkippedNamespace
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(maxConcurrentNamespaceRequests)
+	for i, namespace := range sorted {
+		g.Go(func() error {
+			namespaced := list.DeepCopyObject().(client.ObjectList)
+			if err := c.client.List(gctx, namespaced, client.InNamespace(namespace)); err != nil {
+				reason := err.Error()
+				if apierrors.IsForbidden(err) {
+					reason = "forbidden"
+				}
+				mu.Lock()
+				skipped = append(skipped, SkippedNamespace{Namespace: namespace, Reason: reason})
+				mu.Unlock()
+				return nil
+			}
+			objects, err := meta.ExtractList(namespaced)
+			if err != nil {
+				return err
+			}
+			items[i] = objects
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, fmt.Errorf("failed to merge lists: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var merged []runtime.Object
+	for _, objects := range items {
+		merged = append(merged, objects...)
+	}
+	if err := meta.SetList(list, merged); err != nil {
+		return nil, fmt.Errorf("failed to merge lists: %w", err)
+	}
+	sort.Slice(skipped, func(i, j int) bool {
+		return skipped[i].Namespace < skipped[j].Namespace
+	})
+	return skipped, nil
+}
@@ -0,0 +1,88 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+// newForbiddingClient returns a client that is forbidden to list namespaces and everything in
+// the forbidden namespaces
+func newForbiddingClient(t *testing.T, forbidden map[string]bool, initial ...client.Object) *Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, rolloutv1alpha1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(initial...).
+		WithInterceptorFuncs(interceptor.Funcs{
+			List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				listOpts := (&client.ListOptions{}).ApplyOptions(opts)
+				_, namespaceList := list.(*corev1.NamespaceList)
+				if namespaceList || forbidden[listOpts.Namespace] {
+					return apierrors.NewForbidden(schema.GroupResource{Resource: "rollouts"}, "", nil)
+				}
+				return c.List(ctx, list, opts...)
+			},
+		}).
+		Build()
+	return &Client{client: c}
+}
+
+func TestListAcrossNamespaces(t *testing.T) {
+	c := newForbiddingClient(t, map[string]bool{"secret": true},
+		&rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "api"}},
+		&rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "web"}},
+		&rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "secret", Name: "vault"}},
+		&rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "ignored"}},
+	)
+
+	rollouts := &rolloutv1alpha1.RolloutList{}
+	skipped, err := c.ListAcrossNamespaces(context.Background(), rollouts, []string{"team-b", "secret", "team-a"})
+	require.NoError(t, err)
+
+	require.Len(t, rollouts.Items, 2)
+	assert.Equal(t, "team-a", rollouts.Items[0].Namespace)
+	assert.Equal(t, "team-b", rollouts.Items[1].Namespace)
+	assert.Equal(t, []SkippedNamespace{{Namespace: "secret", Reason: "forbidden"}}, skipped)
+}
+
+func TestAccessibleNamespaces(t *testing.T) {
+	c := newForbiddingClient(t, nil)
+
+	_, _, err := c.AccessibleNamespaces(context.Background(), "kuberik.com", "rollouts", nil)
+	assert.ErrorIs(t, err, ErrNoAccessibleNamespaces)
+
+	// Without a REST config there are no reviews, the allowlist is used as is
+	namespaces, skipped, err := c.AccessibleNamespaces(context.Background(), "kuberik.com", "rollouts", []string{"team-a", "team-b"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"team-a", "team-b"}, namespaces)
+	assert.Empty(t, skipped)
+}
+
+func TestRulesAllow(t *testing.T) {
+	rules := []authorizationv1.ResourceRule{
+		{Verbs: []string{"get", "list"}, APIGroups: []string{"kuberik.com"}, Resources: []string{"rollouts"}},
+		{Verbs: []string{"list"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}, ResourceNames: []string{"web"}},
+		{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"configmaps"}},
+	}
+
+	assert.True(t, RulesAllow(rules, "kuberik.com", "rollouts", "list"))
+	assert.False(t, RulesAllow(rules, "kuberik.com", "rollouts", "delete"))
+	assert.False(t, RulesAllow(rules, "apps", "deployments", "list"), "rules limited to names do not allow listing")
+	assert.True(t, RulesAllow(rules, "", "configmaps", "list"))
+	assert.False(t, RulesAllow(nil, "kuberik.com", "rollouts", "list"))
+}
@@ -0,0 +1,133 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Fields of RolloutOverrides, as named in OverrideChange
+const (
+	OverrideWantedVersion = "wantedVersion"
+	OverrideBypassGates   = "bypassGates"
+	OverrideChannel       = "channel"
+)
+
+// RolloutOverrides are the pins and overrides set on a rollout that outlive a single deployment:
+// the pinned version, the version allowed to bypass gates and the tracked channel
+type RolloutOverrides struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// DeploymentName is the name (spec.name) of the rollout's Environment, which matches the
+	// rollouts of another environment when the overrides are applied there
+	DeploymentName string  `json:"deploymentName,omitempty"`
+	WantedVersion  *string `json:"wantedVersion,omitempty"`
+	BypassGates    string  `json:"bypassGates,omitempty"`
+	Channel        string  `json:"channel,omitempty"`
+}
+
+// OverrideChange is a field of RolloutOverrides that applying them changes; empty values are unset
+type OverrideChange struct {
+	Field string `json:"field"`
+	From  string `json:"from,omitempty"`
+	To    string `json:"to,omitempty"`
+}
+
+// EnvironmentRollout is a rollout managed by an Environment
+type EnvironmentRollout struct {
+	types.NamespacedName
+	DeploymentName string
+}
+
+// EnvironmentDeployments returns the rollouts managed by Environments of the given environment
+// name with the Environments' deployment names, each rollout once, sorted by namespace and name
+func EnvironmentDeployments(environments []envv1alpha1.Environment, environment string) []EnvironmentRollout {
+	seen := map[types.NamespacedName]bool{}
+	rollouts := []EnvironmentRollout{}
+	for _, env := range environments {
+		if env.Spec.Environment != environment || env.Spec.RolloutRef.Name == "" {
+			continue
+		}
+		ref := types.NamespacedName{Namespace: env.Namespace, Name: env.Spec.RolloutRef.Name}
+		if !seen[ref] {
+			seen[ref] = true
+			rollouts = append(rollouts, EnvironmentRollout{NamespacedName: ref, DeploymentName: env.Spec.Name})
+		}
+	}
+	sort.Slice(rollouts, func(i, j int) bool {
+		return rollouts[i].String() < rollouts[j].String()
+	})
+	return rollouts
+}
+
+// GetRolloutOverrides returns the overrides currently set on a rollout
+func GetRolloutOverrides(rollout *rolloutv1alpha1.Rollout, deploymentName string) RolloutOverrides {
+	return RolloutOverrides{
+		Namespace:      rollout.Namespace,
+		Name:           rollout.Name,
+		DeploymentName: deploymentName,
+		WantedVersion:  rollout.Spec.WantedVersion,
+		BypassGates:    rollout.Annotations[BypassGatesAnnotation],
+		Channel:        rollout.Annotations[ChannelAnnotation],
+	}
+}
+
+// DiffOverrides returns the changes that turn the current overrides into the wanted ones
+func DiffOverrides(current, wanted RolloutOverrides) []OverrideChange {
+	changes := []OverrideChange{}
+	deref := func(version *string) string {
+		if version == nil {
+			return ""
+		}
+		return *version
+	}
+	for _, field := range []struct {
+		name         string
+		from, wanted string
+	}{
+		{OverrideWantedVersion, deref(current.WantedVersion), deref(wanted.WantedVersion)},
+		{OverrideBypassGates, current.BypassGates, wanted.BypassGates},
+		{OverrideChannel, current.Channel, wanted.Channel},
+	} {
+		if field.from != field.wanted {
+			changes = append(changes, OverrideChange{Field: field.name, From: field.from, To: field.wanted})
+		}
+	}
+	return changes
+}
+
+// ApplyOverrides sets the overrides of a rollout to wanted in one patch, unsetting the ones wanted
+// leaves empty. A tracked channel keeps the rollout pinned to the channel's release from then on.
+func (c *Client) ApplyOverrides(ctx context.Context, namespace, name string, wanted RolloutOverrides, message string) (*rolloutv1alpha1.Rollout, error) {
+	patch := rolloutPatch(namespace, name)
+	var wantedVersion, bypassGates, channel any
+	if wanted.WantedVersion != nil {
+		wantedVersion = *wanted.WantedVersion
+	}
+	if wanted.BypassGates != "" {
+		bypassGates = wanted.BypassGates
+	}
+	if wanted.Channel != "" {
+		channel = wanted.Channel
+	}
+	patch.Object["spec"] = map[string]any{"wantedVersion": wantedVersion}
+	annotations := map[string]any{
+		BypassGatesAnnotation: bypassGates,
+		ChannelAnnotation:     channel,
+	}
+	if message != "" {
+		annotations[DeployMessageAnnotation] = message
+	}
+	patch.Object["metadata"].(map[string]any)["annotations"] = annotations
+	attributeChange(patch, c.actingUser(ctx), true)
+
+	if err := c.client.Patch(ctx, patch, client.Merge, client.FieldOwner("rollout-dashboard")); err != nil {
+		return nil, fmt.Errorf("failed to apply overrides: %w", err)
+	}
+	return c.GetRollout(ctx, namespace, name)
+}
@@ -0,0 +1,52 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestApplyOverrides(t *testing.T) {
+	scheme, err := NewScheme()
+	require.NoError(t, err)
+	pinned := "v1"
+	c := &Client{client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(&rolloutv1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "shop",
+			Name:        "api",
+			Annotations: map[string]string{BypassGatesAnnotation: "v1", ChannelAnnotation: "stable"},
+		},
+		Spec: rolloutv1alpha1.RolloutSpec{WantedVersion: &pinned},
+	}).Build()}
+	ctx := context.Background()
+
+	rollout, err := c.GetRollout(ctx, "shop", "api")
+	require.NoError(t, err)
+	current := GetRolloutOverrides(rollout, "api")
+	assert.Equal(t, RolloutOverrides{Namespace: "shop", Name: "api", DeploymentName: "api", WantedVersion: &pinned, BypassGates: "v1", Channel: "stable"}, current)
+	assert.Empty(t, DiffOverrides(current, current))
+
+	wantedVersion := "v2"
+	wanted := RolloutOverrides{WantedVersion: &wantedVersion, BypassGates: "v1"}
+	assert.Equal(t, []OverrideChange{
+		{Field: OverrideWantedVersion, From: "v1", To: "v2"},
+		{Field: OverrideChannel, From: "stable"},
+	}, DiffOverrides(current, wanted))
+
+	rollout, err = c.ApplyOverrides(ctx, "shop", "api", wanted, "Applied overrides of environment staging")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", *rollout.Spec.WantedVersion)
+	assert.Equal(t, "v1", rollout.Annotations[BypassGatesAnnotation])
+	assert.NotContains(t, rollout.Annotations, ChannelAnnotation)
+	assert.Equal(t, "Applied overrides of environment staging", rollout.Annotations[DeployMessageAnnotation])
+
+	rollout, err = c.ApplyOverrides(ctx, "shop", "api", RolloutOverrides{}, "")
+	require.NoError(t, err)
+	assert.Nil(t, rollout.Spec.WantedVersion)
+	assert.NotContains(t, rollout.Annotations, BypassGatesAnnotation)
+}
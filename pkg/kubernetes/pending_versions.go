@@ -0,0 +1,151 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"k8s.io/utils/ptr"
+)
+
+// PendingVersions is the queue of release candidates of a rollout waiting to be deployed
+type PendingVersions struct {
+	// ImagePolicy provides the releases of the rollout
+	ImagePolicy    string `json:"imagePolicy,omitempty"`
+	CurrentVersion string `json:"currentVersion,omitempty"`
+	// PinnedVersion is deployed instead of any candidate while the rollout is pinned
+	PinnedVersion string `json:"pinnedVersion,omitempty"`
+	// Versions are newest first, the order the rollout controller prefers them in
+	Versions []PendingVersion `json:"versions"`
+}
+
+// PendingVersion is a release candidate newer than the deployed version
+type PendingVersion struct {
+	rolloutv1alpha1.VersionInfo
+	// GatesAllow reports whether every gate passes and allows the version, regardless of bypasses
+	GatesAllow bool `json:"gatesAllow"`
+	// Next marks the version the rollout controller deploys once nothing holds it
+	Next    bool     `json:"next"`
+	Blocked bool     `json:"blocked"`
+	Reasons []string `json:"reasons"`
+	// Annotations are the rollout annotations that would deploy the version now. They are empty
+	// when nothing holds it, or when only unpinning the rollout would.
+	Annotations []string `json:"annotations"`
+}
+
+// GetPendingVersions returns the pending versions of a rollout, see QueuePendingVersions
+func (c *Client) GetPendingVersions(ctx context.Context, namespace, name string, now time.Time) (*PendingVersions, error) {
+	rollout, err := c.GetRollout(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	gates, err := c.GetRolloutGatesByRolloutReference(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	healthChecks, err := c.GetHealthChecksBySelector(ctx, namespace, rollout.Spec.HealthCheckSelector)
+	if err != nil {
+		return nil, err
+	}
+	pending := QueuePendingVersions(rollout, gates.Items, healthChecks, now)
+	return &pending, nil
+}
+
+// QueuePendingVersions evaluates every release candidate of a rollout the way ExplainGates
+// evaluates the next version. Of the candidates every gate allows, the rollout controller deploys
+// the newest, so an older one needs its gates bypassed to be deployed instead; a version held by
+// the bake of the current version needs to be force deployed.
+func QueuePendingVersions(rollout *rolloutv1alpha1.Rollout, gates []rolloutv1alpha1.RolloutGate, healthChecks []rolloutv1alpha1.HealthCheck, now time.Time) PendingVersions {
+	pending := PendingVersions{
+		ImagePolicy:   rollout.Spec.ReleasesImagePolicy.Name,
+		PinnedVersion: ptr.Deref(rollout.Spec.WantedVersion, ""),
+		Versions:      []PendingVersion{},
+	}
+	if len(rollout.Status.History) > 0 {
+		pending.CurrentVersion = rollout.Status.History[0].Version.Tag
+	}
+
+	explanations := make([]GateExplanation, len(rollout.Status.ReleaseCandidates))
+	next := ""
+	for i, candidate := range rollout.Status.ReleaseCandidates {
+		explanations[i] = explainVersion(rollout, gates, healthChecks, candidate.Tag, now)
+		if next == "" && gatesAllow(explanations[i]) {
+			next = candidate.Tag
+		}
+	}
+	// A bypass makes its version the only one the gates let through, a forced version and then a
+	// pinned one take precedence over any other
+	for _, annotation := range []string{BypassGatesAnnotation, ForceDeployAnnotation} {
+		version := rollout.Annotations[annotation]
+		if slices.ContainsFunc(rollout.Status.ReleaseCandidates, func(candidate rolloutv1alpha1.VersionInfo) bool { return candidate.Tag == version }) {
+			next = version
+		}
+	}
+	if pending.PinnedVersion != "" {
+		next = pending.PinnedVersion
+	}
+
+	for i, candidate := range rollout.Status.ReleaseCandidates {
+		explanation := explanations[i]
+		version := PendingVersion{
+			VersionInfo: candidate,
+			GatesAllow:  gatesAllow(explanation),
+			Next:        candidate.Tag == next,
+			Reasons:     explanation.Reasons,
+			Annotations: []string{},
+		}
+		switch {
+		case pending.PinnedVersion != "" && pending.PinnedVersion != candidate.Tag:
+			version.Reasons = append(version.Reasons, fmt.Sprintf("the rollout is pinned to %s", pending.PinnedVersion))
+		case !version.Next && next != "" && !gatesBlock(explanation):
+			version.Reasons = append(version.Reasons, fmt.Sprintf("%s is deployed first", next))
+		}
+		version.Blocked = len(version.Reasons) > 0
+		if version.Blocked && (pending.PinnedVersion == "" || pending.PinnedVersion == candidate.Tag) {
+			version.Annotations = releasingAnnotations(explanation, version.Next)
+		}
+		pending.Versions = append(pending.Versions, version)
+	}
+	return pending
+}
+
+// releasingAnnotations returns the annotations that would deploy a held version now
+func releasingAnnotations(explanation GateExplanation, next bool) []string {
+	baking := explanation.Bake.Blocking && explanation.Bake.Status != rolloutv1alpha1.BakeStatusFailed
+	for _, healthCheck := range explanation.HealthChecks {
+		baking = baking || healthCheck.Blocking
+	}
+	if baking {
+		return []string{ForceDeployAnnotation}
+	}
+	annotations := []string{}
+	if explanation.Bake.Blocking {
+		annotations = append(annotations, UnblockFailedAnnotation)
+	}
+	if gatesBlock(explanation) || !next {
+		annotations = append(annotations, BypassGatesAnnotation)
+	}
+	return annotations
+}
+
+// gatesAllow reports whether every gate passes and allows the version explained
+func gatesAllow(explanation GateExplanation) bool {
+	for _, gate := range explanation.Gates {
+		if gate.Reason != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// gatesBlock reports whether a gate holds the version explained, bypasses taken into account
+func gatesBlock(explanation GateExplanation) bool {
+	for _, gate := range explanation.Gates {
+		if gate.Blocking {
+			return true
+		}
+	}
+	return false
+}
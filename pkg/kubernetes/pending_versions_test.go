@@ -0,0 +1,59 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestQueuePendingVersions(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	rollout := explainRollout(rolloutv1alpha1.BakeStatusSucceeded, nil)
+	rollout.Spec.ReleasesImagePolicy.Name = "app"
+	rollout.Status.ReleaseCandidates = []rolloutv1alpha1.VersionInfo{{Tag: "1.3.0"}, {Tag: "1.2.0"}, {Tag: "1.1.0"}}
+	rollout.Status.Gates = nil
+	gates := []rolloutv1alpha1.RolloutGate{explainGate("staging", true, "1.2.0", "1.1.0")}
+
+	pending := QueuePendingVersions(rollout, gates, nil, now)
+	assert.Equal(t, "app", pending.ImagePolicy)
+	assert.Equal(t, "1.0.0", pending.CurrentVersion)
+	require.Len(t, pending.Versions, 3)
+
+	newest := pending.Versions[0]
+	assert.Equal(t, "1.3.0", newest.Tag)
+	assert.False(t, newest.GatesAllow)
+	assert.False(t, newest.Next)
+	assert.Equal(t, []string{"gate staging does not allow 1.3.0"}, newest.Reasons)
+	assert.Equal(t, []string{BypassGatesAnnotation}, newest.Annotations)
+
+	next := pending.Versions[1]
+	assert.True(t, next.GatesAllow)
+	assert.True(t, next.Next)
+	assert.False(t, next.Blocked)
+	assert.Empty(t, next.Annotations)
+
+	oldest := pending.Versions[2]
+	assert.True(t, oldest.GatesAllow)
+	assert.Equal(t, []string{"1.2.0 is deployed first"}, oldest.Reasons)
+	assert.Equal(t, []string{BypassGatesAnnotation}, oldest.Annotations)
+
+	// While the current version bakes, only force deploying releases a version
+	rollout = explainRollout(rolloutv1alpha1.BakeStatusInProgress, map[string]string{BypassGatesAnnotation: "1.3.0"})
+	rollout.Status.ReleaseCandidates = []rolloutv1alpha1.VersionInfo{{Tag: "1.3.0"}, {Tag: "1.2.0"}}
+	pending = QueuePendingVersions(rollout, gates, nil, now)
+	assert.True(t, pending.Versions[0].Next)
+	assert.Equal(t, []string{ForceDeployAnnotation}, pending.Versions[0].Annotations)
+	assert.Equal(t, []string{"1.0.0 is still baking for another 40m0s", "1.3.0 is deployed first"}, pending.Versions[1].Reasons)
+
+	// A pinned rollout deploys none of the candidates
+	rollout = explainRollout(rolloutv1alpha1.BakeStatusSucceeded, nil)
+	rollout.Spec.WantedVersion = ptr.To("1.0.0")
+	pending = QueuePendingVersions(rollout, nil, nil, now)
+	assert.Equal(t, "1.0.0", pending.PinnedVersion)
+	assert.Equal(t, []string{"the rollout is pinned to 1.0.0"}, pending.Versions[0].Reasons)
+	assert.Empty(t, pending.Versions[0].Annotations)
+}
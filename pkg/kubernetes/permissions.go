@@ -3,9 +3,12 @@ package kubernetes
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"sync"
 
 	authorizationv1 "k8s.io/api/authorization/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -13,26 +16,68 @@ import (
 // using SelfSubjectAccessReview API
 // Uses the stored REST config which includes the user's OIDC token
 func (c *Client) CheckPermission(ctx context.Context, apiGroup, resource, verb, namespace, name string) (bool, error) {
-	if c.config == nil {
-		return false, fmt.Errorf("REST config is nil - client was not properly initialized")
+	clientset, err := c.userClientset()
+	if err != nil {
+		return false, err
+	}
+	return reviewAccess(ctx, clientset, authorizationv1.ResourceAttributes{
+		Group:     apiGroup,
+		Namespace: namespace,
+		Verb:      verb,
+		Resource:  resource,
+		Name:      name,
+	})
+}
+
+// CheckPodLogPermission checks whether the current user may read the logs of pods in namespace
+func (c *Client) CheckPodLogPermission(ctx context.Context, namespace string) (bool, error) {
+	clientset, err := c.userClientset()
+	if err != nil {
+		return false, err
+	}
+	return reviewAccess(ctx, clientset, authorizationv1.ResourceAttributes{
+		Namespace:   namespace,
+		Verb:        "get",
+		Resource:    "pods",
+		Subresource: "log",
+	})
+}
+
+// CheckNonResourcePermission checks if the current user may perform verb on a non-resource URL,
+// which RBAC grants with nonResourceURLs rules
+func (c *Client) CheckNonResourcePermission(ctx context.Context, path, verb string) (bool, error) {
+	clientset, err := c.userClientset()
+	if err != nil {
+		return false, err
+	}
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			NonResourceAttributes: &authorizationv1.NonResourceAttributes{Path: path, Verb: verb},
+		},
+	}
+	result, err := clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to create SelfSubjectAccessReview: %w", err)
 	}
+	return result.Status.Allowed, nil
+}
 
-	// Create clientset using the stored config (which includes the OIDC token)
+// userClientset creates a clientset using the stored config (which includes the OIDC token)
+func (c *Client) userClientset() (*kubernetes.Clientset, error) {
+	if c.config == nil {
+		return nil, fmt.Errorf("REST config is nil - client was not properly initialized")
+	}
 	clientset, err := kubernetes.NewForConfig(c.config)
 	if err != nil {
-		return false, fmt.Errorf("failed to create clientset: %w", err)
+		return nil, fmt.Errorf("failed to create clientset: %w", err)
 	}
+	return clientset, nil
+}
 
-	// Create SelfSubjectAccessReview
+func reviewAccess(ctx context.Context, clientset *kubernetes.Clientset, attributes authorizationv1.ResourceAttributes) (bool, error) {
 	review := &authorizationv1.SelfSubjectAccessReview{
 		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
-			ResourceAttributes: &authorizationv1.ResourceAttributes{
-				Group:     apiGroup,
-				Namespace: namespace,
-				Verb:      verb,
-				Resource:  resource,
-				Name:      name,
-			},
+			ResourceAttributes: &attributes,
 		},
 	}
 
@@ -49,3 +94,48 @@ func (c *Client) CheckRolloutPermission(ctx context.Context, verb, namespace, na
 	// Rollout resource in the kuberik.com API group
 	return c.CheckPermission(ctx, "kuberik.com", "rollouts", verb, namespace, name)
 }
+
+// maxConcurrentAccessReviews bounds the SelfSubjectAccessReviews sent at once for batch checks
+const maxConcurrentAccessReviews = 8
+
+// CheckRolloutPermissions checks a verb on several rollouts at once and returns the rollouts the
+// current user may not act on. A review that fails counts as denied, so callers never offer an
+// action that could fail halfway through.
+func (c *Client) CheckRolloutPermissions(ctx context.Context, verb string, rollouts []types.NamespacedName) ([]types.NamespacedName, error) {
+	clientset, err := c.userClientset()
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := make([]bool, len(rollouts))
+	sem := make(chan struct{}, maxConcurrentAccessReviews)
+	var wg sync.WaitGroup
+	for i, rollout := range rollouts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			ok, err := reviewAccess(ctx, clientset, authorizationv1.ResourceAttributes{
+				Group:     "kuberik.com",
+				Resource:  "rollouts",
+				Verb:      verb,
+				Namespace: rollout.Namespace,
+				Name:      rollout.Name,
+			})
+			if err != nil {
+				slog.Warn("Failed to check rollout permission", "namespace", rollout.Namespace, "rollout", rollout.Name, "error", err)
+			}
+			allowed[i] = ok
+		}()
+	}
+	wg.Wait()
+
+	denied := []types.NamespacedName{}
+	for i, rollout := range rollouts {
+		if !allowed[i] {
+			denied = append(denied, rollout)
+		}
+	}
+	return denied, nil
+}
@@ -0,0 +1,62 @@
+package kubernetes
+
+import (
+	"testing"
+
+	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+)
+
+func testEnvironment(namespace, name, environment, rollout string) envv1alpha1.Environment {
+	return envv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name + "-" + environment},
+		Spec: envv1alpha1.EnvironmentSpec{
+			Name:        name,
+			Environment: environment,
+			RolloutRef:  corev1.LocalObjectReference{Name: rollout},
+		},
+	}
+}
+
+func TestEnvironmentRollouts(t *testing.T) {
+	environments := []envv1alpha1.Environment{
+		testEnvironment("prod-eu", "shop", "production", "shop"),
+		testEnvironment("prod-us", "shop", "production", "shop"),
+		testEnvironment("prod-us", "shop", "production", "shop"),
+		testEnvironment("prod-us", "billing", "production", "billing"),
+		testEnvironment("staging", "shop", "staging", "shop"),
+		testEnvironment("prod-us", "orphan", "production", ""),
+	}
+
+	assert.Equal(t, []types.NamespacedName{
+		{Namespace: "prod-eu", Name: "shop"},
+		{Namespace: "prod-us", Name: "billing"},
+		{Namespace: "prod-us", Name: "shop"},
+	}, EnvironmentRollouts(environments, "production", ""))
+
+	assert.Equal(t, []types.NamespacedName{
+		{Namespace: "prod-eu", Name: "shop"},
+		{Namespace: "prod-us", Name: "shop"},
+	}, EnvironmentRollouts(environments, "production", "shop"))
+
+	assert.Empty(t, EnvironmentRollouts(environments, "qa", ""))
+}
+
+func TestCredentialKey_Impersonation(t *testing.T) {
+	impersonating := func(user string, groups ...string) *Client {
+		return &Client{config: &rest.Config{
+			BearerToken: "service-account-token",
+			Impersonate: rest.ImpersonationConfig{UserName: user, Groups: groups},
+		}}
+	}
+
+	// Impersonated users share the service account token but must not share cached results
+	assert.NotEqual(t, impersonating("jane").CredentialKey(), impersonating("joe").CredentialKey())
+	assert.NotEqual(t, impersonating("jane").CredentialKey(), impersonating("jane", "admins").CredentialKey())
+	assert.Equal(t, impersonating("jane", "a", "b").CredentialKey(), impersonating("jane", "b", "a").CredentialKey())
+	assert.NotEqual(t, impersonating("").CredentialKey(), impersonating("jane").CredentialKey())
+}
@@ -0,0 +1,33 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrPodNotControlled is returned when restarting a pod that no controller would recreate
+var ErrPodNotControlled = errors.New("pod is not managed by a controller and would not be recreated")
+
+// RestartPod deletes a pod so that its controller (ReplicaSet, Job, ...) replaces it. Pods
+// without a controlling owner are refused, as deleting them would remove them for good.
+// It returns the deleted pod.
+func (c *Client) RestartPod(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+	pod := &corev1.Pod{}
+	if err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, pod); err != nil {
+		return nil, fmt.Errorf("failed to get pod: %w", err)
+	}
+	if metav1.GetControllerOf(pod) == nil {
+		return nil, ErrPodNotControlled
+	}
+
+	// The UID precondition avoids deleting a replacement that reuses the name (e.g. StatefulSet pods)
+	if err := c.client.Delete(ctx, pod, client.Preconditions{UID: &pod.UID}); err != nil {
+		return nil, fmt.Errorf("failed to delete pod: %w", err)
+	}
+	return pod, nil
+}
@@ -0,0 +1,38 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRestartPod(t *testing.T) {
+	controlled := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace:       "apps",
+		Name:            "app-abc",
+		UID:             "uid-1",
+		OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "app-5d8f", Controller: ptr.To(true)}},
+	}}
+	bare := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "debug"}}
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	cli := &Client{client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(controlled, bare).Build()}
+
+	deleted, err := cli.RestartPod(context.Background(), "apps", "app-abc")
+	require.NoError(t, err)
+	require.Equal(t, "app-abc", deleted.Name)
+	err = cli.client.Get(context.Background(), client.ObjectKey{Namespace: "apps", Name: "app-abc"}, &corev1.Pod{})
+	require.True(t, apierrors.IsNotFound(err))
+
+	_, err = cli.RestartPod(context.Background(), "apps", "debug")
+	require.ErrorIs(t, err, ErrPodNotControlled)
+	require.NoError(t, cli.client.Get(context.Background(), client.ObjectKey{Namespace: "apps", Name: "debug"}, &corev1.Pod{}))
+}
@@ -0,0 +1,473 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	kruiserolloutv1beta1 "github.com/openkruise/kruise-rollout-api/rollouts/v1beta1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Progressive delivery providers driving the steps of a rollout's workload
+const (
+	ProviderKruise = "kruise"
+	ProviderArgo   = "argo"
+)
+
+// Strategies of a progressive rollout
+const (
+	StrategyCanary    = "canary"
+	StrategyBlueGreen = "blueGreen"
+)
+
+var (
+	// ErrNoProgressiveRollout is returned when neither a Kruise nor an Argo rollout is named like
+	// the rollout, or their CRDs are not installed
+	ErrNoProgressiveRollout = errors.New("no Kruise or Argo rollout found")
+	// ErrActionUnsupported is returned for actions the provider or the rollout's strategy lacks
+	ErrActionUnsupported = errors.New("action is not supported by the rollout's progressive delivery provider")
+	// ErrStepOutOfRange is returned when jumping to a step the rollout does not have
+	ErrStepOutOfRange = errors.New("step is out of range")
+)
+
+var (
+	argoRolloutGVK     = schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"}
+	argoAnalysisRunGVK = schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "AnalysisRun"}
+)
+
+// argoAnalysisRunUIDLabel is set by Argo Rollouts on the Jobs of an AnalysisRun's job metrics
+const argoAnalysisRunUIDLabel = "analysisrun.argoproj.io/uid"
+
+// ProgressiveRollout is the state of a Kruise or Argo rollout in terms shared by both
+type ProgressiveRollout struct {
+	Provider  string `json:"provider"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Strategy  string `json:"strategy"`
+	// CurrentStep is the 1-based step being executed, 0 before the first one
+	CurrentStep int32 `json:"currentStep"`
+	Steps       int32 `json:"steps"`
+	// Paused reports a pause requested by a user, Waiting a step waiting to be continued
+	Paused  bool   `json:"paused"`
+	Waiting bool   `json:"waiting"`
+	Aborted bool   `json:"aborted"`
+	Phase   string `json:"phase,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// ProgressiveDeliveryProvider drives the canary or blue/green steps of the workload deployed by a
+// rollout. The progressive rollout is named like the rollout.
+type ProgressiveDeliveryProvider interface {
+	// Name returns the provider, ProviderKruise or ProviderArgo
+	Name() string
+	// Get returns the progressive rollout, or ErrNoProgressiveRollout when the provider's CRD is
+	// not installed or the rollout does not exist
+	Get(ctx context.Context, namespace, name string) (*ProgressiveRollout, error)
+	// Continue moves a paused or waiting rollout on to its next step
+	Continue(ctx context.Context, namespace, name string) error
+	// Pause stops the rollout on its current step until it is continued
+	Pause(ctx context.Context, namespace, name string) error
+	// Abort moves traffic back to the stable version
+	Abort(ctx context.Context, namespace, name string) error
+	// SetStep jumps to the 1-based step
+	SetStep(ctx context.Context, namespace, name string, step int32) error
+	// Pods lists the pods of the rollout's workload
+	Pods(ctx context.Context, namespace, name string) ([]corev1.Pod, error)
+	// TestJobs returns the names of the Jobs testing the rollout
+	TestJobs(ctx context.Context, namespace, name string) ([]string, error)
+}
+
+// ProgressiveDeliveryProviders returns the supported providers, Kruise first
+func (c *Client) ProgressiveDeliveryProviders() []ProgressiveDeliveryProvider {
+	return []ProgressiveDeliveryProvider{kruiseProvider{c: c}, argoProvider{c: c}}
+}
+
+// GetProgressiveRollout returns the provider driving the rollout and the state of its progressive
+// rollout. Providers whose CRDs are not installed are skipped, so clusters with either Kruise or
+// Argo Rollouts get the same actions. It returns ErrNoProgressiveRollout when none drives it.
+func (c *Client) GetProgressiveRollout(ctx context.Context, namespace, name string) (ProgressiveDeliveryProvider, *ProgressiveRollout, error) {
+	for _, provider := range c.ProgressiveDeliveryProviders() {
+		rollout, err := provider.Get(ctx, namespace, name)
+		if errors.Is(err, ErrNoProgressiveRollout) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return provider, rollout, nil
+	}
+	return nil, nil, fmt.Errorf("%w named %s/%s", ErrNoProgressiveRollout, namespace, name)
+}
+
+// notInstalledOrFound reports whether err means the object or its CRD does not exist
+func notInstalledOrFound(err error) bool {
+	return apierrors.IsNotFound(err) || meta.IsNoMatchError(err)
+}
+
+// kruiseProvider drives OpenKruise rollouts
+type kruiseProvider struct {
+	c *Client
+}
+
+func (p kruiseProvider) Name() string { return ProviderKruise }
+
+func (p kruiseProvider) get(ctx context.Context, namespace, name string) (*kruiserolloutv1beta1.Rollout, error) {
+	rollout := &kruiserolloutv1beta1.Rollout{}
+	if err := p.c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, rollout); err != nil {
+		if notInstalledOrFound(err) {
+			return nil, ErrNoProgressiveRollout
+		}
+		return nil, fmt.Errorf("failed to get kruise rollout: %w", err)
+	}
+	return rollout, nil
+}
+
+func (p kruiseProvider) Get(ctx context.Context, namespace, name string) (*ProgressiveRollout, error) {
+	rollout, err := p.get(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	out := &ProgressiveRollout{
+		Provider:  ProviderKruise,
+		Namespace: rollout.Namespace,
+		Name:      rollout.Name,
+		Strategy:  StrategyCanary,
+		Paused:    rollout.Spec.Strategy.Paused,
+		Phase:     string(rollout.Status.Phase),
+		Message:   rollout.Status.Message,
+	}
+	var status *kruiserolloutv1beta1.CommonStatus
+	if IsBlueGreen(rollout) {
+		out.Strategy = StrategyBlueGreen
+		out.Steps = int32(len(rollout.Spec.Strategy.BlueGreen.Steps))
+		if rollout.Status.BlueGreenStatus != nil {
+			status = &rollout.Status.BlueGreenStatus.CommonStatus
+		}
+	} else if rollout.Spec.Strategy.Canary != nil {
+		out.Steps = int32(len(rollout.Spec.Strategy.Canary.Steps))
+		if rollout.Status.CanaryStatus != nil {
+			status = &rollout.Status.CanaryStatus.CommonStatus
+		}
+	}
+	if status != nil {
+		out.CurrentStep = status.CurrentStepIndex
+		out.Waiting = status.CurrentStepState == kruiserolloutv1beta1.CanaryStepStatePaused
+	}
+	return out, nil
+}
+
+// Continue unpauses the rollout and marks its current step ready
+func (p kruiseProvider) Continue(ctx context.Context, namespace, name string) error {
+	rollout, err := p.get(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+	if rollout.Spec.Strategy.Paused {
+		if err := p.setPaused(ctx, namespace, name, false); err != nil {
+			return err
+		}
+	}
+	_, err = p.c.ContinueKruiseRollout(ctx, namespace, name)
+	return err
+}
+
+func (p kruiseProvider) Pause(ctx context.Context, namespace, name string) error {
+	if _, err := p.get(ctx, namespace, name); err != nil {
+		return err
+	}
+	return p.setPaused(ctx, namespace, name, true)
+}
+
+func (p kruiseProvider) setPaused(ctx context.Context, namespace, name string, paused bool) error {
+	patch := &unstructured.Unstructured{}
+	patch.SetGroupVersionKind(kruiserolloutv1beta1.GroupVersion.WithKind("Rollout"))
+	patch.SetNamespace(namespace)
+	patch.SetName(name)
+	if err := unstructured.SetNestedField(patch.Object, paused, "spec", "strategy", "paused"); err != nil {
+		return err
+	}
+	if err := p.c.client.Patch(ctx, patch, client.Merge, client.FieldOwner("rollout-dashboard")); err != nil {
+		return fmt.Errorf("failed to patch kruise rollout: %w", err)
+	}
+	return nil
+}
+
+// Abort is not supported, Kruise rolls back when the workload is reverted
+func (p kruiseProvider) Abort(ctx context.Context, namespace, name string) error {
+	if _, err := p.get(ctx, namespace, name); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: kruise rollouts are rolled back by reverting the workload", ErrActionUnsupported)
+}
+
+// SetStep patches the next step index, which Kruise allows to jump between steps, and marks the
+// current step ready so the jump happens right away
+func (p kruiseProvider) SetStep(ctx context.Context, namespace, name string, step int32) error {
+	current, err := p.Get(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+	if step < 1 || step > current.Steps {
+		return fmt.Errorf("%w: rollout has %d steps", ErrStepOutOfRange, current.Steps)
+	}
+	statusField := "canaryStatus"
+	if current.Strategy == StrategyBlueGreen {
+		statusField = "blueGreenStatus"
+	}
+	patch := &unstructured.Unstructured{}
+	patch.SetGroupVersionKind(kruiserolloutv1beta1.GroupVersion.WithKind("Rollout"))
+	patch.SetNamespace(namespace)
+	patch.SetName(name)
+	patch.Object["status"] = map[string]any{
+		statusField: map[string]any{
+			"nextStepIndex":    int64(step),
+			"currentStepState": string(kruiserolloutv1beta1.CanaryStepStateReady),
+		},
+	}
+	if err := p.c.client.Status().Patch(ctx, patch, client.Merge, client.FieldOwner("rollout-dashboard")); err != nil {
+		return fmt.Errorf("failed to set step of kruise rollout: %w", err)
+	}
+	return nil
+}
+
+func (p kruiseProvider) Pods(ctx context.Context, namespace, name string) ([]corev1.Pod, error) {
+	rollout, err := p.get(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return p.c.GetKruiseWorkloadPods(ctx, rollout)
+}
+
+// TestJobs returns the Jobs of the RolloutTests of the rollout. Tests whose app label the rollout
+// name contains are included too, e.g. hello-world tests of the hello-world-app rollout.
+func (p kruiseProvider) TestJobs(ctx context.Context, namespace, name string) ([]string, error) {
+	rolloutTests, err := p.c.GetRolloutTests(ctx, namespace)
+	if meta.IsNoMatchError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var jobs []string
+	for _, rt := range rolloutTests.Items {
+		appLabel := rt.Labels["app"]
+		if rt.Spec.RolloutName != name && (appLabel == "" || !strings.Contains(name, appLabel)) {
+			continue
+		}
+		if rt.Status.JobName != "" {
+			jobs = append(jobs, rt.Status.JobName)
+		}
+	}
+	return jobs, nil
+}
+
+// argoProvider drives Argo Rollouts. The Rollouts are read as unstructured objects, so the Argo
+// API module is not needed.
+type argoProvider struct {
+	c *Client
+}
+
+func (p argoProvider) Name() string { return ProviderArgo }
+
+func (p argoProvider) get(ctx context.Context, namespace, name string) (*unstructured.Unstructured, error) {
+	rollout := &unstructured.Unstructured{}
+	rollout.SetGroupVersionKind(argoRolloutGVK)
+	if err := p.c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, rollout); err != nil {
+		if notInstalledOrFound(err) {
+			return nil, ErrNoProgressiveRollout
+		}
+		return nil, fmt.Errorf("failed to get argo rollout: %w", err)
+	}
+	return rollout, nil
+}
+
+func (p argoProvider) Get(ctx context.Context, namespace, name string) (*ProgressiveRollout, error) {
+	rollout, err := p.get(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return newArgoProgressiveRollout(rollout), nil
+}
+
+func newArgoProgressiveRollout(rollout *unstructured.Unstructured) *ProgressiveRollout {
+	out := &ProgressiveRollout{
+		Provider:  ProviderArgo,
+		Namespace: rollout.GetNamespace(),
+		Name:      rollout.GetName(),
+		Strategy:  StrategyCanary,
+	}
+	if _, ok, _ := unstructured.NestedMap(rollout.Object, "spec", "strategy", "blueGreen"); ok {
+		out.Strategy = StrategyBlueGreen
+	}
+	steps, _, _ := unstructured.NestedSlice(rollout.Object, "spec", "strategy", "canary", "steps")
+	out.Steps = int32(len(steps))
+	// Argo counts steps from 0 and points past the last one once all steps are done
+	if index, ok, _ := unstructured.NestedInt64(rollout.Object, "status", "currentStepIndex"); ok && out.Steps > 0 {
+		out.CurrentStep = min(int32(index)+1, out.Steps)
+	}
+	out.Paused, _, _ = unstructured.NestedBool(rollout.Object, "spec", "paused")
+	pauseConditions, _, _ := unstructured.NestedSlice(rollout.Object, "status", "pauseConditions")
+	out.Waiting = len(pauseConditions) > 0
+	out.Aborted, _, _ = unstructured.NestedBool(rollout.Object, "status", "abort")
+	out.Phase, _, _ = unstructured.NestedString(rollout.Object, "status", "phase")
+	out.Message, _, _ = unstructured.NestedString(rollout.Object, "status", "message")
+	return out
+}
+
+// Continue promotes the rollout like kubectl argo rollouts promote: it unpauses the rollout and
+// clears its pause conditions, or skips the current canary step when nothing is paused
+func (p argoProvider) Continue(ctx context.Context, namespace, name string) error {
+	rollout, err := p.get(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+	current := newArgoProgressiveRollout(rollout)
+	if current.Paused {
+		if err := p.patch(ctx, namespace, name, false, map[string]any{"spec": map[string]any{"paused": false}}); err != nil {
+			return err
+		}
+	}
+	switch {
+	case current.Waiting:
+		return p.patch(ctx, namespace, name, true, map[string]any{"status": map[string]any{"pauseConditions": nil}})
+	case !current.Paused && current.Strategy == StrategyCanary && current.CurrentStep < current.Steps:
+		index, _, _ := unstructured.NestedInt64(rollout.Object, "status", "currentStepIndex")
+		return p.patch(ctx, namespace, name, true, map[string]any{"status": map[string]any{"currentStepIndex": index + 1}})
+	}
+	return nil
+}
+
+func (p argoProvider) Pause(ctx context.Context, namespace, name string) error {
+	if _, err := p.get(ctx, namespace, name); err != nil {
+		return err
+	}
+	return p.patch(ctx, namespace, name, false, map[string]any{"spec": map[string]any{"paused": true}})
+}
+
+func (p argoProvider) Abort(ctx context.Context, namespace, name string) error {
+	if _, err := p.get(ctx, namespace, name); err != nil {
+		return err
+	}
+	return p.patch(ctx, namespace, name, true, map[string]any{"status": map[string]any{"abort": true}})
+}
+
+// SetStep sets the current step index of a canary rollout and clears its pause conditions
+func (p argoProvider) SetStep(ctx context.Context, namespace, name string, step int32) error {
+	current, err := p.Get(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+	if current.Strategy != StrategyCanary {
+		return fmt.Errorf("%w: argo blue/green rollouts have no steps", ErrActionUnsupported)
+	}
+	if step < 1 || step > current.Steps {
+		return fmt.Errorf("%w: rollout has %d steps", ErrStepOutOfRange, current.Steps)
+	}
+	return p.patch(ctx, namespace, name, true, map[string]any{"status": map[string]any{
+		"currentStepIndex": int64(step - 1),
+		"pauseConditions":  nil,
+	}})
+}
+
+// patch merge-patches the Argo rollout, or its status
+func (p argoProvider) patch(ctx context.Context, namespace, name string, status bool, fields map[string]any) error {
+	patch := &unstructured.Unstructured{Object: fields}
+	patch.SetGroupVersionKind(argoRolloutGVK)
+	patch.SetNamespace(namespace)
+	patch.SetName(name)
+	var err error
+	if status {
+		err = p.c.client.Status().Patch(ctx, patch, client.Merge, client.FieldOwner("rollout-dashboard"))
+	} else {
+		err = p.c.client.Patch(ctx, patch, client.Merge, client.FieldOwner("rollout-dashboard"))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to patch argo rollout: %w", err)
+	}
+	return nil
+}
+
+// Pods lists the pods selected by the rollout, or by the workload it references
+func (p argoProvider) Pods(ctx context.Context, namespace, name string) ([]corev1.Pod, error) {
+	rollout, err := p.get(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	selector, err := argoRolloutSelector(rollout)
+	if err != nil {
+		return nil, err
+	}
+	if selector == nil {
+		ref, _, _ := unstructured.NestedStringMap(rollout.Object, "spec", "workloadRef")
+		if ref["kind"] == "" || ref["name"] == "" {
+			return nil, fmt.Errorf("argo rollout %s has no selector", name)
+		}
+		workload := &unstructured.Unstructured{}
+		workload.SetGroupVersionKind(schema.FromAPIVersionAndKind(ref["apiVersion"], ref["kind"]))
+		if err := p.c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref["name"]}, workload); err != nil {
+			return nil, fmt.Errorf("failed to get workload %s %s: %w", ref["kind"], ref["name"], err)
+		}
+		if selector, err = argoRolloutSelector(workload); err != nil {
+			return nil, err
+		}
+		if selector == nil {
+			return nil, fmt.Errorf("workload %s %s has no selector", ref["kind"], ref["name"])
+		}
+	}
+	pods, err := p.c.GetPodsBySelector(ctx, namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+// argoRolloutSelector returns the spec.selector of an Argo rollout or a workload, nil when unset
+func argoRolloutSelector(obj *unstructured.Unstructured) (labels.Selector, error) {
+	raw, ok, _ := unstructured.NestedMap(obj.Object, "spec", "selector")
+	if !ok {
+		return nil, nil
+	}
+	var selector metav1.LabelSelector
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &selector); err != nil {
+		return nil, fmt.Errorf("invalid selector of %s %s: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	return metav1.LabelSelectorAsSelector(&selector)
+}
+
+// TestJobs returns the Jobs run by the job metrics of the AnalysisRuns the rollout owns
+func (p argoProvider) TestJobs(ctx context.Context, namespace, name string) ([]string, error) {
+	analysisRuns := &unstructured.UnstructuredList{}
+	analysisRuns.SetGroupVersionKind(argoAnalysisRunGVK.GroupVersion().WithKind(argoAnalysisRunGVK.Kind + "List"))
+	if err := p.c.client.List(ctx, analysisRuns, client.InNamespace(namespace)); err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list analysis runs: %w", err)
+	}
+	var jobs []string
+	for _, run := range analysisRuns.Items {
+		owner := metav1.GetControllerOf(&run)
+		if owner == nil || owner.Kind != argoRolloutGVK.Kind || owner.Name != name {
+			continue
+		}
+		jobList := &batchv1.JobList{}
+		if err := p.c.client.List(ctx, jobList, client.InNamespace(namespace), client.MatchingLabels{argoAnalysisRunUIDLabel: string(run.GetUID())}); err != nil {
+			return nil, fmt.Errorf("failed to list jobs of analysis run %s: %w", run.GetName(), err)
+		}
+		for _, job := range jobList.Items {
+			jobs = append(jobs, job.Name)
+		}
+	}
+	return jobs, nil
+}
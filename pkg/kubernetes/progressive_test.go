@@ -0,0 +1,215 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	kruiserolloutv1beta1 "github.com/openkruise/kruise-rollout-api/rollouts/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func argoRollout(fields map[string]any) *unstructured.Unstructured {
+	rollout := &unstructured.Unstructured{Object: fields}
+	rollout.SetGroupVersionKind(argoRolloutGVK)
+	rollout.SetNamespace("ns")
+	rollout.SetName("app")
+	rollout.SetUID("rollout-uid")
+	return rollout
+}
+
+// newArgoTestClient serves Argo Rollouts and, when kruise is set, the Kruise CRDs. Like the
+// dashboard's scheme, the client always knows the Kruise types.
+func newArgoTestClient(t *testing.T, kruise bool, initial ...client.Object) *Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kruiserolloutv1beta1.AddToScheme(scheme))
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for gvk := range clientgoscheme.Scheme.AllKnownTypes() {
+		mapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+	mapper.Add(argoRolloutGVK, meta.RESTScopeNamespace)
+	mapper.Add(argoAnalysisRunGVK, meta.RESTScopeNamespace)
+	if kruise {
+		mapper.Add(kruiserolloutv1beta1.GroupVersion.WithKind("Rollout"), meta.RESTScopeNamespace)
+	}
+	statusObject := &unstructured.Unstructured{}
+	statusObject.SetGroupVersionKind(argoRolloutGVK)
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithRESTMapper(mapper).
+		WithObjects(initial...).
+		WithStatusSubresource(statusObject).
+		Build()
+	return &Client{client: c}
+}
+
+func getArgoRollout(t *testing.T, c *Client) *unstructured.Unstructured {
+	t.Helper()
+	rollout, err := argoProvider{c: c}.get(context.Background(), "ns", "app")
+	require.NoError(t, err)
+	return rollout
+}
+
+func TestGetProgressiveRolloutArgo(t *testing.T) {
+	c := newArgoTestClient(t, false, argoRollout(map[string]any{
+		"spec": map[string]any{
+			"strategy": map[string]any{"canary": map[string]any{"steps": []any{
+				map[string]any{"setWeight": int64(20)},
+				map[string]any{"pause": map[string]any{}},
+				map[string]any{"setWeight": int64(100)},
+			}}},
+		},
+		"status": map[string]any{
+			"currentStepIndex": int64(1),
+			"pauseConditions":  []any{map[string]any{"reason": "CanaryPauseStep"}},
+			"phase":            "Paused",
+		},
+	}))
+
+	// Without the Kruise CRDs the Argo rollout is picked
+	provider, progressive, err := c.GetProgressiveRollout(context.Background(), "ns", "app")
+	require.NoError(t, err)
+	assert.Equal(t, ProviderArgo, provider.Name())
+	assert.Equal(t, &ProgressiveRollout{
+		Provider: ProviderArgo, Namespace: "ns", Name: "app", Strategy: StrategyCanary,
+		CurrentStep: 2, Steps: 3, Waiting: true, Phase: "Paused",
+	}, progressive)
+
+	_, _, err = c.GetProgressiveRollout(context.Background(), "ns", "missing")
+	assert.ErrorIs(t, err, ErrNoProgressiveRollout)
+}
+
+func TestGetProgressiveRolloutPrefersKruise(t *testing.T) {
+	c := newArgoTestClient(t, true,
+		argoRollout(map[string]any{}),
+		&kruiserolloutv1beta1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "app"}},
+	)
+	provider, _, err := c.GetProgressiveRollout(context.Background(), "ns", "app")
+	require.NoError(t, err)
+	assert.Equal(t, ProviderKruise, provider.Name())
+}
+
+func TestArgoProviderActions(t *testing.T) {
+	ctx := context.Background()
+	c := newArgoTestClient(t, false, argoRollout(map[string]any{
+		"spec": map[string]any{
+			"paused": true,
+			"strategy": map[string]any{"canary": map[string]any{"steps": []any{
+				map[string]any{"setWeight": int64(20)},
+				map[string]any{"setWeight": int64(50)},
+				map[string]any{"setWeight": int64(100)},
+			}}},
+		},
+		"status": map[string]any{
+			"currentStepIndex": int64(0),
+			"pauseConditions":  []any{map[string]any{"reason": "PausedByUser"}},
+		},
+	}))
+	provider := argoProvider{c: c}
+
+	// Continuing unpauses and clears the pause conditions, without skipping a step
+	require.NoError(t, provider.Continue(ctx, "ns", "app"))
+	progressive := newArgoProgressiveRollout(getArgoRollout(t, c))
+	assert.False(t, progressive.Paused)
+	assert.False(t, progressive.Waiting)
+	assert.Equal(t, int32(1), progressive.CurrentStep)
+
+	// With nothing paused, continuing skips the current step
+	require.NoError(t, provider.Continue(ctx, "ns", "app"))
+	assert.Equal(t, int32(2), newArgoProgressiveRollout(getArgoRollout(t, c)).CurrentStep)
+
+	require.NoError(t, provider.Pause(ctx, "ns", "app"))
+	assert.True(t, newArgoProgressiveRollout(getArgoRollout(t, c)).Paused)
+
+	require.NoError(t, provider.SetStep(ctx, "ns", "app", 3))
+	index, _, _ := unstructured.NestedInt64(getArgoRollout(t, c).Object, "status", "currentStepIndex")
+	assert.Equal(t, int64(2), index)
+	assert.ErrorIs(t, provider.SetStep(ctx, "ns", "app", 4), ErrStepOutOfRange)
+
+	require.NoError(t, provider.Abort(ctx, "ns", "app"))
+	assert.True(t, newArgoProgressiveRollout(getArgoRollout(t, c)).Aborted)
+}
+
+func TestArgoProviderBlueGreenHasNoSteps(t *testing.T) {
+	c := newArgoTestClient(t, false, argoRollout(map[string]any{
+		"spec": map[string]any{"strategy": map[string]any{"blueGreen": map[string]any{"activeService": "app"}}},
+	}))
+	err := argoProvider{c: c}.SetStep(context.Background(), "ns", "app", 1)
+	assert.ErrorIs(t, err, ErrActionUnsupported)
+}
+
+func TestArgoProviderPodsAndTestJobs(t *testing.T) {
+	analysisRun := &unstructured.Unstructured{}
+	analysisRun.SetGroupVersionKind(argoAnalysisRunGVK)
+	analysisRun.SetNamespace("ns")
+	analysisRun.SetName("app-smoke")
+	analysisRun.SetUID("run-uid")
+	analysisRun.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout", Name: "app", UID: "rollout-uid", Controller: ptr.To(true)}})
+
+	c := newArgoTestClient(t, false,
+		argoRollout(map[string]any{"spec": map[string]any{"selector": map[string]any{"matchLabels": map[string]any{"app": "web"}}}}),
+		analysisRun,
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "smoke-1", Labels: map[string]string{argoAnalysisRunUIDLabel: "run-uid"}}},
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "other", Labels: map[string]string{argoAnalysisRunUIDLabel: "other-uid"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "web-1", Labels: map[string]string{"app": "web"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "api-1", Labels: map[string]string{"app": "api"}}},
+	)
+	provider := argoProvider{c: c}
+
+	pods, err := provider.Pods(context.Background(), "ns", "app")
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	assert.Equal(t, "web-1", pods[0].Name)
+
+	jobs, err := provider.TestJobs(context.Background(), "ns", "app")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"smoke-1"}, jobs)
+
+	jobs, err = provider.TestJobs(context.Background(), "ns", "other")
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+}
+
+func TestKruiseProviderActions(t *testing.T) {
+	ctx := context.Background()
+	rollout := blueGreenRollout(1, kruiserolloutv1beta1.CanaryStepStatePaused)
+	rollout.Spec.Strategy.Paused = true
+	c := newKruiseTestClient(t, rollout)
+	provider := kruiseProvider{c: c}
+
+	progressive, err := provider.Get(ctx, "ns", "app")
+	require.NoError(t, err)
+	assert.Equal(t, &ProgressiveRollout{
+		Provider: ProviderKruise, Namespace: "ns", Name: "app", Strategy: StrategyBlueGreen,
+		CurrentStep: 1, Steps: 2, Paused: true, Waiting: true,
+	}, progressive)
+
+	// Continuing also lifts a pause requested by a user
+	require.NoError(t, provider.Continue(ctx, "ns", "app"))
+	updated, err := c.GetKruiseRollout(ctx, "ns", "app")
+	require.NoError(t, err)
+	assert.False(t, updated.Spec.Strategy.Paused)
+	assert.Equal(t, kruiserolloutv1beta1.CanaryStepStateReady, updated.Status.BlueGreenStatus.CurrentStepState)
+
+	require.NoError(t, provider.Pause(ctx, "ns", "app"))
+	require.NoError(t, provider.SetStep(ctx, "ns", "app", 2))
+	updated, err = c.GetKruiseRollout(ctx, "ns", "app")
+	require.NoError(t, err)
+	assert.True(t, updated.Spec.Strategy.Paused)
+	assert.Equal(t, int32(2), updated.Status.BlueGreenStatus.NextStepIndex)
+
+	assert.ErrorIs(t, provider.SetStep(ctx, "ns", "app", 3), ErrStepOutOfRange)
+	assert.ErrorIs(t, provider.Abort(ctx, "ns", "app"), ErrActionUnsupported)
+}
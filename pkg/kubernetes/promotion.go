@@ -0,0 +1,184 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PromotionChainAnnotation records on a rollout the environments the version it was promoted to
+// passed through, oldest first, as a JSON list of PromotionHop
+const PromotionChainAnnotation = "rollout.kuberik.com/promotion-chain"
+
+var (
+	// ErrNoSourceEnvironment is returned when neither the request nor the target Environment's
+	// relationship names the environment to promote from
+	ErrNoSourceEnvironment = errors.New("no environment to promote from")
+	// ErrNoHealthyVersion is returned when the source rollout's current deployment has not baked
+	// successfully
+	ErrNoHealthyVersion = errors.New("source rollout has no healthy version")
+)
+
+// PromotionHop is an environment a promoted version was deployed and baked in
+type PromotionHop struct {
+	Environment string `json:"environment"`
+	Namespace   string `json:"namespace"`
+	Rollout     string `json:"rollout"`
+	Version     string `json:"version"`
+}
+
+// Promotion pairs a rollout of the target environment with the rollout of the source environment
+// with the same deployment name (spec.name) it is promoted from
+type Promotion struct {
+	DeploymentName    string
+	SourceEnvironment string
+	Source            types.NamespacedName
+	Target            types.NamespacedName
+	// Err is set when no source could be found
+	Err error
+}
+
+// Promotions returns the promotions into the rollouts of environment, optionally limited to one
+// deployment name. The source environment is from or, when empty, the environment the target
+// Environment's relationship names; the source rollout is preferably in the target's namespace.
+// Promotions are sorted by target.
+func Promotions(environments []envv1alpha1.Environment, environment, from, deploymentName string) []Promotion {
+	var promotions []Promotion
+	seen := map[types.NamespacedName]bool{}
+	for _, env := range environments {
+		if env.Spec.Environment != environment || env.Spec.RolloutRef.Name == "" {
+			continue
+		}
+		if deploymentName != "" && env.Spec.Name != deploymentName {
+			continue
+		}
+		target := types.NamespacedName{Namespace: env.Namespace, Name: env.Spec.RolloutRef.Name}
+		if seen[target] {
+			continue
+		}
+		seen[target] = true
+
+		promotion := Promotion{DeploymentName: env.Spec.Name, SourceEnvironment: from, Target: target}
+		if promotion.SourceEnvironment == "" && env.Spec.Relationship != nil {
+			promotion.SourceEnvironment = env.Spec.Relationship.Environment
+		}
+		if promotion.SourceEnvironment == "" {
+			promotion.Err = ErrNoSourceEnvironment
+		} else if source, ok := promotionSource(environments, promotion.SourceEnvironment, env); ok {
+			promotion.Source = source
+		} else {
+			promotion.Err = fmt.Errorf("no Environment with environment %q and name %q references a rollout", promotion.SourceEnvironment, env.Spec.Name)
+		}
+		promotions = append(promotions, promotion)
+	}
+	sort.Slice(promotions, func(i, j int) bool {
+		return promotions[i].Target.String() < promotions[j].Target.String()
+	})
+	return promotions
+}
+
+func promotionSource(environments []envv1alpha1.Environment, sourceEnvironment string, target envv1alpha1.Environment) (types.NamespacedName, bool) {
+	var source types.NamespacedName
+	found := false
+	for _, env := range environments {
+		if env.Spec.Environment != sourceEnvironment || env.Spec.Name != target.Spec.Name || env.Spec.RolloutRef.Name == "" {
+			continue
+		}
+		candidate := types.NamespacedName{Namespace: env.Namespace, Name: env.Spec.RolloutRef.Name}
+		if env.Namespace == target.Namespace {
+			return candidate, true
+		}
+		if !found || candidate.String() < source.String() {
+			source, found = candidate, true
+		}
+	}
+	return source, found
+}
+
+// HealthyVersion returns the version of the rollout's current deployment if it baked successfully
+func HealthyVersion(rollout *rolloutv1alpha1.Rollout) (string, error) {
+	if len(rollout.Status.History) == 0 {
+		return "", fmt.Errorf("%w: nothing was deployed yet", ErrNoHealthyVersion)
+	}
+	current := rollout.Status.History[0]
+	if current.BakeStatus == nil || *current.BakeStatus != rolloutv1alpha1.BakeStatusSucceeded {
+		return "", fmt.Errorf("%w: %s has not baked successfully", ErrNoHealthyVersion, current.Version.Tag)
+	}
+	return current.Version.Tag, nil
+}
+
+// PromotionChain returns the chain to record when version is promoted from source, deployed in
+// sourceEnvironment: the source's own chain if it was promoted the same version, extended by the
+// source itself
+func PromotionChain(source *rolloutv1alpha1.Rollout, sourceEnvironment, version string) []PromotionHop {
+	var chain []PromotionHop
+	if value := source.Annotations[PromotionChainAnnotation]; value != "" {
+		if err := json.Unmarshal([]byte(value), &chain); err != nil || len(chain) == 0 || chain[len(chain)-1].Version != version {
+			chain = nil
+		}
+	}
+	return append(chain, PromotionHop{
+		Environment: sourceEnvironment,
+		Namespace:   source.Namespace,
+		Rollout:     source.Name,
+		Version:     version,
+	})
+}
+
+// PromoteVersion deploys version to the rollout and records the chain it was promoted along.
+// With pin the version is pinned (wantedVersion), otherwise it is force deployed and the rollout
+// keeps following new releases afterwards.
+func (c *Client) PromoteVersion(ctx context.Context, namespace, name, version string, pin bool, message string, chain []PromotionHop) (*rolloutv1alpha1.Rollout, error) {
+	chainJSON, err := json.Marshal(chain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode promotion chain: %w", err)
+	}
+
+	patch := &unstructured.Unstructured{}
+	patch.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   "kuberik.com",
+		Version: "v1alpha1",
+		Kind:    "Rollout",
+	})
+	patch.SetNamespace(namespace)
+	patch.SetName(name)
+
+	annotations := map[string]string{
+		PromotionChainAnnotation: string(chainJSON),
+	}
+	if message != "" {
+		annotations[DeployMessageAnnotation] = message
+	}
+	if pin {
+		patch.Object["spec"] = map[string]any{
+			"wantedVersion": version,
+		}
+	} else {
+		annotations[ForceDeployAnnotation] = version
+		patch.Object["spec"] = map[string]any{
+			"wantedVersion": nil,
+		}
+	}
+	patch.SetAnnotations(annotations)
+	stopChannelTracking(patch)
+	attributeChange(patch, c.actingUser(ctx), true)
+
+	if err := c.client.Patch(ctx, patch, client.Merge, client.FieldOwner("rollout-dashboard")); err != nil {
+		return nil, fmt.Errorf("failed to promote version: %w", err)
+	}
+
+	updatedRollout := &rolloutv1alpha1.Rollout{}
+	if err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, updatedRollout); err != nil {
+		return nil, fmt.Errorf("failed to get updated rollout: %w", err)
+	}
+	return updatedRollout, nil
+}
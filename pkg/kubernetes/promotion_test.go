@@ -0,0 +1,103 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestPromotions(t *testing.T) {
+	env := func(namespace, name, environment, rollout, after string) envv1alpha1.Environment {
+		e := envv1alpha1.Environment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name + "-" + environment},
+			Spec: envv1alpha1.EnvironmentSpec{
+				Name:        name,
+				Environment: environment,
+				RolloutRef:  corev1.LocalObjectReference{Name: rollout},
+			},
+		}
+		if after != "" {
+			e.Spec.Relationship = &envv1alpha1.EnvironmentRelationship{Environment: after, Type: envv1alpha1.RelationshipTypeAfter}
+		}
+		return e
+	}
+	environments := []envv1alpha1.Environment{
+		env("shop-staging", "api", "staging", "api", ""),
+		env("shop", "api", "staging", "api-staging", ""),
+		env("shop", "api", "production", "api", "staging"),
+		env("shop", "web", "production", "web", ""),
+		env("billing", "billing", "production", "billing", "canary"),
+	}
+
+	promotions := Promotions(environments, "production", "", "")
+	require.Len(t, promotions, 3)
+	assert.Equal(t, types.NamespacedName{Namespace: "billing", Name: "billing"}, promotions[0].Target)
+	assert.Error(t, promotions[0].Err, "no canary Environment")
+	assert.Equal(t, Promotion{
+		DeploymentName:    "api",
+		SourceEnvironment: "staging",
+		Source:            types.NamespacedName{Namespace: "shop", Name: "api-staging"},
+		Target:            types.NamespacedName{Namespace: "shop", Name: "api"},
+	}, promotions[1], "the source in the target's namespace is preferred")
+	assert.ErrorIs(t, promotions[2].Err, ErrNoSourceEnvironment)
+
+	promotions = Promotions(environments, "production", "staging", "web")
+	require.Len(t, promotions, 1)
+	assert.Error(t, promotions[0].Err, "web has no staging Environment")
+}
+
+func TestHealthyVersionAndPromotionChain(t *testing.T) {
+	succeeded := rolloutv1alpha1.BakeStatusSucceeded
+	inProgress := rolloutv1alpha1.BakeStatusInProgress
+	source := &rolloutv1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api-staging"},
+		Status: rolloutv1alpha1.RolloutStatus{History: []rolloutv1alpha1.DeploymentHistoryEntry{
+			{Version: rolloutv1alpha1.VersionInfo{Tag: "v2"}, BakeStatus: &inProgress},
+			{Version: rolloutv1alpha1.VersionInfo{Tag: "v1"}, BakeStatus: &succeeded},
+		}},
+	}
+	_, err := HealthyVersion(source)
+	assert.ErrorIs(t, err, ErrNoHealthyVersion, "the current deployment is still baking")
+
+	source.Status.History = source.Status.History[1:]
+	version, err := HealthyVersion(source)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", version)
+
+	staging := PromotionHop{Environment: "staging", Namespace: "shop", Rollout: "api-staging", Version: "v1"}
+	assert.Equal(t, []PromotionHop{staging}, PromotionChain(source, "staging", "v1"))
+
+	// The chain continues when the source was promoted the same version
+	dev := PromotionHop{Environment: "dev", Namespace: "shop", Rollout: "api-dev", Version: "v1"}
+	chain, err := json.Marshal([]PromotionHop{dev})
+	require.NoError(t, err)
+	source.Annotations = map[string]string{PromotionChainAnnotation: string(chain)}
+	assert.Equal(t, []PromotionHop{dev, staging}, PromotionChain(source, "staging", "v1"))
+	assert.Equal(t, []PromotionHop{{Environment: "staging", Namespace: "shop", Rollout: "api-staging", Version: "v2"}},
+		PromotionChain(source, "staging", "v2"))
+}
+
+func TestPromoteVersion(t *testing.T) {
+	cli := newTestClient(t, &rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api"}})
+	chain := []PromotionHop{{Environment: "staging", Namespace: "shop", Rollout: "api-staging", Version: "v1"}}
+
+	rollout, err := cli.PromoteVersion(context.Background(), "shop", "api", "v1", true, "", chain)
+	require.NoError(t, err)
+	require.NotNil(t, rollout.Spec.WantedVersion)
+	assert.Equal(t, "v1", *rollout.Spec.WantedVersion)
+	assert.JSONEq(t, `[{"environment":"staging","namespace":"shop","rollout":"api-staging","version":"v1"}]`, rollout.Annotations[PromotionChainAnnotation])
+
+	rollout, err = cli.PromoteVersion(context.Background(), "shop", "api", "v1", false, "release", chain)
+	require.NoError(t, err)
+	assert.Nil(t, rollout.Spec.WantedVersion)
+	assert.Equal(t, "v1", rollout.Annotations[ForceDeployAnnotation])
+	assert.Equal(t, "release", rollout.Annotations[DeployMessageAnnotation])
+}
@@ -0,0 +1,259 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Probe types reported in ProbeFailureSummary
+const (
+	ProbeReadiness = "readiness"
+	ProbeLiveness  = "liveness"
+	ProbeStartup   = "startup"
+)
+
+// ReadinessSummary explains why a rollout's pods are not Ready
+type ReadinessSummary struct {
+	TotalPods int `json:"totalPods"`
+	ReadyPods int `json:"readyPods"`
+	// Probes aggregates probe failures across all pods, keyed by probe type
+	Probes map[string]*ProbeFailureSummary `json:"probes"`
+	// NotReady lists the pods that are not Ready, most restarted first
+	NotReady []PodReadiness `json:"notReady"`
+}
+
+// ProbeFailureSummary aggregates failures of one probe type
+type ProbeFailureSummary struct {
+	Failures    int32     `json:"failures"`
+	Pods        []string  `json:"pods"`
+	LastMessage string    `json:"lastMessage"`
+	LastSeen    time.Time `json:"lastSeen"`
+}
+
+// PodReadiness explains why a single pod is not Ready
+type PodReadiness struct {
+	Namespace  string               `json:"namespace"`
+	Name       string               `json:"name"`
+	Phase      string               `json:"phase"`
+	Reasons    []string             `json:"reasons"`
+	Restarts   int32                `json:"restarts"`
+	Containers []ContainerReadiness `json:"containers"`
+}
+
+// ContainerReadiness describes a container that is not ready
+type ContainerReadiness struct {
+	Name                 string `json:"name"`
+	RestartCount         int32  `json:"restartCount"`
+	WaitingReason        string `json:"waitingReason,omitempty"`
+	WaitingMessage       string `json:"waitingMessage,omitempty"`
+	LastTerminatedReason string `json:"lastTerminatedReason,omitempty"`
+	LastTerminatedExit   int32  `json:"lastTerminatedExitCode,omitempty"`
+	LastTerminatedAt     string `json:"lastTerminatedAt,omitempty"`
+}
+
+// GetRolloutPods lists the pods of the Deployments managed by the rollout's Kustomizations
+func (c *Client) GetRolloutPods(ctx context.Context, namespace, rolloutName string) ([]corev1.Pod, error) {
+	kustomizations, err := c.GetKustomizationsByRolloutAnnotation(ctx, namespace, rolloutName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kustomizations: %w", err)
+	}
+
+	seen := map[string]bool{}
+	var pods []corev1.Pod
+	for _, kustomization := range kustomizations.Items {
+		resources, err := c.GetKustomizationManagedResources(ctx, kustomization.Namespace, kustomization.Name)
+		if err != nil {
+			slog.Warn("Failed to get managed resources for kustomization", "kustomization", kustomization.Name, "error", err)
+			continue
+		}
+		for _, resource := range resources {
+			if !strings.Contains(resource.GroupVersionKind, "apps/v1/Deployment") || resource.Object == nil {
+				continue
+			}
+			var deployment appsv1.Deployment
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(resource.Object.Object, &deployment); err != nil {
+				slog.Warn("Error converting deployment", "deployment", resource.Name, "error", err)
+				continue
+			}
+			if deployment.Spec.Selector == nil {
+				continue
+			}
+			selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+			if err != nil {
+				continue
+			}
+			podList := &corev1.PodList{}
+			if err := c.client.List(ctx, podList, client.InNamespace(deployment.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+				return nil, fmt.Errorf("failed to list pods of deployment %s: %w", deployment.Name, err)
+			}
+			for _, pod := range podList.Items {
+				key := pod.Namespace + "/" + pod.Name
+				if !seen[key] {
+					seen[key] = true
+					pods = append(pods, pod)
+				}
+			}
+		}
+	}
+	return pods, nil
+}
+
+// GetRolloutReadiness summarizes probe failures and container states of the rollout's pods
+func (c *Client) GetRolloutReadiness(ctx context.Context, namespace, rolloutName string) (*ReadinessSummary, error) {
+	pods, err := c.GetRolloutPods(ctx, namespace, rolloutName)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []corev1.Event
+	if c.clientset != nil {
+		namespaces := map[string]bool{}
+		for _, pod := range pods {
+			namespaces[pod.Namespace] = true
+		}
+		for ns := range namespaces {
+			eventList, err := c.clientset.CoreV1().Events(ns).List(ctx, metav1.ListOptions{
+				FieldSelector: "involvedObject.kind=Pod,reason=Unhealthy",
+			})
+			if err != nil {
+				slog.Warn("Failed to list probe events", "namespace", ns, "error", err)
+				continue
+			}
+			events = append(events, eventList.Items...)
+		}
+	}
+
+	return SummarizeReadiness(pods, events), nil
+}
+
+// SummarizeReadiness builds a readiness summary from pods and their "Unhealthy" probe events.
+// Events for pods not in the list are ignored.
+func SummarizeReadiness(pods []corev1.Pod, events []corev1.Event) *ReadinessSummary {
+	summary := &ReadinessSummary{
+		TotalPods: len(pods),
+		Probes:    map[string]*ProbeFailureSummary{},
+		NotReady:  []PodReadiness{},
+	}
+
+	podNames := map[string]bool{}
+	for _, pod := range pods {
+		podNames[pod.Namespace+"/"+pod.Name] = true
+		if podReady(&pod) {
+			summary.ReadyPods++
+			continue
+		}
+		summary.NotReady = append(summary.NotReady, newPodReadiness(&pod))
+	}
+	sort.SliceStable(summary.NotReady, func(i, j int) bool {
+		return summary.NotReady[i].Restarts > summary.NotReady[j].Restarts
+	})
+
+	for i := range events {
+		event := &events[i]
+		if event.Reason != "Unhealthy" || !podNames[event.InvolvedObject.Namespace+"/"+event.InvolvedObject.Name] {
+			continue
+		}
+		probe := probeType(event.Message)
+		if probe == "" {
+			continue
+		}
+		failures := summary.Probes[probe]
+		if failures == nil {
+			failures = &ProbeFailureSummary{}
+			summary.Probes[probe] = failures
+		}
+		count := event.Count
+		if count == 0 && event.Series != nil {
+			count = event.Series.Count
+		}
+		if count == 0 {
+			count = 1
+		}
+		failures.Failures += count
+		if !slices.Contains(failures.Pods, event.InvolvedObject.Name) {
+			failures.Pods = append(failures.Pods, event.InvolvedObject.Name)
+		}
+		if seen := eventTime(event); seen.After(failures.LastSeen) {
+			failures.LastSeen = seen
+			failures.LastMessage = event.Message
+		}
+	}
+	return summary
+}
+
+func newPodReadiness(pod *corev1.Pod) PodReadiness {
+	readiness := PodReadiness{
+		Namespace:  pod.Namespace,
+		Name:       pod.Name,
+		Phase:      string(pod.Status.Phase),
+		Reasons:    []string{},
+		Containers: []ContainerReadiness{},
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Status == corev1.ConditionTrue || condition.Reason == "" {
+			continue
+		}
+		reason := string(condition.Type) + ": " + condition.Reason
+		if condition.Message != "" {
+			reason += " (" + condition.Message + ")"
+		}
+		readiness.Reasons = append(readiness.Reasons, reason)
+	}
+
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		readiness.Restarts += status.RestartCount
+		// Init containers are never Ready; they only matter until they complete
+		if status.Ready || (status.State.Terminated != nil && status.State.Terminated.ExitCode == 0) {
+			continue
+		}
+		container := ContainerReadiness{
+			Name:         status.Name,
+			RestartCount: status.RestartCount,
+		}
+		if waiting := status.State.Waiting; waiting != nil {
+			container.WaitingReason = waiting.Reason
+			container.WaitingMessage = waiting.Message
+		}
+		if terminated := status.LastTerminationState.Terminated; terminated != nil {
+			container.LastTerminatedReason = terminated.Reason
+			container.LastTerminatedExit = terminated.ExitCode
+			if !terminated.FinishedAt.IsZero() {
+				container.LastTerminatedAt = terminated.FinishedAt.UTC().Format(time.RFC3339)
+			}
+		}
+		readiness.Containers = append(readiness.Containers, container)
+	}
+	return readiness
+}
+
+func podReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// probeType extracts the probe type from kubelet messages such as "Readiness probe failed: ..."
+func probeType(message string) string {
+	lower := strings.ToLower(message)
+	for _, probe := range []string{ProbeReadiness, ProbeLiveness, ProbeStartup} {
+		if strings.HasPrefix(lower, probe+" probe") {
+			return probe
+		}
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testPod(name string, ready bool, statuses ...corev1.ContainerStatus) corev1.Pod {
+	condition := corev1.PodCondition{Type: corev1.PodReady, Status: corev1.ConditionTrue}
+	if !ready {
+		condition = corev1.PodCondition{Type: corev1.PodReady, Status: corev1.ConditionFalse, Reason: "ContainersNotReady", Message: "containers with unready status: [app]"}
+	}
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			Conditions:        []corev1.PodCondition{condition},
+			ContainerStatuses: statuses,
+		},
+	}
+}
+
+func unhealthyEvent(pod, message string, count int32, at time.Time) corev1.Event {
+	return corev1.Event{
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "ns", Name: pod},
+		Reason:         "Unhealthy",
+		Message:        message,
+		Count:          count,
+		LastTimestamp:  metav1.NewTime(at),
+	}
+}
+
+func TestSummarizeReadiness(t *testing.T) {
+	now := time.Now()
+	pods := []corev1.Pod{
+		testPod("ready", true, corev1.ContainerStatus{Name: "app", Ready: true}),
+		testPod("crashing", false, corev1.ContainerStatus{
+			Name:         "app",
+			RestartCount: 4,
+			State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff", Message: "back-off 40s"}},
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+				Reason: "Error", ExitCode: 137, FinishedAt: metav1.NewTime(now),
+			}},
+		}),
+		testPod("starting", false, corev1.ContainerStatus{Name: "app"}),
+	}
+	events := []corev1.Event{
+		unhealthyEvent("crashing", "Readiness probe failed: HTTP probe failed with statuscode: 503", 3, now.Add(-time.Minute)),
+		unhealthyEvent("starting", "Readiness probe failed: connection refused", 2, now),
+		unhealthyEvent("crashing", "Liveness probe failed: timeout", 1, now),
+		unhealthyEvent("other-pod", "Readiness probe failed: ignored", 10, now),
+	}
+
+	summary := SummarizeReadiness(pods, events)
+
+	assert.Equal(t, 3, summary.TotalPods)
+	assert.Equal(t, 1, summary.ReadyPods)
+	require.Len(t, summary.NotReady, 2)
+	assert.Equal(t, "crashing", summary.NotReady[0].Name, "most restarted pod first")
+	assert.Equal(t, []string{"Ready: ContainersNotReady (containers with unready status: [app])"}, summary.NotReady[0].Reasons)
+	require.Len(t, summary.NotReady[0].Containers, 1)
+	assert.Equal(t, "CrashLoopBackOff", summary.NotReady[0].Containers[0].WaitingReason)
+	assert.Equal(t, int32(137), summary.NotReady[0].Containers[0].LastTerminatedExit)
+
+	readiness := summary.Probes[ProbeReadiness]
+	require.NotNil(t, readiness)
+	assert.Equal(t, int32(5), readiness.Failures)
+	assert.ElementsMatch(t, []string{"crashing", "starting"}, readiness.Pods)
+	assert.Equal(t, "Readiness probe failed: connection refused", readiness.LastMessage)
+
+	require.NotNil(t, summary.Probes[ProbeLiveness])
+	assert.Equal(t, int32(1), summary.Probes[ProbeLiveness].Failures)
+	assert.Nil(t, summary.Probes[ProbeStartup])
+}
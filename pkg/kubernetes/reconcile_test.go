@@ -0,0 +1,180 @@
+package kubernetes
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2"
+	imagereflectorv1beta2 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcileAllFluxResources(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, rolloutv1alpha1.AddToScheme(scheme))
+	require.NoError(t, imagereflectorv1beta2.AddToScheme(scheme))
+	require.NoError(t, kustomizev1.AddToScheme(scheme))
+	require.NoError(t, sourcev1.AddToScheme(scheme))
+	require.NoError(t, helmv2.AddToScheme(scheme))
+
+	// The ImagePolicy points at an ImageRepository that does not exist, so its reconcile fails
+	objects := []client.Object{
+		&rolloutv1alpha1.Rollout{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "hello-world"},
+			Spec:       rolloutv1alpha1.RolloutSpec{ReleasesImagePolicy: corev1.LocalObjectReference{Name: "hello-world"}},
+		},
+		&imagereflectorv1beta2.ImagePolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "hello-world"},
+			Spec:       imagereflectorv1beta2.ImagePolicySpec{ImageRepositoryRef: meta.NamespacedObjectReference{Name: "missing"}},
+		},
+		&kustomizev1.Kustomization{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "apps",
+				Name:        "hello-world",
+				Annotations: map[string]string{"rollout.kuberik.com/substitute.VERSION.from": "hello-world"},
+			},
+			Spec: kustomizev1.KustomizationSpec{SourceRef: kustomizev1.CrossNamespaceSourceReference{Kind: sourcev1.GitRepositoryKind, Name: "platform", Namespace: "flux-system"}},
+		},
+		&sourcev1.GitRepository{ObjectMeta: metav1.ObjectMeta{Namespace: "flux-system", Name: "platform"}},
+		&sourcev1.OCIRepository{ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "apps",
+			Name:        "hello-world-manifests",
+			Annotations: map[string]string{"rollout.kuberik.com/rollout": "hello-world"},
+		}},
+		&helmv2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "hello-world-chart"},
+			Spec:       helmv2.HelmReleaseSpec{ChartRef: &helmv2.CrossNamespaceSourceReference{Kind: sourcev1.OCIRepositoryKind, Name: "hello-world-manifests"}},
+		},
+	}
+	c := &Client{client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()}
+
+	// Without sources only the rollout's own objects are reconciled
+	_, results, err := c.ReconcileAllFluxResources(context.Background(), "apps", "hello-world", false)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.True(t, results["Kustomization/apps/hello-world"].Succeeded)
+	assert.True(t, results["HelmRelease/apps/hello-world-chart"].Succeeded)
+	assert.True(t, results["OCIRepository/apps/hello-world-manifests"].Succeeded)
+
+	// With sources the Kustomization's GitRepository and the image scan are requested too; the
+	// HelmRelease's chart comes from the OCIRepository already reconciled
+	_, results, err = c.ReconcileAllFluxResources(context.Background(), "apps", "hello-world", true)
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+	assert.True(t, results["GitRepository/flux-system/platform"].Succeeded)
+	assert.True(t, results["OCIRepository/apps/hello-world-manifests"].Succeeded)
+	assert.False(t, results["ImageRepository/apps/missing"].Succeeded)
+	assert.NotEmpty(t, results["ImageRepository/apps/missing"].Error)
+
+	gitRepository := &sourcev1.GitRepository{}
+	require.NoError(t, c.client.Get(context.Background(), client.ObjectKey{Namespace: "flux-system", Name: "platform"}, gitRepository))
+	assert.NotEmpty(t, gitRepository.Annotations["reconcile.fluxcd.io/requestedAt"])
+
+	kustomization := &kustomizev1.Kustomization{}
+	require.NoError(t, c.client.Get(context.Background(), client.ObjectKey{Namespace: "apps", Name: "hello-world"}, kustomization))
+	assert.NotEmpty(t, kustomization.Annotations["reconcile.fluxcd.io/requestedAt"])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = c.ReconcileAllFluxResources(ctx, "apps", "hello-world", true)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestReconcileConcurrentRequests(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kustomizev1.AddToScheme(scheme))
+	require.NoError(t, sourcev1.AddToScheme(scheme))
+
+	objects := []client.Object{
+		&kustomizev1.Kustomization{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "hello-world", Annotations: map[string]string{"team": "payments"}},
+			Spec:       kustomizev1.KustomizationSpec{Path: "./deploy"},
+		},
+		&sourcev1.OCIRepository{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "hello-world"}},
+	}
+	c := &Client{client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()}
+
+	// Read-modify-write updates would fail with conflicts here; patches carry no resourceVersion
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for range 10 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			errs <- c.ReconcileKustomization(context.Background(), "apps", "hello-world")
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- c.ReconcileOCIRepository(context.Background(), "apps", "hello-world")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	kustomization := &kustomizev1.Kustomization{}
+	require.NoError(t, c.client.Get(context.Background(), client.ObjectKey{Namespace: "apps", Name: "hello-world"}, kustomization))
+	assert.NotEmpty(t, kustomization.Annotations[meta.ReconcileRequestAnnotation])
+	assert.Equal(t, "payments", kustomization.Annotations["team"])
+	assert.Equal(t, "./deploy", kustomization.Spec.Path)
+
+	ociRepository := &sourcev1.OCIRepository{}
+	require.NoError(t, c.client.Get(context.Background(), client.ObjectKey{Namespace: "apps", Name: "hello-world"}, ociRepository))
+	assert.NotEmpty(t, ociRepository.Annotations[meta.ReconcileRequestAnnotation])
+
+	err := c.ReconcileKustomization(context.Background(), "apps", "missing")
+	require.True(t, apierrors.IsNotFound(err))
+}
+
+func TestGetRolloutsByImage(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, rolloutv1alpha1.AddToScheme(scheme))
+	require.NoError(t, imagereflectorv1beta2.AddToScheme(scheme))
+	rollout := func(namespace, name, policy string) *rolloutv1alpha1.Rollout {
+		return &rolloutv1alpha1.Rollout{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec:       rolloutv1alpha1.RolloutSpec{ReleasesImagePolicy: corev1.LocalObjectReference{Name: policy}},
+		}
+	}
+	policy := func(namespace, name, repository string) *imagereflectorv1beta2.ImagePolicy {
+		return &imagereflectorv1beta2.ImagePolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec:       imagereflectorv1beta2.ImagePolicySpec{ImageRepositoryRef: meta.NamespacedObjectReference{Name: repository}},
+		}
+	}
+	repository := func(namespace, name, image string) *imagereflectorv1beta2.ImageRepository {
+		return &imagereflectorv1beta2.ImageRepository{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec:       imagereflectorv1beta2.ImageRepositorySpec{Image: image},
+		}
+	}
+	c := &Client{client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		repository("staging", "api", "ghcr.io/shop/api"), policy("staging", "api", "api"), rollout("staging", "api", "api"),
+		repository("prod", "api", "ghcr.io/shop/api"), policy("prod", "api", "api"), rollout("prod", "api", "api"),
+		// A policy of the same name in another namespace does not count
+		repository("dev", "api", "ghcr.io/shop/web"), policy("dev", "api", "api"), rollout("dev", "api", "api"),
+	).Build()}
+
+	rollouts, err := c.GetRolloutsByImage(context.Background(), func(image string) bool { return image == "ghcr.io/shop/api" })
+	require.NoError(t, err)
+	assert.Equal(t, []types.NamespacedName{{Namespace: "prod", Name: "api"}, {Namespace: "staging", Name: "api"}}, rollouts)
+
+	rollouts, err = c.GetRolloutsByImage(context.Background(), func(string) bool { return false })
+	require.NoError(t, err)
+	assert.Empty(t, rollouts)
+}
@@ -0,0 +1,129 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ownedKinds are the kinds of the objects controllers create for an object of a kind, e.g. the
+// ReplicaSets of a Deployment. Only these are looked up when building a resource tree.
+var ownedKinds = map[schema.GroupKind][]schema.GroupVersionKind{
+	{Group: "apps", Kind: "Deployment"}:  {{Group: "apps", Version: "v1", Kind: "ReplicaSet"}},
+	{Group: "apps", Kind: "ReplicaSet"}:  {{Version: "v1", Kind: "Pod"}},
+	{Group: "apps", Kind: "StatefulSet"}: {{Version: "v1", Kind: "Pod"}},
+	{Group: "apps", Kind: "DaemonSet"}:   {{Version: "v1", Kind: "Pod"}},
+	{Group: "batch", Kind: "CronJob"}:    {{Group: "batch", Version: "v1", Kind: "Job"}},
+	{Group: "batch", Kind: "Job"}:        {{Version: "v1", Kind: "Pod"}},
+	{Kind: "Service"}:                    {{Group: "discovery.k8s.io", Version: "v1", Kind: "EndpointSlice"}},
+}
+
+// WithResourceTree adds the objects owned by every managed resource as its children, e.g.
+// Deployment→ReplicaSets→Pods, Job→Pods and Service→EndpointSlices. See AddResourceChildren.
+func WithResourceTree() ManagedResourcesOption {
+	return func(o *managedResourcesOptions) {
+		o.resourceTree = true
+	}
+}
+
+// AddResourceChildren sets the children of the resources to the objects that name them as owner,
+// recursively. Objects are listed once per namespace and kind; kinds that cannot be listed are
+// left out.
+func (c *Client) AddResourceChildren(ctx context.Context, resources []ManagedResourceStatus) {
+	tree := resourceTree{c: c, lists: map[resourceTreeListKey][]unstructured.Unstructured{}}
+	for i := range resources {
+		tree.addChildren(ctx, &resources[i])
+	}
+}
+
+type resourceTreeListKey struct {
+	namespace string
+	gvk       schema.GroupVersionKind
+}
+
+type resourceTree struct {
+	c     *Client
+	lists map[resourceTreeListKey][]unstructured.Unstructured
+}
+
+func (t *resourceTree) addChildren(ctx context.Context, resource *ManagedResourceStatus) {
+	if resource.Object == nil {
+		return
+	}
+	owner := resource.Object
+	for _, gvk := range ownedKinds[owner.GroupVersionKind().GroupKind()] {
+		for _, obj := range t.list(ctx, owner.GetNamespace(), gvk) {
+			if !ownedBy(&obj, owner.GetUID()) {
+				continue
+			}
+			child := newChildResourceStatus(obj.DeepCopy())
+			t.addChildren(ctx, &child)
+			resource.Children = append(resource.Children, child)
+		}
+	}
+	sort.Slice(resource.Children, func(i, j int) bool {
+		a, b := resource.Children[i], resource.Children[j]
+		if a.GroupVersionKind != b.GroupVersionKind {
+			return a.GroupVersionKind < b.GroupVersionKind
+		}
+		return a.Name < b.Name
+	})
+}
+
+func (t *resourceTree) list(ctx context.Context, namespace string, gvk schema.GroupVersionKind) []unstructured.Unstructured {
+	key := resourceTreeListKey{namespace: namespace, gvk: gvk}
+	if items, ok := t.lists[key]; ok {
+		return items
+	}
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := t.c.client.List(ctx, list, client.InNamespace(namespace)); err != nil {
+		slog.Debug("Failed to list owned resources", "namespace", namespace, "kind", gvk.Kind, "error", err)
+	}
+	t.lists[key] = list.Items
+	return list.Items
+}
+
+func ownedBy(obj *unstructured.Unstructured, uid types.UID) bool {
+	for _, ownerRef := range obj.GetOwnerReferences() {
+		if ownerRef.UID == uid {
+			return true
+		}
+	}
+	return false
+}
+
+// newChildResourceStatus computes the kstatus status of an object found through its owner
+func newChildResourceStatus(obj *unstructured.Unstructured) ManagedResourceStatus {
+	gvk := obj.GroupVersionKind()
+	resource := ManagedResourceStatus{
+		GroupVersionKind: fmt.Sprintf("%s/%s/%s", gvk.Group, gvk.Version, gvk.Kind),
+		Name:             obj.GetName(),
+		Namespace:        obj.GetNamespace(),
+		Object:           obj,
+	}
+	for _, field := range obj.GetManagedFields() {
+		if field.Time != nil && field.Time.Time.After(resource.LastModified) {
+			resource.LastModified = field.Time.Time
+		}
+	}
+	if resource.LastModified.IsZero() {
+		resource.LastModified = obj.GetCreationTimestamp().Time
+	}
+	result, err := status.Compute(obj)
+	if err != nil {
+		resource.Status = "Error"
+		resource.Message = fmt.Sprintf("Error computing status: %v", err)
+		return resource
+	}
+	resource.Status = string(result.Status)
+	resource.Message = result.Message
+	return resource
+}
@@ -0,0 +1,78 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func ownedMeta(name string, owner, uid types.UID) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Namespace:       "ns",
+		Name:            name,
+		UID:             uid,
+		OwnerReferences: []metav1.OwnerReference{{Name: "owner", UID: owner}},
+	}
+}
+
+func managedResource(t *testing.T, obj runtime.Object) ManagedResourceStatus {
+	t.Helper()
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	require.NoError(t, err)
+	return ManagedResourceStatus{Object: &unstructured.Unstructured{Object: content}}
+}
+
+func childNames(resource ManagedResourceStatus) []string {
+	var names []string
+	for _, child := range resource.Children {
+		names = append(names, child.GroupVersionKind+" "+child.Name)
+	}
+	return names
+}
+
+func TestAddResourceChildren(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	objects := []client.Object{
+		&appsv1.ReplicaSet{ObjectMeta: ownedMeta("web-1", "deploy-uid", "rs-1")},
+		&appsv1.ReplicaSet{ObjectMeta: ownedMeta("web-2", "deploy-uid", "rs-2")},
+		&appsv1.ReplicaSet{ObjectMeta: ownedMeta("api-1", "other-uid", "rs-3")},
+		&corev1.Pod{ObjectMeta: ownedMeta("web-2-abcde", "rs-2", "pod-1")},
+		&corev1.Pod{ObjectMeta: ownedMeta("migrate-xyz12", "job-uid", "pod-2")},
+		&discoveryv1.EndpointSlice{ObjectMeta: ownedMeta("web-8f2kd", "svc-uid", "slice-1"), AddressType: discoveryv1.AddressTypeIPv4},
+	}
+	c := &Client{client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()}
+
+	resources := []ManagedResourceStatus{
+		managedResource(t, &appsv1.Deployment{TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"}, ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "web", UID: "deploy-uid"}}),
+		managedResource(t, &batchv1.Job{TypeMeta: metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"}, ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "migrate", UID: "job-uid"}}),
+		managedResource(t, &corev1.Service{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Service"}, ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "web", UID: "svc-uid"}}),
+		managedResource(t, &corev1.ConfigMap{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}, ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "web", UID: "cm-uid"}}),
+		{Name: "gone", Status: "NotFound"},
+	}
+	c.AddResourceChildren(context.Background(), resources)
+
+	deployment := resources[0]
+	assert.Equal(t, []string{"apps/v1/ReplicaSet web-1", "apps/v1/ReplicaSet web-2"}, childNames(deployment))
+	assert.Empty(t, deployment.Children[0].Children)
+	assert.Equal(t, []string{"/v1/Pod web-2-abcde"}, childNames(deployment.Children[1]))
+	assert.NotEmpty(t, deployment.Children[1].Children[0].Status)
+
+	assert.Equal(t, []string{"/v1/Pod migrate-xyz12"}, childNames(resources[1]))
+	assert.Equal(t, []string{"discovery.k8s.io/v1/EndpointSlice web-8f2kd"}, childNames(resources[2]))
+	assert.Empty(t, resources[3].Children)
+	assert.Empty(t, resources[4].Children)
+}
@@ -0,0 +1,91 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// ErrNotManaged is returned for resources that are not in the inventory of any Kustomization the
+// caller can list
+var ErrNotManaged = errors.New("resource is not in a Kustomization inventory")
+
+// lastAppliedAnnotation holds a copy of the whole object and is left out of cleaned objects
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// GetManagedResourceMapping resolves a resource in the inventory of a Kustomization to its REST
+// mapping. An empty version is taken from the inventory entry. Returns ErrNotManaged when no
+// Kustomization the caller can list applied the resource.
+func (c *Client) GetManagedResourceMapping(ctx context.Context, group, version, kind, namespace, name string) (*meta.RESTMapping, error) {
+	kustomizations, err := c.GetKustomizationsAllNamespaces(ctx)
+	if apierrors.IsForbidden(err) {
+		// Callers who may not list Kustomizations cluster-wide can still look up resources
+		// applied by the Kustomizations of the resource's namespace
+		kustomizations, err = c.GetKustomizations(ctx, namespace)
+		if apierrors.IsForbidden(err) {
+			return nil, ErrNotManaged
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, kustomization := range kustomizations.Items {
+		if kustomization.Status.Inventory == nil {
+			continue
+		}
+		for _, entry := range kustomization.Status.Inventory.Entries {
+			objMetadata, err := object.ParseObjMetadata(entry.ID)
+			if err != nil || objMetadata.GroupKind.Group != group || objMetadata.GroupKind.Kind != kind ||
+				objMetadata.Namespace != namespace || objMetadata.Name != name {
+				continue
+			}
+			if version == "" {
+				version = entry.Version
+			}
+			mapping, err := c.client.RESTMapper().RESTMapping(objMetadata.GroupKind, version)
+			if err != nil {
+				return nil, fmt.Errorf("failed to map %s: %w", objMetadata.GroupKind, err)
+			}
+			return mapping, nil
+		}
+	}
+	return nil, ErrNotManaged
+}
+
+// GetResourceYAML returns the live object as YAML without its managed fields and last applied
+// configuration. The values of Secrets are redacted, their keys are kept.
+func (c *Client) GetResourceYAML(ctx context.Context, gvk schema.GroupVersionKind, namespace, name string) ([]byte, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	if err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, obj); err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", gvk.Kind, err)
+	}
+	obj.SetManagedFields(nil)
+	if annotations := obj.GetAnnotations(); annotations != nil {
+		delete(annotations, lastAppliedAnnotation)
+		if len(annotations) == 0 {
+			annotations = nil
+		}
+		obj.SetAnnotations(annotations)
+	}
+	if gvk.Group == "" && gvk.Kind == "Secret" {
+		for _, field := range []string{"data", "stringData"} {
+			values, ok := obj.Object[field].(map[string]any)
+			if !ok {
+				continue
+			}
+			for key := range values {
+				values[key] = redactedValue
+			}
+		}
+	}
+	return yaml.Marshal(obj.Object)
+}
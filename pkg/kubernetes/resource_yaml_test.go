@@ -0,0 +1,118 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestGetManagedResourceYAML(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kustomizev1.AddToScheme(scheme))
+	kustomization := &kustomizev1.Kustomization{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "flux-system", Name: "app"},
+		Status: kustomizev1.KustomizationStatus{
+			Inventory: &kustomizev1.ResourceInventory{Entries: []kustomizev1.ResourceRef{{ID: "ns_web_apps_Deployment", Version: "v1"}, {ID: "ns_web__ConfigMap", Version: "v1"}, {ID: "ns_credentials__Secret", Version: "v1"}}},
+		},
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:     "ns",
+			Name:          "web",
+			Annotations:   map[string]string{lastAppliedAnnotation: "{}"},
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kustomize-controller", Operation: metav1.ManagedFieldsOperationApply, APIVersion: "v1", FieldsType: "FieldsV1", FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:data":{}}`)}}},
+		},
+		Data: map[string]string{"key": "value"},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "credentials"},
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+		StringData: map[string]string{"token": "s3cr3t"},
+	}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for gvk := range scheme.AllKnownTypes() {
+		mapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+	c := &Client{client: fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).WithObjects(kustomization, configMap, secret).Build()}
+	ctx := context.Background()
+
+	mapping, err := c.GetManagedResourceMapping(ctx, "apps", "", "Deployment", "ns", "web")
+	require.NoError(t, err)
+	assert.Equal(t, "deployments", mapping.Resource.Resource)
+	assert.Equal(t, "v1", mapping.GroupVersionKind.Version)
+
+	_, err = c.GetManagedResourceMapping(ctx, "", "v1", "Secret", "ns", "other")
+	assert.ErrorIs(t, err, ErrNotManaged)
+	_, err = c.GetManagedResourceMapping(ctx, "", "v1", "ConfigMap", "other", "web")
+	assert.ErrorIs(t, err, ErrNotManaged)
+
+	mapping, err = c.GetManagedResourceMapping(ctx, "", "", "ConfigMap", "ns", "web")
+	require.NoError(t, err)
+	manifest, err := c.GetResourceYAML(ctx, mapping.GroupVersionKind, "ns", "web")
+	require.NoError(t, err)
+	assert.Contains(t, string(manifest), "key: value")
+	assert.NotContains(t, string(manifest), "managedFields")
+	assert.NotContains(t, string(manifest), "annotations")
+
+	// Secret values are never shown, only which keys are set
+	mapping, err = c.GetManagedResourceMapping(ctx, "", "", "Secret", "ns", "credentials")
+	require.NoError(t, err)
+	manifest, err = c.GetResourceYAML(ctx, mapping.GroupVersionKind, "ns", "credentials")
+	require.NoError(t, err)
+	assert.Contains(t, string(manifest), "password: (redacted)")
+	assert.Contains(t, string(manifest), "token: (redacted)")
+	assert.NotContains(t, string(manifest), "aHVudGVyMg")
+	assert.NotContains(t, string(manifest), "s3cr3t")
+}
+
+func TestGetManagedResourceMapping_NamespacedKustomizations(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kustomizev1.AddToScheme(scheme))
+	kustomization := func(namespace, id string) *kustomizev1.Kustomization {
+		return &kustomizev1.Kustomization{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "app"},
+			Status: kustomizev1.KustomizationStatus{
+				Inventory: &kustomizev1.ResourceInventory{Entries: []kustomizev1.ResourceRef{{ID: id, Version: "v1"}}},
+			},
+		}
+	}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for gvk := range scheme.AllKnownTypes() {
+		mapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+	// The caller may only list Kustomizations in the ns namespace
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).
+		WithObjects(kustomization("ns", "ns_web_apps_Deployment"), kustomization("flux-system", "other_web_apps_Deployment")).
+		WithInterceptorFuncs(interceptor.Funcs{
+			List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				if (&client.ListOptions{}).ApplyOptions(opts).Namespace != "ns" {
+					return apierrors.NewForbidden(schema.GroupResource{Group: "kustomize.toolkit.fluxcd.io", Resource: "kustomizations"}, "", nil)
+				}
+				return c.List(ctx, list, opts...)
+			},
+		}).Build()
+	c := &Client{client: fakeClient}
+	ctx := context.Background()
+
+	mapping, err := c.GetManagedResourceMapping(ctx, "apps", "", "Deployment", "ns", "web")
+	require.NoError(t, err)
+	assert.Equal(t, "deployments", mapping.Resource.Resource)
+
+	_, err = c.GetManagedResourceMapping(ctx, "apps", "", "Deployment", "other", "web")
+	assert.ErrorIs(t, err, ErrNotManaged)
+}
@@ -0,0 +1,136 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	imagereflectorv1beta2 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ManagedByLabel marks objects the dashboard created with the value ManagedByDashboard. Deleting
+// a rollout with cascade also deletes its objects labelled so.
+const (
+	ManagedByLabel     = "app.kubernetes.io/managed-by"
+	ManagedByDashboard = "rollout-dashboard"
+)
+
+// ErrManagedByKustomization is returned when deleting a rollout that a Flux Kustomization applies,
+// as it would recreate the rollout
+var ErrManagedByKustomization = errors.New("rollout is applied by a Flux Kustomization and would be recreated")
+
+// DeletionTarget is an object deleted with a rollout. Allowed and Error are filled in while
+// deleting.
+type DeletionTarget struct {
+	Group     string `json:"group,omitempty"`
+	Version   string `json:"version"`
+	Kind      string `json:"kind"`
+	Resource  string `json:"resource"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Allowed   bool   `json:"allowed"`
+	Deleted   bool   `json:"deleted"`
+	Error     string `json:"error,omitempty"`
+}
+
+// PlanRolloutDeletion lists what deleting a rollout deletes: the rollout and, with cascade, its
+// RolloutGates, releases ImagePolicy and that policy's ImageRepository, as far as the dashboard
+// created them and no other rollout or ImagePolicy still references them
+func (c *Client) PlanRolloutDeletion(ctx context.Context, namespace, name string, cascade bool) ([]DeletionTarget, error) {
+	rollout, err := c.GetRollout(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if kustomization := rollout.Labels["kustomize.toolkit.fluxcd.io/name"]; kustomization != "" {
+		return nil, fmt.Errorf("%w: %s/%s", ErrManagedByKustomization, rollout.Labels["kustomize.toolkit.fluxcd.io/namespace"], kustomization)
+	}
+	targets := []DeletionTarget{{Group: "kuberik.com", Version: "v1alpha1", Kind: "Rollout", Resource: "rollouts", Namespace: namespace, Name: name}}
+	if !cascade {
+		return targets, nil
+	}
+
+	gates, err := c.GetRolloutGatesByRolloutReference(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, gate := range gates.Items {
+		if gate.Labels[ManagedByLabel] == ManagedByDashboard {
+			targets = append(targets, DeletionTarget{Group: "kuberik.com", Version: "v1alpha1", Kind: "RolloutGate", Resource: "rolloutgates", Namespace: namespace, Name: gate.Name})
+		}
+	}
+
+	policy := &imagereflectorv1beta2.ImagePolicy{}
+	if err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: rollout.Spec.ReleasesImagePolicy.Name}, policy); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			return targets, nil
+		}
+		return nil, fmt.Errorf("failed to get image policy: %w", err)
+	}
+	if policy.Labels[ManagedByLabel] != ManagedByDashboard {
+		return targets, nil
+	}
+	rollouts := &rolloutv1alpha1.RolloutList{}
+	if err := c.client.List(ctx, rollouts, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list rollouts: %w", err)
+	}
+	for _, other := range rollouts.Items {
+		if other.Name != name && other.Spec.ReleasesImagePolicy.Name == policy.Name {
+			return targets, nil
+		}
+	}
+	targets = append(targets, DeletionTarget{Group: imagereflectorv1beta2.GroupVersion.Group, Version: imagereflectorv1beta2.GroupVersion.Version, Kind: "ImagePolicy", Resource: "imagepolicies", Namespace: namespace, Name: policy.Name})
+
+	repositoryNamespace := policy.Spec.ImageRepositoryRef.Namespace
+	if repositoryNamespace == "" {
+		repositoryNamespace = namespace
+	}
+	repository := &imagereflectorv1beta2.ImageRepository{}
+	if err := c.client.Get(ctx, client.ObjectKey{Namespace: repositoryNamespace, Name: policy.Spec.ImageRepositoryRef.Name}, repository); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			return targets, nil
+		}
+		return nil, fmt.Errorf("failed to get image repository: %w", err)
+	}
+	if repository.Labels[ManagedByLabel] != ManagedByDashboard {
+		return targets, nil
+	}
+	policies := &imagereflectorv1beta2.ImagePolicyList{}
+	if err := c.client.List(ctx, policies); err != nil {
+		return nil, fmt.Errorf("failed to list image policies: %w", err)
+	}
+	for _, other := range policies.Items {
+		otherNamespace := other.Spec.ImageRepositoryRef.Namespace
+		if otherNamespace == "" {
+			otherNamespace = other.Namespace
+		}
+		if (other.Namespace != namespace || other.Name != policy.Name) && otherNamespace == repositoryNamespace && other.Spec.ImageRepositoryRef.Name == repository.Name {
+			return targets, nil
+		}
+	}
+	targets = append(targets, DeletionTarget{Group: imagereflectorv1beta2.GroupVersion.Group, Version: imagereflectorv1beta2.GroupVersion.Version, Kind: "ImageRepository", Resource: "imagerepositories", Namespace: repositoryNamespace, Name: repository.Name})
+	return targets, nil
+}
+
+// DeleteTargets deletes the targets in order, recording on each whether it was deleted. Targets
+// that are already gone count as deleted. It reports whether all were deleted.
+func (c *Client) DeleteTargets(ctx context.Context, targets []DeletionTarget) bool {
+	deleted := true
+	for i := range targets {
+		target := &targets[i]
+		object := &unstructured.Unstructured{}
+		object.SetGroupVersionKind(schema.GroupVersionKind{Group: target.Group, Version: target.Version, Kind: target.Kind})
+		object.SetNamespace(target.Namespace)
+		object.SetName(target.Name)
+		if err := c.client.Delete(ctx, object); client.IgnoreNotFound(err) != nil {
+			target.Error = err.Error()
+			deleted = false
+			continue
+		}
+		target.Deleted = true
+	}
+	return deleted
+}
@@ -0,0 +1,79 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	imagereflectorv1beta2 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	fluxmeta "github.com/fluxcd/pkg/apis/meta"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRolloutDeletion(t *testing.T) {
+	ctx := context.Background()
+	dashboard := map[string]string{ManagedByLabel: ManagedByDashboard}
+	gitops := &rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "gitops", Labels: map[string]string{
+		"kustomize.toolkit.fluxcd.io/name": "apps", "kustomize.toolkit.fluxcd.io/namespace": "flux-system",
+	}}}
+	gitops.Spec.ReleasesImagePolicy.Name = "shared"
+	app := &rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "app"}}
+	app.Spec.ReleasesImagePolicy.Name = "app"
+	other := &rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "other"}}
+	other.Spec.ReleasesImagePolicy.Name = "shared"
+	objects := []client.Object{app, other, gitops,
+		&rolloutv1alpha1.RolloutGate{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "freeze", Labels: dashboard},
+			Spec:       rolloutv1alpha1.RolloutGateSpec{RolloutRef: &corev1.LocalObjectReference{Name: "app"}},
+		},
+		&rolloutv1alpha1.RolloutGate{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "gitops-gate"},
+			Spec:       rolloutv1alpha1.RolloutGateSpec{RolloutRef: &corev1.LocalObjectReference{Name: "app"}},
+		},
+		&imagereflectorv1beta2.ImagePolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "app", Labels: dashboard},
+			Spec:       imagereflectorv1beta2.ImagePolicySpec{ImageRepositoryRef: fluxmeta.NamespacedObjectReference{Name: "app"}},
+		},
+		&imagereflectorv1beta2.ImageRepository{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "app", Labels: dashboard}},
+		&imagereflectorv1beta2.ImagePolicy{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "shared", Labels: dashboard}},
+	}
+	scheme := runtime.NewScheme()
+	require.NoError(t, rolloutv1alpha1.AddToScheme(scheme))
+	require.NoError(t, imagereflectorv1beta2.AddToScheme(scheme))
+	c := &Client{client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()}
+
+	_, err := c.PlanRolloutDeletion(ctx, "ns", "gitops", false)
+	assert.ErrorIs(t, err, ErrManagedByKustomization)
+
+	// The ImagePolicy is still used by another rollout
+	targets, err := c.PlanRolloutDeletion(ctx, "ns", "other", true)
+	require.NoError(t, err)
+	assert.Len(t, targets, 1)
+
+	targets, err = c.PlanRolloutDeletion(ctx, "ns", "app", false)
+	require.NoError(t, err)
+	assert.Equal(t, []DeletionTarget{{Group: "kuberik.com", Version: "v1alpha1", Kind: "Rollout", Resource: "rollouts", Namespace: "ns", Name: "app"}}, targets)
+
+	targets, err = c.PlanRolloutDeletion(ctx, "ns", "app", true)
+	require.NoError(t, err)
+	var names []string
+	for _, target := range targets {
+		names = append(names, target.Kind+"/"+target.Name)
+	}
+	assert.Equal(t, []string{"Rollout/app", "RolloutGate/freeze", "ImagePolicy/app", "ImageRepository/app"}, names)
+
+	assert.True(t, c.DeleteTargets(ctx, targets))
+	for _, target := range targets {
+		assert.True(t, target.Deleted)
+	}
+	err = c.client.Get(ctx, client.ObjectKey{Namespace: "ns", Name: "app"}, &imagereflectorv1beta2.ImageRepository{})
+	assert.True(t, apierrors.IsNotFound(err))
+	require.NoError(t, c.client.Get(ctx, client.ObjectKey{Namespace: "ns", Name: "gitops-gate"}, &rolloutv1alpha1.RolloutGate{}))
+}
@@ -0,0 +1,98 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GateDescriptionAnnotation explains why a RolloutGate created in the dashboard exists, e.g.
+// "holiday freeze"
+const GateDescriptionAnnotation = "rollout.kuberik.com/gate-description"
+
+// ErrGateNotFound is returned for RolloutGates that do not exist or do not gate the rollout
+var ErrGateNotFound = errors.New("rollout gate not found")
+
+// RolloutGateSpec is what can be set on a RolloutGate from the dashboard. A nil Passing lets the
+// controller default it to passing; nil AllowedVersions allows every version.
+type RolloutGateSpec struct {
+	Passing         *bool
+	AllowedVersions *[]string
+	Description     string
+}
+
+// apply sets spec on the gate and records username as the last to change it
+func (spec RolloutGateSpec) apply(gate *rolloutv1alpha1.RolloutGate, username string) {
+	gate.Spec.Passing = spec.Passing
+	gate.Spec.AllowedVersions = spec.AllowedVersions
+	if gate.Annotations == nil {
+		gate.Annotations = map[string]string{}
+	}
+	if spec.Description != "" {
+		gate.Annotations[GateDescriptionAnnotation] = spec.Description
+	} else {
+		delete(gate.Annotations, GateDescriptionAnnotation)
+	}
+	if username != "" {
+		gate.Annotations[ChangedByAnnotation] = username
+	}
+}
+
+// CreateRolloutGate creates a RolloutGate named name gating the rollout, labelled as created by
+// the dashboard
+func (c *Client) CreateRolloutGate(ctx context.Context, namespace, rolloutName, name string, spec RolloutGateSpec) (*rolloutv1alpha1.RolloutGate, error) {
+	gate := &rolloutv1alpha1.RolloutGate{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: map[string]string{ManagedByLabel: ManagedByDashboard}},
+		Spec:       rolloutv1alpha1.RolloutGateSpec{RolloutRef: &corev1.LocalObjectReference{Name: rolloutName}},
+	}
+	spec.apply(gate, c.actingUser(ctx))
+	if err := c.client.Create(ctx, gate); err != nil {
+		return nil, fmt.Errorf("failed to create rollout gate: %w", err)
+	}
+	return gate, nil
+}
+
+// UpdateRolloutGate replaces what can be set from the dashboard on a RolloutGate of the rollout
+func (c *Client) UpdateRolloutGate(ctx context.Context, namespace, rolloutName, name string, spec RolloutGateSpec) (*rolloutv1alpha1.RolloutGate, error) {
+	gate, err := c.getRolloutGate(ctx, namespace, rolloutName, name)
+	if err != nil {
+		return nil, err
+	}
+	spec.apply(gate, c.actingUser(ctx))
+	if err := c.client.Update(ctx, gate); err != nil {
+		return nil, fmt.Errorf("failed to update rollout gate: %w", err)
+	}
+	return gate, nil
+}
+
+// DeleteRolloutGate deletes a RolloutGate of the rollout and returns it as it was deleted
+func (c *Client) DeleteRolloutGate(ctx context.Context, namespace, rolloutName, name string) (*rolloutv1alpha1.RolloutGate, error) {
+	gate, err := c.getRolloutGate(ctx, namespace, rolloutName, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.client.Delete(ctx, gate, client.Preconditions{UID: &gate.UID}); err != nil {
+		return nil, fmt.Errorf("failed to delete rollout gate: %w", err)
+	}
+	return gate, nil
+}
+
+// getRolloutGate returns a RolloutGate, or ErrGateNotFound when it does not gate the rollout
+func (c *Client) getRolloutGate(ctx context.Context, namespace, rolloutName, name string) (*rolloutv1alpha1.RolloutGate, error) {
+	gate := &rolloutv1alpha1.RolloutGate{}
+	if err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, gate); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			return nil, ErrGateNotFound
+		}
+		return nil, fmt.Errorf("failed to get rollout gate: %w", err)
+	}
+	if gate.Spec.RolloutRef == nil || gate.Spec.RolloutRef.Name != rolloutName {
+		return nil, ErrGateNotFound
+	}
+	return gate, nil
+}
@@ -0,0 +1,52 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestRolloutGateLifecycle(t *testing.T) {
+	ctx := context.Background()
+	other := &rolloutv1alpha1.RolloutGate{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "other-gate"},
+		Spec:       rolloutv1alpha1.RolloutGateSpec{RolloutRef: &corev1.LocalObjectReference{Name: "other"}},
+	}
+	c := newTestClient(t, other)
+
+	gate, err := c.CreateRolloutGate(ctx, "ns", "app", "holiday-freeze", RolloutGateSpec{Passing: ptr.To(false), Description: "holiday freeze"})
+	require.NoError(t, err)
+	assert.Equal(t, "app", gate.Spec.RolloutRef.Name)
+	assert.Equal(t, "holiday freeze", gate.Annotations[GateDescriptionAnnotation])
+	assert.Equal(t, ManagedByDashboard, gate.Labels[ManagedByLabel])
+
+	_, err = c.CreateRolloutGate(ctx, "ns", "app", "holiday-freeze", RolloutGateSpec{})
+	assert.True(t, apierrors.IsAlreadyExists(err))
+
+	gate, err = c.UpdateRolloutGate(ctx, "ns", "app", "holiday-freeze", RolloutGateSpec{Passing: ptr.To(true), AllowedVersions: &[]string{"1.2.3"}})
+	require.NoError(t, err)
+	assert.True(t, *gate.Spec.Passing)
+	assert.Equal(t, []string{"1.2.3"}, *gate.Spec.AllowedVersions)
+	assert.NotContains(t, gate.Annotations, GateDescriptionAnnotation)
+
+	// Gates of other rollouts cannot be changed through this one
+	_, err = c.UpdateRolloutGate(ctx, "ns", "app", "other-gate", RolloutGateSpec{})
+	assert.ErrorIs(t, err, ErrGateNotFound)
+	_, err = c.DeleteRolloutGate(ctx, "ns", "app", "other-gate")
+	assert.ErrorIs(t, err, ErrGateNotFound)
+
+	_, err = c.DeleteRolloutGate(ctx, "ns", "app", "holiday-freeze")
+	require.NoError(t, err)
+	err = c.client.Get(ctx, client.ObjectKey{Namespace: "ns", Name: "holiday-freeze"}, &rolloutv1alpha1.RolloutGate{})
+	assert.True(t, apierrors.IsNotFound(err))
+	_, err = c.DeleteRolloutGate(ctx, "ns", "app", "holiday-freeze")
+	assert.ErrorIs(t, err, ErrGateNotFound)
+}
@@ -0,0 +1,187 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// EditableSpecFields are the Rollout spec fields that can be changed from the dashboard, for
+// routine tuning of rollouts the dashboard manages rather than GitOps
+var EditableSpecFields = []string{"bakeTime", "deployTimeout", "healthCheckSelector", "releasesImagePolicy"}
+
+// ErrInvalidSpec is returned for spec changes that name other fields or set invalid values
+var ErrInvalidSpec = errors.New("invalid rollout spec change")
+
+// RolloutSpecPatch is a validated JSON merge patch of the editable spec fields. A nil value
+// clears the field.
+type RolloutSpecPatch map[string]any
+
+// Fields returns the fields the patch sets or clears, sorted
+func (p RolloutSpecPatch) Fields() []string {
+	return slices.Sorted(maps.Keys(p))
+}
+
+// ParseRolloutSpecPatch validates a JSON object of editable spec fields: durations must be
+// positive Go durations, the health check selector has to be a valid HealthCheckSelectorConfig
+// and the releases ImagePolicy cannot be cleared
+func ParseRolloutSpecPatch(body []byte) (RolloutSpecPatch, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%w: no fields to change, editable fields are %v", ErrInvalidSpec, EditableSpecFields)
+	}
+	patch := RolloutSpecPatch{}
+	for field, raw := range fields {
+		if !slices.Contains(EditableSpecFields, field) {
+			return nil, fmt.Errorf("%w: %s cannot be changed, editable fields are %v", ErrInvalidSpec, field, EditableSpecFields)
+		}
+		if string(raw) == "null" {
+			if field == "releasesImagePolicy" {
+				return nil, fmt.Errorf("%w: releasesImagePolicy is required", ErrInvalidSpec)
+			}
+			patch[field] = nil
+			continue
+		}
+		value, err := parseSpecField(field, raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidSpec, field, err)
+		}
+		patch[field] = value
+	}
+	return patch, nil
+}
+
+// parseSpecField decodes and validates the value of an editable spec field
+func parseSpecField(field string, raw json.RawMessage) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	switch field {
+	case "bakeTime", "deployTimeout":
+		var value string
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("%q is not a positive duration", value)
+		}
+		return value, nil
+	case "healthCheckSelector":
+		selector := &rolloutv1alpha1.HealthCheckSelectorConfig{}
+		if err := decoder.Decode(selector); err != nil {
+			return nil, err
+		}
+		for _, labelSelector := range []*metav1.LabelSelector{selector.Selector, selector.NamespaceSelector} {
+			if _, err := metav1.LabelSelectorAsSelector(labelSelector); err != nil {
+				return nil, err
+			}
+		}
+		if !selector.IsValid() {
+			return nil, errors.New("invalid health check selector")
+		}
+		return runtime.DefaultUnstructuredConverter.ToUnstructured(selector)
+	default:
+		var reference struct {
+			Name string `json:"name"`
+		}
+		if err := decoder.Decode(&reference); err != nil {
+			return nil, err
+		}
+		if reference.Name == "" {
+			return nil, errors.New("name is required")
+		}
+		return map[string]any{"name": reference.Name}, nil
+	}
+}
+
+// PatchRolloutSpec applies patch to the spec of a rollout and returns the rollout as patched and
+// the fields that changed. With dryRun the API server validates the change without persisting
+// it. The releases ImagePolicy has to exist in the rollout's namespace. Changing the bake time
+// of a rollout whose bake is extended changes the bake time the extension restores, keeping the
+// extension.
+func (c *Client) PatchRolloutSpec(ctx context.Context, namespace, name string, patch RolloutSpecPatch, dryRun bool) (*rolloutv1alpha1.Rollout, []string, error) {
+	rollout, err := c.GetRollout(ctx, namespace, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if reference, ok := patch["releasesImagePolicy"].(map[string]any); ok {
+		if _, err := c.GetImagePolicy(ctx, namespace, reference["name"].(string)); err != nil {
+			if client.IgnoreNotFound(err) == nil {
+				return nil, nil, fmt.Errorf("%w: ImagePolicy %s/%s does not exist", ErrInvalidSpec, namespace, reference["name"])
+			}
+			return nil, nil, err
+		}
+	}
+
+	spec := map[string]any{}
+	maps.Copy(spec, patch)
+	annotations := map[string]any{}
+	if bakeTime, ok := patch["bakeTime"]; ok {
+		if extension := GetBakeExtension(rollout); extension != nil && !BakeExtensionOver(rollout) {
+			extension.OriginalBakeTime = nil
+			var base time.Duration
+			if bakeTime != nil {
+				base, _ = time.ParseDuration(bakeTime.(string))
+				extension.OriginalBakeTime = &metav1.Duration{Duration: base}
+			}
+			value, err := json.Marshal(extension)
+			if err != nil {
+				return nil, nil, err
+			}
+			spec["bakeTime"] = (base + extension.Total()).String()
+			annotations[BakeExtensionAnnotation] = string(value)
+		}
+	}
+
+	object := &unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{"annotations": annotations},
+		"spec":     spec,
+	}}
+	object.SetGroupVersionKind(schema.GroupVersionKind{Group: "kuberik.com", Version: "v1alpha1", Kind: "Rollout"})
+	object.SetNamespace(namespace)
+	object.SetName(name)
+	attributeChange(object, c.actingUser(ctx), false)
+	options := []client.PatchOption{client.FieldOwner("rollout-dashboard")}
+	if dryRun {
+		options = append(options, client.DryRunAll)
+	}
+	if err := c.client.Patch(ctx, object, client.Merge, options...); err != nil {
+		return nil, nil, fmt.Errorf("failed to patch rollout spec: %w", err)
+	}
+
+	patched := &rolloutv1alpha1.Rollout{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, patched); err != nil {
+		return nil, nil, err
+	}
+	before, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&rollout.Spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	after, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&patched.Spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	changed := []string{}
+	for _, field := range patch.Fields() {
+		if !reflect.DeepEqual(before[field], after[field]) {
+			changed = append(changed, field)
+		}
+	}
+	return patched, changed, nil
+}
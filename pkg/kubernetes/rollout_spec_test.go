@@ -0,0 +1,85 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	imagereflectorv1beta2 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestParseRolloutSpecPatch(t *testing.T) {
+	patch, err := ParseRolloutSpecPatch([]byte(`{"bakeTime":"30m","deployTimeout":null,"healthCheckSelector":{"selector":{"matchLabels":{"app":"web"}}}}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bakeTime", "deployTimeout", "healthCheckSelector"}, patch.Fields())
+	assert.Equal(t, "30m", patch["bakeTime"])
+	assert.Nil(t, patch["deployTimeout"])
+	assert.Equal(t, map[string]any{"selector": map[string]any{"matchLabels": map[string]any{"app": "web"}}}, patch["healthCheckSelector"])
+
+	for _, body := range []string{
+		`{}`,
+		`{"wantedVersion":"1.2.3"}`,
+		`{"bakeTime":"-5m"}`,
+		`{"bakeTime":30}`,
+		`{"releasesImagePolicy":null}`,
+		`{"releasesImagePolicy":{"name":""}}`,
+		`{"healthCheckSelector":{"selector":{"matchExpressions":[{"key":"app","operator":"Bogus"}]}}}`,
+		`{"healthCheckSelector":{"labels":{}}}`,
+	} {
+		_, err := ParseRolloutSpecPatch([]byte(body))
+		assert.ErrorIs(t, err, ErrInvalidSpec, body)
+	}
+}
+
+func TestPatchRolloutSpec(t *testing.T) {
+	ctx := context.Background()
+	extension, err := json.Marshal(BakeExtension{
+		Version:          "1.0.0",
+		OriginalBakeTime: &metav1.Duration{Duration: time.Hour},
+		Extensions:       []BakeExtensionEntry{{Duration: metav1.Duration{Duration: 30 * time.Minute}}},
+	})
+	require.NoError(t, err)
+	rollout := explainRollout(rolloutv1alpha1.BakeStatusInProgress, map[string]string{BakeExtensionAnnotation: string(extension)})
+	rollout.Spec.BakeTime = &metav1.Duration{Duration: 90 * time.Minute}
+	rollout.Spec.ReleasesImagePolicy.Name = "app"
+	scheme := runtime.NewScheme()
+	require.NoError(t, rolloutv1alpha1.AddToScheme(scheme))
+	require.NoError(t, imagereflectorv1beta2.AddToScheme(scheme))
+	c := &Client{client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(rollout,
+		&imagereflectorv1beta2.ImagePolicy{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "app"}},
+		&imagereflectorv1beta2.ImagePolicy{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "app-v2"}},
+	).Build()}
+
+	patch, err := ParseRolloutSpecPatch([]byte(`{"bakeTime":"2h","releasesImagePolicy":{"name":"app"}}`))
+	require.NoError(t, err)
+	patched, changed, err := c.PatchRolloutSpec(ctx, "ns", "app", patch, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bakeTime"}, changed)
+	// The extension stays on top of the new bake time
+	assert.Equal(t, 150*time.Minute, patched.Spec.BakeTime.Duration)
+	assert.Equal(t, 2*time.Hour, GetBakeExtension(patched).OriginalBakeTime.Duration)
+
+	patch, err = ParseRolloutSpecPatch([]byte(`{"releasesImagePolicy":{"name":"missing"}}`))
+	require.NoError(t, err)
+	_, _, err = c.PatchRolloutSpec(ctx, "ns", "app", patch, false)
+	assert.ErrorIs(t, err, ErrInvalidSpec)
+
+	patch, err = ParseRolloutSpecPatch([]byte(`{"releasesImagePolicy":{"name":"app-v2"},"deployTimeout":"10m"}`))
+	require.NoError(t, err)
+	patched, changed, err = c.PatchRolloutSpec(ctx, "ns", "app", patch, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"deployTimeout", "releasesImagePolicy"}, changed)
+	assert.Equal(t, "app-v2", patched.Spec.ReleasesImagePolicy.Name)
+	stored := &rolloutv1alpha1.Rollout{}
+	require.NoError(t, c.client.Get(ctx, client.ObjectKey{Namespace: "ns", Name: "app"}, stored))
+	assert.Equal(t, "app", stored.Spec.ReleasesImagePolicy.Name)
+	assert.Nil(t, stored.Spec.DeployTimeout)
+}
@@ -0,0 +1,116 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ScheduledChangeAnnotation records a version change queued for a later time as JSON, see
+// ScheduledChange. The dashboard's scheduler applies it once it is due and removes the annotation.
+const ScheduledChangeAnnotation = "rollout.kuberik.com/scheduled-change"
+
+// ScheduledChange is the value of ScheduledChangeAnnotation
+type ScheduledChange struct {
+	Version string `json:"version"`
+	// Pin pins the version instead of force deploying it
+	Pin     bool   `json:"pin,omitempty"`
+	Message string `json:"message,omitempty"`
+	// At is when the change is applied
+	At metav1.Time `json:"at"`
+	// User scheduled the change, the change is attributed to them
+	User        string      `json:"user,omitempty"`
+	ScheduledAt metav1.Time `json:"scheduledAt"`
+}
+
+// GetScheduledChange returns the version change scheduled for a rollout, nil when there is none
+// or the annotation cannot be parsed
+func GetScheduledChange(rollout *rolloutv1alpha1.Rollout) *ScheduledChange {
+	raw := rollout.Annotations[ScheduledChangeAnnotation]
+	if raw == "" {
+		return nil
+	}
+	change := &ScheduledChange{}
+	if err := json.Unmarshal([]byte(raw), change); err != nil {
+		return nil
+	}
+	return change
+}
+
+// ScheduledChangeDue reports whether the rollout has a scheduled change that is due at now
+func ScheduledChangeDue(rollout *rolloutv1alpha1.Rollout, now time.Time) bool {
+	change := GetScheduledChange(rollout)
+	return change != nil && !change.At.After(now)
+}
+
+// ScheduleVersionChange queues a pin or force deploy of version for at, replacing a change that
+// was scheduled before. The acting user is recorded, so the change is attributed to them when the
+// scheduler applies it with its own credentials.
+func (c *Client) ScheduleVersionChange(ctx context.Context, namespace, name, version string, pin bool, message string, at time.Time) (*rolloutv1alpha1.Rollout, error) {
+	username := c.actingUser(ctx)
+	value, err := json.Marshal(ScheduledChange{
+		Version:     version,
+		Pin:         pin,
+		Message:     message,
+		At:          metav1.NewTime(at),
+		User:        username,
+		ScheduledAt: metav1.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	patch := rolloutPatch(namespace, name)
+	annotations := map[string]any{ScheduledChangeAnnotation: string(value)}
+	if username != "" {
+		annotations[ChangedByAnnotation] = username
+	}
+	patch.Object["metadata"].(map[string]any)["annotations"] = annotations
+	if err := c.client.Patch(ctx, patch, client.Merge, client.FieldOwner("rollout-dashboard")); err != nil {
+		return nil, fmt.Errorf("failed to schedule version change: %w", err)
+	}
+	return c.GetRollout(ctx, namespace, name)
+}
+
+// ApplyScheduledChange applies the rollout's scheduled change as ChangeVersion would and removes
+// ScheduledChangeAnnotation in the same patch. The patch is made against the rollout's resource
+// version, so a change rescheduled or cancelled in the meantime is not applied.
+func (c *Client) ApplyScheduledChange(ctx context.Context, rollout *rolloutv1alpha1.Rollout) (*ScheduledChange, error) {
+	change := GetScheduledChange(rollout)
+	if change == nil {
+		return nil, fmt.Errorf("rollout %s/%s has no valid scheduled change", rollout.Namespace, rollout.Name)
+	}
+	patch := changeVersionPatch(rollout.Namespace, rollout.Name, change.Version, change.Pin, change.Message)
+	patch.SetResourceVersion(rollout.ResourceVersion)
+	patch.Object["metadata"].(map[string]any)["annotations"].(map[string]any)[ScheduledChangeAnnotation] = nil
+	attributeChange(patch, change.User, true)
+
+	if err := c.client.Patch(ctx, patch, client.Merge, client.FieldOwner("rollout-dashboard")); err != nil {
+		return nil, fmt.Errorf("failed to apply scheduled change: %w", err)
+	}
+	return change, nil
+}
+
+// CancelScheduledChange removes the rollout's scheduled change
+func (c *Client) CancelScheduledChange(ctx context.Context, namespace, name string) error {
+	return c.RemoveRolloutAnnotations(ctx, namespace, name, []string{ScheduledChangeAnnotation})
+}
+
+func rolloutPatch(namespace, name string) *unstructured.Unstructured {
+	patch := &unstructured.Unstructured{}
+	patch.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   "kuberik.com",
+		Version: "v1alpha1",
+		Kind:    "Rollout",
+	})
+	patch.SetNamespace(namespace)
+	patch.SetName(name)
+	return patch
+}
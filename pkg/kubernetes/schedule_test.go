@@ -0,0 +1,64 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestScheduleVersionChange(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, rolloutv1alpha1.AddToScheme(scheme))
+	c := &Client{client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(&rolloutv1alpha1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api", Annotations: map[string]string{ChannelAnnotation: "stable"}},
+		Spec:       rolloutv1alpha1.RolloutSpec{WantedVersion: ptr.To("v1")},
+	}).Build()}
+	ctx := context.Background()
+	at := time.Date(2026, 10, 16, 19, 0, 0, 0, time.UTC)
+
+	rollout, err := c.ScheduleVersionChange(ctx, "shop", "api", "v2", false, "evening deploy", at)
+	require.NoError(t, err)
+	change := GetScheduledChange(rollout)
+	require.NotNil(t, change)
+	assert.Equal(t, "v2", change.Version)
+	assert.True(t, change.At.Time.Equal(at))
+	assert.False(t, ScheduledChangeDue(rollout, at.Add(-time.Minute)))
+	assert.True(t, ScheduledChangeDue(rollout, at))
+	// Nothing changes before the scheduled time
+	assert.Equal(t, "v1", *rollout.Spec.WantedVersion)
+	assert.Equal(t, "stable", rollout.Annotations[ChannelAnnotation])
+
+	applied, err := c.ApplyScheduledChange(ctx, rollout)
+	require.NoError(t, err)
+	assert.Equal(t, "evening deploy", applied.Message)
+	rollout, err = c.GetRollout(ctx, "shop", "api")
+	require.NoError(t, err)
+	assert.Nil(t, rollout.Spec.WantedVersion)
+	assert.Equal(t, "v2", rollout.Annotations[ForceDeployAnnotation])
+	assert.Equal(t, "evening deploy", rollout.Annotations[DeployMessageAnnotation])
+	assert.NotContains(t, rollout.Annotations, ChannelAnnotation)
+	assert.NotContains(t, rollout.Annotations, ScheduledChangeAnnotation)
+
+	// A change rescheduled after the rollout was read is not applied
+	stale, err := c.ScheduleVersionChange(ctx, "shop", "api", "v3", true, "", at)
+	require.NoError(t, err)
+	_, err = c.ScheduleVersionChange(ctx, "shop", "api", "v4", true, "", at.Add(time.Hour))
+	require.NoError(t, err)
+	_, err = c.ApplyScheduledChange(ctx, stale)
+	assert.Error(t, err)
+
+	require.NoError(t, c.CancelScheduledChange(ctx, "shop", "api"))
+	rollout, err = c.GetRollout(ctx, "shop", "api")
+	require.NoError(t, err)
+	assert.Nil(t, GetScheduledChange(rollout))
+	_, err = c.ApplyScheduledChange(ctx, rollout)
+	assert.Error(t, err)
+}
@@ -0,0 +1,187 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagereflectorv1beta2 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+)
+
+// DeploymentSearchResult describes a rollout that currently runs a version matching a search query
+type DeploymentSearchResult struct {
+	Namespace   string                      `json:"namespace"`
+	Name        string                      `json:"name"`
+	Environment string                      `json:"environment,omitempty"`
+	Image       string                      `json:"image,omitempty"`
+	Version     rolloutv1alpha1.VersionInfo `json:"version"`
+	DeployedAt  string                      `json:"deployedAt"`
+	BakeStatus  string                      `json:"bakeStatus,omitempty"`
+	MatchedBy   string                      `json:"matchedBy"`
+}
+
+// SearchDeployedVersions finds rollouts whose currently deployed version matches the given image
+// reference (tag, digest or image:tag / image@digest) or source revision (commit SHA, prefix allowed).
+// An empty namespace searches across all namespaces.
+func (c *Client) SearchDeployedVersions(ctx context.Context, namespace, image, revision string) ([]DeploymentSearchResult, error) {
+	if image == "" && revision == "" {
+		return nil, fmt.Errorf("either image or revision must be provided")
+	}
+
+	var listOpts []client.ListOption
+	if namespace != "" {
+		listOpts = append(listOpts, client.InNamespace(namespace))
+	}
+
+	rollouts := &rolloutv1alpha1.RolloutList{}
+	if err := c.client.List(ctx, rollouts, listOpts...); err != nil {
+		return nil, fmt.Errorf("failed to list rollouts: %w", err)
+	}
+
+	// Image repositories are resolved through the rollout's ImagePolicy, so index both by namespace/name
+	imagePolicies := &imagereflectorv1beta2.ImagePolicyList{}
+	if err := c.client.List(ctx, imagePolicies, listOpts...); err != nil {
+		return nil, fmt.Errorf("failed to list image policies: %w", err)
+	}
+	imageRepositories := &imagereflectorv1beta2.ImageRepositoryList{}
+	if err := c.client.List(ctx, imageRepositories, listOpts...); err != nil {
+		return nil, fmt.Errorf("failed to list image repositories: %w", err)
+	}
+	repoImages := make(map[string]string)
+	for _, repo := range imageRepositories.Items {
+		repoImages[repo.Namespace+"/"+repo.Name] = repo.Spec.Image
+	}
+	policyImages := make(map[string]string)
+	for _, policy := range imagePolicies.Items {
+		policyImages[policy.Namespace+"/"+policy.Name] = repoImages[policy.Namespace+"/"+policy.Spec.ImageRepositoryRef.Name]
+	}
+
+	// Environments are optional, a failure to list them only drops the environment name
+	environmentNames := make(map[string]string)
+	environments := &envv1alpha1.EnvironmentList{}
+	if err := c.client.List(ctx, environments, listOpts...); err == nil {
+		for _, env := range environments.Items {
+			environmentNames[env.Namespace+"/"+env.Spec.RolloutRef.Name] = env.Spec.Environment
+		}
+	}
+
+	results := []DeploymentSearchResult{}
+	for _, rollout := range rollouts.Items {
+		if len(rollout.Status.History) == 0 {
+			continue
+		}
+		current := rollout.Status.History[0]
+		rolloutImage := policyImages[rollout.Namespace+"/"+rollout.Spec.ReleasesImagePolicy.Name]
+
+		matchedBy := ""
+		if image != "" && MatchesImageQuery(image, rolloutImage, current.Version) {
+			matchedBy = "image"
+		} else if revision != "" && MatchesRevisionQuery(revision, current.Version) {
+			matchedBy = "revision"
+		}
+		if matchedBy == "" {
+			continue
+		}
+
+		result := DeploymentSearchResult{
+			Namespace:   rollout.Namespace,
+			Name:        rollout.Name,
+			Environment: environmentNames[rollout.Namespace+"/"+rollout.Name],
+			Image:       rolloutImage,
+			Version:     current.Version,
+			DeployedAt:  current.Timestamp.Format(time.RFC3339),
+			MatchedBy:   matchedBy,
+		}
+		if current.BakeStatus != nil {
+			result.BakeStatus = *current.BakeStatus
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// SearchDeployedVersionsInNamespaces searches like SearchDeployedVersions in each of the given
+// namespaces, for users who may not search across all namespaces. Namespaces that cannot be
+// searched are skipped and returned with the reason.
+func (c *Client) SearchDeployedVersionsInNamespaces(ctx context.Context, namespaces []string, image, revision string) ([]DeploymentSearchResult, []SkippedNamespace, error) {
+	sorted := append([]string(nil), namespaces...)
+	sort.Strings(sorted)
+
+	results := []DeploymentSearchResult{}
+	var skipped []SkippedNamespace
+	for _, namespace := range sorted {
+		found, err := c.SearchDeployedVersions(ctx, namespace, image, revision)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, nil, ctxErr
+			}
+			reason := err.Error()
+			if apierrors.IsForbidden(err) {
+				reason = "forbidden"
+			}
+			skipped = append(skipped, SkippedNamespace{Namespace: namespace, Reason: reason})
+			continue
+		}
+		results = append(results, found...)
+	}
+	return results, skipped, nil
+}
+
+// MatchesImageQuery reports whether a deployed version of the given image matches the query.
+// The query may be a bare tag ("v1.2.3"), a bare digest ("sha256:..."), or a full reference
+// ("registry/repo:tag" or "registry/repo@sha256:...").
+func MatchesImageQuery(query, image string, version rolloutv1alpha1.VersionInfo) bool {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return false
+	}
+
+	repo, ref := query, ""
+	if idx := strings.Index(query, "@"); idx >= 0 {
+		repo, ref = query[:idx], query[idx+1:]
+	} else if strings.HasPrefix(query, "sha256:") {
+		repo, ref = "", query
+	} else if idx := strings.LastIndex(query, ":"); idx >= 0 && !strings.Contains(query[idx+1:], "/") {
+		repo, ref = query[:idx], query[idx+1:]
+	} else if !strings.Contains(query, "/") {
+		// A bare word without a slash is treated as a tag
+		repo, ref = "", query
+	}
+
+	if repo != "" && !strings.EqualFold(strings.TrimSuffix(repo, "/"), strings.TrimSuffix(image, "/")) {
+		return false
+	}
+	if ref == "" {
+		return true
+	}
+	if strings.HasPrefix(ref, "sha256:") {
+		return version.Digest != nil && *version.Digest == ref
+	}
+	return version.Tag == ref
+}
+
+// MatchesRevisionQuery reports whether the version's source revision matches the given commit SHA.
+// Revisions are often recorded as "<branch>@sha1:<sha>", so only the trailing SHA is compared and
+// abbreviated SHAs (at least 7 characters) are accepted.
+func MatchesRevisionQuery(query string, version rolloutv1alpha1.VersionInfo) bool {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if version.Revision == nil || query == "" {
+		return false
+	}
+	revision := strings.ToLower(*version.Revision)
+	if idx := strings.LastIndex(revision, ":"); idx >= 0 {
+		revision = revision[idx+1:]
+	}
+	if revision == query {
+		return true
+	}
+	return len(query) >= 7 && strings.HasPrefix(revision, query)
+}
@@ -0,0 +1,54 @@
+package kubernetes
+
+import (
+	"testing"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	k8sptr "k8s.io/utils/ptr"
+)
+
+func TestMatchesImageQuery(t *testing.T) {
+	version := rolloutv1alpha1.VersionInfo{
+		Tag:    "v1.2.3",
+		Digest: k8sptr.To("sha256:abc"),
+	}
+	image := "ghcr.io/kuberik/app"
+
+	cases := map[string]bool{
+		"v1.2.3":                         true,
+		"v1.2.4":                         false,
+		"sha256:abc":                     true,
+		"sha256:def":                     false,
+		"ghcr.io/kuberik/app:v1.2.3":     true,
+		"ghcr.io/kuberik/app:v1.2.4":     false,
+		"ghcr.io/kuberik/other:v1.2.3":   false,
+		"ghcr.io/kuberik/app@sha256:abc": true,
+		"ghcr.io/kuberik/app":            true,
+		"":                               false,
+	}
+	for query, want := range cases {
+		require.Equal(t, want, MatchesImageQuery(query, image, version), "query %q", query)
+	}
+}
+
+func TestMatchesImageQuery_RegistryWithPort(t *testing.T) {
+	version := rolloutv1alpha1.VersionInfo{Tag: "1.0.0"}
+	require.True(t, MatchesImageQuery("localhost:5000/app", "localhost:5000/app", version))
+	require.True(t, MatchesImageQuery("localhost:5000/app:1.0.0", "localhost:5000/app", version))
+	require.False(t, MatchesImageQuery("localhost:5000/app:2.0.0", "localhost:5000/app", version))
+}
+
+func TestMatchesRevisionQuery(t *testing.T) {
+	version := rolloutv1alpha1.VersionInfo{
+		Tag:      "v1.2.3",
+		Revision: k8sptr.To("main@sha1:0123456789abcdef"),
+	}
+
+	require.True(t, MatchesRevisionQuery("0123456789abcdef", version))
+	require.True(t, MatchesRevisionQuery("0123456", version))
+	require.True(t, MatchesRevisionQuery("0123456789ABCDEF", version))
+	require.False(t, MatchesRevisionQuery("012345", version))
+	require.False(t, MatchesRevisionQuery("fedcba9", version))
+	require.False(t, MatchesRevisionQuery("0123456", rolloutv1alpha1.VersionInfo{Tag: "v1"}))
+}
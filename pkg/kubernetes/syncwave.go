@@ -0,0 +1,301 @@
+package kubernetes
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	fluxmeta "github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrDependencyCycle is returned when Kustomizations depend on each other in a cycle, which Flux
+// cannot reconcile either
+var ErrDependencyCycle = errors.New("kustomizations depend on each other in a cycle")
+
+// syncPollInterval is how often the objects of a wave are checked for readiness
+var syncPollInterval = 2 * time.Second
+
+// syncKinds are the Flux kinds that can be reconciled in sync waves
+var syncKinds = map[string]schema.GroupVersionKind{
+	kustomizev1.KustomizationKind: kustomizev1.GroupVersion.WithKind(kustomizev1.KustomizationKind),
+	sourcev1.OCIRepositoryKind:    sourcev1.GroupVersion.WithKind(sourcev1.OCIRepositoryKind),
+	sourcev1.GitRepositoryKind:    sourcev1.GroupVersion.WithKind(sourcev1.GitRepositoryKind),
+	sourcev1.BucketKind:           sourcev1.GroupVersion.WithKind(sourcev1.BucketKind),
+}
+
+// Phases of a sync target
+const (
+	SyncPhaseRequested = "Requested"
+	SyncPhaseReady     = "Ready"
+	SyncPhaseFailed    = "Failed"
+	SyncPhaseSkipped   = "Skipped"
+)
+
+// SyncTarget is a Flux object reconciled in a sync wave
+type SyncTarget struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// DependsOn are the keys of the targets that must be ready first: a Kustomization's source and
+	// the Kustomizations in its dependsOn
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// Key identifies the target as "Kind/namespace/name", like the results of ReconcileAllFluxResources
+func (t SyncTarget) Key() string {
+	return t.Kind + "/" + t.Namespace + "/" + t.Name
+}
+
+// SyncProgress reports the phase of a target in a sync wave
+type SyncProgress struct {
+	Wave      int    `json:"wave"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Phase     string `json:"phase"`
+	Message   string `json:"message,omitempty"`
+}
+
+// GetSyncTargets returns the Kustomizations of the rollouts, their sources and the rollouts'
+// OCIRepositories, each with the targets it depends on. Dependencies outside this set are left to
+// Flux, which still waits for them.
+func (c *Client) GetSyncTargets(ctx context.Context, rollouts []types.NamespacedName) ([]SyncTarget, error) {
+	targets := map[string]*SyncTarget{}
+	add := func(target SyncTarget) {
+		if existing, ok := targets[target.Key()]; ok {
+			existing.DependsOn = append(existing.DependsOn, target.DependsOn...)
+			return
+		}
+		targets[target.Key()] = &target
+	}
+
+	var kustomizations []kustomizev1.Kustomization
+	for _, rollout := range rollouts {
+		list, err := c.GetKustomizationsByRolloutAnnotation(ctx, rollout.Namespace, rollout.Name)
+		if err != nil {
+			return nil, err
+		}
+		kustomizations = append(kustomizations, list.Items...)
+		ociRepositories, err := c.GetOCIRepositoriesByRolloutAnnotation(ctx, rollout.Namespace, rollout.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, repository := range ociRepositories.Items {
+			add(SyncTarget{Kind: sourcev1.OCIRepositoryKind, Namespace: repository.Namespace, Name: repository.Name})
+		}
+	}
+	for _, kustomization := range kustomizations {
+		source := kustomization.Spec.SourceRef
+		if _, ok := syncKinds[source.Kind]; ok {
+			add(SyncTarget{Kind: source.Kind, Namespace: cmp.Or(source.Namespace, kustomization.Namespace), Name: source.Name})
+		}
+	}
+	isTarget := map[string]bool{}
+	for _, kustomization := range kustomizations {
+		isTarget[SyncTarget{Kind: kustomizev1.KustomizationKind, Namespace: kustomization.Namespace, Name: kustomization.Name}.Key()] = true
+	}
+	for _, kustomization := range kustomizations {
+		target := SyncTarget{Kind: kustomizev1.KustomizationKind, Namespace: kustomization.Namespace, Name: kustomization.Name}
+		source := kustomization.Spec.SourceRef
+		sourceKey := SyncTarget{Kind: source.Kind, Namespace: cmp.Or(source.Namespace, kustomization.Namespace), Name: source.Name}.Key()
+		if _, ok := targets[sourceKey]; ok {
+			target.DependsOn = append(target.DependsOn, sourceKey)
+		}
+		for _, dependency := range kustomization.Spec.DependsOn {
+			key := SyncTarget{Kind: kustomizev1.KustomizationKind, Namespace: cmp.Or(dependency.Namespace, kustomization.Namespace), Name: dependency.Name}.Key()
+			if isTarget[key] {
+				target.DependsOn = append(target.DependsOn, key)
+			}
+		}
+		add(target)
+	}
+
+	result := make([]SyncTarget, 0, len(targets))
+	for _, target := range targets {
+		slices.Sort(target.DependsOn)
+		target.DependsOn = slices.Compact(target.DependsOn)
+		result = append(result, *target)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key() < result[j].Key() })
+	return result, nil
+}
+
+// SyncWaves orders targets into waves, each depending only on targets of earlier waves. Sources
+// end up in the first wave. Dependencies on keys that are not targets are ignored.
+func SyncWaves(targets []SyncTarget) ([][]SyncTarget, error) {
+	remaining := map[string]SyncTarget{}
+	for _, target := range targets {
+		remaining[target.Key()] = target
+	}
+	var waves [][]SyncTarget
+	for len(remaining) > 0 {
+		var wave []SyncTarget
+		for _, target := range remaining {
+			ready := true
+			for _, dependency := range target.DependsOn {
+				if _, pending := remaining[dependency]; pending {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				wave = append(wave, target)
+			}
+		}
+		if len(wave) == 0 {
+			return nil, ErrDependencyCycle
+		}
+		sort.Slice(wave, func(i, j int) bool { return wave[i].Key() < wave[j].Key() })
+		for _, target := range wave {
+			delete(remaining, target.Key())
+		}
+		waves = append(waves, wave)
+	}
+	return waves, nil
+}
+
+// SyncInWaves reconciles the waves in order. The targets of a wave are asked to reconcile at once
+// and the next wave starts when each of them has handled the request and is Ready, or failed, or
+// timeout passed. Targets depending on one that failed or was skipped are skipped rather than
+// reconciled against a broken dependency. Every phase change is passed to progress; the final
+// phase of each target is returned in wave order.
+func (c *Client) SyncInWaves(ctx context.Context, waves [][]SyncTarget, timeout time.Duration, progress func(SyncProgress)) []SyncProgress {
+	failed := map[string]bool{}
+	var results []SyncProgress
+	for i, wave := range waves {
+		var (
+			mu      sync.Mutex
+			wg      sync.WaitGroup
+			outcome = make([]SyncProgress, len(wave))
+		)
+		report := func(p SyncProgress) {
+			mu.Lock()
+			defer mu.Unlock()
+			progress(p)
+		}
+
+		waveCtx, cancel := context.WithTimeout(ctx, timeout)
+		for j, target := range wave {
+			p := SyncProgress{Wave: i, Kind: target.Kind, Namespace: target.Namespace, Name: target.Name}
+			if dependency := slices.IndexFunc(target.DependsOn, func(key string) bool { return failed[key] }); dependency >= 0 {
+				p.Phase, p.Message = SyncPhaseSkipped, fmt.Sprintf("dependency %s is not ready", target.DependsOn[dependency])
+				outcome[j] = p
+				report(p)
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				outcome[j] = c.syncTarget(waveCtx, target, p, report)
+			}()
+		}
+		wg.Wait()
+		cancel()
+
+		for _, p := range outcome {
+			if p.Phase != SyncPhaseReady {
+				failed[SyncTarget{Kind: p.Kind, Namespace: p.Namespace, Name: p.Name}.Key()] = true
+			}
+		}
+		results = append(results, outcome...)
+	}
+	return results
+}
+
+// syncTarget asks a target to reconcile and waits until it handled the request
+func (c *Client) syncTarget(ctx context.Context, target SyncTarget, p SyncProgress, report func(SyncProgress)) SyncProgress {
+	fail := func(err error) SyncProgress {
+		p.Phase, p.Message = SyncPhaseFailed, err.Error()
+		report(p)
+		return p
+	}
+	gvk, ok := syncKinds[target.Kind]
+	if !ok {
+		return fail(fmt.Errorf("kind %s cannot be reconciled", target.Kind))
+	}
+
+	token := strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err := c.requestReconcileAt(ctx, gvk, target.Namespace, target.Name, token); err != nil {
+		return fail(fmt.Errorf("failed to request reconciliation: %w", err))
+	}
+	p.Phase = SyncPhaseRequested
+	report(p)
+
+	ticker := time.NewTicker(syncPollInterval)
+	defer ticker.Stop()
+	for {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		if err := c.client.Get(ctx, client.ObjectKey{Namespace: target.Namespace, Name: target.Name}, obj); err != nil && ctx.Err() == nil {
+			return fail(err)
+		}
+		if ready, err := reconciledState(obj, token); err != nil {
+			return fail(err)
+		} else if ready {
+			p.Phase, p.Message = SyncPhaseReady, ""
+			report(p)
+			return p
+		}
+
+		select {
+		case <-ctx.Done():
+			return fail(fmt.Errorf("not ready in time: %w", ctx.Err()))
+		case <-ticker.C:
+		}
+	}
+}
+
+// reconciledState reports whether a Flux object handled the reconcile request token and is Ready
+// for its current generation, or the error it failed with. Objects still waiting for their
+// dependencies are not failed.
+func reconciledState(obj *unstructured.Unstructured, token string) (bool, error) {
+	handled, _, _ := unstructured.NestedString(obj.Object, "status", "lastHandledReconcileAt")
+	if handled != token {
+		return false, nil
+	}
+	var status struct {
+		ObservedGeneration int64              `json:"observedGeneration"`
+		Conditions         []metav1.Condition `json:"conditions"`
+	}
+	if raw, ok := obj.Object["status"].(map[string]any); ok {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &status); err != nil {
+			return false, nil
+		}
+	}
+	ready := meta.FindStatusCondition(status.Conditions, fluxmeta.ReadyCondition)
+	switch {
+	case ready == nil || status.ObservedGeneration != obj.GetGeneration():
+		return false, nil
+	case ready.Status == metav1.ConditionTrue:
+		return true, nil
+	case ready.Status == metav1.ConditionFalse && ready.Reason != fluxmeta.DependencyNotReadyReason && ready.Reason != fluxmeta.ProgressingReason:
+		return false, fmt.Errorf("%s: %s", ready.Reason, ready.Message)
+	}
+	return false, nil
+}
+
+// requestReconcileAt sets the Flux reconcile.fluxcd.io/requestedAt annotation to token, which the
+// controller copies to status.lastHandledReconcileAt once it handled the request
+func (c *Client) requestReconcileAt(ctx context.Context, gvk schema.GroupVersionKind, namespace, name, token string) error {
+	patch := &unstructured.Unstructured{}
+	patch.SetGroupVersionKind(gvk)
+	patch.SetNamespace(namespace)
+	patch.SetName(name)
+	patch.SetAnnotations(map[string]string{fluxmeta.ReconcileRequestAnnotation: token})
+	return c.client.Patch(ctx, patch, client.Merge, client.FieldOwner("rollout-dashboard"))
+}
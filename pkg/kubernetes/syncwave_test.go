@@ -0,0 +1,129 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetSyncTargetsAndWaves(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kustomizev1.AddToScheme(scheme))
+	require.NoError(t, sourcev1.AddToScheme(scheme))
+	kustomization := func(name string, dependsOn ...string) *kustomizev1.Kustomization {
+		k := &kustomizev1.Kustomization{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: name},
+			Spec:       kustomizev1.KustomizationSpec{SourceRef: kustomizev1.CrossNamespaceSourceReference{Kind: sourcev1.OCIRepositoryKind, Name: "shop"}},
+		}
+		for _, dependency := range dependsOn {
+			k.Spec.DependsOn = append(k.Spec.DependsOn, kustomizev1.DependencyReference{Name: dependency})
+		}
+		return k
+	}
+	c := &Client{client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&sourcev1.OCIRepository{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "apps", Name: "shop", Annotations: map[string]string{"rollout.kuberik.com/rollout": "shop"},
+		}},
+		kustomization("crds"),
+		kustomization("database", "crds"),
+		kustomization("shop", "database", "crds", "cluster-addons"),
+	).Build()}
+
+	targets, err := c.GetSyncTargets(context.Background(), []types.NamespacedName{{Namespace: "apps", Name: "shop"}})
+	require.NoError(t, err)
+	require.Len(t, targets, 4)
+	// Dependencies outside the rollouts are left to Flux
+	assert.Equal(t, []string{"Kustomization/apps/crds", "Kustomization/apps/database", "OCIRepository/apps/shop"}, targets[2].DependsOn)
+
+	waves, err := SyncWaves(targets)
+	require.NoError(t, err)
+	var keys [][]string
+	for _, wave := range waves {
+		var waveKeys []string
+		for _, target := range wave {
+			waveKeys = append(waveKeys, target.Key())
+		}
+		keys = append(keys, waveKeys)
+	}
+	assert.Equal(t, [][]string{
+		{"OCIRepository/apps/shop"},
+		{"Kustomization/apps/crds"},
+		{"Kustomization/apps/database"},
+		{"Kustomization/apps/shop"},
+	}, keys)
+
+	_, err = SyncWaves([]SyncTarget{
+		{Kind: "Kustomization", Namespace: "apps", Name: "a", DependsOn: []string{"Kustomization/apps/b"}},
+		{Kind: "Kustomization", Namespace: "apps", Name: "b", DependsOn: []string{"Kustomization/apps/a"}},
+	})
+	assert.ErrorIs(t, err, ErrDependencyCycle)
+}
+
+func TestSyncInWaves(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kustomizev1.AddToScheme(scheme))
+	require.NoError(t, sourcev1.AddToScheme(scheme))
+	c := &Client{client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&kustomizev1.Kustomization{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "crds"}},
+	).Build()}
+
+	// The missing source fails, so its dependent is skipped; the fake cluster never reports crds
+	// as reconciled, so it times out
+	var phases []string
+	results := c.SyncInWaves(context.Background(), [][]SyncTarget{
+		{{Kind: "OCIRepository", Namespace: "apps", Name: "missing"}, {Kind: "Kustomization", Namespace: "apps", Name: "crds"}},
+		{{Kind: "Kustomization", Namespace: "apps", Name: "shop", DependsOn: []string{"OCIRepository/apps/missing"}}},
+	}, 50*time.Millisecond, func(p SyncProgress) {
+		phases = append(phases, p.Name+"="+p.Phase)
+	})
+
+	require.Len(t, results, 3)
+	assert.Equal(t, SyncPhaseFailed, results[0].Phase)
+	assert.Equal(t, SyncPhaseFailed, results[1].Phase)
+	assert.Contains(t, results[1].Message, "not ready in time")
+	assert.Equal(t, SyncPhaseSkipped, results[2].Phase)
+	assert.Contains(t, phases, "crds="+SyncPhaseRequested)
+	assert.Equal(t, "shop="+SyncPhaseSkipped, phases[len(phases)-1])
+}
+
+func TestReconciledState(t *testing.T) {
+	object := func(handled, status, reason string, observed int64) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]any{"status": map[string]any{
+			"lastHandledReconcileAt": handled,
+			"observedGeneration":     observed,
+			"conditions": []any{map[string]any{
+				"type": "Ready", "status": status, "reason": reason, "message": "apply failed", "lastTransitionTime": "2026-01-02T15:00:00Z",
+			}},
+		}}}
+		obj.SetGeneration(2)
+		return obj
+	}
+
+	ready, err := reconciledState(object("1", "True", "ReconciliationSucceeded", 2), "1")
+	assert.True(t, ready)
+	assert.NoError(t, err)
+
+	// An earlier request, an older generation or a pending dependency are still waited for
+	for _, obj := range []*unstructured.Unstructured{
+		object("0", "True", "ReconciliationSucceeded", 2),
+		object("1", "True", "ReconciliationSucceeded", 1),
+		object("1", "False", "DependencyNotReady", 2),
+	} {
+		ready, err := reconciledState(obj, "1")
+		assert.False(t, ready)
+		assert.NoError(t, err)
+	}
+
+	_, err = reconciledState(object("1", "False", "ReconciliationFailed", 2), "1")
+	assert.EqualError(t, err, "ReconciliationFailed: apply failed")
+}
@@ -0,0 +1,153 @@
+package kubernetes
+
+import (
+	"sort"
+	"strings"
+
+	openkruisev1alpha1 "github.com/kuberik/openkruise-controller/api/v1alpha1"
+	kruiserolloutv1beta1 "github.com/openkruise/kruise-rollout-api/rollouts/v1beta1"
+)
+
+// Effects of a RolloutTest on the progression of its step, as evaluated by the step gate of the
+// openkruise-controller
+const (
+	// TestEffectPassing tests (Succeeded or Skipped for the current revision) let the step proceed
+	TestEffectPassing = "passing"
+	// TestEffectFailing tests (Failed or Cancelled for the current revision) stall the rollout
+	TestEffectFailing = "failing"
+	// TestEffectWaiting tests have not finished for the current revision, or not run for it at all,
+	// and hold the step
+	TestEffectWaiting = "waiting"
+	// TestEffectUpcoming tests are bound to a step the rollout has not reached yet
+	TestEffectUpcoming = "upcoming"
+	// TestEffectDone tests are bound to a step the rollout has passed
+	TestEffectDone = "done"
+)
+
+// TestGate is a RolloutTest and its effect on the step it is bound to
+type TestGate struct {
+	Name  string `json:"name"`
+	Step  int32  `json:"step"`
+	Phase string `json:"phase,omitempty"`
+	// ObservedRevision is the canary revision the test last ran for
+	ObservedRevision string `json:"observedRevision,omitempty"`
+	// CurrentRevision reports whether the test has run for the current canary revision
+	CurrentRevision bool   `json:"currentRevision"`
+	Effect          string `json:"effect"`
+	JobName         string `json:"jobName,omitempty"`
+}
+
+// StepTestGate is a step of a Kruise rollout with the RolloutTests bound to it
+type StepTestGate struct {
+	// Step is the 1-based index of the step, as in RolloutTest.spec.stepIndex
+	Step  int32      `json:"step"`
+	Tests []TestGate `json:"tests"`
+	// Blocking reports whether the tests hold the step, Reason says why
+	Blocking bool   `json:"blocking"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// TestGates are the RolloutTests of a Kruise rollout grouped by step, with the reason the current
+// step does not progress when its tests hold it
+type TestGates struct {
+	CanaryRevision string `json:"canaryRevision,omitempty"`
+	// CurrentStep is 0 when no canary is in progress
+	CurrentStep      int32          `json:"currentStep"`
+	CurrentStepState string         `json:"currentStepState,omitempty"`
+	Steps            []StepTestGate `json:"steps"`
+	// Blocked reports whether the current step is held by its tests, Reason says why
+	Blocked bool   `json:"blocked"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// EvaluateTestGates evaluates the RolloutTests bound to a Kruise rollout the way the step gate of
+// the openkruise-controller does: only tests that ran for the current canary revision count, a
+// failed or cancelled test stalls the step and the step is approved once every test passed. Tests
+// of other rollouts are ignored.
+func EvaluateTestGates(rollout *kruiserolloutv1beta1.Rollout, tests []openkruisev1alpha1.RolloutTest) TestGates {
+	gates := TestGates{Steps: []StepTestGate{}}
+	if status := rollout.Status.CanaryStatus; status != nil {
+		gates.CanaryRevision = status.CanaryRevision
+		gates.CurrentStep = status.CurrentStepIndex
+		gates.CurrentStepState = string(status.CurrentStepState)
+	}
+
+	steps := map[int32]*StepTestGate{}
+	for _, test := range tests {
+		if test.Spec.RolloutName != rollout.Name {
+			continue
+		}
+		gate := TestGate{
+			Name:             test.Name,
+			Step:             test.Spec.StepIndex,
+			Phase:            string(test.Status.Phase),
+			ObservedRevision: test.Status.ObservedCanaryRevision,
+			JobName:          test.Status.JobName,
+		}
+		gate.CurrentRevision = gates.CanaryRevision != "" && gate.ObservedRevision == gates.CanaryRevision
+		switch {
+		case gates.CurrentStep == 0 || gate.Step > gates.CurrentStep:
+			gate.Effect = TestEffectUpcoming
+		case gate.Step < gates.CurrentStep:
+			gate.Effect = TestEffectDone
+		case !gate.CurrentRevision && gates.CanaryRevision != "":
+			gate.Effect = TestEffectWaiting
+		default:
+			gate.Effect = testEffect(test.Status.Phase)
+		}
+		step, ok := steps[gate.Step]
+		if !ok {
+			step = &StepTestGate{Step: gate.Step}
+			steps[gate.Step] = step
+		}
+		step.Tests = append(step.Tests, gate)
+	}
+
+	for _, step := range steps {
+		sort.Slice(step.Tests, func(i, j int) bool { return step.Tests[i].Name < step.Tests[j].Name })
+		if step.Step == gates.CurrentStep {
+			step.Blocking, step.Reason = stepBlocked(step.Tests, gates.CurrentStepState)
+			gates.Blocked, gates.Reason = step.Blocking, step.Reason
+		}
+		gates.Steps = append(gates.Steps, *step)
+	}
+	sort.Slice(gates.Steps, func(i, j int) bool { return gates.Steps[i].Step < gates.Steps[j].Step })
+	return gates
+}
+
+// testEffect is the effect of a test that ran for the current revision of the current step
+func testEffect(phase openkruisev1alpha1.RolloutTestPhase) string {
+	switch phase {
+	case openkruisev1alpha1.RolloutTestPhaseSucceeded, openkruisev1alpha1.RolloutTestPhaseSkipped:
+		return TestEffectPassing
+	case openkruisev1alpha1.RolloutTestPhaseFailed, openkruisev1alpha1.RolloutTestPhaseCancelled:
+		return TestEffectFailing
+	default:
+		return TestEffectWaiting
+	}
+}
+
+// stepBlocked explains how the tests of the current step hold it
+func stepBlocked(tests []TestGate, state string) (bool, string) {
+	var failing, waiting []string
+	for _, test := range tests {
+		switch test.Effect {
+		case TestEffectFailing:
+			failing = append(failing, test.Name)
+		case TestEffectWaiting:
+			if test.Phase == string(openkruisev1alpha1.RolloutTestPhaseWaitingForStep) && state != string(kruiserolloutv1beta1.CanaryStepStatePaused) {
+				// Tests only start once the step is paused
+				waiting = append(waiting, test.Name+" (waiting for the step to pause)")
+			} else {
+				waiting = append(waiting, test.Name)
+			}
+		}
+	}
+	switch {
+	case len(failing) > 0:
+		return true, "tests failed: " + strings.Join(failing, ", ")
+	case len(waiting) > 0:
+		return true, "waiting for tests: " + strings.Join(waiting, ", ")
+	}
+	return false, ""
+}
@@ -0,0 +1,83 @@
+package kubernetes
+
+import (
+	"testing"
+
+	openkruisev1alpha1 "github.com/kuberik/openkruise-controller/api/v1alpha1"
+	kruiserolloutv1beta1 "github.com/openkruise/kruise-rollout-api/rollouts/v1beta1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func rolloutTest(name string, step int32, phase openkruisev1alpha1.RolloutTestPhase, revision string) openkruisev1alpha1.RolloutTest {
+	return openkruisev1alpha1.RolloutTest{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+		Spec:       openkruisev1alpha1.RolloutTestSpec{RolloutName: "app", StepIndex: step},
+		Status:     openkruisev1alpha1.RolloutTestStatus{Phase: phase, ObservedCanaryRevision: revision},
+	}
+}
+
+func canaryAt(step int32, state kruiserolloutv1beta1.CanaryStepState) *kruiserolloutv1beta1.Rollout {
+	return &kruiserolloutv1beta1.Rollout{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "ns"},
+		Status: kruiserolloutv1beta1.RolloutStatus{CanaryStatus: &kruiserolloutv1beta1.CanaryStatus{
+			CanaryRevision: "rev2",
+			CommonStatus:   kruiserolloutv1beta1.CommonStatus{CurrentStepIndex: step, CurrentStepState: state},
+		}},
+	}
+}
+
+func effects(gates TestGates) map[string]string {
+	effects := map[string]string{}
+	for _, step := range gates.Steps {
+		for _, test := range step.Tests {
+			effects[test.Name] = test.Effect
+		}
+	}
+	return effects
+}
+
+func TestEvaluateTestGates(t *testing.T) {
+	other := rolloutTest("other", 2, openkruisev1alpha1.RolloutTestPhaseFailed, "rev2")
+	other.Spec.RolloutName = "db"
+	tests := []openkruisev1alpha1.RolloutTest{
+		rolloutTest("smoke", 1, openkruisev1alpha1.RolloutTestPhaseSucceeded, "rev2"),
+		rolloutTest("load", 2, openkruisev1alpha1.RolloutTestPhaseRunning, "rev2"),
+		rolloutTest("e2e", 2, openkruisev1alpha1.RolloutTestPhaseSucceeded, "rev1"),
+		rolloutTest("soak", 3, openkruisev1alpha1.RolloutTestPhaseWaitingForStep, ""),
+		other,
+	}
+
+	gates := EvaluateTestGates(canaryAt(2, kruiserolloutv1beta1.CanaryStepStatePaused), tests)
+	assert.Equal(t, int32(2), gates.CurrentStep)
+	assert.Equal(t, map[string]string{
+		"smoke": TestEffectDone,
+		"load":  TestEffectWaiting,
+		// Succeeded for the previous revision only, so it has not run for this canary
+		"e2e":  TestEffectWaiting,
+		"soak": TestEffectUpcoming,
+	}, effects(gates))
+	assert.Len(t, gates.Steps, 3)
+	assert.Equal(t, []string{"e2e", "load"}, []string{gates.Steps[1].Tests[0].Name, gates.Steps[1].Tests[1].Name})
+	assert.False(t, gates.Steps[1].Tests[0].CurrentRevision)
+	assert.True(t, gates.Blocked)
+	assert.Equal(t, "waiting for tests: e2e, load", gates.Reason)
+
+	tests[1].Status.Phase = openkruisev1alpha1.RolloutTestPhaseFailed
+	gates = EvaluateTestGates(canaryAt(2, kruiserolloutv1beta1.CanaryStepStatePaused), tests)
+	assert.Equal(t, "tests failed: load", gates.Reason)
+
+	tests[1].Status.Phase = openkruisev1alpha1.RolloutTestPhaseSkipped
+	tests[2].Status.ObservedCanaryRevision = "rev2"
+	gates = EvaluateTestGates(canaryAt(2, kruiserolloutv1beta1.CanaryStepStatePaused), tests)
+	assert.False(t, gates.Blocked)
+	assert.Equal(t, TestEffectPassing, effects(gates)["load"])
+
+	gates = EvaluateTestGates(canaryAt(3, kruiserolloutv1beta1.CanaryStepStateUpgrade), tests)
+	assert.Equal(t, "waiting for tests: soak (waiting for the step to pause)", gates.Reason)
+
+	// Without a canary in progress every test is upcoming and nothing is blocked
+	gates = EvaluateTestGates(&kruiserolloutv1beta1.Rollout{ObjectMeta: metav1.ObjectMeta{Name: "app"}}, tests)
+	assert.False(t, gates.Blocked)
+	assert.Equal(t, TestEffectUpcoming, effects(gates)["smoke"])
+}
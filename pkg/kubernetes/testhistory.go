@@ -0,0 +1,272 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	openkruisev1alpha1 "github.com/kuberik/openkruise-controller/api/v1alpha1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TestHistoryAnnotation records the finished runs of a RolloutTest as a JSON list of TestRun,
+// newest first. The openkruise-controller deletes the Job of a test when the next canary starts,
+// so the dashboard records each run before its Job is gone, see RecordTestRuns.
+const TestHistoryAnnotation = "rollout.kuberik.com/test-history"
+
+// maxTestRuns bounds the runs kept in TestHistoryAnnotation
+const maxTestRuns = 20
+
+// canaryRevisionLabel is the label the openkruise-controller sets on test Jobs with the canary
+// revision they ran for
+const canaryRevisionLabel = "rollout.kuberik.io/canary-revision"
+
+// TestRun is a run of a RolloutTest
+type TestRun struct {
+	// Version is the version the rollout was deploying when the run started, empty when unknown
+	Version        string     `json:"version,omitempty"`
+	CanaryRevision string     `json:"canaryRevision,omitempty"`
+	Phase          string     `json:"phase"`
+	Job            string     `json:"job"`
+	StartedAt      *time.Time `json:"startedAt,omitempty"`
+	FinishedAt     *time.Time `json:"finishedAt,omitempty"`
+	// DurationSeconds is only set for finished runs
+	DurationSeconds *int64 `json:"durationSeconds,omitempty"`
+}
+
+// TestHistory is the runs of a RolloutTest, newest first
+type TestHistory struct {
+	Name string    `json:"name"`
+	Step int32     `json:"step"`
+	Runs []TestRun `json:"runs"`
+	// ConsecutiveFailures is the number of the newest versions the test failed on, each counted by
+	// its latest finished run
+	ConsecutiveFailures int `json:"consecutiveFailures"`
+}
+
+// GetRecordedTestRuns returns the runs recorded in TestHistoryAnnotation, none when the annotation
+// cannot be parsed
+func GetRecordedTestRuns(test *openkruisev1alpha1.RolloutTest) []TestRun {
+	raw := test.Annotations[TestHistoryAnnotation]
+	if raw == "" {
+		return nil
+	}
+	var runs []TestRun
+	if err := json.Unmarshal([]byte(raw), &runs); err != nil {
+		return nil
+	}
+	return runs
+}
+
+// GetRolloutTestHistory returns the run history of the RolloutTests of a Kruise rollout, ordered
+// by step. The recorded runs are completed with the test's current run.
+func (c *Client) GetRolloutTestHistory(ctx context.Context, namespace, name string) ([]TestHistory, error) {
+	tests, err := c.GetRolloutTestsByRolloutName(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	rollout, err := c.testedRollout(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	histories := make([]TestHistory, 0, len(tests.Items))
+	for i := range tests.Items {
+		test := &tests.Items[i]
+		runs := GetRecordedTestRuns(test)
+		current, err := c.currentTestRun(ctx, test, rollout)
+		if err != nil {
+			return nil, err
+		}
+		if current != nil && !recorded(runs, current.Job) {
+			runs = append([]TestRun{*current}, runs...)
+		}
+		if runs == nil {
+			runs = []TestRun{}
+		}
+		histories = append(histories, TestHistory{
+			Name:                test.Name,
+			Step:                test.Spec.StepIndex,
+			Runs:                runs,
+			ConsecutiveFailures: consecutiveFailures(runs),
+		})
+	}
+	sort.SliceStable(histories, func(i, j int) bool {
+		if histories[i].Step != histories[j].Step {
+			return histories[i].Step < histories[j].Step
+		}
+		return histories[i].Name < histories[j].Name
+	})
+	return histories, nil
+}
+
+// RecordTestRuns adds the finished runs of all RolloutTests whose Jobs still exist to their
+// TestHistoryAnnotation, returning how many runs were recorded. Runs are recorded once per Job.
+func (c *Client) RecordTestRuns(ctx context.Context) (int, error) {
+	tests := &openkruisev1alpha1.RolloutTestList{}
+	if err := c.client.List(ctx, tests); err != nil {
+		return 0, fmt.Errorf("failed to list rollout tests: %w", err)
+	}
+	count := 0
+	for i := range tests.Items {
+		recorded, err := c.recordTestRun(ctx, &tests.Items[i])
+		if err != nil {
+			return count, err
+		}
+		if recorded {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// recordTestRun adds the finished run of the test's current Job to its TestHistoryAnnotation,
+// reporting whether it did. The test is updated in place.
+func (c *Client) recordTestRun(ctx context.Context, test *openkruisev1alpha1.RolloutTest) (bool, error) {
+	runs := GetRecordedTestRuns(test)
+	if !testFinished(test.Status.Phase) || test.Status.JobName == "" || recorded(runs, test.Status.JobName) {
+		return false, nil
+	}
+	rollout, err := c.testedRollout(ctx, test.Namespace, test.Spec.RolloutName)
+	if err != nil {
+		return false, err
+	}
+	run, err := c.currentTestRun(ctx, test, rollout)
+	if err != nil || run == nil {
+		return false, err
+	}
+	runs = append([]TestRun{*run}, runs...)
+	if len(runs) > maxTestRuns {
+		runs = runs[:maxTestRuns]
+	}
+	value, err := json.Marshal(runs)
+	if err != nil {
+		return false, err
+	}
+	// Recording the same run from several replicas at once fails on the resource version instead
+	// of losing runs
+	base := test.DeepCopy()
+	if test.Annotations == nil {
+		test.Annotations = map[string]string{}
+	}
+	test.Annotations[TestHistoryAnnotation] = string(value)
+	if err := c.client.Patch(ctx, test, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{}), client.FieldOwner("rollout-dashboard")); err != nil {
+		if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to record run of rollout test %s/%s: %w", test.Namespace, test.Name, err)
+	}
+	return true, nil
+}
+
+// testedRollout returns the kuberik Rollout named like the Kruise rollout of a test, nil when
+// there is none
+func (c *Client) testedRollout(ctx context.Context, namespace, name string) (*rolloutv1alpha1.Rollout, error) {
+	rollout := &rolloutv1alpha1.Rollout{}
+	if err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, rollout); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get rollout: %w", err)
+	}
+	return rollout, nil
+}
+
+// currentTestRun returns the run of the test's current Job, nil when it has none or the Job is gone
+func (c *Client) currentTestRun(ctx context.Context, test *openkruisev1alpha1.RolloutTest, rollout *rolloutv1alpha1.Rollout) (*TestRun, error) {
+	if test.Status.JobName == "" {
+		return nil, nil
+	}
+	job := &batchv1.Job{}
+	if err := c.client.Get(ctx, client.ObjectKey{Namespace: test.Namespace, Name: test.Status.JobName}, job); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	run := &TestRun{
+		CanaryRevision: job.Labels[canaryRevisionLabel],
+		Phase:          string(test.Status.Phase),
+		Job:            job.Name,
+	}
+	started := job.CreationTimestamp.Time
+	if job.Status.StartTime != nil {
+		started = job.Status.StartTime.Time
+	}
+	run.StartedAt = &started
+	if rollout != nil {
+		run.Version = versionAt(rollout, started)
+	}
+	if testFinished(test.Status.Phase) {
+		if finished := jobFinishedAt(job); finished != nil {
+			duration := int64(finished.Sub(started).Seconds())
+			run.FinishedAt, run.DurationSeconds = finished, &duration
+		}
+	}
+	return run, nil
+}
+
+// versionAt returns the version of the newest deployment of the rollout that started by at
+func versionAt(rollout *rolloutv1alpha1.Rollout, at time.Time) string {
+	for _, entry := range rollout.Status.History {
+		if !entry.Timestamp.After(at) {
+			return entry.Version.Tag
+		}
+	}
+	return ""
+}
+
+// jobFinishedAt returns when a Job completed or failed
+func jobFinishedAt(job *batchv1.Job) *time.Time {
+	if job.Status.CompletionTime != nil {
+		return &job.Status.CompletionTime.Time
+	}
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return &condition.LastTransitionTime.Time
+		}
+	}
+	return nil
+}
+
+func testFinished(phase openkruisev1alpha1.RolloutTestPhase) bool {
+	return phase == openkruisev1alpha1.RolloutTestPhaseSucceeded || phase == openkruisev1alpha1.RolloutTestPhaseFailed
+}
+
+func recorded(runs []TestRun, job string) bool {
+	for _, run := range runs {
+		if run.Job == job {
+			return true
+		}
+	}
+	return false
+}
+
+// consecutiveFailures counts the newest versions whose latest finished run failed. Runs of an
+// unknown version count as versions of their own.
+func consecutiveFailures(runs []TestRun) int {
+	failures := 0
+	seen := map[string]bool{}
+	for _, run := range runs {
+		if !testFinished(openkruisev1alpha1.RolloutTestPhase(run.Phase)) {
+			continue
+		}
+		if run.Version != "" {
+			if seen[run.Version] {
+				continue
+			}
+			seen[run.Version] = true
+		}
+		if run.Phase != string(openkruisev1alpha1.RolloutTestPhaseFailed) {
+			break
+		}
+		failures++
+	}
+	return failures
+}
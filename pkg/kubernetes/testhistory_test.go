@@ -0,0 +1,97 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	openkruisev1alpha1 "github.com/kuberik/openkruise-controller/api/v1alpha1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRolloutTestHistory(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, openkruisev1alpha1.AddToScheme(scheme))
+	require.NoError(t, rolloutv1alpha1.AddToScheme(scheme))
+
+	deployed := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	rollout := &rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "app"}}
+	rollout.Status.History = []rolloutv1alpha1.DeploymentHistoryEntry{
+		{Version: rolloutv1alpha1.VersionInfo{Tag: "1.3.0"}, Timestamp: metav1.NewTime(deployed)},
+		{Version: rolloutv1alpha1.VersionInfo{Tag: "1.2.0"}, Timestamp: metav1.NewTime(deployed.Add(-24 * time.Hour))},
+	}
+	smoke := &openkruisev1alpha1.RolloutTest{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "smoke", Annotations: map[string]string{
+			TestHistoryAnnotation: `[{"version":"1.2.0","phase":"Failed","job":"smoke-b"},{"version":"1.2.0","phase":"Failed","job":"smoke-a"},{"version":"1.1.0","phase":"Succeeded","job":"smoke-0"}]`,
+		}},
+		Spec:   openkruisev1alpha1.RolloutTestSpec{RolloutName: "app", StepIndex: 2},
+		Status: openkruisev1alpha1.RolloutTestStatus{Phase: openkruisev1alpha1.RolloutTestPhaseFailed, JobName: "smoke-c"},
+	}
+	load := &openkruisev1alpha1.RolloutTest{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "load"},
+		Spec:       openkruisev1alpha1.RolloutTestSpec{RolloutName: "app", StepIndex: 1},
+		Status:     openkruisev1alpha1.RolloutTestStatus{Phase: openkruisev1alpha1.RolloutTestPhaseRunning, JobName: "load-a"},
+	}
+	started := metav1.NewTime(deployed.Add(10 * time.Minute))
+	c := &Client{client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(rollout, smoke, load,
+		&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "smoke-c", Labels: map[string]string{canaryRevisionLabel: "abc"}},
+			Status: batchv1.JobStatus{StartTime: &started, Conditions: []batchv1.JobCondition{
+				{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(started.Add(90 * time.Second))},
+			}},
+		},
+		&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "load-a"},
+			Status:     batchv1.JobStatus{StartTime: &started},
+		},
+	).Build()}
+
+	history, err := c.GetRolloutTestHistory(ctx, "apps", "app")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+
+	assert.Equal(t, "load", history[0].Name)
+	require.Len(t, history[0].Runs, 1)
+	assert.Equal(t, "Running", history[0].Runs[0].Phase)
+	assert.Nil(t, history[0].Runs[0].DurationSeconds)
+	assert.Equal(t, 0, history[0].ConsecutiveFailures)
+
+	// The current run comes first, reruns of 1.2.0 count once
+	assert.Equal(t, "smoke", history[1].Name)
+	require.Len(t, history[1].Runs, 4)
+	current := history[1].Runs[0]
+	assert.Equal(t, "1.3.0", current.Version)
+	assert.Equal(t, "abc", current.CanaryRevision)
+	assert.Equal(t, "smoke-c", current.Job)
+	require.NotNil(t, current.DurationSeconds)
+	assert.Equal(t, int64(90), *current.DurationSeconds)
+	assert.Equal(t, 2, history[1].ConsecutiveFailures)
+
+	// Only finished runs are recorded, once
+	recordedRuns, err := c.RecordTestRuns(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, recordedRuns)
+	recordedRuns, err = c.RecordTestRuns(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, recordedRuns)
+
+	stored := &openkruisev1alpha1.RolloutTest{}
+	require.NoError(t, c.client.Get(ctx, client.ObjectKey{Namespace: "apps", Name: "smoke"}, stored))
+	runs := GetRecordedTestRuns(stored)
+	require.Len(t, runs, 4)
+	assert.Equal(t, current.Job, runs[0].Job)
+	assert.Equal(t, current.Version, runs[0].Version)
+	require.NoError(t, c.client.Get(ctx, client.ObjectKey{Namespace: "apps", Name: "load"}, stored))
+	assert.Empty(t, GetRecordedTestRuns(stored))
+}
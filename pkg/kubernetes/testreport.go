@@ -0,0 +1,166 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	openkruisev1alpha1 "github.com/kuberik/openkruise-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/junit"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/remotecommand"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// JUnitPathAnnotation on a RolloutTest names the file its containers write a JUnit XML report to,
+// DefaultJUnitPath when unset
+const JUnitPathAnnotation = "rollout.kuberik.com/junit-path"
+
+// DefaultJUnitPath is where test containers write their JUnit XML report by default
+const DefaultJUnitPath = "/reports/junit.xml"
+
+// Sources a test report is read from
+const (
+	// ReportSourceTerminationMessage reports are the termination message of a finished container,
+	// see junit.Decode
+	ReportSourceTerminationMessage = "terminationMessage"
+	// ReportSourceFile reports are read from the report file of a running container
+	ReportSourceFile = "file"
+)
+
+// maxReportFileSize bounds the report file read from a container
+const maxReportFileSize = 10 << 20
+
+// ErrNoTestReport is returned when no container of a test Job has a JUnit report
+var ErrNoTestReport = errors.New("no test report found")
+
+// TestReportSource is a container a report was read from, or failed to be read from
+type TestReportSource struct {
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	Source    string `json:"source"`
+	Error     string `json:"error,omitempty"`
+}
+
+// TestReport is the JUnit report of a run of a RolloutTest
+type TestReport struct {
+	RolloutTest string `json:"rolloutTest"`
+	Job         string `json:"job"`
+	// Pod is the pod the report is from, the newest one with a report when the Job retried
+	Pod     string             `json:"pod"`
+	Report  *junit.Report      `json:"report"`
+	Sources []TestReportSource `json:"sources"`
+}
+
+// GetRolloutTestReport reads the JUnit report of a RolloutTest's Job, its current Job when job is
+// empty. Reports are read from the termination message of finished containers and, for containers
+// still running, from the file named by JUnitPathAnnotation. The reports of a pod's containers are
+// merged; of several pods, e.g. retries of the Job, the newest pod with a report is used.
+func (c *Client) GetRolloutTestReport(ctx context.Context, namespace, name, job string) (*TestReport, error) {
+	test := &openkruisev1alpha1.RolloutTest{}
+	if err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, test); err != nil {
+		return nil, fmt.Errorf("failed to get rollout test: %w", err)
+	}
+	if job == "" {
+		job = test.Status.JobName
+	}
+	if job == "" {
+		return nil, fmt.Errorf("%w: %s has no job", ErrNoTestReport, name)
+	}
+	pods, err := c.GetPodsByJobName(ctx, namespace, job)
+	if err != nil {
+		return nil, err
+	}
+	path := test.Annotations[JUnitPathAnnotation]
+	if path == "" {
+		path = DefaultJUnitPath
+	}
+
+	sort.SliceStable(pods.Items, func(i, j int) bool {
+		return pods.Items[j].CreationTimestamp.Before(&pods.Items[i].CreationTimestamp)
+	})
+	result := &TestReport{RolloutTest: name, Job: job, Sources: []TestReportSource{}}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		var reports []*junit.Report
+		for _, status := range pod.Status.ContainerStatuses {
+			report, source := c.containerTestReport(ctx, pod, status, path)
+			if source != nil {
+				result.Sources = append(result.Sources, *source)
+			}
+			if report != nil {
+				reports = append(reports, report)
+			}
+		}
+		if len(reports) > 0 {
+			result.Pod, result.Report = pod.Name, junit.Merge(reports...)
+			return result, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no container of job %s has a JUnit report in its termination message or at %s", ErrNoTestReport, job, path)
+}
+
+// containerTestReport reads the report of a container. Containers without one have no source,
+// unless reading it failed.
+func (c *Client) containerTestReport(ctx context.Context, pod *corev1.Pod, status corev1.ContainerStatus, path string) (*junit.Report, *TestReportSource) {
+	source := &TestReportSource{Pod: pod.Name, Container: status.Name}
+	var data []byte
+	switch {
+	case status.State.Terminated != nil:
+		source.Source = ReportSourceTerminationMessage
+		data = []byte(status.State.Terminated.Message)
+	case status.State.Running != nil:
+		source.Source = ReportSourceFile
+		var err error
+		if data, err = c.readContainerFile(ctx, pod, status.Name, path); err != nil {
+			source.Error = err.Error()
+			return nil, source
+		}
+	default:
+		return nil, nil
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	report, err := junit.Decode(data)
+	if errors.Is(err, junit.ErrNotJUnit) {
+		// Plain termination messages, e.g. the log tail of FallbackToLogsOnError
+		return nil, nil
+	}
+	if err != nil {
+		source.Error = err.Error()
+		return nil, source
+	}
+	return report, source
+}
+
+// readContainerFile reads a file of a running container by executing cat in it
+func (c *Client) readContainerFile(ctx context.Context, pod *corev1.Pod, container, path string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	err := c.ExecInPod(ctx, pod.Namespace, pod.Name, container, []string{"cat", path}, remotecommand.StreamOptions{
+		Stdout: &limitedBuffer{buffer: &stdout, limit: maxReportFileSize},
+		Stderr: &limitedBuffer{buffer: &stderr, limit: 4 << 10},
+	})
+	if err != nil {
+		if message := bytes.TrimSpace(stderr.Bytes()); len(message) > 0 {
+			return nil, fmt.Errorf("failed to read %s: %s", path, message)
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// limitedBuffer is a writer failing once more than limit bytes were written
+type limitedBuffer struct {
+	buffer *bytes.Buffer
+	limit  int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.buffer.Len()+len(p) > b.limit {
+		return 0, fmt.Errorf("output exceeds %d bytes", b.limit)
+	}
+	return b.buffer.Write(p)
+}
@@ -0,0 +1,69 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	openkruisev1alpha1 "github.com/kuberik/openkruise-controller/api/v1alpha1"
+	"github.com/kuberik/rollout-dashboard/pkg/junit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRolloutTestReport(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, openkruisev1alpha1.AddToScheme(scheme))
+
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	owner := []metav1.OwnerReference{{APIVersion: "batch/v1", Kind: "Job", Name: "smoke-x1", UID: "job-uid"}}
+	terminated := func(name, message string) corev1.ContainerStatus {
+		return corev1.ContainerStatus{Name: name, State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: message}}}
+	}
+	c := &Client{client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&openkruisev1alpha1.RolloutTest{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "smoke"},
+			Status:     openkruisev1alpha1.RolloutTestStatus{JobName: "smoke-x1"},
+		},
+		&openkruisev1alpha1.RolloutTest{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "load"}},
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "smoke-x1", UID: "job-uid"}},
+		// The first attempt of the Job, superseded by the retry
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "smoke-x1-a", OwnerReferences: owner, CreationTimestamp: metav1.NewTime(created)},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				terminated("test", `<testsuite name="old"><testcase name="flaky"><failure/></testcase></testsuite>`),
+			}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "smoke-x1-b", OwnerReferences: owner, CreationTimestamp: metav1.NewTime(created.Add(time.Minute))},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				terminated("api", `<testsuite name="api"><testcase name="orders"><failure message="500"/></testcase></testsuite>`),
+				terminated("ui", `<testsuites><testsuite name="ui"><testcase name="login"/></testsuite></testsuites>`),
+				terminated("proxy", "exited"),
+			}},
+		},
+	).Build()}
+
+	report, err := c.GetRolloutTestReport(ctx, "apps", "smoke", "")
+	require.NoError(t, err)
+	assert.Equal(t, "smoke-x1", report.Job)
+	assert.Equal(t, "smoke-x1-b", report.Pod)
+	assert.Equal(t, []TestReportSource{
+		{Pod: "smoke-x1-b", Container: "api", Source: ReportSourceTerminationMessage},
+		{Pod: "smoke-x1-b", Container: "ui", Source: ReportSourceTerminationMessage},
+	}, report.Sources)
+	assert.Equal(t, 2, report.Report.Tests)
+	assert.Equal(t, 1, report.Report.Failures)
+	assert.Equal(t, junit.Case{Name: "orders", Status: junit.StatusFailed, Message: "500"}, report.Report.Suites[0].Cases[0])
+
+	_, err = c.GetRolloutTestReport(ctx, "apps", "load", "")
+	assert.ErrorIs(t, err, ErrNoTestReport)
+}
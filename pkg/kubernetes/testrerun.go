@@ -0,0 +1,81 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	openkruisev1alpha1 "github.com/kuberik/openkruise-controller/api/v1alpha1"
+	kruiserolloutv1beta1 "github.com/openkruise/kruise-rollout-api/rollouts/v1beta1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// rolloutTestJobLabel is the label the openkruise-controller sets on the Jobs of a RolloutTest
+const rolloutTestJobLabel = "rollout-test"
+
+var (
+	// ErrTestNotRun is returned when rerunning a RolloutTest that has not run yet
+	ErrTestNotRun = errors.New("rollout test has not run yet")
+	// ErrRolloutStalled is returned when rerunning a RolloutTest of a stalled Kruise rollout, for
+	// which the controller does not create Jobs until the rollout is retried
+	ErrRolloutStalled = errors.New("kruise rollout is stalled")
+)
+
+// RerunRolloutTest runs a RolloutTest again by deleting its Jobs and resetting it to
+// WaitingForStep, after which the openkruise-controller creates a new Job while the Kruise rollout
+// is paused at the test's step. It returns the reset test and the names of the deleted Jobs.
+func (c *Client) RerunRolloutTest(ctx context.Context, namespace, name string) (*openkruisev1alpha1.RolloutTest, []string, error) {
+	test := &openkruisev1alpha1.RolloutTest{}
+	if err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, test); err != nil {
+		return nil, nil, fmt.Errorf("failed to get rollout test: %w", err)
+	}
+	if test.Status.Phase == "" || test.Status.Phase == openkruisev1alpha1.RolloutTestPhaseWaitingForStep {
+		return nil, nil, fmt.Errorf("%w: %s", ErrTestNotRun, name)
+	}
+	rollout := &kruiserolloutv1beta1.Rollout{}
+	err := c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: test.Spec.RolloutName}, rollout)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, nil, fmt.Errorf("failed to get kruise rollout: %w", err)
+	}
+	for _, condition := range rollout.Status.Conditions {
+		if condition.Type == "Stalled" && condition.Status == corev1.ConditionTrue {
+			return nil, nil, fmt.Errorf("%w: %s, retry the rollout to rerun its failed tests", ErrRolloutStalled, condition.Reason)
+		}
+	}
+
+	// The run is replaced, keep it in the test's history
+	if _, err := c.recordTestRun(ctx, test); err != nil {
+		return nil, nil, err
+	}
+
+	jobs := &batchv1.JobList{}
+	if err := c.client.List(ctx, jobs, client.InNamespace(namespace), client.MatchingLabels{rolloutTestJobLabel: name}); err != nil {
+		return nil, nil, fmt.Errorf("failed to list rollout test jobs: %w", err)
+	}
+	var deleted []string
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if err := c.client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+			return nil, deleted, fmt.Errorf("failed to delete job %s: %w", job.Name, err)
+		}
+		deleted = append(deleted, job.Name)
+	}
+
+	// Without a Job in a non-terminal phase the controller starts the test again, as it does when
+	// a retry of the rollout resets its failed tests
+	test.Status.Phase = openkruisev1alpha1.RolloutTestPhaseWaitingForStep
+	test.Status.JobName = ""
+	test.Status.RetryCount = 0
+	test.Status.ActivePods = 0
+	test.Status.SucceededPods = 0
+	test.Status.FailedPods = 0
+	test.Status.Conditions = nil
+	if err := c.client.Status().Update(ctx, test); err != nil {
+		return nil, deleted, fmt.Errorf("failed to reset rollout test: %w", err)
+	}
+	return test, deleted, nil
+}
@@ -0,0 +1,89 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	openkruisev1alpha1 "github.com/kuberik/openkruise-controller/api/v1alpha1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	kruiserolloutv1beta1 "github.com/openkruise/kruise-rollout-api/rollouts/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRerunRolloutTest(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, openkruisev1alpha1.AddToScheme(scheme))
+	require.NoError(t, kruiserolloutv1beta1.AddToScheme(scheme))
+	require.NoError(t, rolloutv1alpha1.AddToScheme(scheme))
+
+	failed := &openkruisev1alpha1.RolloutTest{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "smoke"},
+		Spec:       openkruisev1alpha1.RolloutTestSpec{RolloutName: "app", StepIndex: 1},
+		Status: openkruisev1alpha1.RolloutTestStatus{
+			Phase: openkruisev1alpha1.RolloutTestPhaseFailed, JobName: "smoke-x1", FailedPods: 2, ObservedCanaryRevision: "abc",
+			Conditions: []metav1.Condition{{Type: "Failed", Status: metav1.ConditionTrue, Reason: "JobFailed"}},
+		},
+	}
+	waiting := &openkruisev1alpha1.RolloutTest{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "load"},
+		Spec:       openkruisev1alpha1.RolloutTestSpec{RolloutName: "app", StepIndex: 2},
+		Status:     openkruisev1alpha1.RolloutTestStatus{Phase: openkruisev1alpha1.RolloutTestPhaseWaitingForStep},
+	}
+	stalled := &openkruisev1alpha1.RolloutTest{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "e2e"},
+		Spec:       openkruisev1alpha1.RolloutTestSpec{RolloutName: "stalled", StepIndex: 1},
+		Status:     openkruisev1alpha1.RolloutTestStatus{Phase: openkruisev1alpha1.RolloutTestPhaseFailed},
+	}
+	c := &Client{client: fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(failed, waiting, stalled,
+			&kruiserolloutv1beta1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "app"}},
+			&kruiserolloutv1beta1.Rollout{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "stalled"},
+				Status: kruiserolloutv1beta1.RolloutStatus{Conditions: []kruiserolloutv1beta1.RolloutCondition{
+					{Type: "Stalled", Status: corev1.ConditionTrue, Reason: "RolloutTestFailed"},
+				}},
+			},
+			&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "smoke-x1", Labels: map[string]string{"rollout-test": "smoke"}}},
+			&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "load-y2", Labels: map[string]string{"rollout-test": "load"}}},
+		).
+		WithStatusSubresource(&openkruisev1alpha1.RolloutTest{}).
+		Build()}
+
+	test, deleted, err := c.RerunRolloutTest(ctx, "apps", "smoke")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"smoke-x1"}, deleted)
+	assert.Equal(t, openkruisev1alpha1.RolloutTestPhaseWaitingForStep, test.Status.Phase)
+	assert.Empty(t, test.Status.JobName)
+
+	stored := &openkruisev1alpha1.RolloutTest{}
+	require.NoError(t, c.client.Get(ctx, client.ObjectKey{Namespace: "apps", Name: "smoke"}, stored))
+	assert.Equal(t, openkruisev1alpha1.RolloutTestStatus{
+		Phase: openkruisev1alpha1.RolloutTestPhaseWaitingForStep, ObservedCanaryRevision: "abc",
+	}, stored.Status)
+	// The replaced run is kept in the history
+	runs := GetRecordedTestRuns(stored)
+	require.Len(t, runs, 1)
+	assert.Equal(t, "smoke-x1", runs[0].Job)
+	assert.Equal(t, "Failed", runs[0].Phase)
+	jobs := &batchv1.JobList{}
+	require.NoError(t, c.client.List(ctx, jobs, client.InNamespace("apps")))
+	require.Len(t, jobs.Items, 1)
+	assert.Equal(t, "load-y2", jobs.Items[0].Name)
+
+	_, _, err = c.RerunRolloutTest(ctx, "apps", "load")
+	assert.ErrorIs(t, err, ErrTestNotRun)
+
+	_, _, err = c.RerunRolloutTest(ctx, "apps", "e2e")
+	assert.ErrorIs(t, err, ErrRolloutStalled)
+}
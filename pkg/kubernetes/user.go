@@ -3,6 +3,7 @@ package kubernetes
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"strings"
 
 	authenticationv1 "k8s.io/api/authentication/v1"
@@ -10,6 +11,7 @@ import (
 	authenticationv1beta1 "k8s.io/api/authentication/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -18,14 +20,29 @@ import (
 // Returns the username and a boolean indicating if it's a service account
 // Returns empty string and false if unable to determine identity
 func (c *Client) GetCurrentUserIdentity(ctx context.Context) (string, bool, error) {
+	userInfo, err := c.GetCurrentUserInfo(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	return userInfo.Username, IsServiceAccount(userInfo.Username), nil
+}
+
+// IsServiceAccount reports whether username is a service account's
+func IsServiceAccount(username string) bool {
+	return strings.HasPrefix(username, "system:serviceaccount:")
+}
+
+// GetCurrentUserInfo returns the current user's name, UID, groups and extra attributes as the API
+// server authenticates them, see GetCurrentUserIdentity
+func (c *Client) GetCurrentUserInfo(ctx context.Context) (*authenticationv1.UserInfo, error) {
 	if c.config == nil {
-		return "", false, fmt.Errorf("REST config is nil - client was not properly initialized")
+		return nil, fmt.Errorf("REST config is nil - client was not properly initialized")
 	}
 
 	// Create clientset using the stored config (which includes the OIDC token)
 	clientset, err := kubernetes.NewForConfig(c.config)
 	if err != nil {
-		return "", false, fmt.Errorf("failed to create clientset: %w", err)
+		return nil, fmt.Errorf("failed to create clientset: %w", err)
 	}
 
 	// Use SelfSubjectReview API (same as kubectl auth whoami)
@@ -41,53 +58,91 @@ func (c *Client) GetCurrentUserIdentity(ctx context.Context) (string, bool, erro
 			resAlpha, errAlpha := clientset.AuthenticationV1alpha1().SelfSubjectReviews().Create(ctx, &authenticationv1alpha1.SelfSubjectReview{}, metav1.CreateOptions{})
 			if errAlpha != nil {
 				if errors.IsForbidden(errAlpha) {
-					return "", false, fmt.Errorf("selfsubjectreviews API is not enabled in the cluster or you do not have permission to call it")
+					return nil, fmt.Errorf("selfsubjectreviews API is not enabled in the cluster or you do not have permission to call it")
 				}
 				if errors.IsNotFound(errAlpha) {
-					return "", false, fmt.Errorf("selfsubjectreviews API is not enabled in the cluster")
+					return nil, fmt.Errorf("selfsubjectreviews API is not enabled in the cluster")
 				}
-				return "", false, fmt.Errorf("failed to get user identity: %w", errAlpha)
+				return nil, fmt.Errorf("failed to get user identity: %w", errAlpha)
 			}
 			userInfo = resAlpha.Status.UserInfo
 		} else if errBeta != nil {
 			if errors.IsForbidden(errBeta) {
-				return "", false, fmt.Errorf("selfsubjectreviews API is not enabled in the cluster or you do not have permission to call it")
+				return nil, fmt.Errorf("selfsubjectreviews API is not enabled in the cluster or you do not have permission to call it")
 			}
-			return "", false, fmt.Errorf("failed to get user identity: %w", errBeta)
+			return nil, fmt.Errorf("failed to get user identity: %w", errBeta)
 		} else {
 			userInfo = resBeta.Status.UserInfo
 		}
 	} else if err != nil {
 		if errors.IsForbidden(err) {
-			return "", false, fmt.Errorf("selfsubjectreviews API is not enabled in the cluster or you do not have permission to call it")
+			return nil, fmt.Errorf("selfsubjectreviews API is not enabled in the cluster or you do not have permission to call it")
 		}
-		return "", false, fmt.Errorf("failed to get user identity: %w", err)
+		return nil, fmt.Errorf("failed to get user identity: %w", err)
 	} else {
 		userInfo = res.Status.UserInfo
 	}
 
 	if userInfo.Username == "" {
-		return "", false, fmt.Errorf("username not available in SelfSubjectReview response")
+		return nil, fmt.Errorf("username not available in SelfSubjectReview response")
 	}
 
-	username := userInfo.Username
-	isServiceAccount := strings.HasPrefix(username, "system:serviceaccount:")
-
-	return username, isServiceAccount, nil
+	return &userInfo, nil
 }
 
 // FormatUserInfo formats user information for appending to deploy messages
 // Returns empty string if user is a service account
 func (c *Client) FormatUserInfo(ctx context.Context) (string, error) {
-	username, isServiceAccount, err := c.GetCurrentUserIdentity(ctx)
+	username, _, err := c.GetCurrentUserIdentity(ctx)
 	if err != nil {
 		return "", err
 	}
+	return formatUserInfo(username), nil
+}
 
-	if isServiceAccount {
-		return "", nil
+func formatUserInfo(username string) string {
+	if IsServiceAccount(username) {
+		return ""
 	}
 
 	// Format: "Triggered by: <username>"
-	return fmt.Sprintf("Triggered by: %s", username), nil
+	return fmt.Sprintf("Triggered by: %s", username)
+}
+
+// ChangedByAnnotation records who last changed a rollout through the dashboard
+const ChangedByAnnotation = "rollout.kuberik.com/changed-by"
+
+// actingUser returns the user the client acts as, or empty string when it cannot be determined,
+// e.g. because the SelfSubjectReview API is not available
+func (c *Client) actingUser(ctx context.Context) string {
+	username, _, err := c.GetCurrentUserIdentity(ctx)
+	if err != nil {
+		slog.Debug("Could not determine acting user", "error", err)
+		return ""
+	}
+	return username
+}
+
+// attributeChange records username in a rollout merge patch with the changed-by annotation. For
+// changes that deploy a version, users (not service accounts) are also recorded in the deploy-user
+// annotation, which the controller copies into the deployment history, and in the deploy message.
+func attributeChange(patch *unstructured.Unstructured, username string, deploy bool) {
+	if username == "" {
+		return
+	}
+	metadata := patch.Object["metadata"].(map[string]any)
+	annotations, _ := metadata["annotations"].(map[string]any)
+	if annotations == nil {
+		annotations = map[string]any{}
+	}
+	annotations[ChangedByAnnotation] = username
+	if info := formatUserInfo(username); deploy && info != "" {
+		annotations[DeployUserAnnotation] = username
+		if message, _ := annotations[DeployMessageAnnotation].(string); message != "" {
+			annotations[DeployMessageAnnotation] = message + "\n" + info
+		} else {
+			annotations[DeployMessageAnnotation] = info
+		}
+	}
+	metadata["annotations"] = annotations
 }
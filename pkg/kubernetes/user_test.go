@@ -0,0 +1,51 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestAttributeChange(t *testing.T) {
+	patch := func(annotations map[string]string) *unstructured.Unstructured {
+		p := &unstructured.Unstructured{Object: map[string]any{}}
+		p.SetName("api")
+		if annotations != nil {
+			p.SetAnnotations(annotations)
+		}
+		return p
+	}
+	annotations := func(p *unstructured.Unstructured) map[string]any {
+		return p.Object["metadata"].(map[string]any)["annotations"].(map[string]any)
+	}
+
+	deploy := patch(map[string]string{DeployMessageAnnotation: "hotfix"})
+	attributeChange(deploy, "jane@example.com", true)
+	assert.Equal(t, map[string]any{
+		DeployMessageAnnotation: "hotfix\nTriggered by: jane@example.com",
+		DeployUserAnnotation:    "jane@example.com",
+		ChangedByAnnotation:     "jane@example.com",
+	}, annotations(deploy))
+
+	deploy = patch(nil)
+	attributeChange(deploy, "jane@example.com", true)
+	assert.Equal(t, "Triggered by: jane@example.com", annotations(deploy)[DeployMessageAnnotation])
+
+	// Other changes and service accounts only record who made the change
+	unblock := patch(map[string]string{UnblockFailedAnnotation: "true"})
+	attributeChange(unblock, "jane@example.com", false)
+	assert.Equal(t, map[string]any{UnblockFailedAnnotation: "true", ChangedByAnnotation: "jane@example.com"}, annotations(unblock))
+
+	tracked := patch(map[string]string{DeployMessageAnnotation: "Tracking channel stable"})
+	attributeChange(tracked, "system:serviceaccount:dashboard:rollout-dashboard", true)
+	assert.Equal(t, map[string]any{
+		DeployMessageAnnotation: "Tracking channel stable",
+		ChangedByAnnotation:     "system:serviceaccount:dashboard:rollout-dashboard",
+	}, annotations(tracked))
+
+	// Nothing is recorded when the user is unknown
+	unknown := patch(nil)
+	attributeChange(unknown, "", true)
+	assert.NotContains(t, unknown.Object["metadata"], "annotations")
+}
@@ -0,0 +1,251 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Outcomes of validating a manifest against the cluster
+const (
+	ManifestValid   = "valid"
+	ManifestInvalid = "invalid"
+	ManifestSkipped = "skipped"
+)
+
+// ReasonNoKindMatch is the reason of manifests whose kind and version the cluster does not serve,
+// e.g. a custom resource written for a CRD version that is not installed
+const ReasonNoKindMatch = "NoKindMatch"
+
+// ManifestValidation is the result of server-side dry-run applying a manifest
+type ManifestValidation struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	// Reason is the API server's reason for rejecting the manifest, e.g. Invalid or Forbidden for
+	// a denying admission webhook, or NoKindMatch
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Causes are the fields the API server found invalid, as "field: message"
+	Causes []string `json:"causes,omitempty"`
+}
+
+// KustomizationValidation is the result of validating the manifests a Kustomization would apply.
+// Error is set when they could not be built.
+type KustomizationValidation struct {
+	Namespace string               `json:"namespace"`
+	Name      string               `json:"name"`
+	Error     string               `json:"error,omitempty"`
+	Manifests []ManifestValidation `json:"manifests"`
+}
+
+// Valid reports whether the manifests were built and none of them was rejected
+func (v KustomizationValidation) Valid() bool {
+	if v.Error != "" {
+		return false
+	}
+	for _, manifest := range v.Manifests {
+		if manifest.Status == ManifestInvalid {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateOption configures ValidateKustomization
+type ValidateOption func(*validateOptions)
+
+type validateOptions struct {
+	files         map[string][]byte
+	substitutions map[string]string
+}
+
+// WithArtifactFiles builds the manifests from files, by path inside the artifact, instead of the
+// current artifact of the Kustomization's source, e.g. from a release that is not deployed yet
+func WithArtifactFiles(files map[string][]byte) ValidateOption {
+	return func(o *validateOptions) {
+		o.files = files
+	}
+}
+
+// WithSubstitutions overrides post-build variables of the Kustomization
+func WithSubstitutions(vars map[string]string) ValidateOption {
+	return func(o *validateOptions) {
+		o.substitutions = vars
+	}
+}
+
+// RolloutSubstitutions returns the variables the Kustomization takes from the rollout with
+// rollout.kuberik.com/substitute.<variable>.from annotations, set to version
+func RolloutSubstitutions(kustomization *kustomizev1.Kustomization, rolloutName, version string) map[string]string {
+	vars := map[string]string{}
+	for key, value := range kustomization.Annotations {
+		variable, ok := strings.CutPrefix(key, "rollout.kuberik.com/substitute.")
+		if !ok || value != rolloutName {
+			continue
+		}
+		if variable, ok = strings.CutSuffix(variable, ".from"); ok && variable != "" {
+			vars[variable] = version
+		}
+	}
+	return vars
+}
+
+// ValidateKustomization builds the manifests the Kustomization would apply and server-side
+// dry-run applies each of them as kustomize-controller would, so schema violations, kinds the
+// cluster does not serve and admission webhook denials show up before they are deployed.
+func (c *Client) ValidateKustomization(ctx context.Context, kustomization *kustomizev1.Kustomization, opts ...ValidateOption) KustomizationValidation {
+	options := validateOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	validation := KustomizationValidation{Namespace: kustomization.Namespace, Name: kustomization.Name, Manifests: []ManifestValidation{}}
+
+	objects, err := c.buildCandidate(ctx, kustomization, options)
+	if err != nil {
+		validation.Error = err.Error()
+		return validation
+	}
+	validation.Manifests = c.DryRunManifests(ctx, kustomization, objects)
+	return validation
+}
+
+func (c *Client) buildCandidate(ctx context.Context, kustomization *kustomizev1.Kustomization, options validateOptions) ([]*unstructured.Unstructured, error) {
+	dir, err := os.MkdirTemp("", "kustomization-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	if options.files != nil {
+		if err := writeArtifact(dir, options.files); err != nil {
+			return nil, err
+		}
+	} else if err := c.fetchSourceArtifact(ctx, kustomization, dir); err != nil {
+		return nil, err
+	}
+	vars, err := c.substitutionVars(ctx, kustomization)
+	if err != nil {
+		return nil, err
+	}
+	if len(options.substitutions) > 0 {
+		if vars == nil {
+			vars = map[string]string{}
+		}
+		maps.Copy(vars, options.substitutions)
+	}
+	return BuildManifests(dir, kustomization, vars)
+}
+
+// writeArtifact writes files, by path inside the artifact, to dir. Paths escaping dir are skipped.
+func writeArtifact(dir string, files map[string][]byte) error {
+	for name, content := range files {
+		target, err := securePath(dir, name)
+		if err != nil {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, content, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DryRunManifests server-side dry-run applies objects with kustomize-controller's field manager.
+// Custom resources of CRDs and objects in namespaces that are among the objects themselves are
+// skipped when the cluster does not know them yet, as they can only be validated once applied.
+func (c *Client) DryRunManifests(ctx context.Context, kustomization *kustomizev1.Kustomization, objects []*unstructured.Unstructured) []ManifestValidation {
+	definedKinds := map[schema.GroupKind]bool{}
+	createdNamespaces := map[string]bool{}
+	for _, obj := range objects {
+		gvk := obj.GroupVersionKind()
+		switch {
+		case gvk.Group == "apiextensions.k8s.io" && gvk.Kind == "CustomResourceDefinition":
+			group, _, _ := unstructured.NestedString(obj.Object, "spec", "group")
+			kind, _, _ := unstructured.NestedString(obj.Object, "spec", "names", "kind")
+			definedKinds[schema.GroupKind{Group: group, Kind: kind}] = true
+		case gvk.Group == "" && gvk.Kind == "Namespace":
+			createdNamespaces[obj.GetName()] = true
+		}
+	}
+
+	validations := make([]ManifestValidation, 0, len(objects))
+	for _, obj := range objects {
+		gvk := obj.GroupVersionKind()
+		validation := ManifestValidation{
+			APIVersion: obj.GetAPIVersion(),
+			Kind:       obj.GetKind(),
+			Namespace:  obj.GetNamespace(),
+			Name:       obj.GetName(),
+			Status:     ManifestValid,
+		}
+
+		mapping, err := c.client.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+		switch {
+		case meta.IsNoMatchError(err) && definedKinds[gvk.GroupKind()]:
+			validation.Status, validation.Message = ManifestSkipped, "the kind is defined by a CustomResourceDefinition of the same manifests"
+		case meta.IsNoMatchError(err):
+			validation.Status, validation.Reason = ManifestInvalid, ReasonNoKindMatch
+			validation.Message = c.noKindMatchMessage(gvk)
+		case err != nil:
+			validation.Status, validation.Reason, validation.Message = ManifestInvalid, "Error", err.Error()
+		case mapping.Scope.Name() == meta.RESTScopeNameNamespace && obj.GetNamespace() == "":
+			validation.Status, validation.Reason = ManifestInvalid, string(metav1.StatusReasonInvalid)
+			validation.Message = "namespace not specified, set it in the manifest or the Kustomization's targetNamespace"
+		case gvk.Group == "" && gvk.Kind == "Secret" && kustomization.Spec.Decryption != nil:
+			validation.Status, validation.Message = ManifestSkipped, "encrypted in the source artifact"
+		default:
+			err := c.client.Patch(ctx, obj.DeepCopy(), client.Apply, client.DryRunAll, client.FieldOwner(fluxFieldManager), client.ForceOwnership)
+			var status apierrors.APIStatus
+			switch {
+			case err == nil:
+			case apierrors.IsNotFound(err) && createdNamespaces[obj.GetNamespace()]:
+				validation.Status, validation.Message = ManifestSkipped, "the namespace is created by the same manifests"
+			case errors.As(err, &status):
+				validation.Status, validation.Reason = ManifestInvalid, string(status.Status().Reason)
+				validation.Message = status.Status().Message
+				if details := status.Status().Details; details != nil {
+					for _, cause := range details.Causes {
+						validation.Causes = append(validation.Causes, fmt.Sprintf("%s: %s", cause.Field, cause.Message))
+					}
+				}
+			default:
+				validation.Status, validation.Reason, validation.Message = ManifestInvalid, "Error", err.Error()
+			}
+		}
+		validations = append(validations, validation)
+	}
+	return validations
+}
+
+// noKindMatchMessage explains a kind the cluster does not serve, naming the versions it does serve
+// of the kind, which usually means the manifest was written for another CRD version
+func (c *Client) noKindMatchMessage(gvk schema.GroupVersionKind) string {
+	message := fmt.Sprintf("the cluster does not serve %s %s", gvk.GroupVersion(), gvk.Kind)
+	mappings, err := c.client.RESTMapper().RESTMappings(gvk.GroupKind())
+	if err != nil || len(mappings) == 0 {
+		return message
+	}
+	var versions []string
+	for _, mapping := range mappings {
+		versions = append(versions, mapping.GroupVersionKind.Version)
+	}
+	return message + ", served versions: " + strings.Join(versions, ", ")
+}
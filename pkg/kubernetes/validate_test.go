@@ -0,0 +1,142 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+// newDryRunClient returns a client serving Deployments, ConfigMaps, Namespaces, CRDs and v1alpha1
+// Rollouts, whose dry-run applies reject ConfigMaps named "invalid" and objects in namespaces
+// that do not exist
+func newDryRunClient(t *testing.T) *Client {
+	t.Helper()
+	scheme, err := NewScheme()
+	require.NoError(t, err)
+	kinds := []struct {
+		gvk   schema.GroupVersionKind
+		scope meta.RESTScope
+	}{
+		{schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace},
+		{schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace},
+		{schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot},
+		{schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}, meta.RESTScopeRoot},
+		{schema.GroupVersionKind{Group: "kuberik.com", Version: "v1alpha1", Kind: "Rollout"}, meta.RESTScopeNamespace},
+	}
+	var groupVersions []schema.GroupVersion
+	for _, kind := range kinds {
+		groupVersions = append(groupVersions, kind.gvk.GroupVersion())
+	}
+	mapper := meta.NewDefaultRESTMapper(groupVersions)
+	for _, kind := range kinds {
+		mapper.Add(kind.gvk, kind.scope)
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithRESTMapper(mapper).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				switch {
+				case obj.GetNamespace() == "new":
+					return apierrors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, "new")
+				case obj.GetName() == "invalid":
+					return apierrors.NewInvalid(schema.GroupKind{Kind: "ConfigMap"}, "invalid", field.ErrorList{
+						field.Invalid(field.NewPath("data", "port"), "http", "must be a number"),
+					})
+				}
+				return nil
+			},
+		}).
+		Build()
+	return &Client{client: c}
+}
+
+func TestValidateKustomization(t *testing.T) {
+	c := newDryRunClient(t)
+	kustomization := &kustomizev1.Kustomization{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "flux-system",
+			Name:        "shop",
+			Annotations: map[string]string{"rollout.kuberik.com/substitute.SHOP_VERSION.from": "shop"},
+		},
+		Spec: kustomizev1.KustomizationSpec{Path: "./deploy", TargetNamespace: "shop"},
+	}
+	files := map[string][]byte{"deploy/configmap.yaml": []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ${NAME:=valid}
+data:
+  version: ${SHOP_VERSION}
+`)}
+	validation := c.ValidateKustomization(context.Background(), kustomization,
+		WithArtifactFiles(files),
+		WithSubstitutions(RolloutSubstitutions(kustomization, "shop", "v2")))
+	require.Empty(t, validation.Error)
+	require.Len(t, validation.Manifests, 1)
+	assert.Equal(t, ManifestValid, validation.Manifests[0].Status)
+	assert.True(t, validation.Valid())
+
+	validation = c.ValidateKustomization(context.Background(), kustomization,
+		WithArtifactFiles(files),
+		WithSubstitutions(map[string]string{"NAME": "invalid"}))
+	require.Len(t, validation.Manifests, 1)
+	assert.Equal(t, ManifestInvalid, validation.Manifests[0].Status)
+	assert.Equal(t, string(metav1.StatusReasonInvalid), validation.Manifests[0].Reason)
+	assert.Equal(t, []string{"data.port: Invalid value: \"http\": must be a number"}, validation.Manifests[0].Causes)
+	assert.False(t, validation.Valid())
+
+	kustomization.Spec.Path = "./missing"
+	validation = c.ValidateKustomization(context.Background(), kustomization, WithArtifactFiles(files))
+	assert.Contains(t, validation.Error, "not found in the artifact")
+	assert.False(t, validation.Valid())
+}
+
+func TestDryRunManifests(t *testing.T) {
+	c := newDryRunClient(t)
+	objects := []*unstructured.Unstructured{
+		manifest("apps/v1", "Deployment", "shop", "api"),
+		manifest("apps/v1", "Deployment", "", "api"),
+		manifest("kuberik.com/v1alpha9", "Rollout", "shop", "api"),
+		manifest("apiextensions.k8s.io/v1", "CustomResourceDefinition", "", "widgets.example.com"),
+		manifest("example.com/v1", "Widget", "shop", "api"),
+		manifest("v1", "Namespace", "", "new"),
+		manifest("v1", "ConfigMap", "new", "api"),
+		manifest("v1", "ConfigMap", "shop", "invalid"),
+	}
+	objects[3].Object["spec"] = map[string]any{"group": "example.com", "names": map[string]any{"kind": "Widget"}}
+
+	validations := c.DryRunManifests(context.Background(), &kustomizev1.Kustomization{}, objects)
+	require.Len(t, validations, len(objects))
+	statuses := make([]string, len(validations))
+	for i, validation := range validations {
+		statuses[i] = validation.Status
+	}
+	assert.Equal(t, []string{
+		ManifestValid, ManifestInvalid, ManifestInvalid, ManifestValid, ManifestSkipped, ManifestValid, ManifestSkipped, ManifestInvalid,
+	}, statuses)
+	assert.Contains(t, validations[1].Message, "namespace not specified")
+	assert.Equal(t, ReasonNoKindMatch, validations[2].Reason)
+	assert.Equal(t, "the cluster does not serve kuberik.com/v1alpha9 Rollout, served versions: v1alpha1", validations[2].Message)
+	assert.Equal(t, string(metav1.StatusReasonInvalid), validations[7].Reason)
+}
+
+func manifest(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
@@ -0,0 +1,168 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2"
+	imagereflectorv1beta2 "github.com/fluxcd/image-reflector-controller/api/v1beta2"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	envv1alpha1 "github.com/kuberik/environment-controller/api/v1alpha1"
+	openkruisev1alpha1 "github.com/kuberik/openkruise-controller/api/v1alpha1"
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	kruiserolloutv1beta1 "github.com/openkruise/kruise-rollout-api/rollouts/v1beta1"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// watchRetryInterval is how long a watch that could not be started waits before it is retried
+const watchRetryInterval = 30 * time.Second
+
+// Kinds of the objects rollout details are assembled from, as passed to the changed callback of
+// WatchDetailObjects
+const (
+	KindRollout         = "Rollout"
+	KindKustomization   = "Kustomization"
+	KindHelmRelease     = "HelmRelease"
+	KindOCIRepository   = "OCIRepository"
+	KindRolloutGate     = "RolloutGate"
+	KindEnvironment     = "Environment"
+	KindKruiseRollout   = "KruiseRollout"
+	KindRolloutTest     = "RolloutTest"
+	KindImagePolicy     = "ImagePolicy"
+	KindImageRepository = "ImageRepository"
+	KindMigrationJob    = "MigrationJob"
+)
+
+// watchedKind is a kind watched in all namespaces
+type watchedKind struct {
+	name string
+	list func() client.ObjectList
+	opts []client.ListOption
+}
+
+var detailKinds = []watchedKind{
+	{name: KindRollout, list: func() client.ObjectList { return &rolloutv1alpha1.RolloutList{} }},
+	{name: KindKustomization, list: func() client.ObjectList { return &kustomizev1.KustomizationList{} }},
+	{name: KindHelmRelease, list: func() client.ObjectList { return &helmv2.HelmReleaseList{} }},
+	{name: KindOCIRepository, list: func() client.ObjectList { return &sourcev1.OCIRepositoryList{} }},
+	{name: KindRolloutGate, list: func() client.ObjectList { return &rolloutv1alpha1.RolloutGateList{} }},
+	{name: KindEnvironment, list: func() client.ObjectList { return &envv1alpha1.EnvironmentList{} }},
+	{name: KindKruiseRollout, list: func() client.ObjectList { return &kruiserolloutv1beta1.RolloutList{} }},
+	{name: KindRolloutTest, list: func() client.ObjectList { return &openkruisev1alpha1.RolloutTestList{} }},
+	{name: KindImagePolicy, list: func() client.ObjectList { return &imagereflectorv1beta2.ImagePolicyList{} }},
+	{name: KindImageRepository, list: func() client.ObjectList { return &imagereflectorv1beta2.ImageRepositoryList{} }},
+	{name: KindMigrationJob, list: func() client.ObjectList { return &batchv1.JobList{} }, opts: []client.ListOption{client.HasLabels{MigrationLabel}}},
+}
+
+// WatchDetailObjects watches the kinds rollout details are assembled from in all namespaces and
+// calls changed with the kind and namespace of every object added, modified or deleted, so cached
+// details can be invalidated. synced(true) is called once every watch is running and synced(false)
+// as soon as one is not, e.g. while it is restarted after its resource version expired; changes
+// may have been missed in between, so nothing seen before synced(true) can be trusted. Kinds whose
+// CRD is not installed count as running. It blocks until ctx is cancelled.
+func (c *Client) WatchDetailObjects(ctx context.Context, changed func(kind, namespace string), synced func(bool)) error {
+	watcher, ok := c.client.(client.WithWatch)
+	if !ok {
+		return fmt.Errorf("client does not support watches")
+	}
+
+	var mu sync.Mutex
+	running := make([]bool, len(detailKinds))
+	allRunning := false
+	setRunning := func(i int, value bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		running[i] = value
+		all := true
+		for _, r := range running {
+			all = all && r
+		}
+		if all != allRunning {
+			allRunning = all
+			synced(all)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i, kind := range detailKinds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			watchKind(ctx, watcher, kind, func(value bool) { setRunning(i, value) }, changed)
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// watchKind keeps a watch on kind running. Watches closed by the API server are resumed from the
+// last resource version seen, so no change is missed; only when that is not possible is the kind
+// reported as not running until a new watch has started.
+func watchKind(ctx context.Context, watcher client.WithWatch, kind watchedKind, setRunning func(bool), changed func(kind, namespace string)) {
+	resourceVersion := ""
+	for ctx.Err() == nil {
+		opts := append([]client.ListOption{&client.ListOptions{Raw: &metav1.ListOptions{
+			ResourceVersion:     resourceVersion,
+			AllowWatchBookmarks: true,
+		}}}, kind.opts...)
+		w, err := watcher.Watch(ctx, kind.list(), opts...)
+		switch {
+		case meta.IsNoMatchError(err):
+			// No objects of a kind that is not installed can change; check again later
+			setRunning(true)
+			sleepContext(ctx, watchRetryInterval)
+			continue
+		case err != nil:
+			setRunning(false)
+			resourceVersion = ""
+			slog.Warn("Failed to watch objects for rollout details", "kind", kind.name, "error", err)
+			sleepContext(ctx, watchRetryInterval)
+			continue
+		}
+		setRunning(true)
+		resourceVersion = consumeWatch(ctx, w, resourceVersion, func(namespace string) { changed(kind.name, namespace) })
+		if resourceVersion == "" {
+			setRunning(false)
+		}
+	}
+}
+
+// consumeWatch reports the namespaces of changed objects until the watch ends and returns the
+// resource version to resume from, or "" when the watch cannot be resumed
+func consumeWatch(ctx context.Context, w watch.Interface, resourceVersion string, changed func(namespace string)) string {
+	defer w.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return resourceVersion
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return resourceVersion
+			}
+			if event.Type == watch.Error {
+				// Usually 410 Gone: the resource version is too old to resume from
+				if status, ok := event.Object.(*metav1.Status); !ok || status.Code == http.StatusGone {
+					return ""
+				}
+				return resourceVersion
+			}
+			obj, ok := event.Object.(client.Object)
+			if !ok {
+				continue
+			}
+			resourceVersion = obj.GetResourceVersion()
+			if event.Type != watch.Bookmark {
+				changed(obj.GetNamespace())
+			}
+		}
+	}
+}
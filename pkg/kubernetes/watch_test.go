@@ -0,0 +1,47 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	rolloutv1alpha1 "github.com/kuberik/rollout-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestWatchDetailObjects(t *testing.T) {
+	scheme, err := NewScheme()
+	require.NoError(t, err)
+	c := &Client{client: fake.NewClientBuilder().WithScheme(scheme).Build()}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	type change struct{ kind, namespace string }
+	changes := make(chan change, 10)
+	synced := make(chan bool, 10)
+	done := make(chan error)
+	go func() {
+		done <- c.WatchDetailObjects(ctx, func(kind, namespace string) { changes <- change{kind, namespace} }, func(s bool) { synced <- s })
+	}()
+
+	select {
+	case s := <-synced:
+		assert.True(t, s)
+	case <-time.After(5 * time.Second):
+		t.Fatal("watches did not start")
+	}
+
+	require.NoError(t, c.client.Create(ctx, &rolloutv1alpha1.Rollout{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api"}}))
+	select {
+	case got := <-changes:
+		assert.Equal(t, change{KindRollout, "shop"}, got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("change was not reported")
+	}
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
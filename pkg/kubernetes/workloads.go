@@ -0,0 +1,90 @@
+package kubernetes
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// workloadKinds are the kinds in the apps group whose pods serve a rollout's traffic
+var workloadKinds = []string{"Deployment", "StatefulSet", "DaemonSet"}
+
+// Workload is a Deployment, StatefulSet or DaemonSet deployed by a rollout
+type Workload struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// podNameSuffixes are the suffixes controllers append to a workload's name to name its pods,
+// e.g. shop-5d9f8b7c4-x2x7q for a Deployment, shop-0 for a StatefulSet and shop-x2x7q for a
+// DaemonSet
+var podNameSuffixes = map[string]string{
+	"Deployment":  `-[a-z0-9]+-[a-z0-9]{5}`,
+	"StatefulSet": `-[0-9]+`,
+	"DaemonSet":   `-[a-z0-9]{5}`,
+}
+
+// PodNameSuffix returns the regular expression matching what follows the workload's name in the
+// names of its pods, or empty string for kinds that are not workloads
+func PodNameSuffix(kind string) string {
+	return podNameSuffixes[kind]
+}
+
+// OwnsPod reports whether a pod name is one the workload's controller would give its pods
+func (w Workload) OwnsPod(namespace, pod string) bool {
+	suffix := PodNameSuffix(w.Kind)
+	if namespace != w.Namespace || suffix == "" || !strings.HasPrefix(pod, w.Name) {
+		return false
+	}
+	return regexp.MustCompile("^" + suffix + "$").MatchString(strings.TrimPrefix(pod, w.Name))
+}
+
+// GetRolloutWorkloads returns the workloads in the inventories of the rollout's Kustomizations.
+// Only the inventories are read, so workloads that were deleted in-cluster are included.
+func (c *Client) GetRolloutWorkloads(ctx context.Context, namespace, rolloutName string) ([]Workload, error) {
+	kustomizations, err := c.GetKustomizationsByRolloutAnnotation(ctx, namespace, rolloutName)
+	if err != nil {
+		return nil, err
+	}
+	return InventoryWorkloads(kustomizations.Items), nil
+}
+
+// InventoryWorkloads returns the workloads in the inventories of the Kustomizations, sorted and
+// without duplicates
+func InventoryWorkloads(kustomizations []kustomizev1.Kustomization) []Workload {
+	seen := map[Workload]bool{}
+	workloads := []Workload{}
+	for _, kustomization := range kustomizations {
+		if kustomization.Status.Inventory == nil {
+			continue
+		}
+		for _, entry := range kustomization.Status.Inventory.Entries {
+			objMetadata, err := object.ParseObjMetadata(entry.ID)
+			if err != nil || objMetadata.GroupKind.Group != "apps" || !slices.Contains(workloadKinds, objMetadata.GroupKind.Kind) {
+				continue
+			}
+			workload := Workload{Kind: objMetadata.GroupKind.Kind, Namespace: objMetadata.Namespace, Name: objMetadata.Name}
+			if !seen[workload] {
+				seen[workload] = true
+				workloads = append(workloads, workload)
+			}
+		}
+	}
+	sort.Slice(workloads, func(i, j int) bool {
+		a, b := workloads[i], workloads[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	return workloads
+}
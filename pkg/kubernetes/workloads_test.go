@@ -0,0 +1,41 @@
+package kubernetes
+
+import (
+	"testing"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInventoryWorkloads(t *testing.T) {
+	inventory := func(ids ...string) kustomizev1.Kustomization {
+		k := kustomizev1.Kustomization{Status: kustomizev1.KustomizationStatus{Inventory: &kustomizev1.ResourceInventory{}}}
+		for _, id := range ids {
+			k.Status.Inventory.Entries = append(k.Status.Inventory.Entries, kustomizev1.ResourceRef{ID: id, Version: "v1"})
+		}
+		return k
+	}
+
+	workloads := InventoryWorkloads([]kustomizev1.Kustomization{
+		inventory("shop_web_apps_Deployment", "shop_web__Service", "shop_db_apps_StatefulSet", "shop_migrate_batch_Job"),
+		inventory("shop_web_apps_Deployment", "monitoring_agent_apps_DaemonSet", "invalid"),
+		{},
+	})
+	// Duplicates and resources that do not run long-lived pods are dropped
+	assert.Equal(t, []Workload{
+		{Kind: "DaemonSet", Namespace: "monitoring", Name: "agent"},
+		{Kind: "Deployment", Namespace: "shop", Name: "web"},
+		{Kind: "StatefulSet", Namespace: "shop", Name: "db"},
+	}, workloads)
+}
+
+func TestWorkloadOwnsPod(t *testing.T) {
+	web := Workload{Kind: "Deployment", Namespace: "shop", Name: "web"}
+	assert.True(t, web.OwnsPod("shop", "web-5d9f8b7c4-x2x7q"))
+	assert.False(t, web.OwnsPod("shop", "webhook-5d9f8b7c4-x2x7q"))
+	assert.False(t, web.OwnsPod("other", "web-5d9f8b7c4-x2x7q"))
+
+	db := Workload{Kind: "StatefulSet", Namespace: "shop", Name: "db"}
+	assert.True(t, db.OwnsPod("shop", "db-0"))
+	assert.False(t, db.OwnsPod("shop", "db-x2x7q"))
+}
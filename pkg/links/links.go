@@ -0,0 +1,214 @@
+package links
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// AnnotationPrefix is followed by a link's name in the annotations that define quick links on a
+// rollout, e.g. rollout.kuberik.com/link.runbook. The value is a URL template; an empty value hides
+// the configured link of the same name.
+const AnnotationPrefix = "rollout.kuberik.com/link."
+
+// Names of the standard quick links, shown with their default titles
+const (
+	Runbook = "runbook"
+	Grafana = "grafana"
+	Logs    = "logs"
+)
+
+var defaultTitles = map[string]string{
+	Runbook: "Runbook",
+	Grafana: "Grafana dashboard",
+	Logs:    "Logs",
+}
+
+// Sources of a Link
+const (
+	SourceConfig     = "config"
+	SourceAnnotation = "annotation"
+)
+
+// variable matches the placeholders of URL templates
+var variable = regexp.MustCompile(`\$(namespace|name|version|team|environment)\b`)
+
+// Config holds the quick links every rollout gets. It is read from a YAML or JSON file:
+//
+//	links:
+//	  - name: grafana
+//	    url: https://grafana.example.com/d/rollouts?var-namespace=$namespace&var-rollout=$name
+//	  - name: logs
+//	    title: Application logs
+//	    url: https://logs.example.com/search?q=namespace:$namespace%20version:$version
+//	    namespaces: [shop, payments]
+type Config struct {
+	Links []Template `json:"links"`
+}
+
+// Template is a quick link whose URL may use the placeholders $namespace, $name, $version (the
+// deployed version), $team and $environment, expanded URL-escaped for each rollout
+type Template struct {
+	Name string `json:"name"`
+	// Title defaults to the standard title for runbook, grafana and logs and to the name otherwise
+	Title string `json:"title,omitempty"`
+	URL   string `json:"url"`
+	// Namespaces limits the link to rollouts in these namespaces, all when empty
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// Link is a quick link of a rollout with its URL expanded
+type Link struct {
+	Name  string `json:"name"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+	// Source is config for links of LINKS_CONFIG and annotation for links defined on the rollout
+	Source string `json:"source"`
+}
+
+// Variables are the values of a rollout substituted into URL templates
+type Variables struct {
+	Namespace   string
+	Name        string
+	Version     string
+	Team        string
+	Environment string
+}
+
+// ConfigFromEnv reads the configuration file named by LINKS_CONFIG. Without it rollouts only have
+// the links defined by their annotations.
+func ConfigFromEnv() (Config, error) {
+	path := os.Getenv("LINKS_CONFIG")
+	if path == "" {
+		return Config{}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read links config: %w", err)
+	}
+	return ParseConfig(data)
+}
+
+// ParseConfig parses and validates a YAML or JSON configuration
+func ParseConfig(data []byte) (Config, error) {
+	var cfg Config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to parse links config: %w", err)
+	}
+	names := map[string]bool{}
+	for i, template := range cfg.Links {
+		if template.Name == "" {
+			return Config{}, fmt.Errorf("link %d: name is required", i)
+		}
+		if names[template.Name] {
+			return Config{}, fmt.Errorf("link %s: defined more than once", template.Name)
+		}
+		names[template.Name] = true
+		if err := validURL(variable.ReplaceAllString(template.URL, "x")); err != nil {
+			return Config{}, fmt.Errorf("link %s: %w", template.Name, err)
+		}
+	}
+	return cfg, nil
+}
+
+// Templates expands the configured quick links and those defined by annotations for a rollout.
+// A nil Templates only expands annotations.
+type Templates struct {
+	cfg Config
+}
+
+// New creates the templates of a configuration
+func New(cfg Config) *Templates {
+	return &Templates{cfg: cfg}
+}
+
+// Resolve returns the quick links of a rollout with the given annotations: the configured links
+// for its namespace in the order of the configuration, replaced by annotations of the same name,
+// followed by the other annotated links sorted by name. Links referencing a variable without a
+// value, e.g. $version before the first deployment, and links that do not expand to an http(s)
+// URL are left out.
+func (t *Templates) Resolve(annotations map[string]string, vars Variables) []Link {
+	var links []Link
+	configured := map[string]bool{}
+	if t != nil {
+		for _, template := range t.cfg.Links {
+			if len(template.Namespaces) > 0 && !slices.Contains(template.Namespaces, vars.Namespace) {
+				continue
+			}
+			configured[template.Name] = true
+			link := Link{Name: template.Name, Title: template.Title, URL: template.URL, Source: SourceConfig}
+			if override, ok := annotations[AnnotationPrefix+template.Name]; ok {
+				link.URL, link.Source = override, SourceAnnotation
+			}
+			if link, ok := expand(link, vars); ok {
+				links = append(links, link)
+			}
+		}
+	}
+
+	var annotated []string
+	for key := range annotations {
+		if name, ok := strings.CutPrefix(key, AnnotationPrefix); ok && name != "" && !configured[name] {
+			annotated = append(annotated, name)
+		}
+	}
+	sort.Strings(annotated)
+	for _, name := range annotated {
+		link := Link{Name: name, URL: annotations[AnnotationPrefix+name], Source: SourceAnnotation}
+		if link, ok := expand(link, vars); ok {
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// expand substitutes the variables into the link's URL and fills in a missing title
+func expand(link Link, vars Variables) (Link, bool) {
+	if link.URL == "" {
+		return Link{}, false
+	}
+	values := map[string]string{
+		"namespace":   vars.Namespace,
+		"name":        vars.Name,
+		"version":     vars.Version,
+		"team":        vars.Team,
+		"environment": vars.Environment,
+	}
+	missing := false
+	link.URL = variable.ReplaceAllStringFunc(link.URL, func(placeholder string) string {
+		value := values[placeholder[1:]]
+		if value == "" {
+			missing = true
+		}
+		// Escaped so values are safe both in paths and in query strings
+		return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+	})
+	if missing || validURL(link.URL) != nil {
+		return Link{}, false
+	}
+	if link.Title == "" {
+		link.Title = defaultTitles[link.Name]
+	}
+	if link.Title == "" {
+		link.Title = link.Name
+	}
+	return link, true
+}
+
+// validURL accepts absolute http and https URLs only, so links cannot run scripts in the browser
+func validURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" || parsed.Host == "" {
+		return fmt.Errorf("url %q must be an absolute http or https URL", raw)
+	}
+	return nil
+}
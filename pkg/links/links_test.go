@@ -0,0 +1,60 @@
+package links
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`
+links:
+  - name: grafana
+    url: https://grafana.example.com/d/rollouts?var-namespace=$namespace&var-rollout=$name
+  - name: logs
+    title: Application logs
+    url: https://logs.example.com/search?q=version:$version
+  - name: runbook
+    url: https://wiki.example.com/runbooks/$team
+  - name: billing
+    url: https://billing.example.com/$namespace
+    namespaces: [payments]
+`))
+	require.NoError(t, err)
+	templates := New(cfg)
+	vars := Variables{Namespace: "shop", Name: "api", Version: "v1.2.0 rc&1", Environment: "production"}
+
+	assert.Equal(t, []Link{
+		{Name: Grafana, Title: "Grafana dashboard", URL: "https://grafana.example.com/d/rollouts?var-namespace=shop&var-rollout=api", Source: SourceConfig},
+		{Name: Logs, Title: "Application logs", URL: "https://logs.example.com/search?q=version:v1.2.0%20rc%261", Source: SourceConfig},
+		{Name: "on-call", Title: "on-call", URL: "https://oncall.example.com/production", Source: SourceAnnotation},
+	}, templates.Resolve(map[string]string{
+		AnnotationPrefix + "on-call": "https://oncall.example.com/$environment",
+		AnnotationPrefix + "script":  "javascript:alert(1)",
+	}, vars), "runbook needs a team, billing is for another namespace")
+
+	assert.Equal(t, []Link{
+		{Name: Grafana, Title: "Grafana dashboard", URL: "https://grafana.example.com/custom/api", Source: SourceAnnotation},
+		{Name: Runbook, Title: "Runbook", URL: "https://wiki.example.com/runbooks/checkout", Source: SourceConfig},
+	}, templates.Resolve(map[string]string{
+		AnnotationPrefix + Grafana: "https://grafana.example.com/custom/$name",
+		AnnotationPrefix + Logs:    "",
+	}, Variables{Namespace: "shop", Name: "api", Team: "checkout"}), "annotations override and hide configured links, no version yet")
+
+	var none *Templates
+	assert.Empty(t, none.Resolve(nil, vars))
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	for _, config := range []string{
+		`links: [{url: "https://example.com"}]`,
+		`links: [{name: a, url: "https://example.com"}, {name: a, url: "https://example.com"}]`,
+		`links: [{name: a, url: "javascript:alert(1)"}]`,
+		`links: [{name: a, url: "/relative/$name"}]`,
+		`links: [{name: a, url: "https://example.com", icon: book}]`,
+	} {
+		_, err := ParseConfig([]byte(config))
+		assert.Error(t, err, config)
+	}
+}
@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/requestid"
+)
+
+const loggerContextKey = "request_logger"
+
+// Setup configures the process-wide slog logger from the environment:
+//   - LOG_LEVEL: debug, info (default), warn or error
+//   - LOG_FORMAT: text (default) or json
+func Setup() *slog.Logger {
+	logger := New(os.Stderr, os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT"))
+	slog.SetDefault(logger)
+	return logger
+}
+
+// New creates a logger writing to w with the given level and format names
+func New(w io.Writer, level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: ParseLevel(level)}
+
+	var handler slog.Handler
+	if strings.EqualFold(format, "json") {
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = slog.NewTextHandler(w, opts)
+	}
+	return slog.New(NewSanitizingHandler(handler))
+}
+
+// ParseLevel converts a level name to a slog.Level, defaulting to info
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// Middleware attaches a request-scoped logger (method, path, request ID, namespace, user) to the context
+// and logs each completed request
+func Middleware(userFunc func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		attrs := []any{
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+		}
+		if id := requestid.FromContext(c); id != "" {
+			attrs = append(attrs, "requestId", id)
+		}
+		if namespace := c.Param("namespace"); namespace != "" {
+			attrs = append(attrs, "namespace", namespace)
+		}
+		if userFunc != nil {
+			if user := userFunc(c); user != "" {
+				attrs = append(attrs, "user", user)
+			}
+		}
+		logger := slog.Default().With(attrs...)
+		c.Set(loggerContextKey, logger)
+
+		c.Next()
+
+		level := slog.LevelInfo
+		if c.Writer.Status() >= 500 {
+			level = slog.LevelError
+		}
+		logger.Log(c.Request.Context(), level, "request completed",
+			"status", c.Writer.Status(),
+			"duration", time.Since(start),
+			"clientIP", c.ClientIP(),
+		)
+	}
+}
+
+// FromContext returns the request-scoped logger, or the default logger if none is set
+func FromContext(c *gin.Context) *slog.Logger {
+	if c != nil {
+		if logger, exists := c.Get(loggerContextKey); exists {
+			if l, ok := logger.(*slog.Logger); ok {
+				return l
+			}
+		}
+	}
+	return slog.Default()
+}
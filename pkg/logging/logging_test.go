@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLevel(t *testing.T) {
+	assert.Equal(t, slog.LevelDebug, ParseLevel("debug"))
+	assert.Equal(t, slog.LevelWarn, ParseLevel("WARN"))
+	assert.Equal(t, slog.LevelError, ParseLevel("error"))
+	assert.Equal(t, slog.LevelInfo, ParseLevel(""))
+	assert.Equal(t, slog.LevelInfo, ParseLevel("bogus"))
+}
+
+func TestMiddlewareAddsRequestFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(New(&buf, "info", "json"))
+	defer slog.SetDefault(previous)
+
+	r := gin.New()
+	r.Use(Middleware(func(c *gin.Context) string { return "alice" }))
+	r.GET("/api/rollouts/:namespace/:name", func(c *gin.Context) {
+		FromContext(c).Info("handler called")
+		c.Status(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest("GET", "/api/rollouts/team-a/app", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(lines[0], &entry))
+	assert.Equal(t, "handler called", entry["msg"])
+	assert.Equal(t, "/api/rollouts/team-a/app", entry["path"])
+	assert.Equal(t, "team-a", entry["namespace"])
+	assert.Equal(t, "alice", entry["user"])
+}
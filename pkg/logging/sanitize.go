@@ -0,0 +1,113 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute keys whose values are always redacted
+var sensitiveKeys = map[string]bool{
+	"token":         true,
+	"id_token":      true,
+	"idtoken":       true,
+	"access_token":  true,
+	"authorization": true,
+	"cookie":        true,
+	"set-cookie":    true,
+	"password":      true,
+	"secret":        true,
+	"data":          true,
+	"stringdata":    true,
+}
+
+var (
+	// jwtPattern matches compact-serialized JWTs
+	jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	// bearerPattern matches bearer credentials embedded in strings (e.g. echoed headers)
+	bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`)
+)
+
+// sanitizingHandler scrubs credentials from log records before passing them on
+type sanitizingHandler struct {
+	next slog.Handler
+}
+
+// NewSanitizingHandler wraps a handler so that tokens, Authorization/Cookie values and
+// Secret data are never emitted, whichever code path produced the record
+func NewSanitizingHandler(next slog.Handler) slog.Handler {
+	return &sanitizingHandler{next: next}
+}
+
+func (h *sanitizingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *sanitizingHandler) Handle(ctx context.Context, record slog.Record) error {
+	sanitized := slog.NewRecord(record.Time, record.Level, SanitizeString(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		sanitized.AddAttrs(sanitizeAttr(attr))
+		return true
+	})
+	return h.next.Handle(ctx, sanitized)
+}
+
+func (h *sanitizingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	sanitized := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		sanitized[i] = sanitizeAttr(attr)
+	}
+	return &sanitizingHandler{next: h.next.WithAttrs(sanitized)}
+}
+
+func (h *sanitizingHandler) WithGroup(name string) slog.Handler {
+	return &sanitizingHandler{next: h.next.WithGroup(name)}
+}
+
+// SanitizeString removes JWTs and bearer credentials from free-form text
+func SanitizeString(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "${1}"+redacted)
+	return jwtPattern.ReplaceAllString(s, redacted)
+}
+
+func sanitizeAttr(attr slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, redacted)
+	}
+
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, SanitizeString(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		sanitized := make([]any, len(group))
+		for i, a := range group {
+			sanitized[i] = sanitizeAttr(a)
+		}
+		return slog.Group(attr.Key, sanitized...)
+	case slog.KindAny:
+		switch v := value.Any().(type) {
+		case error:
+			return slog.String(attr.Key, SanitizeString(v.Error()))
+		case *corev1.Secret:
+			return slog.String(attr.Key, secretReference(v))
+		case corev1.Secret:
+			return slog.String(attr.Key, secretReference(&v))
+		}
+	}
+	return slog.Attr{Key: attr.Key, Value: value}
+}
+
+// secretReference identifies a Secret without exposing its data
+func secretReference(secret *corev1.Secret) string {
+	if secret == nil {
+		return "<nil>"
+	}
+	return "Secret " + secret.Namespace + "/" + secret.Name + " " + redacted
+}
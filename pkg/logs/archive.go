@@ -0,0 +1,146 @@
+package logs
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// LogOpener opens the log of a single container
+type LogOpener func(ctx context.Context, namespace, pod string, opts *corev1.PodLogOptions) (io.ReadCloser, error)
+
+// ClientLogOpener opens container logs through the Kubernetes API
+func ClientLogOpener(client *kubernetes.Client) LogOpener {
+	return func(ctx context.Context, namespace, pod string, opts *corev1.PodLogOptions) (io.ReadCloser, error) {
+		clientset := client.GetClientset()
+		if clientset == nil {
+			return nil, fmt.Errorf("clientset not initialized")
+		}
+		return clientset.CoreV1().Pods(namespace).GetLogs(pod, opts).Stream(ctx)
+	}
+}
+
+// ArchiveOptions selects which logs are written to an archive
+type ArchiveOptions struct {
+	// Previous also includes the logs of the previous instance of restarted containers
+	Previous bool
+	// Window limits each log, e.g. to its last lines; the zero value includes whole logs
+	Window Window
+}
+
+// WriteArchive writes the logs of every (init) container of pods to w as a zip archive, one file
+// per container at <type>/<pod>/<container>.log (and <container>.previous.log). Logs that cannot
+// be read are listed in errors.txt instead of failing the archive, so a single missing log does
+// not cost the caller the others. Only write errors to w and ctx cancellation are returned.
+func WriteArchive(ctx context.Context, w io.Writer, pods []DiscoveredPod, open LogOpener, opts ArchiveOptions) error {
+	archive := zip.NewWriter(w)
+	var failures []string
+
+	for _, discovered := range pods {
+		pod := discovered.Pod
+		podType := discovered.Type
+		if podType == "" {
+			podType = "pod"
+		}
+
+		for _, container := range archiveContainers(&pod) {
+			variants := []bool{false}
+			if opts.Previous && container.restarts > 0 {
+				variants = append(variants, true)
+			}
+			for _, previous := range variants {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+
+				name := path.Join(podType, pod.Name, container.name+".log")
+				if previous {
+					name = path.Join(podType, pod.Name, container.name+".previous.log")
+				}
+				logOpts := &corev1.PodLogOptions{
+					Container:  container.name,
+					Previous:   previous,
+					Timestamps: true,
+				}
+				opts.Window.Apply(logOpts)
+				err := writeArchiveEntry(ctx, archive, name, open, pod.Namespace, pod.Name, logOpts)
+				if err != nil {
+					var writeErr archiveWriteError
+					if errors.As(err, &writeErr) {
+						return writeErr.err
+					}
+					failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+				}
+			}
+		}
+	}
+
+	if len(failures) > 0 {
+		entry, err := archive.CreateHeader(&zip.FileHeader{Name: "errors.txt", Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(entry, strings.Join(failures, "\n")+"\n"); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// archiveWriteError marks failures writing to the archive itself, as opposed to reading a log
+type archiveWriteError struct{ err error }
+
+func (e archiveWriteError) Error() string { return e.err.Error() }
+
+// writeArchiveEntry copies a single container log into the archive. The entry is only created
+// once the log could be opened, so unavailable logs leave no empty files behind.
+func writeArchiveEntry(ctx context.Context, archive *zip.Writer, name string, open LogOpener, namespace, pod string, opts *corev1.PodLogOptions) error {
+	stream, err := open(ctx, namespace, pod, opts)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	entry, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return archiveWriteError{err}
+	}
+	if _, err := io.Copy(entry, stream); err != nil {
+		// A log that breaks off midway is kept as far as it was read
+		return fmt.Errorf("log truncated: %w", err)
+	}
+	return nil
+}
+
+type archiveContainer struct {
+	name     string
+	restarts int32
+}
+
+// archiveContainers lists the init and regular containers of a pod with their restart counts
+func archiveContainers(pod *corev1.Pod) []archiveContainer {
+	restarts := map[string]int32{}
+	for _, status := range pod.Status.InitContainerStatuses {
+		restarts[status.Name] = status.RestartCount
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		restarts[status.Name] = status.RestartCount
+	}
+
+	var containers []archiveContainer
+	for _, container := range pod.Spec.InitContainers {
+		containers = append(containers, archiveContainer{name: container.Name, restarts: restarts[container.Name]})
+	}
+	for _, container := range pod.Spec.Containers {
+		containers = append(containers, archiveContainer{name: container.Name, restarts: restarts[container.Name]})
+	}
+	return containers
+}
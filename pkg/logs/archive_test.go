@@ -0,0 +1,64 @@
+package logs
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWriteArchive(t *testing.T) {
+	pods := []DiscoveredPod{
+		{Type: "pod", Pod: corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "web-1"},
+			Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "migrate"}},
+				Containers:     []corev1.Container{{Name: "app"}},
+			},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{Name: "app", RestartCount: 2}}},
+		}},
+		{Type: "test", Pod: corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "smoke-1"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "test"}}},
+		}},
+	}
+	open := func(ctx context.Context, namespace, pod string, opts *corev1.PodLogOptions) (io.ReadCloser, error) {
+		if opts.Container == "migrate" {
+			return nil, errors.New("container not started")
+		}
+		line := pod + "/" + opts.Container
+		if opts.Previous {
+			line += " previous"
+		}
+		return io.NopCloser(strings.NewReader(line + "\n")), nil
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteArchive(context.Background(), &buf, pods, open, ArchiveOptions{Previous: true}))
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, file := range archive.File {
+		r, err := file.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(r)
+		require.NoError(t, err)
+		files[file.Name] = string(content)
+	}
+
+	assert.Equal(t, map[string]string{
+		"pod/web-1/app.log":          "web-1/app\n",
+		"pod/web-1/app.previous.log": "web-1/app previous\n",
+		"test/smoke-1/test.log":      "smoke-1/test\n",
+		"errors.txt":                 "pod/web-1/migrate.log: container not started\n",
+	}, files)
+}
@@ -0,0 +1,104 @@
+package logs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+)
+
+// Selection decides which sources of a stream are sent, so sources hidden in the UI cost no
+// bandwidth. Sources are pods ("pod") or containers ("pod/container"). While any source is soloed
+// only soloed sources are sent, otherwise all but the muted ones.
+type Selection struct {
+	mu     sync.RWMutex
+	muted  map[string]bool
+	soloed map[string]bool
+}
+
+// Set replaces the muted and soloed sources
+func (s *Selection) Set(mute, solo []string) {
+	muted, soloed := sourceSet(mute), sourceSet(solo)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.muted, s.soloed = muted, soloed
+}
+
+// Allows reports whether lines of the container are sent
+func (s *Selection) Allows(pod, container string) bool {
+	if s == nil {
+		return true
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	matches := func(sources map[string]bool) bool {
+		return sources[pod] || sources[pod+"/"+container]
+	}
+	if len(s.soloed) > 0 {
+		return matches(s.soloed)
+	}
+	return !matches(s.muted)
+}
+
+func sourceSet(sources []string) map[string]bool {
+	set := make(map[string]bool, len(sources))
+	for _, source := range sources {
+		if source = strings.TrimSpace(source); source != "" {
+			set[source] = true
+		}
+	}
+	return set
+}
+
+// StreamRegistry tracks the running log streams so clients can control them by ID, e.g. mute or
+// solo sources mid-stream. SSE streams cannot receive messages, so control requests are sent
+// separately and matched by the ID sent at the start of the stream.
+type StreamRegistry struct {
+	mu      sync.Mutex
+	streams map[string]registeredStream
+}
+
+type registeredStream struct {
+	owner    StreamOwner
+	streamer *LogStreamer
+}
+
+// StreamOwner identifies who may control a stream: the rollout it streams and the credentials it
+// was opened with
+type StreamOwner struct {
+	Credentials string
+	Namespace   string
+	Rollout     string
+}
+
+// NewStreamRegistry creates an empty registry
+func NewStreamRegistry() *StreamRegistry {
+	return &StreamRegistry{streams: map[string]registeredStream{}}
+}
+
+// Register adds the streamer under a new random ID; unregister removes it when the stream ends
+func (r *StreamRegistry) Register(owner StreamOwner, streamer *LogStreamer) (id string, unregister func()) {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	id = hex.EncodeToString(b)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.streams[id] = registeredStream{owner: owner, streamer: streamer}
+	return id, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.streams, id)
+	}
+}
+
+// Get returns the stream with the ID if owner opened it
+func (r *StreamRegistry) Get(id string, owner StreamOwner) (*LogStreamer, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stream, ok := r.streams[id]
+	if !ok || stream.owner != owner {
+		return nil, false
+	}
+	return stream.streamer, true
+}
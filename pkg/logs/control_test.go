@@ -0,0 +1,46 @@
+package logs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelection(t *testing.T) {
+	var s Selection
+	assert.True(t, s.Allows("api-1", "app"), "everything is sent by default")
+
+	s.Set([]string{"api-1", "api-2/sidecar"}, nil)
+	assert.False(t, s.Allows("api-1", "app"))
+	assert.False(t, s.Allows("api-2", "sidecar"))
+	assert.True(t, s.Allows("api-2", "app"))
+
+	s.Set([]string{"api-1"}, []string{"api-2/app", "api-3"})
+	assert.True(t, s.Allows("api-2", "app"))
+	assert.True(t, s.Allows("api-3", "sidecar"))
+	assert.False(t, s.Allows("api-2", "sidecar"))
+	assert.False(t, s.Allows("api-4", "app"))
+
+	s.Set(nil, nil)
+	assert.True(t, s.Allows("api-1", "app"))
+}
+
+func TestStreamRegistry(t *testing.T) {
+	r := NewStreamRegistry()
+	owner := StreamOwner{Credentials: "jane", Namespace: "shop", Rollout: "api"}
+	streamer := &LogStreamer{}
+
+	id, unregister := r.Register(owner, streamer)
+	got, ok := r.Get(id, owner)
+	assert.True(t, ok)
+	assert.Same(t, streamer, got)
+
+	_, ok = r.Get(id, StreamOwner{Credentials: "john", Namespace: "shop", Rollout: "api"})
+	assert.False(t, ok, "other credentials")
+	_, ok = r.Get(id, StreamOwner{Credentials: "jane", Namespace: "shop", Rollout: "web"})
+	assert.False(t, ok, "other rollout")
+
+	unregister()
+	_, ok = r.Get(id, owner)
+	assert.False(t, ok)
+}
@@ -0,0 +1,125 @@
+package logs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// levels orders the recognized log levels by severity
+var levels = map[string]int{
+	"trace": 0,
+	"debug": 1,
+	"info":  2,
+	"warn":  3,
+	"error": 4,
+	"fatal": 5,
+}
+
+// levelAliases maps level spellings used by common logging libraries to the levels above
+var levelAliases = map[string]string{
+	"trc": "trace", "dbg": "debug", "inf": "info", "information": "info",
+	"warning": "warn", "wrn": "warn", "err": "error", "eror": "error",
+	"crit": "fatal", "critical": "fatal", "panic": "fatal", "emerg": "fatal", "alert": "fatal",
+	// klog prefixes, e.g. "E0102 15:04:05.000000"
+	"i": "info", "w": "warn", "e": "error", "f": "fatal",
+}
+
+var (
+	// levelFieldRegex matches structured levels: {"level":"error"}, level=error, severity: ERROR
+	levelFieldRegex = regexp.MustCompile(`(?i)\b(?:level|lvl|severity)"?\s*[:=]\s*"?([a-z]+)`)
+	// klogRegex matches the level prefix of klog lines
+	klogRegex = regexp.MustCompile(`^([IWEF])\d{4} \d{2}:\d{2}:\d{2}`)
+	// levelWordRegex matches upper case level words of plain text logs, e.g. "[ERROR] ..." or "WARN ..."
+	levelWordRegex = regexp.MustCompile(`\b(TRACE|DEBUG|INFO|WARN|WARNING|ERROR|FATAL|PANIC|CRITICAL)\b`)
+)
+
+// Filter selects log lines on the server, so clients only receive what they are looking for
+type Filter struct {
+	grep     []string
+	exclude  []string
+	minLevel int
+}
+
+// NewFilter creates a filter keeping lines that contain any of grep, none of exclude (both case
+// insensitive) and have at least level. Lines without a recognizable level are dropped when a
+// level is given. It returns nil when nothing is filtered.
+func NewFilter(grep, exclude []string, level string) (*Filter, error) {
+	f := &Filter{minLevel: -1}
+	for _, s := range grep {
+		if s != "" {
+			f.grep = append(f.grep, strings.ToLower(s))
+		}
+	}
+	for _, s := range exclude {
+		if s != "" {
+			f.exclude = append(f.exclude, strings.ToLower(s))
+		}
+	}
+	if level != "" {
+		minLevel, ok := parseLevel(level)
+		if !ok {
+			return nil, fmt.Errorf("unknown log level %q", level)
+		}
+		f.minLevel = minLevel
+	}
+
+	if len(f.grep) == 0 && len(f.exclude) == 0 && f.minLevel < 0 {
+		return nil, nil
+	}
+	return f, nil
+}
+
+// Match reports whether a line passes the filter. A nil filter matches every line.
+func (f *Filter) Match(line string) bool {
+	if f == nil {
+		return true
+	}
+	if f.minLevel >= 0 {
+		level, ok := lineLevel(line)
+		if !ok || level < f.minLevel {
+			return false
+		}
+	}
+
+	lower := strings.ToLower(line)
+	for _, s := range f.exclude {
+		if strings.Contains(lower, s) {
+			return false
+		}
+	}
+	if len(f.grep) == 0 {
+		return true
+	}
+	for _, s := range f.grep {
+		if strings.Contains(lower, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseLevel(level string) (int, bool) {
+	level = strings.ToLower(level)
+	if alias, ok := levelAliases[level]; ok {
+		level = alias
+	}
+	severity, ok := levels[level]
+	return severity, ok
+}
+
+// lineLevel detects the level of a structured (JSON, logfmt), klog or plain text log line
+func lineLevel(line string) (int, bool) {
+	if match := levelFieldRegex.FindStringSubmatch(line); match != nil {
+		if level, ok := parseLevel(match[1]); ok {
+			return level, true
+		}
+	}
+	if match := klogRegex.FindStringSubmatch(line); match != nil {
+		return parseLevel(match[1])
+	}
+	if match := levelWordRegex.FindStringSubmatch(line); match != nil {
+		return parseLevel(match[1])
+	}
+	return 0, false
+}
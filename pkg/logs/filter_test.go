@@ -0,0 +1,59 @@
+package logs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFilter_Empty(t *testing.T) {
+	f, err := NewFilter([]string{""}, nil, "")
+	require.NoError(t, err)
+	assert.Nil(t, f)
+	assert.True(t, f.Match("anything"))
+
+	_, err = NewFilter(nil, nil, "loud")
+	assert.Error(t, err)
+}
+
+func TestFilter_GrepAndExclude(t *testing.T) {
+	f, err := NewFilter([]string{"timeout", "refused"}, []string{"healthz"}, "")
+	require.NoError(t, err)
+
+	assert.True(t, f.Match("request Timeout after 30s"))
+	assert.True(t, f.Match("connection refused"))
+	assert.False(t, f.Match("GET /healthz timeout"))
+	assert.False(t, f.Match("request served"))
+}
+
+func TestFilter_Level(t *testing.T) {
+	f, err := NewFilter(nil, nil, "warning")
+	require.NoError(t, err)
+
+	tests := map[string]bool{
+		`{"level":"error","msg":"failed"}`:                    true,
+		`{"level":"info","msg":"error count is 0"}`:           false,
+		`time=2026-01-01T00:00:00Z level=WARN msg=slow`:       true,
+		`level=debug msg="retrying"`:                          false,
+		`E0102 15:04:05.000000       1 controller.go:1] boom`: true,
+		`I0102 15:04:05.000000       1 controller.go:1] ok`:   false,
+		`2026-01-01 12:00:00 [ERROR] database unavailable`:    true,
+		`2026-01-01 12:00:00 INFO started`:                    false,
+		`panic: runtime error`:                                false,
+		`plain line without a level`:                          false,
+	}
+	for line, want := range tests {
+		assert.Equal(t, want, f.Match(line), line)
+	}
+}
+
+func TestFilter_Combined(t *testing.T) {
+	f, err := NewFilter([]string{"db"}, []string{"replica"}, "error")
+	require.NoError(t, err)
+
+	assert.True(t, f.Match(`level=error msg="db unavailable"`))
+	assert.False(t, f.Match(`level=info msg="db connected"`))
+	assert.False(t, f.Match(`level=error msg="db replica lagging"`))
+	assert.False(t, f.Match(`level=error msg="cache unavailable"`))
+}
@@ -0,0 +1,104 @@
+package logs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+)
+
+// kustomizationCacheTTL is how long a rollout's Kustomization lookup is shared between log
+// streams. It is shorter than the discovery interval, so a stream never acts on the result of its
+// own previous tick, while viewers of the same rollout ticking within the window share one lookup.
+const kustomizationCacheTTL = 4 * time.Second
+
+// kustomizationLookup is the lookup being cached, replaceable in tests
+type kustomizationLookup func(ctx context.Context, client *kubernetes.Client, namespace, rolloutName string) (*kustomizev1.KustomizationList, error)
+
+// kustomizationCache shares GetKustomizationsByRolloutAnnotation results between the discovery
+// loops of concurrent log streams. Entries are scoped to the caller's credentials so a viewer
+// never sees Kustomizations listed with another user's permissions.
+type kustomizationCache struct {
+	ttl    time.Duration
+	lookup kustomizationLookup
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[kustomizationCacheKey]*kustomizationCacheEntry
+}
+
+type kustomizationCacheKey struct {
+	credentials string
+	namespace   string
+	rolloutName string
+}
+
+type kustomizationCacheEntry struct {
+	ready     chan struct{}
+	fetchedAt time.Time
+	result    *kustomizev1.KustomizationList
+	err       error
+}
+
+var sharedKustomizations = newKustomizationCache(kustomizationCacheTTL, func(ctx context.Context, client *kubernetes.Client, namespace, rolloutName string) (*kustomizev1.KustomizationList, error) {
+	return client.GetKustomizationsByRolloutAnnotation(ctx, namespace, rolloutName)
+})
+
+func newKustomizationCache(ttl time.Duration, lookup kustomizationLookup) *kustomizationCache {
+	return &kustomizationCache{
+		ttl:     ttl,
+		lookup:  lookup,
+		now:     time.Now,
+		entries: map[kustomizationCacheKey]*kustomizationCacheEntry{},
+	}
+}
+
+// get returns the cached lookup result, fetching it at most once per TTL for concurrent callers.
+// Errors are returned to all waiting callers but not cached.
+func (kc *kustomizationCache) get(ctx context.Context, client *kubernetes.Client, namespace, rolloutName string) (*kustomizev1.KustomizationList, error) {
+	key := kustomizationCacheKey{credentials: client.CredentialKey(), namespace: namespace, rolloutName: rolloutName}
+
+	kc.mu.Lock()
+	now := kc.now()
+	for k, entry := range kc.entries {
+		if isDone(entry.ready) && now.Sub(entry.fetchedAt) >= kc.ttl {
+			delete(kc.entries, k)
+		}
+	}
+	entry, ok := kc.entries[key]
+	if !ok {
+		entry = &kustomizationCacheEntry{ready: make(chan struct{})}
+		kc.entries[key] = entry
+	}
+	kc.mu.Unlock()
+
+	if ok {
+		select {
+		case <-entry.ready:
+			return entry.result, entry.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	entry.result, entry.err = kc.lookup(ctx, client, namespace, rolloutName)
+	kc.mu.Lock()
+	entry.fetchedAt = kc.now()
+	if entry.err != nil {
+		delete(kc.entries, key)
+	}
+	kc.mu.Unlock()
+	close(entry.ready)
+	return entry.result, entry.err
+}
+
+func isDone(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
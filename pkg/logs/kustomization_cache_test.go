@@ -0,0 +1,85 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKustomizationCache_SharesConcurrentLookups(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	cache := newKustomizationCache(time.Minute, func(ctx context.Context, _ *kubernetes.Client, _, _ string) (*kustomizev1.KustomizationList, error) {
+		calls.Add(1)
+		<-release
+		return &kustomizev1.KustomizationList{}, nil
+	})
+
+	client := &kubernetes.Client{}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			list, err := cache.get(context.Background(), client, "ns", "app")
+			assert.NoError(t, err)
+			assert.NotNil(t, list)
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestKustomizationCache_ExpiresAndScopesByRollout(t *testing.T) {
+	var calls atomic.Int32
+	cache := newKustomizationCache(time.Second, func(ctx context.Context, _ *kubernetes.Client, _, _ string) (*kustomizev1.KustomizationList, error) {
+		calls.Add(1)
+		return &kustomizev1.KustomizationList{}, nil
+	})
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	client := &kubernetes.Client{}
+
+	_, err := cache.get(context.Background(), client, "ns", "app")
+	require.NoError(t, err)
+	_, err = cache.get(context.Background(), client, "ns", "app")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+
+	_, err = cache.get(context.Background(), client, "ns", "other")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+
+	now = now.Add(2 * time.Second)
+	_, err = cache.get(context.Background(), client, "ns", "app")
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestKustomizationCache_DoesNotCacheErrors(t *testing.T) {
+	var calls atomic.Int32
+	cache := newKustomizationCache(time.Minute, func(ctx context.Context, _ *kubernetes.Client, _, _ string) (*kustomizev1.KustomizationList, error) {
+		if calls.Add(1) == 1 {
+			return nil, errors.New("boom")
+		}
+		return &kustomizev1.KustomizationList{}, nil
+	})
+	client := &kubernetes.Client{}
+
+	_, err := cache.get(context.Background(), client, "ns", "app")
+	require.Error(t, err)
+	_, err = cache.get(context.Background(), client, "ns", "app")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+}
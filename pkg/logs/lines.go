@@ -0,0 +1,96 @@
+package logs
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxLineLength is the default limit for the content of a single log line in bytes
+const DefaultMaxLineLength = 16 * 1024
+
+// timestampPrefixLength is room kept for the RFC3339Nano timestamp Kubernetes prepends to each
+// line, so the limit applies to the line's content only
+const timestampPrefixLength = 64
+
+// MaxLineLengthFromEnv reads LOG_MAX_LINE_LENGTH, the maximum length of a log line in bytes.
+// Longer lines are truncated; 0 disables truncation.
+func MaxLineLengthFromEnv() int {
+	if value, err := strconv.Atoi(os.Getenv("LOG_MAX_LINE_LENGTH")); err == nil && value >= 0 {
+		return value
+	}
+	return DefaultMaxLineLength
+}
+
+// lineReader reads newline-terminated lines without buffering more than limit bytes of any
+// line. The rest of an overlong line is discarded, so a multi-MB line neither exhausts memory
+// nor aborts the stream the way bufio.Scanner does.
+type lineReader struct {
+	r     *bufio.Reader
+	limit int
+}
+
+func newLineReader(r io.Reader, limit int) *lineReader {
+	return &lineReader{r: bufio.NewReader(r), limit: limit}
+}
+
+// next returns the next line without its line ending, and the full length of the line when it
+// was cut short. It returns io.EOF once all lines are read.
+func (lr *lineReader) next() (line string, length int, err error) {
+	var buf []byte
+	for {
+		fragment, err := lr.r.ReadSlice('\n')
+		length += len(fragment)
+		if lr.limit <= 0 || len(buf) < lr.limit {
+			room := len(fragment)
+			if lr.limit > 0 {
+				room = min(room, lr.limit-len(buf))
+			}
+			buf = append(buf, fragment[:room]...)
+		}
+
+		switch {
+		case err == nil:
+			// The newline is not part of the line's length
+			return trimLineEnding(buf), lineLength(buf, length-1), nil
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case errors.Is(err, io.EOF) && length > 0:
+			return trimLineEnding(buf), lineLength(buf, length), nil
+		default:
+			return "", 0, err
+		}
+	}
+}
+
+func trimLineEnding(buf []byte) string {
+	return strings.TrimRight(string(buf), "\r\n")
+}
+
+// lineLength returns the length of the line when it was not read completely, otherwise zero
+func lineLength(buf []byte, length int) int {
+	if length > len(strings.TrimSuffix(string(buf), "\n")) {
+		return length
+	}
+	return 0
+}
+
+// sanitizeLine truncates content to limit bytes on a rune boundary and replaces invalid UTF-8,
+// which some applications emit as raw binary, so every line survives JSON encoding intact.
+// It reports whether the content was truncated.
+func sanitizeLine(content string, limit int) (string, bool) {
+	truncated := false
+	if limit > 0 && len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		content = content[:cut]
+		truncated = true
+	}
+	return strings.ToValidUTF8(content, "�"), truncated
+}
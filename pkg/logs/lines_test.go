@@ -0,0 +1,58 @@
+package logs
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineReader_TruncatesLongLines(t *testing.T) {
+	long := strings.Repeat("x", 100_000)
+	lr := newLineReader(strings.NewReader("short\r\n"+long+"\nlast"), 10)
+
+	line, length, err := lr.next()
+	require.NoError(t, err)
+	assert.Equal(t, "short", line)
+	assert.Zero(t, length)
+
+	line, length, err = lr.next()
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("x", 10), line)
+	assert.Equal(t, 100_000, length)
+
+	// Reading continues after an overlong line, including an unterminated last line
+	line, _, err = lr.next()
+	require.NoError(t, err)
+	assert.Equal(t, "last", line)
+
+	_, _, err = lr.next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestLineReader_Unlimited(t *testing.T) {
+	long := strings.Repeat("x", 100_000)
+	lr := newLineReader(strings.NewReader(long+"\n"), 0)
+
+	line, length, err := lr.next()
+	require.NoError(t, err)
+	assert.Equal(t, long, line)
+	assert.Zero(t, length)
+}
+
+func TestSanitizeLine(t *testing.T) {
+	line, truncated := sanitizeLine("ok \xff\xfe bytes", 0)
+	assert.False(t, truncated)
+	assert.Equal(t, "ok � bytes", line)
+
+	// Truncation never splits a multi-byte rune
+	line, truncated = sanitizeLine("aé€", 4)
+	assert.True(t, truncated)
+	assert.Equal(t, "aé", line)
+
+	line, truncated = sanitizeLine("short", 10)
+	assert.False(t, truncated)
+	assert.Equal(t, "short", line)
+}
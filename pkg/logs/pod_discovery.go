@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"sort"
 	"strings"
 
 	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -19,6 +22,7 @@ type LogTarget struct {
 	Namespace     string          `json:"namespace"`
 	LabelSelector labels.Selector `json:"labelSelector"` // Selector for Stern
 	Type          string          `json:"type"`          // "pod" or "test"
+	Track         string          `json:"track"`         // "canary" or "stable" during a Kruise canary
 	ContainerName string          `json:"containerName"` // Optional concrete container name
 }
 
@@ -29,6 +33,7 @@ type PodDiscovery struct {
 	rolloutName       string
 	currentVersionTag string
 	filterType        string
+	track             string
 }
 
 // NewPodDiscovery creates a new PodDiscovery instance
@@ -42,88 +47,118 @@ func NewPodDiscovery(client *kubernetes.Client, namespace, rolloutName, currentV
 	}
 }
 
+// WithTrack limits discovery to the workload pods on a track of a Kruise canary rollout, see
+// kubernetes.CanaryTracks. Without a canary in progress no pod is on a track.
+func (pd *PodDiscovery) WithTrack(track string) *PodDiscovery {
+	pd.track = track
+	return pd
+}
+
 // Discover finds all targets that should have their logs streamed
 func (pd *PodDiscovery) Discover(ctx context.Context) ([]LogTarget, error) {
+	targets, _, err := pd.discover(ctx)
+	return targets, err
+}
+
+// discover finds the targets and the canary tracks their pods are on
+func (pd *PodDiscovery) discover(ctx context.Context) ([]LogTarget, *kubernetes.CanaryTracks, error) {
 	var targets []LogTarget
+	var tracks *kubernetes.CanaryTracks
 
 	if pd.filterType == "" || pd.filterType == "pod" {
-		deploymentTargets, err := pd.discoverDeployments(ctx)
+		var err error
+		if tracks, err = pd.client.GetCanaryTracks(ctx, pd.namespace, pd.rolloutName); err != nil {
+			return nil, nil, fmt.Errorf("failed to discover canary tracks: %w", err)
+		}
+		deploymentTargets, err := pd.discoverDeployments(ctx, tracks)
 		if err != nil {
-			return nil, fmt.Errorf("failed to discover deployments: %w", err)
+			return nil, nil, fmt.Errorf("failed to discover deployments: %w", err)
 		}
 		targets = append(targets, deploymentTargets...)
 	}
 
-	if pd.filterType == "" || pd.filterType == "test" {
+	// Test pods are on no track
+	if (pd.filterType == "" || pd.filterType == "test") && pd.track == "" {
 		testTargets, err := pd.discoverJobs(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to discover test jobs: %w", err)
+			return nil, nil, fmt.Errorf("failed to discover test jobs: %w", err)
 		}
 		targets = append(targets, testTargets...)
 	}
 
-	return targets, nil
+	return targets, tracks, nil
 }
 
-// discoverDeployments finds deployments and creates LogTargets for them
-// It now discovers ReplicaSets for the deployment and targets them via pod-template-hash
-func (pd *PodDiscovery) discoverDeployments(ctx context.Context) ([]LogTarget, error) {
+// discoverDeployments finds deployments and Argo rollouts and creates LogTargets for them
+// It now discovers ReplicaSets for the workload and targets them via their pod template hash
+func (pd *PodDiscovery) discoverDeployments(ctx context.Context, tracks *kubernetes.CanaryTracks) ([]LogTarget, error) {
 	var targets []LogTarget
 
 	// filters
 
-	kustomizations, err := pd.client.GetKustomizationsByRolloutAnnotation(ctx, pd.namespace, pd.rolloutName)
+	// Shared between concurrent streams of the same rollout, see kustomizationCache
+	kustomizations, err := sharedKustomizations.get(ctx, pd.client, pd.namespace, pd.rolloutName)
 	if err != nil {
-		fmt.Printf("Error getting kustomizations for rollout %s: %v\n", pd.rolloutName, err)
+		slog.Warn("Error getting kustomizations for rollout", "rollout", pd.rolloutName, "error", err)
 		return targets, err
 	}
 	if kustomizations == nil {
-		fmt.Printf("No kustomizations found for rollout %s\n", pd.rolloutName)
+		slog.Debug("No kustomizations found for rollout", "rollout", pd.rolloutName)
 		return targets, nil
 	}
-	fmt.Printf("Found %d kustomizations for rollout %s\n", len(kustomizations.Items), pd.rolloutName)
+	slog.Debug("Found kustomizations for rollout", "rollout", pd.rolloutName, "count", len(kustomizations.Items))
 
 	for _, kustomization := range kustomizations.Items {
 		managedResources, err := pd.client.GetKustomizationManagedResources(ctx, kustomization.Namespace, kustomization.Name)
 		if err != nil {
-			fmt.Printf("Error getting managed resources for kustomization %s: %v\n", kustomization.Name, err)
+			slog.Warn("Error getting managed resources for kustomization", "kustomization", kustomization.Name, "error", err)
 			continue
 		}
-		fmt.Printf("Found %d managed resources in kustomization %s\n", len(managedResources), kustomization.Name)
+		slog.Debug("Found managed resources in kustomization", "kustomization", kustomization.Name, "count", len(managedResources))
 
 		for _, resource := range managedResources {
-			if !strings.Contains(resource.GroupVersionKind, "apps/v1/Deployment") {
+			// Argo Rollouts own the ReplicaSets of their versions like Deployments do, but label
+			// them with their own pod template hash
+			ownerKind, hashLabel := "Deployment", "pod-template-hash"
+			switch {
+			case strings.Contains(resource.GroupVersionKind, "apps/v1/Deployment"):
+			case strings.HasPrefix(resource.GroupVersionKind, "argoproj.io/") && strings.HasSuffix(resource.GroupVersionKind, "/Rollout"):
+				ownerKind, hashLabel = "Rollout", "rollouts-pod-template-hash"
+			default:
+				continue
+			}
+			if resource.Object == nil {
 				continue
 			}
 
-			// Parse Deployment
-			var deployment appsv1.Deployment
-			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(resource.Object.Object, &deployment); err != nil {
-				fmt.Printf("Error converting deployment: %v\n", err)
+			// Parse the workload, of which only the metadata and selector are needed
+			var workload appsv1.Deployment
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(resource.Object.Object, &workload); err != nil {
+				slog.Warn("Error converting workload", "kind", ownerKind, "error", err)
 				continue
 			}
-			fmt.Printf("Found Deployment %s\n", deployment.Name)
+			slog.Debug("Found workload", "kind", ownerKind, "name", workload.Name)
 
-			// Find ReplicaSets for this Deployment
-			replicaSets, err := pd.client.GetReplicaSets(ctx, deployment.Namespace)
+			// Find ReplicaSets for this workload
+			replicaSets, err := pd.client.GetReplicaSets(ctx, workload.Namespace)
 			if err != nil {
-				fmt.Printf("Error listing ReplicaSets: %v\n", err)
+				slog.Warn("Error listing ReplicaSets", "error", err)
 				continue
 			}
-			fmt.Printf("Found %d ReplicaSets in namespace %s\n", len(replicaSets.Items), deployment.Namespace)
+			slog.Debug("Found ReplicaSets", "namespace", workload.Namespace, "count", len(replicaSets.Items))
 
-			// Deployment selector to match ReplicaSets
-			deploymentSelector, err := metav1LabelSelectorAsSelector(deployment.Spec.Selector)
+			// Workload selector to match ReplicaSets
+			workloadSelector, err := metav1LabelSelectorAsSelector(workload.Spec.Selector)
 			if err != nil {
 				continue
 			}
 
 			for _, rs := range replicaSets.Items {
-				// Check if ReplicaSet is owned by or matches the Deployment
+				// Check if ReplicaSet is owned by or matches the workload
 				// 1. Check OwnerReferences (strongest link)
 				isOwned := false
 				for _, ref := range rs.OwnerReferences {
-					if ref.Kind == "Deployment" && ref.Name == deployment.Name {
+					if ref.Kind == ownerKind && ref.Name == workload.Name {
 						isOwned = true
 						break
 					}
@@ -131,7 +166,7 @@ func (pd *PodDiscovery) discoverDeployments(ctx context.Context) ([]LogTarget, e
 
 				// 2. Check Label Selector if not explicitly owned (though RS usually owned by Deploy)
 				if !isOwned {
-					if deploymentSelector.Matches(labels.Set(rs.Labels)) {
+					if workloadSelector.Matches(labels.Set(rs.Labels)) {
 						isOwned = true
 					}
 				}
@@ -164,10 +199,15 @@ func (pd *PodDiscovery) discoverDeployments(ctx context.Context) ([]LogTarget, e
 					}
 				}
 
-				// Create Target for this ReplicaSet using pod-template-hash
+				// Create Target for this ReplicaSet using its pod template hash
 				// This ensures we only get logs from pods belonging to this specific RS version
-				if hash, ok := rs.Labels["pod-template-hash"]; ok {
-					selector, err := labels.Parse(fmt.Sprintf("pod-template-hash=%s", hash))
+				if hash, ok := rs.Labels[hashLabel]; ok {
+					// The canary ReplicaSet carries the pod template hash of the canary
+					track := tracks.RevisionTrack(hash)
+					if pd.track != "" && track != pd.track {
+						continue
+					}
+					selector, err := labels.Parse(fmt.Sprintf("%s=%s", hashLabel, hash))
 					if err != nil {
 						continue
 					}
@@ -177,6 +217,7 @@ func (pd *PodDiscovery) discoverDeployments(ctx context.Context) ([]LogTarget, e
 						Namespace:     rs.Namespace,
 						LabelSelector: selector,
 						Type:          "pod",
+						Track:         track,
 					})
 				}
 			}
@@ -186,49 +227,83 @@ func (pd *PodDiscovery) discoverDeployments(ctx context.Context) ([]LogTarget, e
 	return targets, nil
 }
 
-// discoverJobs finds RolloutTest jobs and creates LogTargets for them
+// discoverJobs finds the test jobs of the rollout, e.g. of RolloutTests with Kruise or of
+// AnalysisRuns with Argo Rollouts, and creates LogTargets for them
 func (pd *PodDiscovery) discoverJobs(ctx context.Context) ([]LogTarget, error) {
 	var targets []LogTarget
 
-	rolloutTests, err := pd.client.GetRolloutTests(ctx, pd.namespace)
-	if err != nil {
-		fmt.Printf("Error listing RolloutTests: %v\n", err)
-		return targets, err
-	}
+	for _, provider := range pd.client.ProgressiveDeliveryProviders() {
+		jobs, err := provider.TestJobs(ctx, pd.namespace, pd.rolloutName)
+		if err != nil {
+			slog.Warn("Error listing test jobs", "provider", provider.Name(), "error", err)
+			return targets, err
+		}
 
-	// fmt.Printf("DEBUG: Found %d RolloutTests in namespace %s\n", len(rolloutTests.Items), pd.namespace)
+		for _, job := range jobs {
+			selector, err := labels.Parse(fmt.Sprintf("batch.kubernetes.io/job-name=%s", job))
+			if err != nil {
+				slog.Warn("Failed to parse label selector for job", "job", job, "error", err)
+				continue
+			}
 
-	for _, rt := range rolloutTests.Items {
-		match := false
-		if rt.Spec.RolloutName == pd.rolloutName {
-			match = true
-		} else if appLabel := rt.Labels["app"]; appLabel != "" && strings.Contains(pd.rolloutName, appLabel) {
-			match = true // Fallback: match if rollout name contains app label (e.g. hello-world-app contains hello-world)
+			targets = append(targets, LogTarget{
+				ID:            fmt.Sprintf("job/%s/%s", pd.namespace, job),
+				Namespace:     pd.namespace,
+				LabelSelector: selector,
+				Type:          "test",
+			})
 		}
+	}
 
-		if !match {
-			continue
-		}
+	return targets, nil
+}
 
-		if rt.Status.JobName == "" {
-			continue
-		}
+// DiscoveredPod is a pod of a discovered target
+type DiscoveredPod struct {
+	Pod   corev1.Pod
+	Type  string // "pod" or "test"
+	Track string // "canary" or "stable" during a Kruise canary
+}
 
-		selector, err := labels.Parse(fmt.Sprintf("batch.kubernetes.io/job-name=%s", rt.Status.JobName))
+// DiscoverPods lists the pods of all discovered targets, sorted by type and name
+func (pd *PodDiscovery) DiscoverPods(ctx context.Context) ([]DiscoveredPod, error) {
+	targets, tracks, err := pd.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var pods []DiscoveredPod
+	for _, target := range targets {
+		podList, err := pd.client.GetPodsBySelector(ctx, target.Namespace, target.LabelSelector)
 		if err != nil {
-			fmt.Printf("Failed to parse label selector for job %s: %v\n", rt.Status.JobName, err)
-			continue
+			return nil, fmt.Errorf("failed to list pods for %s: %w", target.ID, err)
+		}
+		for _, pod := range podList.Items {
+			key := pod.Namespace + "/" + pod.Name
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			discovered := DiscoveredPod{Pod: pod, Type: target.Type, Track: target.Track}
+			if target.Type == "pod" {
+				// Kruise's batch labels are more precise than the ReplicaSet the pod belongs to
+				discovered.Track = tracks.PodTrack(pod)
+			}
+			if pd.track != "" && discovered.Track != pd.track {
+				continue
+			}
+			pods = append(pods, discovered)
 		}
-
-		targets = append(targets, LogTarget{
-			ID:            fmt.Sprintf("job/%s/%s", pd.namespace, rt.Status.JobName),
-			Namespace:     pd.namespace,
-			LabelSelector: selector,
-			Type:          "test",
-		})
 	}
 
-	return targets, nil
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Type != pods[j].Type {
+			return pods[i].Type < pods[j].Type
+		}
+		return pods[i].Pod.Name < pods[j].Pod.Name
+	})
+	return pods, nil
 }
 
 // Helper to convert metav1.LabelSelector to labels.Selector
@@ -1,15 +1,17 @@
 package logs
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/kuberik/rollout-dashboard/pkg/api"
 	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -26,6 +28,7 @@ type PodInfo struct {
 	Name      string `json:"name"`
 	Namespace string `json:"namespace"`
 	Type      string `json:"type"`
+	Track     string `json:"track,omitempty"`
 }
 
 // LogStreamer handles streaming logs using custom direct streaming
@@ -37,22 +40,32 @@ type LogStreamer struct {
 	activeStreams map[string]context.CancelFunc // key: target.ID
 	streamsMu     sync.Mutex
 	wg            sync.WaitGroup
-	sinceTime     *time.Time
+	window        Window
+	maxLineLength int
+	filter        *Filter
+	selection     Selection
 
 	// Track active pods for frontend (aggregated from all targets)
 	activePods   map[string]PodInfo // key: podName
 	activePodsMu sync.Mutex
+
+	// dropped counts log lines dropped because the SSE channel was full
+	dropped atomic.Int64
 }
 
-// NewLogStreamer creates a new LogStreamer instance
-func NewLogStreamer(client *kubernetes.Client, discovery *PodDiscovery, ctx context.Context, sinceTime *time.Time) *LogStreamer {
+// NewLogStreamer creates a new LogStreamer instance. Each container stream starts with the log
+// history selected by window. Log lines longer than maxLineLength bytes are truncated; 0 disables
+// truncation. Only lines matching filter are sent; nil sends all.
+func NewLogStreamer(client *kubernetes.Client, discovery *PodDiscovery, ctx context.Context, window Window, maxLineLength int, filter *Filter) *LogStreamer {
 	ls := &LogStreamer{
 		client:        client,
 		discovery:     discovery,
 		sseChan:       make(chan SSEMessage, 1000),
 		ctx:           ctx,
 		activeStreams: make(map[string]context.CancelFunc),
-		sinceTime:     sinceTime,
+		window:        window,
+		maxLineLength: maxLineLength,
+		filter:        filter,
 		activePods:    make(map[string]PodInfo),
 	}
 	// Start periodic pods broadcast
@@ -130,11 +143,21 @@ func (ls *LogStreamer) Start() error {
 	return nil
 }
 
+// Select replaces the muted and soloed pods and containers, see Selection
+func (ls *LogStreamer) Select(mute, solo []string) {
+	ls.selection.Set(mute, solo)
+}
+
 // GetSSEChannel returns the channel for SSE messages
 func (ls *LogStreamer) GetSSEChannel() <-chan SSEMessage {
 	return ls.sseChan
 }
 
+// Dropped returns how many log lines were dropped because the client did not keep up
+func (ls *LogStreamer) Dropped() int64 {
+	return ls.dropped.Load()
+}
+
 // SendKeepalive sends a ping message to keep the connection alive
 func (ls *LogStreamer) SendKeepalive() {
 	select {
@@ -249,7 +272,7 @@ func (ls *LogStreamer) reconcilePodStreams(ctx context.Context, target LogTarget
 		LabelSelector: target.LabelSelector.String(),
 	})
 	if err != nil {
-		fmt.Printf("Error listing pods for target %s: %v\n", target.ID, err)
+		slog.Warn("Error listing pods for target", "target", target.ID, "error", err)
 		return
 	}
 
@@ -263,6 +286,7 @@ func (ls *LogStreamer) reconcilePodStreams(ctx context.Context, target LogTarget
 			Name:      pod.Name,
 			Namespace: pod.Namespace,
 			Type:      target.Type,
+			Track:     target.Track,
 		}
 	}
 	ls.activePodsMu.Unlock()
@@ -282,7 +306,7 @@ func (ls *LogStreamer) reconcilePodStreams(ctx context.Context, target LogTarget
 			if _, active := streamKeys[key]; !active {
 				podCtx, cancel := context.WithCancel(ctx)
 				streamKeys[key] = cancel
-				go ls.streamContainerLogs(podCtx, pod, container.Name, target.Type)
+				go ls.streamContainerLogs(podCtx, pod, container.Name, target)
 			}
 		}
 	}
@@ -308,23 +332,13 @@ func (ls *LogStreamer) reconcilePodStreams(ctx context.Context, target LogTarget
 	}
 }
 
-func (ls *LogStreamer) streamContainerLogs(ctx context.Context, pod corev1.Pod, containerName, filterType string) {
-	// Default options
-	tail := int64(1000)
+func (ls *LogStreamer) streamContainerLogs(ctx context.Context, pod corev1.Pod, containerName string, target LogTarget) {
 	opts := &corev1.PodLogOptions{
 		Container:  containerName,
 		Follow:     true,
 		Timestamps: true,
-		TailLines:  &tail,
-	}
-
-	// Use SinceTime if configured
-	if ls.sinceTime != nil {
-		t := metav1.NewTime(*ls.sinceTime)
-		opts.SinceTime = &t
-		opts.TailLines = nil // SinceTime and TailLines are mutually exclusive usually, or SinceTime takes precedence?
-		// Kubernetes API allows both but usually one is preferred. Let's unset TailLines if SinceTime is set.
 	}
+	ls.window.Apply(opts)
 
 	req := ls.client.GetClientset().CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, opts)
 	stream, err := req.Stream(ctx)
@@ -333,14 +347,21 @@ func (ls *LogStreamer) streamContainerLogs(ctx context.Context, pod corev1.Pod,
 	}
 	defer stream.Close()
 
-	scanner := bufio.NewScanner(stream)
+	readLimit := 0
+	if ls.maxLineLength > 0 {
+		readLimit = ls.maxLineLength + timestampPrefixLength
+	}
+	reader := newLineReader(stream, readLimit)
 
 	// Parse timestamp from line (Kubernetes adds it: 2023-.... content)
 	// Timestamp regex: RFC3339Nano or RFC3339 at start of line
 	timestampRegex := regexp.MustCompile(`^(\S+) (.*)$`)
 
-	for scanner.Scan() {
-		line := scanner.Text()
+	for {
+		line, length, err := reader.next()
+		if err != nil {
+			return
+		}
 
 		var timestamp int64
 		var content string
@@ -360,14 +381,30 @@ func (ls *LogStreamer) streamContainerLogs(ctx context.Context, pod corev1.Pod,
 			timestamp = time.Now().UnixMilli()
 			content = line
 		}
+		// Filter before encoding so dropped lines never reach the SSE channel
+		if !ls.selection.Allows(pod.Name, containerName) || !ls.filter.Match(content) {
+			continue
+		}
 
-		logEntry := map[string]interface{}{
-			"pod":       pod.Name,
-			"container": containerName,
-			"type":      filterType,
-			"line":      content,
-			"timestamp": timestamp,
-			"namespace": pod.Namespace,
+		// Lines cut short while reading report their full length, without the timestamp prefix
+		originalLength := len(content)
+		if length > 0 {
+			originalLength = length - (len(line) - len(content))
+		}
+		content, truncated := sanitizeLine(content, ls.maxLineLength)
+
+		logEntry := api.LogLine{
+			Pod:       pod.Name,
+			Container: containerName,
+			Type:      target.Type,
+			Track:     target.Track,
+			Line:      content,
+			Timestamp: timestamp,
+			Namespace: pod.Namespace,
+		}
+		if truncated {
+			logEntry.Truncated = true
+			logEntry.OriginalLength = originalLength
 		}
 
 		jsonBytes, err := json.Marshal(logEntry)
@@ -380,7 +417,7 @@ func (ls *LogStreamer) streamContainerLogs(ctx context.Context, pod corev1.Pod,
 			return
 		case ls.sseChan <- SSEMessage{Event: "log", Data: string(jsonBytes)}:
 		default:
-			// drop
+			ls.dropped.Add(1)
 		}
 	}
 }
@@ -0,0 +1,84 @@
+package logs
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultTailLines is how many lines of history a log stream starts with when the client does
+// not choose a window
+const DefaultTailLines = 1000
+
+// Window selects how much log history is returned, see PodLogOptions
+type Window struct {
+	TailLines    *int64
+	SinceSeconds *int64
+	SinceTime    *time.Time
+}
+
+// ParseWindow parses the tail, since and sinceTime query parameters of the log endpoints:
+//   - tail: number of lines from the end of the log, or "all"
+//   - since: relative duration such as "15m" or "2h". A bare number is a Unix timestamp in
+//     milliseconds, as sent by clients resuming a stream.
+//   - sinceTime: RFC 3339 timestamp
+//
+// since and sinceTime are mutually exclusive. defaultTail is used when none of the parameters is
+// given; 0 returns whole logs.
+func ParseWindow(tail, since, sinceTime string, defaultTail int64) (Window, error) {
+	var w Window
+
+	switch tail {
+	case "":
+		if since == "" && sinceTime == "" && defaultTail > 0 {
+			w.TailLines = &defaultTail
+		}
+	case "all":
+	default:
+		lines, err := strconv.ParseInt(tail, 10, 64)
+		if err != nil || lines < 0 {
+			return Window{}, fmt.Errorf("tail must be a non-negative number of lines or \"all\"")
+		}
+		w.TailLines = &lines
+	}
+
+	if since != "" && sinceTime != "" {
+		return Window{}, fmt.Errorf("since and sinceTime are mutually exclusive")
+	}
+	if since != "" {
+		if ms, err := strconv.ParseInt(since, 10, 64); err == nil {
+			t := time.UnixMilli(ms)
+			w.SinceTime = &t
+		} else {
+			duration, err := time.ParseDuration(since)
+			if err != nil || duration <= 0 {
+				return Window{}, fmt.Errorf("since must be a positive duration such as 15m")
+			}
+			seconds := int64(math.Ceil(duration.Seconds()))
+			w.SinceSeconds = &seconds
+		}
+	}
+	if sinceTime != "" {
+		t, err := time.Parse(time.RFC3339, sinceTime)
+		if err != nil {
+			return Window{}, fmt.Errorf("sinceTime must be an RFC 3339 timestamp")
+		}
+		w.SinceTime = &t
+	}
+	return w, nil
+}
+
+// Apply sets the window on log options
+func (w Window) Apply(opts *corev1.PodLogOptions) {
+	opts.TailLines = w.TailLines
+	opts.SinceSeconds = w.SinceSeconds
+	opts.SinceTime = nil
+	if w.SinceTime != nil {
+		t := metav1.NewTime(*w.SinceTime)
+		opts.SinceTime = &t
+	}
+}
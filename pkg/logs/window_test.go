@@ -0,0 +1,70 @@
+package logs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestParseWindow(t *testing.T) {
+	w, err := ParseWindow("", "", "", DefaultTailLines)
+	require.NoError(t, err)
+	assert.Equal(t, Window{TailLines: ptr.To[int64](DefaultTailLines)}, w)
+
+	w, err = ParseWindow("", "", "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, Window{}, w)
+
+	w, err = ParseWindow("all", "", "", DefaultTailLines)
+	require.NoError(t, err)
+	assert.Nil(t, w.TailLines)
+
+	// The default tail does not cut a requested time range short
+	w, err = ParseWindow("", "90s", "", DefaultTailLines)
+	require.NoError(t, err)
+	assert.Equal(t, Window{SinceSeconds: ptr.To[int64](90)}, w)
+
+	w, err = ParseWindow("50", "1500ms", "", DefaultTailLines)
+	require.NoError(t, err)
+	assert.Equal(t, Window{TailLines: ptr.To[int64](50), SinceSeconds: ptr.To[int64](2)}, w)
+
+	w, err = ParseWindow("", "1700000000123", "", DefaultTailLines)
+	require.NoError(t, err)
+	require.NotNil(t, w.SinceTime)
+	assert.Equal(t, time.UnixMilli(1700000000123), *w.SinceTime)
+
+	w, err = ParseWindow("", "", "2026-01-02T15:04:05Z", DefaultTailLines)
+	require.NoError(t, err)
+	require.NotNil(t, w.SinceTime)
+	assert.Equal(t, time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC), w.SinceTime.UTC())
+	assert.Nil(t, w.TailLines)
+}
+
+func TestParseWindow_Invalid(t *testing.T) {
+	for _, params := range [][3]string{
+		{"-1", "", ""},
+		{"many", "", ""},
+		{"", "yesterday", ""},
+		{"", "-5m", ""},
+		{"", "", "2026-01-02"},
+		{"", "5m", "2026-01-02T15:04:05Z"},
+	} {
+		_, err := ParseWindow(params[0], params[1], params[2], DefaultTailLines)
+		assert.Error(t, err, params)
+	}
+}
+
+func TestWindow_Apply(t *testing.T) {
+	since := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	opts := &corev1.PodLogOptions{Container: "app", TailLines: ptr.To[int64](10)}
+	Window{SinceTime: &since}.Apply(opts)
+
+	assert.Equal(t, "app", opts.Container)
+	assert.Nil(t, opts.TailLines)
+	require.NotNil(t, opts.SinceTime)
+	assert.True(t, since.Equal(opts.SinceTime.Time))
+}
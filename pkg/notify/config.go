@@ -0,0 +1,171 @@
+package notify
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// defaultDedupWindow is used when the configuration does not set dedupWindow
+const defaultDedupWindow = time.Hour
+
+// Config holds the routing rules of notifications. It is read from a YAML or JSON file:
+//
+//	dedupWindow: 30m
+//	routes:
+//	  - name: production-pager
+//	    match:
+//	      environments: [production]
+//	      severities: [critical]
+//	    webhooks: [https://events.example.com/pager]
+//	  - name: chat
+//	    match:
+//	      events: [deployed, bake-failed]
+//	    format: slack
+//	    webhooks: [https://hooks.example.com/chat]
+//	    quietHours: {start: "20:00", end: "08:00", timezone: Europe/Berlin}
+type Config struct {
+	// DedupWindow suppresses repeated notifications with the same key on the same route
+	DedupWindow *metav1.Duration `json:"dedupWindow,omitempty"`
+	// Routes are evaluated in order; the first matching route receives the notification unless
+	// it sets continue
+	Routes []Route `json:"routes"`
+}
+
+// Route sends matching notifications to webhooks
+type Route struct {
+	Name     string   `json:"name"`
+	Match    Match    `json:"match,omitempty"`
+	Webhooks []string `json:"webhooks"`
+	// Format is the body posted to the webhooks: FormatGeneric (default), FormatSlack or FormatTeams
+	Format string `json:"format,omitempty"`
+	// QuietHours drops notifications of this route during the given daily period
+	QuietHours *QuietHours `json:"quietHours,omitempty"`
+	// Continue also evaluates the following routes after this one matched
+	Continue bool `json:"continue,omitempty"`
+}
+
+// Match selects notifications. Empty lists match everything; values within a list are alternatives.
+type Match struct {
+	Environments []string `json:"environments,omitempty"`
+	Namespaces   []string `json:"namespaces,omitempty"`
+	Severities   []string `json:"severities,omitempty"`
+	Events       []string `json:"events,omitempty"`
+}
+
+// QuietHours is a daily period in "15:04" format. A start after end spans midnight.
+type QuietHours struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone,omitempty"`
+
+	start, end time.Duration
+	location   *time.Location
+}
+
+// ConfigFromEnv reads the configuration file named by NOTIFY_CONFIG. Without it no routes are
+// configured and notifications are only logged.
+func ConfigFromEnv() (Config, error) {
+	path := os.Getenv("NOTIFY_CONFIG")
+	if path == "" {
+		return Config{}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read notification config: %w", err)
+	}
+	return ParseConfig(data)
+}
+
+// ParseConfig parses and validates a YAML or JSON configuration
+func ParseConfig(data []byte) (Config, error) {
+	var cfg Config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to parse notification config: %w", err)
+	}
+	for i := range cfg.Routes {
+		route := &cfg.Routes[i]
+		if route.Name == "" {
+			return Config{}, fmt.Errorf("route %d: name is required", i)
+		}
+		if len(route.Webhooks) == 0 {
+			return Config{}, fmt.Errorf("route %s: at least one webhook is required", route.Name)
+		}
+		for _, severity := range route.Match.Severities {
+			if !validSeverity(severity) {
+				return Config{}, fmt.Errorf("route %s: unknown severity %q", route.Name, severity)
+			}
+		}
+		for _, event := range route.Match.Events {
+			if !validEvent(event) {
+				return Config{}, fmt.Errorf("route %s: unknown event %q", route.Name, event)
+			}
+		}
+		switch route.Format {
+		case "", FormatGeneric, FormatSlack, FormatTeams:
+		default:
+			return Config{}, fmt.Errorf("route %s: unknown format %q", route.Name, route.Format)
+		}
+		if route.QuietHours != nil {
+			if err := route.QuietHours.parse(); err != nil {
+				return Config{}, fmt.Errorf("route %s: %w", route.Name, err)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+func (q *QuietHours) parse() error {
+	start, err := time.Parse("15:04", q.Start)
+	if err != nil {
+		return fmt.Errorf("invalid quiet hours start %q", q.Start)
+	}
+	end, err := time.Parse("15:04", q.End)
+	if err != nil {
+		return fmt.Errorf("invalid quiet hours end %q", q.End)
+	}
+	q.start = time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute
+	q.end = time.Duration(end.Hour())*time.Hour + time.Duration(end.Minute())*time.Minute
+
+	q.location = time.UTC
+	if q.Timezone != "" {
+		if q.location, err = time.LoadLocation(q.Timezone); err != nil {
+			return fmt.Errorf("invalid quiet hours timezone %q", q.Timezone)
+		}
+	}
+	return nil
+}
+
+// Contains reports whether t falls into the quiet hours
+func (q *QuietHours) Contains(t time.Time) bool {
+	t = t.In(q.location)
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if q.start <= q.end {
+		return offset >= q.start && offset < q.end
+	}
+	return offset >= q.start || offset < q.end
+}
+
+// Matches reports whether a notification is selected by the match
+func (m Match) Matches(n Notification) bool {
+	return matchesAny(m.Environments, n.Environment) &&
+		matchesAny(m.Namespaces, n.Namespace) &&
+		matchesAny(m.Severities, n.Severity) &&
+		matchesAny(m.Events, n.Event)
+}
+
+func matchesAny(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
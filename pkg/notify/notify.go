@@ -0,0 +1,309 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Severities of notifications
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+func validSeverity(severity string) bool {
+	switch severity {
+	case SeverityInfo, SeverityWarning, SeverityCritical:
+		return true
+	}
+	return false
+}
+
+// Rollout lifecycle events notifications are sent for
+const (
+	// EventDeployed is sent when a rollout deployed a new version
+	EventDeployed = "deployed"
+	// EventForceDeployed is sent when a version was force deployed, skipping gates and ordering
+	EventForceDeployed = "force-deployed"
+	// EventGateBlocked is sent when a gate starts blocking a rollout
+	EventGateBlocked = "gate-blocked"
+	// EventBakeFailed is sent when the bake of a rollout's current deployment failed
+	EventBakeFailed = "bake-failed"
+)
+
+func validEvent(event string) bool {
+	switch event {
+	case EventDeployed, EventForceDeployed, EventGateBlocked, EventBakeFailed:
+		return true
+	}
+	return false
+}
+
+// Formats of the body posted to webhooks
+const (
+	// FormatGeneric posts the Payload as JSON; its text field is understood by Slack-compatible
+	// chat webhooks
+	FormatGeneric = "generic"
+	// FormatSlack posts a Slack message with a colored attachment
+	FormatSlack = "slack"
+	// FormatTeams posts a Microsoft Teams message card
+	FormatTeams = "teams"
+)
+
+// severityColors color chat messages by severity
+var severityColors = map[string]string{
+	SeverityInfo:     "2EB67D",
+	SeverityWarning:  "ECB22E",
+	SeverityCritical: "E01E5A",
+}
+
+// Notification is an event worth telling people about, e.g. a failed rollout
+type Notification struct {
+	// Key identifies the event for deduplication, e.g. "rollout-failed/<namespace>/<name>/<version>"
+	Key         string    `json:"key"`
+	Event       string    `json:"event,omitempty"`
+	Severity    string    `json:"severity"`
+	Environment string    `json:"environment,omitempty"`
+	Namespace   string    `json:"namespace,omitempty"`
+	Rollout     string    `json:"rollout,omitempty"`
+	Title       string    `json:"title"`
+	Message     string    `json:"message,omitempty"`
+	Time        time.Time `json:"time"`
+	// Routes names routes that receive the notification even when their match does not select it
+	// or an earlier route stopped the evaluation, e.g. the routes a rollout asks for
+	Routes []string `json:"-"`
+}
+
+// Payload is the JSON body posted to webhooks. Text repeats title and message so chat webhooks
+// that only understand a text field (Slack, Mattermost, ...) can be used directly.
+type Payload struct {
+	Notification
+	Route string `json:"route"`
+	Text  string `json:"text"`
+}
+
+// Notifier routes notifications to webhooks according to Config
+type Notifier struct {
+	now  func() time.Time
+	post func(url, format string, payload Payload) error
+
+	cfgMu       sync.RWMutex
+	cfg         Config
+	dedupWindow time.Duration
+
+	mu   sync.Mutex
+	sent map[string]time.Time
+}
+
+// New creates a notifier for a validated configuration
+func New(cfg Config) *Notifier {
+	n := &Notifier{
+		now:  time.Now,
+		post: postWebhook,
+		sent: map[string]time.Time{},
+	}
+	n.SetConfig(cfg)
+	return n
+}
+
+// SetConfig replaces the routing rules with a validated configuration, e.g. after webhook URLs
+// were rotated. Notifications already delivered stay deduplicated.
+func (n *Notifier) SetConfig(cfg Config) {
+	dedupWindow := defaultDedupWindow
+	if cfg.DedupWindow != nil {
+		dedupWindow = cfg.DedupWindow.Duration
+	}
+	n.cfgMu.Lock()
+	defer n.cfgMu.Unlock()
+	n.cfg, n.dedupWindow = cfg, dedupWindow
+}
+
+// Config returns the current routing rules
+func (n *Notifier) Config() Config {
+	n.cfgMu.RLock()
+	defer n.cfgMu.RUnlock()
+	return n.cfg
+}
+
+// Notify delivers a notification to every matching route and every route it names that is not in
+// its quiet hours and has not delivered the same key within the dedup window. Webhooks are called
+// in the background. It returns the names of the routes the notification was sent to.
+func (n *Notifier) Notify(notification Notification) []string {
+	now := n.now()
+	if notification.Time.IsZero() {
+		notification.Time = now
+	}
+	logger := slog.With("key", notification.Key, "event", notification.Event, "severity", notification.Severity,
+		"environment", notification.Environment, "namespace", notification.Namespace)
+
+	n.cfgMu.RLock()
+	cfg, dedupWindow := n.cfg, n.dedupWindow
+	n.cfgMu.RUnlock()
+
+	var routes []string
+	stopped := false
+	for _, route := range cfg.Routes {
+		named := slices.Contains(notification.Routes, route.Name)
+		matched := !stopped && route.Match.Matches(notification)
+		if !named && !matched {
+			continue
+		}
+		switch {
+		case route.QuietHours != nil && route.QuietHours.Contains(now):
+			logger.Debug("Notification suppressed by quiet hours", "route", route.Name)
+		case !n.claim(route.Name+"\x00"+notification.Key, now, dedupWindow):
+			logger.Debug("Duplicate notification suppressed", "route", route.Name)
+		default:
+			routes = append(routes, route.Name)
+			payload := Payload{Notification: notification, Route: route.Name, Text: notification.Title}
+			if notification.Message != "" {
+				payload.Text += ": " + notification.Message
+			}
+			for _, url := range route.Webhooks {
+				go func() {
+					if err := n.post(url, route.Format, payload); err != nil {
+						logger.Error("Failed to send notification", "route", route.Name, "error", err)
+					}
+				}()
+			}
+		}
+		if matched && !route.Continue {
+			stopped = true
+		}
+	}
+
+	logger.Info(notification.Title, "routes", routes)
+	return routes
+}
+
+// claim records a delivery and reports false when the key was delivered within the dedup window
+func (n *Notifier) claim(key string, now time.Time, dedupWindow time.Duration) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for k, sent := range n.sent {
+		if now.Sub(sent) >= dedupWindow {
+			delete(n.sent, k)
+		}
+	}
+	if _, ok := n.sent[key]; ok {
+		return false
+	}
+	n.sent[key] = now
+	return true
+}
+
+// WebhookResult is the outcome of posting a test notification to a webhook
+type WebhookResult struct {
+	Route string
+	// Webhook is the webhook URL redacted with RedactURL
+	Webhook string
+	Err     error
+}
+
+// Test posts a test notification to every webhook of every route, regardless of matches, quiet
+// hours and deduplication, and waits for the responses. Results are in route and webhook order.
+func (n *Notifier) Test() []WebhookResult {
+	notification := Notification{
+		Key:      "test",
+		Severity: SeverityInfo,
+		Title:    "Test notification from the rollout dashboard",
+		Time:     n.now(),
+	}
+
+	var results []WebhookResult
+	var urls, formats []string
+	for _, route := range n.Config().Routes {
+		for _, url := range route.Webhooks {
+			results = append(results, WebhookResult{Route: route.Name, Webhook: RedactURL(url)})
+			urls = append(urls, url)
+			formats = append(formats, route.Format)
+		}
+	}
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			payload := Payload{Notification: notification, Route: results[i].Route, Text: notification.Title}
+			results[i].Err = n.post(urls[i], formats[i], payload)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// RedactURL strips user info, path and query from a webhook URL, as they usually hold its secret
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "(invalid URL)"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// encodePayload returns the body posted to a webhook of the given format
+func encodePayload(format string, payload Payload) ([]byte, error) {
+	color := severityColors[payload.Severity]
+	switch format {
+	case FormatSlack:
+		type attachment struct {
+			Color  string `json:"color,omitempty"`
+			Title  string `json:"title"`
+			Text   string `json:"text,omitempty"`
+			Footer string `json:"footer,omitempty"`
+			Ts     int64  `json:"ts"`
+		}
+		return json.Marshal(struct {
+			Text        string       `json:"text"`
+			Attachments []attachment `json:"attachments"`
+		}{
+			Text: payload.Text,
+			Attachments: []attachment{{
+				Color: "#" + color, Title: payload.Title, Text: payload.Message, Footer: payload.Route, Ts: payload.Time.Unix(),
+			}},
+		})
+	case FormatTeams:
+		return json.Marshal(map[string]string{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    payload.Text,
+			"themeColor": color,
+			"title":      payload.Title,
+			"text":       payload.Message,
+		})
+	}
+	return json.Marshal(payload)
+}
+
+func postWebhook(url, format string, payload Payload) error {
+	body, err := encodePayload(format, payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post notification: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
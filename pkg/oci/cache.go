@@ -0,0 +1,141 @@
+package oci
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+)
+
+const (
+	// defaultManifestCacheSize is the number of digest-addressed manifests kept in memory
+	defaultManifestCacheSize = 1024
+	// defaultTagTTL is how long a tag→digest resolution is trusted before asking the registry again
+	defaultTagTTL = 5 * time.Minute
+)
+
+// manifestCache is shared by all manifest-based lookups (annotations, artifact type)
+var manifestCache = NewManifestCache(defaultManifestCacheSize, defaultTagTTL)
+
+// ManifestCache caches manifests by digest and tag→digest resolutions with a TTL.
+// Manifests addressed by digest are immutable, so they are kept until evicted by the LRU;
+// only the (mutable) tag mapping needs to be refreshed.
+type ManifestCache struct {
+	mu        sync.Mutex
+	tagTTL    time.Duration
+	tags      map[string]tagResolution
+	manifests *lru
+
+	// resolveDigest and fetchManifest talk to the registry; overridable in tests
+	resolveDigest func(ref string, opts ...crane.Option) (string, error)
+	fetchManifest func(ref string, opts ...crane.Option) ([]byte, error)
+}
+
+type tagResolution struct {
+	digest    string
+	expiresAt time.Time
+}
+
+// NewManifestCache creates a cache holding up to size manifests and trusting tag resolutions for tagTTL
+func NewManifestCache(size int, tagTTL time.Duration) *ManifestCache {
+	return &ManifestCache{
+		tagTTL:        tagTTL,
+		tags:          make(map[string]tagResolution),
+		manifests:     newLRU(size),
+		resolveDigest: crane.Digest,
+		fetchManifest: crane.Manifest,
+	}
+}
+
+// Manifest returns the raw manifest for image:version, where version is a tag or a digest
+func (m *ManifestCache) Manifest(image, version string, opts ...crane.Option) ([]byte, error) {
+	digest, err := m.Digest(image, version, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	key := image + "@" + digest
+	m.mu.Lock()
+	manifest, ok := m.manifests.get(key)
+	m.mu.Unlock()
+	if ok {
+		return manifest, nil
+	}
+
+	manifest, err = m.fetchManifest(key, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch manifest: %w", err)
+	}
+
+	m.mu.Lock()
+	m.manifests.add(key, manifest)
+	m.mu.Unlock()
+	return manifest, nil
+}
+
+// Digest resolves image:version to a digest, using the cached resolution while it is fresh
+func (m *ManifestCache) Digest(image, version string, opts ...crane.Option) (string, error) {
+	if strings.HasPrefix(version, "sha256:") {
+		return version, nil
+	}
+
+	ref := fmt.Sprintf("%s:%s", image, version)
+	m.mu.Lock()
+	resolution, ok := m.tags[ref]
+	m.mu.Unlock()
+	if ok && time.Now().Before(resolution.expiresAt) {
+		return resolution.digest, nil
+	}
+
+	digest, err := m.resolveDigest(ref, opts...)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve digest: %w", err)
+	}
+
+	m.mu.Lock()
+	m.tags[ref] = tagResolution{digest: digest, expiresAt: time.Now().Add(m.tagTTL)}
+	m.mu.Unlock()
+	return digest, nil
+}
+
+// lru is a minimal least-recently-used cache of byte slices; callers synchronize access
+type lru struct {
+	size  int
+	order *list.List
+	items map[string]*list.Element
+}
+
+type lruEntry struct {
+	key   string
+	value []byte
+}
+
+func newLRU(size int) *lru {
+	return &lru{size: size, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (l *lru) get(key string) ([]byte, bool) {
+	el, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	l.order.MoveToFront(el)
+	return el.Value.(*lruEntry).value, true
+}
+
+func (l *lru) add(key string, value []byte) {
+	if el, ok := l.items[key]; ok {
+		el.Value.(*lruEntry).value = value
+		l.order.MoveToFront(el)
+		return
+	}
+	l.items[key] = l.order.PushFront(&lruEntry{key: key, value: value})
+	for l.order.Len() > l.size {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.items, oldest.Value.(*lruEntry).key)
+	}
+}
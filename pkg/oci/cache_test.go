@@ -0,0 +1,71 @@
+package oci
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestManifestCache(size int, ttl time.Duration) (*ManifestCache, *int, *int) {
+	resolves, fetches := 0, 0
+	cache := NewManifestCache(size, ttl)
+	cache.resolveDigest = func(ref string, opts ...crane.Option) (string, error) {
+		resolves++
+		return "sha256:" + ref[len(ref)-1:], nil
+	}
+	cache.fetchManifest = func(ref string, opts ...crane.Option) ([]byte, error) {
+		fetches++
+		return []byte(ref), nil
+	}
+	return cache, &resolves, &fetches
+}
+
+func TestManifestCacheReusesDigestAndManifest(t *testing.T) {
+	cache, resolves, fetches := newTestManifestCache(10, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		manifest, err := cache.Manifest("registry/app", "v1")
+		require.NoError(t, err)
+		require.Equal(t, "registry/app@sha256:1", string(manifest))
+	}
+
+	require.Equal(t, 1, *resolves)
+	require.Equal(t, 1, *fetches)
+}
+
+func TestManifestCacheReResolvesExpiredTags(t *testing.T) {
+	cache, resolves, fetches := newTestManifestCache(10, -time.Second)
+
+	_, err := cache.Manifest("registry/app", "v1")
+	require.NoError(t, err)
+	_, err = cache.Manifest("registry/app", "v1")
+	require.NoError(t, err)
+
+	require.Equal(t, 2, *resolves, "expired tag mapping must be resolved again")
+	require.Equal(t, 1, *fetches, "manifest for an unchanged digest must come from cache")
+}
+
+func TestManifestCacheSkipsResolutionForDigests(t *testing.T) {
+	cache, resolves, _ := newTestManifestCache(10, time.Minute)
+
+	_, err := cache.Manifest("registry/app", "sha256:abc")
+	require.NoError(t, err)
+	require.Equal(t, 0, *resolves)
+}
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	l := newLRU(2)
+	l.add("a", []byte("1"))
+	l.add("b", []byte("2"))
+	_, _ = l.get("a")
+	l.add("c", []byte("3"))
+
+	_, ok := l.get("b")
+	require.False(t, ok)
+	_, ok = l.get("a")
+	require.True(t, ok)
+	_, ok = l.get("c")
+	require.True(t, ok)
+}
@@ -0,0 +1,83 @@
+package oci
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/blang/semver/v4"
+)
+
+// Standard OCI annotations a changelog entry is built from
+const (
+	RevisionAnnotation    = "org.opencontainers.image.revision"
+	DescriptionAnnotation = "org.opencontainers.image.description"
+	TitleAnnotation       = "org.opencontainers.image.title"
+	SourceAnnotation      = "org.opencontainers.image.source"
+	CreatedAnnotation     = "org.opencontainers.image.created"
+)
+
+// ChangelogEntry is a release between two versions as described by its OCI annotations
+type ChangelogEntry struct {
+	Version     string     `json:"version"`
+	Revision    string     `json:"revision,omitempty"`
+	Title       string     `json:"title,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Source      string     `json:"source,omitempty"`
+	Created     *time.Time `json:"created,omitempty"`
+	// Error is set when the release's annotations could not be read
+	Error string `json:"error,omitempty"`
+}
+
+// NewChangelogEntry describes a release by its manifest annotations
+func NewChangelogEntry(version string, annotations map[string]string) ChangelogEntry {
+	entry := ChangelogEntry{
+		Version:     version,
+		Revision:    annotations[RevisionAnnotation],
+		Title:       annotations[TitleAnnotation],
+		Description: annotations[DescriptionAnnotation],
+		Source:      annotations[SourceAnnotation],
+	}
+	if created, err := time.Parse(time.RFC3339, annotations[CreatedAnnotation]); err == nil {
+		entry.Created = &created
+	}
+	return entry
+}
+
+// VersionsBetween returns the tags that are semantic versions after from up to and including to,
+// newest first. Tags that are not semantic versions, such as channel tags, are left out; from and
+// to must be semantic versions, with or without a leading "v".
+func VersionsBetween(tags []string, from, to string) ([]string, error) {
+	lower, err := semver.ParseTolerant(from)
+	if err != nil {
+		return nil, fmt.Errorf("from %q is not a semantic version: %w", from, err)
+	}
+	upper, err := semver.ParseTolerant(to)
+	if err != nil {
+		return nil, fmt.Errorf("to %q is not a semantic version: %w", to, err)
+	}
+	if upper.LT(lower) {
+		return nil, fmt.Errorf("to %s is older than from %s", to, from)
+	}
+
+	type tagVersion struct {
+		tag     string
+		version semver.Version
+	}
+	var between []tagVersion
+	for _, tag := range tags {
+		version, err := semver.ParseTolerant(tag)
+		if err != nil || version.LTE(lower) || version.GT(upper) {
+			continue
+		}
+		between = append(between, tagVersion{tag: tag, version: version})
+	}
+	sort.SliceStable(between, func(i, j int) bool {
+		return between[i].version.GT(between[j].version)
+	})
+	versions := make([]string, 0, len(between))
+	for _, tag := range between {
+		versions = append(versions, tag.tag)
+	}
+	return versions, nil
+}
@@ -0,0 +1,37 @@
+package oci
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionsBetween(t *testing.T) {
+	tags := []string{"latest", "v1.10.0", "v1.2.0", "v1.9.0", "1.3.0", "v1.3.0-rc.1", "v2.0.0", "stable", "v1.1.0"}
+
+	versions, err := VersionsBetween(tags, "v1.2.0", "v1.10.0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v1.10.0", "v1.9.0", "1.3.0", "v1.3.0-rc.1"}, versions)
+
+	versions, err = VersionsBetween(tags, "v1.10.0", "v1.10.0")
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+
+	for _, bounds := range [][2]string{{"latest", "v2.0.0"}, {"v1.0.0", "stable"}, {"v2.0.0", "v1.0.0"}} {
+		_, err := VersionsBetween(tags, bounds[0], bounds[1])
+		assert.Error(t, err, bounds)
+	}
+}
+
+func TestNewChangelogEntry(t *testing.T) {
+	entry := NewChangelogEntry("v1.3.0", map[string]string{
+		RevisionAnnotation:    "main@sha1:4f2a9c1",
+		DescriptionAnnotation: "Faster checkout",
+		CreatedAnnotation:     "2026-10-01T12:00:00Z",
+	})
+	created := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, ChangelogEntry{Version: "v1.3.0", Revision: "main@sha1:4f2a9c1", Description: "Faster checkout", Created: &created}, entry)
+	assert.Equal(t, ChangelogEntry{Version: "v1.4.0"}, NewChangelogEntry("v1.4.0", nil))
+}
@@ -0,0 +1,158 @@
+package oci
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// maxFileDiffSize bounds the unified diff returned for a single file
+const maxFileDiffSize = 256 << 10
+
+// Statuses of a file between two versions of an artifact
+const (
+	FileAdded    = "added"
+	FileRemoved  = "removed"
+	FileModified = "modified"
+)
+
+// Component is a logical app of a monorepo artifact, made of the files below Path
+type Component struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// FileDiff is a file that changed between two versions of an artifact
+type FileDiff struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// Diff is the unified diff of the file, empty for files that are not text
+	Diff      string `json:"diff,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// ParseComponents parses a comma or newline separated list of name=path pairs, e.g.
+// "api=apps/api,worker=apps/worker". Paths are directories inside the artifact.
+func ParseComponents(value string) ([]Component, error) {
+	var components []Component
+	seen := map[string]bool{}
+	for _, entry := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '\n' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, dir, ok := strings.Cut(entry, "=")
+		name, dir = strings.TrimSpace(name), cleanPath(dir)
+		if !ok || name == "" || dir == "" {
+			return nil, fmt.Errorf("invalid component %q: expected name=path", entry)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("component %q is defined more than once", name)
+		}
+		seen[name] = true
+		components = append(components, Component{Name: name, Path: dir})
+	}
+	return components, nil
+}
+
+// ComponentOf returns the name of the component a file belongs to, the one with the longest
+// matching path, or empty string when the file is outside all components
+func ComponentOf(components []Component, name string) string {
+	name = cleanPath(name)
+	var match Component
+	for _, component := range components {
+		if (name == component.Path || strings.HasPrefix(name, component.Path+"/")) && len(component.Path) > len(match.Path) {
+			match = component
+		}
+	}
+	return match.Name
+}
+
+// SplitComponents groups files by the component they belong to. Files outside all components are
+// grouped under the empty name.
+func SplitComponents(files []File, components []Component) map[string][]File {
+	split := map[string][]File{}
+	for _, file := range files {
+		component := ComponentOf(components, file.Name)
+		split[component] = append(split[component], file)
+	}
+	return split
+}
+
+// DiffFiles returns the files added, removed or modified from one version to the other, sorted by
+// name
+func DiffFiles(from, to []File) []FileDiff {
+	before := map[string][]byte{}
+	for _, file := range from {
+		before[cleanPath(file.Name)] = file.Content
+	}
+	after := map[string][]byte{}
+	for _, file := range to {
+		after[cleanPath(file.Name)] = file.Content
+	}
+
+	diffs := []FileDiff{}
+	for name, content := range after {
+		previous, existed := before[name]
+		switch {
+		case !existed:
+			diffs = append(diffs, unifiedDiff(name, FileAdded, nil, content))
+		case !bytes.Equal(previous, content):
+			diffs = append(diffs, unifiedDiff(name, FileModified, previous, content))
+		}
+	}
+	for name, content := range before {
+		if _, exists := after[name]; !exists {
+			diffs = append(diffs, unifiedDiff(name, FileRemoved, content, nil))
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Name < diffs[j].Name })
+	return diffs
+}
+
+func unifiedDiff(name, status string, from, to []byte) FileDiff {
+	diff := FileDiff{Name: name, Status: status}
+	if bytes.IndexByte(from, 0) >= 0 || bytes.IndexByte(to, 0) >= 0 {
+		return diff
+	}
+	text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        lines(from),
+		B:        lines(to),
+		FromFile: "a/" + name,
+		ToFile:   "b/" + name,
+		Context:  3,
+	})
+	if err != nil {
+		return diff
+	}
+	if len(text) > maxFileDiffSize {
+		text, diff.Truncated = text[:maxFileDiffSize], true
+	}
+	diff.Diff = text
+	return diff
+}
+
+// lines splits content into lines ending in a line break, as difflib expects. Unlike
+// difflib.SplitLines, no empty line is added after a final line break.
+func lines(content []byte) []string {
+	split := strings.SplitAfter(string(content), "\n")
+	if last := split[len(split)-1]; last == "" {
+		split = split[:len(split)-1]
+	} else {
+		split[len(split)-1] = last + "\n"
+	}
+	return split
+}
+
+// cleanPath normalizes a path inside an artifact: relative, without ./ or trailing slashes
+func cleanPath(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ""
+	}
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
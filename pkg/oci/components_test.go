@@ -0,0 +1,57 @@
+package oci
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseComponents(t *testing.T) {
+	components, err := ParseComponents("api=./apps/api/, worker=apps/worker\napi-v2=apps/api/v2")
+	require.NoError(t, err)
+	assert.Equal(t, []Component{{Name: "api", Path: "apps/api"}, {Name: "worker", Path: "apps/worker"}, {Name: "api-v2", Path: "apps/api/v2"}}, components)
+
+	components, err = ParseComponents("")
+	require.NoError(t, err)
+	assert.Empty(t, components)
+
+	for _, value := range []string{"api", "api=", "=apps/api", "api=apps/api,api=apps/v2"} {
+		_, err := ParseComponents(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestComponentOf(t *testing.T) {
+	components := []Component{{Name: "api", Path: "apps/api"}, {Name: "api-v2", Path: "apps/api/v2"}}
+	assert.Equal(t, "api", ComponentOf(components, "apps/api/deployment.yaml"))
+	assert.Equal(t, "api-v2", ComponentOf(components, "./apps/api/v2/deployment.yaml"))
+	// A shared prefix is not a parent directory
+	assert.Equal(t, "", ComponentOf(components, "apps/api-gateway/deployment.yaml"))
+	assert.Equal(t, "", ComponentOf(components, "kustomization.yaml"))
+}
+
+func TestDiffFiles(t *testing.T) {
+	from := []File{
+		{Name: "apps/api/deployment.yaml", Content: []byte("replicas: 1\nimage: api:v1\n")},
+		{Name: "apps/api/service.yaml", Content: []byte("port: 80\n")},
+		{Name: "apps/api/old.yaml", Content: []byte("kind: ConfigMap\n")},
+	}
+	to := []File{
+		{Name: "./apps/api/deployment.yaml", Content: []byte("replicas: 1\nimage: api:v2\n")},
+		{Name: "apps/api/service.yaml", Content: []byte("port: 80\n")},
+		{Name: "apps/api/new.yaml", Content: []byte("kind: Secret\n")},
+	}
+
+	diffs := DiffFiles(from, to)
+	require.Len(t, diffs, 3)
+	assert.Equal(t, "apps/api/deployment.yaml", diffs[0].Name)
+	assert.Equal(t, FileModified, diffs[0].Status)
+	assert.Contains(t, diffs[0].Diff, "-image: api:v1\n+image: api:v2\n")
+	assert.Equal(t, FileAdded, diffs[1].Status)
+	assert.True(t, strings.HasSuffix(diffs[1].Diff, "@@\n+kind: Secret\n"), diffs[1].Diff)
+	assert.Equal(t, FileRemoved, diffs[2].Status)
+
+	assert.Empty(t, DiffFiles(from, from))
+}
@@ -74,10 +74,8 @@ func GetImageContents(ctx context.Context, image, version string, opts ...crane.
 
 // GetImageAnnotations returns the annotations for a given image.
 func GetImageAnnotations(ctx context.Context, image, version string, opts ...crane.Option) (map[string]string, error) {
-	ref := fmt.Sprintf("%s:%s", image, version)
-
-	// Get the manifest for the image
-	manifestBytes, err := crane.Manifest(ref, opts...)
+	// Manifests are cached by digest, so repeated lookups of the same version avoid registry reads
+	manifestBytes, err := manifestCache.Manifest(image, version, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get manifest: %w", err)
 	}
@@ -95,9 +93,7 @@ func GetImageAnnotations(ctx context.Context, image, version string, opts ...cra
 // GetArtifactType returns the artifact/media type for the given image:tag by parsing the manifest.
 // Preference order: manifest.artifactType (OCI 1.1 artifacts), then config.mediaType, then manifest.mediaType.
 func GetArtifactType(ctx context.Context, image, version string, opts ...crane.Option) (string, error) {
-	ref := fmt.Sprintf("%s:%s", image, version)
-
-	manifestBytes, err := crane.Manifest(ref, opts...)
+	manifestBytes, err := manifestCache.Manifest(image, version, opts...)
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch manifest: %w", err)
 	}
@@ -132,3 +128,14 @@ func ListRepositoryTags(ctx context.Context, image string, opts ...crane.Option)
 
 	return tags, nil
 }
+
+// ResolveTag returns the current digest of image:tag, bypassing the manifest cache because channel
+// tags are expected to move
+func ResolveTag(ctx context.Context, image, tag string, opts ...crane.Option) (string, error) {
+	opts = append(opts, crane.WithContext(ctx))
+	digest, err := crane.Digest(fmt.Sprintf("%s:%s", image, tag), opts...)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve tag %s: %w", tag, err)
+	}
+	return digest, nil
+}
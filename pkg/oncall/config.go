@@ -0,0 +1,129 @@
+package oncall
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// defaultCacheTTL is used when the configuration does not set cacheTTL
+const defaultCacheTTL = 5 * time.Minute
+
+// Default API endpoints of the schedule providers
+const (
+	defaultOpsGenieURL  = "https://api.opsgenie.com"
+	defaultPagerDutyURL = "https://api.pagerduty.com"
+)
+
+// Config is the directory of teams that own rollouts. It is read from a YAML or JSON file:
+//
+//	cacheTTL: 5m
+//	opsgenie:
+//	  url: https://api.eu.opsgenie.com
+//	teams:
+//	  - name: checkout
+//	    contacts:
+//	      - {name: Checkout, slack: "#checkout", email: checkout@example.com}
+//	    escalationURL: https://wiki.example.com/checkout/escalation
+//	    opsgenieSchedule: checkout_schedule
+//	  - name: payments
+//	    pagerdutySchedule: P1ABCDE
+//
+// The API keys of the schedule providers are not part of the file but read from
+// ONCALL_OPSGENIE_API_KEY and ONCALL_PAGERDUTY_API_KEY.
+type Config struct {
+	// CacheTTL is how long the on-call of a schedule is reused before asking the provider again
+	CacheTTL  *metav1.Duration `json:"cacheTTL,omitempty"`
+	OpsGenie  *Provider        `json:"opsgenie,omitempty"`
+	PagerDuty *Provider        `json:"pagerduty,omitempty"`
+	Teams     []Team           `json:"teams"`
+}
+
+// Provider is the API of an on-call schedule provider
+type Provider struct {
+	// URL overrides the provider's default API endpoint, e.g. for OpsGenie's EU instance
+	URL string `json:"url,omitempty"`
+
+	apiKey string
+}
+
+// Team is a rollout owner, referenced by the rollout.kuberik.com/team annotation. A team may use
+// at most one on-call schedule.
+type Team struct {
+	Name     string    `json:"name"`
+	Contacts []Contact `json:"contacts,omitempty"`
+	// EscalationURL points to the team's escalation policy or runbook
+	EscalationURL     string `json:"escalationURL,omitempty"`
+	OpsGenieSchedule  string `json:"opsgenieSchedule,omitempty"`
+	PagerDutySchedule string `json:"pagerdutySchedule,omitempty"`
+}
+
+// Contact is a way to reach a person or team
+type Contact struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+	Slack string `json:"slack,omitempty"`
+}
+
+// ConfigFromEnv reads the configuration file named by ONCALL_CONFIG and the providers' API keys.
+// Without it the directory is empty and rollouts only show their owner annotations.
+func ConfigFromEnv() (Config, error) {
+	path := os.Getenv("ONCALL_CONFIG")
+	if path == "" {
+		return Config{}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read on-call config: %w", err)
+	}
+	cfg, err := ParseConfig(data)
+	if err != nil {
+		return Config{}, err
+	}
+	if cfg.OpsGenie != nil {
+		cfg.OpsGenie.apiKey = os.Getenv("ONCALL_OPSGENIE_API_KEY")
+	}
+	if cfg.PagerDuty != nil {
+		cfg.PagerDuty.apiKey = os.Getenv("ONCALL_PAGERDUTY_API_KEY")
+	}
+	return cfg, nil
+}
+
+// ParseConfig parses and validates a YAML or JSON configuration. Providers used by a team's
+// schedule are added with their default endpoint when the file does not configure them.
+func ParseConfig(data []byte) (Config, error) {
+	var cfg Config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to parse on-call config: %w", err)
+	}
+	names := map[string]bool{}
+	for i, team := range cfg.Teams {
+		if team.Name == "" {
+			return Config{}, fmt.Errorf("team %d: name is required", i)
+		}
+		if names[team.Name] {
+			return Config{}, fmt.Errorf("team %s: defined more than once", team.Name)
+		}
+		names[team.Name] = true
+		if team.OpsGenieSchedule != "" && team.PagerDutySchedule != "" {
+			return Config{}, fmt.Errorf("team %s: only one of opsgenieSchedule and pagerdutySchedule may be set", team.Name)
+		}
+		if team.OpsGenieSchedule != "" && cfg.OpsGenie == nil {
+			cfg.OpsGenie = &Provider{}
+		}
+		if team.PagerDutySchedule != "" && cfg.PagerDuty == nil {
+			cfg.PagerDuty = &Provider{}
+		}
+	}
+	if cfg.OpsGenie != nil && cfg.OpsGenie.URL == "" {
+		cfg.OpsGenie.URL = defaultOpsGenieURL
+	}
+	if cfg.PagerDuty != nil && cfg.PagerDuty.URL == "" {
+		cfg.PagerDuty.URL = defaultPagerDutyURL
+	}
+	return cfg, nil
+}
@@ -0,0 +1,203 @@
+// Package oncall resolves the teams owning rollouts to their contacts and who is currently on
+// call for them, so a bad deploy can be escalated without searching for the right person
+package oncall
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Schedule providers
+const (
+	SourceOpsGenie  = "opsgenie"
+	SourcePagerDuty = "pagerduty"
+)
+
+// lookupTimeout bounds a request to a schedule provider, so a slow provider does not hold up the
+// rollout detail
+const lookupTimeout = 5 * time.Second
+
+// OnCall is who to contact for a team
+type OnCall struct {
+	Team          string    `json:"team"`
+	Contacts      []Contact `json:"contacts,omitempty"`
+	EscalationURL string    `json:"escalationURL,omitempty"`
+	// OnCall are the people currently on call according to the team's schedule
+	OnCall []Contact `json:"onCall,omitempty"`
+	// Source is the provider of the schedule, empty for teams without one
+	Source string `json:"source,omitempty"`
+	// Until is when the current on-call shift ends, if the provider reports it
+	Until *time.Time `json:"until,omitempty"`
+	// Error is set when the schedule could not be read; the static contacts are still returned
+	Error string `json:"error,omitempty"`
+}
+
+// Directory looks up the on-call of teams. A nil Directory knows no teams.
+type Directory struct {
+	cfg      Config
+	client   *http.Client
+	now      func() time.Time
+	cacheTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	onCall  []Contact
+	until   *time.Time
+	err     error
+	fetched time.Time
+}
+
+// New creates a directory for a validated configuration
+func New(cfg Config) *Directory {
+	cacheTTL := defaultCacheTTL
+	if cfg.CacheTTL != nil {
+		cacheTTL = cfg.CacheTTL.Duration
+	}
+	return &Directory{
+		cfg:      cfg,
+		client:   &http.Client{Timeout: lookupTimeout},
+		now:      time.Now,
+		cacheTTL: cacheTTL,
+		cache:    map[string]cacheEntry{},
+	}
+}
+
+// Lookup returns the contacts of team and who is on call for it, nil if the directory does not
+// know the team. Schedules are read at most once per cache TTL, failures included, so a provider
+// outage does not slow down every request.
+func (d *Directory) Lookup(ctx context.Context, team string) *OnCall {
+	if d == nil {
+		return nil
+	}
+	for _, t := range d.cfg.Teams {
+		if t.Name != team {
+			continue
+		}
+		result := &OnCall{Team: t.Name, Contacts: t.Contacts, EscalationURL: t.EscalationURL}
+		var entry cacheEntry
+		switch {
+		case t.OpsGenieSchedule != "":
+			result.Source = SourceOpsGenie
+			entry = d.cached(SourceOpsGenie+"/"+t.OpsGenieSchedule, func() ([]Contact, *time.Time, error) {
+				return d.opsGenieOnCall(ctx, t.OpsGenieSchedule)
+			})
+		case t.PagerDutySchedule != "":
+			result.Source = SourcePagerDuty
+			entry = d.cached(SourcePagerDuty+"/"+t.PagerDutySchedule, func() ([]Contact, *time.Time, error) {
+				return d.pagerDutyOnCall(ctx, t.PagerDutySchedule)
+			})
+		default:
+			return result
+		}
+		result.OnCall, result.Until = entry.onCall, entry.until
+		if entry.err != nil {
+			result.Error = entry.err.Error()
+		}
+		return result
+	}
+	return nil
+}
+
+func (d *Directory) cached(key string, fetch func() ([]Contact, *time.Time, error)) cacheEntry {
+	d.mu.Lock()
+	entry, ok := d.cache[key]
+	d.mu.Unlock()
+	if ok && d.now().Sub(entry.fetched) < d.cacheTTL {
+		return entry
+	}
+
+	entry = cacheEntry{fetched: d.now()}
+	entry.onCall, entry.until, entry.err = fetch()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.cache[key] = entry
+	return entry
+}
+
+// opsGenieOnCall reads the recipients currently on call for a schedule, identified by name
+func (d *Directory) opsGenieOnCall(ctx context.Context, schedule string) ([]Contact, *time.Time, error) {
+	provider := d.cfg.OpsGenie
+	if provider.apiKey == "" {
+		return nil, nil, errors.New("ONCALL_OPSGENIE_API_KEY is not set")
+	}
+	endpoint := fmt.Sprintf("%s/v2/schedules/%s/on-calls?scheduleIdentifierType=name&flat=true", provider.URL, url.PathEscape(schedule))
+	var response struct {
+		Data struct {
+			OnCallRecipients []string `json:"onCallRecipients"`
+		} `json:"data"`
+	}
+	if err := d.get(ctx, endpoint, "GenieKey "+provider.apiKey, &response); err != nil {
+		return nil, nil, fmt.Errorf("opsgenie schedule %s: %w", schedule, err)
+	}
+	var contacts []Contact
+	for _, recipient := range response.Data.OnCallRecipients {
+		contacts = append(contacts, Contact{Email: recipient})
+	}
+	return contacts, nil, nil
+}
+
+// pagerDutyOnCall reads the first escalation level currently on call for a schedule, identified by
+// ID, and when their shift ends
+func (d *Directory) pagerDutyOnCall(ctx context.Context, schedule string) ([]Contact, *time.Time, error) {
+	provider := d.cfg.PagerDuty
+	if provider.apiKey == "" {
+		return nil, nil, errors.New("ONCALL_PAGERDUTY_API_KEY is not set")
+	}
+	query := url.Values{"schedule_ids[]": {schedule}, "include[]": {"users"}, "earliest": {"true"}}
+	var response struct {
+		OnCalls []struct {
+			User struct {
+				Name  string `json:"name"`
+				Email string `json:"email"`
+			} `json:"user"`
+			EscalationLevel int        `json:"escalation_level"`
+			End             *time.Time `json:"end"`
+		} `json:"oncalls"`
+	}
+	if err := d.get(ctx, provider.URL+"/oncalls?"+query.Encode(), "Token token="+provider.apiKey, &response); err != nil {
+		return nil, nil, fmt.Errorf("pagerduty schedule %s: %w", schedule, err)
+	}
+	var contacts []Contact
+	var until *time.Time
+	for _, onCall := range response.OnCalls {
+		if onCall.EscalationLevel > 1 {
+			continue
+		}
+		contacts = append(contacts, Contact{Name: onCall.User.Name, Email: onCall.User.Email})
+		if onCall.End != nil && (until == nil || onCall.End.Before(*until)) {
+			until = onCall.End
+		}
+	}
+	return contacts, until, nil
+}
+
+func (d *Directory) get(ctx context.Context, endpoint, authorization string, out any) error {
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Accept", "application/json")
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
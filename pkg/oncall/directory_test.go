@@ -0,0 +1,102 @@
+package oncall
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `
+cacheTTL: 1m
+teams:
+  - name: checkout
+    contacts: [{name: Checkout, slack: "#checkout"}]
+    escalationURL: https://wiki.example.com/checkout
+    opsgenieSchedule: checkout schedule
+  - name: payments
+    pagerdutySchedule: P1ABCDE
+  - name: search
+    contacts: [{email: search@example.com}]
+`
+
+func TestDirectory_Lookup(t *testing.T) {
+	var opsGenieCalls atomic.Int32
+	opsGenie := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opsGenieCalls.Add(1)
+		assert.Equal(t, "/v2/schedules/checkout schedule/on-calls", r.URL.Path)
+		assert.Equal(t, "GenieKey og-key", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"data": {"onCallRecipients": ["jane@example.com"]}}`))
+	}))
+	defer opsGenie.Close()
+	pagerDuty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "P1ABCDE", r.URL.Query().Get("schedule_ids[]"))
+		assert.Equal(t, "Token token=pd-key", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"oncalls": [
+			{"user": {"name": "Sam", "email": "sam@example.com"}, "escalation_level": 1, "end": "2026-01-02T09:00:00Z"},
+			{"user": {"name": "Lead", "email": "lead@example.com"}, "escalation_level": 2, "end": "2026-01-01T12:00:00Z"}
+		]}`))
+	}))
+	defer pagerDuty.Close()
+
+	cfg, err := ParseConfig([]byte(testConfig))
+	require.NoError(t, err)
+	cfg.OpsGenie.URL, cfg.OpsGenie.apiKey = opsGenie.URL, "og-key"
+	cfg.PagerDuty.URL, cfg.PagerDuty.apiKey = pagerDuty.URL, "pd-key"
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	directory := New(cfg)
+	directory.now = func() time.Time { return now }
+
+	checkout := directory.Lookup(context.Background(), "checkout")
+	require.NotNil(t, checkout)
+	assert.Equal(t, SourceOpsGenie, checkout.Source)
+	assert.Equal(t, []Contact{{Email: "jane@example.com"}}, checkout.OnCall)
+	assert.Equal(t, "#checkout", checkout.Contacts[0].Slack)
+	assert.Empty(t, checkout.Error)
+
+	// Served from the cache until the TTL passed
+	directory.Lookup(context.Background(), "checkout")
+	assert.Equal(t, int32(1), opsGenieCalls.Load())
+	now = now.Add(time.Minute)
+	directory.Lookup(context.Background(), "checkout")
+	assert.Equal(t, int32(2), opsGenieCalls.Load())
+
+	payments := directory.Lookup(context.Background(), "payments")
+	require.NotNil(t, payments)
+	assert.Equal(t, []Contact{{Name: "Sam", Email: "sam@example.com"}}, payments.OnCall)
+	assert.Equal(t, time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC), *payments.Until)
+
+	search := directory.Lookup(context.Background(), "search")
+	require.NotNil(t, search)
+	assert.Empty(t, search.Source)
+	assert.Nil(t, search.OnCall)
+
+	assert.Nil(t, directory.Lookup(context.Background(), "unknown"))
+	assert.Nil(t, (*Directory)(nil).Lookup(context.Background(), "checkout"))
+}
+
+func TestDirectory_LookupError(t *testing.T) {
+	cfg, err := ParseConfig([]byte(testConfig))
+	require.NoError(t, err)
+	checkout := New(cfg).Lookup(context.Background(), "checkout")
+	require.NotNil(t, checkout)
+	assert.Equal(t, "ONCALL_OPSGENIE_API_KEY is not set", checkout.Error)
+	assert.Len(t, checkout.Contacts, 1, "static contacts are returned when the schedule fails")
+}
+
+func TestParseConfig_Invalid(t *testing.T) {
+	for _, config := range []string{
+		`teams: [{contacts: [{name: x}]}]`,
+		`teams: [{name: a}, {name: a}]`,
+		`teams: [{name: a, opsgenieSchedule: x, pagerdutySchedule: y}]`,
+		`teams: [{name: a, unknown: x}]`,
+	} {
+		_, err := ParseConfig([]byte(config))
+		assert.Error(t, err, config)
+	}
+}
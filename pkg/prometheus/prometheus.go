@@ -0,0 +1,280 @@
+// Package prometheus runs range queries against Prometheus, scoped to the pods of a rollout's
+// workloads, so metrics can be shown next to the rollout's bake status
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+)
+
+// queryTimeout bounds a single request to Prometheus
+const queryTimeout = 30 * time.Second
+
+// MaxPoints caps the samples per series a range query may return, like Prometheus' own limit
+const MaxPoints = 11000
+
+// SelectorPlaceholder is replaced with the label matchers selecting the rollout's pods. Queries
+// must use it, e.g. sum(rate(http_requests_total{$selector}[5m])).
+const SelectorPlaceholder = "$selector"
+
+// IntervalPlaceholder is replaced with the rate interval, a multiple of the query step
+const IntervalPlaceholder = "$interval"
+
+// Presets are the named queries of common health metrics. The HTTP metrics follow the Prometheus
+// client conventions, the container metrics come from cAdvisor and kube-state-metrics.
+var Presets = map[string]string{
+	"error-rate":   `sum(rate(http_requests_total{$selector,code=~"5.."}[$interval])) / sum(rate(http_requests_total{$selector}[$interval]))`,
+	"request-rate": `sum(rate(http_requests_total{$selector}[$interval]))`,
+	"latency-p50":  `histogram_quantile(0.5, sum by (le) (rate(http_request_duration_seconds_bucket{$selector}[$interval])))`,
+	"latency-p99":  `histogram_quantile(0.99, sum by (le) (rate(http_request_duration_seconds_bucket{$selector}[$interval])))`,
+	"cpu":          `sum by (pod) (rate(container_cpu_usage_seconds_total{$selector,container!=""}[$interval]))`,
+	"memory":       `sum by (pod) (container_memory_working_set_bytes{$selector,container!=""})`,
+	"restarts":     `sum by (pod) (increase(kube_pod_container_status_restarts_total{$selector}[$interval]))`,
+}
+
+// Client queries the Prometheus HTTP API
+type Client struct {
+	url string
+	// tokenFile holds a bearer token, re-read on every request so rotated tokens are picked up
+	tokenFile string
+	http      *http.Client
+}
+
+// New creates a client for the Prometheus at url, authenticating with the bearer token in
+// tokenFile unless it is empty
+func New(url, tokenFile string) *Client {
+	return &Client{url: strings.TrimSuffix(url, "/"), tokenFile: tokenFile, http: &http.Client{Timeout: queryTimeout}}
+}
+
+// FromEnv creates a client for PROMETHEUS_URL, nil when it is not set
+func FromEnv() *Client {
+	url := os.Getenv("PROMETHEUS_URL")
+	if url == "" {
+		return nil
+	}
+	return New(url, os.Getenv("PROMETHEUS_BEARER_TOKEN_FILE"))
+}
+
+// URL returns the address of Prometheus
+func (c *Client) URL() string {
+	return c.url
+}
+
+// Sample is a value of a series at a point in time
+type Sample struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// Series is a time series of a range query result. Samples that are not a number (e.g. an error
+// rate without requests) are left out, so they show as gaps.
+type Series struct {
+	Metric  map[string]string `json:"metric"`
+	Samples []Sample          `json:"samples"`
+}
+
+// APIError is an error returned by the Prometheus API. Type bad_data means the query is invalid.
+type APIError struct {
+	Type    string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("prometheus %s: %s", e.Type, e.Message)
+}
+
+// IsBadQuery reports whether Prometheus rejected the query itself rather than failing to run it
+func IsBadQuery(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Type == "bad_data"
+}
+
+// QueryRange evaluates query over [start, end] at the given step
+func (c *Client) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) ([]Series, error) {
+	params := url.Values{
+		"query": {query},
+		"start": {strconv.FormatInt(start.Unix(), 10)},
+		"end":   {strconv.FormatInt(end.Unix(), 10)},
+		"step":  {strconv.FormatFloat(step.Seconds(), 'f', -1, 64)},
+	}
+	var data struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][2]any          `json:"values"`
+		} `json:"result"`
+	}
+	if err := c.get(ctx, "/api/v1/query_range", params, &data); err != nil {
+		return nil, err
+	}
+	if data.ResultType != "matrix" {
+		return nil, fmt.Errorf("unexpected result type %q", data.ResultType)
+	}
+
+	series := make([]Series, 0, len(data.Result))
+	for _, result := range data.Result {
+		s := Series{Metric: result.Metric, Samples: []Sample{}}
+		if s.Metric == nil {
+			s.Metric = map[string]string{}
+		}
+		for _, value := range result.Values {
+			sample, ok := parseSample(value)
+			if ok {
+				s.Samples = append(s.Samples, sample)
+			}
+		}
+		series = append(series, s)
+	}
+	return series, nil
+}
+
+// BuildInfo returns the version of Prometheus, checking it is reachable with the configured token
+func (c *Client) BuildInfo(ctx context.Context) (string, error) {
+	var data struct {
+		Version string `json:"version"`
+	}
+	if err := c.get(ctx, "/api/v1/status/buildinfo", nil, &data); err != nil {
+		return "", err
+	}
+	return data.Version, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, params url.Values, data any) error {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	endpoint := c.url + path
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if c.tokenFile != "" {
+		token, err := os.ReadFile(c.tokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read bearer token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Errors of the query API come with a JSON body, others (e.g. from a proxy) may not
+	var body struct {
+		Status    string          `json:"status"`
+		Data      json.RawMessage `json:"data"`
+		ErrorType string          `json:"errorType"`
+		Error     string          `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if body.Status != "success" {
+		return &APIError{Type: body.ErrorType, Message: body.Error}
+	}
+	return json.Unmarshal(body.Data, data)
+}
+
+// parseSample parses a [unix seconds, "value"] pair, skipping NaN and infinite values
+func parseSample(value [2]any) (Sample, bool) {
+	seconds, ok := value[0].(float64)
+	if !ok {
+		return Sample{}, false
+	}
+	raw, ok := value[1].(string)
+	if !ok {
+		return Sample{}, false
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return Sample{}, false
+	}
+	whole, frac := math.Modf(seconds)
+	return Sample{Time: time.Unix(int64(whole), int64(frac*1e9)).UTC(), Value: v}, true
+}
+
+// Selector returns the label matchers selecting the pods of the workloads by namespace and pod
+// name
+func Selector(workloads []kubernetes.Workload) string {
+	var namespaces []string
+	names := map[string][]string{}
+	for _, workload := range workloads {
+		namespaces = append(namespaces, regexp.QuoteMeta(workload.Namespace))
+		names[workload.Kind] = append(names[workload.Kind], regexp.QuoteMeta(workload.Name))
+	}
+	slices.Sort(namespaces)
+
+	var pods []string
+	for _, kind := range slices.Sorted(maps.Keys(names)) {
+		pattern := kubernetes.PodNameSuffix(kind)
+		if pattern == "" {
+			continue
+		}
+		kindNames := slices.Compact(slices.Sorted(slices.Values(names[kind])))
+		pods = append(pods, "("+strings.Join(kindNames, "|")+")"+pattern)
+	}
+	return fmt.Sprintf(`namespace=~"%s",pod=~"%s"`,
+		escapeLabelValue(strings.Join(slices.Compact(namespaces), "|")), escapeLabelValue(strings.Join(pods, "|")))
+}
+
+// RevisionSelectors returns the label matchers selecting the pods of a Deployment's ReplicaSet with
+// podTemplateHash, e.g. the canary of a rollout, and those of its other ReplicaSets
+func RevisionSelectors(namespace, deployment, podTemplateHash string) (revision, others string) {
+	namespaceMatcher := fmt.Sprintf(`namespace="%s"`, escapeLabelValue(namespace))
+	name := escapeLabelValue(regexp.QuoteMeta(deployment))
+	hash := escapeLabelValue(regexp.QuoteMeta(podTemplateHash))
+	revision = fmt.Sprintf(`%s,pod=~"%s-%s-[a-z0-9]{5}"`, namespaceMatcher, name, hash)
+	others = fmt.Sprintf(`%s,pod=~"%s%s",pod!~"%s-%s-[a-z0-9]{5}"`, namespaceMatcher, name, kubernetes.PodNameSuffix("Deployment"), name, hash)
+	return revision, others
+}
+
+// Render replaces the placeholders of query. It fails for queries that do not select the
+// rollout's pods with SelectorPlaceholder.
+func Render(query, selector string, interval time.Duration) (string, error) {
+	if !strings.Contains(query, SelectorPlaceholder) {
+		return "", fmt.Errorf("query must select the rollout's pods with %s", SelectorPlaceholder)
+	}
+	query = strings.ReplaceAll(query, SelectorPlaceholder, selector)
+	return strings.ReplaceAll(query, IntervalPlaceholder, strconv.FormatInt(int64(interval.Seconds()), 10)+"s"), nil
+}
+
+// Step picks the resolution of a range query: about 250 points, at least 15 seconds apart
+func Step(start, end time.Time) time.Duration {
+	step := end.Sub(start) / 250
+	if step < 15*time.Second {
+		step = 15 * time.Second
+	}
+	return step.Round(time.Second)
+}
+
+// RateInterval is the interval of rate() for a step. It covers several scrapes so rates are
+// defined even for short steps.
+func RateInterval(step time.Duration) time.Duration {
+	return max(4*step, time.Minute)
+}
+
+// escapeLabelValue escapes a regular expression for a double-quoted PromQL string
+func escapeLabelValue(value string) string {
+	return strings.ReplaceAll(value, `\`, `\\`)
+}
@@ -0,0 +1,90 @@
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/kuberik/rollout-dashboard/pkg/kubernetes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelector(t *testing.T) {
+	selector := Selector([]kubernetes.Workload{
+		{Kind: "Deployment", Namespace: "shop", Name: "web"},
+		{Kind: "Deployment", Namespace: "shop", Name: "api.v2"},
+		{Kind: "StatefulSet", Namespace: "shop", Name: "db"},
+		{Kind: "CronJob", Namespace: "batch", Name: "report"},
+	})
+	assert.Equal(t, `namespace=~"batch|shop",pod=~"(api\\.v2|web)-[a-z0-9]+-[a-z0-9]{5}|(db)-[0-9]+"`, selector)
+
+	pods := regexp.MustCompile(`^(?:(api\.v2|web)-[a-z0-9]+-[a-z0-9]{5}|(db)-[0-9]+)$`)
+	assert.True(t, pods.MatchString("web-5d9f8b7c4-x2x7q"))
+	assert.True(t, pods.MatchString("db-0"))
+	assert.False(t, pods.MatchString("webhook-5d9f8b7c4-x2x7q"))
+}
+
+func TestRevisionSelectors(t *testing.T) {
+	canary, stable := RevisionSelectors("shop", "web.v2", "5d9f8b7c4")
+	assert.Equal(t, `namespace="shop",pod=~"web\\.v2-5d9f8b7c4-[a-z0-9]{5}"`, canary)
+	assert.Equal(t, `namespace="shop",pod=~"web\\.v2-[a-z0-9]+-[a-z0-9]{5}",pod!~"web\\.v2-5d9f8b7c4-[a-z0-9]{5}"`, stable)
+}
+
+func TestRender(t *testing.T) {
+	query, err := Render(Presets["error-rate"], `namespace=~"shop"`, 2*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, `sum(rate(http_requests_total{namespace=~"shop",code=~"5.."}[120s])) / sum(rate(http_requests_total{namespace=~"shop"}[120s]))`, query)
+
+	_, err = Render(`sum(rate(http_requests_total[5m]))`, `namespace=~"shop"`, time.Minute)
+	assert.Error(t, err)
+}
+
+func TestStep(t *testing.T) {
+	start := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	assert.Equal(t, 15*time.Second, Step(start, start.Add(time.Hour)))
+	assert.Equal(t, 346*time.Second, Step(start, start.Add(24*time.Hour)))
+	assert.Equal(t, time.Minute, RateInterval(15*time.Second))
+	assert.Equal(t, 20*time.Minute, RateInterval(5*time.Minute))
+}
+
+func TestQueryRange(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("secret\n"), 0o600))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		if r.URL.Query().Get("query") == "bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`))
+			return
+		}
+		assert.Equal(t, "/api/v1/query_range", r.URL.Path)
+		assert.Equal(t, "15", r.URL.Query().Get("step"))
+		w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[
+			{"metric":{"pod":"web-5d9f8b7c4-x2x7q"},"values":[[1767366000,"0.5"],[1767366015,"NaN"],[1767366030.5,"1"]]}
+		]}}`))
+	}))
+	defer srv.Close()
+	c := New(srv.URL+"/", tokenFile)
+	assert.Equal(t, srv.URL, c.URL())
+
+	end := time.Unix(1767366030, 0)
+	series, err := c.QueryRange(context.Background(), "up", end.Add(-time.Minute), end, 15*time.Second)
+	require.NoError(t, err)
+	require.Len(t, series, 1)
+	assert.Equal(t, map[string]string{"pod": "web-5d9f8b7c4-x2x7q"}, series[0].Metric)
+	// The NaN sample is left out
+	assert.Equal(t, []Sample{
+		{Time: time.Unix(1767366000, 0).UTC(), Value: 0.5},
+		{Time: time.Unix(1767366030, 5e8).UTC(), Value: 1},
+	}, series[0].Samples)
+
+	_, err = c.QueryRange(context.Background(), "bad", end.Add(-time.Minute), end, 15*time.Second)
+	assert.True(t, IsBadQuery(err))
+}
@@ -0,0 +1,215 @@
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuberik/rollout-dashboard/pkg/api"
+	"golang.org/x/time/rate"
+)
+
+// Config holds the per-client limits. A zero value disables the corresponding limit.
+type Config struct {
+	// MutationsPerMinute is the sustained rate of mutating API requests per client
+	MutationsPerMinute int
+	// MutationBurst is how many mutating requests a client may send at once
+	MutationBurst int
+	// MaxStreamsPerClient caps concurrent streaming (SSE) connections per client
+	MaxStreamsPerClient int
+}
+
+// idleTTL is how long per-client state is kept after its last request
+const idleTTL = 10 * time.Minute
+
+// ConfigFromEnv reads the limits from the environment:
+//   - RATE_LIMIT_MUTATIONS_PER_MINUTE: mutating requests per minute per client (default 60, 0 disables)
+//   - RATE_LIMIT_MUTATION_BURST: burst of mutating requests (default 10)
+//   - MAX_STREAMS_PER_CLIENT: concurrent log and event streams per client (default 10, 0 disables)
+func ConfigFromEnv() Config {
+	return Config{
+		MutationsPerMinute:  envInt("RATE_LIMIT_MUTATIONS_PER_MINUTE", 60),
+		MutationBurst:       envInt("RATE_LIMIT_MUTATION_BURST", 10),
+		MaxStreamsPerClient: envInt("MAX_STREAMS_PER_CLIENT", 10),
+	}
+}
+
+// Limiter enforces Config per client. Clients are identified by keyFunc, falling back to the
+// client IP when it returns an empty string. Keys from keyFunc must not start with "ip:".
+type Limiter struct {
+	cfg     Config
+	keyFunc func(c *gin.Context) string
+	now     func() time.Time
+
+	mu        sync.Mutex
+	clients   map[string]*clientState
+	lastSweep time.Time
+}
+
+type clientState struct {
+	mutations *rate.Limiter
+	streams   int
+	lastSeen  time.Time
+}
+
+// New creates a limiter
+func New(cfg Config, keyFunc func(c *gin.Context) string) *Limiter {
+	return &Limiter{
+		cfg:     cfg,
+		keyFunc: keyFunc,
+		now:     time.Now,
+		clients: map[string]*clientState{},
+	}
+}
+
+// Mutations rate-limits mutating API requests (anything but GET, HEAD and OPTIONS below /api/)
+func (l *Limiter) Mutations() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if l.cfg.MutationsPerMinute <= 0 || !isMutation(c.Request) {
+			c.Next()
+			return
+		}
+
+		if !l.chargeMutations(c, 1) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// ChargeMutations counts n more mutating requests for a request acting on several objects, e.g.
+// a batch, on top of the request itself. It responds with 429 and returns false when the client
+// has not enough requests left; more than the burst at once are always refused.
+func (l *Limiter) ChargeMutations(c *gin.Context, n int) bool {
+	if l.cfg.MutationsPerMinute <= 0 || n <= 0 {
+		return true
+	}
+	// The request itself has already been counted by Mutations
+	if n+1 > l.burst() {
+		api.RespondErrorDetails(c, http.StatusTooManyRequests, api.CodeRateLimited, "Too many requests",
+			"at most "+strconv.Itoa(l.burst())+" mutating requests are allowed at once")
+		return false
+	}
+	return l.chargeMutations(c, n)
+}
+
+func (l *Limiter) chargeMutations(c *gin.Context, n int) bool {
+	if delay := l.reserveMutations(l.key(c), n); delay > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+		api.RespondErrorDetails(c, http.StatusTooManyRequests, api.CodeRateLimited, "Too many requests",
+			"mutating requests are limited to "+strconv.Itoa(l.cfg.MutationsPerMinute)+" per minute")
+		return false
+	}
+	return true
+}
+
+// Streams caps concurrent streaming connections per client. It must be added to the streaming
+// routes only, as the slot is held until the handler returns.
+func (l *Limiter) Streams() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if l.cfg.MaxStreamsPerClient <= 0 {
+			c.Next()
+			return
+		}
+
+		key := l.key(c)
+		if !l.acquireStream(key) {
+			api.RespondErrorDetails(c, http.StatusTooManyRequests, api.CodeRateLimited, "Too many open streams",
+				"at most "+strconv.Itoa(l.cfg.MaxStreamsPerClient)+" concurrent streams are allowed per user")
+			return
+		}
+		defer l.releaseStream(key)
+		c.Next()
+	}
+}
+
+func (l *Limiter) key(c *gin.Context) string {
+	if l.keyFunc != nil {
+		if key := l.keyFunc(c); key != "" {
+			return key
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// reserveMutations takes n tokens, at most the burst, for mutating requests and returns zero, or
+// how long the client has to wait for them when not enough are available
+func (l *Limiter) reserveMutations(key string, n int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	state := l.client(key)
+	if state.mutations == nil {
+		state.mutations = rate.NewLimiter(rate.Limit(float64(l.cfg.MutationsPerMinute)/60), l.burst())
+	}
+	now := l.now()
+	reservation := state.mutations.ReserveN(now, n)
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		reservation.CancelAt(now)
+	}
+	return delay
+}
+
+func (l *Limiter) burst() int {
+	return max(l.cfg.MutationBurst, 1)
+}
+
+func (l *Limiter) acquireStream(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	state := l.client(key)
+	if state.streams >= l.cfg.MaxStreamsPerClient {
+		return false
+	}
+	state.streams++
+	return true
+}
+
+func (l *Limiter) releaseStream(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if state, ok := l.clients[key]; ok && state.streams > 0 {
+		state.streams--
+		state.lastSeen = l.now()
+	}
+}
+
+// client returns the state of a client, dropping idle clients periodically. Callers hold l.mu.
+func (l *Limiter) client(key string) *clientState {
+	now := l.now()
+	if now.Sub(l.lastSweep) > idleTTL {
+		for k, state := range l.clients {
+			if state.streams == 0 && now.Sub(state.lastSeen) > idleTTL {
+				delete(l.clients, k)
+			}
+		}
+		l.lastSweep = now
+	}
+	state, ok := l.clients[key]
+	if !ok {
+		state = &clientState{}
+		l.clients[key] = state
+	}
+	state.lastSeen = now
+	return state
+}
+
+func isMutation(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return strings.HasPrefix(r.URL.Path, "/api/")
+}
+
+func envInt(name string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(name)); err == nil && value >= 0 {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRouter(l *Limiter, streamStarted, releaseStream chan struct{}) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(l.Mutations())
+	r.GET("/api/v1/rollouts", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.POST("/api/v1/rollouts/ns/app/pin", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/api/v1/rollouts/ns/app/pods/logs", l.Streams(), func(c *gin.Context) {
+		streamStarted <- struct{}{}
+		<-releaseStream
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func userKey(c *gin.Context) string {
+	return c.GetHeader("X-Test-User")
+}
+
+func do(r *gin.Engine, method, path, user string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if user != "" {
+		req.Header.Set("X-Test-User", user)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMutations_LimitedPerUser(t *testing.T) {
+	l := New(Config{MutationsPerMinute: 60, MutationBurst: 2}, userKey)
+	now := time.Now()
+	l.now = func() time.Time { return now }
+	r := newTestRouter(l, nil, nil)
+
+	assert.Equal(t, http.StatusOK, do(r, http.MethodPost, "/api/v1/rollouts/ns/app/pin", "alice").Code)
+	assert.Equal(t, http.StatusOK, do(r, http.MethodPost, "/api/v1/rollouts/ns/app/pin", "alice").Code)
+
+	w := do(r, http.MethodPost, "/api/v1/rollouts/ns/app/pin", "alice")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "RATE_LIMITED")
+
+	// Reads and other users are not affected
+	assert.Equal(t, http.StatusOK, do(r, http.MethodGet, "/api/v1/rollouts", "alice").Code)
+	assert.Equal(t, http.StatusOK, do(r, http.MethodPost, "/api/v1/rollouts/ns/app/pin", "bob").Code)
+
+	now = now.Add(time.Second)
+	assert.Equal(t, http.StatusOK, do(r, http.MethodPost, "/api/v1/rollouts/ns/app/pin", "alice").Code)
+}
+
+func TestChargeMutations(t *testing.T) {
+	l := New(Config{MutationsPerMinute: 60, MutationBurst: 5}, userKey)
+	now := time.Now()
+	l.now = func() time.Time { return now }
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(l.Mutations())
+	r.POST("/api/v1/rollouts/batch", func(c *gin.Context) {
+		items, _ := strconv.Atoi(c.Query("items"))
+		if l.ChargeMutations(c, items-1) {
+			c.Status(http.StatusOK)
+		}
+	})
+
+	// Every item counts, on top of the request itself
+	assert.Equal(t, http.StatusOK, do(r, http.MethodPost, "/api/v1/rollouts/batch?items=3", "alice").Code)
+	w := do(r, http.MethodPost, "/api/v1/rollouts/batch?items=3", "alice")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	now = now.Add(2 * time.Second)
+	assert.Equal(t, http.StatusOK, do(r, http.MethodPost, "/api/v1/rollouts/batch?items=3", "alice").Code)
+
+	// More items than the burst are never allowed
+	w = do(r, http.MethodPost, "/api/v1/rollouts/batch?items=6", "bob")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Empty(t, w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "at most 5")
+}
+
+func TestStreams_ConcurrencyCap(t *testing.T) {
+	l := New(Config{MaxStreamsPerClient: 1}, userKey)
+	started, release := make(chan struct{}), make(chan struct{})
+	r := newTestRouter(l, started, release)
+
+	done := make(chan int)
+	go func() {
+		done <- do(r, http.MethodGet, "/api/v1/rollouts/ns/app/pods/logs", "alice").Code
+	}()
+	<-started
+
+	assert.Equal(t, http.StatusTooManyRequests, do(r, http.MethodGet, "/api/v1/rollouts/ns/app/pods/logs", "alice").Code)
+
+	release <- struct{}{}
+	require.Equal(t, http.StatusOK, <-done)
+
+	// The slot is released once the first stream ends
+	go func() {
+		<-started
+		release <- struct{}{}
+	}()
+	assert.Equal(t, http.StatusOK, do(r, http.MethodGet, "/api/v1/rollouts/ns/app/pods/logs", "alice").Code)
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("RATE_LIMIT_MUTATIONS_PER_MINUTE", "0")
+	t.Setenv("MAX_STREAMS_PER_CLIENT", "invalid")
+
+	cfg := ConfigFromEnv()
+	assert.Equal(t, 0, cfg.MutationsPerMinute)
+	assert.Equal(t, 10, cfg.MutationBurst)
+	assert.Equal(t, 10, cfg.MaxStreamsPerClient)
+}
@@ -0,0 +1,187 @@
+// Package registryhook verifies and parses the webhooks registries and CI send when a tag is
+// pushed, so the rollouts of the image can be reconciled right away instead of at the next scan
+package registryhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of the request body as "sha256=<hex>", like GitHub
+// webhooks
+const SignatureHeader = "X-Hub-Signature-256"
+
+// minSecretLength is the shortest secret accepted from REGISTRY_WEBHOOK_SECRET
+const minSecretLength = 16
+
+var (
+	// ErrInvalidSignature is returned when the signature is missing or was not made with the secret
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrNoPushes is returned for payloads that do not announce a pushed tag
+	ErrNoPushes = errors.New("payload does not announce a pushed tag")
+)
+
+// Verifier checks webhook signatures against a shared secret
+type Verifier struct {
+	secret []byte
+}
+
+// New creates a verifier for secret
+func New(secret []byte) *Verifier {
+	return &Verifier{secret: secret}
+}
+
+// FromEnv creates a verifier for REGISTRY_WEBHOOK_SECRET. The webhook is disabled, and nil is
+// returned, when no secret is set.
+func FromEnv() (*Verifier, error) {
+	secret := os.Getenv("REGISTRY_WEBHOOK_SECRET")
+	if secret == "" {
+		return nil, nil
+	}
+	if len(secret) < minSecretLength {
+		return nil, fmt.Errorf("REGISTRY_WEBHOOK_SECRET must be at least %d characters", minSecretLength)
+	}
+	return New([]byte(secret)), nil
+}
+
+// Sign returns the signature header value of body
+func (v *Verifier) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, v.secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature header value of body
+func (v *Verifier) Verify(body []byte, signature string) error {
+	encoded, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return ErrInvalidSignature
+	}
+	mac, err := hex.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	expected := hmac.New(sha256.New, v.secret)
+	expected.Write(body)
+	if !hmac.Equal(mac, expected.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Push is a tag pushed to an image repository
+type Push struct {
+	Image string `json:"image"`
+	Tag   string `json:"tag,omitempty"`
+}
+
+// payload holds the fields of the supported webhook formats
+type payload struct {
+	// Generic, e.g. sent from CI: {"image": "registry.example.com/app", "tag": "v1.2.3"}
+	Image string `json:"image"`
+	Tag   string `json:"tag"`
+
+	// Docker Hub
+	PushData *struct {
+		Tag string `json:"tag"`
+	} `json:"push_data"`
+	Repository *struct {
+		RepoName string `json:"repo_name"`
+	} `json:"repository"`
+
+	// Harbor
+	Type      string `json:"type"`
+	EventData *struct {
+		Resources []struct {
+			Tag         string `json:"tag"`
+			ResourceURL string `json:"resource_url"`
+		} `json:"resources"`
+	} `json:"event_data"`
+
+	// CNCF Distribution (Docker registry) notifications
+	Events []struct {
+		Action string `json:"action"`
+		Target struct {
+			Repository string `json:"repository"`
+			Tag        string `json:"tag"`
+		} `json:"target"`
+		Request struct {
+			Host string `json:"host"`
+		} `json:"request"`
+	} `json:"events"`
+}
+
+// ParsePushes returns the tags a webhook announces as pushed. Generic payloads, Docker Hub,
+// Harbor and CNCF Distribution notifications are understood.
+func ParsePushes(body []byte) ([]Push, error) {
+	var p payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse payload: %w", err)
+	}
+
+	var pushes []Push
+	switch {
+	case p.Image != "":
+		pushes = append(pushes, Push{Image: p.Image, Tag: p.Tag})
+	case p.PushData != nil && p.Repository != nil && p.Repository.RepoName != "":
+		pushes = append(pushes, Push{Image: p.Repository.RepoName, Tag: p.PushData.Tag})
+	case p.EventData != nil:
+		if p.Type != "" && p.Type != "PUSH_ARTIFACT" {
+			break
+		}
+		for _, resource := range p.EventData.Resources {
+			image, _, _ := strings.Cut(resource.ResourceURL, "@")
+			if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+				image = image[:i]
+			}
+			if image != "" {
+				pushes = append(pushes, Push{Image: image, Tag: resource.Tag})
+			}
+		}
+	default:
+		for _, event := range p.Events {
+			if event.Action != "push" || event.Target.Repository == "" {
+				continue
+			}
+			image := event.Target.Repository
+			if event.Request.Host != "" {
+				image = event.Request.Host + "/" + image
+			}
+			pushes = append(pushes, Push{Image: image, Tag: event.Target.Tag})
+		}
+	}
+	// Distribution sends an event per blob and manifest of a push
+	var unique []Push
+	for _, push := range pushes {
+		if !slices.Contains(unique, push) {
+			unique = append(unique, push)
+		}
+	}
+	if len(unique) == 0 {
+		return nil, ErrNoPushes
+	}
+	return unique, nil
+}
+
+// SameRepository reports whether two image references name the same repository, e.g.
+// "nginx" and "docker.io/library/nginx"
+func SameRepository(a, b string) bool {
+	repoA, err := name.NewRepository(a)
+	if err != nil {
+		return false
+	}
+	repoB, err := name.NewRepository(b)
+	if err != nil {
+		return false
+	}
+	return repoA.Name() == repoB.Name()
+}
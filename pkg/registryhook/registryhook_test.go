@@ -0,0 +1,80 @@
+package registryhook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	v := New([]byte("0123456789abcdef"))
+	body := []byte(`{"image":"ghcr.io/shop/api","tag":"v1.2.0"}`)
+
+	assert.NoError(t, v.Verify(body, v.Sign(body)))
+	for _, signature := range []string{"", v.Sign(body)[len("sha256="):], "sha256=zz", New([]byte("another secret!!")).Sign(body)} {
+		assert.ErrorIs(t, v.Verify(body, signature), ErrInvalidSignature, signature)
+	}
+	assert.ErrorIs(t, v.Verify([]byte(`{"image":"ghcr.io/shop/other"}`), v.Sign(body)), ErrInvalidSignature)
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("REGISTRY_WEBHOOK_SECRET", "")
+	v, err := FromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, v)
+
+	t.Setenv("REGISTRY_WEBHOOK_SECRET", "short")
+	_, err = FromEnv()
+	assert.Error(t, err)
+}
+
+func TestParsePushes(t *testing.T) {
+	tests := map[string]struct {
+		body string
+		want []Push
+	}{
+		"generic": {
+			body: `{"image":"ghcr.io/shop/api","tag":"v1.2.0"}`,
+			want: []Push{{Image: "ghcr.io/shop/api", Tag: "v1.2.0"}},
+		},
+		"docker hub": {
+			body: `{"push_data":{"tag":"v1.2.0","pusher":"ci"},"repository":{"repo_name":"shop/api","namespace":"shop"}}`,
+			want: []Push{{Image: "shop/api", Tag: "v1.2.0"}},
+		},
+		"harbor": {
+			body: `{"type":"PUSH_ARTIFACT","event_data":{"resources":[
+				{"tag":"v1.2.0","resource_url":"harbor.example.com:8443/shop/api:v1.2.0"},
+				{"resource_url":"harbor.example.com:8443/shop/api@sha256:abc"}]}}`,
+			want: []Push{{Image: "harbor.example.com:8443/shop/api", Tag: "v1.2.0"}, {Image: "harbor.example.com:8443/shop/api"}},
+		},
+		"distribution": {
+			body: `{"events":[
+				{"action":"push","target":{"repository":"shop/api"},"request":{"host":"registry:5000"}},
+				{"action":"push","target":{"repository":"shop/api","tag":"v1.2.0"},"request":{"host":"registry:5000"}},
+				{"action":"pull","target":{"repository":"shop/web","tag":"v1"}},
+				{"action":"push","target":{"repository":"shop/api"},"request":{"host":"registry:5000"}}]}`,
+			want: []Push{{Image: "registry:5000/shop/api"}, {Image: "registry:5000/shop/api", Tag: "v1.2.0"}},
+		},
+	}
+	for name, tt := range tests {
+		pushes, err := ParsePushes([]byte(tt.body))
+		require.NoError(t, err, name)
+		assert.Equal(t, tt.want, pushes, name)
+	}
+
+	for _, body := range []string{`{"type":"DELETE_ARTIFACT","event_data":{"resources":[{"resource_url":"harbor/shop/api:v1"}]}}`, `{}`, `{"events":[]}`} {
+		_, err := ParsePushes([]byte(body))
+		assert.ErrorIs(t, err, ErrNoPushes, body)
+	}
+	_, err := ParsePushes([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestSameRepository(t *testing.T) {
+	assert.True(t, SameRepository("nginx", "docker.io/library/nginx"))
+	assert.True(t, SameRepository("shop/api", "index.docker.io/shop/api"))
+	assert.True(t, SameRepository("ghcr.io/shop/api", "ghcr.io/shop/api"))
+	assert.False(t, SameRepository("ghcr.io/shop/api", "ghcr.io/shop/web"))
+	assert.False(t, SameRepository("ghcr.io/shop/api", "Not A Reference"))
+}
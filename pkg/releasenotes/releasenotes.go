@@ -0,0 +1,237 @@
+// Package releasenotes aggregates what a range of releases brings, from their OCI annotations,
+// the commits they were built from, the JIRA issues those commits mention and their test results,
+// into release notes for a "what's in this deploy" view
+package releasenotes
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kuberik/rollout-dashboard/pkg/gitprovider"
+	"github.com/kuberik/rollout-dashboard/pkg/oci"
+)
+
+// Release annotations with the results of the tests a release passed in CI
+const (
+	// TestResultsAnnotation counts the tests by outcome, e.g. passed=412,failed=0,skipped=3
+	TestResultsAnnotation = "rollout.kuberik.com/test-results"
+	// TestReportAnnotation is the URL of the full test report
+	TestReportAnnotation = "rollout.kuberik.com/test-report"
+)
+
+// issueKey matches JIRA issue keys such as SHOP-123
+var issueKey = regexp.MustCompile(`\b[A-Z][A-Z0-9]+-[1-9][0-9]*\b`)
+
+// Issue is a JIRA issue mentioned by a release
+type Issue struct {
+	Key string `json:"key"`
+	// URL is set when JIRA_URL is configured
+	URL string `json:"url,omitempty"`
+}
+
+// TestResults are the test outcomes of a release
+type TestResults struct {
+	Passed  int    `json:"passed"`
+	Failed  int    `json:"failed"`
+	Skipped int    `json:"skipped"`
+	Report  string `json:"report,omitempty"`
+}
+
+// Release is one release of the notes
+type Release struct {
+	oci.ChangelogEntry
+	Commit *gitprovider.Commit `json:"commit,omitempty"`
+	// CommitError is set when the commit could not be looked up
+	CommitError string       `json:"commitError,omitempty"`
+	Issues      []Issue      `json:"issues"`
+	Tests       *TestResults `json:"tests,omitempty"`
+}
+
+// Notes are the release notes of the releases after From up to and including To
+type Notes struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Releases are newest first
+	Releases []Release `json:"releases"`
+	// Issues are the distinct issues of all releases, in order of first mention from the newest
+	Issues []Issue `json:"issues"`
+	// Tests sums the test results of the releases that have them
+	Tests *TestResults `json:"tests,omitempty"`
+	// Omitted counts the oldest releases left out of notes that grew too long
+	Omitted int `json:"omitted,omitempty"`
+}
+
+// Builder builds release notes, linking issues to a JIRA instance
+type Builder struct {
+	jiraURL string
+}
+
+// New creates a builder linking issues to jiraURL/browse/<key>, or not linking them when jiraURL
+// is empty
+func New(jiraURL string) *Builder {
+	return &Builder{jiraURL: strings.TrimSuffix(jiraURL, "/")}
+}
+
+// FromEnv creates a builder linking issues to the JIRA instance at JIRA_URL
+func FromEnv() *Builder {
+	return New(os.Getenv("JIRA_URL"))
+}
+
+// Release describes a release by its changelog entry, annotations and, when known, the commit it
+// was built from. Issues are collected from the release's title and description, the commit
+// message and the titles of the commit's pull requests.
+func (b *Builder) Release(entry oci.ChangelogEntry, annotations map[string]string, commit *gitprovider.Commit) Release {
+	release := Release{ChangelogEntry: entry, Commit: commit, Tests: ParseTestResults(annotations)}
+	texts := []string{entry.Title, entry.Description}
+	if commit != nil {
+		texts = append(texts, commit.Message)
+		for _, change := range commit.Changes {
+			texts = append(texts, change.Title)
+		}
+	}
+	release.Issues = b.issues(texts...)
+	return release
+}
+
+// Notes aggregates the releases, newest first, into release notes
+func (b *Builder) Notes(from, to string, releases []Release, omitted int) Notes {
+	notes := Notes{From: from, To: to, Releases: releases, Issues: []Issue{}, Omitted: omitted}
+	for _, release := range releases {
+		for _, issue := range release.Issues {
+			if !slices.Contains(notes.Issues, issue) {
+				notes.Issues = append(notes.Issues, issue)
+			}
+		}
+		if release.Tests != nil {
+			if notes.Tests == nil {
+				notes.Tests = &TestResults{}
+			}
+			notes.Tests.Passed += release.Tests.Passed
+			notes.Tests.Failed += release.Tests.Failed
+			notes.Tests.Skipped += release.Tests.Skipped
+		}
+	}
+	return notes
+}
+
+// issues returns the distinct issue keys mentioned in texts, in order of first mention
+func (b *Builder) issues(texts ...string) []Issue {
+	issues := []Issue{}
+	seen := map[string]bool{}
+	for _, text := range texts {
+		for _, key := range issueKey.FindAllString(text, -1) {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			issue := Issue{Key: key}
+			if b.jiraURL != "" {
+				issue.URL = b.jiraURL + "/browse/" + key
+			}
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// ParseTestResults reads the test results of a release from its annotations, nil when it has none.
+// Unknown outcomes, malformed counts and reports that are not http(s) URLs are ignored.
+func ParseTestResults(annotations map[string]string) *TestResults {
+	value, report := annotations[TestResultsAnnotation], annotations[TestReportAnnotation]
+	if value == "" && report == "" {
+		return nil
+	}
+	results := &TestResults{}
+	// Only http(s) reports are linked, so a report cannot run scripts in the browser
+	if strings.HasPrefix(report, "https://") || strings.HasPrefix(report, "http://") {
+		results.Report = report
+	}
+	for _, pair := range strings.Split(value, ",") {
+		outcome, count, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		n, err := strconv.Atoi(strings.TrimSpace(count))
+		if err != nil || n < 0 {
+			continue
+		}
+		switch strings.TrimSpace(outcome) {
+		case "passed":
+			results.Passed = n
+		case "failed":
+			results.Failed = n
+		case "skipped":
+			results.Skipped = n
+		}
+	}
+	return results
+}
+
+// Markdown renders the notes as a Markdown document
+func (n Notes) Markdown() string {
+	var md strings.Builder
+	fmt.Fprintf(&md, "# Release notes %s → %s\n", n.From, n.To)
+	if n.Tests != nil {
+		fmt.Fprintf(&md, "\nTests: %d passed, %d failed, %d skipped\n", n.Tests.Passed, n.Tests.Failed, n.Tests.Skipped)
+	}
+	if len(n.Issues) > 0 {
+		md.WriteString("\n## Issues\n\n")
+		for _, issue := range n.Issues {
+			fmt.Fprintf(&md, "- %s\n", markdownLink(issue.Key, issue.URL))
+		}
+	}
+	for _, release := range n.Releases {
+		fmt.Fprintf(&md, "\n## %s\n\n", release.Version)
+		title := release.Title
+		if release.Commit != nil {
+			title = release.Commit.Title
+		}
+		if title != "" {
+			fmt.Fprintf(&md, "%s\n\n", title)
+		}
+		if release.Description != "" {
+			fmt.Fprintf(&md, "%s\n\n", release.Description)
+		}
+		if release.Commit != nil {
+			fmt.Fprintf(&md, "- Commit: %s by %s\n", markdownLink(shortSHA(release.Commit.SHA), release.Commit.URL), release.Commit.Author.Name)
+			for _, change := range release.Commit.Changes {
+				fmt.Fprintf(&md, "- %s\n", markdownLink(fmt.Sprintf("#%d %s", change.Number, change.Title), change.URL))
+			}
+		} else if release.Revision != "" {
+			fmt.Fprintf(&md, "- Revision: %s\n", release.Revision)
+		}
+		if len(release.Issues) > 0 {
+			keys := make([]string, 0, len(release.Issues))
+			for _, issue := range release.Issues {
+				keys = append(keys, markdownLink(issue.Key, issue.URL))
+			}
+			fmt.Fprintf(&md, "- Issues: %s\n", strings.Join(keys, ", "))
+		}
+		if release.Tests != nil {
+			tests := fmt.Sprintf("%d passed, %d failed, %d skipped", release.Tests.Passed, release.Tests.Failed, release.Tests.Skipped)
+			fmt.Fprintf(&md, "- Tests: %s\n", markdownLink(tests, release.Tests.Report))
+		}
+		if release.Error != "" {
+			fmt.Fprintf(&md, "- Error: %s\n", release.Error)
+		}
+	}
+	if n.Omitted > 0 {
+		fmt.Fprintf(&md, "\n%d older releases omitted\n", n.Omitted)
+	}
+	return md.String()
+}
+
+func markdownLink(text, url string) string {
+	if url == "" {
+		return text
+	}
+	return "[" + text + "](" + url + ")"
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,58 @@
+package releasenotes
+
+import (
+	"testing"
+
+	"github.com/kuberik/rollout-dashboard/pkg/gitprovider"
+	"github.com/kuberik/rollout-dashboard/pkg/oci"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTestResults(t *testing.T) {
+	assert.Nil(t, ParseTestResults(nil))
+	assert.Equal(t, &TestResults{Passed: 412, Skipped: 3, Report: "https://ci.example.com/runs/1"}, ParseTestResults(map[string]string{
+		TestResultsAnnotation: "passed=412, failed=0,skipped=3,flaky=2,broken",
+		TestReportAnnotation:  "https://ci.example.com/runs/1",
+	}))
+	assert.Equal(t, &TestResults{Failed: 1}, ParseTestResults(map[string]string{
+		TestResultsAnnotation: "failed=1",
+		TestReportAnnotation:  "javascript:alert(1)",
+	}))
+}
+
+func TestNotes(t *testing.T) {
+	builder := New("https://jira.example.com/")
+	commit := &gitprovider.Commit{
+		SHA:     "4f2a9c1d0e",
+		Title:   "Fix checkout totals",
+		Message: "Fix checkout totals\n\nFixes SHOP-12 and SHOP-12 again, see PAY-3",
+		Changes: []gitprovider.ChangeRequest{{Number: 42, Title: "SHOP-40: rounding"}},
+	}
+	releases := []Release{
+		builder.Release(oci.ChangelogEntry{Version: "v1.2.0", Title: "shop"}, map[string]string{TestResultsAnnotation: "passed=10,failed=1"}, commit),
+		builder.Release(oci.ChangelogEntry{Version: "v1.1.0", Description: "Implements PAY-3 (not pay-4)"}, map[string]string{TestResultsAnnotation: "passed=5,skipped=2"}, nil),
+		builder.Release(oci.ChangelogEntry{Version: "v1.0.1", Error: "manifest unknown"}, nil, nil),
+	}
+
+	assert.Equal(t, []Issue{
+		{Key: "SHOP-12", URL: "https://jira.example.com/browse/SHOP-12"},
+		{Key: "PAY-3", URL: "https://jira.example.com/browse/PAY-3"},
+		{Key: "SHOP-40", URL: "https://jira.example.com/browse/SHOP-40"},
+	}, releases[0].Issues)
+	assert.Empty(t, releases[2].Issues)
+
+	notes := builder.Notes("v1.0.0", "v1.2.0", releases, 0)
+	var keys []string
+	for _, issue := range notes.Issues {
+		keys = append(keys, issue.Key)
+	}
+	assert.Equal(t, []string{"SHOP-12", "PAY-3", "SHOP-40"}, keys)
+	assert.Equal(t, &TestResults{Passed: 15, Failed: 1, Skipped: 2}, notes.Tests)
+
+	md := notes.Markdown()
+	assert.Contains(t, md, "# Release notes v1.0.0 → v1.2.0")
+	assert.Contains(t, md, "## v1.2.0\n\nFix checkout totals\n")
+	assert.Contains(t, md, "- Issues: [SHOP-12](https://jira.example.com/browse/SHOP-12)")
+	assert.Contains(t, md, "- Tests: 10 passed, 1 failed, 0 skipped\n")
+	assert.Contains(t, md, "- Error: manifest unknown\n")
+}
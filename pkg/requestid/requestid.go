@@ -0,0 +1,48 @@
+package requestid
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// HeaderName is the header used to propagate request IDs
+	HeaderName = "X-Request-ID"
+
+	contextKey = "request_id"
+)
+
+// validID restricts client-supplied IDs to a safe charset and length so they can be echoed and logged
+var validID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// Middleware assigns a request ID to every request, reusing a well-formed incoming X-Request-ID
+// (e.g. from the gateway) and echoing it in the response headers
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(HeaderName)
+		if !validID.MatchString(id) {
+			id = New()
+		}
+		c.Set(contextKey, id)
+		c.Header(HeaderName, id)
+		c.Next()
+	}
+}
+
+// FromContext returns the request ID assigned by Middleware, or empty string if none
+func FromContext(c *gin.Context) string {
+	if c == nil {
+		return ""
+	}
+	return c.GetString(contextKey)
+}
+
+// New generates a random request ID
+func New() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,170 @@
+// Package search ranks rollouts against a free-text query, so clients can find rollouts across
+// all namespaces without downloading and filtering the full list
+package search
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Fields of a Document a query word can match
+const (
+	FieldName       = "name"
+	FieldNamespace  = "namespace"
+	FieldLabel      = "label"
+	FieldAnnotation = "annotation"
+	FieldImage      = "image"
+	FieldVersion    = "version"
+)
+
+// Scores of the ways a word can match a value. A word counts with the best of its matches.
+const (
+	scoreExact     = 100
+	scorePrefix    = 60
+	scoreSubstring = 30
+)
+
+// fieldWeight scales the score of a match by how much the field identifies a rollout
+var fieldWeight = map[string]int{
+	FieldName:       4,
+	FieldNamespace:  2,
+	FieldVersion:    2,
+	FieldImage:      2,
+	FieldLabel:      1,
+	FieldAnnotation: 1,
+}
+
+// ignoredAnnotations are not searched since they repeat the object or hold large blobs
+var ignoredAnnotations = map[string]bool{
+	"kubectl.kubernetes.io/last-applied-configuration": true,
+}
+
+// Document is a rollout as it is searched
+type Document struct {
+	Namespace   string
+	Name        string
+	Labels      map[string]string
+	Annotations map[string]string
+	// Image is the image the rollout's releases come from, Version the deployed version
+	Image   string
+	Version string
+}
+
+// Match is a field of a document a query term matched
+type Match struct {
+	Field string `json:"field"`
+	// Value is the matched value, key=value for labels and annotations
+	Value string `json:"value"`
+}
+
+// Result is a document matching every word of a query
+type Result struct {
+	// Index is the position of the document in the searched slice
+	Index   int     `json:"-"`
+	Score   int     `json:"score"`
+	Matches []Match `json:"matches"`
+}
+
+// Search returns the documents matching every word of query, best match first and by namespace
+// and name among equal scores. Words match case-insensitively; labels and annotations are matched
+// by key, value and key=value, so team=payments finds the rollouts labelled with it. A query
+// without words matches nothing.
+func Search(query string, documents []Document) []Result {
+	words := strings.Fields(strings.ToLower(query))
+	results := []Result{}
+	if len(words) == 0 {
+		return results
+	}
+	for i, document := range documents {
+		result := Result{Index: i}
+		matched := true
+		for _, word := range words {
+			score, matches := match(word, document)
+			if score == 0 {
+				matched = false
+				break
+			}
+			result.Score += score
+			for _, m := range matches {
+				if !slices.Contains(result.Matches, m) {
+					result.Matches = append(result.Matches, m)
+				}
+			}
+		}
+		if matched {
+			results = append(results, result)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		a, b := documents[results[i].Index], documents[results[j].Index]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return results
+}
+
+// match scores a lower-cased word against a document and returns the fields it matched with the
+// best score
+func match(word string, document Document) (int, []Match) {
+	best := 0
+	var matches []Match
+	add := func(score int, m Match) {
+		switch {
+		case score > best:
+			best, matches = score, []Match{m}
+		case score == best && score > 0:
+			matches = append(matches, m)
+		}
+	}
+
+	add(textScore(word, document.Name)*fieldWeight[FieldName], Match{Field: FieldName, Value: document.Name})
+	add(textScore(word, document.Namespace)*fieldWeight[FieldNamespace], Match{Field: FieldNamespace, Value: document.Namespace})
+	add(textScore(word, document.Image)*fieldWeight[FieldImage], Match{Field: FieldImage, Value: document.Image})
+	add(textScore(word, document.Version)*fieldWeight[FieldVersion], Match{Field: FieldVersion, Value: document.Version})
+	for _, field := range []struct {
+		name   string
+		values map[string]string
+	}{{FieldLabel, document.Labels}, {FieldAnnotation, document.Annotations}} {
+		for _, key := range sortedKeys(field.values) {
+			if ignoredAnnotations[key] {
+				continue
+			}
+			value := field.values[key]
+			score := max(textScore(word, key), textScore(word, value), textScore(word, key+"="+value))
+			add(score*fieldWeight[field.name], Match{Field: field.name, Value: key + "=" + value})
+		}
+	}
+	return best, matches
+}
+
+// textScore scores how a lower-cased word matches a value, 0 when it does not
+func textScore(text, value string) int {
+	if value == "" {
+		return 0
+	}
+	value = strings.ToLower(value)
+	switch {
+	case value == text:
+		return scoreExact
+	case strings.HasPrefix(value, text):
+		return scorePrefix
+	case strings.Contains(value, text):
+		return scoreSubstring
+	}
+	return 0
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
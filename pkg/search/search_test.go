@@ -0,0 +1,66 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch(t *testing.T) {
+	documents := []Document{
+		{Namespace: "shop", Name: "checkout", Labels: map[string]string{"team": "payments"}, Image: "ghcr.io/example/checkout", Version: "v1.4.0"},
+		{Namespace: "shop", Name: "checkout-worker", Labels: map[string]string{"team": "payments"}, Image: "ghcr.io/example/checkout-worker", Version: "v1.4.0"},
+		{Namespace: "payments", Name: "ledger", Annotations: map[string]string{
+			"example.com/owner": "Finance",
+			"kubectl.kubernetes.io/last-applied-configuration": `{"metadata":{"name":"checkout"}}`,
+		}, Image: "ghcr.io/example/ledger", Version: "v2.0.1"},
+		{Namespace: "web", Name: "frontend", Version: "v1.4.1"},
+	}
+	names := func(results []Result) []string {
+		var names []string
+		for _, result := range results {
+			names = append(names, documents[result.Index].Name)
+		}
+		return names
+	}
+
+	t.Run("Exact names rank before prefixes", func(t *testing.T) {
+		results := Search("Checkout", documents)
+		assert.Equal(t, []string{"checkout", "checkout-worker"}, names(results))
+		assert.Greater(t, results[0].Score, results[1].Score)
+		assert.Contains(t, results[0].Matches, Match{Field: FieldName, Value: "checkout"})
+	})
+
+	t.Run("Labels match by key=value", func(t *testing.T) {
+		results := Search("team=payments", documents)
+		assert.Equal(t, []string{"checkout", "checkout-worker"}, names(results))
+		assert.Equal(t, []Match{{Field: FieldLabel, Value: "team=payments"}}, results[0].Matches)
+	})
+
+	t.Run("A word matches namespaces and label values", func(t *testing.T) {
+		// The namespace outranks the label values
+		assert.Equal(t, []string{"ledger", "checkout", "checkout-worker"}, names(Search("payments", documents)))
+	})
+
+	t.Run("Every word must match", func(t *testing.T) {
+		assert.Equal(t, []string{"checkout-worker"}, names(Search("worker v1.4", documents)))
+		assert.Empty(t, Search("worker v2", documents))
+	})
+
+	t.Run("Versions, images and annotations", func(t *testing.T) {
+		assert.Equal(t, []string{"frontend"}, names(Search("v1.4.1", documents)))
+		assert.Equal(t, []string{"ledger"}, names(Search("example/ledger", documents)))
+		results := Search("finance", documents)
+		require.Len(t, results, 1)
+		assert.Equal(t, []Match{{Field: FieldAnnotation, Value: "example.com/owner=Finance"}}, results[0].Matches)
+	})
+
+	t.Run("Large annotations are not searched", func(t *testing.T) {
+		assert.NotContains(t, names(Search("metadata", documents)), "ledger")
+	})
+
+	t.Run("Empty queries match nothing", func(t *testing.T) {
+		assert.Empty(t, Search("  ", documents))
+	})
+}
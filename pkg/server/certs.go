@@ -0,0 +1,101 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// certReloader serves a certificate from disk and reloads it when the files change. Kubernetes
+// updates Secret volumes in place, so this picks up rotated certificates without a restart.
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	modTime  time.Time
+	lastStat time.Time
+}
+
+// certCheckInterval limits how often the files are stat'ed during handshakes
+const certCheckInterval = 10 * time.Second
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	cert, due := r.cert, time.Since(r.lastStat) >= certCheckInterval
+	r.mu.RUnlock()
+	if !due {
+		return cert, nil
+	}
+
+	if err := r.reloadIfChanged(); err != nil {
+		// Keep serving the previous certificate; the files may be mid-update
+		slog.Warn("Failed to reload TLS certificate", "cert", r.certFile, "error", err)
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+func (r *certReloader) reloadIfChanged() error {
+	modTime, err := r.latestModTime()
+	r.mu.Lock()
+	r.lastStat = time.Now()
+	unchanged := err == nil && !modTime.After(r.modTime)
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if unchanged {
+		return nil
+	}
+	if err := r.reload(); err != nil {
+		return err
+	}
+	slog.Info("Reloaded TLS certificate", "cert", r.certFile)
+	return nil
+}
+
+func (r *certReloader) reload() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS key pair: %w", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.modTime = modTime
+	r.lastStat = time.Now()
+	return nil
+}
+
+func (r *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to stat %s: %w", file, err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
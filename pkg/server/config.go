@@ -0,0 +1,144 @@
+package server
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config controls how the HTTP server listens and shuts down. Every flag can also be set through
+// the environment variable named in its usage string; flags take precedence.
+type Config struct {
+	// Address is the host:port to listen on, or several comma-separated ones, e.g.
+	// "0.0.0.0:8080,[::]:8080" to listen on IPv4 and IPv6 separately. A wildcard host such as
+	// ":8080" listens on both where the OS supports dual-stack sockets.
+	Address string
+	// ProxyProtocol expects every connection to start with a PROXY protocol header, as sent by L4
+	// load balancers, and takes the client address from it
+	ProxyProtocol bool
+	// TLSCertFile and TLSKeyFile enable HTTPS when both are set. The files are re-read when they
+	// change, so certificates mounted from a Secret rotate without a restart.
+	TLSCertFile string
+	TLSKeyFile  string
+	// ReadHeaderTimeout and ReadTimeout bound how long reading a request may take
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	// WriteTimeout bounds writing a response. It applies to streaming endpoints as well, so it is
+	// disabled by default.
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// ShutdownTimeout is how long in-flight requests may take to finish after SIGTERM
+	ShutdownTimeout time.Duration
+	// SelfTest runs the API self test against canned data instead of serving
+	SelfTest bool
+}
+
+// DefaultConfig returns the configuration used when no flags or environment variables are set
+func DefaultConfig() Config {
+	return Config{
+		Address:           ":8080",
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+		ShutdownTimeout:   30 * time.Second,
+	}
+}
+
+// ParseFlags reads the configuration from args, falling back to environment variables and then to
+// DefaultConfig. PORT is honoured as a shorthand for LISTEN_ADDRESS=":<port>".
+func ParseFlags(args []string) (Config, error) {
+	cfg := DefaultConfig()
+	if port := os.Getenv("PORT"); port != "" {
+		cfg.Address = ":" + port
+	}
+
+	fs := flag.NewFlagSet("rollout-dashboard", flag.ContinueOnError)
+	fs.StringVar(&cfg.Address, "listen-address", envString("LISTEN_ADDRESS", cfg.Address), "Address to listen on (LISTEN_ADDRESS)")
+	fs.StringVar(&cfg.TLSCertFile, "tls-cert-file", envString("TLS_CERT_FILE", ""), "TLS certificate file, enables HTTPS together with -tls-key-file (TLS_CERT_FILE)")
+	fs.StringVar(&cfg.TLSKeyFile, "tls-key-file", envString("TLS_KEY_FILE", ""), "TLS private key file (TLS_KEY_FILE)")
+	proxyProtocol, err := envBool("PROXY_PROTOCOL")
+	if err != nil {
+		return Config{}, err
+	}
+	fs.BoolVar(&cfg.ProxyProtocol, "proxy-protocol", proxyProtocol, "Require a PROXY protocol (v1 or v2) header on every connection and use the client address it carries (PROXY_PROTOCOL)")
+	fs.BoolVar(&cfg.SelfTest, "selftest", false, "Exercise every API route against a fake cluster with canned data and exit non-zero on failures")
+
+	durations := []struct {
+		target *time.Duration
+		flag   string
+		env    string
+		usage  string
+	}{
+		{&cfg.ReadHeaderTimeout, "read-header-timeout", "READ_HEADER_TIMEOUT", "Maximum time to read request headers"},
+		{&cfg.ReadTimeout, "read-timeout", "READ_TIMEOUT", "Maximum time to read a request, 0 disables"},
+		{&cfg.WriteTimeout, "write-timeout", "WRITE_TIMEOUT", "Maximum time to write a response including streams, 0 disables"},
+		{&cfg.IdleTimeout, "idle-timeout", "IDLE_TIMEOUT", "Maximum time to keep idle connections open"},
+		{&cfg.ShutdownTimeout, "shutdown-timeout", "SHUTDOWN_TIMEOUT", "Maximum time to wait for in-flight requests on shutdown"},
+	}
+	for _, d := range durations {
+		value := *d.target
+		if raw := os.Getenv(d.env); raw != "" {
+			parsed, err := time.ParseDuration(raw)
+			if err != nil {
+				return Config{}, fmt.Errorf("invalid %s: %w", d.env, err)
+			}
+			value = parsed
+		}
+		fs.DurationVar(d.target, d.flag, value, d.usage+" ("+d.env+")")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return Config{}, fmt.Errorf("both a TLS certificate and key file must be set")
+	}
+	addresses := cfg.Addresses()
+	if len(addresses) == 0 {
+		return Config{}, fmt.Errorf("no listen address")
+	}
+	for _, address := range addresses {
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			return Config{}, fmt.Errorf("invalid listen address %q, expected host:port or [ipv6]:port", address)
+		}
+	}
+	return cfg, nil
+}
+
+// Addresses returns the addresses to listen on
+func (c Config) Addresses() []string {
+	var addresses []string
+	for _, address := range strings.Split(c.Address, ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+// TLSEnabled reports whether the server serves HTTPS
+func (c Config) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+func envBool(name string) (bool, error) {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return false, nil
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return value, nil
+}
+
+func envString(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// proxyV2Signature starts every PROXY protocol v2 header
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// maxProxyV1HeaderLength is the longest v1 header the specification allows, including CRLF
+const maxProxyV1HeaderLength = 107
+
+var errMissingProxyHeader = errors.New("connection did not start with a PROXY protocol header")
+
+// proxyListener reads the PROXY protocol header (v1 or v2) that L4 load balancers such as AWS NLB
+// or HAProxy send before the client's data, so RemoteAddr is the client's address instead of the
+// load balancer's. Connections without a header are rejected: once enabled, only the load
+// balancer may connect, otherwise clients could claim any address.
+type proxyListener struct {
+	net.Listener
+	// headerTimeout bounds how long reading the header may take
+	headerTimeout time.Duration
+}
+
+func (l *proxyListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &proxyConn{Conn: conn, reader: bufio.NewReader(conn), headerTimeout: l.headerTimeout}, nil
+}
+
+// proxyConn reads the header on first use rather than in Accept, so a slow client does not block
+// the accept loop
+type proxyConn struct {
+	net.Conn
+	reader        *bufio.Reader
+	headerTimeout time.Duration
+
+	once   sync.Once
+	remote net.Addr
+	err    error
+}
+
+func (c *proxyConn) readHeader() {
+	c.once.Do(func() {
+		if c.headerTimeout > 0 {
+			_ = c.Conn.SetReadDeadline(time.Now().Add(c.headerTimeout))
+			defer c.Conn.SetReadDeadline(time.Time{})
+		}
+		c.remote, c.err = readProxyHeader(c.reader)
+		if c.err != nil {
+			slog.Debug("Rejected connection with invalid PROXY protocol header", "remote", c.Conn.RemoteAddr().String(), "error", c.err)
+		}
+	})
+}
+
+func (c *proxyConn) Read(b []byte) (int, error) {
+	c.readHeader()
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.reader.Read(b)
+}
+
+// RemoteAddr returns the client address from the header, or the peer's address for headers that
+// carry none (health checks of the load balancer itself)
+func (c *proxyConn) RemoteAddr() net.Addr {
+	c.readHeader()
+	if c.remote != nil {
+		return c.remote
+	}
+	return c.Conn.RemoteAddr()
+}
+
+// readProxyHeader consumes a PROXY protocol header and returns the source address it carries, or
+// nil for LOCAL and UNKNOWN connections
+func readProxyHeader(r *bufio.Reader) (net.Addr, error) {
+	prefix, err := r.Peek(len(proxyV2Signature))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PROXY protocol header: %w", err)
+	}
+	switch {
+	case bytes.Equal(prefix, proxyV2Signature):
+		return readProxyV2Header(r)
+	case bytes.HasPrefix(prefix, []byte("PROXY ")):
+		return readProxyV1Header(r)
+	default:
+		return nil, errMissingProxyHeader
+	}
+}
+
+// readProxyV1Header parses the text format, e.g. "PROXY TCP4 203.0.113.7 10.0.0.1 51234 443\r\n"
+func readProxyV1Header(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for len(line) < maxProxyV1HeaderLength {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read PROXY protocol header: %w", err)
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	header, ok := strings.CutSuffix(string(line), "\r\n")
+	if !ok {
+		return nil, fmt.Errorf("PROXY protocol v1 header is not terminated by CRLF")
+	}
+
+	fields := strings.Split(header, " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, fmt.Errorf("invalid PROXY protocol v1 header %q", header)
+	}
+	ip := net.ParseIP(fields[2])
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if ip == nil || err != nil || (fields[1] == "TCP4") != (ip.To4() != nil) {
+		return nil, fmt.Errorf("invalid PROXY protocol v1 source %s:%s", fields[2], fields[4])
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// readProxyV2Header parses the binary format: the signature, version and command, address family,
+// the length of the rest and the addresses
+func readProxyV2Header(r *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("failed to read PROXY protocol header: %w", err)
+	}
+	if header[12]>>4 != 2 {
+		return nil, fmt.Errorf("unsupported PROXY protocol version %d", header[12]>>4)
+	}
+	command, family := header[12]&0x0f, header[13]
+	payload := make([]byte, binary.BigEndian.Uint16(header[14:16]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, fmt.Errorf("failed to read PROXY protocol addresses: %w", err)
+	}
+
+	switch command {
+	case 0x0:
+		// LOCAL: sent by the load balancer for its own connections, e.g. health checks
+		return nil, nil
+	case 0x1:
+	default:
+		return nil, fmt.Errorf("unsupported PROXY protocol command %d", command)
+	}
+	switch family {
+	case 0x11: // TCP over IPv4
+		if len(payload) < 12 {
+			return nil, fmt.Errorf("PROXY protocol IPv4 addresses are truncated")
+		}
+		return &net.TCPAddr{IP: net.IP(payload[0:4]), Port: int(binary.BigEndian.Uint16(payload[8:10]))}, nil
+	case 0x21: // TCP over IPv6
+		if len(payload) < 36 {
+			return nil, fmt.Errorf("PROXY protocol IPv6 addresses are truncated")
+		}
+		return &net.TCPAddr{IP: net.IP(payload[0:16]), Port: int(binary.BigEndian.Uint16(payload[32:34]))}, nil
+	default:
+		// UNSPEC, UDP and Unix sockets carry no usable client address
+		return nil, nil
+	}
+}
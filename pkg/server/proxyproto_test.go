@@ -0,0 +1,103 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func proxyV2Header(command byte, family byte, addresses []byte) []byte {
+	header := append([]byte{}, proxyV2Signature...)
+	header = append(header, 0x20|command, family, 0, 0)
+	binary.BigEndian.PutUint16(header[14:16], uint16(len(addresses)))
+	return append(header, addresses...)
+}
+
+func TestReadProxyHeader(t *testing.T) {
+	ipv4 := []byte{203, 0, 113, 7, 10, 0, 0, 1, 0xc8, 0x22, 0x01, 0xbb}
+	ipv6 := make([]byte, 36)
+	copy(ipv6, net.ParseIP("2001:db8::7"))
+	binary.BigEndian.PutUint16(ipv6[32:34], 51234)
+
+	for name, tc := range map[string]struct {
+		header string
+		want   string
+	}{
+		"v1 IPv4":    {"PROXY TCP4 203.0.113.7 10.0.0.1 51234 443\r\n", "203.0.113.7:51234"},
+		"v1 IPv6":    {"PROXY TCP6 2001:db8::7 2001:db8::1 51234 443\r\n", "[2001:db8::7]:51234"},
+		"v1 unknown": {"PROXY UNKNOWN\r\n", ""},
+		"v2 IPv4":    {string(proxyV2Header(0x1, 0x11, ipv4)), "203.0.113.7:51234"},
+		"v2 IPv6":    {string(proxyV2Header(0x1, 0x21, ipv6)), "[2001:db8::7]:51234"},
+		"v2 local":   {string(proxyV2Header(0x0, 0x00, nil)), ""},
+	} {
+		r := bufio.NewReader(strings.NewReader(tc.header + "GET / HTTP/1.1\r\n"))
+		addr, err := readProxyHeader(r)
+		require.NoError(t, err, name)
+		if tc.want == "" {
+			assert.Nil(t, addr, name)
+		} else {
+			assert.Equal(t, tc.want, addr.String(), name)
+		}
+		// The client's data follows the header untouched
+		rest, _ := io.ReadAll(r)
+		assert.Equal(t, "GET / HTTP/1.1\r\n", string(rest), name)
+	}
+
+	for name, header := range map[string]string{
+		"no header":       "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n",
+		"v1 without CRLF": "PROXY TCP4 203.0.113.7 10.0.0.1 51234 443\n",
+		"v1 bad address":  "PROXY TCP4 2001:db8::7 10.0.0.1 51234 443\r\n",
+		"v1 too long":     "PROXY TCP4 " + strings.Repeat("1", 120) + "\r\n",
+		"v2 truncated":    string(proxyV2Header(0x1, 0x11, ipv4[:8])),
+	} {
+		_, err := readProxyHeader(bufio.NewReader(strings.NewReader(header)))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestServe_ProxyProtocol(t *testing.T) {
+	remote := make(chan string, 1)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remote <- r.RemoteAddr
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	cfg := DefaultConfig()
+	cfg.ProxyProtocol = true
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go func() { _ = Serve(ctx, cfg, listener, handler) }()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("PROXY TCP4 203.0.113.7 10.0.0.1 51234 443\r\nGET / HTTP/1.1\r\nHost: dashboard\r\n\r\n"))
+	require.NoError(t, err)
+	select {
+	case addr := <-remote:
+		assert.Equal(t, "203.0.113.7:51234", addr)
+	case <-time.After(3 * time.Second):
+		t.Fatal("request was not served")
+	}
+
+	// Connections without the header are rejected without reaching the handler
+	conn, err = net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: dashboard\r\n\r\n"))
+	require.NoError(t, err)
+	_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	response, _ := io.ReadAll(conn)
+	assert.NotContains(t, string(response), "200 OK")
+	assert.Empty(t, remote)
+}
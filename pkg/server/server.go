@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+)
+
+type drainingKey struct{}
+
+// Run serves handler on every configured address until ctx is cancelled and then shuts down
+// gracefully: the listeners are closed, streams created with StreamContext (SSE log and event
+// streams) are ended so clients reconnect to another replica, and in-flight requests get up to
+// ShutdownTimeout to complete.
+func Run(ctx context.Context, cfg Config, handler http.Handler) error {
+	var listeners []net.Listener
+	for _, address := range cfg.Addresses() {
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return fmt.Errorf("failed to listen on %s: %w", address, err)
+		}
+		listeners = append(listeners, listener)
+	}
+	return serve(ctx, cfg, listeners, handler)
+}
+
+// Serve is like Run but uses an existing listener
+func Serve(ctx context.Context, cfg Config, listener net.Listener, handler http.Handler) error {
+	return serve(ctx, cfg, []net.Listener{listener}, handler)
+}
+
+func serve(ctx context.Context, cfg Config, listeners []net.Listener, handler http.Handler) error {
+	// Streaming handlers otherwise only return when the client disconnects, which would hold
+	// Shutdown until it times out. Request contexts carry a signal that StreamContext watches.
+	draining, drainStreams := context.WithCancel(context.Background())
+	defer drainStreams()
+	baseCtx := context.WithValue(context.Background(), drainingKey{}, draining)
+
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		BaseContext:       func(net.Listener) context.Context { return baseCtx },
+	}
+
+	// The PROXY protocol header precedes the TLS handshake
+	if cfg.ProxyProtocol {
+		for i := range listeners {
+			listeners[i] = &proxyListener{Listener: listeners[i], headerTimeout: cfg.ReadHeaderTimeout}
+		}
+	}
+
+	if cfg.TLSEnabled() {
+		certs, err := newCertReloader(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			for _, listener := range listeners {
+				listener.Close()
+			}
+			return err
+		}
+		srv.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certs.GetCertificate,
+		}
+		for i := range listeners {
+			listeners[i] = tls.NewListener(listeners[i], srv.TLSConfig)
+		}
+	}
+
+	serveErr := make(chan error, len(listeners))
+	for _, listener := range listeners {
+		go func() {
+			slog.Info("Starting server", "address", listener.Addr().String(), "tls", cfg.TLSEnabled(), "proxyProtocol", cfg.ProxyProtocol)
+			serveErr <- srv.Serve(listener)
+		}()
+	}
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down server", "timeout", cfg.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	shutdownDone := make(chan error, 1)
+	go func() {
+		shutdownDone <- srv.Shutdown(shutdownCtx)
+	}()
+	drainStreams()
+
+	if err := <-shutdownDone; err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			slog.Warn("Shutdown timed out, closing remaining connections")
+			return srv.Close()
+		}
+		return fmt.Errorf("failed to shut down server: %w", err)
+	}
+	for range listeners {
+		if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("server failed: %w", err)
+		}
+	}
+	slog.Info("Server stopped")
+	return nil
+}
+
+// StreamContext returns a context for a long-lived response that is cancelled when ctx is done or
+// when the server starts shutting down
+func StreamContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	streamCtx, cancel := context.WithCancel(ctx)
+	draining, ok := ctx.Value(drainingKey{}).(context.Context)
+	if !ok {
+		return streamCtx, cancel
+	}
+	stop := context.AfterFunc(draining, cancel)
+	return streamCtx, func() {
+		stop()
+		cancel()
+	}
+}
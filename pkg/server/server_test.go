@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFlags_Defaults(t *testing.T) {
+	cfg, err := ParseFlags(nil)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultConfig(), cfg)
+	assert.False(t, cfg.TLSEnabled())
+}
+
+func TestParseFlags_EnvAndFlags(t *testing.T) {
+	t.Setenv("PORT", "9090")
+	t.Setenv("WRITE_TIMEOUT", "1m")
+
+	cfg, err := ParseFlags(nil)
+	require.NoError(t, err)
+	assert.Equal(t, ":9090", cfg.Address)
+	assert.Equal(t, time.Minute, cfg.WriteTimeout)
+
+	// Flags take precedence over the environment
+	cfg, err = ParseFlags([]string{"-listen-address", "127.0.0.1:7000", "-write-timeout", "5s"})
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:7000", cfg.Address)
+	assert.Equal(t, 5*time.Second, cfg.WriteTimeout)
+}
+
+func TestParseFlags_Addresses(t *testing.T) {
+	t.Setenv("PROXY_PROTOCOL", "true")
+	cfg, err := ParseFlags([]string{"-listen-address", "0.0.0.0:8080, [::]:8080"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0.0.0.0:8080", "[::]:8080"}, cfg.Addresses())
+	assert.True(t, cfg.ProxyProtocol)
+
+	for _, address := range []string{"::1", "8080", ",", "[::1]"} {
+		_, err := ParseFlags([]string{"-listen-address", address})
+		assert.Error(t, err, address)
+	}
+}
+
+func TestRun_ListensOnEveryAddress(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Address = "127.0.0.1:0,[::1]:0"
+	if l, err := net.Listen("tcp", "[::1]:0"); err != nil {
+		t.Skip("IPv6 loopback not available")
+	} else {
+		l.Close()
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Run(ctx, cfg, http.NotFoundHandler()) }()
+	stop()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(3 * time.Second):
+		t.Fatal("server did not stop")
+	}
+}
+
+func TestParseFlags_Invalid(t *testing.T) {
+	_, err := ParseFlags([]string{"-tls-cert-file", "cert.pem"})
+	assert.Error(t, err)
+
+	t.Setenv("SHUTDOWN_TIMEOUT", "soon")
+	_, err = ParseFlags(nil)
+	assert.ErrorContains(t, err, "SHUTDOWN_TIMEOUT")
+}
+
+func TestServe_ShutdownDrainsStreams(t *testing.T) {
+	streaming := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := StreamContext(r.Context())
+		defer cancel()
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		close(streaming)
+		<-ctx.Done()
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	cfg := DefaultConfig()
+	cfg.ShutdownTimeout = 5 * time.Second
+
+	ctx, stop := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Serve(ctx, cfg, listener, handler) }()
+
+	resp, err := http.Get("http://" + listener.Addr().String())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	<-streaming
+
+	stop()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(3 * time.Second):
+		t.Fatal("server did not drain the open stream")
+	}
+}
+
+func TestCertReloader_ReloadsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeTestCert(t, certFile, keyFile, "first")
+
+	reloader, err := newCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+	assert.Equal(t, "first", leafCommonName(t, reloader.cert))
+
+	writeTestCert(t, certFile, keyFile, "second")
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+	reloader.lastStat = time.Time{}
+
+	cert, err := reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "second", leafCommonName(t, cert))
+}
+
+func writeTestCert(t *testing.T, certFile, keyFile, commonName string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+}
+
+func leafCommonName(t *testing.T, cert *tls.Certificate) string {
+	t.Helper()
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf.Subject.CommonName
+}
@@ -0,0 +1,186 @@
+// Package share signs and verifies links that grant read-only access to a single rollout, for
+// stakeholders without cluster RBAC
+package share
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// QueryParameter carries the share token on requests
+	QueryParameter = "share"
+	// ContextKey holds the *Claims of a verified share token
+	ContextKey = "share_claims"
+
+	// DefaultTTL is how long a link is valid when no TTL is requested
+	DefaultTTL = 24 * time.Hour
+	// defaultMaxTTL bounds the TTL of a link unless SHARE_LINK_MAX_TTL says otherwise
+	defaultMaxTTL = 7 * 24 * time.Hour
+)
+
+var (
+	// ErrInvalidToken is returned for tokens that are malformed, expired or not signed with the
+	// configured secret
+	ErrInvalidToken = errors.New("invalid share token")
+	// ErrWrongRollout is returned when a valid token is used for another rollout or route
+	ErrWrongRollout = errors.New("share token does not grant access to this resource")
+)
+
+// Config holds the signing secret and the longest TTL links may be created with
+type Config struct {
+	Secret []byte
+	MaxTTL time.Duration
+}
+
+// ConfigFromEnv reads SHARE_LINK_SECRET and SHARE_LINK_MAX_TTL. Sharing is disabled, and nil is
+// returned, when no secret is set.
+func ConfigFromEnv() (*Config, error) {
+	secret := os.Getenv("SHARE_LINK_SECRET")
+	if secret == "" {
+		return nil, nil
+	}
+	if len(secret) < 32 {
+		return nil, fmt.Errorf("SHARE_LINK_SECRET must be at least 32 characters")
+	}
+	cfg := &Config{Secret: []byte(secret), MaxTTL: defaultMaxTTL}
+	if value := os.Getenv("SHARE_LINK_MAX_TTL"); value != "" {
+		maxTTL, err := time.ParseDuration(value)
+		if err != nil || maxTTL <= 0 {
+			return nil, fmt.Errorf("SHARE_LINK_MAX_TTL must be a positive duration")
+		}
+		cfg.MaxTTL = maxTTL
+	}
+	return cfg, nil
+}
+
+// Claims is what a share token grants: read access to one rollout until it expires
+type Claims struct {
+	Namespace string `json:"ns"`
+	Name      string `json:"name"`
+	// CreatedBy is the user who created the link, for the audit log
+	CreatedBy string    `json:"by,omitempty"`
+	ExpiresAt time.Time `json:"exp"`
+}
+
+// Signer creates and verifies share tokens
+type Signer struct {
+	cfg Config
+	now func() time.Time
+}
+
+// NewSigner returns a signer for cfg
+func NewSigner(cfg Config) *Signer {
+	return &Signer{cfg: cfg, now: time.Now}
+}
+
+// MaxTTL is the longest TTL a link may be created with
+func (s *Signer) MaxTTL() time.Duration {
+	return s.cfg.MaxTTL
+}
+
+// Sign returns a token granting read access to namespace/name for ttl
+func (s *Signer) Sign(namespace, name, createdBy string, ttl time.Duration) (string, Claims, error) {
+	if ttl <= 0 || ttl > s.cfg.MaxTTL {
+		return "", Claims{}, fmt.Errorf("ttl must be between 0 and %s", s.cfg.MaxTTL)
+	}
+	claims := Claims{
+		Namespace: namespace,
+		Name:      name,
+		CreatedBy: createdBy,
+		ExpiresAt: s.now().Add(ttl).UTC().Truncate(time.Second),
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", Claims{}, err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.mac(encoded)), claims, nil
+}
+
+// Verify checks the token's signature and expiry and returns its claims
+func (s *Signer) Verify(token string) (*Claims, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, s.mac(encoded)) {
+		return nil, ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	claims := &Claims{}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if !s.now().Before(claims.ExpiresAt) {
+		return nil, fmt.Errorf("%w: expired at %s", ErrInvalidToken, claims.ExpiresAt.Format(time.RFC3339))
+	}
+	return claims, nil
+}
+
+func (s *Signer) mac(payload string) []byte {
+	h := hmac.New(sha256.New, s.cfg.Secret)
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
+
+// Middleware accepts share tokens on the read-only routes it is given, as paths relative to the
+// API version, e.g. "/rollouts/:namespace/:name". Requests carrying a valid token for the route's
+// :namespace/:name rollout get the claims stored under ContextKey; invalid tokens, tokens for other
+// rollouts and tokens on other routes are passed to reject, which must write the error response.
+// Requests without a token are left to the handlers. A nil signer rejects every token.
+func (s *Signer) Middleware(routes []string, reject func(c *gin.Context, err error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.Query(QueryParameter)
+		if token == "" {
+			c.Next()
+			return
+		}
+		if s == nil {
+			reject(c, fmt.Errorf("%w: sharing is not enabled", ErrInvalidToken))
+			c.Abort()
+			return
+		}
+		claims, err := s.Verify(token)
+		if err != nil {
+			reject(c, err)
+			c.Abort()
+			return
+		}
+		shareable := c.Request.Method == http.MethodGet && slices.ContainsFunc(routes, func(route string) bool {
+			return strings.HasSuffix(c.FullPath(), route)
+		})
+		if !shareable || claims.Namespace != c.Param("namespace") || claims.Name != c.Param("name") {
+			reject(c, ErrWrongRollout)
+			c.Abort()
+			return
+		}
+		c.Set(ContextKey, claims)
+		c.Next()
+	}
+}
+
+// FromContext returns the claims of the request's verified share token, or nil
+func FromContext(c *gin.Context) *Claims {
+	if claims, exists := c.Get(ContextKey); exists {
+		if cl, ok := claims.(*Claims); ok {
+			return cl
+		}
+	}
+	return nil
+}
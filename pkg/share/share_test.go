@@ -0,0 +1,110 @@
+package share
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSigner(now time.Time) *Signer {
+	s := NewSigner(Config{Secret: []byte("0123456789abcdef0123456789abcdef"), MaxTTL: 7 * 24 * time.Hour})
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestSigner_SignVerify(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	s := newTestSigner(now)
+
+	token, claims, err := s.Sign("shop", "api", "jane", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(time.Hour), claims.ExpiresAt)
+
+	verified, err := s.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, &claims, verified)
+
+	_, _, err = s.Sign("shop", "api", "jane", 8*24*time.Hour)
+	assert.Error(t, err, "ttl above the maximum")
+
+	other := NewSigner(Config{Secret: []byte("another-secret-another-secret-xx"), MaxTTL: time.Hour})
+	for name, token := range map[string]string{
+		"malformed":      "not-a-token",
+		"bad signature":  token[:len(token)-2] + "xx",
+		"other secret":   func() string { t, _, _ := other.Sign("shop", "api", "jane", time.Hour); return t }(),
+		"tampered claim": "eyJucyI6InNob3AiLCJuYW1lIjoid2ViIn0" + token[len(token)-44:],
+	} {
+		_, err := s.Verify(token)
+		assert.ErrorIs(t, err, ErrInvalidToken, name)
+	}
+
+	s.now = func() time.Time { return now.Add(time.Hour) }
+	_, err = s.Verify(token)
+	assert.ErrorIs(t, err, ErrInvalidToken, "expired")
+}
+
+func TestSigner_Middleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := newTestSigner(time.Now())
+	token, _, err := s.Sign("shop", "api", "jane", time.Hour)
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.Use(s.Middleware([]string{"/rollouts/:namespace/:name"}, func(c *gin.Context, err error) {
+		if err == ErrWrongRollout {
+			c.Status(http.StatusForbidden)
+			return
+		}
+		c.Status(http.StatusUnauthorized)
+	}))
+	handler := func(c *gin.Context) {
+		if FromContext(c) != nil {
+			c.String(http.StatusOK, "shared")
+			return
+		}
+		c.String(http.StatusOK, "own credentials")
+	}
+	r.GET("/api/v1/rollouts/:namespace/:name", handler)
+	r.POST("/api/v1/rollouts/:namespace/:name", handler)
+	r.GET("/api/v1/rollouts/:namespace/:name/events", handler)
+
+	for _, tc := range []struct {
+		method, path string
+		status       int
+		body         string
+	}{
+		{http.MethodGet, "/api/v1/rollouts/shop/api?share=" + token, http.StatusOK, "shared"},
+		{http.MethodGet, "/api/v1/rollouts/shop/api", http.StatusOK, "own credentials"},
+		{http.MethodGet, "/api/v1/rollouts/shop/api?share=invalid", http.StatusUnauthorized, ""},
+		{http.MethodGet, "/api/v1/rollouts/shop/web?share=" + token, http.StatusForbidden, ""},
+		{http.MethodGet, "/api/v1/rollouts/shop/api/events?share=" + token, http.StatusForbidden, ""},
+		{http.MethodPost, "/api/v1/rollouts/shop/api?share=" + token, http.StatusForbidden, ""},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		assert.Equal(t, tc.status, w.Code, tc.method+" "+tc.path)
+		assert.Equal(t, tc.body, w.Body.String(), tc.method+" "+tc.path)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("SHARE_LINK_SECRET", "")
+	cfg, err := ConfigFromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, cfg, "sharing is disabled without a secret")
+
+	t.Setenv("SHARE_LINK_SECRET", "short")
+	_, err = ConfigFromEnv()
+	assert.Error(t, err)
+
+	t.Setenv("SHARE_LINK_SECRET", "0123456789abcdef0123456789abcdef")
+	t.Setenv("SHARE_LINK_MAX_TTL", "72h")
+	cfg, err = ConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, 72*time.Hour, cfg.MaxTTL)
+}
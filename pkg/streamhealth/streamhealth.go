@@ -0,0 +1,255 @@
+package streamhealth
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// ReconnectEvent is sent to clients whose stream is ended for being degraded, before the
+// connection is closed. Its data is a Reconnect.
+const ReconnectEvent = "reconnect"
+
+const (
+	// checkInterval is how often every stream is checked
+	checkInterval = 5 * time.Second
+	// dropWindow is the period within which a stream may drop Config.MaxDropped messages
+	dropWindow = time.Minute
+	// reconnectWriteTimeout bounds sending the reconnect event to a degraded stream
+	reconnectWriteTimeout = time.Second
+	// reconnectRetry is the reconnection delay, in milliseconds, suggested with the reconnect event
+	reconnectRetry = 1000
+)
+
+// Reasons a stream is ended
+const (
+	ReasonStalled = "stalled"
+	ReasonDropped = "dropping messages"
+)
+
+// Config holds the limits past which a stream counts as degraded. A zero value disables the
+// corresponding check.
+type Config struct {
+	// StallTimeout ends streams without a successful write for this long. Streams send keepalives
+	// every 10 seconds, so a longer stall means the client stopped reading.
+	StallTimeout time.Duration
+	// MaxDropped ends streams that drop more messages than this within a minute because the
+	// client cannot keep up
+	MaxDropped int
+}
+
+// ConfigFromEnv reads the limits from the environment:
+//   - SSE_STALL_TIMEOUT: time without a successful write before a stream is ended (default 45s, 0 disables)
+//   - SSE_MAX_DROPPED: messages a stream may drop per minute before it is ended (default 500, 0 disables)
+func ConfigFromEnv() Config {
+	cfg := Config{StallTimeout: 45 * time.Second, MaxDropped: 500}
+	if value, err := time.ParseDuration(os.Getenv("SSE_STALL_TIMEOUT")); err == nil && value >= 0 {
+		cfg.StallTimeout = value
+	}
+	if value, err := strconv.Atoi(os.Getenv("SSE_MAX_DROPPED")); err == nil && value >= 0 {
+		cfg.MaxDropped = value
+	}
+	return cfg
+}
+
+// Reconnect tells a client why its stream was ended
+type Reconnect struct {
+	Reason string `json:"reason"`
+}
+
+// Status is the health of an open stream
+type Status struct {
+	ID        uint64    `json:"id"`
+	Route     string    `json:"route"`
+	User      string    `json:"user,omitempty"`
+	Started   time.Time `json:"started"`
+	LastWrite time.Time `json:"lastWrite"`
+	// Sent counts the events written, Dropped the messages dropped before reaching the stream
+	Sent    int64 `json:"sent"`
+	Dropped int64 `json:"dropped"`
+}
+
+// Monitor is a dead-man's switch for Server-Sent Events streams. It tracks when each stream last
+// wrote successfully and how many messages it dropped, and ends degraded streams with a reconnect
+// event instead of leaving them to hold their goroutines until the TCP connection times out.
+type Monitor struct {
+	cfg Config
+	now func() time.Time
+
+	mu      sync.Mutex
+	streams map[uint64]*Stream
+	nextID  uint64
+}
+
+// New creates a monitor
+func New(cfg Config) *Monitor {
+	return &Monitor{cfg: cfg, now: time.Now, streams: map[uint64]*Stream{}}
+}
+
+// Stream is an SSE response watched by a Monitor. All events must be written with Send, which
+// serializes writes so the monitor can interleave the reconnect event.
+type Stream struct {
+	monitor *Monitor
+	status  Status
+	writer  http.ResponseWriter
+	cancel  context.CancelFunc
+
+	// mu is held while writing
+	mu        sync.Mutex
+	lastWrite atomic.Int64
+	sent      atomic.Int64
+	dropped   atomic.Pointer[func() int64]
+
+	// Dropped messages at the start of the current drop window
+	windowStart   time.Time
+	windowDropped int64
+}
+
+// Start watches the SSE response of c until Close. The returned context, derived from ctx, is
+// cancelled when the stream is ended for being degraded or a write fails.
+func (m *Monitor) Start(ctx context.Context, c *gin.Context, user string) (context.Context, *Stream) {
+	ctx, cancel := context.WithCancel(ctx)
+	now := m.now()
+	s := &Stream{
+		monitor:     m,
+		status:      Status{Route: c.FullPath(), User: user, Started: now},
+		writer:      c.Writer,
+		cancel:      cancel,
+		windowStart: now,
+	}
+	s.lastWrite.Store(now.UnixNano())
+
+	m.mu.Lock()
+	m.nextID++
+	s.status.ID = m.nextID
+	m.streams[s.status.ID] = s
+	m.mu.Unlock()
+
+	if m.cfg.StallTimeout > 0 || m.cfg.MaxDropped > 0 {
+		go s.watch(ctx)
+	}
+	return ctx, s
+}
+
+// Streams returns the health of the open streams, oldest first
+func (m *Monitor) Streams() []Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	statuses := make([]Status, 0, len(m.streams))
+	for _, s := range m.streams {
+		statuses = append(statuses, s.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].ID < statuses[j].ID })
+	return statuses
+}
+
+// Send writes and flushes an event. A failed write ends the stream.
+func (s *Stream) Send(event sse.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := sse.Encode(s.writer, event); err != nil {
+		s.cancel()
+		return err
+	}
+	if flusher, ok := s.writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	s.lastWrite.Store(s.monitor.now().UnixNano())
+	s.sent.Add(1)
+	return nil
+}
+
+// TrackDropped makes the monitor read the number of messages dropped before reaching the stream,
+// e.g. by a full buffer, from dropped
+func (s *Stream) TrackDropped(dropped func() int64) {
+	s.dropped.Store(&dropped)
+}
+
+// Status returns the stream's health
+func (s *Stream) Status() Status {
+	status := s.status
+	status.LastWrite = time.Unix(0, s.lastWrite.Load()).UTC()
+	status.Sent = s.sent.Load()
+	status.Dropped = s.droppedCount()
+	return status
+}
+
+// Close stops watching the stream
+func (s *Stream) Close() {
+	s.cancel()
+	s.monitor.mu.Lock()
+	delete(s.monitor.streams, s.status.ID)
+	s.monitor.mu.Unlock()
+}
+
+func (s *Stream) droppedCount() int64 {
+	if dropped := s.dropped.Load(); dropped != nil {
+		return (*dropped)()
+	}
+	return 0
+}
+
+func (s *Stream) watch(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if reason := s.check(s.monitor.now()); reason != "" {
+				s.terminate(reason)
+				return
+			}
+		}
+	}
+}
+
+// check returns why the stream is degraded at now, or an empty string while it is healthy
+func (s *Stream) check(now time.Time) string {
+	cfg := s.monitor.cfg
+	if cfg.StallTimeout > 0 && now.Sub(time.Unix(0, s.lastWrite.Load())) > cfg.StallTimeout {
+		return ReasonStalled
+	}
+	if cfg.MaxDropped > 0 {
+		dropped := s.droppedCount()
+		if dropped-s.windowDropped > int64(cfg.MaxDropped) {
+			return ReasonDropped
+		}
+		if now.Sub(s.windowStart) >= dropWindow {
+			s.windowStart, s.windowDropped = now, dropped
+		}
+	}
+	return ""
+}
+
+// terminate tells the client to reconnect, unless a write is stuck, and ends the stream. The
+// write deadline makes a write blocked on a client that stopped reading fail, so the handler
+// returns.
+func (s *Stream) terminate(reason string) {
+	controller := http.NewResponseController(s.writer)
+	if s.mu.TryLock() {
+		_ = controller.SetWriteDeadline(time.Now().Add(reconnectWriteTimeout))
+		data, _ := json.Marshal(Reconnect{Reason: reason})
+		if sse.Encode(s.writer, sse.Event{Event: ReconnectEvent, Retry: reconnectRetry, Data: string(data)}) == nil {
+			_ = controller.Flush()
+		}
+		s.mu.Unlock()
+	}
+	_ = controller.SetWriteDeadline(time.Now())
+	s.cancel()
+
+	status := s.Status()
+	slog.Warn("Ended degraded stream", "reason", reason, "route", status.Route, "user", status.User,
+		"lastWrite", status.LastWrite, "sent", status.Sent, "dropped", status.Dropped)
+}
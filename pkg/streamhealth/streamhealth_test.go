@@ -0,0 +1,76 @@
+package streamhealth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMonitor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	m := New(Config{StallTimeout: 45 * time.Second, MaxDropped: 100})
+	m.now = func() time.Time { return now }
+
+	start := func() (*httptest.ResponseRecorder, context.Context, *Stream) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/rollouts/shop/api/pods/logs", nil)
+		ctx, stream := m.Start(context.Background(), c, "alice@example.com")
+		return w, ctx, stream
+	}
+
+	w, ctx, stream := start()
+	defer stream.Close()
+	require.NoError(t, stream.Send(sse.Event{Event: "ping", Data: "{}"}))
+	assert.Contains(t, w.Body.String(), "event:ping")
+
+	// Keepalives keep the stream healthy
+	now = now.Add(30 * time.Second)
+	require.NoError(t, stream.Send(sse.Event{Event: "ping", Data: "{}"}))
+	now = now.Add(30 * time.Second)
+	assert.Empty(t, stream.check(now))
+	now = now.Add(20 * time.Second)
+	assert.Equal(t, ReasonStalled, stream.check(now))
+
+	statuses := m.Streams()
+	require.Len(t, statuses, 1)
+	assert.Equal(t, Status{ID: 1, Route: "", User: "alice@example.com", Started: now.Add(-80 * time.Second), LastWrite: now.Add(-50 * time.Second), Sent: 2}, statuses[0])
+
+	stream.terminate(ReasonStalled)
+	assert.Error(t, ctx.Err())
+	assert.Contains(t, w.Body.String(), "event:reconnect\nretry:1000\ndata:{\"reason\":\"stalled\"}")
+
+	// Drops are counted per minute
+	_, _, dropping := start()
+	defer dropping.Close()
+	var dropped int64
+	dropping.TrackDropped(func() int64 { return dropped })
+	dropped = 90
+	assert.Empty(t, dropping.check(now))
+	now = now.Add(time.Minute)
+	require.NoError(t, dropping.Send(sse.Event{Event: "ping", Data: "{}"}))
+	assert.Empty(t, dropping.check(now))
+	dropped = 150
+	assert.Empty(t, dropping.check(now), "150 dropped, but only 60 in the current minute")
+	dropped = 300
+	assert.Equal(t, ReasonDropped, dropping.check(now))
+	assert.Equal(t, int64(300), dropping.Status().Dropped)
+
+	stream.Close()
+	assert.Len(t, m.Streams(), 1)
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	assert.Equal(t, Config{StallTimeout: 45 * time.Second, MaxDropped: 500}, ConfigFromEnv())
+	t.Setenv("SSE_STALL_TIMEOUT", "0")
+	t.Setenv("SSE_MAX_DROPPED", "50")
+	assert.Equal(t, Config{MaxDropped: 50}, ConfigFromEnv())
+}
@@ -0,0 +1,181 @@
+package terminal
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// Message types exchanged over the terminal WebSocket
+const (
+	// TypeStdin carries keyboard input from the browser
+	TypeStdin = "stdin"
+	// TypeResize reports the browser terminal size in Cols and Rows
+	TypeResize = "resize"
+	// TypeStdout and TypeStderr carry container output
+	TypeStdout = "stdout"
+	TypeStderr = "stderr"
+	// TypeExit is sent once when the command ended; Code is its exit code
+	TypeExit = "exit"
+	// TypeError is sent once when the session could not be started or broke down
+	TypeError = "error"
+)
+
+// Message is a single JSON frame of the terminal protocol
+type Message struct {
+	Type    string `json:"type"`
+	Data    string `json:"data,omitempty"`
+	Cols    uint16 `json:"cols,omitempty"`
+	Rows    uint16 `json:"rows,omitempty"`
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Conn is the part of a WebSocket connection used by a Session
+type Conn interface {
+	ReadJSON(v any) error
+	WriteJSON(v any) error
+}
+
+// Session bridges a WebSocket connection to the streams of a pods/exec request: stdin and resize
+// frames are fed to the exec stream, output is written back as stdout/stderr frames.
+type Session struct {
+	conn Conn
+
+	writeMu sync.Mutex
+
+	stdin      *io.PipeReader
+	stdinInput *io.PipeWriter
+
+	sizes chan remotecommand.TerminalSize
+	done  chan struct{}
+	once  sync.Once
+}
+
+// NewSession creates a session on conn. ReadLoop must be running for input to be delivered.
+func NewSession(conn Conn) *Session {
+	stdin, stdinInput := io.Pipe()
+	return &Session{
+		conn:       conn,
+		stdin:      stdin,
+		stdinInput: stdinInput,
+		sizes:      make(chan remotecommand.TerminalSize, 1),
+		done:       make(chan struct{}),
+	}
+}
+
+// StreamOptions returns the streams to pass to the exec executor
+func (s *Session) StreamOptions(tty bool) remotecommand.StreamOptions {
+	opts := remotecommand.StreamOptions{
+		Stdin:  s.stdin,
+		Stdout: &writer{session: s, messageType: TypeStdout},
+		Tty:    tty,
+	}
+	if tty {
+		// Output is merged into stdout by the TTY, and only a TTY can be resized
+		opts.TerminalSizeQueue = s
+	} else {
+		opts.Stderr = &writer{session: s, messageType: TypeStderr}
+	}
+	return opts
+}
+
+// ReadLoop reads frames from the connection until it fails or is closed, then closes stdin so
+// the command sees EOF. Unknown frame types are ignored.
+func (s *Session) ReadLoop() {
+	defer s.Close()
+	for {
+		var msg Message
+		if err := s.conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		switch msg.Type {
+		case TypeStdin:
+			if _, err := s.stdinInput.Write([]byte(msg.Data)); err != nil {
+				return
+			}
+		case TypeResize:
+			if msg.Cols == 0 || msg.Rows == 0 {
+				continue
+			}
+			s.resize(remotecommand.TerminalSize{Width: msg.Cols, Height: msg.Rows})
+		}
+	}
+}
+
+// resize queues the latest size, replacing one that has not been picked up yet
+func (s *Session) resize(size remotecommand.TerminalSize) {
+	for {
+		select {
+		case s.sizes <- size:
+			return
+		default:
+		}
+		select {
+		case <-s.sizes:
+		default:
+		}
+	}
+}
+
+// Next implements remotecommand.TerminalSizeQueue. It returns nil once the session is closed.
+func (s *Session) Next() *remotecommand.TerminalSize {
+	select {
+	case size := <-s.sizes:
+		return &size
+	default:
+	}
+	select {
+	case size := <-s.sizes:
+		return &size
+	case <-s.done:
+		return nil
+	}
+}
+
+// Done is closed when the browser side of the session has gone away
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Close ends the input side of the session
+func (s *Session) Close() {
+	s.once.Do(func() {
+		s.stdinInput.Close()
+		close(s.done)
+	})
+}
+
+// Exit reports the outcome of the command to the browser. A nil error is exit code 0; errors
+// carrying an exit status report that code, other errors are sent as TypeError.
+func (s *Session) Exit(err error) error {
+	if err == nil {
+		return s.write(Message{Type: TypeExit})
+	}
+	var exitErr interface{ ExitStatus() int }
+	if errors.As(err, &exitErr) {
+		return s.write(Message{Type: TypeExit, Code: exitErr.ExitStatus(), Message: err.Error()})
+	}
+	return s.write(Message{Type: TypeError, Message: err.Error()})
+}
+
+func (s *Session) write(msg Message) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.conn.WriteJSON(msg)
+}
+
+// writer sends everything written to it as frames of one type
+type writer struct {
+	session     *Session
+	messageType string
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if err := w.session.write(Message{Type: w.messageType, Data: string(p)}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
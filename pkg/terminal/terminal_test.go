@@ -0,0 +1,77 @@
+package terminal
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// fakeConn replays incoming frames and records written ones
+type fakeConn struct {
+	mu      sync.Mutex
+	in      chan Message
+	written []Message
+}
+
+func (c *fakeConn) ReadJSON(v any) error {
+	msg, ok := <-c.in
+	if !ok {
+		return io.EOF
+	}
+	*v.(*Message) = msg
+	return nil
+}
+
+func (c *fakeConn) WriteJSON(v any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.written = append(c.written, v.(Message))
+	return nil
+}
+
+func TestSessionInput(t *testing.T) {
+	conn := &fakeConn{in: make(chan Message, 4)}
+	session := NewSession(conn)
+	go session.ReadLoop()
+
+	conn.in <- Message{Type: TypeResize, Cols: 80, Rows: 24}
+	conn.in <- Message{Type: TypeStdin, Data: "ls\n"}
+	close(conn.in)
+
+	opts := session.StreamOptions(true)
+	input, err := io.ReadAll(opts.Stdin)
+	require.NoError(t, err)
+	assert.Equal(t, "ls\n", string(input))
+
+	assert.Equal(t, &remotecommand.TerminalSize{Width: 80, Height: 24}, opts.TerminalSizeQueue.Next())
+	// After the connection closed the size queue ends
+	assert.Nil(t, opts.TerminalSizeQueue.Next())
+}
+
+func TestSessionOutput(t *testing.T) {
+	conn := &fakeConn{in: make(chan Message)}
+	session := NewSession(conn)
+
+	opts := session.StreamOptions(false)
+	assert.Nil(t, opts.TerminalSizeQueue)
+	_, err := opts.Stdout.Write([]byte("out"))
+	require.NoError(t, err)
+	_, err = opts.Stderr.Write([]byte("err"))
+	require.NoError(t, err)
+
+	require.NoError(t, session.Exit(utilexec.CodeExitError{Err: errors.New("command terminated with exit code 2"), Code: 2}))
+	require.NoError(t, session.Exit(errors.New("connection refused")))
+
+	assert.Equal(t, []Message{
+		{Type: TypeStdout, Data: "out"},
+		{Type: TypeStderr, Data: "err"},
+		{Type: TypeExit, Code: 2, Message: "command terminated with exit code 2"},
+		{Type: TypeError, Message: "connection refused"},
+	}, conn.written)
+}